@@ -0,0 +1,277 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"berth/internal/domain/accessrequests"
+	"berth/internal/domain/rbac"
+	"berth/internal/domain/rbac/permnames"
+	"berth/internal/domain/security"
+	"berth/internal/domain/stack"
+	"berth/internal/domain/user"
+	"berth/internal/pkg/config"
+	"berth/internal/pkg/response"
+
+	e2etesting "berth/e2e/internal/harness"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessRequests(t *testing.T) {
+	t.Parallel()
+	app := SetupTestAppWithConfig(t, func(c *config.Config) {
+		c.Auth.AccessRequestExpiryInterval = 100 * time.Millisecond
+	})
+
+	admin := &e2etesting.TestUser{
+		Username: "access_req_admin",
+		Email:    "access_req_admin@example.com",
+		Password: "password123",
+	}
+	app.CreateAdminTestUser(t, admin)
+	adminClient := app.HTTPClient.WithBearerToken(app.AuthHelper.JWTLogin(t, admin.Username, admin.Password))
+
+	_, srv := app.CreateTestServerWithAgent(t, "access-req-server")
+
+	permsResp, err := adminClient.Get("/api/v1/admin/permissions")
+	require.NoError(t, err)
+	require.Equal(t, 200, permsResp.StatusCode)
+	var permList response.Response[rbac.ListPermissionsData]
+	require.NoError(t, permsResp.GetJSON(&permList))
+
+	createRole := func(name string, permNames ...string) uint {
+		resp, err := adminClient.Post("/api/v1/admin/roles", map[string]any{
+			"name":        name,
+			"description": "access request test role",
+		})
+		require.NoError(t, err)
+		require.Equal(t, 201, resp.StatusCode, "create role: %s", resp.GetString())
+		var role response.Response[user.RoleWithPermissions]
+		require.NoError(t, resp.GetJSON(&role))
+
+		for _, name := range permNames {
+			var permID uint
+			for _, p := range permList.Data.Permissions {
+				if p.Name == name {
+					permID = p.ID
+					break
+				}
+			}
+			require.NotZero(t, permID, "permission %q not found", name)
+			resp, err := adminClient.Post("/api/v1/admin/roles/"+Itoa(role.Data.ID)+"/stack-permissions", map[string]any{
+				"server_id":     srv.ID,
+				"permission_id": permID,
+				"stack_pattern": "*",
+			})
+			require.NoError(t, err)
+			require.Equal(t, 201, resp.StatusCode, "add stack-permission: %s", resp.GetString())
+		}
+		return role.Data.ID
+	}
+	readerRoleID := createRole("access-req-reader", permnames.StacksRead)
+	approverRoleID := createRole("access-req-approver", permnames.StacksRead, permnames.StacksAccessApprove)
+
+	newUser := func(username string, roleID uint) *e2etesting.HTTPClient {
+		u := &e2etesting.TestUser{
+			Username: username,
+			Email:    username + "@example.com",
+			Password: "password123",
+		}
+		app.AuthHelper.CreateTestUser(t, u)
+		resp, err := adminClient.Post("/api/v1/admin/users/assign-role", map[string]any{
+			"user_id": u.ID,
+			"role_id": roleID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode, "assign role: %s", resp.GetString())
+		return app.HTTPClient.WithBearerToken(app.AuthHelper.JWTLogin(t, u.Username, u.Password))
+	}
+	member := newUser("access_req_member", readerRoleID)
+	bystander := newUser("access_req_bystander", readerRoleID)
+	lead := newUser("access_req_lead", approverRoleID)
+
+	requestsPath := "/api/v1/servers/" + Itoa(srv.ID) + "/access-requests"
+
+	create := func(client *e2etesting.HTTPClient, body map[string]any) (*e2etesting.Response, accessrequests.AccessRequest) {
+		t.Helper()
+		resp, err := client.Post(requestsPath, body)
+		require.NoError(t, err)
+		var data response.Response[accessrequests.GetRequestData]
+		_ = resp.GetJSON(&data)
+		return resp, data.Data.Request
+	}
+
+	listPending := func(client *e2etesting.HTTPClient) []accessrequests.AccessRequest {
+		t.Helper()
+		resp, err := client.Get("/api/v1/access-requests/pending")
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		var data response.Response[accessrequests.ListRequestsData]
+		require.NoError(t, resp.GetJSON(&data))
+		return data.Data.Requests
+	}
+
+	stackPermissions := func(client *e2etesting.HTTPClient, stackname string) []string {
+		t.Helper()
+		resp, err := client.Get("/api/v1/servers/" + Itoa(srv.ID) + "/stacks/" + stackname + "/permissions")
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		var data response.Response[stack.StackPermissionsData]
+		require.NoError(t, resp.GetJSON(&data))
+		return data.Data.Permissions
+	}
+
+	resp, request := create(member, map[string]any{
+		"stack_pattern":    "web-*",
+		"permission":       permnames.StacksManage,
+		"duration_minutes": 60,
+		"reason":           "deploy hotfix",
+	})
+	require.Equal(t, 201, resp.StatusCode, "body=%s", resp.GetString())
+	assert.Equal(t, accessrequests.StatusPending, request.Status)
+
+	t.Run("POST /api/v1/servers/:serverid/access-requests rejects invalid requests", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/servers/:serverid/access-requests", e2etesting.CategoryValidation, e2etesting.ValueMedium)
+
+		resp, _ := create(member, map[string]any{
+			"stack_pattern": "web-*", "permission": permnames.StacksManage, "duration_minutes": 30, "reason": "again",
+		})
+		assert.Equal(t, 409, resp.StatusCode, "duplicate pending request: %s", resp.GetString())
+
+		resp, _ = create(member, map[string]any{
+			"stack_pattern": "web-*", "permission": permnames.StacksRead, "duration_minutes": 30, "reason": "read",
+		})
+		assert.Equal(t, 400, resp.StatusCode, "stacks.read is not requestable: %s", resp.GetString())
+
+		resp, _ = create(member, map[string]any{
+			"stack_pattern": "api", "permission": permnames.StacksManage, "duration_minutes": 25 * 60, "reason": "long",
+		})
+		assert.Equal(t, 400, resp.StatusCode, "duration above the maximum: %s", resp.GetString())
+		assert.Contains(t, resp.GetString(), "must not exceed 1440")
+
+		resp, _ = create(member, map[string]any{
+			"stack_pattern": "api", "permission": permnames.StacksManage, "duration_minutes": 30,
+		})
+		assert.Equal(t, 400, resp.StatusCode, "missing reason: %s", resp.GetString())
+	})
+
+	t.Run("GET /api/v1/access-requests lists own requests", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/access-requests", e2etesting.CategoryHappyPath, e2etesting.ValueMedium)
+		resp, err := member.Get("/api/v1/access-requests")
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		var data response.Response[accessrequests.ListRequestsData]
+		require.NoError(t, resp.GetJSON(&data))
+		require.Len(t, data.Data.Requests, 1)
+		assert.Equal(t, request.ID, data.Data.Requests[0].ID)
+
+		resp, err = bystander.Get("/api/v1/access-requests")
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		require.NoError(t, resp.GetJSON(&data))
+		assert.Empty(t, data.Data.Requests)
+	})
+
+	t.Run("GET /api/v1/access-requests/pending only shows decidable requests", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/access-requests/pending", e2etesting.CategoryAuthorization, e2etesting.ValueHigh)
+		pending := listPending(lead)
+		require.Len(t, pending, 1)
+		assert.Equal(t, request.ID, pending[0].ID)
+
+		assert.Empty(t, listPending(bystander))
+		assert.Empty(t, listPending(member), "own requests are never listed")
+	})
+
+	approvePath := "/api/v1/access-requests/" + Itoa(request.ID) + "/approve"
+
+	t.Run("POST /api/v1/access-requests/:id/approve requires an approver", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/access-requests/:id/approve", e2etesting.CategoryAuthorization, e2etesting.ValueHigh)
+		resp, err := member.Post(approvePath, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, 403, resp.StatusCode, "self approval: %s", resp.GetString())
+
+		resp, err = bystander.Post(approvePath, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode, "non-approver: %s", resp.GetString())
+
+		assert.NotContains(t, stackPermissions(member, "web-app"), permnames.StacksManage)
+	})
+
+	t.Run("approved request grants the permission until it expires", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/access-requests/:id/approve", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		resp, err := lead.Post(approvePath, map[string]any{"note": "ok for today"})
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		var data response.Response[accessrequests.GetRequestData]
+		require.NoError(t, resp.GetJSON(&data))
+		assert.Equal(t, accessrequests.StatusApproved, data.Data.Request.Status)
+		require.NotNil(t, data.Data.Request.ExpiresAt)
+		assert.Equal(t, "access_req_lead", data.Data.Request.DecidedByUsername)
+
+		assert.Contains(t, stackPermissions(member, "web-app"), permnames.StacksManage)
+		assert.NotContains(t, stackPermissions(member, "api"), permnames.StacksManage)
+		assert.NotContains(t, stackPermissions(bystander, "web-app"), permnames.StacksManage)
+
+		resp, err = lead.Post(approvePath, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, 409, resp.StatusCode, "already decided: %s", resp.GetString())
+
+		assert.Equal(t, int64(1), countAuditEvents(t, app.DB, security.EventAccessRequestCreated))
+		assert.Equal(t, int64(1), countAuditEvents(t, app.DB, security.EventAccessRequestApproved))
+
+		require.NoError(t, app.DB.Model(&accessrequests.AccessRequest{}).
+			Where("id = ?", request.ID).
+			Update("expires_at", time.Now().Add(-time.Second)).Error)
+
+		require.Eventually(t, func() bool {
+			return countAuditEvents(t, app.DB, security.EventAccessRequestExpired) == 1
+		}, 10*time.Second, 100*time.Millisecond)
+
+		var stored accessrequests.AccessRequest
+		require.NoError(t, app.DB.First(&stored, request.ID).Error)
+		assert.Equal(t, accessrequests.StatusExpired, stored.Status)
+		assert.NotContains(t, stackPermissions(member, "web-app"), permnames.StacksManage)
+	})
+
+	t.Run("POST /api/v1/access-requests/:id/cancel withdraws a request", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/access-requests/:id/cancel", e2etesting.CategoryHappyPath, e2etesting.ValueMedium)
+		resp, pending := create(member, map[string]any{
+			"stack_pattern": "api", "permission": permnames.StacksManage, "duration_minutes": 30, "reason": "restart",
+		})
+		require.Equal(t, 201, resp.StatusCode, "body=%s", resp.GetString())
+		cancelPath := "/api/v1/access-requests/" + Itoa(pending.ID) + "/cancel"
+
+		resp, err := bystander.Post(cancelPath, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode, "other users' requests: %s", resp.GetString())
+
+		resp, err = member.Post(cancelPath, map[string]any{})
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+
+		resp, err = member.Post(cancelPath, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, 409, resp.StatusCode, "already cancelled: %s", resp.GetString())
+		assert.Equal(t, int64(1), countAuditEvents(t, app.DB, security.EventAccessRequestCancelled))
+	})
+
+	t.Run("POST /api/v1/access-requests/:id/deny rejects a request", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/access-requests/:id/deny", e2etesting.CategoryHappyPath, e2etesting.ValueMedium)
+		resp, pending := create(member, map[string]any{
+			"stack_pattern": "db", "permission": permnames.StacksManage, "duration_minutes": 30, "reason": "migrate",
+		})
+		require.Equal(t, 201, resp.StatusCode, "body=%s", resp.GetString())
+
+		resp, err := lead.Post("/api/v1/access-requests/"+Itoa(pending.ID)+"/deny", map[string]any{"note": "use the pipeline"})
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		var data response.Response[accessrequests.GetRequestData]
+		require.NoError(t, resp.GetJSON(&data))
+		assert.Equal(t, accessrequests.StatusDenied, data.Data.Request.Status)
+		assert.Nil(t, data.Data.Request.ExpiresAt)
+		assert.NotContains(t, stackPermissions(member, "db"), permnames.StacksManage)
+		assert.Equal(t, int64(1), countAuditEvents(t, app.DB, security.EventAccessRequestDenied))
+	})
+}
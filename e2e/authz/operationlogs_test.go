@@ -17,7 +17,7 @@ import (
 func seedOperationLog(t *testing.T, app *e2e.TestApp, userID, serverID uint, operationID string) {
 	t.Helper()
 	row := &operationlogs.OperationLog{
-		UserID:      userID,
+		UserID:      &userID,
 		ServerID:    serverID,
 		StackName:   "prod-web",
 		OperationID: operationID,
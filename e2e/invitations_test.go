@@ -0,0 +1,200 @@
+package e2e
+
+import (
+	"testing"
+
+	"berth/internal/domain/auth"
+	"berth/internal/domain/invitations"
+	"berth/internal/pkg/response"
+
+	e2etesting "berth/e2e/internal/harness"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminInvitations(t *testing.T) {
+	t.Parallel()
+	app := SetupTestApp(t)
+
+	admin := &e2etesting.TestUser{
+		Username: "invite_admin",
+		Email:    "invite_admin@example.com",
+		Password: "password123",
+	}
+	app.CreateAdminTestUser(t, admin)
+	adminToken := loginAndIssueJWT(t, app, admin.Username, admin.Password)
+
+	adminRequest := func(method, path string, body any) *e2etesting.Response {
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method: method,
+			Path:   path,
+			Headers: map[string]string{
+				"Authorization": "Bearer " + adminToken,
+				"Content-Type":  "application/json",
+			},
+			Body: body,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	roleResp := adminRequest("POST", "/api/v1/admin/roles", map[string]any{
+		"name":        "invited-operators",
+		"description": "Granted through an invitation",
+	})
+	require.Equal(t, 201, roleResp.StatusCode, "body=%s", roleResp.GetString())
+	var role response.Response[RoleWithPermissions]
+	require.NoError(t, roleResp.GetJSON(&role))
+
+	invite := func(email string) (invitations.InvitationInfo, string) {
+		t.Helper()
+		app.Mail.Reset()
+		resp := adminRequest("POST", "/api/v1/admin/invitations", invitations.CreateInvitationRequest{
+			Email:   email,
+			RoleIDs: []uint{role.Data.ID},
+		})
+		require.Equal(t, 201, resp.StatusCode, "body=%s", resp.GetString())
+		var created response.Response[invitations.InvitationInfo]
+		require.NoError(t, resp.GetJSON(&created))
+		return created.Data, lastInvitationToken(t, app, email)
+	}
+
+	t.Run("POST /api/v1/admin/invitations emails a signed link", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/admin/invitations", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		created, token := invite("invitee_one@example.com")
+		assert.Equal(t, invitations.StatusPending, created.Status)
+		assert.Equal(t, admin.Username, created.InvitedBy)
+		require.Len(t, created.Roles, 1)
+		assert.Equal(t, "invited-operators", created.Roles[0].Name)
+
+		resp, err := app.HTTPClient.Post("/api/v1/auth/invitations/lookup", invitations.LookupInvitationRequest{Token: token})
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		var lookup response.Response[invitations.LookupInvitationData]
+		require.NoError(t, resp.GetJSON(&lookup))
+		assert.Equal(t, "invitee_one@example.com", lookup.Data.Email)
+		assert.Equal(t, []string{"invited-operators"}, lookup.Data.Roles)
+	})
+
+	t.Run("duplicate invitations and existing accounts are rejected", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/admin/invitations", e2etesting.CategoryValidation, e2etesting.ValueMedium)
+		resp := adminRequest("POST", "/api/v1/admin/invitations", invitations.CreateInvitationRequest{Email: "invitee_one@example.com"})
+		assert.Equal(t, 409, resp.StatusCode)
+		resp = adminRequest("POST", "/api/v1/admin/invitations", invitations.CreateInvitationRequest{Email: admin.Email})
+		assert.Equal(t, 409, resp.StatusCode)
+	})
+
+	t.Run("POST /api/v1/auth/invitations/accept creates the account", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/auth/invitations/accept", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		_, token := invite("invitee_two@example.com")
+
+		resp, err := app.HTTPClient.Post("/api/v1/auth/invitations/accept", invitations.AcceptInvitationRequest{
+			Token:                token,
+			Username:             "invitee_two",
+			Password:             "Password123!",
+			PasswordConfirmation: "Password123!",
+		})
+		require.NoError(t, err)
+		require.Equal(t, 201, resp.StatusCode, "body=%s", resp.GetString())
+
+		loginResp, err := app.HTTPClient.Post("/api/v1/auth/login", auth.AuthLoginRequest{
+			Username: "invitee_two",
+			Password: "Password123!",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 200, loginResp.StatusCode, "body=%s", loginResp.GetString())
+
+		var roleNames []string
+		require.NoError(t, app.DB.Table("roles").
+			Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+			Joins("JOIN users ON users.id = user_roles.user_id").
+			Where("users.username = ?", "invitee_two").
+			Pluck("roles.name", &roleNames).Error)
+		assert.Equal(t, []string{"invited-operators"}, roleNames)
+
+		resp, err = app.HTTPClient.Post("/api/v1/auth/invitations/accept", invitations.AcceptInvitationRequest{
+			Token:                token,
+			Username:             "invitee_two_again",
+			Password:             "Password123!",
+			PasswordConfirmation: "Password123!",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 400, resp.StatusCode, "an invitation works once")
+	})
+
+	t.Run("POST /api/v1/admin/invitations/:id/resend replaces the link", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/admin/invitations/:id/resend", e2etesting.CategoryHappyPath, e2etesting.ValueMedium)
+		created, oldToken := invite("invitee_three@example.com")
+
+		app.Mail.Reset()
+		resp := adminRequest("POST", "/api/v1/admin/invitations/"+Itoa(created.ID)+"/resend", nil)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		newToken := lastInvitationToken(t, app, "invitee_three@example.com")
+		assert.NotEqual(t, oldToken, newToken)
+
+		lookup, err := app.HTTPClient.Post("/api/v1/auth/invitations/lookup", invitations.LookupInvitationRequest{Token: oldToken})
+		require.NoError(t, err)
+		assert.Equal(t, 400, lookup.StatusCode, "the previous link stops working")
+	})
+
+	t.Run("DELETE /api/v1/admin/invitations/:id revokes the invitation", func(t *testing.T) {
+		TagTest(t, "DELETE", "/api/v1/admin/invitations/:id", e2etesting.CategoryHappyPath, e2etesting.ValueMedium)
+		created, token := invite("invitee_four@example.com")
+
+		resp := adminRequest("DELETE", "/api/v1/admin/invitations/"+Itoa(created.ID), nil)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+
+		lookup, err := app.HTTPClient.Post("/api/v1/auth/invitations/lookup", invitations.LookupInvitationRequest{Token: token})
+		require.NoError(t, err)
+		assert.Equal(t, 400, lookup.StatusCode)
+
+		resp = adminRequest("DELETE", "/api/v1/admin/invitations/999999", nil)
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+
+	t.Run("GET /api/v1/admin/invitations lists open invitations", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/admin/invitations", e2etesting.CategoryHappyPath, e2etesting.ValueMedium)
+		resp := adminRequest("GET", "/api/v1/admin/invitations", nil)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		var list response.Response[invitations.ListInvitationsData]
+		require.NoError(t, resp.GetJSON(&list))
+
+		var emails []string
+		for _, inv := range list.Data.Invitations {
+			emails = append(emails, inv.Email)
+		}
+		assert.ElementsMatch(t, []string{"invitee_one@example.com", "invitee_three@example.com"}, emails)
+	})
+
+	t.Run("non-admins cannot manage invitations", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/admin/invitations", e2etesting.CategoryAuthorization, e2etesting.ValueHigh)
+		member := &e2etesting.TestUser{
+			Username: "invite_member",
+			Email:    "invite_member@example.com",
+			Password: "password123",
+		}
+		app.AuthHelper.CreateTestUser(t, member)
+		memberToken := loginAndIssueJWT(t, app, member.Username, member.Password)
+
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method:  "POST",
+			Path:    "/api/v1/admin/invitations",
+			Headers: map[string]string{"Authorization": "Bearer " + memberToken},
+			Body:    invitations.CreateInvitationRequest{Email: "sneaky@example.com"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 403, resp.StatusCode)
+	})
+}
+
+func lastInvitationToken(t *testing.T, app *TestApp, email string) string {
+	t.Helper()
+	mails := app.Mail.ByTemplate("user_invitation")
+	require.NotEmpty(t, mails, "expected a user_invitation email")
+	last := mails[len(mails)-1]
+	require.Equal(t, []string{email}, last.To)
+	acceptURL, ok := last.Data["AcceptURL"].(string)
+	require.True(t, ok)
+	return extractVerificationTokenFromURL(t, acceptURL)
+}
@@ -0,0 +1,72 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"berth/internal/domain/auth/tokens"
+	"berth/internal/pkg/config"
+
+	e2etesting "berth/e2e/internal/harness"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWKSAsymmetricSigning(t *testing.T) {
+	t.Parallel()
+	app := SetupTestAppWithConfig(t, func(cfg *config.Config) {
+		cfg.JWT.Algorithm = tokens.AlgorithmEdDSA
+		cfg.JWT.KeyRotationInterval = 24 * time.Hour
+	})
+
+	user := &e2etesting.TestUser{
+		Username: "jwks_user",
+		Email:    "jwks_user@example.com",
+		Password: "password123",
+	}
+	app.AuthHelper.CreateTestUser(t, user)
+
+	t.Run("GET /.well-known/jwks.json publishes the key access tokens are signed with", func(t *testing.T) {
+		TagTest(t, "GET", "/.well-known/jwks.json", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		accessToken := loginAndIssueJWT(t, app, user.Username, user.Password)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(accessToken, jwt.MapClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, "EdDSA", parsed.Header["alg"])
+		kid, _ := parsed.Header["kid"].(string)
+		require.NotEmpty(t, kid)
+
+		resp, err := app.HTTPClient.Get("/.well-known/jwks.json")
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		var set tokens.JWKSet
+		require.NoError(t, resp.GetJSON(&set))
+		require.Len(t, set.Keys, 1)
+		assert.Equal(t, kid, set.Keys[0].Kid)
+		assert.Equal(t, "OKP", set.Keys[0].Kty)
+		assert.Equal(t, "Ed25519", set.Keys[0].Crv)
+
+		profile, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method:  "GET",
+			Path:    "/api/v1/profile",
+			Headers: map[string]string{"Authorization": "Bearer " + accessToken},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 200, profile.StatusCode)
+	})
+}
+
+func TestJWKSEmptyForHS256(t *testing.T) {
+	t.Parallel()
+	app := SetupTestApp(t)
+
+	TagTest(t, "GET", "/.well-known/jwks.json", e2etesting.CategorySecurity, e2etesting.ValueMedium)
+	resp, err := app.HTTPClient.Get("/.well-known/jwks.json")
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	var set tokens.JWKSet
+	require.NoError(t, resp.GetJSON(&set))
+	assert.Empty(t, set.Keys)
+}
@@ -0,0 +1,79 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"berth/internal/domain/leader"
+	"berth/internal/pkg/config"
+	"berth/internal/pkg/response"
+
+	e2etesting "berth/e2e/internal/harness"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderLeaseStatus(t *testing.T) {
+	t.Parallel()
+	app := SetupTestAppWithConfig(t, func(cfg *config.Config) {
+		cfg.Leader = config.LeaderConfig{
+			Enabled:    true,
+			InstanceID: "e2e-instance",
+			LeaseTTL:   30 * time.Second,
+		}
+	})
+
+	admin := &e2etesting.TestUser{
+		Username: "leader_admin",
+		Email:    "leader_admin@example.com",
+		Password: "password123",
+	}
+	app.CreateAdminTestUser(t, admin)
+	adminToken := loginAndIssueJWT(t, app, admin.Username, admin.Password)
+
+	member := &e2etesting.TestUser{
+		Username: "leader_member",
+		Email:    "leader_member@example.com",
+		Password: "password123",
+	}
+	app.AuthHelper.CreateTestUser(t, member)
+	memberToken := loginAndIssueJWT(t, app, member.Username, member.Password)
+
+	get := func(token string) *e2etesting.Response {
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method:  "GET",
+			Path:    "/api/v1/admin/leader/leases",
+			Headers: map[string]string{"Authorization": "Bearer " + token},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("GET /api/v1/admin/leader/leases shows the leases this instance holds", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/admin/leader/leases", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		resp := get(adminToken)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+
+		var status response.Response[leader.StatusData]
+		require.NoError(t, resp.GetJSON(&status))
+		assert.True(t, status.Data.Enabled)
+		assert.Equal(t, "e2e-instance", status.Data.InstanceID)
+
+		held := make(map[string]bool)
+		for _, l := range status.Data.Leases {
+			assert.Equal(t, "e2e-instance", l.Holder)
+			assert.True(t, l.Self)
+			held[l.Name] = true
+		}
+		assert.True(t, held[leader.LeaseImageUpdates])
+		assert.True(t, held[leader.LeaseRetention])
+		assert.True(t, held[leader.LeaseTokenMaintenance])
+	})
+
+	t.Run("non-admins cannot view leases", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/admin/leader/leases", e2etesting.CategoryAuthorization, e2etesting.ValueMedium)
+		resp := get(memberToken)
+		assert.Equal(t, 403, resp.StatusCode)
+	})
+}
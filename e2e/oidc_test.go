@@ -0,0 +1,211 @@
+package e2e
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"berth/internal/domain/user"
+	"berth/internal/pkg/config"
+
+	e2etesting "berth/e2e/internal/harness"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oidcTestProvider is a minimal OpenID Connect provider. Each code redeemed
+// at its token endpoint returns an ID token for the identity queued with
+// issueCode.
+type oidcTestProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	mu     sync.Mutex
+	codes  map[string]jwt.MapClaims
+}
+
+func newOIDCTestProvider(t *testing.T) *oidcTestProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &oidcTestProvider{key: key, codes: map[string]jwt.MapClaims{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		p.mu.Lock()
+		claims, ok := p.codes[r.Form.Get("code")]
+		delete(p.codes, r.Form.Get("code"))
+		p.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "k1"
+		idToken, err := token.SignedString(p.key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "provider-access-token",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *oidcTestProvider) configure(cfg *config.Config) {
+	cfg.OIDC = config.OIDCConfig{
+		Enabled:       true,
+		ProviderName:  "Test SSO",
+		IssuerURL:     p.server.URL,
+		ClientID:      "berth",
+		ClientSecret:  "s3cret",
+		RedirectURL:   "https://berth.example.com/api/v1/auth/oidc/callback",
+		Scopes:        []string{"openid", "profile", "email"},
+		UsernameClaim: "preferred_username",
+		GroupsClaim:   "groups",
+		AutoProvision: true,
+	}
+}
+
+// issueCode queues an authorization code for the identity, bound to the
+// nonce of the login that authURL started.
+func (p *oidcTestProvider) issueCode(t *testing.T, authURL, code string, identity jwt.MapClaims) {
+	t.Helper()
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"aud":   "berth",
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": parsed.Query().Get("nonce"),
+	}
+	for k, v := range identity {
+		claims[k] = v
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.codes[code] = claims
+}
+
+func TestOIDCLoginExemptFromRoleTwoFactor(t *testing.T) {
+	t.Parallel()
+	provider := newOIDCTestProvider(t)
+	app := SetupTestAppWithConfig(t, provider.configure)
+	client := app.HTTPClient.WithoutRedirects()
+
+	login := func(code string, identity jwt.MapClaims) *e2etesting.Response {
+		t.Helper()
+		start, err := client.Get("/api/v1/auth/oidc/login")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusFound, start.StatusCode, "body=%s", start.GetString())
+		authURL := start.Header.Get("Location")
+
+		var stateCookie *http.Cookie
+		for _, ck := range start.Cookies() {
+			if ck.Name == "berth_oidc_state" {
+				stateCookie = ck
+			}
+		}
+		require.NotNil(t, stateCookie)
+
+		provider.issueCode(t, authURL, code, identity)
+		parsed, err := url.Parse(authURL)
+		require.NoError(t, err)
+
+		resp, err := client.Request(&e2etesting.RequestOptions{
+			Method:  "GET",
+			Path:    "/api/v1/auth/oidc/callback?" + url.Values{"state": {parsed.Query().Get("state")}, "code": {code}}.Encode(),
+			Cookies: []*http.Cookie{stateCookie},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	refreshCookie := func(resp *e2etesting.Response) *http.Cookie {
+		for _, ck := range resp.Cookies() {
+			if ck.Name == "berth_refresh" {
+				return ck
+			}
+		}
+		return nil
+	}
+
+	t.Run("users without a two-factor requirement are signed in", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/auth/oidc/callback", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		resp := login("code-plain", jwt.MapClaims{
+			"sub":                "sso-plain",
+			"email":              "sso_plain@example.com",
+			"email_verified":     true,
+			"preferred_username": "sso_plain",
+		})
+		assert.Equal(t, http.StatusFound, resp.StatusCode, "body=%s", resp.GetString())
+		assert.Equal(t, "/", resp.Header.Get("Location"))
+		assert.NotNil(t, refreshCookie(resp))
+	})
+
+	t.Run("a role requiring a second factor does not block single sign-on", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/auth/oidc/callback", e2etesting.CategorySecurity, e2etesting.ValueHigh)
+		member := &e2etesting.TestUser{
+			Username: "sso_mfa_member",
+			Email:    "sso_mfa_member@example.com",
+			Password: "password123",
+		}
+		app.AuthHelper.CreateTestUser(t, member)
+
+		role := user.Role{Name: "sso-mfa-operators", RequireTwoFactor: true}
+		require.NoError(t, app.DB.Create(&role).Error)
+		require.NoError(t, app.DB.Create(&user.UserRole{UserID: member.ID, RoleID: role.ID}).Error)
+
+		resp := login("code-mfa", jwt.MapClaims{
+			"sub":            "sso-mfa-member",
+			"email":          member.Email,
+			"email_verified": true,
+		})
+		assert.Equal(t, http.StatusFound, resp.StatusCode, "body=%s", resp.GetString())
+		assert.Equal(t, "/", resp.Header.Get("Location"), "the identity provider is expected to enforce MFA")
+		assert.NotNil(t, refreshCookie(resp))
+	})
+}
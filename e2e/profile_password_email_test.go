@@ -0,0 +1,177 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+
+	"berth/internal/domain/auth"
+	"berth/internal/pkg/response"
+
+	e2etesting "berth/e2e/internal/harness"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loginForTokens(t *testing.T, app *TestApp, username, password string) auth.AuthLoginData {
+	t.Helper()
+	resp, err := app.HTTPClient.Post("/api/v1/auth/login", auth.AuthLoginRequest{
+		Username: username,
+		Password: password,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+	var login response.Response[auth.AuthLoginData]
+	require.NoError(t, resp.GetJSON(&login))
+	return login.Data
+}
+
+func TestAPIProfileChangePassword(t *testing.T) {
+	t.Parallel()
+	app := SetupTestApp(t)
+
+	user := &e2etesting.TestUser{
+		Username: "api_profile_password",
+		Email:    "api_profile_password@example.com",
+		Password: "oldpassword123",
+	}
+	app.AuthHelper.CreateTestUser(t, user)
+
+	other := loginForTokens(t, app, user.Username, user.Password)
+	current := loginForTokens(t, app, user.Username, user.Password)
+
+	changePassword := func(body auth.ProfileChangePasswordRequest) *e2etesting.Response {
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method: "POST",
+			Path:   "/api/v1/profile/password",
+			Headers: map[string]string{
+				"Authorization": "Bearer " + current.AccessToken,
+				"Content-Type":  "application/json",
+			},
+			Body: body,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("wrong current password is rejected", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/profile/password", e2etesting.CategoryValidation, e2etesting.ValueHigh)
+		resp := changePassword(auth.ProfileChangePasswordRequest{
+			CurrentPassword:      "not-my-password",
+			Password:             "newpassword456",
+			PasswordConfirmation: "newpassword456",
+		})
+		assert.Equal(t, 400, resp.StatusCode)
+		var errResp response.ErrorResponseBody
+		require.NoError(t, resp.GetJSON(&errResp))
+		assert.Equal(t, "invalid_current_password", errResp.Error.Code)
+	})
+
+	t.Run("change revokes other sessions and notifies the account", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/profile/password", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		app.Mail.Reset()
+		resp := changePassword(auth.ProfileChangePasswordRequest{
+			CurrentPassword:      user.Password,
+			Password:             "newpassword456",
+			PasswordConfirmation: "newpassword456",
+		})
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+
+		notices := app.Mail.ByTemplate("password_changed")
+		require.Len(t, notices, 1)
+		assert.Equal(t, []string{user.Email}, notices[0].To)
+
+		refreshResp, err := app.HTTPClient.Post("/api/v1/auth/refresh", auth.AuthRefreshRequest{
+			RefreshToken: other.RefreshToken,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 401, refreshResp.StatusCode, "other sessions must be signed out")
+
+		refreshResp, err = app.HTTPClient.Post("/api/v1/auth/refresh", auth.AuthRefreshRequest{
+			RefreshToken: current.RefreshToken,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 200, refreshResp.StatusCode, "the caller's session must survive")
+
+		loginForTokens(t, app, user.Username, "newpassword456")
+	})
+}
+
+func TestAPIProfileChangeEmail(t *testing.T) {
+	t.Parallel()
+	app := SetupTestApp(t)
+
+	user := &e2etesting.TestUser{
+		Username: "api_profile_email",
+		Email:    "api_profile_email@example.com",
+		Password: "password123",
+	}
+	app.AuthHelper.CreateTestUser(t, user)
+	taken := &e2etesting.TestUser{
+		Username: "api_profile_email_taken",
+		Email:    "api_profile_email_taken@example.com",
+		Password: "password123",
+	}
+	app.AuthHelper.CreateTestUser(t, taken)
+
+	token := loginForTokens(t, app, user.Username, user.Password).AccessToken
+	changeEmail := func(email string) *e2etesting.Response {
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method: "POST",
+			Path:   "/api/v1/profile/email",
+			Headers: map[string]string{
+				"Authorization": "Bearer " + token,
+				"Content-Type":  "application/json",
+			},
+			Body: auth.ProfileChangeEmailRequest{Email: email, CurrentPassword: user.Password},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+	currentEmail := func() string {
+		var email string
+		require.NoError(t, app.DB.Table("users").Select("email").Where("id = ?", user.ID).Scan(&email).Error)
+		return email
+	}
+
+	t.Run("address used by another account is rejected", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/profile/email", e2etesting.CategoryValidation, e2etesting.ValueMedium)
+		resp := changeEmail(taken.Email)
+		assert.Equal(t, 409, resp.StatusCode)
+
+		resp = changeEmail(strings.ToUpper(taken.Email))
+		assert.Equal(t, 409, resp.StatusCode, "addresses differing only in case belong to the same account")
+	})
+
+	t.Run("change is applied only after the new address is verified", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/profile/email", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		app.Mail.Reset()
+		resp := changeEmail(" API_Profile_Email_New@Example.com ")
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		assert.Equal(t, user.Email, currentEmail(), "email must not change before verification")
+
+		mails := app.Mail.ByTemplate("email_change_verification")
+		require.Len(t, mails, 1)
+		assert.Equal(t, []string{"api_profile_email_new@example.com"}, mails[0].To)
+		verificationURL, ok := mails[0].Data["VerificationURL"].(string)
+		require.True(t, ok)
+		changeToken := extractVerificationTokenFromURL(t, verificationURL)
+
+		verifyResp, err := app.HTTPClient.Post("/api/v1/auth/verify-email", auth.AuthVerifyEmailRequest{Token: changeToken})
+		require.NoError(t, err)
+		assert.NotEqual(t, 200, verifyResp.StatusCode, "an email change token is not a sign-up verification token")
+
+		confirmResp, err := app.HTTPClient.Post("/api/v1/auth/email-change/confirm", auth.ConfirmEmailChangeRequest{Token: changeToken})
+		require.NoError(t, err)
+		require.Equal(t, 200, confirmResp.StatusCode, "body=%s", confirmResp.GetString())
+		assert.Equal(t, "api_profile_email_new@example.com", currentEmail())
+
+		notices := app.Mail.ByTemplate("email_changed")
+		require.Len(t, notices, 1)
+		assert.Equal(t, []string{user.Email}, notices[0].To, "the previous address must be notified")
+
+		confirmResp, err = app.HTTPClient.Post("/api/v1/auth/email-change/confirm", auth.ConfirmEmailChangeRequest{Token: changeToken})
+		require.NoError(t, err)
+		assert.Equal(t, 400, confirmResp.StatusCode, "a change link works once")
+	})
+}
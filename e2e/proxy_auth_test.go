@@ -0,0 +1,164 @@
+package e2e
+
+import (
+	"testing"
+
+	"berth/internal/domain/auth/totp"
+	"berth/internal/domain/security"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+
+	e2etesting "berth/e2e/internal/harness"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func proxyAuthConfig(trusted ...string) func(*config.Config) {
+	return func(cfg *config.Config) {
+		cfg.Server.TrustedProxies = trusted
+		cfg.ProxyAuth = config.ProxyAuthConfig{
+			Enabled:        true,
+			UserHeader:     "Remote-User",
+			EmailHeader:    "Remote-Email",
+			GroupsHeader:   "Remote-Groups",
+			GroupSeparator: ",",
+			RoleMapping:    "berth-admins=admin",
+			AutoProvision:  true,
+		}
+	}
+}
+
+func TestProxyHeaderAuth(t *testing.T) {
+	t.Parallel()
+	app := SetupTestAppWithConfig(t, proxyAuthConfig("127.0.0.1", "::1"))
+
+	asProxy := func(path string, headers map[string]string) *e2etesting.Response {
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method:  "GET",
+			Path:    path,
+			Headers: headers,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("trusted proxy identity provisions and authenticates the user", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/profile", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		resp := asProxy("/api/v1/profile", map[string]string{
+			"Remote-User":  "proxy_alice",
+			"Remote-Email": "proxy_alice@example.com",
+		})
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		assert.Contains(t, resp.GetString(), "proxy_alice@example.com")
+		assert.Equal(t, int64(1), countAuditEvents(t, app.DB, security.EventUserCreated))
+	})
+
+	t.Run("mapped groups grant admin access", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/admin/users", e2etesting.CategoryAuthorization, e2etesting.ValueHigh)
+		resp := asProxy("/api/v1/admin/users", map[string]string{"Remote-User": "proxy_alice"})
+		assert.Equal(t, 403, resp.StatusCode)
+
+		resp = asProxy("/api/v1/admin/users", map[string]string{
+			"Remote-User":   "proxy_alice",
+			"Remote-Groups": "staff, berth-admins",
+		})
+		assert.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+	})
+
+	t.Run("requests without identity headers still need credentials", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/profile", e2etesting.CategoryAuthorization, e2etesting.ValueMedium)
+		resp := asProxy("/api/v1/profile", nil)
+		assert.Equal(t, 401, resp.StatusCode)
+	})
+}
+
+func TestProxyHeaderAuthRoleRequiredTwoFactor(t *testing.T) {
+	t.Parallel()
+	app := SetupTestAppWithConfig(t, proxyAuthConfig("127.0.0.1", "::1"))
+
+	member := &e2etesting.TestUser{
+		Username: "proxy_mfa_member",
+		Email:    "proxy_mfa_member@example.com",
+		Password: "password123",
+	}
+	app.AuthHelper.CreateTestUser(t, member)
+
+	role := usermodel.Role{Name: "proxy-mfa-operators", RequireTwoFactor: true}
+	require.NoError(t, app.DB.Create(&role).Error)
+	require.NoError(t, app.DB.Create(&usermodel.UserRole{UserID: member.ID, RoleID: role.ID}).Error)
+
+	asProxy := func(path string) *e2etesting.Response {
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method:  "GET",
+			Path:    path,
+			Headers: map[string]string{"Remote-User": member.Username},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("users without the required second factor only reach enrollment", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/servers", e2etesting.CategorySecurity, e2etesting.ValueHigh)
+		resp := asProxy("/api/v1/servers")
+		assert.Equal(t, 403, resp.StatusCode, "body=%s", resp.GetString())
+
+		resp = asProxy("/api/v1/totp/status")
+		assert.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+	})
+
+	t.Run("enrolled users get full access", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/servers", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		require.NoError(t, app.DB.Create(&totp.TOTPSecret{UserID: member.ID, Secret: "s", Enabled: true}).Error)
+
+		resp := asProxy("/api/v1/servers")
+		assert.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+	})
+}
+
+func TestProxyHeaderAuthRejectsUntrustedSource(t *testing.T) {
+	t.Parallel()
+	app := SetupTestAppWithConfig(t, proxyAuthConfig("192.0.2.10"))
+
+	user := &e2etesting.TestUser{
+		Username: "proxy_victim",
+		Email:    "proxy_victim@example.com",
+		Password: "password123",
+	}
+	app.CreateAdminTestUser(t, user)
+	token := loginAndIssueJWT(t, app, user.Username, user.Password)
+
+	t.Run("spoofed identity headers are rejected and audited", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/profile", e2etesting.CategorySecurity, e2etesting.ValueHigh)
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method:  "GET",
+			Path:    "/api/v1/profile",
+			Headers: map[string]string{"Remote-User": user.Username},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+		assert.Equal(t, int64(1), countAuditEvents(t, app.DB, security.EventAuthProxyHeaderRejected))
+	})
+
+	t.Run("spoofed headers are rejected alongside a valid token", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/profile", e2etesting.CategorySecurity, e2etesting.ValueHigh)
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method: "GET",
+			Path:   "/api/v1/profile",
+			Headers: map[string]string{
+				"Authorization": "Bearer " + token,
+				"Remote-Groups": "berth-admins",
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+
+		resp, err = app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method:  "GET",
+			Path:    "/api/v1/profile",
+			Headers: map[string]string{"Authorization": "Bearer " + token},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+}
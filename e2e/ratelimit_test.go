@@ -7,7 +7,10 @@ import (
 	"testing"
 
 	e2etesting "berth/e2e/internal/harness"
+	"berth/internal/domain/apikey"
+	"berth/internal/domain/security"
 	"berth/internal/pkg/config"
+	"berth/internal/pkg/response"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -183,3 +186,138 @@ func TestRateLimitHeadersPresent(t *testing.T) {
 		assertHeaders(t, limited, "0")
 	})
 }
+
+func TestRateLimitPerUser(t *testing.T) {
+	t.Parallel()
+	app := SetupTestAppWithConfig(t, func(c *config.Config) {
+		c.RateLimit.Enabled = true
+		c.RateLimit.UserRate = 3
+	})
+
+	tokens := make([]string, 2)
+	for i := range tokens {
+		user := &e2etesting.TestUser{
+			Username: fmt.Sprintf("ratelimituser%d", i),
+			Email:    fmt.Sprintf("ratelimituser%d@example.com", i),
+			Password: "password123",
+		}
+		app.AuthHelper.CreateTestUser(t, user)
+		tokens[i] = loginAndIssueJWT(t, app, user.Username, user.Password)
+	}
+
+	getVersion := func(token string) *e2etesting.Response {
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method:  "GET",
+			Path:    "/api/v1/version",
+			Headers: map[string]string{"Authorization": "Bearer " + token},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("each user has their own bucket behind a shared address", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/version", e2etesting.CategorySecurity, e2etesting.ValueHigh)
+		for i := 1; i <= 3; i++ {
+			resp := getVersion(tokens[0])
+			require.Equal(t, http.StatusOK, resp.StatusCode, "request %d", i)
+			assert.Equal(t, "3", resp.Header.Get("X-RateLimit-Limit"))
+		}
+		assert.Equal(t, http.StatusTooManyRequests, getVersion(tokens[0]).StatusCode)
+
+		assert.Equal(t, http.StatusOK, getVersion(tokens[1]).StatusCode,
+			"another user from the same IP keeps their own budget")
+	})
+}
+
+func TestRateLimitPerAPIKey(t *testing.T) {
+	t.Parallel()
+	app := SetupTestAppWithConfig(t, func(c *config.Config) {
+		c.RateLimit.Enabled = true
+		c.RateLimit.APIKeyRate = 2
+	})
+
+	admin := &e2etesting.TestUser{
+		Username: "ratelimitkeyadmin",
+		Email:    "ratelimitkeyadmin@example.com",
+		Password: "password123",
+	}
+	app.CreateAdminTestUser(t, admin)
+	adminToken := loginAndIssueJWT(t, app, admin.Username, admin.Password)
+
+	request := func(method, path, token string, body any) *e2etesting.Response {
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method: method,
+			Path:   path,
+			Headers: map[string]string{
+				"Authorization": "Bearer " + token,
+				"Content-Type":  "application/json",
+			},
+			Body: body,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	createKey := func(name string) apikey.CreateAPIKeyData {
+		resp := request("POST", "/api/v1/api-keys", adminToken, map[string]any{"name": name})
+		require.Equal(t, http.StatusCreated, resp.StatusCode, "body=%s", resp.GetString())
+		var created response.Response[apikey.CreateAPIKeyData]
+		require.NoError(t, resp.GetJSON(&created))
+		return created.Data
+	}
+
+	noisy := createKey("noisy")
+	quiet := createKey("quiet")
+
+	t.Run("each key is limited on its own", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/version", e2etesting.CategorySecurity, e2etesting.ValueHigh)
+		for i := 1; i <= 2; i++ {
+			require.Equal(t, http.StatusOK, request("GET", "/api/v1/version", noisy.PlainKey, nil).StatusCode, "request %d", i)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, request("GET", "/api/v1/version", noisy.PlainKey, nil).StatusCode)
+
+		assert.Equal(t, http.StatusOK, request("GET", "/api/v1/version", quiet.PlainKey, nil).StatusCode)
+		assert.Equal(t, http.StatusOK, request("GET", "/api/v1/version", adminToken, nil).StatusCode,
+			"the key owner's own requests are counted separately")
+	})
+
+	t.Run("PUT /api/v1/admin/api-keys/:id/rate-limit overrides the default", func(t *testing.T) {
+		TagTest(t, "PUT", "/api/v1/admin/api-keys/:id/rate-limit", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		path := fmt.Sprintf("/api/v1/admin/api-keys/%d/rate-limit", quiet.APIKey.ID)
+		resp := request("PUT", path, adminToken, map[string]any{"rate_limit": 5})
+		require.Equal(t, http.StatusOK, resp.StatusCode, "body=%s", resp.GetString())
+		var updated response.Response[apikey.APIKeyInfo]
+		require.NoError(t, resp.GetJSON(&updated))
+		require.NotNil(t, updated.Data.RateLimit)
+		assert.Equal(t, 5, *updated.Data.RateLimit)
+
+		for i := 2; i <= 5; i++ {
+			resp := request("GET", "/api/v1/version", quiet.PlainKey, nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode, "request %d", i)
+			assert.Equal(t, "5", resp.Header.Get("X-RateLimit-Limit"))
+		}
+		assert.Equal(t, http.StatusTooManyRequests, request("GET", "/api/v1/version", quiet.PlainKey, nil).StatusCode)
+		assert.Equal(t, int64(1), countAuditEvents(t, app.DB, security.EventAPIKeyRateLimitUpdated))
+	})
+
+	t.Run("PUT /api/v1/admin/api-keys/:id/rate-limit validates input", func(t *testing.T) {
+		TagTest(t, "PUT", "/api/v1/admin/api-keys/:id/rate-limit", e2etesting.CategoryValidation, e2etesting.ValueMedium)
+		path := fmt.Sprintf("/api/v1/admin/api-keys/%d/rate-limit", quiet.APIKey.ID)
+		assert.Equal(t, http.StatusBadRequest, request("PUT", path, adminToken, map[string]any{"rate_limit": 0}).StatusCode)
+		assert.Equal(t, http.StatusNotFound,
+			request("PUT", "/api/v1/admin/api-keys/999999/rate-limit", adminToken, map[string]any{"rate_limit": 5}).StatusCode)
+	})
+
+	t.Run("PUT /api/v1/admin/api-keys/:id/rate-limit requires admin.users.write", func(t *testing.T) {
+		TagTest(t, "PUT", "/api/v1/admin/api-keys/:id/rate-limit", e2etesting.CategoryAuthorization, e2etesting.ValueHigh)
+		member := &e2etesting.TestUser{
+			Username: "ratelimitkeymember",
+			Email:    "ratelimitkeymember@example.com",
+			Password: "password123",
+		}
+		app.AuthHelper.CreateTestUser(t, member)
+		memberToken := loginAndIssueJWT(t, app, member.Username, member.Password)
+		path := fmt.Sprintf("/api/v1/admin/api-keys/%d/rate-limit", noisy.APIKey.ID)
+		assert.Equal(t, http.StatusForbidden, request("PUT", path, memberToken, map[string]any{"rate_limit": 100}).StatusCode)
+	})
+}
@@ -0,0 +1,223 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"berth/internal/domain/rbac"
+	"berth/internal/domain/security"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+	"berth/internal/pkg/response"
+
+	e2etesting "berth/e2e/internal/harness"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemporaryRoleAssignment(t *testing.T) {
+	t.Parallel()
+	app := SetupTestAppWithConfig(t, func(c *config.Config) {
+		c.Auth.RoleExpiryInterval = 100 * time.Millisecond
+	})
+
+	admin := &e2etesting.TestUser{
+		Username: "role_expiry_admin",
+		Email:    "role_expiry_admin@example.com",
+		Password: "password123",
+	}
+	app.CreateAdminTestUser(t, admin)
+	adminToken := loginAndIssueJWT(t, app, admin.Username, admin.Password)
+
+	member := &e2etesting.TestUser{
+		Username: "role_expiry_member",
+		Email:    "role_expiry_member@example.com",
+		Password: "password123",
+	}
+	app.AuthHelper.CreateTestUser(t, member)
+
+	var adminRoleID uint
+	require.NoError(t, app.DB.Table("roles").Where("name = ?", "admin").Pluck("id", &adminRoleID).Error)
+
+	request := func(token, method, path string, body any) *e2etesting.Response {
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method: method,
+			Path:   path,
+			Headers: map[string]string{
+				"Authorization": "Bearer " + token,
+				"Content-Type":  "application/json",
+			},
+			Body: body,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("POST /api/v1/admin/users/assign-role rejects invalid expiry", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/admin/users/assign-role", e2etesting.CategoryValidation, e2etesting.ValueMedium)
+		resp := request(adminToken, "POST", "/api/v1/admin/users/assign-role", map[string]any{
+			"user_id":    member.ID,
+			"role_id":    adminRoleID,
+			"expires_at": time.Now().Add(-time.Hour).Format(time.RFC3339),
+		})
+		assert.Equal(t, 400, resp.StatusCode, "body=%s", resp.GetString())
+
+		resp = request(adminToken, "POST", "/api/v1/admin/users/assign-role", map[string]any{
+			"user_id":         member.ID,
+			"role_id":         adminRoleID,
+			"revoke_sessions": true,
+		})
+		assert.Equal(t, 400, resp.StatusCode, "body=%s", resp.GetString())
+	})
+
+	expiresAt := time.Now().Add(2 * time.Second).UTC().Truncate(time.Second)
+	resp := request(adminToken, "POST", "/api/v1/admin/users/assign-role", map[string]any{
+		"user_id":         member.ID,
+		"role_id":         adminRoleID,
+		"expires_at":      expiresAt.Format(time.RFC3339),
+		"revoke_sessions": true,
+	})
+	require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+	memberToken := loginAndIssueJWT(t, app, member.Username, member.Password)
+
+	t.Run("GET /api/v1/admin/users/:id/roles reports the expiry", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/admin/users/:id/roles", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		resp := request(adminToken, "GET", "/api/v1/admin/users/"+Itoa(member.ID)+"/roles", nil)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		var roles response.Response[rbac.GetUserRolesData]
+		require.NoError(t, resp.GetJSON(&roles))
+		require.Len(t, roles.Data.Assignments, 1)
+		assert.Equal(t, "admin", roles.Data.Assignments[0].RoleName)
+		require.NotNil(t, roles.Data.Assignments[0].ExpiresAt)
+		got, err := time.Parse(time.RFC3339, *roles.Data.Assignments[0].ExpiresAt)
+		require.NoError(t, err)
+		assert.True(t, got.Equal(expiresAt))
+		assert.True(t, roles.Data.Assignments[0].RevokeSessions)
+	})
+
+	t.Run("temporary role grants access until it expires", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/admin/users", e2etesting.CategoryAuthorization, e2etesting.ValueHigh)
+		resp := request(memberToken, "GET", "/api/v1/admin/users", nil)
+		assert.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+
+		require.Eventually(t, func() bool {
+			return countAuditEvents(t, app.DB, security.EventUserRoleExpired) == 1
+		}, 10*time.Second, 100*time.Millisecond)
+
+		resp = request(memberToken, "GET", "/api/v1/admin/users", nil)
+		assert.Equal(t, 401, resp.StatusCode, "sessions are revoked on expiry")
+
+		freshToken := loginAndIssueJWT(t, app, member.Username, member.Password)
+		resp = request(freshToken, "GET", "/api/v1/admin/users", nil)
+		assert.Equal(t, 403, resp.StatusCode)
+
+		resp = request(adminToken, "GET", "/api/v1/admin/users/"+Itoa(member.ID)+"/roles", nil)
+		require.Equal(t, 200, resp.StatusCode)
+		var roles response.Response[rbac.GetUserRolesData]
+		require.NoError(t, resp.GetJSON(&roles))
+		assert.Empty(t, roles.Data.Assignments)
+		assert.Empty(t, roles.Data.User.Roles)
+	})
+
+	t.Run("expired assignment is recorded in the audit log", func(t *testing.T) {
+		var log security.SecurityAuditLog
+		require.NoError(t, app.DB.Where("event_type = ?", security.EventUserRoleExpired).First(&log).Error)
+		assert.Equal(t, "role-expiry", log.ActorUsername)
+		assert.Contains(t, log.Metadata, `"sessions_revoked":true`)
+		assert.Contains(t, log.Metadata, `"role_name":"admin"`)
+	})
+}
+
+func TestExpiredRoleAssignmentHiddenBeforePruning(t *testing.T) {
+	t.Parallel()
+	app := SetupTestAppWithConfig(t, func(c *config.Config) {
+		c.Auth.RoleExpiryInterval = time.Hour
+	})
+
+	admin := &e2etesting.TestUser{
+		Username: "unpruned_admin",
+		Email:    "unpruned_admin@example.com",
+		Password: "password123",
+	}
+	app.CreateAdminTestUser(t, admin)
+	adminToken := loginAndIssueJWT(t, app, admin.Username, admin.Password)
+
+	expired := &e2etesting.TestUser{
+		Username: "unpruned_expired",
+		Email:    "unpruned_expired@example.com",
+		Password: "password123",
+	}
+	app.AuthHelper.CreateTestUser(t, expired)
+
+	var adminRoleID uint
+	require.NoError(t, app.DB.Table("roles").Where("name = ?", "admin").Pluck("id", &adminRoleID).Error)
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, app.DB.Create(&usermodel.UserRole{UserID: expired.ID, RoleID: adminRoleID, ExpiresAt: &past}).Error)
+
+	TagTest(t, "GET", "/api/v1/admin/users", e2etesting.CategoryEdgeCase, e2etesting.ValueMedium)
+	resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+		Method:  "GET",
+		Path:    "/api/v1/admin/users",
+		Headers: map[string]string{"Authorization": "Bearer " + adminToken},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+
+	var list response.Response[rbac.ListUsersData]
+	require.NoError(t, resp.GetJSON(&list))
+	roleNames := map[uint][]string{}
+	for _, u := range list.Data.Users {
+		for _, r := range u.Roles {
+			roleNames[u.ID] = append(roleNames[u.ID], r.Name)
+		}
+	}
+	assert.Contains(t, roleNames[admin.ID], "admin")
+	assert.Empty(t, roleNames[expired.ID], "an expired assignment is hidden before the expiry worker removes it")
+}
+
+func TestTemporaryAssignmentOfPermanentRole(t *testing.T) {
+	t.Parallel()
+	app := SetupTestApp(t)
+
+	admin := &e2etesting.TestUser{
+		Username: "permanent_role_admin",
+		Email:    "permanent_role_admin@example.com",
+		Password: "password123",
+	}
+	app.CreateAdminTestUser(t, admin)
+	adminToken := loginAndIssueJWT(t, app, admin.Username, admin.Password)
+
+	member := &e2etesting.TestUser{
+		Username: "permanent_role_member",
+		Email:    "permanent_role_member@example.com",
+		Password: "password123",
+	}
+	app.AuthHelper.CreateTestUser(t, member)
+
+	role := usermodel.Role{Name: "permanent-operators"}
+	require.NoError(t, app.DB.Create(&role).Error)
+	require.NoError(t, app.DB.Create(&usermodel.UserRole{UserID: member.ID, RoleID: role.ID}).Error)
+
+	TagTest(t, "POST", "/api/v1/admin/users/assign-role", e2etesting.CategoryEdgeCase, e2etesting.ValueHigh)
+	resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+		Method: "POST",
+		Path:   "/api/v1/admin/users/assign-role",
+		Headers: map[string]string{
+			"Authorization": "Bearer " + adminToken,
+			"Content-Type":  "application/json",
+		},
+		Body: map[string]any{
+			"user_id":    member.ID,
+			"role_id":    role.ID,
+			"expires_at": time.Now().Add(time.Hour).Format(time.RFC3339),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode, "body=%s", resp.GetString())
+	assert.Zero(t, countAuditEvents(t, app.DB, security.EventUserRoleAssigned), "no temporary grant is audited")
+
+	var assignment usermodel.UserRole
+	require.NoError(t, app.DB.Where("user_id = ? AND role_id = ?", member.ID, role.ID).First(&assignment).Error)
+	assert.Nil(t, assignment.ExpiresAt, "the permanent assignment is kept")
+}
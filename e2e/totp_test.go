@@ -661,3 +661,136 @@ func TestTOTPVerifyCannotBeReplayed(t *testing.T) {
 			"replaying the same pending token + code must be rejected; body=%s", resp.GetString())
 	})
 }
+
+func TestTOTPRecoveryCodes(t *testing.T) {
+	t.Parallel()
+	app := SetupTestApp(t)
+
+	user := &e2etesting.TestUser{
+		Username: "totp_recovery",
+		Email:    "totp_recovery@example.com",
+		Password: "password123",
+	}
+	app.AuthHelper.CreateTestUser(t, user)
+
+	loginResp, err := app.HTTPClient.Post("/api/v1/auth/login", auth.AuthLoginRequest{
+		Username: user.Username,
+		Password: user.Password,
+	})
+	require.NoError(t, err)
+	var login response.Response[auth.AuthLoginData]
+	require.NoError(t, loginResp.GetJSON(&login))
+	accessToken := login.Data.AccessToken
+
+	setupResp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+		Method:  "GET",
+		Path:    "/api/v1/totp/setup",
+		Headers: map[string]string{"Authorization": "Bearer " + accessToken},
+	})
+	require.NoError(t, err)
+	var setup response.Response[auth.TOTPSetupData]
+	require.NoError(t, setupResp.GetJSON(&setup))
+
+	validCode, err := totp.GenerateCode(setup.Data.Secret, time.Now())
+	require.NoError(t, err)
+	enableResp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+		Method: "POST",
+		Path:   "/api/v1/totp/enable",
+		Headers: map[string]string{
+			"Authorization": "Bearer " + accessToken,
+			"Content-Type":  "application/json",
+		},
+		Body: auth.TOTPEnableRequest{Code: validCode},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 200, enableResp.StatusCode)
+	var enabled response.Response[auth.TOTPEnableData]
+	require.NoError(t, enableResp.GetJSON(&enabled))
+	require.Len(t, enabled.Data.RecoveryCodes, 10)
+	codes := enabled.Data.RecoveryCodes
+
+	verifyWith := func(code string) *e2etesting.Response {
+		resp, err := app.HTTPClient.Post("/api/v1/auth/login", auth.AuthLoginRequest{
+			Username: user.Username,
+			Password: user.Password,
+		})
+		require.NoError(t, err)
+		var challenge response.Response[auth.AuthTOTPRequiredData]
+		require.NoError(t, resp.GetJSON(&challenge))
+		require.NotEmpty(t, challenge.Data.TemporaryToken)
+
+		resp, err = app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method: "POST",
+			Path:   "/api/v1/auth/totp/verify",
+			Headers: map[string]string{
+				"Authorization": "Bearer " + challenge.Data.TemporaryToken,
+				"Content-Type":  "application/json",
+			},
+			Body: auth.AuthTOTPVerifyRequest{Code: code},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("a recovery code completes login once", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/auth/totp/verify", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		resp := verifyWith(codes[0])
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+
+		resp = verifyWith(codes[0])
+		assert.Equal(t, 401, resp.StatusCode)
+		var errResp response.ErrorResponseBody
+		require.NoError(t, resp.GetJSON(&errResp))
+		assert.Equal(t, "invalid_recovery_code", errResp.Error.Code)
+	})
+
+	t.Run("GET /api/v1/totp/status reports remaining recovery codes", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/totp/status", e2etesting.CategoryHappyPath, e2etesting.ValueMedium)
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method:  "GET",
+			Path:    "/api/v1/totp/status",
+			Headers: map[string]string{"Authorization": "Bearer " + accessToken},
+		})
+		require.NoError(t, err)
+		var status response.Response[auth.TOTPStatusData]
+		require.NoError(t, resp.GetJSON(&status))
+		assert.True(t, status.Data.Enabled)
+		assert.Equal(t, 9, status.Data.RecoveryCodesRemaining)
+	})
+
+	t.Run("POST /api/v1/totp/recovery-codes requires the password", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/totp/recovery-codes", e2etesting.CategoryValidation, e2etesting.ValueMedium)
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method: "POST",
+			Path:   "/api/v1/totp/recovery-codes",
+			Headers: map[string]string{
+				"Authorization": "Bearer " + accessToken,
+				"Content-Type":  "application/json",
+			},
+			Body: auth.TOTPRecoveryCodesRequest{Code: codes[1], Password: "wrong-password"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 401, resp.StatusCode)
+	})
+
+	t.Run("POST /api/v1/totp/recovery-codes replaces the old set", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/totp/recovery-codes", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method: "POST",
+			Path:   "/api/v1/totp/recovery-codes",
+			Headers: map[string]string{
+				"Authorization": "Bearer " + accessToken,
+				"Content-Type":  "application/json",
+			},
+			Body: auth.TOTPRecoveryCodesRequest{Code: codes[1], Password: user.Password},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		var regenerated response.Response[auth.TOTPRecoveryCodesData]
+		require.NoError(t, resp.GetJSON(&regenerated))
+		require.Len(t, regenerated.Data.RecoveryCodes, 10)
+
+		assert.Equal(t, 401, verifyWith(codes[2]).StatusCode, "codes from the old set must no longer work")
+		assert.Equal(t, 200, verifyWith(regenerated.Data.RecoveryCodes[0]).StatusCode)
+	})
+}
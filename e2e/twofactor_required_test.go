@@ -0,0 +1,163 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"berth/internal/domain/auth"
+	"berth/internal/domain/rbac"
+	"berth/internal/pkg/response"
+
+	e2etesting "berth/e2e/internal/harness"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleRequiredTwoFactor(t *testing.T) {
+	t.Parallel()
+	app := SetupTestApp(t)
+
+	admin := &e2etesting.TestUser{
+		Username: "mfa_required_admin",
+		Email:    "mfa_required_admin@example.com",
+		Password: "password123",
+	}
+	app.CreateAdminTestUser(t, admin)
+	adminToken := loginAndIssueJWT(t, app, admin.Username, admin.Password)
+
+	member := &e2etesting.TestUser{
+		Username: "mfa_required_member",
+		Email:    "mfa_required_member@example.com",
+		Password: "password123",
+	}
+	app.AuthHelper.CreateTestUser(t, member)
+
+	adminRequest := func(method, path string, body any) *e2etesting.Response {
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method: method,
+			Path:   path,
+			Headers: map[string]string{
+				"Authorization": "Bearer " + adminToken,
+				"Content-Type":  "application/json",
+			},
+			Body: body,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	roleResp := adminRequest("POST", "/api/v1/admin/roles", map[string]any{
+		"name":               "mfa-operators",
+		"description":        "Operators must use a second factor",
+		"require_two_factor": true,
+	})
+	require.Equal(t, 201, roleResp.StatusCode, "body=%s", roleResp.GetString())
+	var role response.Response[RoleWithPermissions]
+	require.NoError(t, roleResp.GetJSON(&role))
+	require.True(t, role.Data.RequireTwoFactor)
+
+	assignResp := adminRequest("POST", "/api/v1/admin/users/assign-role", map[string]any{
+		"user_id": member.ID,
+		"role_id": role.Data.ID,
+	})
+	require.Equal(t, 200, assignResp.StatusCode)
+
+	nonCompliant := func() []rbac.TwoFactorNonCompliantUser {
+		resp := adminRequest("GET", "/api/v1/admin/users/two-factor/non-compliant", nil)
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		var report response.Response[rbac.ListTwoFactorNonCompliantData]
+		require.NoError(t, resp.GetJSON(&report))
+		return report.Data.Users
+	}
+
+	t.Run("GET /api/v1/admin/users/two-factor/non-compliant lists users without a second factor", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/admin/users/two-factor/non-compliant", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		users := nonCompliant()
+		require.Len(t, users, 1)
+		assert.Equal(t, member.Username, users[0].Username)
+		assert.Equal(t, []string{"mfa-operators"}, users[0].Roles)
+	})
+
+	var enrollmentToken string
+	t.Run("login issues a restricted enrollment token", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/auth/login", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		resp, err := app.HTTPClient.Post("/api/v1/auth/login", auth.AuthLoginRequest{
+			Username: member.Username,
+			Password: member.Password,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		for _, ck := range resp.Cookies() {
+			assert.NotEqual(t, "berth_refresh", ck.Name, "no refresh cookie until a second factor is enrolled")
+		}
+
+		var enrollment response.Response[auth.AuthTwoFactorEnrollmentData]
+		require.NoError(t, resp.GetJSON(&enrollment))
+		assert.True(t, enrollment.Data.EnrollmentRequired)
+		assert.Contains(t, enrollment.Data.EnrollmentMethods, "totp")
+		require.NotEmpty(t, enrollment.Data.EnrollmentToken)
+		enrollmentToken = enrollment.Data.EnrollmentToken
+	})
+	require.NotEmpty(t, enrollmentToken)
+
+	withEnrollment := func(method, path string, body any) *e2etesting.Response {
+		resp, err := app.HTTPClient.Request(&e2etesting.RequestOptions{
+			Method: method,
+			Path:   path,
+			Headers: map[string]string{
+				"Authorization": "Bearer " + enrollmentToken,
+				"Content-Type":  "application/json",
+			},
+			Body: body,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("enrollment token is refused outside the enrollment endpoints", func(t *testing.T) {
+		TagTest(t, "GET", "/api/v1/sessions", e2etesting.CategoryAuthorization, e2etesting.ValueHigh)
+		resp := withEnrollment("GET", "/api/v1/sessions", nil)
+		assert.Equal(t, 403, resp.StatusCode)
+
+		resp = withEnrollment("GET", "/api/v1/profile", nil)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("enrolling TOTP clears the requirement", func(t *testing.T) {
+		TagTest(t, "POST", "/api/v1/totp/enable", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		setupResp := withEnrollment("GET", "/api/v1/totp/setup", nil)
+		require.Equal(t, 200, setupResp.StatusCode, "body=%s", setupResp.GetString())
+		var setup response.Response[auth.TOTPSetupData]
+		require.NoError(t, setupResp.GetJSON(&setup))
+
+		code, err := totp.GenerateCode(setup.Data.Secret, time.Now())
+		require.NoError(t, err)
+		enableResp := withEnrollment("POST", "/api/v1/totp/enable", auth.TOTPEnableRequest{Code: code})
+		require.Equal(t, 200, enableResp.StatusCode, "body=%s", enableResp.GetString())
+
+		assert.Empty(t, nonCompliant())
+
+		resp, err := app.HTTPClient.Post("/api/v1/auth/login", auth.AuthLoginRequest{
+			Username: member.Username,
+			Password: member.Password,
+		})
+		require.NoError(t, err)
+		var challenge response.Response[auth.AuthTOTPRequiredData]
+		require.NoError(t, resp.GetJSON(&challenge))
+		assert.True(t, challenge.Data.TOTPRequired)
+	})
+
+	t.Run("PUT /api/v1/admin/roles/:id/two-factor toggles the requirement", func(t *testing.T) {
+		TagTest(t, "PUT", "/api/v1/admin/roles/:id/two-factor", e2etesting.CategoryHappyPath, e2etesting.ValueHigh)
+		resp := adminRequest("PUT", "/api/v1/admin/roles/"+Itoa(role.Data.ID)+"/two-factor", rbac.SetRoleTwoFactorRequest{Required: false})
+		require.Equal(t, 200, resp.StatusCode, "body=%s", resp.GetString())
+		var updated response.Response[RoleWithPermissions]
+		require.NoError(t, resp.GetJSON(&updated))
+		assert.False(t, updated.Data.RequireTwoFactor)
+
+		resp = adminRequest("PUT", "/api/v1/admin/roles/999999/two-factor", rbac.SetRoleTwoFactorRequest{Required: true})
+		assert.Equal(t, 404, resp.StatusCode)
+	})
+}
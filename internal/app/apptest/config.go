@@ -49,6 +49,10 @@ func BuildConfig(t *testing.T, modifiers ...func(*config.Config)) *config.Config
 			EmailVerificationEnabled:     false,
 			EmailVerificationTokenLength: 32,
 			EmailVerificationExpiry:      time.Hour,
+			InvitationExpiry:             72 * time.Hour,
+			RoleExpiryInterval:           time.Minute,
+			AccessRequestMaxDuration:     24 * time.Hour,
+			AccessRequestExpiryInterval:  time.Minute,
 		},
 		JWT: config.JWTConfig{
 			SecretKey:    "test-secret-key-for-testing-only",
@@ -75,7 +79,15 @@ func BuildConfig(t *testing.T, modifiers ...func(*config.Config)) *config.Config
 			Development: true,
 		},
 		RateLimit: config.RateLimitConfig{
-			Enabled: false,
+			Enabled:      false,
+			AuthRate:     25,
+			AuthPeriod:   time.Minute,
+			APIRate:      1000,
+			APIPeriod:    10 * time.Minute,
+			UserRate:     1000,
+			UserPeriod:   10 * time.Minute,
+			APIKeyRate:   1000,
+			APIKeyPeriod: 10 * time.Minute,
 		},
 		Mail: config.MailConfig{
 			FromAddress: "test@example.com",
@@ -61,6 +61,8 @@ func (s *spySecurityAuditor) getEvents() []*security.SecurityAuditLog {
 	return append([]*security.SecurityAuditLog{}, s.events...)
 }
 
+func uintPtr(v uint) *uint { return &v }
+
 func setupTestDB(t *testing.T) *gorm.DB {
 	t.Helper()
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
@@ -82,7 +84,7 @@ func TestRegisterAuditCallbacks_OperationLogCreate(t *testing.T) {
 	})
 
 	log := &operationlogs.OperationLog{
-		UserID:      1,
+		UserID:      uintPtr(1),
 		ServerID:    1,
 		StackName:   "test-stack",
 		OperationID: "op-123",
@@ -112,7 +114,7 @@ func TestRegisterAuditCallbacks_OperationLogUpdate(t *testing.T) {
 	})
 
 	log := &operationlogs.OperationLog{
-		UserID:      1,
+		UserID:      uintPtr(1),
 		ServerID:    1,
 		StackName:   "test-stack",
 		OperationID: "op-456",
@@ -178,7 +180,7 @@ func TestRegisterAuditCallbacks_NilLoggersNoError(t *testing.T) {
 	})
 
 	log := &operationlogs.OperationLog{
-		UserID:      1,
+		UserID:      uintPtr(1),
 		ServerID:    1,
 		StackName:   "test-stack",
 		OperationID: "op-789",
@@ -217,7 +219,7 @@ func TestRegisterAuditCallbacks_DoesNotFireForOtherTables(t *testing.T) {
 	assert.Empty(t, opSpy.getUpdates(), "operation update callback should not fire for security audit log")
 
 	opLog := &operationlogs.OperationLog{
-		UserID:      1,
+		UserID:      uintPtr(1),
 		ServerID:    1,
 		StackName:   "test-stack",
 		OperationID: "op-cross",
@@ -55,7 +55,7 @@ func TestBoot_AuditCallbacksFireOnInsert(t *testing.T) {
 	require.NoError(t, booted.DB.Create(&srv).Error)
 
 	require.NoError(t, booted.DB.Create(&operationlogs.OperationLog{
-		UserID:      usr.ID,
+		UserID:      &usr.ID,
 		ServerID:    srv.ID,
 		StackName:   "stack-x",
 		OperationID: "op-1",
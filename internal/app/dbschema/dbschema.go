@@ -2,14 +2,31 @@ package dbschema
 
 import (
 	"berth/internal/domain/auth"
+	"berth/internal/domain/auth/ldap"
+	"berth/internal/domain/auth/lockout"
+	"berth/internal/domain/auth/oidc"
+	"berth/internal/domain/auth/passkey"
 	"berth/internal/domain/auth/tokens"
 	"berth/internal/domain/auth/totp"
+	"berth/internal/domain/autoupdates"
+	"berth/internal/domain/backupretention"
+	"berth/internal/domain/backupschedules"
 	"berth/internal/domain/imageupdates"
+	"berth/internal/domain/invitations"
+	"berth/internal/domain/leader"
+	"berth/internal/domain/maintwindows"
 	"berth/internal/domain/operationlogs"
+	"berth/internal/domain/operationschedules"
+	"berth/internal/domain/prunepolicies"
+	"berth/internal/domain/scanschedules"
 	"berth/internal/domain/security"
 	"berth/internal/domain/server"
 	"berth/internal/domain/session"
+	"berth/internal/domain/updatedigests"
+	"berth/internal/domain/vulnalerts"
 	"berth/internal/domain/vulnscan"
+	"berth/internal/domain/webhooks"
+	"berth/internal/platform/middleware/ratelimit"
 	"berth/seeds"
 )
 
@@ -21,8 +38,25 @@ func Models() []any {
 		&session.UserSession{},
 		&imageupdates.ContainerImageUpdate{},
 		&vulnscan.ImageScan{}, &vulnscan.ImageVulnerability{}, &vulnscan.ScanScope{}, &vulnscan.ScanServiceImage{},
-		&totp.TOTPSecret{}, &totp.UsedCode{},
+		&totp.TOTPSecret{}, &totp.UsedCode{}, &totp.RecoveryCode{},
 		&auth.PasswordResetToken{}, &auth.EmailVerificationToken{},
-		&tokens.RevokedToken{}, &tokens.RefreshToken{},
+		&tokens.RevokedToken{}, &tokens.RefreshToken{}, &tokens.SigningKey{},
+		&ratelimit.Counter{},
+		&oidc.Identity{}, &oidc.LoginState{},
+		&ldap.Identity{},
+		&lockout.AccountLockout{},
+		&invitations.Invitation{},
+		&leader.Lease{},
+		&passkey.Credential{}, &passkey.Ceremony{},
+		&backupschedules.BackupSchedule{},
+		&backupretention.RetentionPolicy{},
+		&operationschedules.OperationSchedule{}, &operationschedules.OperationScheduleRun{},
+		&scanschedules.ScanSchedule{},
+		&autoupdates.UpdatePolicy{}, &autoupdates.UpdateHistory{},
+		&maintwindows.MaintenanceWindow{},
+		&prunepolicies.PrunePolicy{},
+		&webhooks.Endpoint{}, &webhooks.Delivery{},
+		&updatedigests.Subscription{},
+		&vulnalerts.AlertRule{}, &vulnalerts.Alert{},
 	)
 }
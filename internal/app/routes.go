@@ -3,28 +3,42 @@ package app
 import (
 	"net/http"
 	"path/filepath"
-	"time"
 
+	"berth/internal/domain/accessrequests"
 	"berth/internal/domain/apikey"
 	"berth/internal/domain/auth"
+	"berth/internal/domain/auth/lockout"
+	"berth/internal/domain/auth/proxyauth"
 	"berth/internal/domain/auth/tokens"
 	"berth/internal/domain/authz"
 	authzengine "berth/internal/domain/authz/engine"
+	"berth/internal/domain/autoupdates"
+	"berth/internal/domain/backupretention"
 	"berth/internal/domain/backups"
+	"berth/internal/domain/backupschedules"
 	"berth/internal/domain/dataexport"
 	"berth/internal/domain/files"
 	"berth/internal/domain/imageupdates"
+	"berth/internal/domain/invitations"
+	"berth/internal/domain/leader"
 	"berth/internal/domain/logs"
 	"berth/internal/domain/maintenance"
+	"berth/internal/domain/maintwindows"
 	"berth/internal/domain/operationlogs"
 	"berth/internal/domain/operations"
+	"berth/internal/domain/operationschedules"
+	"berth/internal/domain/prunepolicies"
 	"berth/internal/domain/rbac"
 	"berth/internal/domain/registry"
+	"berth/internal/domain/scanschedules"
 	"berth/internal/domain/security"
 	"berth/internal/domain/server"
 	"berth/internal/domain/stack"
+	"berth/internal/domain/updatedigests"
 	"berth/internal/domain/version"
+	"berth/internal/domain/vulnalerts"
 	"berth/internal/domain/vulnscan"
+	"berth/internal/domain/webhooks"
 	"berth/internal/domain/websocket"
 	"berth/internal/pkg/config"
 	"berth/internal/platform/httperr"
@@ -34,6 +48,13 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 )
 
+// apiRateLimits are the limits in front of the authenticated API: ip runs
+// before authentication, principal after it.
+type apiRateLimits struct {
+	ip        echo.MiddlewareFunc
+	principal []echo.MiddlewareFunc
+}
+
 func newRateLimit(cfg *config.Config, rlc ratelimit.Config) echo.MiddlewareFunc {
 	if !cfg.RateLimit.Enabled {
 		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
@@ -59,13 +80,16 @@ func registerRoutes(g *Graph) {
 		return
 	}
 
+	e.GET("/.well-known/jwks.json", g.AuthAPIHandler.JWKS)
+
 	api := e.Group("/api/v1")
 
+	rl := g.Cfg.RateLimit
 	authApiRateLimit := newRateLimit(g.Cfg, ratelimit.Config{
 		Store:     g.RateLimit,
 		Name:      "api_auth",
-		Rate:      25,
-		Period:    time.Minute,
+		Rate:      rl.AuthRate,
+		Period:    rl.AuthPeriod,
 		CountMode: ratelimit.CountNon2xx,
 		KeyFunc:   ratelimit.KeyByIP,
 	})
@@ -73,24 +97,49 @@ func registerRoutes(g *Graph) {
 	generalApiRateLimit := newRateLimit(g.Cfg, ratelimit.Config{
 		Store:     g.RateLimit,
 		Name:      "api_general",
-		Rate:      1000,
-		Period:    time.Minute * 10,
-		CountMode: ratelimit.CountAll,
+		Rate:      rl.APIRate,
+		Period:    rl.APIPeriod,
+		CountMode: ratelimit.CountNon2xx,
 		KeyFunc:   ratelimit.KeyByIP,
 	})
 
+	userRateLimit := newRateLimit(g.Cfg, ratelimit.Config{
+		Store:     g.RateLimit,
+		Name:      "api_user",
+		Rate:      rl.UserRate,
+		Period:    rl.UserPeriod,
+		CountMode: ratelimit.CountAll,
+		KeyFunc:   authz.RateLimitKeyByUser,
+	})
+
+	apiKeyRateLimit := newRateLimit(g.Cfg, ratelimit.Config{
+		Store:     g.RateLimit,
+		Name:      "api_key",
+		Rate:      rl.APIKeyRate,
+		Period:    rl.APIKeyPeriod,
+		CountMode: ratelimit.CountAll,
+		KeyFunc:   authz.RateLimitKeyByAPIKey,
+		RateFunc:  authz.APIKeyRateLimit,
+	})
+
+	rateLimits := apiRateLimits{ip: generalApiRateLimit, principal: []echo.MiddlewareFunc{userRateLimit, apiKeyRateLimit}}
+
 	authzEngine := g.AuthzEngine
 
-	publicRegistrar := registerAPIAuthRoutes(api, authApiRateLimit, g.AuthAPIHandler, authzEngine)
-	protectedRegistrar := registerProtectedAPIRoutes(api, generalApiRateLimit, g.JWTSvc, g.APIKeySvc, g.AuthUserProv, g.SecurityAuditSvc,
+	publicRegistrar := registerAPIAuthRoutes(api, authApiRateLimit, g.AuthAPIHandler, g.InvitationsHandler, authzEngine)
+	protectedRegistrar := registerProtectedAPIRoutes(api, rateLimits, g.JWTSvc, g.APIKeySvc, g.ProxyAuthSvc, g.AuthUserProv, g.SecurityAuditSvc,
 		g.AuthAPIHandler, g.ServerUserAPIHandler, authzEngine,
 		g.StackAPIHandler, g.FilesAPIHandler, g.BackupsAPIHandler, g.LogsHandler, g.OperationsHandler,
 		g.OperationLogsHandler, g.MaintAPIHandler, g.VulnscanHandler,
-		g.ImageUpdatesAPIHandler, g.APIKeyHandler, g.VersionHandler, g.RegistryAPIHandler)
-	adminRegistrar := registerAdminAPIRoutes(api, generalApiRateLimit, g.JWTSvc, g.APIKeySvc, g.AuthUserProv, g.SecurityAuditSvc,
+		g.ImageUpdatesAPIHandler, g.APIKeyHandler, g.VersionHandler, g.RegistryAPIHandler,
+		g.BackupSchedulesHandler, g.BackupRetentionHandler, g.OperationSchedulesHandler, g.ScanSchedulesHandler,
+		g.AutoUpdatesHandler, g.MaintWindowsHandler, g.PrunePoliciesHandler, g.UpdateDigestsHandler, g.VulnAlertsHandler,
+		g.AccessRequestsHandler)
+	adminRegistrar := registerAdminAPIRoutes(api, rateLimits, g.JWTSvc, g.APIKeySvc, g.ProxyAuthSvc, g.AuthUserProv, g.SecurityAuditSvc,
 		g.RBACAPIHandler, g.OperationLogsHandler,
-		g.ServerAPIHandler, g.DataExportHandler, g.SecurityHandler, authzEngine)
-	wsRegistrar := registerAPIWebSocketRoutes(e, g.JWTSvc, g.APIKeySvc, g.AuthUserProv, g.SecurityAuditSvc, g.WSHandler, g.WSEventsHandler, g.OperationsStreamHandler, authzEngine)
+		g.ServerAPIHandler, g.DataExportHandler, g.SecurityHandler, g.ScanSchedulesHandler, g.MaintWindowsHandler,
+		g.WebhooksHandler, g.VulnAlertsHandler, g.LockoutHandler, g.InvitationsHandler, g.LeaderHandler, g.APIKeyHandler, authzEngine)
+	wsRegistrar := registerAPIWebSocketRoutes(e, g.JWTSvc, g.APIKeySvc, g.ProxyAuthSvc, g.AuthUserProv, g.SecurityAuditSvc, g.WSHandler, g.WSEventsHandler, g.OperationsStreamHandler, authzEngine)
 
 	auditRegistrars := []*authz.Registrar{publicRegistrar, protectedRegistrar, adminRegistrar}
 	if wsRegistrar != nil {
@@ -122,24 +171,33 @@ func registerStaticAssetRoutes(e *echo.Echo, publicDir string) {
 	e.GET("/pwa/*", echo.WrapHandler(http.StripPrefix("/pwa/", http.FileServer(http.Dir(pwaDir)))))
 }
 
-func registerAPIAuthRoutes(api *echo.Group, authApiRateLimit echo.MiddlewareFunc, mobileAuthHandler *auth.APIHandler, authzEngine *authzengine.Engine) *authz.Registrar {
+func registerAPIAuthRoutes(api *echo.Group, authApiRateLimit echo.MiddlewareFunc, mobileAuthHandler *auth.APIHandler, invitationsHandler *invitations.APIHandler, authzEngine *authzengine.Engine) *authz.Registrar {
 	authApi := api.Group("/auth")
 	authApi.Use(authApiRateLimit)
 	publicRegistrar := authz.NewRegistrar(authApi, "/api/v1/auth", authzEngine.Middleware)
 	mobileAuthHandler.RegisterPublicAPIRoutes(publicRegistrar)
+	if invitationsHandler != nil {
+		invitationsHandler.RegisterPublicAPIRoutes(publicRegistrar)
+	}
 	return publicRegistrar
 }
 
-func registerProtectedAPIRoutes(api *echo.Group, generalApiRateLimit echo.MiddlewareFunc, jwtSvc *tokens.Service, apiKeySvc *apikey.Service, userProvider auth.UserProvider, auditor auth.APIKeyAuthAuditor,
+func registerProtectedAPIRoutes(api *echo.Group, rateLimits apiRateLimits, jwtSvc *tokens.Service, apiKeySvc *apikey.Service, proxyAuthSvc *proxyauth.Service, userProvider auth.UserProvider, auditor auth.APIKeyAuthAuditor,
 	mobileAuthHandler *auth.APIHandler, serverUserAPIHandler *server.UserAPIHandler,
 	authzEngine *authzengine.Engine, stackAPIHandler *stack.APIHandler, filesAPIHandler *files.APIHandler, backupsAPIHandler *backups.APIHandler, logsHandler *logs.Handler,
 	operationsHandler *operations.Handler, operationLogsHandler *operationlogs.Handler, maintenanceAPIHandler *maintenance.APIHandler,
 	vulnscanHandler *vulnscan.Handler, imageUpdatesAPIHandler *imageupdates.APIHandler, apiKeyHandler *apikey.Handler,
-	versionHandler *version.Handler, registryAPIHandler *registry.APIHandler) *authz.Registrar {
+	versionHandler *version.Handler, registryAPIHandler *registry.APIHandler,
+	backupSchedulesHandler *backupschedules.APIHandler, backupRetentionHandler *backupretention.APIHandler,
+	operationSchedulesHandler *operationschedules.APIHandler, scanSchedulesHandler *scanschedules.APIHandler,
+	autoUpdatesHandler *autoupdates.APIHandler, maintWindowsHandler *maintwindows.APIHandler,
+	prunePoliciesHandler *prunepolicies.APIHandler, updateDigestsHandler *updatedigests.APIHandler,
+	vulnAlertsHandler *vulnalerts.APIHandler, accessRequestsHandler *accessrequests.APIHandler) *authz.Registrar {
 
 	apiProtected := api.Group("")
-	apiProtected.Use(generalApiRateLimit)
-	apiProtected.Use(auth.RequireAuth(jwtSvc, apiKeySvc, userProvider, auditor))
+	apiProtected.Use(rateLimits.ip)
+	apiProtected.Use(auth.RequireAuth(jwtSvc, apiKeySvc, proxyAuthSvc, userProvider, auditor))
+	apiProtected.Use(rateLimits.principal...)
 
 	protectedRegistrar := authz.NewRegistrar(apiProtected, "/api/v1", authzEngine.Middleware)
 
@@ -185,22 +243,54 @@ func registerProtectedAPIRoutes(api *echo.Group, generalApiRateLimit echo.Middle
 	if registryAPIHandler != nil {
 		registryAPIHandler.RegisterProtectedAPIRoutes(protectedRegistrar)
 	}
+	if backupSchedulesHandler != nil {
+		backupSchedulesHandler.RegisterProtectedAPIRoutes(protectedRegistrar)
+	}
+	if backupRetentionHandler != nil {
+		backupRetentionHandler.RegisterProtectedAPIRoutes(protectedRegistrar)
+	}
+	if operationSchedulesHandler != nil {
+		operationSchedulesHandler.RegisterProtectedAPIRoutes(protectedRegistrar)
+	}
+	if scanSchedulesHandler != nil {
+		scanSchedulesHandler.RegisterProtectedAPIRoutes(protectedRegistrar)
+	}
+	if autoUpdatesHandler != nil {
+		autoUpdatesHandler.RegisterProtectedAPIRoutes(protectedRegistrar)
+	}
+	if maintWindowsHandler != nil {
+		maintWindowsHandler.RegisterProtectedAPIRoutes(protectedRegistrar)
+	}
+	if prunePoliciesHandler != nil {
+		prunePoliciesHandler.RegisterProtectedAPIRoutes(protectedRegistrar)
+	}
+	if updateDigestsHandler != nil {
+		updateDigestsHandler.RegisterProtectedAPIRoutes(protectedRegistrar)
+	}
+	if vulnAlertsHandler != nil {
+		vulnAlertsHandler.RegisterProtectedAPIRoutes(protectedRegistrar)
+	}
+	if accessRequestsHandler != nil {
+		accessRequestsHandler.RegisterProtectedAPIRoutes(protectedRegistrar)
+	}
 
 	return protectedRegistrar
 }
 
-func registerAdminAPIRoutes(api *echo.Group, generalApiRateLimit echo.MiddlewareFunc, jwtSvc *tokens.Service, apiKeySvc *apikey.Service, userProvider auth.UserProvider, auditor auth.APIKeyAuthAuditor,
+func registerAdminAPIRoutes(api *echo.Group, rateLimits apiRateLimits, jwtSvc *tokens.Service, apiKeySvc *apikey.Service, proxyAuthSvc *proxyauth.Service, userProvider auth.UserProvider, auditor auth.APIKeyAuthAuditor,
 	rbacAPIHandler *rbac.APIHandler, operationLogsHandler *operationlogs.Handler,
 	serverAPIHandler *server.APIHandler, migrationHandler *dataexport.Handler, securityHandler *security.Handler,
-	authzEngine *authzengine.Engine) *authz.Registrar {
+	scanSchedulesHandler *scanschedules.APIHandler, maintWindowsHandler *maintwindows.APIHandler,
+	webhooksHandler *webhooks.APIHandler, vulnAlertsHandler *vulnalerts.APIHandler, lockoutHandler *lockout.APIHandler, invitationsHandler *invitations.APIHandler, leaderHandler *leader.APIHandler, apiKeyHandler *apikey.Handler, authzEngine *authzengine.Engine) *authz.Registrar {
 
 	if rbacAPIHandler == nil {
 		return nil
 	}
 
 	apiProtected := api.Group("")
-	apiProtected.Use(generalApiRateLimit)
-	apiProtected.Use(auth.RequireAuth(jwtSvc, apiKeySvc, userProvider, auditor))
+	apiProtected.Use(rateLimits.ip)
+	apiProtected.Use(auth.RequireAuth(jwtSvc, apiKeySvc, proxyAuthSvc, userProvider, auditor))
+	apiProtected.Use(rateLimits.principal...)
 
 	apiAdmin := apiProtected.Group("/admin")
 	adminRegistrar := authz.NewRegistrar(apiAdmin, "/api/v1/admin", authzEngine.Middleware)
@@ -218,18 +308,42 @@ func registerAdminAPIRoutes(api *echo.Group, generalApiRateLimit echo.Middleware
 	if securityHandler != nil {
 		securityHandler.RegisterAdminAPIRoutes(adminRegistrar)
 	}
+	if scanSchedulesHandler != nil {
+		scanSchedulesHandler.RegisterAdminAPIRoutes(adminRegistrar)
+	}
+	if maintWindowsHandler != nil {
+		maintWindowsHandler.RegisterAdminAPIRoutes(adminRegistrar)
+	}
+	if webhooksHandler != nil {
+		webhooksHandler.RegisterAdminAPIRoutes(adminRegistrar)
+	}
+	if vulnAlertsHandler != nil {
+		vulnAlertsHandler.RegisterAdminAPIRoutes(adminRegistrar)
+	}
+	if lockoutHandler != nil {
+		lockoutHandler.RegisterAdminAPIRoutes(adminRegistrar)
+	}
+	if invitationsHandler != nil {
+		invitationsHandler.RegisterAdminAPIRoutes(adminRegistrar)
+	}
+	if leaderHandler != nil {
+		leaderHandler.RegisterAdminAPIRoutes(adminRegistrar)
+	}
+	if apiKeyHandler != nil {
+		apiKeyHandler.RegisterAdminAPIRoutes(adminRegistrar)
+	}
 
 	return adminRegistrar
 }
 
-func registerAPIWebSocketRoutes(srv *echo.Echo, jwtSvc *tokens.Service, apiKeySvc *apikey.Service, userProvider auth.UserProvider, auditor auth.APIKeyAuthAuditor, wsHandler *websocket.Handler, eventsHandler *websocket.EventsHandler, operationsStreamHandler *operations.StreamHandler, authzEngine *authzengine.Engine) *authz.Registrar {
+func registerAPIWebSocketRoutes(srv *echo.Echo, jwtSvc *tokens.Service, apiKeySvc *apikey.Service, proxyAuthSvc *proxyauth.Service, userProvider auth.UserProvider, auditor auth.APIKeyAuthAuditor, wsHandler *websocket.Handler, eventsHandler *websocket.EventsHandler, operationsStreamHandler *operations.StreamHandler, authzEngine *authzengine.Engine) *authz.Registrar {
 	if wsHandler == nil {
 		return nil
 	}
 
 	wsGroup := srv.Group("/ws")
 	wsAPIGroup := wsGroup.Group("/api")
-	wsAPIGroup.Use(auth.RequireAuth(jwtSvc, apiKeySvc, userProvider, auditor))
+	wsAPIGroup.Use(auth.RequireAuth(jwtSvc, apiKeySvc, proxyAuthSvc, userProvider, auditor))
 
 	wsRegistrar := authz.NewRegistrar(wsAPIGroup, "/ws/api", authzEngine.Middleware)
 	wsHandler.RegisterProtectedAPIRoutes(wsRegistrar)
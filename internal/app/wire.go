@@ -7,34 +7,53 @@ import (
 	"io"
 	"path/filepath"
 
+	"berth/internal/domain/accessrequests"
 	"berth/internal/domain/agent"
 	"berth/internal/domain/apikey"
 	"berth/internal/domain/auth"
+	"berth/internal/domain/auth/ldap"
+	"berth/internal/domain/auth/lockout"
+	"berth/internal/domain/auth/oidc"
+	"berth/internal/domain/auth/passkey"
+	"berth/internal/domain/auth/proxyauth"
 	"berth/internal/domain/auth/tokens"
 	"berth/internal/domain/auth/totp"
 	authzengine "berth/internal/domain/authz/engine"
+	"berth/internal/domain/autoupdates"
+	"berth/internal/domain/backupretention"
 	"berth/internal/domain/backups"
+	"berth/internal/domain/backupschedules"
 	"berth/internal/domain/dataexport"
 	"berth/internal/domain/files"
 	"berth/internal/domain/imageupdates"
+	"berth/internal/domain/invitations"
+	"berth/internal/domain/leader"
 	"berth/internal/domain/logs"
 	"berth/internal/domain/maintenance"
+	"berth/internal/domain/maintwindows"
 	"berth/internal/domain/operationlogs"
 	"berth/internal/domain/operations"
+	"berth/internal/domain/operationschedules"
+	"berth/internal/domain/prunepolicies"
 	"berth/internal/domain/rbac"
 	"berth/internal/domain/registry"
+	"berth/internal/domain/scanschedules"
 	"berth/internal/domain/security"
 	"berth/internal/domain/server"
 	"berth/internal/domain/session"
 	"berth/internal/domain/setup"
 	"berth/internal/domain/stack"
+	"berth/internal/domain/updatedigests"
 	"berth/internal/domain/version"
+	"berth/internal/domain/vulnalerts"
 	"berth/internal/domain/vulnscan"
+	"berth/internal/domain/webhooks"
 	"berth/internal/domain/websocket"
 	"berth/internal/pkg/apidocs"
 	"berth/internal/pkg/config"
 	"berth/internal/pkg/crypto"
 	"berth/internal/pkg/origin"
+	"berth/internal/pkg/periodic"
 	"berth/internal/platform/mail"
 	"berth/internal/platform/middleware/ratelimit"
 	"berth/internal/platform/retention"
@@ -72,15 +91,26 @@ type Graph struct {
 	Mail        auth.MailService
 	Crypto      *crypto.Crypto
 	OriginCheck origin.CheckOriginFunc
-	RateLimit   *ratelimit.Store
+	RateLimit   ratelimit.Store
 	APIDocs     *apidocs.OpenAPI
 
-	JWTSvc         *tokens.Service
-	SessionSvc     *session.Service
-	AuthSvc        *auth.Service
-	AuthUserProv   auth.UserProvider
-	TOTPSvc        *totp.Service
-	AuthAPIHandler *auth.APIHandler
+	LeaderSvc     *leader.Service
+	LeaderHandler *leader.APIHandler
+
+	JWTSvc             *tokens.Service
+	SessionSvc         *session.Service
+	AuthSvc            *auth.Service
+	AuthUserProv       auth.UserProvider
+	TOTPSvc            *totp.Service
+	OIDCSvc            *oidc.Service
+	LDAPSvc            *ldap.Service
+	ProxyAuthSvc       *proxyauth.Service
+	LockoutSvc         *lockout.Service
+	LockoutHandler     *lockout.APIHandler
+	InvitationsSvc     *invitations.Service
+	InvitationsHandler *invitations.APIHandler
+	PasskeySvc         *passkey.Service
+	AuthAPIHandler     *auth.APIHandler
 
 	OperationsSummaryParser *operations.SummaryParser
 	OperationsAuditLogger   *operations.AuditLogger
@@ -93,43 +123,74 @@ type Graph struct {
 	SecurityAuditSvc    *security.AuditService
 	SecurityHandler     *security.Handler
 
-	AgentSvc               *agent.Service
-	RBACSvc                *rbac.Service
-	AuthzEngine            *authzengine.Engine
-	RBACAPIHandler         *rbac.APIHandler
-	APIKeySvc              *apikey.Service
-	APIKeyHandler          *apikey.Handler
-	SetupSvc               *setup.Service
-	ServerSvc              *server.Service
-	ServerAPIHandler       *server.APIHandler
-	ServerUserAPIHandler   *server.UserAPIHandler
-	StackSvc               *stack.Service
-	StackAPIHandler        *stack.APIHandler
-	MaintSvc               *maintenance.Service
-	MaintAPIHandler        *maintenance.APIHandler
-	FilesSvc               *files.Service
-	FilesAPIHandler        *files.APIHandler
-	BackupsSvc             *backups.Service
-	BackupsAPIHandler      *backups.APIHandler
-	LogsSvc                *logs.Service
-	LogsHandler            *logs.Handler
-	RegistrySvc            *registry.Service
-	RegistryAPIHandler     *registry.APIHandler
-	OperationLogsSvc       *operationlogs.Service
-	OperationLogsHandler   *operationlogs.Handler
-	DataExportSvc          *dataexport.Service
-	DataExportHandler      *dataexport.Handler
-	ImageUpdatesSvc        *imageupdates.Service
-	ImageUpdatesAPIHandler *imageupdates.APIHandler
-	VersionHandler         *version.Handler
-	VulnscanSvc            *vulnscan.Service
-	VulnscanHandler        *vulnscan.Handler
-	VulnscanPoller         *vulnscan.Poller
-	WSEventRegistry        *websocket.StackEventRegistry
-	WSEventsHandler        *websocket.EventsHandler
-	WSAgentMgr             *websocket.AgentManager
-	WSServiceMgr           *websocket.ServiceManager
-	WSHandler              *websocket.Handler
+	AgentSvc                  *agent.Service
+	RBACSvc                   *rbac.Service
+	AuthzEngine               *authzengine.Engine
+	RBACAPIHandler            *rbac.APIHandler
+	RoleExpiryWorker          *periodic.Runner
+	APIKeySvc                 *apikey.Service
+	APIKeyHandler             *apikey.Handler
+	SetupSvc                  *setup.Service
+	ServerSvc                 *server.Service
+	ServerAPIHandler          *server.APIHandler
+	ServerUserAPIHandler      *server.UserAPIHandler
+	StackSvc                  *stack.Service
+	StackAPIHandler           *stack.APIHandler
+	MaintSvc                  *maintenance.Service
+	MaintAPIHandler           *maintenance.APIHandler
+	PrunePoliciesSvc          *prunepolicies.Service
+	PrunePoliciesHandler      *prunepolicies.APIHandler
+	PruneScheduler            *periodic.Runner
+	FilesSvc                  *files.Service
+	FilesAPIHandler           *files.APIHandler
+	BackupsSvc                *backups.Service
+	BackupsAPIHandler         *backups.APIHandler
+	BackupSchedulesSvc        *backupschedules.Service
+	BackupSchedulesHandler    *backupschedules.APIHandler
+	BackupScheduler           *periodic.Runner
+	BackupRetentionSvc        *backupretention.Service
+	BackupRetentionHandler    *backupretention.APIHandler
+	MaintWindowsSvc           *maintwindows.Service
+	MaintWindowsHandler       *maintwindows.APIHandler
+	AccessRequestsSvc         *accessrequests.Service
+	AccessRequestsHandler     *accessrequests.APIHandler
+	AccessRequestExpiryWorker *periodic.Runner
+	OperationSchedulesSvc     *operationschedules.Service
+	OperationSchedulesHandler *operationschedules.APIHandler
+	OperationScheduler        *periodic.Runner
+	LogsSvc                   *logs.Service
+	LogsHandler               *logs.Handler
+	RegistrySvc               *registry.Service
+	RegistryAPIHandler        *registry.APIHandler
+	OperationLogsSvc          *operationlogs.Service
+	OperationLogsHandler      *operationlogs.Handler
+	DataExportSvc             *dataexport.Service
+	DataExportHandler         *dataexport.Handler
+	ImageUpdatesSvc           *imageupdates.Service
+	ImageUpdatesAPIHandler    *imageupdates.APIHandler
+	AutoUpdatesSvc            *autoupdates.Service
+	AutoUpdatesHandler        *autoupdates.APIHandler
+	AutoUpdateWorker          *periodic.Runner
+	UpdateDigestsSvc          *updatedigests.Service
+	UpdateDigestsHandler      *updatedigests.APIHandler
+	UpdateDigestScheduler     *periodic.Runner
+	VersionHandler            *version.Handler
+	VulnscanSvc               *vulnscan.Service
+	VulnscanHandler           *vulnscan.Handler
+	VulnscanPoller            *vulnscan.Poller
+	ScanSchedulesSvc          *scanschedules.Service
+	ScanSchedulesHandler      *scanschedules.APIHandler
+	ScanScheduler             *periodic.Runner
+	VulnAlertsSvc             *vulnalerts.Service
+	VulnAlertsHandler         *vulnalerts.APIHandler
+	WSEventRegistry           *websocket.StackEventRegistry
+	WSEventsHandler           *websocket.EventsHandler
+	WSAgentMgr                *websocket.AgentManager
+	WSServiceMgr              *websocket.ServiceManager
+	WSHandler                 *websocket.Handler
+	WebhooksSvc               *webhooks.Service
+	WebhooksHandler           *webhooks.APIHandler
+	WebhookDispatcher         *webhooks.Dispatcher
 
 	RetentionWorker *retention.Worker
 
@@ -156,12 +217,24 @@ func Build(
 
 	g.Crypto = crypto.NewCrypto(cfg.Custom.EncryptionSecret)
 	g.OriginCheck = origin.NewOriginChecker(cfg.App.URL)
-	g.RateLimit = ratelimit.NewStore()
+	if cfg.RateLimit.Store == "database" {
+		store, err := ratelimit.NewSQLStore(db, logger)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit store: %w", err)
+		}
+		g.RateLimit = store
+	} else {
+		g.RateLimit = ratelimit.NewMemoryStore()
+	}
 	g.addHook("rate limit store cleanup", nil, func(context.Context) error {
 		g.RateLimit.Stop()
 		return nil
 	})
 	g.APIDocs = apidocs.NewOpenAPI()
+
+	g.LeaderSvc = leader.NewService(db, cfg.Leader, logger)
+	g.LeaderHandler = leader.NewAPIHandler(g.LeaderSvc)
+	g.addHook("leader election", g.LeaderSvc.Start, g.LeaderSvc.Stop)
 	g.VersionHandler = version.NewHandler()
 
 	if overrides.Mail != nil {
@@ -174,11 +247,12 @@ func Build(
 		g.Mail = client
 	}
 
-	jwtSvc, err := tokens.NewService(cfg, db, logger)
+	jwtSvc, err := tokens.NewService(cfg, db, g.Crypto, logger)
 	if err != nil {
 		return nil, fmt.Errorf("tokens service: %w", err)
 	}
 	g.JWTSvc = jwtSvc
+	jwtSvc.SetLeaderGate(g.LeaderSvc.Gate(leader.LeaseTokenMaintenance))
 	g.addHook("tokens cleanup worker", jwtSvc.Start, jwtSvc.Stop)
 
 	g.SessionSvc = session.ProvideSessionService(db, jwtSvc, logger)
@@ -214,6 +288,51 @@ func Build(
 		userSessionRevoker = g.SessionSvc
 	}
 	g.RBACAPIHandler = rbac.NewAPIHandler(db, g.RBACSvc, g.TOTPSvc, g.AuthSvc, g.SecurityAuditSvc, userSessionRevoker)
+	g.RoleExpiryWorker = rbac.NewExpiryWorker(g.RBACSvc, userSessionRevoker, g.SecurityAuditSvc, cfg.Auth.RoleExpiryInterval, logger)
+	g.RoleExpiryWorker.SetLeaderGate(g.LeaderSvc.Gate(leader.LeaseRoleExpiry))
+	g.addHook("role expiry worker",
+		func(context.Context) error { g.RoleExpiryWorker.Start(); return nil },
+		func(context.Context) error { g.RoleExpiryWorker.Stop(); return nil },
+	)
+
+	if cfg.OIDC.Enabled {
+		g.OIDCSvc, err = oidc.NewService(cfg, db, g.RBACSvc, logger)
+		if err != nil {
+			return nil, fmt.Errorf("oidc service: %w", err)
+		}
+		g.AuthAPIHandler.SetOIDCService(g.OIDCSvc)
+	}
+
+	if cfg.LDAP.Enabled {
+		g.LDAPSvc, err = ldap.NewService(cfg, db, g.RBACSvc, logger)
+		if err != nil {
+			return nil, fmt.Errorf("ldap service: %w", err)
+		}
+		g.AuthAPIHandler.SetLDAPService(g.LDAPSvc)
+	}
+
+	if cfg.ProxyAuth.Enabled {
+		g.ProxyAuthSvc, err = proxyauth.NewService(cfg, db, g.RBACSvc, g.SecurityAuditSvc, logger)
+		if err != nil {
+			return nil, fmt.Errorf("proxy auth service: %w", err)
+		}
+		g.ProxyAuthSvc.SetTwoFactorPolicy(g.AuthSvc)
+	}
+
+	g.LockoutSvc = lockout.NewService(cfg, db, g.Mail, logger)
+	g.LockoutHandler = lockout.NewAPIHandler(g.LockoutSvc, db, g.SecurityAuditSvc)
+	g.AuthAPIHandler.SetLockoutService(g.LockoutSvc)
+
+	g.InvitationsSvc = invitations.NewService(cfg, db, g.Mail, g.AuthSvc, logger)
+	g.InvitationsHandler = invitations.NewAPIHandler(g.InvitationsSvc, g.SecurityAuditSvc, logger)
+
+	if cfg.WebAuthn.Enabled {
+		g.PasskeySvc, err = passkey.NewService(cfg, db, logger)
+		if err != nil {
+			return nil, fmt.Errorf("passkey service: %w", err)
+		}
+		g.AuthAPIHandler.SetPasskeyService(g.PasskeySvc)
+	}
 
 	g.AuthzEngine = authzengine.New(db, logger)
 	g.AuthzEngine.SetAuthorizationAuditor(g.SecurityAuditSvc)
@@ -233,6 +352,15 @@ func Build(
 	g.MaintSvc = maintenance.NewService(g.AgentSvc, g.ServerSvc, g.AuthzEngine, logger)
 	g.MaintAPIHandler = maintenance.NewAPIHandler(g.MaintSvc, g.SecurityAuditSvc)
 
+	g.PrunePoliciesSvc = prunepolicies.NewService(db, g.ServerSvc, g.MaintSvc, g.SecurityAuditSvc, logger)
+	g.PrunePoliciesHandler = prunepolicies.NewAPIHandler(g.PrunePoliciesSvc, g.SecurityAuditSvc)
+	g.PruneScheduler = prunepolicies.NewScheduler(g.PrunePoliciesSvc, logger)
+	g.PruneScheduler.SetLeaderGate(g.LeaderSvc.Gate(leader.LeasePruneSchedules))
+	g.addHook("prune scheduler",
+		func(context.Context) error { g.PruneScheduler.Start(); return nil },
+		func(context.Context) error { g.PruneScheduler.Stop(); return nil },
+	)
+
 	g.FilesSvc = files.NewService(g.AgentSvc, g.ServerSvc, g.AuthzEngine, logger)
 	g.FilesAPIHandler = files.NewAPIHandler(g.FilesSvc, g.SecurityAuditSvc)
 
@@ -258,14 +386,49 @@ func Build(
 	g.RegistrySvc = registry.NewService(db, g.Crypto, logger)
 	g.RegistryAPIHandler = registry.NewAPIHandler(g.RegistrySvc, g.AuthzEngine, g.SecurityAuditSvc)
 
+	g.MaintWindowsSvc = maintwindows.NewService(db, g.AuthzEngine, logger)
+	g.MaintWindowsHandler = maintwindows.NewAPIHandler(g.MaintWindowsSvc, g.SecurityAuditSvc)
+
+	g.AccessRequestsSvc = accessrequests.NewService(db, g.AuthzEngine, cfg.Auth.AccessRequestMaxDuration, logger)
+	g.AccessRequestsHandler = accessrequests.NewAPIHandler(g.AccessRequestsSvc, g.SecurityAuditSvc)
+	g.AccessRequestExpiryWorker = accessrequests.NewExpiryWorker(g.AccessRequestsSvc, g.SecurityAuditSvc, cfg.Auth.AccessRequestExpiryInterval, logger)
+	g.AccessRequestExpiryWorker.SetLeaderGate(g.LeaderSvc.Gate(leader.LeaseAccessRequestExpiry))
+	g.addHook("access request expiry worker",
+		func(context.Context) error { g.AccessRequestExpiryWorker.Start(); return nil },
+		func(context.Context) error { g.AccessRequestExpiryWorker.Stop(); return nil },
+	)
+
 	g.OperationsSvc = operations.NewService(g.ServerSvc, g.AuthzEngine, g.OperationsAuditSvc, g.RegistrySvc, g.FilesSvc, logger)
+	g.OperationsSvc.SetMaintenanceGate(g.MaintWindowsSvc)
 	g.OperationsStreamHandler = operations.NewStreamHandler(g.OperationsSvc, g.OriginCheck, logger)
 	g.OperationsHandler = operations.NewHandler(g.OperationsSvc, g.SecurityAuditSvc)
 
+	g.BackupSchedulesSvc = backupschedules.NewService(db, g.ServerSvc, g.StackSvc, g.AuthzEngine, g.OperationsSvc, logger)
+	g.BackupSchedulesHandler = backupschedules.NewAPIHandler(g.BackupSchedulesSvc, g.AuthzEngine, g.SecurityAuditSvc)
+	g.BackupScheduler = backupschedules.NewScheduler(g.BackupSchedulesSvc, logger)
+	g.BackupScheduler.SetLeaderGate(g.LeaderSvc.Gate(leader.LeaseBackupSchedules))
+	g.addHook("backup scheduler",
+		func(context.Context) error { g.BackupScheduler.Start(); return nil },
+		func(context.Context) error { g.BackupScheduler.Stop(); return nil },
+	)
+
+	g.BackupRetentionSvc = backupretention.NewService(db, g.BackupsSvc, g.StackSvc, g.AuthzEngine, g.SecurityAuditSvc, logger)
+	g.BackupRetentionHandler = backupretention.NewAPIHandler(g.BackupRetentionSvc, g.AuthzEngine, g.SecurityAuditSvc)
+
+	g.OperationSchedulesSvc = operationschedules.NewService(db, g.AuthzEngine, g.OperationsSvc, g.OperationsAuditSvc, g.MaintWindowsSvc, logger)
+	g.OperationSchedulesHandler = operationschedules.NewAPIHandler(g.OperationSchedulesSvc, g.SecurityAuditSvc)
+	g.OperationScheduler = operationschedules.NewScheduler(g.OperationSchedulesSvc, logger)
+	g.OperationScheduler.SetLeaderGate(g.LeaderSvc.Gate(leader.LeaseOperationSchedules))
+	g.addHook("operation scheduler",
+		func(context.Context) error { g.OperationScheduler.Start(); return nil },
+		func(context.Context) error { g.OperationScheduler.Stop(); return nil },
+	)
+
 	g.OperationLogsSvc = operationlogs.NewService(db, logger)
 	g.OperationLogsHandler = operationlogs.NewHandler(db, g.OperationLogsSvc, logger, cfg.Custom.OperationTimeoutSeconds)
 
 	g.RetentionWorker = retention.NewWorker(cfg.Retention.Interval, logger, retentionTasks(cfg, g)...)
+	g.RetentionWorker.SetLeaderGate(g.LeaderSvc.Gate(leader.LeaseRetention))
 	g.addHook("retention worker", g.RetentionWorker.Start, g.RetentionWorker.Stop)
 
 	g.DataExportSvc = dataexport.NewService(db, logger)
@@ -273,19 +436,55 @@ func Build(
 
 	g.ImageUpdatesSvc = imageupdates.NewService(db, g.AgentSvc, g.ServerSvc, g.Crypto, logger, cfg)
 	g.ImageUpdatesAPIHandler = imageupdates.NewAPIHandler(g.ImageUpdatesSvc, logger)
+	g.ImageUpdatesSvc.SetLeaderGate(g.LeaderSvc.Gate(leader.LeaseImageUpdates))
 	g.addHook("image updates checker",
 		func(context.Context) error { g.ImageUpdatesSvc.Start(); return nil },
 		func(context.Context) error { g.ImageUpdatesSvc.Stop(); return nil },
 	)
 
+	g.UpdateDigestsSvc = updatedigests.NewService(db, g.AuthzEngine, g.ImageUpdatesSvc, g.Mail, cfg.App.Name, cfg.App.URL, logger)
+	g.UpdateDigestsHandler = updatedigests.NewAPIHandler(g.UpdateDigestsSvc, logger)
+	g.UpdateDigestScheduler = updatedigests.NewScheduler(g.UpdateDigestsSvc, logger)
+	g.UpdateDigestScheduler.SetLeaderGate(g.LeaderSvc.Gate(leader.LeaseUpdateDigests))
+	g.addHook("update digest scheduler",
+		func(context.Context) error { g.UpdateDigestScheduler.Start(); return nil },
+		func(context.Context) error { g.UpdateDigestScheduler.Stop(); return nil },
+	)
+
+	g.AutoUpdatesSvc = autoupdates.NewService(db, g.ImageUpdatesSvc, g.StackSvc, g.OperationsSvc, g.OperationsAuditSvc, g.MaintWindowsSvc, logger)
+	g.AutoUpdatesHandler = autoupdates.NewAPIHandler(g.AutoUpdatesSvc, g.SecurityAuditSvc)
+	g.AutoUpdateWorker = autoupdates.NewWorker(g.AutoUpdatesSvc, logger)
+	g.AutoUpdateWorker.SetLeaderGate(g.LeaderSvc.Gate(leader.LeaseAutoUpdates))
+	g.OperationsAuditSvc.AddEndListener(g.AutoUpdatesSvc)
+	g.addHook("auto-update worker",
+		func(context.Context) error { g.AutoUpdateWorker.Start(); return nil },
+		func(context.Context) error { g.AutoUpdateWorker.Stop(); return nil },
+	)
+
 	g.VulnscanSvc = vulnscan.NewService(db, g.ServerSvc, g.AgentSvc, g.AuthzEngine, logger)
 	g.VulnscanHandler = vulnscan.NewHandler(g.VulnscanSvc, logger)
 	g.VulnscanPoller = vulnscan.NewPoller(db, g.VulnscanSvc, logger)
+	g.VulnscanPoller.SetLeaderGate(g.LeaderSvc.Gate(leader.LeaseVulnscanPoller))
 	g.addHook("vulnscan poller",
 		func(context.Context) error { g.VulnscanPoller.Start(); return nil },
 		func(context.Context) error { g.VulnscanPoller.Stop(); return nil },
 	)
 
+	g.VulnAlertsSvc = vulnalerts.NewService(db, g.VulnscanSvc, g.ServerSvc, g.Mail, cfg.App.Name, cfg.App.URL, logger)
+	g.VulnAlertsHandler = vulnalerts.NewAPIHandler(g.VulnAlertsSvc, g.SecurityAuditSvc)
+	g.VulnscanSvc.AddCompletionListener(g.VulnAlertsSvc)
+
+	g.ScanSchedulesSvc = scanschedules.NewService(db, g.ServerSvc, g.StackSvc, g.AuthzEngine, g.VulnscanSvc, logger)
+	g.ScanSchedulesHandler = scanschedules.NewAPIHandler(g.ScanSchedulesSvc, g.AuthzEngine, g.SecurityAuditSvc)
+	g.ScanScheduler = scanschedules.NewScheduler(g.ScanSchedulesSvc, logger)
+	g.ScanScheduler.SetLeaderGate(g.LeaderSvc.Gate(leader.LeaseScanSchedules))
+	g.ImageUpdatesSvc.AddDigestChangeListener(g.ScanSchedulesSvc)
+	g.OperationsAuditSvc.AddEndListener(g.ScanSchedulesSvc)
+	g.addHook("scan scheduler",
+		func(context.Context) error { g.ScanScheduler.Start(); return nil },
+		func(context.Context) error { g.ScanScheduler.Stop(); return nil },
+	)
+
 	g.WSEventRegistry = websocket.NewStackEventRegistry(logger)
 	g.WSEventsHandler = websocket.NewEventsHandler(g.WSEventRegistry, g.OriginCheck, logger)
 	g.WSAgentMgr = websocket.NewAgentManager(g.WSEventRegistry, logger)
@@ -307,6 +506,22 @@ func Build(
 		},
 	)
 
+	g.WebhooksSvc = webhooks.NewService(db, g.Crypto, logger)
+	g.WebhooksHandler = webhooks.NewAPIHandler(g.WebhooksSvc, g.SecurityAuditSvc)
+	g.WebhookDispatcher = webhooks.NewDispatcher(g.WebhooksSvc, logger)
+	g.WebhookDispatcher.SetLeaderGate(g.LeaderSvc.Gate(leader.LeaseWebhooks))
+	g.OperationsAuditSvc.AddStartListener(g.WebhooksSvc)
+	g.OperationsAuditSvc.AddEndListener(g.WebhooksSvc)
+	g.VulnscanSvc.AddCompletionListener(g.WebhooksSvc)
+	g.ImageUpdatesSvc.AddUpdateAvailableListener(g.WebhooksSvc)
+	g.WSAgentMgr.AddDisconnectListener(g.WebhooksSvc)
+	g.SecurityAuditSvc.AddListener(g.WebhooksSvc)
+	g.AccessRequestsSvc.AddListener(g.WebhooksSvc)
+	g.addHook("webhook dispatcher",
+		func(context.Context) error { g.WebhookDispatcher.Start(); return nil },
+		func(context.Context) error { g.WebhookDispatcher.Stop(); return nil },
+	)
+
 	g.SPASvc = spa.New(cfg.Frontend.Development, cfg.Frontend.ViteDevURL, logger)
 	if err := g.SPASvc.LoadTemplate(cfg.Frontend.RootView); err != nil {
 		return nil, fmt.Errorf("SPA template: %w", err)
@@ -371,6 +586,23 @@ func retentionTasks(cfg *config.Config, g *Graph) []retention.Task {
 		})
 	}
 
+	if cfg.Retention.WebhookDeliveryDays > 0 {
+		tasks = append(tasks, retention.Task{
+			Name: "webhook deliveries",
+			Run: func() error {
+				_, err := g.WebhooksSvc.DeleteOldDeliveries(cfg.Retention.WebhookDeliveryDays)
+				return err
+			},
+		})
+	}
+
+	tasks = append(tasks, retention.Task{
+		Name: "backup retention policies",
+		Run: func() error {
+			return g.BackupRetentionSvc.ApplyPolicies(context.Background())
+		},
+	})
+
 	tasks = append(tasks, retention.Task{
 		Name: "expired password reset tokens",
 		Run: func() error {
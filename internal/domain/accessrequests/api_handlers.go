@@ -0,0 +1,208 @@
+package accessrequests
+
+import (
+	"errors"
+	"fmt"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/security"
+	"berth/internal/domain/session"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type requestAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+type APIHandler struct {
+	service      *Service
+	auditService requestAuditLogger
+}
+
+func NewAPIHandler(service *Service, auditService requestAuditLogger) *APIHandler {
+	return &APIHandler{
+		service:      service,
+		auditService: auditService,
+	}
+}
+
+func (h *APIHandler) CreateRequest(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req CreateRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	request, err := h.service.Create(p, session.ResolveUsername(c), serverID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDurationTooLong):
+			return response.BadRequest(c, fmt.Sprintf("duration_minutes must not exceed %d", int(h.service.MaxDuration().Minutes())))
+		case errors.Is(err, ErrNotReadable):
+			return response.Forbidden(c, ErrNotReadable.Error())
+		case errors.Is(err, ErrAlreadyGranted), errors.Is(err, ErrDuplicate):
+			return response.Conflict(c, err.Error())
+		}
+		return response.Internal(c, "Failed to create access request")
+	}
+
+	h.audit(c, p, security.EventAccessRequestCreated, request)
+
+	return response.Created(c, GetRequestData{
+		Request: *request,
+	})
+}
+
+func (h *APIHandler) ListMyRequests(c echo.Context) error {
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	requests, err := h.service.ListForUser(p.UserID())
+	if err != nil {
+		return response.Internal(c, "Failed to fetch access requests")
+	}
+
+	return response.OK(c, ListRequestsData{
+		Requests: requests,
+	})
+}
+
+func (h *APIHandler) ListPending(c echo.Context) error {
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	requests, err := h.service.ListPending(p)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch access requests")
+	}
+
+	return response.OK(c, ListRequestsData{
+		Requests: requests,
+	})
+}
+
+func (h *APIHandler) Approve(c echo.Context) error {
+	return h.decide(c, h.service.Approve, security.EventAccessRequestApproved)
+}
+
+func (h *APIHandler) Deny(c echo.Context) error {
+	return h.decide(c, h.service.Deny, security.EventAccessRequestDenied)
+}
+
+type decideFunc func(p authz.Principal, username string, id uint, note string) (*AccessRequest, error)
+
+func (h *APIHandler) decide(c echo.Context, decide decideFunc, eventType string) error {
+	id, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req DecisionRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	request, err := decide(p, session.ResolveUsername(c), id, req.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, ErrNotApprover):
+			return response.NotFound(c, "Access request not found")
+		case errors.Is(err, ErrSelfDecision):
+			return response.Forbidden(c, ErrSelfDecision.Error())
+		case errors.Is(err, ErrNotPending):
+			return response.Conflict(c, ErrNotPending.Error())
+		}
+		return response.Internal(c, "Failed to decide access request")
+	}
+
+	h.audit(c, p, eventType, request)
+
+	return response.OK(c, GetRequestData{
+		Request: *request,
+	})
+}
+
+func (h *APIHandler) Cancel(c echo.Context) error {
+	id, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	request, err := h.service.Cancel(p.UserID(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return response.NotFound(c, "Access request not found")
+		case errors.Is(err, ErrNotCancellable):
+			return response.Conflict(c, ErrNotCancellable.Error())
+		}
+		return response.Internal(c, "Failed to cancel access request")
+	}
+
+	h.audit(c, p, security.EventAccessRequestCancelled, request)
+
+	return response.OK(c, GetRequestData{
+		Request: *request,
+	})
+}
+
+func (h *APIHandler) audit(c echo.Context, p authz.Principal, eventType string, request *AccessRequest) {
+	actorID := p.UserID()
+	requestID := request.ID
+	userID := request.UserID
+	serverID := request.ServerID
+	metadata := map[string]any{
+		"permission":       request.Permission,
+		"stack_pattern":    request.StackPattern,
+		"duration_minutes": request.DurationMinutes,
+		"reason":           request.Reason,
+	}
+	if request.DecisionNote != "" {
+		metadata["decision_note"] = request.DecisionNote
+	}
+	if request.ExpiresAt != nil {
+		metadata["expires_at"] = request.ExpiresAt
+	}
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      eventType,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetUserID:   &userID,
+		TargetType:     security.TargetTypeAccessRequest,
+		TargetID:       &requestID,
+		TargetName:     request.Username,
+		Success:        true,
+		Metadata:       metadata,
+		ServerID:       &serverID,
+	})
+}
@@ -0,0 +1,77 @@
+package accessrequests
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"berth/internal/domain/rbac"
+	"berth/internal/domain/rbac/permnames"
+)
+
+// maxTextLength caps reasons and decision notes.
+const maxTextLength = 1000
+
+var (
+	ErrStackPatternInvalid = errors.New("stack_pattern must not be empty or contain '/' or whitespace")
+	ErrPermissionInvalid   = errors.New("permission must be a stack permission other than stacks.read and stacks.access.approve")
+	ErrDurationInvalid     = errors.New("duration_minutes must be at least 1")
+	ErrReasonRequired      = errors.New("reason is required")
+	ErrReasonTooLong       = errors.New("reason must be at most 1000 characters")
+	ErrNoteTooLong         = errors.New("note must be at most 1000 characters")
+)
+
+// IsRequestable reports whether perm may be asked for. stacks.read is left
+// to roles, so requests only extend access to stacks the requester can
+// already see, and approval rights cannot be handed out by approvers.
+func IsRequestable(perm string) bool {
+	if perm == permnames.StacksRead || perm == permnames.StacksAccessApprove {
+		return false
+	}
+	return slices.Contains(rbac.AdminStackPermissions(), perm)
+}
+
+type CreateRequest struct {
+	StackPattern    string `json:"stack_pattern"`
+	Permission      string `json:"permission"`
+	DurationMinutes int    `json:"duration_minutes"`
+	Reason          string `json:"reason"`
+}
+
+func (r *CreateRequest) Validate() error {
+	if r.StackPattern == "" || strings.ContainsAny(r.StackPattern, "/ \t\r\n") {
+		return ErrStackPatternInvalid
+	}
+	if !IsRequestable(r.Permission) {
+		return ErrPermissionInvalid
+	}
+	if r.DurationMinutes < 1 {
+		return ErrDurationInvalid
+	}
+	if strings.TrimSpace(r.Reason) == "" {
+		return ErrReasonRequired
+	}
+	if len(r.Reason) > maxTextLength {
+		return ErrReasonTooLong
+	}
+	return nil
+}
+
+type DecisionRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+func (r *DecisionRequest) Validate() error {
+	if len(r.Note) > maxTextLength {
+		return ErrNoteTooLong
+	}
+	return nil
+}
+
+type ListRequestsData struct {
+	Requests []AccessRequest `json:"requests"`
+}
+
+type GetRequestData struct {
+	Request AccessRequest `json:"request"`
+}
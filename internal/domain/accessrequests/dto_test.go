@@ -0,0 +1,55 @@
+package accessrequests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCreateRequest_Validate(t *testing.T) {
+	valid := func(mutate func(*CreateRequest)) CreateRequest {
+		r := CreateRequest{
+			StackPattern:    "web-*",
+			Permission:      "stacks.manage",
+			DurationMinutes: 60,
+			Reason:          "deploy hotfix",
+		}
+		mutate(&r)
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		req     CreateRequest
+		wantErr error
+	}{
+		{"valid", valid(func(*CreateRequest) {}), nil},
+		{"empty pattern", valid(func(r *CreateRequest) { r.StackPattern = "" }), ErrStackPatternInvalid},
+		{"pattern with slash", valid(func(r *CreateRequest) { r.StackPattern = "prod/*" }), ErrStackPatternInvalid},
+		{"unknown permission", valid(func(r *CreateRequest) { r.Permission = "stacks.explode" }), ErrPermissionInvalid},
+		{"stacks.read", valid(func(r *CreateRequest) { r.Permission = "stacks.read" }), ErrPermissionInvalid},
+		{"approver permission", valid(func(r *CreateRequest) { r.Permission = "stacks.access.approve" }), ErrPermissionInvalid},
+		{"admin permission", valid(func(r *CreateRequest) { r.Permission = "admin.users.write" }), ErrPermissionInvalid},
+		{"zero duration", valid(func(r *CreateRequest) { r.DurationMinutes = 0 }), ErrDurationInvalid},
+		{"blank reason", valid(func(r *CreateRequest) { r.Reason = "  " }), ErrReasonRequired},
+		{"long reason", valid(func(r *CreateRequest) { r.Reason = strings.Repeat("x", 1001) }), ErrReasonTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecisionRequest_Validate(t *testing.T) {
+	if got := (&DecisionRequest{}).Validate(); got != nil {
+		t.Errorf("Validate() without note = %v, want nil", got)
+	}
+	if got := (&DecisionRequest{Note: strings.Repeat("x", 1001)}).Validate(); !errors.Is(got, ErrNoteTooLong) {
+		t.Errorf("Validate() with long note = %v, want %v", got, ErrNoteTooLong)
+	}
+}
@@ -0,0 +1,73 @@
+package accessrequests
+
+import (
+	"context"
+	"time"
+
+	"berth/internal/domain/security"
+	"berth/internal/pkg/periodic"
+
+	"go.uber.org/zap"
+)
+
+// expiryActor is recorded as the actor of lapsed grants.
+const expiryActor = "access-request-expiry"
+
+type expiryAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+// grantExpiry marks approved requests as expired once their grant lapses and
+// records an rbac.access_request.expired event for each. Access already ends
+// at expires_at; this settles the status and the audit trail.
+type grantExpiry struct {
+	svc    *Service
+	audit  expiryAuditLogger
+	logger *zap.Logger
+}
+
+// NewExpiryWorker returns a runner that expires lapsed grants every interval;
+// an interval of zero disables it.
+func NewExpiryWorker(svc *Service, audit expiryAuditLogger, interval time.Duration, logger *zap.Logger) *periodic.Runner {
+	w := &grantExpiry{svc: svc, audit: audit, logger: logger}
+	return periodic.NewRunner("access request expiry worker", interval, w.run, logger)
+}
+
+func (w *grantExpiry) run(context.Context) error {
+	expired, err := w.svc.ExpireGrants(time.Now())
+	for i := range expired {
+		w.record(&expired[i])
+	}
+	return err
+}
+
+func (w *grantExpiry) record(req *AccessRequest) {
+	w.logger.Info("access request grant expired",
+		zap.Uint("request_id", req.ID),
+		zap.Uint("user_id", req.UserID),
+		zap.Uint("server_id", req.ServerID),
+		zap.String("permission", req.Permission),
+	)
+
+	if w.audit == nil {
+		return
+	}
+	requestID := req.ID
+	userID := req.UserID
+	serverID := req.ServerID
+	_ = w.audit.Log(security.LogEvent{
+		EventType:     security.EventAccessRequestExpired,
+		Success:       true,
+		ActorUsername: expiryActor,
+		TargetUserID:  &userID,
+		TargetType:    security.TargetTypeAccessRequest,
+		TargetID:      &requestID,
+		TargetName:    req.Username,
+		ServerID:      &serverID,
+		Metadata: map[string]any{
+			"permission":    req.Permission,
+			"stack_pattern": req.StackPattern,
+			"expires_at":    req.ExpiresAt,
+		},
+	})
+}
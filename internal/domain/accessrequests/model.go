@@ -0,0 +1,46 @@
+package accessrequests
+
+import (
+	"time"
+
+	"berth/internal/platform/db"
+
+	"gorm.io/gorm"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusApproved  = "approved"
+	StatusDenied    = "denied"
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired"
+)
+
+// AccessRequest asks for a stack permission on a server for a limited time.
+// Once approved it acts as a grant alongside the requester's roles until
+// ExpiresAt.
+type AccessRequest struct {
+	db.BaseModel
+	UserID            uint       `json:"user_id" gorm:"not null;index"`
+	Username          string     `json:"username" gorm:"not null"`
+	ServerID          uint       `json:"server_id" gorm:"not null;index"`
+	StackPattern      string     `json:"stack_pattern" gorm:"not null"`
+	Permission        string     `json:"permission" gorm:"not null"`
+	DurationMinutes   int        `json:"duration_minutes" gorm:"not null"`
+	Reason            string     `json:"reason" gorm:"type:text;not null"`
+	Status            string     `json:"status" gorm:"not null;index"`
+	DecidedByUserID   *uint      `json:"decided_by_user_id,omitempty"`
+	DecidedByUsername string     `json:"decided_by_username,omitempty"`
+	DecisionNote      string     `json:"decision_note,omitempty" gorm:"type:text"`
+	DecidedAt         *time.Time `json:"decided_at,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty" gorm:"index"`
+}
+
+func (AccessRequest) TableName() string {
+	return "access_requests"
+}
+
+// ActiveGrants limits a query to approved requests that have not expired.
+func ActiveGrants(db *gorm.DB) *gorm.DB {
+	return db.Where("access_requests.status = ? AND access_requests.expires_at > ?", StatusApproved, time.Now())
+}
@@ -0,0 +1,15 @@
+package accessrequests
+
+import (
+	"berth/internal/domain/authz"
+)
+
+func (h *APIHandler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
+	reg.POST("/servers/:serverid/access-requests", h.CreateRequest, authz.ServerAccess())
+	reg.GET("/access-requests", h.ListMyRequests, authz.Authenticated())
+	reg.POST("/access-requests/:id/cancel", h.Cancel, authz.Authenticated())
+
+	reg.GET("/access-requests/pending", h.ListPending, authz.APIKeyDenied())
+	reg.POST("/access-requests/:id/approve", h.Approve, authz.APIKeyDenied())
+	reg.POST("/access-requests/:id/deny", h.Deny, authz.APIKeyDenied())
+}
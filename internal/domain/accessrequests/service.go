@@ -0,0 +1,317 @@
+package accessrequests
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDurationTooLong = errors.New("requested duration exceeds the maximum")
+	ErrNotReadable     = errors.New("access can only be requested for stacks you can read")
+	ErrAlreadyGranted  = errors.New("you already hold this permission on the requested stacks")
+	ErrDuplicate       = errors.New("a matching request is already pending")
+	ErrNotPending      = errors.New("request is no longer pending")
+	ErrNotCancellable  = errors.New("request is no longer pending or active")
+	ErrSelfDecision    = errors.New("requests cannot be decided by their requester")
+	ErrNotApprover     = errors.New("not allowed to decide this request")
+)
+
+type accessAuthorizer interface {
+	HasStackPermission(p authz.Principal, serverID uint, stackname, permission string) (bool, error)
+}
+
+// Listener is notified when a request is created and whenever its status
+// changes. Listeners must not block.
+type Listener interface {
+	OnAccessRequestChanged(req *AccessRequest)
+}
+
+type Service struct {
+	db          *gorm.DB
+	authzSvc    accessAuthorizer
+	maxDuration time.Duration
+	logger      *zap.Logger
+	listeners   []Listener
+	now         func() time.Time
+}
+
+func NewService(db *gorm.DB, authzSvc accessAuthorizer, maxDuration time.Duration, logger *zap.Logger) *Service {
+	return &Service{
+		db:          db,
+		authzSvc:    authzSvc,
+		maxDuration: maxDuration,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// AddListener registers l for request notifications. It must be called
+// during wiring, before the service is used.
+func (s *Service) AddListener(l Listener) {
+	s.listeners = append(s.listeners, l)
+}
+
+func (s *Service) MaxDuration() time.Duration {
+	return s.maxDuration
+}
+
+func (s *Service) notify(req *AccessRequest) {
+	for _, l := range s.listeners {
+		l.OnAccessRequestChanged(req)
+	}
+}
+
+func (s *Service) Create(p authz.Principal, username string, serverID uint, req CreateRequest) (*AccessRequest, error) {
+	if s.maxDuration > 0 && time.Duration(req.DurationMinutes)*time.Minute > s.maxDuration {
+		return nil, ErrDurationTooLong
+	}
+
+	readable, err := s.authzSvc.HasStackPermission(p, serverID, req.StackPattern, permnames.StacksRead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permissions: %w", err)
+	}
+	if !readable {
+		return nil, ErrNotReadable
+	}
+
+	held, err := s.authzSvc.HasStackPermission(p, serverID, req.StackPattern, req.Permission)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permissions: %w", err)
+	}
+	if held {
+		return nil, ErrAlreadyGranted
+	}
+
+	var pending int64
+	if err := s.db.Model(&AccessRequest{}).
+		Where("user_id = ? AND server_id = ? AND stack_pattern = ? AND permission = ? AND status = ?",
+			p.UserID(), serverID, req.StackPattern, req.Permission, StatusPending).
+		Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, ErrDuplicate
+	}
+
+	request := AccessRequest{
+		UserID:          p.UserID(),
+		Username:        username,
+		ServerID:        serverID,
+		StackPattern:    req.StackPattern,
+		Permission:      req.Permission,
+		DurationMinutes: req.DurationMinutes,
+		Reason:          req.Reason,
+		Status:          StatusPending,
+	}
+	if err := s.db.Create(&request).Error; err != nil {
+		s.logger.Error("failed to create access request",
+			zap.Error(err),
+			zap.Uint("user_id", p.UserID()),
+			zap.Uint("server_id", serverID),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("access request created",
+		zap.Uint("request_id", request.ID),
+		zap.Uint("user_id", request.UserID),
+		zap.Uint("server_id", serverID),
+		zap.String("stack_pattern", request.StackPattern),
+		zap.String("permission", request.Permission),
+	)
+
+	s.notify(&request)
+	return &request, nil
+}
+
+func (s *Service) Get(id uint) (*AccessRequest, error) {
+	var request AccessRequest
+	if err := s.db.First(&request, id).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// ListForUser returns the user's own requests, newest first.
+func (s *Service) ListForUser(userID uint) ([]AccessRequest, error) {
+	var requests []AccessRequest
+	if err := s.db.Where("user_id = ?", userID).Order("id DESC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// ListPending returns the pending requests p may decide, oldest first.
+func (s *Service) ListPending(p authz.Principal) ([]AccessRequest, error) {
+	var requests []AccessRequest
+	if err := s.db.Where("status = ? AND user_id <> ?", StatusPending, p.UserID()).
+		Order("id").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+
+	decidable := make([]AccessRequest, 0, len(requests))
+	for _, request := range requests {
+		ok, err := s.authzSvc.HasStackPermission(p, request.ServerID, request.StackPattern, permnames.StacksAccessApprove)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check permissions: %w", err)
+		}
+		if ok {
+			decidable = append(decidable, request)
+		}
+	}
+	return decidable, nil
+}
+
+// Approve grants the requested permission for the requested duration,
+// starting now.
+func (s *Service) Approve(p authz.Principal, username string, id uint, note string) (*AccessRequest, error) {
+	return s.decide(p, username, id, StatusApproved, note)
+}
+
+func (s *Service) Deny(p authz.Principal, username string, id uint, note string) (*AccessRequest, error) {
+	return s.decide(p, username, id, StatusDenied, note)
+}
+
+// decide requires stacks.access.approve on a pattern covering the request's
+// stack pattern. Requesters never decide their own requests.
+func (s *Service) decide(p authz.Principal, username string, id uint, status, note string) (*AccessRequest, error) {
+	request, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if request.UserID == p.UserID() {
+		return nil, ErrSelfDecision
+	}
+	ok, err := s.authzSvc.HasStackPermission(p, request.ServerID, request.StackPattern, permnames.StacksAccessApprove)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permissions: %w", err)
+	}
+	if !ok {
+		return nil, ErrNotApprover
+	}
+	if request.Status != StatusPending {
+		return nil, ErrNotPending
+	}
+
+	now := s.now()
+	deciderID := p.UserID()
+	updates := map[string]any{
+		"status":              status,
+		"decided_by_user_id":  deciderID,
+		"decided_by_username": username,
+		"decision_note":       note,
+		"decided_at":          now,
+	}
+	var expiresAt *time.Time
+	if status == StatusApproved {
+		t := now.Add(time.Duration(request.DurationMinutes) * time.Minute)
+		expiresAt = &t
+		updates["expires_at"] = t
+	}
+
+	result := s.db.Model(&AccessRequest{}).
+		Where("id = ? AND status = ?", request.ID, StatusPending).
+		Updates(updates)
+	if result.Error != nil {
+		s.logger.Error("failed to decide access request",
+			zap.Error(result.Error),
+			zap.Uint("request_id", request.ID),
+		)
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotPending
+	}
+
+	request.Status = status
+	request.DecidedByUserID = &deciderID
+	request.DecidedByUsername = username
+	request.DecisionNote = note
+	request.DecidedAt = &now
+	request.ExpiresAt = expiresAt
+
+	s.logger.Info("access request decided",
+		zap.Uint("request_id", request.ID),
+		zap.String("status", status),
+		zap.Uint("decided_by_user_id", deciderID),
+	)
+
+	s.notify(request)
+	return request, nil
+}
+
+// Cancel withdraws one of the user's pending requests, or gives up an
+// approved grant before it expires.
+func (s *Service) Cancel(userID, id uint) (*AccessRequest, error) {
+	var request AccessRequest
+	if err := s.db.Where("user_id = ?", userID).First(&request, id).Error; err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	updates := map[string]any{"status": StatusCancelled}
+	switch {
+	case request.Status == StatusPending:
+	case request.Status == StatusApproved && request.ExpiresAt != nil && request.ExpiresAt.After(now):
+		updates["expires_at"] = now
+	default:
+		return nil, ErrNotCancellable
+	}
+
+	result := s.db.Model(&AccessRequest{}).
+		Where("id = ? AND status = ?", request.ID, request.Status).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotCancellable
+	}
+
+	if request.Status == StatusApproved {
+		request.ExpiresAt = &now
+	}
+	request.Status = StatusCancelled
+
+	s.logger.Info("access request cancelled",
+		zap.Uint("request_id", request.ID),
+		zap.Uint("user_id", userID),
+	)
+
+	s.notify(&request)
+	return &request, nil
+}
+
+// ExpireGrants marks approved requests whose grant has lapsed as expired and
+// returns them. Rows another instance expired first are skipped.
+func (s *Service) ExpireGrants(now time.Time) ([]AccessRequest, error) {
+	var due []AccessRequest
+	if err := s.db.Where("status = ? AND expires_at <= ?", StatusApproved, now).
+		Find(&due).Error; err != nil {
+		return nil, err
+	}
+
+	expired := make([]AccessRequest, 0, len(due))
+	for _, request := range due {
+		result := s.db.Model(&AccessRequest{}).
+			Where("id = ? AND status = ?", request.ID, StatusApproved).
+			Update("status", StatusExpired)
+		if result.Error != nil {
+			return expired, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		request.Status = StatusExpired
+		s.notify(&request)
+		expired = append(expired, request)
+	}
+	return expired, nil
+}
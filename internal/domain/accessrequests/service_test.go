@@ -0,0 +1,232 @@
+package accessrequests
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+const (
+	requesterID = 1
+	approverID  = 2
+	bystanderID = 3
+)
+
+// fakeAuthorizer grants each user a fixed set of permissions on every stack.
+type fakeAuthorizer struct {
+	perms map[uint][]string
+}
+
+func (f *fakeAuthorizer) HasStackPermission(p authz.Principal, _ uint, _, permission string) (bool, error) {
+	for _, perm := range f.perms[p.UserID()] {
+		if perm == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type recordingListener struct {
+	statuses []string
+}
+
+func (l *recordingListener) OnAccessRequestChanged(req *AccessRequest) {
+	l.statuses = append(l.statuses, req.Status)
+}
+
+var noon = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+func newTestService(t *testing.T) (*Service, *recordingListener) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:accessrequests_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&AccessRequest{}))
+
+	authorizer := &fakeAuthorizer{perms: map[uint][]string{
+		requesterID: {permnames.StacksRead},
+		approverID:  {permnames.StacksRead, permnames.StacksAccessApprove},
+		bystanderID: {permnames.StacksRead},
+	}}
+	svc := NewService(db, authorizer, 24*time.Hour, zap.NewNop())
+	svc.now = func() time.Time { return noon }
+	listener := &recordingListener{}
+	svc.AddListener(listener)
+	return svc, listener
+}
+
+func principal(userID uint) authz.Principal {
+	return authz.NewPrincipal(userID, false, nil)
+}
+
+func createRequest(t *testing.T, svc *Service) *AccessRequest {
+	t.Helper()
+	req, err := svc.Create(principal(requesterID), "dev", 1, CreateRequest{
+		StackPattern:    "web-*",
+		Permission:      permnames.StacksManage,
+		DurationMinutes: 90,
+		Reason:          "deploy hotfix",
+	})
+	require.NoError(t, err)
+	return req
+}
+
+func TestCreate(t *testing.T) {
+	svc, listener := newTestService(t)
+
+	req := createRequest(t, svc)
+	assert.Equal(t, StatusPending, req.Status)
+	assert.Equal(t, "dev", req.Username)
+	assert.Nil(t, req.ExpiresAt)
+	assert.Equal(t, []string{StatusPending}, listener.statuses)
+
+	t.Run("duplicate pending request", func(t *testing.T) {
+		_, err := svc.Create(principal(requesterID), "dev", 1, CreateRequest{
+			StackPattern: "web-*", Permission: permnames.StacksManage, DurationMinutes: 30, Reason: "again",
+		})
+		assert.ErrorIs(t, err, ErrDuplicate)
+	})
+
+	t.Run("longer than the maximum", func(t *testing.T) {
+		_, err := svc.Create(principal(requesterID), "dev", 1, CreateRequest{
+			StackPattern: "api", Permission: permnames.StacksManage, DurationMinutes: 25 * 60, Reason: "long",
+		})
+		assert.ErrorIs(t, err, ErrDurationTooLong)
+	})
+
+	t.Run("stacks the requester cannot read", func(t *testing.T) {
+		_, err := svc.Create(principal(99), "stranger", 1, CreateRequest{
+			StackPattern: "web-*", Permission: permnames.StacksManage, DurationMinutes: 30, Reason: "please",
+		})
+		assert.ErrorIs(t, err, ErrNotReadable)
+	})
+
+	t.Run("permission already held", func(t *testing.T) {
+		_, err := svc.Create(principal(approverID), "lead", 1, CreateRequest{
+			StackPattern: "web-*", Permission: permnames.StacksAccessApprove, DurationMinutes: 30, Reason: "already",
+		})
+		assert.ErrorIs(t, err, ErrAlreadyGranted)
+	})
+}
+
+func TestDecide(t *testing.T) {
+	svc, listener := newTestService(t)
+	req := createRequest(t, svc)
+
+	_, err := svc.Approve(principal(requesterID), "dev", req.ID, "")
+	assert.ErrorIs(t, err, ErrSelfDecision)
+
+	_, err = svc.Approve(principal(bystanderID), "other", req.ID, "")
+	assert.ErrorIs(t, err, ErrNotApprover)
+
+	approved, err := svc.Approve(principal(approverID), "lead", req.ID, "ok for today")
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, approved.Status)
+	require.NotNil(t, approved.ExpiresAt)
+	assert.True(t, approved.ExpiresAt.Equal(noon.Add(90*time.Minute)))
+	assert.Equal(t, "lead", approved.DecidedByUsername)
+	assert.Equal(t, "ok for today", approved.DecisionNote)
+
+	stored, err := svc.Get(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, stored.Status)
+
+	_, err = svc.Deny(principal(approverID), "lead", req.ID, "")
+	assert.ErrorIs(t, err, ErrNotPending)
+
+	assert.Equal(t, []string{StatusPending, StatusApproved}, listener.statuses)
+}
+
+func TestDeny(t *testing.T) {
+	svc, _ := newTestService(t)
+	req := createRequest(t, svc)
+
+	denied, err := svc.Deny(principal(approverID), "lead", req.ID, "use the pipeline")
+	require.NoError(t, err)
+	assert.Equal(t, StatusDenied, denied.Status)
+	assert.Nil(t, denied.ExpiresAt)
+}
+
+func TestListPending(t *testing.T) {
+	svc, _ := newTestService(t)
+	req := createRequest(t, svc)
+
+	pending, err := svc.ListPending(principal(approverID))
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, req.ID, pending[0].ID)
+
+	pending, err = svc.ListPending(principal(bystanderID))
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	_, err = svc.Deny(principal(approverID), "lead", req.ID, "")
+	require.NoError(t, err)
+	pending, err = svc.ListPending(principal(approverID))
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestCancel(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	t.Run("pending request", func(t *testing.T) {
+		req := createRequest(t, svc)
+
+		_, err := svc.Cancel(bystanderID, req.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		cancelled, err := svc.Cancel(requesterID, req.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusCancelled, cancelled.Status)
+
+		_, err = svc.Cancel(requesterID, req.ID)
+		assert.ErrorIs(t, err, ErrNotCancellable)
+	})
+
+	t.Run("approved grant ends early", func(t *testing.T) {
+		req := createRequest(t, svc)
+		_, err := svc.Approve(principal(approverID), "lead", req.ID, "")
+		require.NoError(t, err)
+
+		cancelled, err := svc.Cancel(requesterID, req.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusCancelled, cancelled.Status)
+		require.NotNil(t, cancelled.ExpiresAt)
+		assert.True(t, cancelled.ExpiresAt.Equal(noon))
+	})
+}
+
+func TestExpireGrants(t *testing.T) {
+	svc, listener := newTestService(t)
+	req := createRequest(t, svc)
+	_, err := svc.Approve(principal(approverID), "lead", req.ID, "")
+	require.NoError(t, err)
+
+	expired, err := svc.ExpireGrants(noon.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, expired, "grant still active")
+
+	expired, err = svc.ExpireGrants(noon.Add(90 * time.Minute))
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, StatusExpired, expired[0].Status)
+
+	expired, err = svc.ExpireGrants(noon.Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+
+	assert.Equal(t, []string{StatusPending, StatusApproved, StatusExpired}, listener.statuses)
+}
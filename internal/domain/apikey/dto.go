@@ -5,6 +5,7 @@ import "errors"
 const (
 	maxAPIKeyNameLength        = 255
 	maxScopeStackPatternLength = 255
+	maxAPIKeyRateLimit         = 1000000
 )
 
 var (
@@ -15,6 +16,8 @@ var (
 	ErrScopeStackPatternTooLong      = errors.New("Stack pattern must be less than 255 characters")
 	ErrScopeStackPatternInvalidChars = errors.New("Stack pattern contains invalid characters. Only alphanumeric, dash, underscore, dot, and asterisk are allowed")
 	ErrScopePermissionRequired       = errors.New("Permission is required")
+
+	ErrRateLimitOutOfRange = errors.New("Rate limit must be between 1 and 1000000 requests")
 )
 
 type CreateAPIKeyRequest struct {
@@ -32,6 +35,19 @@ func (r *CreateAPIKeyRequest) Validate() error {
 	return nil
 }
 
+// SetRateLimitRequest sets an API key's own request limit for the configured
+// RATE_LIMIT_API_KEY_PERIOD. A null rate limit restores the default.
+type SetRateLimitRequest struct {
+	RateLimit *int `json:"rate_limit"`
+}
+
+func (r *SetRateLimitRequest) Validate() error {
+	if r.RateLimit != nil && (*r.RateLimit < 1 || *r.RateLimit > maxAPIKeyRateLimit) {
+		return ErrRateLimitOutOfRange
+	}
+	return nil
+}
+
 type AddScopeRequest struct {
 	ServerID     *uint  `json:"server_id,omitempty"`
 	StackPattern string `json:"stack_pattern"`
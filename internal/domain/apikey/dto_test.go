@@ -59,3 +59,28 @@ func TestAddScopeRequest_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestSetRateLimitRequest_Validate(t *testing.T) {
+	intptr := func(n int) *int { return &n }
+	tests := []struct {
+		name    string
+		req     SetRateLimitRequest
+		wantErr error
+	}{
+		{"null restores default", SetRateLimitRequest{}, nil},
+		{"one request", SetRateLimitRequest{RateLimit: intptr(1)}, nil},
+		{"maximum", SetRateLimitRequest{RateLimit: intptr(1000000)}, nil},
+		{"zero rejected", SetRateLimitRequest{RateLimit: intptr(0)}, ErrRateLimitOutOfRange},
+		{"negative rejected", SetRateLimitRequest{RateLimit: intptr(-5)}, ErrRateLimitOutOfRange},
+		{"above maximum rejected", SetRateLimitRequest{RateLimit: intptr(1000001)}, ErrRateLimitOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
@@ -150,6 +150,43 @@ func (h *Handler) RevokeAPIKey(c echo.Context) error {
 	return response.OK(c, MessageData{Message: "API key revoked successfully"})
 }
 
+func (h *Handler) SetRateLimit(c echo.Context) error {
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return response.Unauthorized(c, "User not authenticated")
+	}
+
+	apiKeyID, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var req SetRateLimitRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	apiKey, err := h.service.SetRateLimit(apiKeyID, req.RateLimit)
+	if err != nil {
+		return response.NotFound(c, "API key not found")
+	}
+
+	h.auditService.LogAPIKeyEvent(
+		security.EventAPIKeyRateLimitUpdated,
+		p.UserID(),
+		session.ResolveUsername(c),
+		apiKey.ID,
+		apiKey.Name,
+		c.RealIP(),
+		map[string]any{
+			"owner_user_id": apiKey.UserID,
+			"rate_limit":    req.RateLimit,
+		},
+	)
+
+	return response.OK(c, apiKey.ToResponse())
+}
+
 func (h *Handler) ListScopes(c echo.Context) error {
 	userID, err := session.GetCurrentUserID(c)
 	if err != nil {
@@ -20,6 +20,7 @@ type APIKey struct {
 	LastUsedAt *time.Time    `json:"last_used_at"`
 	ExpiresAt  *time.Time    `json:"expires_at"`
 	IsActive   bool          `json:"is_active" gorm:"default:true;index"`
+	RateLimit  *int          `json:"rate_limit"`
 	User       user.User     `json:"user" gorm:"foreignKey:UserID"`
 	Scopes     []APIKeyScope `json:"scopes" gorm:"foreignKey:APIKeyID"`
 }
@@ -42,6 +43,7 @@ type APIKeyInfo struct {
 	LastUsedAt *string `json:"last_used_at"`
 	ExpiresAt  *string `json:"expires_at"`
 	IsActive   bool    `json:"is_active"`
+	RateLimit  *int    `json:"rate_limit"`
 	ScopeCount int     `json:"scope_count"`
 }
 
@@ -67,6 +69,7 @@ func (a *APIKey) ToResponse() APIKeyInfo {
 		LastUsedAt: lastUsedAt,
 		ExpiresAt:  expiresAt,
 		IsActive:   a.IsActive,
+		RateLimit:  a.RateLimit,
 		ScopeCount: len(a.Scopes),
 	}
 }
@@ -1,6 +1,9 @@
 package apikey
 
-import "berth/internal/domain/authz"
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
 
 func (h *Handler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
 	rule := authz.APIKeyDenied()
@@ -12,3 +15,7 @@ func (h *Handler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
 	reg.POST("/api-keys/:id/scopes", h.AddScope, rule)
 	reg.DELETE("/api-keys/:id/scopes/:scopeId", h.RemoveScope, rule)
 }
+
+func (h *Handler) RegisterAdminAPIRoutes(reg *authz.Registrar) {
+	reg.PUT("/api-keys/:id/rate-limit", h.SetRateLimit, authz.Admin(permnames.AdminUsersWrite))
+}
@@ -108,11 +108,14 @@ func (s *Service) ValidateAPIKey(key string) (*user.User, *APIKey, error) {
 	keyHash := base64.StdEncoding.EncodeToString(hash[:])
 
 	var apiKey APIKey
-	err := s.db.Preload("User.Roles").
+	err := s.db.Preload("User").
 		Preload("Scopes.Permission").
 		Preload("Scopes.Server").
 		Where("key_hash = ?", keyHash).
 		First(&apiKey).Error
+	if err == nil && apiKey.User.ID != 0 {
+		err = user.LoadActiveRoles(s.db, &apiKey.User)
+	}
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -231,6 +234,34 @@ func (s *Service) RevokeAPIKey(apiKeyID uint, userID uint) error {
 	return nil
 }
 
+// SetRateLimit replaces the request limit of any user's API key. A nil limit
+// returns the key to the configured default.
+func (s *Service) SetRateLimit(apiKeyID uint, rateLimit *int) (*APIKey, error) {
+	var apiKey APIKey
+	if err := s.db.Preload("Scopes").First(&apiKey, apiKeyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("API key not found")
+		}
+		return nil, err
+	}
+
+	if err := s.db.Model(&apiKey).Update("rate_limit", rateLimit).Error; err != nil {
+		s.logger.Error("failed to update API key rate limit",
+			zap.Error(err),
+			zap.Uint("api_key_id", apiKeyID),
+		)
+		return nil, err
+	}
+	apiKey.RateLimit = rateLimit
+
+	s.logger.Info("API key rate limit updated",
+		zap.Uint("api_key_id", apiKeyID),
+		zap.Uint("user_id", apiKey.UserID),
+	)
+
+	return &apiKey, nil
+}
+
 func (s *Service) AddScope(p authz.Principal, apiKeyID uint, serverID *uint, stackPattern string, permissionName string) error {
 	s.logger.Info("adding scope to API key",
 		zap.Uint("api_key_id", apiKeyID),
@@ -261,7 +292,7 @@ func (s *Service) AddScope(p authz.Principal, apiKeyID uint, serverID *uint, sta
 	if permission.IsAPIKeyOnly {
 		if strings.HasPrefix(permission.Name, "admin.") {
 			var u user.User
-			err = s.db.Preload("Roles").First(&u, p.UserID()).Error
+			err = user.FirstWithActiveRoles(s.db, &u, p.UserID())
 			if err != nil {
 				s.logger.Error("failed to load user roles",
 					zap.Error(err),
@@ -59,10 +59,25 @@ type AuthLoginData struct {
 	User             user.UserInfo `json:"user"`
 }
 
+// AuthTOTPRequiredData asks for a second factor. TOTPRequired is set for
+// any second factor; SecondFactorMethods lists the ones the user can
+// complete ("totp", "passkey").
 type AuthTOTPRequiredData struct {
-	Message        string `json:"message"`
-	TOTPRequired   bool   `json:"totp_required"`
-	TemporaryToken string `json:"temporary_token"`
+	Message             string   `json:"message"`
+	TOTPRequired        bool     `json:"totp_required"`
+	TemporaryToken      string   `json:"temporary_token"`
+	SecondFactorMethods []string `json:"second_factor_methods"`
+}
+
+// AuthTwoFactorEnrollmentData is returned by /auth/login when one of the
+// user's roles requires a second factor and none is enrolled. The enrollment
+// token only reaches the profile, TOTP and passkey enrollment endpoints.
+type AuthTwoFactorEnrollmentData struct {
+	Message            string   `json:"message"`
+	EnrollmentRequired bool     `json:"enrollment_required"`
+	EnrollmentToken    string   `json:"enrollment_token"`
+	ExpiresIn          int      `json:"expires_in"`
+	EnrollmentMethods  []string `json:"enrollment_methods"`
 }
 
 type AuthRefreshData struct {
@@ -0,0 +1,13 @@
+package auth
+
+type OIDCMethodInfo struct {
+	ProviderName string `json:"provider_name"`
+	LoginURL     string `json:"login_url"`
+}
+
+type AuthMethodsData struct {
+	LocalLoginEnabled bool            `json:"local_login_enabled"`
+	LDAPEnabled       bool            `json:"ldap_enabled"`
+	PasskeyEnabled    bool            `json:"passkey_enabled"`
+	OIDC              *OIDCMethodInfo `json:"oidc,omitempty"`
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"berth/internal/domain/auth/passkey"
+
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+const maxPasskeyNameLength = 100
+
+var (
+	ErrPasskeyNameRequired       = errors.New("name is required")
+	ErrPasskeyNameTooLong        = errors.New("name must be at most 100 characters")
+	ErrPasskeyCredentialRequired = errors.New("session_id and credential are required")
+)
+
+// PasskeyRegisterRequest completes a registration started with
+// /passkeys/register/begin. Credential is the PublicKeyCredential returned by
+// navigator.credentials.create, serialised with base64url-encoded buffers.
+type PasskeyRegisterRequest struct {
+	SessionID  string          `json:"session_id"`
+	Name       string          `json:"name"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+func (r *PasskeyRegisterRequest) Validate() error {
+	if r.SessionID == "" || len(r.Credential) == 0 {
+		return ErrPasskeyCredentialRequired
+	}
+	return validatePasskeyName(r.Name)
+}
+
+type PasskeyRenameRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *PasskeyRenameRequest) Validate() error {
+	return validatePasskeyName(r.Name)
+}
+
+// PasskeyAssertionRequest completes a login or second-factor check.
+// Credential is the PublicKeyCredential returned by navigator.credentials.get.
+type PasskeyAssertionRequest struct {
+	SessionID  string          `json:"session_id"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+func (r *PasskeyAssertionRequest) Validate() error {
+	if r.SessionID == "" || len(r.Credential) == 0 {
+		return ErrPasskeyCredentialRequired
+	}
+	return nil
+}
+
+func validatePasskeyName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ErrPasskeyNameRequired
+	}
+	if len(name) > maxPasskeyNameLength {
+		return ErrPasskeyNameTooLong
+	}
+	return nil
+}
+
+type PasskeyInfo struct {
+	ID             uint       `json:"id"`
+	Name           string     `json:"name"`
+	BackupEligible bool       `json:"backup_eligible"`
+	BackupState    bool       `json:"backup_state"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+}
+
+func ToPasskeyInfo(c *passkey.Credential) PasskeyInfo {
+	return PasskeyInfo{
+		ID:             c.ID,
+		Name:           c.Name,
+		BackupEligible: c.BackupEligible,
+		BackupState:    c.BackupState,
+		CreatedAt:      c.CreatedAt,
+		LastUsedAt:     c.LastUsedAt,
+	}
+}
+
+type PasskeyListData struct {
+	Passkeys []PasskeyInfo `json:"passkeys"`
+}
+
+type PasskeyData struct {
+	Passkey PasskeyInfo `json:"passkey"`
+}
+
+type PasskeyMessageData struct {
+	Message string `json:"message"`
+}
+
+// PasskeyRegistrationOptionsData carries the options to pass to
+// navigator.credentials.create and the session ID to send back with the
+// result.
+type PasskeyRegistrationOptionsData struct {
+	SessionID string                       `json:"session_id"`
+	Options   *protocol.CredentialCreation `json:"options"`
+}
+
+// PasskeyAssertionOptionsData carries the options to pass to
+// navigator.credentials.get and the session ID to send back with the result.
+type PasskeyAssertionOptionsData struct {
+	SessionID string                        `json:"session_id"`
+	Options   *protocol.CredentialAssertion `json:"options"`
+}
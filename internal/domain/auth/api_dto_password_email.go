@@ -66,3 +66,47 @@ func (r *AuthResendVerificationRequest) Validate() error {
 type AuthMessageData struct {
 	Message string `json:"message"`
 }
+
+var (
+	ErrProfilePasswordFieldsRequired = errors.New("Current password, new password and confirmation are required")
+	ErrProfileEmailFieldsRequired    = errors.New("Email and current password are required")
+)
+
+type ProfileChangePasswordRequest struct {
+	CurrentPassword      string `json:"current_password"`
+	Password             string `json:"password"`
+	PasswordConfirmation string `json:"password_confirmation"`
+}
+
+func (r *ProfileChangePasswordRequest) Validate() error {
+	if r.CurrentPassword == "" || r.Password == "" || r.PasswordConfirmation == "" {
+		return ErrProfilePasswordFieldsRequired
+	}
+	if r.Password != r.PasswordConfirmation {
+		return ErrAuthPasswordResetPasswordsMismatch
+	}
+	return nil
+}
+
+type ProfileChangeEmailRequest struct {
+	Email           string `json:"email"`
+	CurrentPassword string `json:"current_password"`
+}
+
+func (r *ProfileChangeEmailRequest) Validate() error {
+	if r.Email == "" || r.CurrentPassword == "" {
+		return ErrProfileEmailFieldsRequired
+	}
+	return nil
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+func (r *ConfirmEmailChangeRequest) Validate() error {
+	if r.Token == "" {
+		return ErrAuthVerifyEmailTokenRequired
+	}
+	return nil
+}
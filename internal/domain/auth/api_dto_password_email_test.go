@@ -107,3 +107,46 @@ func TestAuthResendVerificationRequest_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestProfileChangePasswordRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ProfileChangePasswordRequest
+		wantErr error
+	}{
+		{"empty current", ProfileChangePasswordRequest{Password: "pw", PasswordConfirmation: "pw"}, ErrProfilePasswordFieldsRequired},
+		{"empty new", ProfileChangePasswordRequest{CurrentPassword: "old"}, ErrProfilePasswordFieldsRequired},
+		{"mismatched", ProfileChangePasswordRequest{CurrentPassword: "old", Password: "pw1", PasswordConfirmation: "pw2"}, ErrAuthPasswordResetPasswordsMismatch},
+		{"valid", ProfileChangePasswordRequest{CurrentPassword: "old", Password: "pw", PasswordConfirmation: "pw"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProfileChangeEmailRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ProfileChangeEmailRequest
+		wantErr error
+	}{
+		{"empty email", ProfileChangeEmailRequest{CurrentPassword: "pw"}, ErrProfileEmailFieldsRequired},
+		{"empty password", ProfileChangeEmailRequest{Email: "new@example.com"}, ErrProfileEmailFieldsRequired},
+		{"valid", ProfileChangeEmailRequest{Email: "new@example.com", CurrentPassword: "pw"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
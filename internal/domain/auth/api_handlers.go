@@ -8,6 +8,10 @@ import (
 	"net/http"
 	"time"
 
+	"berth/internal/domain/auth/ldap"
+	"berth/internal/domain/auth/lockout"
+	"berth/internal/domain/auth/oidc"
+	"berth/internal/domain/auth/passkey"
 	"berth/internal/domain/auth/tokens"
 	"berth/internal/domain/auth/totp"
 	"berth/internal/domain/authz"
@@ -25,6 +29,7 @@ import (
 type apiAuditLogger interface {
 	LogAPIEvent(eventType string, userID *uint, username, ip, userAgent string, success bool, failureReason string, metadata map[string]any) error
 	LogAuthEvent(eventType string, userID *uint, username, ip, userAgent string, success bool, failureReason string, metadata map[string]any) error
+	Log(event security.LogEvent) error
 }
 
 type APIHandler struct {
@@ -33,6 +38,10 @@ type APIHandler struct {
 	tokens     *tokens.Service
 	totpSvc    *totp.Service
 	sessionSvc *session.Service
+	oidcSvc    *oidc.Service
+	ldapSvc    *ldap.Service
+	lockoutSvc *lockout.Service
+	passkeySvc *passkey.Service
 	logger     *zap.Logger
 	auditSvc   apiAuditLogger
 }
@@ -63,56 +72,27 @@ func (h *APIHandler) Login(c echo.Context) error {
 		return err
 	}
 
+	if !h.authSvc.IsLocalLoginEnabled() && h.ldapSvc == nil {
+		return response.Err(c, http.StatusForbidden, "local_login_disabled", "Password login is disabled; sign in with single sign-on")
+	}
+
 	h.logger.Info("mobile login attempt",
 		zap.String("username", req.Username),
 		zap.String("remote_ip", c.RealIP()),
 		zap.String("user_agent", c.Request().UserAgent()),
 	)
 
-	var user usermodel.User
-	if err := h.db.Preload("Roles").Where("username = ?", req.Username).First(&user).Error; err != nil {
-		h.logger.Warn("mobile login failed - user not found",
-			zap.String("username", req.Username),
-			zap.String("remote_ip", c.RealIP()),
-		)
-		_ = h.auditSvc.LogAPIEvent(
-			security.EventAPIAuthFailed,
-			nil,
-			req.Username,
-			c.RealIP(),
-			c.Request().UserAgent(),
-			false,
-			"user not found",
-			nil,
-		)
-		return response.Err(c, http.StatusUnauthorized, "invalid_credentials", "Invalid username or password")
-	}
-
-	if err := h.authSvc.VerifyPassword(user.Password, req.Password); err != nil {
-		h.logger.Warn("mobile login failed - invalid password",
-			zap.String("username", req.Username),
-			zap.Uint("user_id", user.ID),
-			zap.String("remote_ip", c.RealIP()),
-		)
-		_ = h.auditSvc.LogAPIEvent(
-			security.EventAPIAuthFailed,
-			&user.ID,
-			req.Username,
-			c.RealIP(),
-			c.Request().UserAgent(),
-			false,
-			"invalid password",
-			nil,
-		)
-		return response.Err(c, http.StatusUnauthorized, "invalid_credentials", "Invalid username or password")
+	user, method, err := h.authenticatePassword(c, req.Username, req.Password)
+	if user == nil {
+		return err
 	}
 
 	if h.authSvc.IsEmailVerificationRequired() && !h.authSvc.IsEmailVerified(user.Email) {
 		return response.Err(c, http.StatusForbidden, "email_not_verified", "Please verify your email before signing in")
 	}
 
-	if h.totpSvc.IsUserTOTPEnabled(user.ID) {
-		temporaryToken, err := h.tokens.IssueTOTPPendingToken(user.ID)
+	if secondFactors := h.secondFactorMethods(user.ID); len(secondFactors) > 0 {
+		temporaryToken, err := h.tokens.IssueTOTPPendingToken(user.ID, method)
 		if err != nil {
 			h.logger.Error("failed to generate TOTP token",
 				zap.Uint("user_id", user.ID),
@@ -128,12 +108,17 @@ func (h *APIHandler) Login(c echo.Context) error {
 		)
 
 		return response.OK(c, AuthTOTPRequiredData{
-			Message:        "Two-factor authentication required",
-			TOTPRequired:   true,
-			TemporaryToken: temporaryToken,
+			Message:             "Two-factor authentication required",
+			TOTPRequired:        true,
+			TemporaryToken:      temporaryToken,
+			SecondFactorMethods: secondFactors,
 		})
 	}
 
+	if handled, err := h.requireTwoFactorEnrollment(c, user, method); handled {
+		return err
+	}
+
 	accessToken, err := h.tokens.IssueAccessToken(user.ID)
 	if err != nil {
 		h.logger.Error("failed to generate access token",
@@ -163,7 +148,7 @@ func (h *APIHandler) Login(c echo.Context) error {
 	h.trackJWTSession(c, user.ID, accessToken, refreshTokenData)
 
 	now := time.Now()
-	if err := h.db.Model(&user).Update("last_login_at", now).Error; err != nil {
+	if err := h.db.Model(user).Update("last_login_at", now).Error; err != nil {
 		h.logger.Warn("failed to update last login time",
 			zap.Uint("user_id", user.ID),
 			zap.Error(err),
@@ -187,7 +172,10 @@ func (h *APIHandler) Login(c echo.Context) error {
 		nil,
 	)
 
-	userInfo := usermodel.ToUserInfo(user, h.totpSvc.IsUserTOTPEnabled(user.ID))
+	h.auditLoginSuccess(c, user, method)
+	h.recordAuthSuccess(user)
+
+	userInfo := usermodel.ToUserInfo(*user, h.totpSvc.IsUserTOTPEnabled(user.ID))
 
 	return response.OK(c, AuthLoginData{
 		AccessToken:      accessToken,
@@ -290,7 +278,7 @@ func (h *APIHandler) Profile(c echo.Context) error {
 	}
 
 	var fullUser usermodel.User
-	if err := h.db.Preload("Roles").Where("id = ?", p.UserID()).First(&fullUser).Error; err != nil {
+	if err := usermodel.FirstWithActiveRoles(h.db, &fullUser, p.UserID()); err != nil {
 		return response.Err(c, http.StatusInternalServerError, "database_error", "Failed to load user profile")
 	}
 
@@ -401,25 +389,43 @@ func (h *APIHandler) VerifyTOTP(c echo.Context) error {
 		return response.Err(c, http.StatusUnauthorized, "unauthorized", "Invalid or expired token")
 	}
 
-	if err := h.totpSvc.VerifyUserCode(claims.UserID, req.Code); err != nil {
-		var user usermodel.User
-		if dbErr := h.db.First(&user, claims.UserID).Error; dbErr == nil {
-			_ = h.auditSvc.LogAPIEvent(
-				security.EventAPIAuthFailed,
-				&user.ID,
-				user.Username,
-				c.RealIP(),
-				c.Request().UserAgent(),
-				false,
-				"TOTP verification failed",
-				nil,
-			)
+	var user usermodel.User
+	if err := h.db.First(&user, claims.UserID).Error; err != nil {
+		return response.Err(c, http.StatusInternalServerError, "user_not_found", "User not found")
+	}
+
+	if locked, err := h.rejectIfLocked(c, &user); locked {
+		return err
+	}
+
+	usedRecovery, err := h.verifyTOTPOrRecoveryCode(c, &user, req.Code)
+	if err != nil {
+		failureReason := "TOTP verification failed"
+		if usedRecovery {
+			failureReason = "recovery code verification failed"
+		}
+		_ = h.auditSvc.LogAPIEvent(
+			security.EventAPIAuthFailed,
+			&user.ID,
+			user.Username,
+			c.RealIP(),
+			c.Request().UserAgent(),
+			false,
+			failureReason,
+			nil,
+		)
+		if isInvalidSecondFactorCode(err) {
+			if until, locked := h.recordAuthFailure(c, &user); locked {
+				return h.lockedResponse(c, until)
+			}
 		}
 		switch err {
 		case totp.ErrInvalidCode:
 			return response.Err(c, http.StatusUnauthorized, "invalid_totp_code", "Invalid TOTP code")
 		case totp.ErrCodeAlreadyUsed:
 			return response.Err(c, http.StatusUnauthorized, "code_already_used", "TOTP code has already been used")
+		case totp.ErrInvalidRecoveryCode:
+			return response.Err(c, http.StatusUnauthorized, "invalid_recovery_code", "Invalid or already used recovery code")
 		}
 		h.logger.Error("TOTP verification failed",
 			zap.Uint("user_id", claims.UserID),
@@ -428,11 +434,6 @@ func (h *APIHandler) VerifyTOTP(c echo.Context) error {
 		return response.Err(c, http.StatusInternalServerError, "totp_verification_failed", "Failed to verify TOTP code")
 	}
 
-	var user usermodel.User
-	if err := h.db.First(&user, claims.UserID).Error; err != nil {
-		return response.Err(c, http.StatusInternalServerError, "user_not_found", "User not found")
-	}
-
 	accessToken, err := h.tokens.IssueAccessToken(claims.UserID)
 	if err != nil {
 		h.logger.Error("failed to generate access token after TOTP verification",
@@ -483,10 +484,14 @@ func (h *APIHandler) VerifyTOTP(c echo.Context) error {
 		true,
 		"",
 		map[string]any{
-			"totp_verified": true,
+			"totp_verified":      true,
+			"recovery_code_used": usedRecovery,
 		},
 	)
 
+	h.auditLoginSuccess(c, &user, claims.AuthMethod)
+	h.recordAuthSuccess(&user)
+
 	return response.OK(c, AuthLoginData{
 		AccessToken:      accessToken,
 		RefreshToken:     refreshTokenData.Token,
@@ -554,7 +559,8 @@ func (h *APIHandler) EnableTOTP(c echo.Context) error {
 		return err
 	}
 
-	if err := h.totpSvc.EnableTOTP(userModel.ID, req.Code); err != nil {
+	recoveryCodes, err := h.totpSvc.EnableTOTP(userModel.ID, req.Code)
+	if err != nil {
 		if err == totp.ErrInvalidCode {
 			return response.Err(c, http.StatusBadRequest, "invalid_totp_code", "Invalid TOTP code. Please try again.")
 		}
@@ -572,8 +578,9 @@ func (h *APIHandler) EnableTOTP(c echo.Context) error {
 		nil,
 	)
 
-	return response.OK(c, TOTPMessageData{
-		Message: "Two-factor authentication has been enabled successfully",
+	return response.OK(c, TOTPEnableData{
+		Message:       "Two-factor authentication has been enabled successfully",
+		RecoveryCodes: recoveryCodes,
 	})
 }
 
@@ -597,7 +604,7 @@ func (h *APIHandler) DisableTOTP(c echo.Context) error {
 		return response.Err(c, http.StatusUnauthorized, "invalid_password", "Invalid password")
 	}
 
-	if err := h.totpSvc.VerifyUserCode(userModel.ID, req.Code); err != nil {
+	if _, err := h.verifyTOTPOrRecoveryCode(c, &userModel, req.Code); err != nil {
 		return response.Err(c, http.StatusUnauthorized, "invalid_totp_code", "Invalid TOTP code")
 	}
 
@@ -634,7 +641,11 @@ func (h *APIHandler) GetTOTPStatus(c echo.Context) error {
 
 	enabled := h.totpSvc.IsUserTOTPEnabled(userModel.ID)
 
-	return response.OK(c, TOTPStatusData{Enabled: enabled})
+	status := TOTPStatusData{Enabled: enabled}
+	if enabled {
+		status.RecoveryCodesRemaining = h.totpSvc.RecoveryCodesRemaining(userModel.ID)
+	}
+	return response.OK(c, status)
 }
 
 func (h *APIHandler) GetSessions(c echo.Context) error {
@@ -826,3 +837,11 @@ func toBool(v any) bool {
 	}
 	return false
 }
+
+// JWKS serves the public keys that verify access tokens. The response is a
+// bare RFC 7517 key set rather than the usual envelope so that standard JWT
+// libraries can consume it directly.
+func (h *APIHandler) JWKS(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(http.StatusOK, h.tokens.JWKS())
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"berth/internal/domain/security"
+	usermodel "berth/internal/domain/user"
+
+	"github.com/labstack/echo/v4"
+)
+
+// federatedUserChanges describes what a login through an external identity
+// source did to the local account.
+type federatedUserChanges struct {
+	source       string
+	provisioned  bool
+	created      map[string]any
+	emailChanged bool
+	assigned     []string
+	revoked      []string
+}
+
+// auditFederatedUserChanges records account changes made on the user's behalf
+// during an external login. The user is both actor and target, and the
+// metadata names the source that made the change.
+func (h *APIHandler) auditFederatedUserChanges(c echo.Context, user *usermodel.User, changes federatedUserChanges) {
+	logUserEvent := func(eventType string, metadata map[string]any) {
+		metadata["source"] = changes.source
+		_ = h.auditSvc.Log(security.LogEvent{
+			EventType:      eventType,
+			Success:        true,
+			ActorUserID:    &user.ID,
+			ActorUsername:  user.Username,
+			ActorIP:        c.RealIP(),
+			ActorUserAgent: c.Request().UserAgent(),
+			TargetUserID:   &user.ID,
+			TargetType:     security.TargetTypeUser,
+			TargetID:       &user.ID,
+			TargetName:     user.Email,
+			Metadata:       metadata,
+		})
+	}
+
+	if changes.provisioned {
+		metadata := map[string]any{"username": user.Username}
+		for k, v := range changes.created {
+			metadata[k] = v
+		}
+		logUserEvent(security.EventUserCreated, metadata)
+	}
+	if changes.emailChanged {
+		logUserEvent(security.EventUserEmailChanged, map[string]any{"email": user.Email})
+	}
+	for _, role := range changes.assigned {
+		logUserEvent(security.EventUserRoleAssigned, map[string]any{"role_name": role})
+	}
+	for _, role := range changes.revoked {
+		logUserEvent(security.EventUserRoleRevoked, map[string]any{"role_name": role})
+	}
+}
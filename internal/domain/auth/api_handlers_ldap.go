@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"berth/internal/domain/auth/ldap"
+	"berth/internal/domain/security"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/response"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	authMethodLocal = "local"
+	authMethodLDAP  = "ldap"
+)
+
+// SetLDAPService enables directory logins on the password login endpoint.
+func (h *APIHandler) SetLDAPService(svc *ldap.Service) {
+	h.ldapSvc = svc
+}
+
+// authenticatePassword checks the credentials against the local account
+// first and then the directory, returning the user and the backend that
+// accepted them. Local accounts stay usable while LDAP is enabled so
+// administrators keep a way in when the directory is down. A nil user means
+// the failure response has already been written.
+func (h *APIHandler) authenticatePassword(c echo.Context, username, password string) (*usermodel.User, string, error) {
+	var local usermodel.User
+	localFound := usermodel.FirstWithActiveRoles(h.db.Where("username = ?", username), &local) == nil
+
+	if localFound {
+		if locked, err := h.rejectIfLocked(c, &local); locked {
+			return nil, "", err
+		}
+	}
+
+	if localFound && h.authSvc.IsLocalLoginEnabled() {
+		if err := h.authSvc.VerifyPassword(local.Password, password); err == nil {
+			return &local, authMethodLocal, nil
+		}
+	}
+
+	if h.ldapSvc != nil {
+		result, err := h.ldapSvc.Authenticate(username, password)
+		if err == nil {
+			h.auditFederatedUserChanges(c, result.User, federatedUserChanges{
+				source:       authMethodLDAP,
+				provisioned:  result.Provisioned,
+				created:      map[string]any{"dn": result.DN},
+				emailChanged: result.EmailUpdated,
+				assigned:     result.RolesAssigned,
+				revoked:      result.RolesRevoked,
+			})
+			if !localFound || result.User.ID != local.ID {
+				if locked, err := h.rejectIfLocked(c, result.User); locked {
+					return nil, "", err
+				}
+			}
+			return result.User, authMethodLDAP, nil
+		}
+
+		if errors.Is(err, ldap.ErrUnavailable) {
+			h.logger.Error("LDAP login failed - directory unavailable",
+				zap.String("username", username),
+				zap.Error(err),
+			)
+			_ = h.auditSvc.LogAPIEvent(
+				security.EventAPIAuthFailed,
+				nil,
+				username,
+				c.RealIP(),
+				c.Request().UserAgent(),
+				false,
+				"directory unavailable",
+				map[string]any{"method": authMethodLDAP},
+			)
+			return nil, "", response.Err(c, http.StatusServiceUnavailable, "directory_unavailable", "The user directory is unavailable; try again later")
+		}
+
+		if !errors.Is(err, ldap.ErrInvalidCredentials) {
+			h.logger.Warn("LDAP login rejected",
+				zap.String("username", username),
+				zap.Error(err),
+			)
+		}
+	}
+
+	var userID *uint
+	reason := "user not found"
+	switch {
+	case localFound:
+		userID = &local.ID
+		reason = "invalid password"
+	case h.ldapSvc != nil:
+		reason = "invalid credentials"
+	}
+	h.logger.Warn("mobile login failed - "+reason,
+		zap.String("username", username),
+		zap.String("remote_ip", c.RealIP()),
+	)
+	_ = h.auditSvc.LogAPIEvent(
+		security.EventAPIAuthFailed,
+		userID,
+		username,
+		c.RealIP(),
+		c.Request().UserAgent(),
+		false,
+		reason,
+		nil,
+	)
+	if localFound {
+		if until, locked := h.recordAuthFailure(c, &local); locked {
+			return nil, "", h.lockedResponse(c, until)
+		}
+	}
+	return nil, "", response.Err(c, http.StatusUnauthorized, "invalid_credentials", "Invalid username or password")
+}
+
+// auditLoginSuccess records a completed password login that was verified by
+// an external backend, naming the backend. Local logins are already covered
+// by the token issuance event.
+func (h *APIHandler) auditLoginSuccess(c echo.Context, user *usermodel.User, method string) {
+	if method == "" || method == authMethodLocal {
+		return
+	}
+	_ = h.auditSvc.LogAuthEvent(
+		security.EventAuthLoginSuccess,
+		&user.ID,
+		user.Username,
+		c.RealIP(),
+		c.Request().UserAgent(),
+		true,
+		"",
+		map[string]any{
+			"method": method,
+		},
+	)
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"berth/internal/domain/auth/lockout"
+	"berth/internal/domain/security"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/response"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// SetLockoutService enables per-account lockout after repeated failed
+// password or TOTP attempts.
+func (h *APIHandler) SetLockoutService(svc *lockout.Service) {
+	h.lockoutSvc = svc
+}
+
+// rejectIfLocked writes a 423 response when the account is locked and reports
+// whether it did. Attempts against a locked account are not verified and do
+// not extend the lockout.
+func (h *APIHandler) rejectIfLocked(c echo.Context, user *usermodel.User) (bool, error) {
+	if h.lockoutSvc == nil || !h.lockoutSvc.Enabled() {
+		return false, nil
+	}
+
+	until, locked, err := h.lockoutSvc.Check(user.ID)
+	if err != nil {
+		h.logger.Error("failed to check account lockout",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		return false, nil
+	}
+	if !locked {
+		return false, nil
+	}
+
+	_ = h.auditSvc.LogAPIEvent(
+		security.EventAPIAuthFailed,
+		&user.ID,
+		user.Username,
+		c.RealIP(),
+		c.Request().UserAgent(),
+		false,
+		"account locked",
+		map[string]any{"locked_until": until.UTC()},
+	)
+	return true, h.lockedResponse(c, until)
+}
+
+// recordAuthFailure counts a failed attempt against the account and returns
+// the lockout expiry when this attempt locked it.
+func (h *APIHandler) recordAuthFailure(c echo.Context, user *usermodel.User) (time.Time, bool) {
+	if h.lockoutSvc == nil || !h.lockoutSvc.Enabled() {
+		return time.Time{}, false
+	}
+
+	result, err := h.lockoutSvc.RecordFailure(user, c.RealIP())
+	if err != nil {
+		h.logger.Error("failed to record failed login attempt",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		return time.Time{}, false
+	}
+	if !result.Locked {
+		return time.Time{}, false
+	}
+
+	h.logger.Warn("account locked after repeated failed logins",
+		zap.Uint("user_id", user.ID),
+		zap.String("username", user.Username),
+		zap.Time("locked_until", result.LockedUntil),
+		zap.String("remote_ip", c.RealIP()),
+	)
+	_ = h.auditSvc.Log(security.LogEvent{
+		EventType:      security.EventAuthAccountLocked,
+		ActorUsername:  user.Username,
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetUserID:   &user.ID,
+		TargetType:     security.TargetTypeUser,
+		TargetID:       &user.ID,
+		TargetName:     user.Username,
+		Success:        true,
+		Metadata: map[string]any{
+			"locked_until":  result.LockedUntil.UTC(),
+			"lockout_count": result.LockoutCount,
+		},
+	})
+	return result.LockedUntil, true
+}
+
+// recordAuthSuccess clears the failure count once a sign-in has completed.
+func (h *APIHandler) recordAuthSuccess(user *usermodel.User) {
+	if h.lockoutSvc == nil {
+		return
+	}
+	if err := h.lockoutSvc.RecordSuccess(user.ID); err != nil {
+		h.logger.Warn("failed to reset failed login attempts",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+func (h *APIHandler) lockedResponse(c echo.Context, until time.Time) error {
+	retryAfter := int(math.Ceil(time.Until(until).Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	return response.Err(c, http.StatusLocked, "account_locked", "Account is temporarily locked after too many failed attempts; try again later")
+}
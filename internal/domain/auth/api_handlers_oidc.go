@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"berth/internal/domain/auth/oidc"
+	"berth/internal/domain/auth/tokens"
+	"berth/internal/domain/security"
+	"berth/internal/pkg/response"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	oidcStateCookieName = "berth_oidc_state"
+	oidcStateCookiePath = "/api/v1/auth/oidc"
+	oidcLoginPath       = "/api/v1/auth/oidc/login"
+
+	// The web UI picks the session up from the refresh cookie once it loads.
+	oidcSuccessRedirect = "/"
+	oidcFailureRedirect = "/auth/login"
+)
+
+// SetOIDCService enables single sign-on through an OpenID Connect provider.
+func (h *APIHandler) SetOIDCService(svc *oidc.Service) {
+	h.oidcSvc = svc
+}
+
+func (h *APIHandler) LoginMethods(c echo.Context) error {
+	data := AuthMethodsData{
+		LocalLoginEnabled: h.authSvc.IsLocalLoginEnabled(),
+		LDAPEnabled:       h.ldapSvc != nil,
+		PasskeyEnabled:    h.passkeySvc != nil,
+	}
+	if h.oidcSvc != nil {
+		data.OIDC = &OIDCMethodInfo{
+			ProviderName: h.oidcSvc.ProviderName(),
+			LoginURL:     oidcLoginPath,
+		}
+	}
+	return response.OK(c, data)
+}
+
+func (h *APIHandler) OIDCLogin(c echo.Context) error {
+	if h.oidcSvc == nil {
+		return response.Err(c, http.StatusNotFound, "oidc_disabled", "Single sign-on is not enabled")
+	}
+
+	authURL, state, err := h.oidcSvc.BeginLogin(c.Request().Context())
+	if err != nil {
+		h.logger.Error("failed to start OIDC login", zap.Error(err))
+		return response.Err(c, http.StatusBadGateway, "oidc_unavailable", "The identity provider is unavailable")
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    state,
+		Path:     oidcStateCookiePath,
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		// Lax so the cookie survives the top-level redirect back from the
+		// provider.
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+func (h *APIHandler) OIDCCallback(c echo.Context) error {
+	if h.oidcSvc == nil {
+		return response.Err(c, http.StatusNotFound, "oidc_disabled", "Single sign-on is not enabled")
+	}
+
+	cookieState := ""
+	if cookie, err := c.Cookie(oidcStateCookieName); err == nil {
+		cookieState = cookie.Value
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    "",
+		Path:     oidcStateCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if providerErr := c.QueryParam("error"); providerErr != "" {
+		return h.oidcFailure(c, nil, "", "provider returned "+providerErr, "denied")
+	}
+
+	// The state must match the one set on this browser, so a callback URL
+	// started by someone else cannot log the browser into their account.
+	state := c.QueryParam("state")
+	if cookieState == "" || subtle.ConstantTimeCompare([]byte(cookieState), []byte(state)) != 1 {
+		return h.oidcFailure(c, nil, "", "state mismatch", "state_invalid")
+	}
+
+	result, err := h.oidcSvc.CompleteLogin(c.Request().Context(), state, c.QueryParam("code"))
+	if err != nil {
+		code := "failed"
+		switch {
+		case errors.Is(err, oidc.ErrStateInvalid):
+			code = "state_invalid"
+		case errors.Is(err, oidc.ErrAccountNotFound):
+			code = "account_not_found"
+		case errors.Is(err, oidc.ErrEmailMissing), errors.Is(err, oidc.ErrEmailNotVerified):
+			code = "email_unverified"
+		}
+		return h.oidcFailure(c, nil, "", err.Error(), code)
+	}
+
+	user := result.User
+	h.auditFederatedUserChanges(c, user, federatedUserChanges{
+		source:      "oidc",
+		provisioned: result.Provisioned,
+		created:     map[string]any{"subject": result.Subject},
+		assigned:    result.RolesAssigned,
+		revoked:     result.RolesRevoked,
+	})
+
+	if h.authSvc.IsEmailVerificationRequired() && !h.authSvc.IsEmailVerified(user.Email) {
+		return h.oidcFailure(c, &user.ID, user.Username, "email not verified", "email_not_verified")
+	}
+
+	sessionInfo := tokens.SessionInfo{
+		IPAddress:  c.RealIP(),
+		UserAgent:  c.Request().UserAgent(),
+		DeviceInfo: GetDeviceInfo(c.Request().UserAgent()),
+	}
+
+	refreshTokenData, err := h.tokens.IssueRefresh(user.ID, sessionInfo)
+	if err != nil {
+		h.logger.Error("failed to generate refresh token after OIDC login",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		return c.Redirect(http.StatusFound, oidcFailureURL("failed"))
+	}
+
+	setRefreshCookie(c, refreshTokenData.Token, refreshTokenData.ExpiresAt)
+
+	if h.sessionSvc != nil {
+		if err := h.sessionSvc.TrackJWTSessionWithRefreshToken(user.ID, "", refreshTokenData.TokenID, c.RealIP(), c.Request().UserAgent(), refreshTokenData.ExpiresAt); err != nil {
+			h.logger.Warn("failed to track JWT session",
+				zap.Uint("user_id", user.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if err := h.db.Model(user).Update("last_login_at", time.Now()).Error; err != nil {
+		h.logger.Warn("failed to update last login time",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+	}
+
+	h.logger.Info("OIDC login successful",
+		zap.String("username", user.Username),
+		zap.Uint("user_id", user.ID),
+		zap.String("remote_ip", c.RealIP()),
+	)
+
+	_ = h.auditSvc.LogAuthEvent(
+		security.EventAuthLoginSuccess,
+		&user.ID,
+		user.Username,
+		c.RealIP(),
+		c.Request().UserAgent(),
+		true,
+		"",
+		map[string]any{
+			"method":      "oidc",
+			"issuer":      h.oidcSvc.Issuer(),
+			"subject":     result.Subject,
+			"provisioned": result.Provisioned,
+			"linked":      result.Linked,
+		},
+	)
+
+	return c.Redirect(http.StatusFound, oidcSuccessRedirect)
+}
+
+func (h *APIHandler) oidcFailure(c echo.Context, userID *uint, username, reason, code string) error {
+	h.logger.Warn("OIDC login failed",
+		zap.String("reason", reason),
+		zap.String("remote_ip", c.RealIP()),
+	)
+
+	_ = h.auditSvc.LogAuthEvent(
+		security.EventAuthLoginFailure,
+		userID,
+		username,
+		c.RealIP(),
+		c.Request().UserAgent(),
+		false,
+		reason,
+		map[string]any{
+			"method": "oidc",
+		},
+	)
+
+	return c.Redirect(http.StatusFound, oidcFailureURL(code))
+}
+
+func oidcFailureURL(code string) string {
+	return oidcFailureRedirect + "?" + url.Values{"sso_error": {code}}.Encode()
+}
@@ -0,0 +1,457 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"berth/internal/domain/auth/passkey"
+	"berth/internal/domain/auth/tokens"
+	"berth/internal/domain/security"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	authMethodPasskey = "passkey"
+
+	secondFactorTOTP    = "totp"
+	secondFactorPasskey = "passkey"
+)
+
+// SetPasskeyService enables WebAuthn credentials as a second factor and for
+// passwordless login.
+func (h *APIHandler) SetPasskeyService(svc *passkey.Service) {
+	h.passkeySvc = svc
+}
+
+// secondFactorMethods lists the second factors the user can complete login
+// with. An empty list means no second factor is required.
+func (h *APIHandler) secondFactorMethods(userID uint) []string {
+	var methods []string
+	if h.totpSvc.IsUserTOTPEnabled(userID) {
+		methods = append(methods, secondFactorTOTP)
+	}
+	if h.passkeySvc != nil && h.passkeySvc.HasCredentials(userID) {
+		methods = append(methods, secondFactorPasskey)
+	}
+	return methods
+}
+
+func (h *APIHandler) ListPasskeys(c echo.Context) error {
+	user, err := h.passkeyUser(c)
+	if user == nil {
+		return err
+	}
+
+	credentials, err := h.passkeySvc.ListCredentials(user.ID)
+	if err != nil {
+		return response.Err(c, http.StatusInternalServerError, "passkey_list_failed", "Failed to fetch passkeys")
+	}
+
+	passkeys := make([]PasskeyInfo, len(credentials))
+	for i := range credentials {
+		passkeys[i] = ToPasskeyInfo(&credentials[i])
+	}
+	return response.OK(c, PasskeyListData{Passkeys: passkeys})
+}
+
+func (h *APIHandler) BeginPasskeyRegistration(c echo.Context) error {
+	user, err := h.passkeyUser(c)
+	if user == nil {
+		return err
+	}
+
+	options, sessionID, err := h.passkeySvc.BeginRegistration(user)
+	if err != nil {
+		h.logger.Error("failed to start passkey registration",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		return response.Err(c, http.StatusInternalServerError, "passkey_registration_failed", "Failed to start passkey registration")
+	}
+
+	return response.OK(c, PasskeyRegistrationOptionsData{
+		SessionID: sessionID,
+		Options:   options,
+	})
+}
+
+func (h *APIHandler) FinishPasskeyRegistration(c echo.Context) error {
+	user, err := h.passkeyUser(c)
+	if user == nil {
+		return err
+	}
+
+	var req PasskeyRegisterRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	credential, err := h.passkeySvc.FinishRegistration(user, req.SessionID, req.Name, req.Credential)
+	if err != nil {
+		switch {
+		case errors.Is(err, passkey.ErrCeremonyInvalid):
+			return response.Err(c, http.StatusBadRequest, "passkey_challenge_invalid", "Passkey challenge is invalid or has expired")
+		case errors.Is(err, passkey.ErrAlreadyRegistered):
+			return response.Err(c, http.StatusConflict, "passkey_already_registered", "This passkey is already registered")
+		case errors.Is(err, passkey.ErrVerificationFailed):
+			h.logger.Warn("passkey registration rejected",
+				zap.Uint("user_id", user.ID),
+				zap.Error(err),
+			)
+			return response.Err(c, http.StatusBadRequest, "passkey_verification_failed", "Passkey could not be verified")
+		}
+		h.logger.Error("failed to register passkey",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		return response.Err(c, http.StatusInternalServerError, "passkey_registration_failed", "Failed to register passkey")
+	}
+
+	h.auditPasskeyEvent(c, security.EventPasskeyRegistered, user, credential, true, "", map[string]any{
+		"backup_eligible": credential.BackupEligible,
+	})
+
+	return response.Created(c, PasskeyData{Passkey: ToPasskeyInfo(credential)})
+}
+
+func (h *APIHandler) RenamePasskey(c echo.Context) error {
+	user, err := h.passkeyUser(c)
+	if user == nil {
+		return err
+	}
+
+	id, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var req PasskeyRenameRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	credential, err := h.passkeySvc.RenameCredential(user.ID, id, req.Name)
+	if err != nil {
+		if errors.Is(err, passkey.ErrCredentialNotFound) {
+			return response.NotFound(c, "Passkey not found")
+		}
+		return response.Err(c, http.StatusInternalServerError, "passkey_update_failed", "Failed to rename passkey")
+	}
+
+	h.auditPasskeyEvent(c, security.EventPasskeyRenamed, user, credential, true, "", nil)
+
+	return response.OK(c, PasskeyData{Passkey: ToPasskeyInfo(credential)})
+}
+
+func (h *APIHandler) DeletePasskey(c echo.Context) error {
+	user, err := h.passkeyUser(c)
+	if user == nil {
+		return err
+	}
+
+	id, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	credential, err := h.passkeySvc.DeleteCredential(user.ID, id)
+	if err != nil {
+		if errors.Is(err, passkey.ErrCredentialNotFound) {
+			return response.NotFound(c, "Passkey not found")
+		}
+		return response.Err(c, http.StatusInternalServerError, "passkey_delete_failed", "Failed to remove passkey")
+	}
+
+	h.auditPasskeyEvent(c, security.EventPasskeyRemoved, user, credential, true, "", nil)
+
+	return response.OK(c, PasskeyMessageData{Message: "Passkey removed"})
+}
+
+// BeginPasskeyLogin starts a passwordless login with a discoverable
+// credential.
+func (h *APIHandler) BeginPasskeyLogin(c echo.Context) error {
+	if h.passkeySvc == nil {
+		return response.Err(c, http.StatusNotFound, "passkeys_disabled", "Passkeys are not enabled")
+	}
+
+	options, sessionID, err := h.passkeySvc.BeginPasswordlessLogin()
+	if err != nil {
+		h.logger.Error("failed to start passkey login", zap.Error(err))
+		return response.Err(c, http.StatusInternalServerError, "passkey_login_failed", "Failed to start passkey login")
+	}
+
+	return response.OK(c, PasskeyAssertionOptionsData{
+		SessionID: sessionID,
+		Options:   options,
+	})
+}
+
+// FinishPasskeyLogin completes a passwordless login. The authenticator has
+// verified the user, so no further second factor is asked for.
+func (h *APIHandler) FinishPasskeyLogin(c echo.Context) error {
+	if h.passkeySvc == nil {
+		return response.Err(c, http.StatusNotFound, "passkeys_disabled", "Passkeys are not enabled")
+	}
+
+	var req PasskeyAssertionRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	user, credential, err := h.passkeySvc.FinishPasswordlessLogin(req.SessionID, req.Credential)
+	if err != nil {
+		return h.passkeyAssertionFailure(c, nil, err, "passwordless")
+	}
+
+	if locked, err := h.rejectIfLocked(c, user); locked {
+		return err
+	}
+
+	if h.authSvc.IsEmailVerificationRequired() && !h.authSvc.IsEmailVerified(user.Email) {
+		return response.Err(c, http.StatusForbidden, "email_not_verified", "Please verify your email before signing in")
+	}
+
+	h.auditPasskeyEvent(c, security.EventPasskeyVerificationSuccess, user, credential, true, "", map[string]any{
+		"mode": "passwordless",
+	})
+
+	return h.completePasskeyLogin(c, user, authMethodPasskey)
+}
+
+// BeginPasskeyVerification starts the passkey second factor for a login
+// that returned a temporary token.
+func (h *APIHandler) BeginPasskeyVerification(c echo.Context) error {
+	if h.passkeySvc == nil {
+		return response.Err(c, http.StatusNotFound, "passkeys_disabled", "Passkeys are not enabled")
+	}
+
+	claims, err := h.secondFactorClaims(c)
+	if claims == nil {
+		return err
+	}
+
+	var user usermodel.User
+	if err := h.db.First(&user, claims.UserID).Error; err != nil {
+		return response.Err(c, http.StatusUnauthorized, "unauthorized", "Invalid or expired token")
+	}
+
+	options, sessionID, err := h.passkeySvc.BeginLogin(&user)
+	if err != nil {
+		if errors.Is(err, passkey.ErrNoCredentials) {
+			return response.Err(c, http.StatusBadRequest, "no_passkeys", "No passkeys are registered for this account")
+		}
+		h.logger.Error("failed to start passkey verification",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		return response.Err(c, http.StatusInternalServerError, "passkey_login_failed", "Failed to start passkey verification")
+	}
+
+	return response.OK(c, PasskeyAssertionOptionsData{
+		SessionID: sessionID,
+		Options:   options,
+	})
+}
+
+// FinishPasskeyVerification completes the passkey second factor and issues
+// tokens, like /auth/totp/verify.
+func (h *APIHandler) FinishPasskeyVerification(c echo.Context) error {
+	if h.passkeySvc == nil {
+		return response.Err(c, http.StatusNotFound, "passkeys_disabled", "Passkeys are not enabled")
+	}
+
+	var req PasskeyAssertionRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	claims, err := h.secondFactorClaims(c)
+	if claims == nil {
+		return err
+	}
+
+	var user usermodel.User
+	if err := h.db.First(&user, claims.UserID).Error; err != nil {
+		return response.Err(c, http.StatusUnauthorized, "unauthorized", "Invalid or expired token")
+	}
+
+	if locked, err := h.rejectIfLocked(c, &user); locked {
+		return err
+	}
+
+	credential, err := h.passkeySvc.FinishLogin(&user, req.SessionID, req.Credential)
+	if err != nil {
+		return h.passkeyAssertionFailure(c, &user, err, "second_factor")
+	}
+
+	h.auditPasskeyEvent(c, security.EventPasskeyVerificationSuccess, &user, credential, true, "", map[string]any{
+		"mode": "second_factor",
+	})
+
+	return h.completePasskeyLogin(c, &user, claims.AuthMethod)
+}
+
+// secondFactorClaims validates the temporary token from /auth/login. A nil
+// result means the failure response has already been written.
+func (h *APIHandler) secondFactorClaims(c echo.Context) (*tokens.Claims, error) {
+	authHeader := c.Request().Header.Get("Authorization")
+	if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
+		return nil, response.Err(c, http.StatusUnauthorized, "unauthorized", "Missing or invalid authorization header")
+	}
+
+	claims, err := h.tokens.ValidateTOTPPending(authHeader[7:])
+	if err != nil {
+		if errors.Is(err, tokens.ErrInvalidTokenType) {
+			return nil, response.Err(c, http.StatusUnauthorized, "invalid_token_type", "Invalid token for second-factor verification")
+		}
+		return nil, response.Err(c, http.StatusUnauthorized, "unauthorized", "Invalid or expired token")
+	}
+	return claims, nil
+}
+
+func (h *APIHandler) passkeyAssertionFailure(c echo.Context, user *usermodel.User, err error, mode string) error {
+	if errors.Is(err, passkey.ErrCeremonyInvalid) {
+		return response.Err(c, http.StatusBadRequest, "passkey_challenge_invalid", "Passkey challenge is invalid or has expired")
+	}
+	if !errors.Is(err, passkey.ErrVerificationFailed) && !errors.Is(err, passkey.ErrCredentialNotFound) {
+		h.logger.Error("passkey verification failed",
+			zap.String("mode", mode),
+			zap.Error(err),
+		)
+		return response.Err(c, http.StatusInternalServerError, "passkey_login_failed", "Failed to verify passkey")
+	}
+
+	h.logger.Warn("passkey verification rejected",
+		zap.String("mode", mode),
+		zap.String("remote_ip", c.RealIP()),
+		zap.Error(err),
+	)
+	h.auditPasskeyEvent(c, security.EventPasskeyVerificationFailure, user, nil, false, "passkey verification failed", map[string]any{
+		"mode": mode,
+	})
+	return response.Err(c, http.StatusUnauthorized, "passkey_verification_failed", "Passkey could not be verified")
+}
+
+// completePasskeyLogin issues access and refresh tokens once a passkey has
+// completed the login.
+func (h *APIHandler) completePasskeyLogin(c echo.Context, user *usermodel.User, method string) error {
+	accessToken, err := h.tokens.IssueAccessToken(user.ID)
+	if err != nil {
+		h.logger.Error("failed to generate access token after passkey verification",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		return response.Err(c, http.StatusInternalServerError, "token_generation_failed", "Failed to generate authentication token")
+	}
+
+	sessionInfo := tokens.SessionInfo{
+		IPAddress:  c.RealIP(),
+		UserAgent:  c.Request().UserAgent(),
+		DeviceInfo: GetDeviceInfo(c.Request().UserAgent()),
+	}
+
+	refreshTokenData, err := h.tokens.IssueRefresh(user.ID, sessionInfo)
+	if err != nil {
+		h.logger.Error("failed to generate refresh token after passkey verification",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		return response.Err(c, http.StatusInternalServerError, "token_generation_failed", "Failed to generate refresh token")
+	}
+
+	setRefreshCookie(c, refreshTokenData.Token, refreshTokenData.ExpiresAt)
+
+	h.trackJWTSession(c, user.ID, accessToken, refreshTokenData)
+
+	if err := h.db.Model(user).Update("last_login_at", time.Now()).Error; err != nil {
+		h.logger.Warn("failed to update last login time",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+	}
+
+	h.logger.Info("passkey login successful",
+		zap.Uint("user_id", user.ID),
+		zap.String("method", method),
+		zap.String("remote_ip", c.RealIP()),
+	)
+
+	_ = h.auditSvc.LogAPIEvent(
+		security.EventAPITokenIssued,
+		&user.ID,
+		user.Username,
+		c.RealIP(),
+		c.Request().UserAgent(),
+		true,
+		"",
+		map[string]any{
+			"passkey_verified": true,
+		},
+	)
+
+	h.auditLoginSuccess(c, user, method)
+	h.recordAuthSuccess(user)
+
+	return response.OK(c, AuthLoginData{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshTokenData.Token,
+		TokenType:        "Bearer",
+		ExpiresIn:        h.tokens.GetAccessExpirySeconds(),
+		RefreshExpiresIn: int(time.Until(refreshTokenData.ExpiresAt).Seconds()),
+		User:             usermodel.ToUserInfo(*user, h.totpSvc.IsUserTOTPEnabled(user.ID)),
+	})
+}
+
+// passkeyUser resolves the signed-in user for the passkey management
+// endpoints. A nil user means the failure response has already been written.
+func (h *APIHandler) passkeyUser(c echo.Context) (*usermodel.User, error) {
+	if h.passkeySvc == nil {
+		return nil, response.Err(c, http.StatusNotFound, "passkeys_disabled", "Passkeys are not enabled")
+	}
+
+	user := GetCurrentUser(c)
+	if user == nil {
+		return nil, response.Err(c, http.StatusUnauthorized, "unauthorized", "Invalid or missing authentication token")
+	}
+
+	userModel, ok := user.(usermodel.User)
+	if !ok {
+		return nil, response.Err(c, http.StatusInternalServerError, "user_data_error", "Failed to process user data")
+	}
+	return &userModel, nil
+}
+
+func (h *APIHandler) auditPasskeyEvent(c echo.Context, eventType string, user *usermodel.User, credential *passkey.Credential, success bool, failureReason string, extra map[string]any) {
+	metadata := map[string]any{}
+	if credential != nil {
+		metadata["passkey_id"] = credential.ID
+		metadata["passkey_name"] = credential.Name
+	}
+	for k, v := range extra {
+		metadata[k] = v
+	}
+
+	var userID *uint
+	username := ""
+	if user != nil {
+		userID = &user.ID
+		username = user.Username
+	}
+	_ = h.auditSvc.LogAuthEvent(
+		eventType,
+		userID,
+		username,
+		c.RealIP(),
+		c.Request().UserAgent(),
+		success,
+		failureReason,
+		metadata,
+	)
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"berth/internal/domain/security"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	passwordChangedMessage      = "Your password has been changed. Other sessions have been signed out."
+	emailChangeRequestedMessage = "A verification link has been sent to the new email address."
+	emailChangedMessage         = "Your email address has been changed."
+)
+
+func (h *APIHandler) ChangePassword(c echo.Context) error {
+	user, ok := GetCurrentUser(c).(usermodel.User)
+	if !ok {
+		return response.Err(c, http.StatusUnauthorized, "unauthorized", "Invalid or missing authentication token")
+	}
+
+	var req ProfileChangePasswordRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.authSvc.ChangePassword(&user, req.CurrentPassword, req.Password); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidCredentials):
+			h.auditProfileChange(c, &user, security.EventUserPasswordChanged, false, "invalid current password", nil)
+			return response.Err(c, http.StatusBadRequest, "invalid_current_password", "Current password is incorrect")
+		case errors.Is(err, ErrPasswordUnchanged):
+			return response.Err(c, http.StatusBadRequest, "password_unchanged", err.Error())
+		case errors.Is(err, ErrWeakPassword):
+			return response.Err(c, http.StatusBadRequest, "weak_password", err.Error())
+		default:
+			h.logger.Error("password change failed",
+				zap.Uint("user_id", user.ID),
+				zap.Error(err))
+			return response.Err(c, http.StatusInternalServerError, "password_change_failed", "Failed to change password")
+		}
+	}
+
+	revoked := h.revokeOtherSessions(c, user.ID)
+	h.auditProfileChange(c, &user, security.EventUserPasswordChanged, true, "", map[string]any{
+		"other_sessions_revoked": revoked,
+	})
+
+	return response.OK(c, AuthMessageData{Message: passwordChangedMessage})
+}
+
+// revokeOtherSessions signs out every session but the caller's. When the
+// current session cannot be identified all sessions are revoked.
+func (h *APIHandler) revokeOtherSessions(c echo.Context, userID uint) bool {
+	if h.sessionSvc == nil {
+		return false
+	}
+
+	currentToken := ""
+	if accessJTI, err := extractAccessJTI(c, h.tokens); err == nil {
+		if token, err := h.sessionSvc.GetCurrentSessionToken(userID, accessJTI); err == nil {
+			currentToken = token
+		}
+	}
+
+	if err := h.sessionSvc.RevokeAllOtherSessions(userID, currentToken); err != nil {
+		h.logger.Error("revoke other sessions after password change failed",
+			zap.Uint("user_id", userID),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (h *APIHandler) ChangeEmail(c echo.Context) error {
+	user, ok := GetCurrentUser(c).(usermodel.User)
+	if !ok {
+		return response.Err(c, http.StatusUnauthorized, "unauthorized", "Invalid or missing authentication token")
+	}
+
+	var req ProfileChangeEmailRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.authSvc.VerifyPassword(user.Password, req.CurrentPassword); err != nil {
+		h.auditProfileChange(c, &user, security.EventUserEmailChanged, false, "invalid current password", nil)
+		return response.Err(c, http.StatusBadRequest, "invalid_current_password", "Current password is incorrect")
+	}
+
+	if err := h.authSvc.RequestEmailChange(&user, req.Email); err != nil {
+		switch {
+		case errors.Is(err, ErrEmailUnchanged):
+			return response.Err(c, http.StatusBadRequest, "email_unchanged", err.Error())
+		case errors.Is(err, ErrEmailInUse):
+			return response.Err(c, http.StatusConflict, "email_in_use", "That email address is already in use")
+		case errors.Is(err, ErrMailServiceUnavailable):
+			return response.Err(c, http.StatusInternalServerError, "mail_service_unavailable", "Email service is not properly configured")
+		default:
+			h.logger.Error("email change request failed",
+				zap.Uint("user_id", user.ID),
+				zap.Error(err))
+			return response.Err(c, http.StatusInternalServerError, "email_change_failed", "Failed to send verification email")
+		}
+	}
+
+	return response.OK(c, AuthMessageData{Message: emailChangeRequestedMessage})
+}
+
+func (h *APIHandler) ConfirmEmailChangeAPI(c echo.Context) error {
+	var req ConfirmEmailChangeRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	user, oldEmail, err := h.authSvc.ConfirmEmailChange(req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrEmailChangeTokenInvalid):
+			return response.Err(c, http.StatusBadRequest, "invalid_token", "Invalid or expired email change link")
+		case errors.Is(err, ErrEmailInUse):
+			return response.Err(c, http.StatusConflict, "email_in_use", "That email address is already in use")
+		default:
+			h.logger.Error("email change confirmation failed", zap.Error(err))
+			return response.Err(c, http.StatusInternalServerError, "email_change_failed", "Something went wrong. Please try again.")
+		}
+	}
+
+	h.auditProfileChange(c, user, security.EventUserEmailChanged, true, "", map[string]any{
+		"email":          user.Email,
+		"previous_email": oldEmail,
+		"source":         "self_service",
+	})
+
+	return response.OK(c, AuthMessageData{Message: emailChangedMessage})
+}
+
+// auditProfileChange records a change the user made to their own account;
+// they are both actor and target.
+func (h *APIHandler) auditProfileChange(c echo.Context, user *usermodel.User, eventType string, success bool, failureReason string, metadata map[string]any) {
+	_ = h.auditSvc.Log(security.LogEvent{
+		EventType:      eventType,
+		Success:        success,
+		FailureReason:  failureReason,
+		ActorUserID:    &user.ID,
+		ActorUsername:  user.Username,
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetUserID:   &user.ID,
+		TargetType:     security.TargetTypeUser,
+		TargetID:       &user.ID,
+		TargetName:     user.Email,
+		Metadata:       metadata,
+	})
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"berth/internal/domain/auth/totp"
+	"berth/internal/domain/security"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *APIHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	user := GetCurrentUser(c)
+	if user == nil {
+		return response.Err(c, http.StatusUnauthorized, "unauthorized", "Invalid or missing authentication token")
+	}
+
+	userModel, ok := user.(usermodel.User)
+	if !ok {
+		return response.Err(c, http.StatusInternalServerError, "user_data_error", "Failed to process user data")
+	}
+
+	var req TOTPRecoveryCodesRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if !h.totpSvc.IsUserTOTPEnabled(userModel.ID) {
+		return response.Err(c, http.StatusBadRequest, "totp_not_enabled", "Two-factor authentication is not enabled")
+	}
+
+	if err := h.authSvc.VerifyPassword(userModel.Password, req.Password); err != nil {
+		return response.Err(c, http.StatusUnauthorized, "invalid_password", "Invalid password")
+	}
+
+	if _, err := h.verifyTOTPOrRecoveryCode(c, &userModel, req.Code); err != nil {
+		return response.Err(c, http.StatusUnauthorized, "invalid_totp_code", "Invalid TOTP code")
+	}
+
+	codes, err := h.totpSvc.RegenerateRecoveryCodes(userModel.ID)
+	if err != nil {
+		return response.Err(c, http.StatusInternalServerError, "recovery_codes_failed", "Failed to regenerate recovery codes")
+	}
+
+	_ = h.auditSvc.LogAuthEvent(
+		security.EventTOTPRecoveryCodesRegenerated,
+		&userModel.ID,
+		userModel.Username,
+		c.RealIP(),
+		c.Request().UserAgent(),
+		true,
+		"",
+		map[string]any{"count": len(codes)},
+	)
+
+	return response.OK(c, TOTPRecoveryCodesData{RecoveryCodes: codes})
+}
+
+// verifyTOTPOrRecoveryCode checks code against the authenticator, or consumes
+// it as a recovery code when it has that shape. usedRecovery reports which.
+func (h *APIHandler) verifyTOTPOrRecoveryCode(c echo.Context, user *usermodel.User, code string) (usedRecovery bool, err error) {
+	if !totp.IsRecoveryCode(code) {
+		return false, h.totpSvc.VerifyUserCode(user.ID, code)
+	}
+
+	remaining, err := h.totpSvc.ConsumeRecoveryCode(user.ID, code)
+	if err != nil {
+		return true, err
+	}
+
+	_ = h.auditSvc.LogAuthEvent(
+		security.EventTOTPRecoveryCodeUsed,
+		&user.ID,
+		user.Username,
+		c.RealIP(),
+		c.Request().UserAgent(),
+		true,
+		"",
+		map[string]any{"remaining": remaining},
+	)
+	return true, nil
+}
+
+func isInvalidSecondFactorCode(err error) bool {
+	return errors.Is(err, totp.ErrInvalidCode) ||
+		errors.Is(err, totp.ErrCodeAlreadyUsed) ||
+		errors.Is(err, totp.ErrInvalidRecoveryCode)
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"net/http"
+
+	"berth/internal/domain/security"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/response"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// requireTwoFactorEnrollment sends a user whose role requires a second factor
+// they have not enrolled to enrollmentRequired, and reports whether it wrote
+// a response. Logins that can finish without a second-factor step call it
+// before a session is issued; single sign-on is exempt.
+func (h *APIHandler) requireTwoFactorEnrollment(c echo.Context, user *usermodel.User, method string) (bool, error) {
+	if len(h.secondFactorMethods(user.ID)) > 0 {
+		return false, nil
+	}
+
+	required, err := h.authSvc.RequiresTwoFactor(user.ID)
+	if err != nil {
+		h.logger.Error("failed to check two-factor requirement",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		return true, response.Err(c, http.StatusInternalServerError, "login_failed", "Failed to complete login")
+	}
+	if !required {
+		return false, nil
+	}
+	return true, h.enrollmentRequired(c, user, method)
+}
+
+// enrollmentRequired answers a successful login for a user whose role
+// requires a second factor they have not enrolled. No refresh token is
+// issued; the user signs in again once enrolled.
+func (h *APIHandler) enrollmentRequired(c echo.Context, user *usermodel.User, method string) error {
+	enrollmentToken, err := h.tokens.IssueEnrollmentToken(user.ID)
+	if err != nil {
+		h.logger.Error("failed to generate enrollment token",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		return response.Err(c, http.StatusInternalServerError, "token_generation_failed", "Failed to generate authentication token")
+	}
+
+	methods := []string{secondFactorTOTP}
+	if h.passkeySvc != nil {
+		methods = append(methods, secondFactorPasskey)
+	}
+
+	_ = h.auditSvc.LogAuthEvent(
+		security.EventAuthTwoFactorEnrollmentRequired,
+		&user.ID,
+		user.Username,
+		c.RealIP(),
+		c.Request().UserAgent(),
+		true,
+		"",
+		map[string]any{"method": method},
+	)
+	h.recordAuthSuccess(user)
+
+	return response.OK(c, AuthTwoFactorEnrollmentData{
+		Message:            "Your role requires two-factor authentication; enroll a second factor to continue",
+		EnrollmentRequired: true,
+		EnrollmentToken:    enrollmentToken,
+		ExpiresIn:          h.tokens.GetEnrollmentExpirySeconds(),
+		EnrollmentMethods:  methods,
+	})
+}
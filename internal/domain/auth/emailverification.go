@@ -16,7 +16,7 @@ func (s *Service) createEmailVerificationToken(email string) (*EmailVerification
 
 	now := time.Now()
 	if err := s.db.Model(&EmailVerificationToken{}).
-		Where("email = ? AND used = ? AND expires_at > ?", email, false, now).
+		Where("email = ? AND user_id IS NULL AND used = ? AND expires_at > ?", email, false, now).
 		Update("used", true).Error; err != nil {
 		return nil, fmt.Errorf("invalidate existing verification tokens: %w", err)
 	}
@@ -43,7 +43,7 @@ func (s *Service) ValidateEmailVerificationToken(token string) (*EmailVerificati
 	}
 
 	var row EmailVerificationToken
-	if err := s.db.Where("token = ?", token).First(&row).Error; err != nil {
+	if err := s.db.Where("token = ? AND user_id IS NULL", token).First(&row).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrEmailVerificationTokenInvalid
 		}
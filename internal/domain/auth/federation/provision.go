@@ -0,0 +1,44 @@
+package federation
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	usermodel "berth/internal/domain/user"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// PlaceholderPassword returns a bcrypt hash of a random secret for accounts
+// that sign in through an external source. The secret is never disclosed, so
+// the hash only satisfies the schema.
+func PlaceholderPassword() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate placeholder password: %w", err)
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(base64.RawURLEncoding.EncodeToString(b)), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("hash placeholder password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+// UniqueUsername appends a counter when the external username is already
+// taken by another account.
+func UniqueUsername(tx *gorm.DB, base string) (string, error) {
+	candidate := base
+	for i := 2; i < 100; i++ {
+		var count int64
+		if err := tx.Unscoped().Model(&usermodel.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+	return "", fmt.Errorf("no free username for %q", base)
+}
@@ -0,0 +1,132 @@
+// Package federation holds the pieces shared by login backends that take
+// identities from an external source (OIDC, LDAP): group-to-role mapping and
+// provisioning of local accounts.
+package federation
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	usermodel "berth/internal/domain/user"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var ErrRoleMappingInvalid = errors.New("role mapping entries must be group=role separated by ';'")
+
+type RoleManager interface {
+	AssignRole(userID uint, roleID uint) error
+	RevokeRole(userID uint, roleID uint) error
+}
+
+// ParseRoleMapping parses "group=role;group=role" into the roles granted by
+// each group. Entries are split at their last '=' so group names such as LDAP
+// DNs may contain '=' and ','. A group may be listed more than once to grant
+// several roles.
+func ParseRoleMapping(raw string) (map[string][]string, error) {
+	mappings := map[string][]string{}
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		idx := strings.LastIndex(entry, "=")
+		if idx <= 0 || idx == len(entry)-1 {
+			return nil, fmt.Errorf("%w: %q", ErrRoleMappingInvalid, entry)
+		}
+		group := strings.TrimSpace(entry[:idx])
+		role := strings.TrimSpace(entry[idx+1:])
+		if group == "" || role == "" {
+			return nil, fmt.Errorf("%w: %q", ErrRoleMappingInvalid, entry)
+		}
+		mappings[group] = append(mappings[group], role)
+	}
+	return mappings, nil
+}
+
+// RoleSyncer grants the mapped roles a user's groups call for and revokes
+// mapped roles they no longer do. Roles that appear in no mapping are left
+// alone, so roles assigned by hand survive logins.
+type RoleSyncer struct {
+	DB       *gorm.DB
+	Roles    RoleManager
+	Mappings map[string][]string
+	Logger   *zap.Logger
+	// Source names the backend in log messages.
+	Source string
+}
+
+func (s RoleSyncer) Sync(user *usermodel.User, groups []string) (assigned []string, revoked []string) {
+	if len(s.Mappings) == 0 {
+		return nil, nil
+	}
+
+	wanted := map[string]bool{}
+	managed := map[string]bool{}
+	for group, roles := range s.Mappings {
+		for _, role := range roles {
+			managed[role] = true
+			if slices.Contains(groups, group) {
+				wanted[role] = true
+			}
+		}
+	}
+
+	current := map[string]bool{}
+	for _, role := range user.Roles {
+		current[role.Name] = true
+	}
+
+	names := make([]string, 0, len(managed))
+	for name := range managed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if wanted[name] == current[name] {
+			continue
+		}
+
+		var role usermodel.Role
+		if err := s.DB.Where("name = ?", name).First(&role).Error; err != nil {
+			s.Logger.Warn("role mapping references an unknown role",
+				zap.String("source", s.Source),
+				zap.String("role_name", name),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		if wanted[name] {
+			if err := s.Roles.AssignRole(user.ID, role.ID); err != nil {
+				s.Logger.Error("failed to assign mapped role",
+					zap.String("source", s.Source),
+					zap.Uint("user_id", user.ID),
+					zap.String("role_name", name),
+					zap.Error(err),
+				)
+				continue
+			}
+			assigned = append(assigned, name)
+			continue
+		}
+
+		if err := s.Roles.RevokeRole(user.ID, role.ID); err != nil {
+			s.Logger.Warn("failed to revoke mapped role",
+				zap.String("source", s.Source),
+				zap.Uint("user_id", user.ID),
+				zap.String("role_name", name),
+				zap.Error(err),
+			)
+			continue
+		}
+		revoked = append(revoked, name)
+	}
+
+	return assigned, revoked
+}
@@ -0,0 +1,115 @@
+package federation
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	usermodel "berth/internal/domain/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+func TestParseRoleMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string][]string
+		wantErr error
+	}{
+		{"empty", "", map[string][]string{}, nil},
+		{"multiple entries", "admins=admin; devs=developer;devs=viewer", map[string][]string{
+			"admins": {"admin"},
+			"devs":   {"developer", "viewer"},
+		}, nil},
+		{"group containing equals", "cn=ops,dc=example=operator", map[string][]string{
+			"cn=ops,dc=example": {"operator"},
+		}, nil},
+		{"missing role", "admins=", nil, ErrRoleMappingInvalid},
+		{"missing separator", "admins", nil, ErrRoleMappingInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRoleMapping(tt.raw)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+type recordingRoles struct {
+	assigned []uint
+	revoked  []uint
+}
+
+func (r *recordingRoles) AssignRole(_ uint, roleID uint) error {
+	r.assigned = append(r.assigned, roleID)
+	return nil
+}
+
+func (r *recordingRoles) RevokeRole(_ uint, roleID uint) error {
+	r.revoked = append(r.revoked, roleID)
+	return nil
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:federation_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&usermodel.User{}, &usermodel.Role{}, &usermodel.UserRole{}))
+	return db
+}
+
+func TestRoleSyncer_OnlyManagesMappedRoles(t *testing.T) {
+	db := newTestDB(t)
+	roles := map[string]usermodel.Role{}
+	for _, name := range []string{"admin", "developer", "ops"} {
+		role := usermodel.Role{Name: name}
+		require.NoError(t, db.Create(&role).Error)
+		roles[name] = role
+	}
+
+	recorder := &recordingRoles{}
+	syncer := RoleSyncer{
+		DB:    db,
+		Roles: recorder,
+		Mappings: map[string][]string{
+			"admins": {"admin"},
+			"devs":   {"developer", "missing"},
+		},
+		Logger: zap.NewNop(),
+		Source: "test",
+	}
+
+	user := &usermodel.User{Roles: []usermodel.Role{roles["admin"], roles["ops"]}}
+	assigned, revoked := syncer.Sync(user, []string{"devs", "unmapped"})
+
+	assert.Equal(t, []string{"developer"}, assigned)
+	assert.Equal(t, []string{"admin"}, revoked)
+	assert.Equal(t, []uint{roles["developer"].ID}, recorder.assigned)
+	assert.Equal(t, []uint{roles["admin"].ID}, recorder.revoked, "unmapped roles such as ops are left alone")
+}
+
+func TestUniqueUsername(t *testing.T) {
+	db := newTestDB(t)
+	require.NoError(t, db.Create(&usermodel.User{Username: "alice", Email: "a@example.com", Password: "x"}).Error)
+	require.NoError(t, db.Create(&usermodel.User{Username: "alice-2", Email: "b@example.com", Password: "x"}).Error)
+
+	name, err := UniqueUsername(db, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "alice-3", name)
+
+	name, err = UniqueUsername(db, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", name)
+}
@@ -0,0 +1,176 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"berth/internal/pkg/config"
+
+	ldapv3 "github.com/go-ldap/ldap/v3"
+)
+
+// Entry is the part of a directory user that Berth consumes.
+type Entry struct {
+	DN       string
+	Username string
+	Email    string
+	Groups   []string
+}
+
+// directory authenticates a username and password against the directory.
+// It returns ErrInvalidCredentials for unknown users and bad passwords, and
+// wraps ErrUnavailable when the directory cannot be queried.
+type directory interface {
+	Authenticate(username, password string) (*Entry, error)
+}
+
+type ldapDirectory struct {
+	cfg config.LDAPConfig
+}
+
+func (d *ldapDirectory) Authenticate(username, password string) (*Entry, error) {
+	// An empty password would be an unauthenticated bind, which many
+	// servers accept for any DN.
+	if password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := d.connect()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer conn.Close()
+
+	if err := d.bindService(conn); err != nil {
+		return nil, err
+	}
+
+	result, err := conn.Search(ldapv3.NewSearchRequest(
+		d.cfg.UserBaseDN,
+		ldapv3.ScopeWholeSubtree, ldapv3.NeverDerefAliases, 2, 0, false,
+		expandFilter(d.cfg.UserFilter, "{username}", username),
+		[]string{d.cfg.UsernameAttribute, d.cfg.EmailAttribute, d.cfg.GroupAttribute},
+		nil,
+	))
+	if err != nil {
+		if ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultSizeLimitExceeded) {
+			return nil, fmt.Errorf("%w: filter matched more than one entry", ErrInvalidCredentials)
+		}
+		return nil, fmt.Errorf("%w: user search: %v", ErrUnavailable, err)
+	}
+	if len(result.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	found := result.Entries[0]
+
+	if err := conn.Bind(found.DN, password); err != nil {
+		if ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: user bind: %v", ErrUnavailable, err)
+	}
+
+	entry := &Entry{
+		DN:       found.DN,
+		Username: found.GetEqualFoldAttributeValue(d.cfg.UsernameAttribute),
+		Email:    found.GetEqualFoldAttributeValue(d.cfg.EmailAttribute),
+		Groups:   found.GetEqualFoldAttributeValues(d.cfg.GroupAttribute),
+	}
+
+	if d.cfg.GroupBaseDN != "" {
+		groups, err := d.searchGroups(conn, found.DN)
+		if err != nil {
+			return nil, err
+		}
+		entry.Groups = append(entry.Groups, groups...)
+	}
+
+	return entry, nil
+}
+
+// searchGroups finds groups listing the user as a member, for directories
+// that do not maintain memberOf on user entries.
+func (d *ldapDirectory) searchGroups(conn *ldapv3.Conn, userDN string) ([]string, error) {
+	// Group search runs as the service account, not the user.
+	if err := d.bindService(conn); err != nil {
+		return nil, err
+	}
+
+	result, err := conn.Search(ldapv3.NewSearchRequest(
+		d.cfg.GroupBaseDN,
+		ldapv3.ScopeWholeSubtree, ldapv3.NeverDerefAliases, 0, 0, false,
+		expandFilter(d.cfg.GroupFilter, "{dn}", userDN),
+		[]string{"dn"},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("%w: group search: %v", ErrUnavailable, err)
+	}
+
+	groups := make([]string, 0, len(result.Entries))
+	for _, group := range result.Entries {
+		groups = append(groups, group.DN)
+	}
+	return groups, nil
+}
+
+func (d *ldapDirectory) connect() (*ldapv3.Conn, error) {
+	parsed, err := url.Parse(d.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parse LDAP URL: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         parsed.Hostname(),
+		InsecureSkipVerify: d.cfg.InsecureSkipVerify, //nolint:gosec // opt-in for lab directories with self-signed certificates
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	conn, err := ldapv3.DialURL(d.cfg.URL,
+		ldapv3.DialWithDialer(&net.Dialer{Timeout: d.cfg.Timeout}),
+		ldapv3.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(d.cfg.Timeout)
+
+	if d.cfg.StartTLS && !strings.EqualFold(parsed.Scheme, "ldaps") {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("start TLS: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// bindService binds as the configured search account. Without one the
+// connection stays anonymous, which some directories allow for searches.
+func (d *ldapDirectory) bindService(conn *ldapv3.Conn) error {
+	if d.cfg.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(d.cfg.BindDN, d.cfg.BindPassword); err != nil {
+		return fmt.Errorf("%w: service bind: %v", ErrUnavailable, err)
+	}
+	return nil
+}
+
+// expandFilter substitutes an escaped value for the placeholder so user
+// input cannot change the structure of the filter.
+func expandFilter(filter, placeholder, value string) string {
+	return strings.ReplaceAll(filter, placeholder, ldapv3.EscapeFilter(value))
+}
+
+// NormalizeDN returns a canonical lower-case form of dn so DNs that differ
+// only in case or spacing compare equal.
+func NormalizeDN(dn string) string {
+	parsed, err := ldapv3.ParseDN(dn)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(dn))
+	}
+	return strings.ToLower(parsed.String())
+}
@@ -0,0 +1,19 @@
+package ldap
+
+import (
+	"gorm.io/gorm"
+)
+
+// Identity links a directory entry to a Berth user. Entries are matched by
+// the hash of their normalised DN, which keeps the unique index short enough
+// for every supported database.
+type Identity struct {
+	gorm.Model
+	UserID uint   `json:"user_id" gorm:"not null;uniqueIndex"`
+	DN     string `json:"dn" gorm:"type:text;not null"`
+	DNHash string `json:"-" gorm:"size:64;not null;uniqueIndex"`
+}
+
+func (Identity) TableName() string {
+	return "ldap_identities"
+}
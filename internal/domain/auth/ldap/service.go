@@ -0,0 +1,264 @@
+package ldap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"berth/internal/domain/auth/federation"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid directory credentials")
+	ErrUnavailable        = errors.New("directory is unavailable")
+	ErrAccountNotFound    = errors.New("no account is linked to this directory entry")
+	ErrEmailMissing       = errors.New("directory entry has no email address")
+)
+
+type Service struct {
+	cfg       config.LDAPConfig
+	db        *gorm.DB
+	directory directory
+	roles     federation.RoleSyncer
+	logger    *zap.Logger
+	now       func() time.Time
+}
+
+func NewService(cfg *config.Config, db *gorm.DB, roles federation.RoleManager, logger *zap.Logger) (*Service, error) {
+	raw, err := federation.ParseRoleMapping(cfg.LDAP.RoleMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group DNs are compared in normalised form; directories are free to
+	// return them with different case or spacing than the mapping uses.
+	mappings := make(map[string][]string, len(raw))
+	for group, roles := range raw {
+		key := NormalizeDN(group)
+		mappings[key] = append(mappings[key], roles...)
+	}
+
+	return &Service{
+		cfg:       cfg.LDAP,
+		db:        db,
+		directory: &ldapDirectory{cfg: cfg.LDAP},
+		roles: federation.RoleSyncer{
+			DB:       db,
+			Roles:    roles,
+			Mappings: mappings,
+			Logger:   logger,
+			Source:   "ldap",
+		},
+		logger: logger,
+		now:    time.Now,
+	}, nil
+}
+
+func (s *Service) URL() string {
+	return s.cfg.URL
+}
+
+type LoginResult struct {
+	User          *usermodel.User
+	DN            string
+	Provisioned   bool
+	Linked        bool
+	EmailUpdated  bool
+	RolesAssigned []string
+	RolesRevoked  []string
+}
+
+// Authenticate binds as the directory user and resolves the Berth account for
+// the entry, provisioning one if allowed. The account's email and mapped
+// roles are brought in line with the directory on every login.
+func (s *Service) Authenticate(username, password string) (*LoginResult, error) {
+	entry, err := s.directory.Authenticate(username, password)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Username == "" {
+		entry.Username = username
+	}
+
+	result, err := s.resolveUser(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	result.EmailUpdated = s.syncEmail(result.User, entry.Email)
+
+	groups := make([]string, 0, len(entry.Groups))
+	for _, group := range entry.Groups {
+		groups = append(groups, NormalizeDN(group))
+	}
+	result.RolesAssigned, result.RolesRevoked = s.roles.Sync(result.User, groups)
+
+	if len(result.RolesAssigned) > 0 || len(result.RolesRevoked) > 0 {
+		var refreshed usermodel.User
+		if err := usermodel.FirstWithActiveRoles(s.db, &refreshed, result.User.ID); err != nil {
+			return nil, err
+		}
+		result.User = &refreshed
+	}
+
+	return result, nil
+}
+
+func (s *Service) resolveUser(entry *Entry) (*LoginResult, error) {
+	result := &LoginResult{DN: entry.DN}
+	dnHash := hashDN(entry.DN)
+
+	var identity Identity
+	err := s.db.Where("dn_hash = ?", dnHash).First(&identity).Error
+	switch {
+	case err == nil:
+		var user usermodel.User
+		if err := usermodel.FirstWithActiveRoles(s.db, &user, identity.UserID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrAccountNotFound
+			}
+			return nil, err
+		}
+		result.User = &user
+		return result, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(entry.Email))
+	if email == "" {
+		return nil, ErrEmailMissing
+	}
+
+	// Directory addresses are managed by administrators, so an entry may
+	// claim the account with the same email. This also re-links users whose
+	// entry moved to a new DN.
+	var user usermodel.User
+	err = usermodel.FirstWithActiveRoles(s.db.Where("LOWER(email) = ?", email), &user)
+	if err == nil {
+		if err := s.link(user.ID, entry.DN, dnHash); err != nil {
+			return nil, err
+		}
+		result.User = &user
+		result.Linked = true
+		return result, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if !s.cfg.AutoProvision {
+		return nil, ErrAccountNotFound
+	}
+
+	provisioned, err := s.provision(entry, email, dnHash)
+	if err != nil {
+		return nil, err
+	}
+	result.User = provisioned
+	result.Provisioned = true
+	return result, nil
+}
+
+func (s *Service) link(userID uint, dn, dnHash string) error {
+	var existing Identity
+	err := s.db.Where("user_id = ?", userID).First(&existing).Error
+	if err == nil {
+		return s.db.Model(&existing).Updates(map[string]any{
+			"dn":      dn,
+			"dn_hash": dnHash,
+		}).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return s.db.Create(&Identity{UserID: userID, DN: dn, DNHash: dnHash}).Error
+}
+
+func (s *Service) provision(entry *Entry, email, dnHash string) (*usermodel.User, error) {
+	hashed, err := federation.PlaceholderPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	var user usermodel.User
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		username, err := federation.UniqueUsername(tx, entry.Username)
+		if err != nil {
+			return err
+		}
+
+		now := s.now()
+		user = usermodel.User{
+			Username:        username,
+			Email:           email,
+			Password:        hashed,
+			EmailVerifiedAt: &now,
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&Identity{UserID: user.ID, DN: entry.DN, DNHash: dnHash}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("provision user: %w", err)
+	}
+
+	s.logger.Info("provisioned user from LDAP login",
+		zap.Uint("user_id", user.ID),
+		zap.String("username", user.Username),
+	)
+	return &user, nil
+}
+
+// syncEmail copies the directory address onto the account and marks it
+// verified. An address already used by another account is left alone.
+func (s *Service) syncEmail(user *usermodel.User, email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || (strings.EqualFold(user.Email, email) && user.EmailVerifiedAt != nil) {
+		return false
+	}
+
+	updates := map[string]any{}
+	if !strings.EqualFold(user.Email, email) {
+		var count int64
+		if err := s.db.Model(&usermodel.User{}).Where("LOWER(email) = ? AND id <> ?", email, user.ID).Count(&count).Error; err != nil || count > 0 {
+			s.logger.Warn("not syncing LDAP email already used by another account",
+				zap.Uint("user_id", user.ID),
+				zap.Error(err),
+			)
+			return false
+		}
+		updates["email"] = email
+	}
+	if user.EmailVerifiedAt == nil {
+		updates["email_verified_at"] = s.now()
+	}
+
+	if err := s.db.Model(user).Updates(updates).Error; err != nil {
+		s.logger.Warn("failed to sync LDAP email",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+		return false
+	}
+	if _, changed := updates["email"]; !changed {
+		return false
+	}
+	user.Email = email
+	return true
+}
+
+func hashDN(dn string) string {
+	sum := sha256.Sum256([]byte(NormalizeDN(dn)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,195 @@
+package ldap
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+	"berth/internal/platform/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+// fakeDirectory accepts a single password for each configured entry.
+type fakeDirectory struct {
+	entries map[string]*Entry
+	err     error
+}
+
+func (f *fakeDirectory) Authenticate(username, password string) (*Entry, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	entry, ok := f.entries[username]
+	if !ok || password != "directory-password" {
+		return nil, ErrInvalidCredentials
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+type dbRoleManager struct {
+	db *gorm.DB
+}
+
+func (r dbRoleManager) AssignRole(userID, roleID uint) error {
+	return r.db.Model(&usermodel.User{BaseModel: db.BaseModel{ID: userID}}).Association("Roles").Append(&usermodel.Role{BaseModel: db.BaseModel{ID: roleID}})
+}
+
+func (r dbRoleManager) RevokeRole(userID, roleID uint) error {
+	return r.db.Model(&usermodel.User{BaseModel: db.BaseModel{ID: userID}}).Association("Roles").Delete(&usermodel.Role{BaseModel: db.BaseModel{ID: roleID}})
+}
+
+func newTestService(t *testing.T, dir *fakeDirectory, modify func(*config.LDAPConfig)) (*Service, *gorm.DB) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:ldap_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&usermodel.User{}, &usermodel.Role{}, &usermodel.UserRole{}, &Identity{}))
+	require.NoError(t, database.Create(&usermodel.Role{Name: "admin", IsAdmin: true}).Error)
+	require.NoError(t, database.Create(&usermodel.Role{Name: "developer"}).Error)
+
+	cfg := &config.Config{
+		LDAP: config.LDAPConfig{
+			Enabled:       true,
+			URL:           "ldap://directory.example.com",
+			UserBaseDN:    "ou=people,dc=example,dc=com",
+			RoleMapping:   "CN=Berth Admins,OU=Groups,DC=example,DC=com=admin; cn=devs,ou=groups,dc=example,dc=com=developer",
+			AutoProvision: true,
+		},
+	}
+	if modify != nil {
+		modify(&cfg.LDAP)
+	}
+
+	svc, err := NewService(cfg, database, dbRoleManager{db: database}, zap.NewNop())
+	require.NoError(t, err)
+	svc.directory = dir
+	return svc, database
+}
+
+func roleNames(user *usermodel.User) []string {
+	names := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+func TestAuthenticate_ProvisionsAndSyncsDirectoryUser(t *testing.T) {
+	dir := &fakeDirectory{entries: map[string]*Entry{
+		"alice": {
+			DN:       "uid=alice,ou=people,dc=example,dc=com",
+			Username: "alice",
+			Email:    "Alice@Example.com",
+			Groups:   []string{"cn=berth admins, ou=groups, dc=example, dc=com"},
+		},
+	}}
+	svc, database := newTestService(t, dir, nil)
+
+	result, err := svc.Authenticate("alice", "directory-password")
+	require.NoError(t, err)
+	assert.True(t, result.Provisioned)
+	assert.Equal(t, "alice", result.User.Username)
+	assert.Equal(t, "alice@example.com", result.User.Email)
+	assert.NotNil(t, result.User.EmailVerifiedAt, "directory addresses count as verified")
+	assert.Equal(t, []string{"admin"}, result.RolesAssigned, "group DNs match regardless of case and spacing")
+	assert.ElementsMatch(t, []string{"admin"}, roleNames(result.User))
+
+	// The directory moved alice between groups and changed her address.
+	dir.entries["alice"].Email = "alice@corp.example.com"
+	dir.entries["alice"].Groups = []string{"cn=devs,ou=groups,dc=example,dc=com"}
+
+	result2, err := svc.Authenticate("alice", "directory-password")
+	require.NoError(t, err)
+	assert.False(t, result2.Provisioned)
+	assert.Equal(t, result.User.ID, result2.User.ID)
+	assert.True(t, result2.EmailUpdated)
+	assert.Equal(t, []string{"developer"}, result2.RolesAssigned)
+	assert.Equal(t, []string{"admin"}, result2.RolesRevoked)
+	assert.ElementsMatch(t, []string{"developer"}, roleNames(result2.User))
+
+	var stored usermodel.User
+	require.NoError(t, database.First(&stored, result.User.ID).Error)
+	assert.Equal(t, "alice@corp.example.com", stored.Email)
+}
+
+func TestAuthenticate_LinksExistingAccountByEmail(t *testing.T) {
+	dir := &fakeDirectory{entries: map[string]*Entry{
+		"bob": {DN: "uid=bob,ou=people,dc=example,dc=com", Username: "bob", Email: "bob@example.com"},
+	}}
+	svc, database := newTestService(t, dir, nil)
+
+	existing := usermodel.User{Username: "robert", Email: "bob@example.com", Password: "x"}
+	require.NoError(t, database.Create(&existing).Error)
+
+	result, err := svc.Authenticate("bob", "directory-password")
+	require.NoError(t, err)
+	assert.True(t, result.Linked)
+	assert.Equal(t, existing.ID, result.User.ID)
+
+	// A renamed entry keeps resolving to the same account through its email,
+	// and the stored DN follows it.
+	dir.entries["bob"].DN = "uid=bob,ou=staff,dc=example,dc=com"
+	result, err = svc.Authenticate("bob", "directory-password")
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, result.User.ID)
+
+	var identities []Identity
+	require.NoError(t, database.Find(&identities).Error)
+	require.Len(t, identities, 1)
+	assert.Equal(t, "uid=bob,ou=staff,dc=example,dc=com", identities[0].DN)
+}
+
+func TestAuthenticate_Failures(t *testing.T) {
+	t.Run("bad password", func(t *testing.T) {
+		dir := &fakeDirectory{entries: map[string]*Entry{
+			"carol": {DN: "uid=carol,dc=example,dc=com", Email: "carol@example.com"},
+		}}
+		svc, _ := newTestService(t, dir, nil)
+		_, err := svc.Authenticate("carol", "wrong")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("directory unavailable", func(t *testing.T) {
+		svc, _ := newTestService(t, &fakeDirectory{err: fmt.Errorf("%w: connection refused", ErrUnavailable)}, nil)
+		_, err := svc.Authenticate("carol", "directory-password")
+		assert.ErrorIs(t, err, ErrUnavailable)
+	})
+
+	t.Run("auto provisioning disabled", func(t *testing.T) {
+		dir := &fakeDirectory{entries: map[string]*Entry{
+			"dave": {DN: "uid=dave,dc=example,dc=com", Email: "dave@example.com"},
+		}}
+		svc, _ := newTestService(t, dir, func(c *config.LDAPConfig) { c.AutoProvision = false })
+		_, err := svc.Authenticate("dave", "directory-password")
+		assert.ErrorIs(t, err, ErrAccountNotFound)
+	})
+
+	t.Run("entry without email", func(t *testing.T) {
+		dir := &fakeDirectory{entries: map[string]*Entry{
+			"erin": {DN: "uid=erin,dc=example,dc=com"},
+		}}
+		svc, _ := newTestService(t, dir, nil)
+		_, err := svc.Authenticate("erin", "directory-password")
+		assert.ErrorIs(t, err, ErrEmailMissing)
+	})
+}
+
+func TestExpandFilter_EscapesInput(t *testing.T) {
+	got := expandFilter("(&(objectClass=person)(uid={username}))", "{username}", "a*)(uid=*")
+	assert.Equal(t, `(&(objectClass=person)(uid=a\2a\29\28uid=\2a))`, got)
+}
+
+func TestNormalizeDN(t *testing.T) {
+	assert.Equal(t, "cn=ops,ou=groups,dc=example,dc=com", NormalizeDN("CN=Ops, OU=Groups, DC=example, DC=com"))
+	assert.Equal(t, "not a dn", NormalizeDN(" Not A DN "))
+}
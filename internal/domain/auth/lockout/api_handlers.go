@@ -0,0 +1,90 @@
+package lockout
+
+import (
+	"errors"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/security"
+	"berth/internal/domain/session"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type lockoutAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+type APIHandler struct {
+	service      *Service
+	db           *gorm.DB
+	auditService lockoutAuditLogger
+}
+
+func NewAPIHandler(service *Service, db *gorm.DB, auditService lockoutAuditLogger) *APIHandler {
+	return &APIHandler{
+		service:      service,
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+func (h *APIHandler) ListLockouts(c echo.Context) error {
+	entries, err := h.service.List()
+	if err != nil {
+		return response.Internal(c, "Failed to fetch account lockouts")
+	}
+
+	return response.OK(c, ListLockoutsData{
+		Lockouts: ToResponseList(entries),
+	})
+}
+
+func (h *APIHandler) ClearLockout(c echo.Context) error {
+	userID, err := echoparams.ParseUintParam(c, "userid")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var user usermodel.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "User not found")
+		}
+		return response.Internal(c, "Failed to fetch user")
+	}
+
+	cleared, err := h.service.Clear(user.ID)
+	if err != nil {
+		return response.Internal(c, "Failed to clear account lockout")
+	}
+	if !cleared {
+		return response.NotFound(c, "Account is not locked")
+	}
+
+	actorID := p.UserID()
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      security.EventAuthAccountUnlocked,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetUserID:   &user.ID,
+		TargetType:     security.TargetTypeUser,
+		TargetID:       &user.ID,
+		TargetName:     user.Username,
+		Success:        true,
+	})
+
+	return response.OK(c, ClearLockoutData{
+		Message: "Account lockout cleared successfully",
+	})
+}
@@ -0,0 +1,48 @@
+package lockout
+
+import "time"
+
+type LockoutInfo struct {
+	UserID         uint       `json:"user_id"`
+	Username       string     `json:"username"`
+	Email          string     `json:"email"`
+	Locked         bool       `json:"locked"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LockoutCount   int        `json:"lockout_count"`
+	LastFailedAt   *time.Time `json:"last_failed_at,omitempty"`
+	LastFailedIP   string     `json:"last_failed_ip,omitempty"`
+}
+
+type ListLockoutsData struct {
+	Lockouts []LockoutInfo `json:"lockouts"`
+}
+
+type ClearLockoutData struct {
+	Message string `json:"message"`
+}
+
+func ToResponse(e *LockoutEntry) LockoutInfo {
+	info := LockoutInfo{
+		UserID:         e.UserID,
+		Username:       e.Username,
+		Email:          e.Email,
+		Locked:         e.Locked,
+		FailedAttempts: e.FailedAttempts,
+		LockoutCount:   e.LockoutCount,
+		LastFailedAt:   e.LastFailedAt,
+		LastFailedIP:   e.LastFailedIP,
+	}
+	if e.Locked {
+		info.LockedUntil = e.LockedUntil
+	}
+	return info
+}
+
+func ToResponseList(entries []LockoutEntry) []LockoutInfo {
+	result := make([]LockoutInfo, len(entries))
+	for i := range entries {
+		result[i] = ToResponse(&entries[i])
+	}
+	return result
+}
@@ -0,0 +1,21 @@
+package lockout
+
+import "time"
+
+// AccountLockout tracks consecutive failed sign-ins for one account. The row
+// is removed after a successful sign-in or when an administrator clears it.
+type AccountLockout struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"user_id" gorm:"not null;uniqueIndex"`
+	FailedAttempts int        `json:"failed_attempts" gorm:"not null;default:0"`
+	LockoutCount   int        `json:"lockout_count" gorm:"not null;default:0"`
+	LastFailedAt   *time.Time `json:"last_failed_at"`
+	LastFailedIP   string     `json:"last_failed_ip" gorm:"size:64"`
+	LockedUntil    *time.Time `json:"locked_until" gorm:"index"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (AccountLockout) TableName() string {
+	return "account_lockouts"
+}
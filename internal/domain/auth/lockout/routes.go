@@ -0,0 +1,11 @@
+package lockout
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
+
+func (h *APIHandler) RegisterAdminAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/lockouts", h.ListLockouts, authz.Admin(permnames.AdminUsersRead))
+	reg.DELETE("/lockouts/:userid", h.ClearLockout, authz.Admin(permnames.AdminUsersWrite))
+}
@@ -0,0 +1,227 @@
+package lockout
+
+import (
+	"errors"
+	"time"
+
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const templateName = "account_locked"
+
+type lockoutMailer interface {
+	SendTemplate(templateName string, to []string, subject string, data map[string]any) error
+}
+
+type Service struct {
+	cfg     config.LockoutConfig
+	db      *gorm.DB
+	mailer  lockoutMailer
+	appName string
+	logger  *zap.Logger
+	now     func() time.Time
+}
+
+func NewService(cfg *config.Config, db *gorm.DB, mailer lockoutMailer, logger *zap.Logger) *Service {
+	return &Service{
+		cfg:     cfg.Auth.Lockout,
+		db:      db,
+		mailer:  mailer,
+		appName: cfg.App.Name,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+func (s *Service) Enabled() bool {
+	return s.cfg.Enabled
+}
+
+// Check reports whether the account is locked and until when.
+func (s *Service) Check(userID uint) (time.Time, bool, error) {
+	if !s.cfg.Enabled {
+		return time.Time{}, false, nil
+	}
+
+	var record AccountLockout
+	err := s.db.Where("user_id = ?", userID).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	if record.LockedUntil != nil && s.now().Before(*record.LockedUntil) {
+		return *record.LockedUntil, true, nil
+	}
+	return time.Time{}, false, nil
+}
+
+// FailureResult describes the account state after a failed attempt.
+type FailureResult struct {
+	FailedAttempts int
+	Locked         bool
+	LockedUntil    time.Time
+	LockoutCount   int
+}
+
+// RecordFailure counts a failed password or second-factor attempt. Reaching
+// the threshold locks the account for Duration doubled for every earlier
+// lockout, and restarts the count for the next one. Failures older than
+// ResetAfter are forgotten.
+func (s *Service) RecordFailure(user *usermodel.User, ip string) (*FailureResult, error) {
+	if !s.cfg.Enabled {
+		return &FailureResult{}, nil
+	}
+
+	now := s.now()
+	var record AccountLockout
+	locked := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", user.ID).First(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			record = AccountLockout{UserID: user.ID}
+		} else if err != nil {
+			return err
+		}
+
+		if record.LastFailedAt != nil && now.Sub(*record.LastFailedAt) > s.cfg.ResetAfter {
+			record.FailedAttempts = 0
+			record.LockoutCount = 0
+		}
+
+		record.FailedAttempts++
+		record.LastFailedAt = &now
+		record.LastFailedIP = ip
+
+		if record.FailedAttempts >= s.cfg.Threshold {
+			record.LockoutCount++
+			until := now.Add(s.lockoutDuration(record.LockoutCount))
+			record.LockedUntil = &until
+			record.FailedAttempts = 0
+			locked = true
+		}
+
+		return tx.Save(&record).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FailureResult{
+		FailedAttempts: record.FailedAttempts,
+		LockoutCount:   record.LockoutCount,
+	}
+	if locked {
+		result.Locked = true
+		result.LockedUntil = *record.LockedUntil
+		s.notify(user, result, ip)
+	}
+	return result, nil
+}
+
+// RecordSuccess forgets earlier failures once the user has fully signed in.
+func (s *Service) RecordSuccess(userID uint) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+	return s.db.Where("user_id = ?", userID).Delete(&AccountLockout{}).Error
+}
+
+// Clear removes the lockout and failure count for the account. It reports
+// whether there was anything to clear.
+func (s *Service) Clear(userID uint) (bool, error) {
+	result := s.db.Where("user_id = ?", userID).Delete(&AccountLockout{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// LockoutEntry pairs a lockout record with the account it belongs to.
+type LockoutEntry struct {
+	AccountLockout
+	Username string
+	Email    string
+	Locked   bool
+}
+
+// List returns locked accounts and accounts with recent failures, currently
+// locked accounts first.
+func (s *Service) List() ([]LockoutEntry, error) {
+	var records []AccountLockout
+	if err := s.db.Order("locked_until DESC, last_failed_at DESC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint, 0, len(records))
+	for _, record := range records {
+		userIDs = append(userIDs, record.UserID)
+	}
+	var users []usermodel.User
+	if len(userIDs) > 0 {
+		if err := s.db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[uint]usermodel.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	now := s.now()
+	locked := make([]LockoutEntry, 0, len(records))
+	failing := make([]LockoutEntry, 0, len(records))
+	for _, record := range records {
+		user, ok := byID[record.UserID]
+		if !ok {
+			continue
+		}
+		entry := LockoutEntry{
+			AccountLockout: record,
+			Username:       user.Username,
+			Email:          user.Email,
+			Locked:         record.LockedUntil != nil && now.Before(*record.LockedUntil),
+		}
+		switch {
+		case entry.Locked:
+			locked = append(locked, entry)
+		case record.FailedAttempts > 0 && (record.LastFailedAt == nil || now.Sub(*record.LastFailedAt) <= s.cfg.ResetAfter):
+			failing = append(failing, entry)
+		}
+	}
+	return append(locked, failing...), nil
+}
+
+func (s *Service) lockoutDuration(lockoutCount int) time.Duration {
+	d := s.cfg.Duration
+	for i := 1; i < lockoutCount; i++ {
+		d *= 2
+		if d >= s.cfg.MaxDuration {
+			return s.cfg.MaxDuration
+		}
+	}
+	return min(d, s.cfg.MaxDuration)
+}
+
+func (s *Service) notify(user *usermodel.User, result *FailureResult, ip string) {
+	if !s.cfg.NotifyUser || s.mailer == nil || user.Email == "" {
+		return
+	}
+
+	err := s.mailer.SendTemplate(templateName, []string{user.Email}, "Your account has been temporarily locked", map[string]any{
+		"Username":    user.Username,
+		"LockedUntil": result.LockedUntil.UTC().Format(time.RFC1123),
+		"Duration":    result.LockedUntil.Sub(s.now()).Round(time.Second).String(),
+		"IPAddress":   ip,
+		"AppName":     s.appName,
+	})
+	if err != nil {
+		s.logger.Warn("failed to send account lockout email",
+			zap.Uint("user_id", user.ID),
+			zap.Error(err),
+		)
+	}
+}
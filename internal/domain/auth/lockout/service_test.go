@@ -0,0 +1,231 @@
+package lockout
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type sentMail struct {
+	template string
+	to       []string
+	data     map[string]any
+}
+
+type fakeMailer struct {
+	sent []sentMail
+}
+
+func (f *fakeMailer) SendTemplate(templateName string, to []string, _ string, data map[string]any) error {
+	f.sent = append(f.sent, sentMail{template: templateName, to: to, data: data})
+	return nil
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestService(t *testing.T, mutate func(*config.LockoutConfig)) (*Service, *gorm.DB, *fakeMailer, *testClock) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:lockout_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&usermodel.User{}, &AccountLockout{}))
+
+	cfg := &config.Config{}
+	cfg.App.Name = "berth"
+	cfg.Auth.Lockout = config.LockoutConfig{
+		Enabled:     true,
+		Threshold:   3,
+		Duration:    time.Minute,
+		MaxDuration: 5 * time.Minute,
+		ResetAfter:  time.Hour,
+		NotifyUser:  true,
+	}
+	if mutate != nil {
+		mutate(&cfg.Auth.Lockout)
+	}
+
+	mailer := &fakeMailer{}
+	clock := &testClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	svc := NewService(cfg, database, mailer, zap.NewNop())
+	svc.now = func() time.Time { return clock.now }
+	return svc, database, mailer, clock
+}
+
+func createUser(t *testing.T, database *gorm.DB, username string) *usermodel.User {
+	t.Helper()
+	user := &usermodel.User{Username: username, Email: username + "@example.com", Password: "x"}
+	require.NoError(t, database.Create(user).Error)
+	return user
+}
+
+func failN(t *testing.T, svc *Service, user *usermodel.User, n int) *FailureResult {
+	t.Helper()
+	var result *FailureResult
+	for range n {
+		var err error
+		result, err = svc.RecordFailure(user, "203.0.113.7")
+		require.NoError(t, err)
+	}
+	return result
+}
+
+func TestRecordFailure_LocksAtThreshold(t *testing.T) {
+	svc, database, mailer, clock := newTestService(t, nil)
+	user := createUser(t, database, "alice")
+
+	result := failN(t, svc, user, 2)
+	assert.False(t, result.Locked)
+	assert.Equal(t, 2, result.FailedAttempts)
+	_, locked, err := svc.Check(user.ID)
+	require.NoError(t, err)
+	assert.False(t, locked)
+
+	result = failN(t, svc, user, 1)
+	assert.True(t, result.Locked)
+	assert.Equal(t, 1, result.LockoutCount)
+	assert.Equal(t, clock.now.Add(time.Minute), result.LockedUntil)
+
+	until, locked, err := svc.Check(user.ID)
+	require.NoError(t, err)
+	assert.True(t, locked)
+	assert.Equal(t, result.LockedUntil, until.UTC())
+
+	require.Len(t, mailer.sent, 1)
+	assert.Equal(t, "account_locked", mailer.sent[0].template)
+	assert.Equal(t, []string{"alice@example.com"}, mailer.sent[0].to)
+	assert.Equal(t, "203.0.113.7", mailer.sent[0].data["IPAddress"])
+
+	clock.advance(time.Minute + time.Second)
+	_, locked, err = svc.Check(user.ID)
+	require.NoError(t, err)
+	assert.False(t, locked)
+}
+
+func TestRecordFailure_BackoffDoublesUpToCap(t *testing.T) {
+	svc, database, _, clock := newTestService(t, nil)
+	user := createUser(t, database, "alice")
+
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, duration := range want {
+		result := failN(t, svc, user, 3)
+		require.True(t, result.Locked, "lockout %d", i+1)
+		assert.Equal(t, i+1, result.LockoutCount)
+		assert.Equal(t, clock.now.Add(duration), result.LockedUntil, "lockout %d", i+1)
+		clock.advance(duration)
+	}
+}
+
+func TestRecordFailure_ResetsAfterQuietPeriod(t *testing.T) {
+	svc, database, _, clock := newTestService(t, nil)
+	user := createUser(t, database, "alice")
+
+	failN(t, svc, user, 3)
+	clock.advance(2 * time.Minute)
+	failN(t, svc, user, 2)
+
+	clock.advance(time.Hour + time.Second)
+	result := failN(t, svc, user, 1)
+	assert.False(t, result.Locked)
+	assert.Equal(t, 1, result.FailedAttempts)
+	assert.Equal(t, 0, result.LockoutCount)
+
+	result = failN(t, svc, user, 2)
+	assert.True(t, result.Locked)
+	assert.Equal(t, clock.now.Add(time.Minute), result.LockedUntil)
+}
+
+func TestRecordSuccess_ClearsFailures(t *testing.T) {
+	svc, database, _, _ := newTestService(t, nil)
+	user := createUser(t, database, "alice")
+
+	failN(t, svc, user, 2)
+	require.NoError(t, svc.RecordSuccess(user.ID))
+
+	result := failN(t, svc, user, 2)
+	assert.False(t, result.Locked)
+	assert.Equal(t, 2, result.FailedAttempts)
+}
+
+func TestRecordFailure_NoEmailWhenNotifyDisabled(t *testing.T) {
+	svc, database, mailer, _ := newTestService(t, func(cfg *config.LockoutConfig) {
+		cfg.NotifyUser = false
+	})
+	user := createUser(t, database, "alice")
+
+	result := failN(t, svc, user, 3)
+	assert.True(t, result.Locked)
+	assert.Empty(t, mailer.sent)
+}
+
+func TestDisabled_NeverLocks(t *testing.T) {
+	svc, database, _, _ := newTestService(t, func(cfg *config.LockoutConfig) {
+		cfg.Enabled = false
+	})
+	user := createUser(t, database, "alice")
+
+	result := failN(t, svc, user, 10)
+	assert.False(t, result.Locked)
+	_, locked, err := svc.Check(user.ID)
+	require.NoError(t, err)
+	assert.False(t, locked)
+
+	var count int64
+	require.NoError(t, database.Model(&AccountLockout{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestListAndClear(t *testing.T) {
+	svc, database, _, clock := newTestService(t, nil)
+	alice := createUser(t, database, "alice")
+	bob := createUser(t, database, "bob")
+	carol := createUser(t, database, "carol")
+
+	failN(t, svc, bob, 1)
+	failN(t, svc, alice, 3)
+	failN(t, svc, carol, 1)
+	clock.advance(time.Hour + time.Minute)
+	failN(t, svc, bob, 1)
+
+	entries, err := svc.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "bob", entries[0].Username)
+	assert.False(t, entries[0].Locked)
+
+	failN(t, svc, alice, 3)
+	entries, err = svc.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "alice", entries[0].Username)
+	assert.True(t, entries[0].Locked)
+	assert.Equal(t, "bob", entries[1].Username)
+
+	cleared, err := svc.Clear(alice.ID)
+	require.NoError(t, err)
+	assert.True(t, cleared)
+	_, locked, err := svc.Check(alice.ID)
+	require.NoError(t, err)
+	assert.False(t, locked)
+
+	cleared, err = svc.Clear(alice.ID)
+	require.NoError(t, err)
+	assert.False(t, cleared)
+}
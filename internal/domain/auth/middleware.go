@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"berth/internal/domain/apikey"
+	"berth/internal/domain/auth/proxyauth"
 	tokens "berth/internal/domain/auth/tokens"
 	"berth/internal/domain/authz"
 	"berth/internal/domain/security"
@@ -23,7 +24,11 @@ type APIKeyAuthAuditor interface {
 	LogAPIEvent(eventType string, userID *uint, username, ip, userAgent string, success bool, failureReason string, metadata map[string]any) error
 }
 
-func RequireAuth(jwtService *tokens.Service, apiKeyService *apikey.Service, userProvider UserProvider, auditor APIKeyAuthAuditor) echo.MiddlewareFunc {
+// RequireAuth authenticates the request by bearer token (JWT or API key) or,
+// when proxyAuth is set, by identity headers from a trusted reverse proxy.
+// An explicit token takes precedence over proxy headers, but proxy headers
+// from an untrusted source reject the request either way.
+func RequireAuth(jwtService *tokens.Service, apiKeyService *apikey.Service, proxyAuth *proxyauth.Service, userProvider UserProvider, auditor APIKeyAuthAuditor) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			authHeader := c.Request().Header.Get("Authorization")
@@ -33,6 +38,14 @@ func RequireAuth(jwtService *tokens.Service, apiKeyService *apikey.Service, user
 				return echo.NewHTTPError(http.StatusBadRequest, "Ambiguous authentication: set either Authorization or Sec-WebSocket-Protocol, not both")
 			}
 
+			proxyIdentity := false
+			if proxyAuth != nil && proxyAuth.Present(c.Request().Header) {
+				if err := proxyAuth.CheckSource(proxyRequest(c)); err != nil {
+					return echo.NewHTTPError(http.StatusUnauthorized, "Authentication failed")
+				}
+				proxyIdentity = true
+			}
+
 			var tokenString string
 			switch {
 			case authHeader != "":
@@ -49,6 +62,8 @@ func RequireAuth(jwtService *tokens.Service, apiKeyService *apikey.Service, user
 					return echo.NewHTTPError(http.StatusUnauthorized, "Invalid Sec-WebSocket-Protocol format")
 				}
 				tokenString = token
+			case proxyIdentity:
+				return handleProxyAuth(c, next, proxyAuth, auditor)
 			default:
 				return echo.NewHTTPError(http.StatusUnauthorized, "Authorization header required")
 			}
@@ -94,6 +109,45 @@ func handleAPIKeyAuth(c echo.Context, next echo.HandlerFunc, apiKeyService *apik
 	return next(c)
 }
 
+func handleProxyAuth(c echo.Context, next echo.HandlerFunc, proxyAuth *proxyauth.Service, auditor APIKeyAuthAuditor) error {
+	req := proxyRequest(c)
+	user, err := proxyAuth.Authenticate(req)
+	if err != nil {
+		if auditor != nil {
+			_ = auditor.LogAPIEvent(security.EventAPIAuthFailed, nil, "", req.IP, req.UserAgent, false, err.Error(), map[string]any{"source": "proxy"})
+		}
+		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication failed")
+	}
+
+	// The proxy authenticates every request, so a user whose role requires a
+	// second factor they have not enrolled only gets the enrollment endpoints,
+	// as after a password login.
+	enrollmentOnly, err := proxyAuth.RequiresEnrollment(user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to check two-factor requirement")
+	}
+
+	c.Set(UserIDKey, user.ID)
+	c.Set("currentUser", *user)
+
+	p := authz.NewPrincipal(user.ID, hasAdminRole(user.Roles), nil)
+	if enrollmentOnly {
+		p = p.WithEnrollmentOnly()
+	}
+	authz.SetPrincipal(c, p)
+
+	return next(c)
+}
+
+func proxyRequest(c echo.Context) proxyauth.Request {
+	return proxyauth.Request{
+		Header:     c.Request().Header,
+		RemoteAddr: c.Request().RemoteAddr,
+		IP:         c.RealIP(),
+		UserAgent:  c.Request().UserAgent(),
+	}
+}
+
 func keyDescriptor(apiKey *apikey.APIKey) *authz.KeyDescriptor {
 	scopes := make([]authz.KeyScope, 0, len(apiKey.Scopes))
 	for _, scope := range apiKey.Scopes {
@@ -103,7 +157,11 @@ func keyDescriptor(apiKey *apikey.APIKey) *authz.KeyDescriptor {
 			Permission:   scope.Permission.Name,
 		})
 	}
-	return &authz.KeyDescriptor{ID: apiKey.ID, Scopes: scopes}
+	desc := &authz.KeyDescriptor{ID: apiKey.ID, Scopes: scopes}
+	if apiKey.RateLimit != nil {
+		desc.RateLimit = *apiKey.RateLimit
+	}
+	return desc
 }
 
 func hasAdminRole(roles []usermodel.Role) bool {
@@ -116,7 +174,7 @@ func hasAdminRole(roles []usermodel.Role) bool {
 }
 
 func handleJWTAuth(c echo.Context, next echo.HandlerFunc, jwtService *tokens.Service, userProvider UserProvider, tokenString string) error {
-	claims, err := jwtService.ValidateAccess(tokenString)
+	claims, enrollmentOnly, err := jwtService.ValidateAccessOrEnrollment(tokenString)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusUnauthorized, "Authentication failed")
 	}
@@ -129,7 +187,11 @@ func handleJWTAuth(c echo.Context, next echo.HandlerFunc, jwtService *tokens.Ser
 		c.Set("currentUser", user)
 
 		if u, ok := user.(usermodel.User); ok {
-			authz.SetPrincipal(c, authz.NewPrincipal(u.ID, hasAdminRole(u.Roles), nil))
+			p := authz.NewPrincipal(u.ID, hasAdminRole(u.Roles), nil)
+			if enrollmentOnly {
+				p = p.WithEnrollmentOnly()
+			}
+			authz.SetPrincipal(c, p)
 		}
 	}
 
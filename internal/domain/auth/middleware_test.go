@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"berth/internal/domain/auth/tokens"
+	"berth/internal/domain/authz"
 	usermodel "berth/internal/domain/user"
 	"berth/internal/pkg/config"
 
@@ -30,7 +31,7 @@ func newTestJWTService(t *testing.T) *tokens.Service {
 	cfg.JWT.SecretKey = "unit-test-jwt-secret-key-at-least-32"
 	cfg.JWT.Issuer = "berth"
 	cfg.JWT.AccessExpiry = 15 * time.Minute
-	svc, err := tokens.NewService(cfg, nil, zap.NewNop())
+	svc, err := tokens.NewService(cfg, nil, nil, zap.NewNop())
 	require.NoError(t, err)
 	return svc
 }
@@ -41,7 +42,7 @@ func TestRequireAuthJWT_RejectsTokenForMissingUser(t *testing.T) {
 	require.NoError(t, err)
 
 	run := func(provider UserProvider) (handlerRan bool, mwErr error) {
-		mw := RequireAuth(jwtSvc, nil, provider, nil)
+		mw := RequireAuth(jwtSvc, nil, nil, provider, nil)
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/profile", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		c := echo.New().NewContext(req, httptest.NewRecorder())
@@ -66,3 +67,41 @@ func TestRequireAuthJWT_RejectsTokenForMissingUser(t *testing.T) {
 		assert.True(t, ran, "a token for a live user must reach the handler")
 	})
 }
+
+func TestRequireAuthJWT_EnrollmentTokenYieldsRestrictedPrincipal(t *testing.T) {
+	jwtSvc := newTestJWTService(t)
+	u := usermodel.User{}
+	u.ID = 7
+
+	run := func(token string) (authz.Principal, error) {
+		var got authz.Principal
+		mw := RequireAuth(jwtSvc, nil, nil, stubUserProvider{user: u}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/profile", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		err := mw(func(c echo.Context) error {
+			got, _ = authz.PrincipalFromEcho(c)
+			return c.NoContent(http.StatusOK)
+		})(c)
+		return got, err
+	}
+
+	enrollment, err := jwtSvc.IssueEnrollmentToken(7)
+	require.NoError(t, err)
+	p, err := run(enrollment)
+	require.NoError(t, err)
+	assert.True(t, p.EnrollmentOnly(), "an enrollment token must only reach enrollment routes")
+
+	access, err := jwtSvc.IssueAccessToken(7)
+	require.NoError(t, err)
+	p, err = run(access)
+	require.NoError(t, err)
+	assert.False(t, p.EnrollmentOnly())
+
+	pending, err := jwtSvc.IssueTOTPPendingToken(7, "local")
+	require.NoError(t, err)
+	_, err = run(pending)
+	he, ok := err.(*echo.HTTPError)
+	require.True(t, ok, "expected an echo.HTTPError, got %v", err)
+	assert.Equal(t, http.StatusUnauthorized, he.Code)
+}
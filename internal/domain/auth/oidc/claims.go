@@ -0,0 +1,79 @@
+package oidc
+
+import "strings"
+
+// Claims are the claims of a verified ID token, merged with the userinfo
+// response when the provider has one.
+type Claims map[string]any
+
+func (c Claims) String(name string) string {
+	if v, ok := c.lookup(name).(string); ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+// Bool reads a boolean claim. Some providers send email_verified as a
+// string, so "true" is accepted too.
+func (c Claims) Bool(name string) bool {
+	switch v := c.lookup(name).(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	}
+	return false
+}
+
+// Strings reads a claim holding a list of strings, such as groups. A single
+// string is treated as a list of one.
+func (c Claims) Strings(name string) []string {
+	switch v := c.lookup(name).(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// lookup resolves a claim name, following dots into nested objects so that
+// claims such as realm_access.roles can be used.
+func (c Claims) lookup(name string) any {
+	if v, ok := c[name]; ok {
+		return v
+	}
+
+	var current any = map[string]any(c)
+	for _, part := range strings.Split(name, ".") {
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current, ok = obj[part]
+		if !ok {
+			return nil
+		}
+	}
+	return current
+}
+
+// merge copies claims that are not already set.
+func (c Claims) merge(other Claims) {
+	for k, v := range other {
+		if _, ok := c[k]; !ok {
+			c[k] = v
+		}
+	}
+}
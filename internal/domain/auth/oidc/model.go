@@ -0,0 +1,36 @@
+package oidc
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Identity links an identity provider subject to a Berth user. A user is
+// matched by issuer and subject on every login, so renaming the account at
+// the provider does not create a second user.
+type Identity struct {
+	gorm.Model
+	UserID  uint   `json:"user_id" gorm:"not null;index"`
+	Issuer  string `json:"issuer" gorm:"size:255;not null;uniqueIndex:idx_oidc_identity_subject,priority:1"`
+	Subject string `json:"subject" gorm:"size:255;not null;uniqueIndex:idx_oidc_identity_subject,priority:2"`
+}
+
+func (Identity) TableName() string {
+	return "oidc_identities"
+}
+
+// LoginState holds the values of an authorization request until the
+// provider redirects back. Each state is single use.
+type LoginState struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	StateHash    string    `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	Nonce        string    `json:"-" gorm:"size:64;not null"`
+	CodeVerifier string    `json:"-" gorm:"size:128;not null"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (LoginState) TableName() string {
+	return "oidc_login_states"
+}
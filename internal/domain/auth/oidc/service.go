@@ -0,0 +1,336 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"berth/internal/domain/auth/federation"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	CallbackPath = "/api/v1/auth/oidc/callback"
+
+	stateTTL = 10 * time.Minute
+)
+
+var (
+	ErrStateInvalid     = errors.New("login state is invalid or has expired")
+	ErrEmailMissing     = errors.New("identity provider did not return an email address")
+	ErrAccountNotFound  = errors.New("no account is linked to this identity")
+	ErrEmailNotVerified = errors.New("an account with this email exists but the provider has not verified the address")
+)
+
+type Service struct {
+	cfg         config.OIDCConfig
+	db          *gorm.DB
+	provider    *Provider
+	roles       federation.RoleSyncer
+	redirectURL string
+	logger      *zap.Logger
+	now         func() time.Time
+}
+
+func NewService(cfg *config.Config, db *gorm.DB, roles federation.RoleManager, logger *zap.Logger) (*Service, error) {
+	mappings, err := federation.ParseRoleMapping(cfg.OIDC.RoleMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	redirectURL := cfg.OIDC.RedirectURL
+	if redirectURL == "" {
+		redirectURL = strings.TrimRight(cfg.App.URL, "/") + CallbackPath
+	}
+
+	return &Service{
+		cfg:      cfg.OIDC,
+		db:       db,
+		provider: NewProvider(cfg.OIDC.IssuerURL, cfg.OIDC.ClientID, cfg.OIDC.ClientSecret),
+		roles: federation.RoleSyncer{
+			DB:       db,
+			Roles:    roles,
+			Mappings: mappings,
+			Logger:   logger,
+			Source:   "oidc",
+		},
+		redirectURL: redirectURL,
+		logger:      logger,
+		now:         time.Now,
+	}, nil
+}
+
+func (s *Service) ProviderName() string {
+	return s.cfg.ProviderName
+}
+
+func (s *Service) Issuer() string {
+	return s.cfg.IssuerURL
+}
+
+// BeginLogin starts an authorization request and returns the provider URL to
+// redirect the browser to, along with the state to bind to the browser.
+func (s *Service) BeginLogin(ctx context.Context) (authURL string, state string, err error) {
+	state, err = randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	authURL, err = s.provider.AuthCodeURL(ctx, s.redirectURL, s.cfg.Scopes, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		return "", "", err
+	}
+
+	now := s.now()
+	if err := s.db.Where("expires_at <= ?", now).Delete(&LoginState{}).Error; err != nil {
+		s.logger.Warn("failed to clean up expired OIDC login states", zap.Error(err))
+	}
+	if err := s.db.Create(&LoginState{
+		StateHash:    hashState(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(stateTTL),
+	}).Error; err != nil {
+		return "", "", fmt.Errorf("store login state: %w", err)
+	}
+
+	return authURL, state, nil
+}
+
+// LoginResult describes the user an OIDC login resolved to and what the
+// login changed.
+type LoginResult struct {
+	User          *usermodel.User
+	Subject       string
+	EmailVerified bool
+	Provisioned   bool
+	Linked        bool
+	RolesAssigned []string
+	RolesRevoked  []string
+}
+
+// CompleteLogin redeems the code returned to the callback, verifies the ID
+// token and resolves the Berth user, creating it when auto-provisioning is
+// enabled. Mapped roles are synced from the user's groups on every login.
+func (s *Service) CompleteLogin(ctx context.Context, state, code string) (*LoginResult, error) {
+	loginState, err := s.consumeState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.provider.Exchange(ctx, code, s.redirectURL, loginState.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.provider.VerifyIDToken(ctx, tokens.IDToken, loginState.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.provider.UserInfo(ctx, tokens.AccessToken)
+	if err != nil {
+		s.logger.Warn("failed to fetch OIDC userinfo; using ID token claims only", zap.Error(err))
+	} else if info != nil && info.String("sub") == claims.String("sub") {
+		claims.merge(info)
+	}
+
+	result, err := s.resolveUser(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	result.RolesAssigned, result.RolesRevoked = s.roles.Sync(result.User, claims.Strings(s.cfg.GroupsClaim))
+	if len(result.RolesAssigned) > 0 || len(result.RolesRevoked) > 0 {
+		if err := usermodel.FirstWithActiveRoles(s.db, result.User, result.User.ID); err != nil {
+			return nil, fmt.Errorf("reload user: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Service) consumeState(state string) (*LoginState, error) {
+	if state == "" {
+		return nil, ErrStateInvalid
+	}
+
+	var loginState LoginState
+	if err := s.db.Where("state_hash = ?", hashState(state)).First(&loginState).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStateInvalid
+		}
+		return nil, err
+	}
+
+	deleted := s.db.Delete(&loginState)
+	if deleted.Error != nil {
+		return nil, deleted.Error
+	}
+	if deleted.RowsAffected == 0 || !s.now().Before(loginState.ExpiresAt) {
+		return nil, ErrStateInvalid
+	}
+	return &loginState, nil
+}
+
+func (s *Service) resolveUser(claims Claims) (*LoginResult, error) {
+	subject := claims.String("sub")
+	email := strings.ToLower(claims.String("email"))
+	result := &LoginResult{
+		Subject:       subject,
+		EmailVerified: claims.Bool("email_verified"),
+	}
+
+	var identity Identity
+	err := s.db.Where("issuer = ? AND subject = ?", s.cfg.IssuerURL, subject).First(&identity).Error
+	switch {
+	case err == nil:
+		var user usermodel.User
+		if err := usermodel.FirstWithActiveRoles(s.db, &user, identity.UserID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrAccountNotFound
+			}
+			return nil, err
+		}
+		result.User = &user
+		return result, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	if email == "" {
+		return nil, ErrEmailMissing
+	}
+
+	// Only an address the provider has verified may claim an existing
+	// account; otherwise anyone able to set an email at the provider could
+	// take over the matching Berth user.
+	if result.EmailVerified {
+		var user usermodel.User
+		err := usermodel.FirstWithActiveRoles(s.db.Where("LOWER(email) = ?", email), &user)
+		if err == nil {
+			if err := s.link(user.ID, subject); err != nil {
+				return nil, err
+			}
+			result.User = &user
+			result.Linked = true
+			return result, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	} else {
+		var count int64
+		if err := s.db.Model(&usermodel.User{}).Where("LOWER(email) = ?", email).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, ErrEmailNotVerified
+		}
+	}
+
+	if !s.cfg.AutoProvision {
+		return nil, ErrAccountNotFound
+	}
+
+	user, err := s.provision(claims, email, result.EmailVerified, subject)
+	if err != nil {
+		return nil, err
+	}
+	result.User = user
+	result.Provisioned = true
+	return result, nil
+}
+
+func (s *Service) link(userID uint, subject string) error {
+	return s.db.Create(&Identity{
+		UserID:  userID,
+		Issuer:  s.cfg.IssuerURL,
+		Subject: subject,
+	}).Error
+}
+
+func (s *Service) provision(claims Claims, email string, emailVerified bool, subject string) (*usermodel.User, error) {
+	hashed, err := federation.PlaceholderPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	var user usermodel.User
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		username, err := federation.UniqueUsername(tx, preferredUsername(claims, s.cfg.UsernameClaim, email))
+		if err != nil {
+			return err
+		}
+
+		user = usermodel.User{
+			Username: username,
+			Email:    email,
+			Password: hashed,
+		}
+		if emailVerified {
+			now := s.now()
+			user.EmailVerifiedAt = &now
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&Identity{
+			UserID:  user.ID,
+			Issuer:  s.cfg.IssuerURL,
+			Subject: subject,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("provision user: %w", err)
+	}
+
+	s.logger.Info("provisioned user from OIDC login",
+		zap.Uint("user_id", user.ID),
+		zap.String("username", user.Username),
+	)
+	return &user, nil
+}
+
+func preferredUsername(claims Claims, usernameClaim, email string) string {
+	if username := claims.String(usernameClaim); username != "" {
+		return username
+	}
+	if local, _, ok := strings.Cut(email, "@"); ok && local != "" {
+		return local
+	}
+	return claims.String("sub")
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
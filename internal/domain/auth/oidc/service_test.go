@@ -0,0 +1,382 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+	"berth/internal/platform/db"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+const testClientID = "berth"
+
+type pendingCode struct {
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+// mockProvider is a minimal OpenID Connect provider: discovery, JWKS, a
+// PKCE-checking token endpoint and userinfo.
+type mockProvider struct {
+	t        *testing.T
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	mu       sync.Mutex
+	codes    map[string]pendingCode
+	issued   int
+	userinfo map[string]any
+	// audience overrides the ID token audience when set.
+	audience string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockProvider{t: t, key: key, codes: map[string]pendingCode{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"userinfo_endpoint":      m.server.URL + "/userinfo",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", m.token)
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer provider-access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.userinfo == nil {
+			_ = json.NewEncoder(w).Encode(map[string]any{})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(m.userinfo)
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	clientID, secret, _ := r.BasicAuth()
+	if clientID != testClientID || secret != "s3cret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	m.mu.Lock()
+	pending, ok := m.codes[r.Form.Get("code")]
+	delete(m.codes, r.Form.Get("code"))
+	m.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != pending.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"aud":   testClientID,
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": pending.nonce,
+	}
+	if m.audience != "" {
+		claims["aud"] = m.audience
+	}
+	for k, v := range pending.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	idToken, err := token.SignedString(m.key)
+	require.NoError(m.t, err)
+
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "provider-access-token",
+		"id_token":     idToken,
+		"token_type":   "Bearer",
+	})
+}
+
+// authorize stands in for the user signing in at the provider: it reads the
+// authorization request and issues a code for the given claims.
+func (m *mockProvider) authorize(t *testing.T, authURL string, claims jwt.MapClaims) string {
+	t.Helper()
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+	require.Equal(t, testClientID, query.Get("client_id"))
+
+	m.mu.Lock()
+	m.issued++
+	code := fmt.Sprintf("code-%d", m.issued)
+	m.codes[code] = pendingCode{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), claims: claims}
+	m.mu.Unlock()
+	return code
+}
+
+type dbRoleManager struct {
+	db *gorm.DB
+}
+
+func (r dbRoleManager) AssignRole(userID, roleID uint) error {
+	return r.db.Model(&usermodel.User{BaseModel: baseModel(userID)}).Association("Roles").Append(&usermodel.Role{BaseModel: baseModel(roleID)})
+}
+
+func (r dbRoleManager) RevokeRole(userID, roleID uint) error {
+	return r.db.Model(&usermodel.User{BaseModel: baseModel(userID)}).Association("Roles").Delete(&usermodel.Role{BaseModel: baseModel(roleID)})
+}
+
+func baseModel(id uint) db.BaseModel {
+	return db.BaseModel{ID: id}
+}
+
+func newTestService(t *testing.T, provider *mockProvider, modify func(*config.OIDCConfig)) (*Service, *gorm.DB) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:oidc_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&usermodel.User{}, &usermodel.Role{}, &usermodel.UserRole{}, &Identity{}, &LoginState{}))
+	require.NoError(t, database.Create(&usermodel.Role{Name: "admin", IsAdmin: true}).Error)
+	require.NoError(t, database.Create(&usermodel.Role{Name: "developer"}).Error)
+
+	cfg := &config.Config{
+		App: config.AppConfig{URL: "https://berth.example.com/"},
+		OIDC: config.OIDCConfig{
+			Enabled:       true,
+			IssuerURL:     provider.server.URL,
+			ClientID:      testClientID,
+			ClientSecret:  "s3cret",
+			Scopes:        []string{"openid", "email", "profile"},
+			UsernameClaim: "preferred_username",
+			GroupsClaim:   "groups",
+			RoleMapping:   "berth-admins=admin; berth-devs=developer",
+			AutoProvision: true,
+		},
+	}
+	if modify != nil {
+		modify(&cfg.OIDC)
+	}
+
+	svc, err := NewService(cfg, database, dbRoleManager{db: database}, zap.NewNop())
+	require.NoError(t, err)
+	return svc, database
+}
+
+func login(t *testing.T, svc *Service, provider *mockProvider, claims jwt.MapClaims) (*LoginResult, error) {
+	t.Helper()
+	authURL, state, err := svc.BeginLogin(context.Background())
+	require.NoError(t, err)
+	code := provider.authorize(t, authURL, claims)
+	return svc.CompleteLogin(context.Background(), state, code)
+}
+
+func roleNames(user *usermodel.User) []string {
+	names := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+func TestCompleteLogin_ProvisionsUserAndSyncsMappedRoles(t *testing.T) {
+	provider := newMockProvider(t)
+	svc, db := newTestService(t, provider, nil)
+
+	result, err := login(t, svc, provider, jwt.MapClaims{
+		"sub":                "alice-sub",
+		"email":              "Alice@Example.com",
+		"email_verified":     true,
+		"preferred_username": "alice",
+		"groups":             []string{"berth-admins", "unrelated"},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Provisioned)
+	assert.Equal(t, "alice", result.User.Username)
+	assert.Equal(t, "alice@example.com", result.User.Email)
+	assert.NotNil(t, result.User.EmailVerifiedAt)
+	assert.Equal(t, []string{"admin"}, result.RolesAssigned)
+	assert.ElementsMatch(t, []string{"admin"}, roleNames(result.User))
+
+	var identities int64
+	require.NoError(t, db.Model(&Identity{}).Where("user_id = ? AND subject = ?", result.User.ID, "alice-sub").Count(&identities).Error)
+	assert.Equal(t, int64(1), identities)
+
+	// The next login resolves the same user by subject even though the
+	// username changed at the provider, and moves the mapped roles.
+	result2, err := login(t, svc, provider, jwt.MapClaims{
+		"sub":                "alice-sub",
+		"email":              "alice@example.com",
+		"preferred_username": "alice.renamed",
+		"groups":             []string{"berth-devs"},
+	})
+	require.NoError(t, err)
+	assert.False(t, result2.Provisioned)
+	assert.Equal(t, result.User.ID, result2.User.ID)
+	assert.Equal(t, []string{"developer"}, result2.RolesAssigned)
+	assert.Equal(t, []string{"admin"}, result2.RolesRevoked)
+	assert.ElementsMatch(t, []string{"developer"}, roleNames(result2.User))
+}
+
+func TestCompleteLogin_GroupsFromUserInfo(t *testing.T) {
+	provider := newMockProvider(t)
+	provider.userinfo = map[string]any{"sub": "bob-sub", "groups": []string{"berth-devs"}}
+	svc, _ := newTestService(t, provider, nil)
+
+	result, err := login(t, svc, provider, jwt.MapClaims{
+		"sub":            "bob-sub",
+		"email":          "bob@example.com",
+		"email_verified": true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "bob", result.User.Username, "falls back to the email local part")
+	assert.ElementsMatch(t, []string{"developer"}, roleNames(result.User))
+}
+
+func TestCompleteLogin_LinksExistingUserByVerifiedEmail(t *testing.T) {
+	provider := newMockProvider(t)
+	svc, db := newTestService(t, provider, nil)
+
+	existing := usermodel.User{Username: "carol", Email: "carol@example.com", Password: "x"}
+	require.NoError(t, db.Create(&existing).Error)
+	require.NoError(t, db.Create(&usermodel.User{Username: "dave", Email: "dave@example.com", Password: "x"}).Error)
+
+	result, err := login(t, svc, provider, jwt.MapClaims{
+		"sub":            "carol-sub",
+		"email":          "carol@example.com",
+		"email_verified": true,
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Linked)
+	assert.Equal(t, existing.ID, result.User.ID)
+
+	_, err = login(t, svc, provider, jwt.MapClaims{
+		"sub":            "dave-sub",
+		"email":          "dave@example.com",
+		"email_verified": false,
+	})
+	assert.ErrorIs(t, err, ErrEmailNotVerified)
+}
+
+func TestCompleteLogin_RequiresLinkedAccountWithoutAutoProvision(t *testing.T) {
+	provider := newMockProvider(t)
+	svc, _ := newTestService(t, provider, func(c *config.OIDCConfig) { c.AutoProvision = false })
+
+	_, err := login(t, svc, provider, jwt.MapClaims{
+		"sub":            "erin-sub",
+		"email":          "erin@example.com",
+		"email_verified": true,
+	})
+	assert.ErrorIs(t, err, ErrAccountNotFound)
+}
+
+func TestCompleteLogin_StateIsSingleUseAndExpires(t *testing.T) {
+	provider := newMockProvider(t)
+	svc, _ := newTestService(t, provider, nil)
+	claims := jwt.MapClaims{"sub": "frank-sub", "email": "frank@example.com", "email_verified": true}
+
+	authURL, state, err := svc.BeginLogin(context.Background())
+	require.NoError(t, err)
+	code := provider.authorize(t, authURL, claims)
+	_, err = svc.CompleteLogin(context.Background(), state, code)
+	require.NoError(t, err)
+
+	_, err = svc.CompleteLogin(context.Background(), state, code)
+	assert.ErrorIs(t, err, ErrStateInvalid)
+
+	_, err = svc.CompleteLogin(context.Background(), "never-issued", code)
+	assert.ErrorIs(t, err, ErrStateInvalid)
+
+	authURL, state, err = svc.BeginLogin(context.Background())
+	require.NoError(t, err)
+	code = provider.authorize(t, authURL, claims)
+	svc.now = func() time.Time { return time.Now().Add(stateTTL + time.Second) }
+	_, err = svc.CompleteLogin(context.Background(), state, code)
+	assert.ErrorIs(t, err, ErrStateInvalid)
+}
+
+func TestCompleteLogin_RejectsInvalidIDTokens(t *testing.T) {
+	provider := newMockProvider(t)
+	svc, _ := newTestService(t, provider, nil)
+
+	t.Run("wrong audience", func(t *testing.T) {
+		provider.audience = "another-client"
+		defer func() { provider.audience = "" }()
+		_, err := login(t, svc, provider, jwt.MapClaims{"sub": "gina-sub", "email": "gina@example.com"})
+		assert.ErrorContains(t, err, "invalid ID token")
+	})
+
+	t.Run("replayed nonce", func(t *testing.T) {
+		_, err := login(t, svc, provider, jwt.MapClaims{"sub": "gina-sub", "email": "gina@example.com", "nonce": "attacker"})
+		assert.ErrorContains(t, err, "nonce")
+	})
+
+	t.Run("missing email", func(t *testing.T) {
+		_, err := login(t, svc, provider, jwt.MapClaims{"sub": "gina-sub"})
+		assert.ErrorIs(t, err, ErrEmailMissing)
+	})
+}
+
+func TestClaims_Strings(t *testing.T) {
+	claims := Claims{
+		"groups":       []any{"a", "b", 3},
+		"single":       "one",
+		"realm_access": map[string]any{"roles": []any{"ops"}},
+	}
+
+	assert.Equal(t, []string{"a", "b"}, claims.Strings("groups"))
+	assert.Equal(t, []string{"one"}, claims.Strings("single"))
+	assert.Equal(t, []string{"ops"}, claims.Strings("realm_access.roles"))
+	assert.Nil(t, claims.Strings("missing"))
+}
@@ -0,0 +1,44 @@
+package passkey
+
+import "time"
+
+// Credential is a WebAuthn public key credential registered to a user: a
+// roaming security key or a platform or synced passkey. Revoked credentials
+// are deleted outright so the same authenticator can be registered again.
+type Credential struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	Name            string     `json:"name" gorm:"size:100;not null"`
+	CredentialID    string     `json:"-" gorm:"size:255;not null;uniqueIndex"`
+	PublicKey       []byte     `json:"-" gorm:"not null"`
+	AttestationType string     `json:"-" gorm:"size:32"`
+	Transports      string     `json:"-" gorm:"size:255"`
+	AAGUID          []byte     `json:"-"`
+	SignCount       uint32     `json:"-"`
+	UserVerified    bool       `json:"-"`
+	BackupEligible  bool       `json:"backup_eligible"`
+	BackupState     bool       `json:"backup_state"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (Credential) TableName() string {
+	return "webauthn_credentials"
+}
+
+// Ceremony holds the challenge of a registration or login between its begin
+// and finish requests. Each ceremony is single use.
+type Ceremony struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	SessionHash string    `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	Purpose     string    `json:"purpose" gorm:"size:32;not null"`
+	UserID      *uint     `json:"user_id,omitempty" gorm:"index"`
+	Data        string    `json:"-" gorm:"type:text;not null"`
+	ExpiresAt   time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (Ceremony) TableName() string {
+	return "webauthn_ceremonies"
+}
@@ -0,0 +1,517 @@
+package passkey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	purposeRegistration = "registration"
+	purposeSecondFactor = "second_factor"
+	purposePasswordless = "passwordless"
+)
+
+var (
+	ErrCeremonyInvalid    = errors.New("passkey challenge is invalid or has expired")
+	ErrVerificationFailed = errors.New("passkey could not be verified")
+	ErrCredentialNotFound = errors.New("passkey not found")
+	ErrNoCredentials      = errors.New("no passkeys are registered")
+	ErrAlreadyRegistered  = errors.New("passkey is already registered")
+)
+
+type Service struct {
+	db       *gorm.DB
+	webauthn *webauthn.WebAuthn
+	timeout  time.Duration
+	logger   *zap.Logger
+	now      func() time.Time
+}
+
+func NewService(cfg *config.Config, db *gorm.DB, logger *zap.Logger) (*Service, error) {
+	rpID := cfg.WebAuthn.RPID
+	origins := cfg.WebAuthn.Origins
+	if rpID == "" || len(origins) == 0 {
+		appURL, err := url.Parse(cfg.App.URL)
+		if err != nil || appURL.Hostname() == "" {
+			return nil, fmt.Errorf("derive relying party from APP_URL %q: set WEBAUTHN_RP_ID and WEBAUTHN_ORIGINS", cfg.App.URL)
+		}
+		if rpID == "" {
+			rpID = appURL.Hostname()
+		}
+		if len(origins) == 0 {
+			origins = []string{appURL.Scheme + "://" + appURL.Host}
+		}
+	}
+
+	displayName := cfg.WebAuthn.RPDisplayName
+	if displayName == "" {
+		displayName = cfg.App.Name
+	}
+
+	timeout := cfg.WebAuthn.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: displayName,
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationPreferred,
+		},
+		AttestationPreference: protocol.PreferNoAttestation,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Timeout: timeout, TimeoutUVD: timeout},
+			Registration: webauthn.TimeoutConfig{Timeout: timeout, TimeoutUVD: timeout},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("webauthn config: %w", err)
+	}
+
+	return &Service{
+		db:       db,
+		webauthn: wa,
+		timeout:  timeout,
+		logger:   logger,
+		now:      time.Now,
+	}, nil
+}
+
+// BeginRegistration starts registering a new credential for the user. The
+// returned session ID must be sent back with the authenticator's response.
+func (s *Service) BeginRegistration(user *usermodel.User) (*protocol.CredentialCreation, string, error) {
+	account, err := s.loadAccount(user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	creation, session, err := s.webauthn.BeginRegistration(account,
+		webauthn.WithExclusions(webauthn.Credentials(account.credentials).CredentialDescriptors()),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sessionID, err := s.storeCeremony(purposeRegistration, &user.ID, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return creation, sessionID, nil
+}
+
+// FinishRegistration verifies the authenticator's attestation response and
+// stores the new credential under the given name.
+func (s *Service) FinishRegistration(user *usermodel.User, sessionID, name string, response []byte) (*Credential, error) {
+	session, err := s.consumeCeremony(sessionID, purposeRegistration, &user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.loadAccount(user)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrVerificationFailed, err)
+	}
+	created, err := s.webauthn.CreateCredential(account, *session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrVerificationFailed, err)
+	}
+
+	credentialID := encodeID(created.ID)
+	var existing int64
+	if err := s.db.Model(&Credential{}).Where("credential_id = ?", credentialID).Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, ErrAlreadyRegistered
+	}
+
+	transports := make([]string, len(created.Transport))
+	for i, transport := range created.Transport {
+		transports[i] = string(transport)
+	}
+
+	credential := &Credential{
+		UserID:          user.ID,
+		Name:            strings.TrimSpace(name),
+		CredentialID:    credentialID,
+		PublicKey:       created.PublicKey,
+		AttestationType: created.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          created.Authenticator.AAGUID,
+		SignCount:       created.Authenticator.SignCount,
+		UserVerified:    created.Flags.UserVerified,
+		BackupEligible:  created.Flags.BackupEligible,
+		BackupState:     created.Flags.BackupState,
+	}
+	if err := s.db.Create(credential).Error; err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+func (s *Service) ListCredentials(userID uint) ([]Credential, error) {
+	var credentials []Credential
+	err := s.db.Where("user_id = ?", userID).Order("created_at ASC, id ASC").Find(&credentials).Error
+	return credentials, err
+}
+
+func (s *Service) HasCredentials(userID uint) bool {
+	var count int64
+	if err := s.db.Model(&Credential{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
+
+func (s *Service) RenameCredential(userID, id uint, name string) (*Credential, error) {
+	credential, err := s.getCredential(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	credential.Name = strings.TrimSpace(name)
+	if err := s.db.Model(credential).Update("name", credential.Name).Error; err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// DeleteCredential revokes the credential. It returns the deleted record for
+// auditing.
+func (s *Service) DeleteCredential(userID, id uint) (*Credential, error) {
+	credential, err := s.getCredential(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Delete(credential).Error; err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// BeginLogin starts a second-factor assertion limited to the user's
+// registered credentials.
+func (s *Service) BeginLogin(user *usermodel.User) (*protocol.CredentialAssertion, string, error) {
+	account, err := s.loadAccount(user)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(account.credentials) == 0 {
+		return nil, "", ErrNoCredentials
+	}
+
+	assertion, session, err := s.webauthn.BeginLogin(account)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sessionID, err := s.storeCeremony(purposeSecondFactor, &user.ID, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return assertion, sessionID, nil
+}
+
+// FinishLogin verifies a second-factor assertion for the user and returns
+// the credential that was used.
+func (s *Service) FinishLogin(user *usermodel.User, sessionID string, response []byte) (*Credential, error) {
+	session, err := s.consumeCeremony(sessionID, purposeSecondFactor, &user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.loadAccount(user)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrVerificationFailed, err)
+	}
+	validated, err := s.webauthn.ValidateLogin(account, *session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrVerificationFailed, err)
+	}
+
+	return s.recordUse(account, validated)
+}
+
+// BeginPasswordlessLogin starts a usernameless assertion. The authenticator
+// picks a discoverable credential and must verify the user itself.
+func (s *Service) BeginPasswordlessLogin() (*protocol.CredentialAssertion, string, error) {
+	assertion, session, err := s.webauthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sessionID, err := s.storeCeremony(purposePasswordless, nil, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return assertion, sessionID, nil
+}
+
+// FinishPasswordlessLogin verifies a usernameless assertion and resolves the
+// user that owns the credential.
+func (s *Service) FinishPasswordlessLogin(sessionID string, response []byte) (*usermodel.User, *Credential, error) {
+	session, err := s.consumeCeremony(sessionID, purposePasswordless, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrVerificationFailed, err)
+	}
+
+	var account *webauthnAccount
+	handler := func(_, userHandle []byte) (webauthn.User, error) {
+		userID, ok := decodeUserHandle(userHandle)
+		if !ok {
+			return nil, ErrCredentialNotFound
+		}
+		var user usermodel.User
+		if err := usermodel.FirstWithActiveRoles(s.db, &user, userID); err != nil {
+			return nil, err
+		}
+		loaded, err := s.loadAccount(&user)
+		if err != nil {
+			return nil, err
+		}
+		account = loaded
+		return account, nil
+	}
+
+	validated, err := s.webauthn.ValidateDiscoverableLogin(handler, *session, parsed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrVerificationFailed, err)
+	}
+
+	credential, err := s.recordUse(account, validated)
+	if err != nil {
+		return nil, nil, err
+	}
+	return account.user, credential, nil
+}
+
+// recordUse stores the authenticator's new signature counter and backup
+// state. A counter that went backwards suggests a cloned authenticator, so
+// the assertion is rejected.
+func (s *Service) recordUse(account *webauthnAccount, validated *webauthn.Credential) (*Credential, error) {
+	credentialID := encodeID(validated.ID)
+	var credential Credential
+	if err := s.db.Where("user_id = ? AND credential_id = ?", account.user.ID, credentialID).First(&credential).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCredentialNotFound
+		}
+		return nil, err
+	}
+
+	if validated.Authenticator.CloneWarning {
+		s.logger.Warn("passkey signature counter did not increase - possible cloned authenticator",
+			zap.Uint("user_id", account.user.ID),
+			zap.Uint("credential_id", credential.ID),
+		)
+		return nil, fmt.Errorf("%w: signature counter did not increase", ErrVerificationFailed)
+	}
+
+	now := s.now()
+	credential.SignCount = validated.Authenticator.SignCount
+	credential.BackupState = validated.Flags.BackupState
+	credential.LastUsedAt = &now
+	if err := s.db.Model(&credential).Updates(map[string]any{
+		"sign_count":   credential.SignCount,
+		"backup_state": credential.BackupState,
+		"last_used_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (s *Service) getCredential(userID, id uint) (*Credential, error) {
+	var credential Credential
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&credential).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCredentialNotFound
+		}
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (s *Service) loadAccount(user *usermodel.User) (*webauthnAccount, error) {
+	records, err := s.ListCredentials(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]webauthn.Credential, 0, len(records))
+	for _, record := range records {
+		id, err := base64.RawURLEncoding.DecodeString(record.CredentialID)
+		if err != nil {
+			s.logger.Warn("skipping passkey with malformed credential ID",
+				zap.Uint("credential_id", record.ID),
+			)
+			continue
+		}
+		var transports []protocol.AuthenticatorTransport
+		for _, transport := range strings.Split(record.Transports, ",") {
+			if transport != "" {
+				transports = append(transports, protocol.AuthenticatorTransport(transport))
+			}
+		}
+		credentials = append(credentials, webauthn.Credential{
+			ID:              id,
+			PublicKey:       record.PublicKey,
+			AttestationType: record.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				UserPresent:    true,
+				UserVerified:   record.UserVerified,
+				BackupEligible: record.BackupEligible,
+				BackupState:    record.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    record.AAGUID,
+				SignCount: record.SignCount,
+			},
+		})
+	}
+
+	return &webauthnAccount{user: user, credentials: credentials}, nil
+}
+
+func (s *Service) storeCeremony(purpose string, userID *uint, session *webauthn.SessionData) (string, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate session ID: %w", err)
+	}
+	sessionID := base64.RawURLEncoding.EncodeToString(b)
+
+	now := s.now()
+	if err := s.db.Where("expires_at <= ?", now).Delete(&Ceremony{}).Error; err != nil {
+		s.logger.Warn("failed to clean up expired passkey ceremonies", zap.Error(err))
+	}
+	if err := s.db.Create(&Ceremony{
+		SessionHash: hashSessionID(sessionID),
+		Purpose:     purpose,
+		UserID:      userID,
+		Data:        string(data),
+		ExpiresAt:   now.Add(s.timeout),
+	}).Error; err != nil {
+		return "", fmt.Errorf("store passkey ceremony: %w", err)
+	}
+	return sessionID, nil
+}
+
+// consumeCeremony redeems a session ID once. The ceremony must have been
+// started for the same purpose and, when given, the same user.
+func (s *Service) consumeCeremony(sessionID, purpose string, userID *uint) (*webauthn.SessionData, error) {
+	if sessionID == "" {
+		return nil, ErrCeremonyInvalid
+	}
+
+	var ceremony Ceremony
+	if err := s.db.Where("session_hash = ?", hashSessionID(sessionID)).First(&ceremony).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCeremonyInvalid
+		}
+		return nil, err
+	}
+
+	deleted := s.db.Delete(&ceremony)
+	if deleted.Error != nil {
+		return nil, deleted.Error
+	}
+	if deleted.RowsAffected == 0 || !s.now().Before(ceremony.ExpiresAt) || ceremony.Purpose != purpose {
+		return nil, ErrCeremonyInvalid
+	}
+	if userID != nil && (ceremony.UserID == nil || *ceremony.UserID != *userID) {
+		return nil, ErrCeremonyInvalid
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(ceremony.Data), &session); err != nil {
+		return nil, fmt.Errorf("decode passkey ceremony: %w", err)
+	}
+	return &session, nil
+}
+
+// webauthnAccount adapts a user and their stored credentials to the
+// webauthn.User interface.
+type webauthnAccount struct {
+	user        *usermodel.User
+	credentials []webauthn.Credential
+}
+
+// WebAuthnID is the user handle stored on discoverable credentials. It is
+// the user ID, which is never reused, so usernameless logins can find the
+// account without a lookup table.
+func (a *webauthnAccount) WebAuthnID() []byte {
+	return userHandle(a.user.ID)
+}
+
+func (a *webauthnAccount) WebAuthnName() string {
+	return a.user.Username
+}
+
+func (a *webauthnAccount) WebAuthnDisplayName() string {
+	return a.user.Username
+}
+
+func (a *webauthnAccount) WebAuthnCredentials() []webauthn.Credential {
+	return a.credentials
+}
+
+func userHandle(userID uint) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(userID))
+}
+
+func decodeUserHandle(handle []byte) (uint, bool) {
+	if len(handle) != 8 {
+		return 0, false
+	}
+	return uint(binary.BigEndian.Uint64(handle)), true
+}
+
+func encodeID(id []byte) string {
+	return base64.RawURLEncoding.EncodeToString(id)
+}
+
+func hashSessionID(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,364 @@
+package passkey
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testOrigin = "https://berth.example.com"
+
+var dbCounter atomic.Int64
+
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagAttested       = 0x40
+)
+
+// softAuthenticator is a minimal ES256 authenticator producing "none"
+// attestations and assertions the way a browser would return them.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+	userHandle   []byte
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	require.NoError(t, err)
+	return &softAuthenticator{key: key, credentialID: id}
+}
+
+func (a *softAuthenticator) authData(flags byte, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte("berth.example.com"))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if !attested {
+		return data
+	}
+
+	data = append(data, make([]byte, 16)...)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+	data = append(data, a.credentialID...)
+	publicKey, err := cbor.Marshal(map[int]any{
+		1:  2,
+		3:  -7,
+		-1: 1,
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return append(data, publicKey...)
+}
+
+func clientData(t *testing.T, ceremonyType, challenge string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{
+		"type":      ceremonyType,
+		"challenge": challenge,
+		"origin":    testOrigin,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func (a *softAuthenticator) register(t *testing.T, challenge string) []byte {
+	t.Helper()
+	attestation, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(flagUserPresent|flagUserVerified|flagAttested, true),
+	})
+	require.NoError(t, err)
+
+	body, err := json.Marshal(map[string]any{
+		"id":    b64(a.credentialID),
+		"rawId": b64(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]any{
+			"clientDataJSON":    b64(clientData(t, "webauthn.create", challenge)),
+			"attestationObject": b64(attestation),
+		},
+	})
+	require.NoError(t, err)
+	return body
+}
+
+func (a *softAuthenticator) assert(t *testing.T, challenge string, flags byte) []byte {
+	t.Helper()
+	a.signCount++
+	authData := a.authData(flags, false)
+	client := clientData(t, "webauthn.get", challenge)
+	clientHash := sha256.Sum256(client)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	response := map[string]any{
+		"clientDataJSON":    b64(client),
+		"authenticatorData": b64(authData),
+		"signature":         b64(signature),
+	}
+	if a.userHandle != nil {
+		response["userHandle"] = b64(a.userHandle)
+	}
+	body, err := json.Marshal(map[string]any{
+		"id":       b64(a.credentialID),
+		"rawId":    b64(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	require.NoError(t, err)
+	return body
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func newTestService(t *testing.T) (*Service, *gorm.DB, *testClock) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:passkey_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&usermodel.User{}, &usermodel.Role{}, &usermodel.UserRole{}, &Credential{}, &Ceremony{}))
+
+	cfg := &config.Config{}
+	cfg.App.Name = "Berth"
+	cfg.App.URL = testOrigin
+	cfg.WebAuthn.Timeout = time.Minute
+
+	svc, err := NewService(cfg, database, zap.NewNop())
+	require.NoError(t, err)
+	clock := &testClock{now: time.Now()}
+	svc.now = func() time.Time { return clock.now }
+	return svc, database, clock
+}
+
+func createUser(t *testing.T, database *gorm.DB, username string) *usermodel.User {
+	t.Helper()
+	user := &usermodel.User{Username: username, Email: username + "@example.com", Password: "x"}
+	require.NoError(t, database.Create(user).Error)
+	return user
+}
+
+func registerPasskey(t *testing.T, svc *Service, user *usermodel.User, name string) (*softAuthenticator, *Credential) {
+	t.Helper()
+	authenticator := newSoftAuthenticator(t)
+	options, sessionID, err := svc.BeginRegistration(user)
+	require.NoError(t, err)
+	credential, err := svc.FinishRegistration(user, sessionID, name, authenticator.register(t, options.Response.Challenge.String()))
+	require.NoError(t, err)
+	return authenticator, credential
+}
+
+func TestNewService_DerivesRelyingPartyFromAppURL(t *testing.T) {
+	svc, database, _ := newTestService(t)
+	user := createUser(t, database, "alice")
+
+	options, _, err := svc.BeginRegistration(user)
+	require.NoError(t, err)
+	assert.Equal(t, "berth.example.com", options.Response.RelyingParty.ID)
+	assert.Equal(t, "Berth", options.Response.RelyingParty.Name)
+	assert.Equal(t, "alice", options.Response.User.Name)
+}
+
+func TestRegistration(t *testing.T) {
+	svc, database, _ := newTestService(t)
+	user := createUser(t, database, "alice")
+
+	authenticator, credential := registerPasskey(t, svc, user, "  YubiKey  ")
+	assert.Equal(t, "YubiKey", credential.Name)
+	assert.Equal(t, b64(authenticator.credentialID), credential.CredentialID)
+	assert.True(t, credential.UserVerified)
+	assert.True(t, svc.HasCredentials(user.ID))
+
+	options, sessionID, err := svc.BeginRegistration(user)
+	require.NoError(t, err)
+	require.Len(t, options.Response.CredentialExcludeList, 1, "existing credentials must be excluded")
+
+	_, err = svc.FinishRegistration(user, sessionID, "again", authenticator.register(t, options.Response.Challenge.String()))
+	assert.ErrorIs(t, err, ErrAlreadyRegistered)
+}
+
+func TestRegistration_RejectsWrongChallenge(t *testing.T) {
+	svc, database, _ := newTestService(t)
+	user := createUser(t, database, "alice")
+
+	_, sessionID, err := svc.BeginRegistration(user)
+	require.NoError(t, err)
+	_, err = svc.FinishRegistration(user, sessionID, "key", newSoftAuthenticator(t).register(t, b64([]byte("not-the-challenge-value"))))
+	assert.ErrorIs(t, err, ErrVerificationFailed)
+	assert.False(t, svc.HasCredentials(user.ID))
+}
+
+func TestCeremony_SingleUseBoundToUserAndExpiring(t *testing.T) {
+	svc, database, clock := newTestService(t)
+	alice := createUser(t, database, "alice")
+	bob := createUser(t, database, "bob")
+
+	options, sessionID, err := svc.BeginRegistration(alice)
+	require.NoError(t, err)
+	_, err = svc.FinishRegistration(bob, sessionID, "key", newSoftAuthenticator(t).register(t, options.Response.Challenge.String()))
+	assert.ErrorIs(t, err, ErrCeremonyInvalid, "a ceremony started by another user must be rejected")
+	_, err = svc.FinishRegistration(alice, sessionID, "key", newSoftAuthenticator(t).register(t, options.Response.Challenge.String()))
+	assert.ErrorIs(t, err, ErrCeremonyInvalid, "a ceremony must be single use")
+
+	options, sessionID, err = svc.BeginRegistration(alice)
+	require.NoError(t, err)
+	clock.now = clock.now.Add(2 * time.Minute)
+	_, err = svc.FinishRegistration(alice, sessionID, "key", newSoftAuthenticator(t).register(t, options.Response.Challenge.String()))
+	assert.ErrorIs(t, err, ErrCeremonyInvalid)
+}
+
+func TestSecondFactorLogin(t *testing.T) {
+	svc, database, _ := newTestService(t)
+	user := createUser(t, database, "alice")
+	authenticator, registered := registerPasskey(t, svc, user, "YubiKey")
+
+	options, sessionID, err := svc.BeginLogin(user)
+	require.NoError(t, err)
+	require.Len(t, options.Response.AllowedCredentials, 1)
+
+	credential, err := svc.FinishLogin(user, sessionID, authenticator.assert(t, options.Response.Challenge.String(), flagUserPresent))
+	require.NoError(t, err)
+	assert.Equal(t, registered.ID, credential.ID)
+	assert.Equal(t, uint32(1), credential.SignCount)
+	require.NotNil(t, credential.LastUsedAt)
+
+	_, err = svc.FinishLogin(user, sessionID, authenticator.assert(t, options.Response.Challenge.String(), flagUserPresent))
+	assert.ErrorIs(t, err, ErrCeremonyInvalid, "the challenge must not be replayable")
+}
+
+func TestSecondFactorLogin_RejectsOtherUsersCredential(t *testing.T) {
+	svc, database, _ := newTestService(t)
+	alice := createUser(t, database, "alice")
+	bob := createUser(t, database, "bob")
+	registerPasskey(t, svc, alice, "alice key")
+	bobKey, _ := registerPasskey(t, svc, bob, "bob key")
+
+	options, sessionID, err := svc.BeginLogin(alice)
+	require.NoError(t, err)
+	_, err = svc.FinishLogin(alice, sessionID, bobKey.assert(t, options.Response.Challenge.String(), flagUserPresent))
+	assert.ErrorIs(t, err, ErrVerificationFailed)
+}
+
+func TestSecondFactorLogin_RejectsClonedAuthenticator(t *testing.T) {
+	svc, database, _ := newTestService(t)
+	user := createUser(t, database, "alice")
+	authenticator, _ := registerPasskey(t, svc, user, "YubiKey")
+
+	authenticator.signCount = 10
+	options, sessionID, err := svc.BeginLogin(user)
+	require.NoError(t, err)
+	_, err = svc.FinishLogin(user, sessionID, authenticator.assert(t, options.Response.Challenge.String(), flagUserPresent))
+	require.NoError(t, err)
+
+	authenticator.signCount = 3
+	options, sessionID, err = svc.BeginLogin(user)
+	require.NoError(t, err)
+	_, err = svc.FinishLogin(user, sessionID, authenticator.assert(t, options.Response.Challenge.String(), flagUserPresent))
+	assert.ErrorIs(t, err, ErrVerificationFailed)
+}
+
+func TestBeginLogin_NoCredentials(t *testing.T) {
+	svc, database, _ := newTestService(t)
+	user := createUser(t, database, "alice")
+
+	_, _, err := svc.BeginLogin(user)
+	assert.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestPasswordlessLogin(t *testing.T) {
+	svc, database, _ := newTestService(t)
+	user := createUser(t, database, "alice")
+	authenticator, registered := registerPasskey(t, svc, user, "Phone")
+	authenticator.userHandle = userHandle(user.ID)
+
+	options, sessionID, err := svc.BeginPasswordlessLogin()
+	require.NoError(t, err)
+	assert.Empty(t, options.Response.AllowedCredentials)
+
+	resolved, credential, err := svc.FinishPasswordlessLogin(sessionID, authenticator.assert(t, options.Response.Challenge.String(), flagUserPresent|flagUserVerified))
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, resolved.ID)
+	assert.Equal(t, registered.ID, credential.ID)
+}
+
+func TestPasswordlessLogin_RequiresUserVerification(t *testing.T) {
+	svc, database, _ := newTestService(t)
+	user := createUser(t, database, "alice")
+	authenticator, _ := registerPasskey(t, svc, user, "Phone")
+	authenticator.userHandle = userHandle(user.ID)
+
+	options, sessionID, err := svc.BeginPasswordlessLogin()
+	require.NoError(t, err)
+	_, _, err = svc.FinishPasswordlessLogin(sessionID, authenticator.assert(t, options.Response.Challenge.String(), flagUserPresent))
+	assert.ErrorIs(t, err, ErrVerificationFailed)
+}
+
+func TestPasswordlessLogin_RejectsForeignUserHandle(t *testing.T) {
+	svc, database, _ := newTestService(t)
+	alice := createUser(t, database, "alice")
+	bob := createUser(t, database, "bob")
+	authenticator, _ := registerPasskey(t, svc, alice, "Phone")
+	authenticator.userHandle = userHandle(bob.ID)
+
+	options, sessionID, err := svc.BeginPasswordlessLogin()
+	require.NoError(t, err)
+	_, _, err = svc.FinishPasswordlessLogin(sessionID, authenticator.assert(t, options.Response.Challenge.String(), flagUserPresent|flagUserVerified))
+	assert.ErrorIs(t, err, ErrVerificationFailed)
+}
+
+func TestRenameAndDelete_ScopedToOwner(t *testing.T) {
+	svc, database, _ := newTestService(t)
+	alice := createUser(t, database, "alice")
+	bob := createUser(t, database, "bob")
+	_, credential := registerPasskey(t, svc, alice, "YubiKey")
+
+	_, err := svc.RenameCredential(bob.ID, credential.ID, "mine now")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+	_, err = svc.DeleteCredential(bob.ID, credential.ID)
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+
+	renamed, err := svc.RenameCredential(alice.ID, credential.ID, " Work key ")
+	require.NoError(t, err)
+	assert.Equal(t, "Work key", renamed.Name)
+
+	deleted, err := svc.DeleteCredential(alice.ID, credential.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Work key", deleted.Name)
+	assert.False(t, svc.HasCredentials(alice.ID))
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	usermodel "berth/internal/domain/user"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ChangePassword replaces the user's password after checking the current
+// one, and notifies the account's email address. Revoking other sessions is
+// left to the caller, which knows the current session.
+func (s *Service) ChangePassword(user *usermodel.User, currentPassword, newPassword string) error {
+	if err := s.VerifyPassword(user.Password, currentPassword); err != nil {
+		return err
+	}
+	if currentPassword == newPassword {
+		return ErrPasswordUnchanged
+	}
+
+	hashedPassword, err := s.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Model(&usermodel.User{}).Where("id = ?", user.ID).Update("password", hashedPassword).Error; err != nil {
+		return fmt.Errorf("update password: %w", err)
+	}
+
+	s.notify("password_changed", user.Email, "Your password was changed", map[string]any{
+		"Username": user.Username,
+		"Email":    user.Email,
+	})
+	return nil
+}
+
+// RequestEmailChange sends a verification link to newEmail. The address is
+// only switched once the link is followed, see ConfirmEmailChange.
+func (s *Service) RequestEmailChange(user *usermodel.User, newEmail string) error {
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrEmailUnchanged
+	}
+	if err := s.ensureEmailAvailable(s.db, user.ID, newEmail); err != nil {
+		return err
+	}
+	if s.mailService == nil {
+		return ErrMailServiceUnavailable
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&EmailVerificationToken{}).
+		Where("user_id = ? AND used = ? AND expires_at > ?", user.ID, false, now).
+		Update("used", true).Error; err != nil {
+		return fmt.Errorf("invalidate existing email change tokens: %w", err)
+	}
+
+	token, err := generateHexToken(s.config.Auth.EmailVerificationTokenLength)
+	if err != nil {
+		return err
+	}
+	row := &EmailVerificationToken{
+		Email:     newEmail,
+		UserID:    &user.ID,
+		Token:     token,
+		ExpiresAt: now.Add(s.config.Auth.EmailVerificationExpiry),
+	}
+	if err := s.db.Create(row).Error; err != nil {
+		return fmt.Errorf("create email change token: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/auth/confirm-email-change?token=%s", s.config.App.URL, token)
+	if err := s.mailService.SendTemplate("email_change_verification", []string{newEmail}, "Confirm your new email address", map[string]any{
+		"Username":        user.Username,
+		"Email":           newEmail,
+		"VerificationURL": url,
+		"ExpiryDuration":  s.config.Auth.EmailVerificationExpiry.String(),
+		"AppName":         s.config.App.Name,
+	}); err != nil {
+		s.logger.Error("send email change verification failed", zap.Uint("user_id", user.ID), zap.Error(err))
+		return fmt.Errorf("failed to send email change verification email: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange switches the user's email to the address the token was
+// sent to, marks it verified and notifies the previous address.
+func (s *Service) ConfirmEmailChange(token string) (*usermodel.User, string, error) {
+	var user usermodel.User
+	var oldEmail string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var row EmailVerificationToken
+		if err := tx.Where("token = ? AND user_id IS NOT NULL", token).First(&row).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmailChangeTokenInvalid
+			}
+			return fmt.Errorf("find email change token: %w", err)
+		}
+		if row.Used || time.Now().After(row.ExpiresAt) {
+			return ErrEmailChangeTokenInvalid
+		}
+		if err := tx.First(&user, *row.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmailChangeTokenInvalid
+			}
+			return fmt.Errorf("load user: %w", err)
+		}
+		if err := s.ensureEmailAvailable(tx, user.ID, row.Email); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		oldEmail = user.Email
+		if err := tx.Model(&row).Updates(map[string]any{"used": true, "used_at": now}).Error; err != nil {
+			return fmt.Errorf("mark email change token used: %w", err)
+		}
+		if err := tx.Model(&usermodel.User{}).Where("id = ?", user.ID).
+			Updates(map[string]any{"email": row.Email, "email_verified_at": now}).Error; err != nil {
+			return fmt.Errorf("update email: %w", err)
+		}
+		user.Email = row.Email
+		user.EmailVerifiedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.notify("email_changed", oldEmail, "Your email address was changed", map[string]any{
+		"Username": user.Username,
+		"Email":    oldEmail,
+		"NewEmail": user.Email,
+	})
+	return &user, oldEmail, nil
+}
+
+func (s *Service) ensureEmailAvailable(db *gorm.DB, userID uint, email string) error {
+	var count int64
+	if err := db.Model(&usermodel.User{}).Where("LOWER(email) = ? AND id <> ?", strings.ToLower(email), userID).Count(&count).Error; err != nil {
+		return fmt.Errorf("check email availability: %w", err)
+	}
+	if count > 0 {
+		return ErrEmailInUse
+	}
+	return nil
+}
+
+// notify sends a security notice; failures are logged rather than returned
+// because the change it reports has already been applied.
+func (s *Service) notify(templateName, email, subject string, data map[string]any) {
+	if s.mailService == nil {
+		return
+	}
+	data["AppName"] = s.config.App.Name
+	if err := s.mailService.SendTemplate(templateName, []string{email}, subject, data); err != nil {
+		s.logger.Warn("send security notice failed",
+			zap.String("template", templateName),
+			zap.String("email", email),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,313 @@
+// Package proxyauth authenticates API requests by identity headers set by an
+// authenticating reverse proxy. The headers are only believed on connections
+// from SERVER_TRUSTED_PROXIES; anyone else sending them is rejected.
+package proxyauth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"berth/internal/domain/auth/federation"
+	"berth/internal/domain/security"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUntrustedSource  = errors.New("identity headers received from an untrusted source")
+	ErrUsernameMissing  = errors.New("proxy did not supply a username")
+	ErrAccountNotFound  = errors.New("no account matches the proxy identity")
+	ErrEmailMissing     = errors.New("proxy did not supply an email address")
+	ErrNoTrustedProxies = errors.New("proxy authentication requires at least one valid trusted proxy")
+)
+
+type proxyAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+// twoFactorPolicy reports whether a user must enroll a second factor required
+// by one of their roles.
+type twoFactorPolicy interface {
+	NeedsTwoFactorEnrollment(userID uint) (bool, error)
+}
+
+// Request carries what the middleware knows about the caller.
+type Request struct {
+	Header     http.Header
+	RemoteAddr string
+	IP         string
+	UserAgent  string
+}
+
+type Service struct {
+	cfg       config.ProxyAuthConfig
+	db        *gorm.DB
+	trusted   []*net.IPNet
+	roles     federation.RoleSyncer
+	audit     proxyAuditLogger
+	twoFactor twoFactorPolicy
+	logger    *zap.Logger
+	now       func() time.Time
+}
+
+func NewService(cfg *config.Config, db *gorm.DB, roles federation.RoleManager, audit proxyAuditLogger, logger *zap.Logger) (*Service, error) {
+	mappings, err := federation.ParseRoleMapping(cfg.ProxyAuth.RoleMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	trusted := parseTrustedProxies(cfg.Server.TrustedProxies)
+	if len(trusted) == 0 {
+		return nil, ErrNoTrustedProxies
+	}
+
+	return &Service{
+		cfg:     cfg.ProxyAuth,
+		db:      db,
+		trusted: trusted,
+		roles: federation.RoleSyncer{
+			DB:       db,
+			Roles:    roles,
+			Mappings: mappings,
+			Logger:   logger,
+			Source:   "proxy",
+		},
+		audit:  audit,
+		logger: logger,
+		now:    time.Now,
+	}, nil
+}
+
+// SetTwoFactorPolicy makes proxy identities subject to role-based mandatory
+// two-factor enrollment.
+func (s *Service) SetTwoFactorPolicy(p twoFactorPolicy) {
+	s.twoFactor = p
+}
+
+// RequiresEnrollment reports whether the user must enroll a second factor
+// before being given more than enrollment access.
+func (s *Service) RequiresEnrollment(userID uint) (bool, error) {
+	if s.twoFactor == nil {
+		return false, nil
+	}
+	return s.twoFactor.NeedsTwoFactorEnrollment(userID)
+}
+
+// Present reports whether the request carries any of the identity headers.
+func (s *Service) Present(h http.Header) bool {
+	for _, name := range s.headerNames() {
+		if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSource rejects and audits identity headers that did not come from a
+// trusted proxy. Only the TCP peer is considered: X-Forwarded-For is as easy
+// to forge as the identity headers themselves.
+func (s *Service) CheckSource(req Request) error {
+	if s.isTrusted(req.RemoteAddr) {
+		return nil
+	}
+
+	s.logger.Warn("rejected proxy identity headers from untrusted source",
+		zap.String("remote_addr", req.RemoteAddr),
+		zap.String("claimed_user", req.Header.Get(s.cfg.UserHeader)),
+	)
+	_ = s.audit.Log(security.LogEvent{
+		EventType:      security.EventAuthProxyHeaderRejected,
+		Success:        false,
+		FailureReason:  ErrUntrustedSource.Error(),
+		ActorUsername:  req.Header.Get(s.cfg.UserHeader),
+		ActorIP:        req.IP,
+		ActorUserAgent: req.UserAgent,
+		Metadata: map[string]any{
+			"remote_addr": req.RemoteAddr,
+			"headers":     s.presentHeaders(req.Header),
+		},
+	})
+	return ErrUntrustedSource
+}
+
+// Authenticate resolves the account named by the identity headers,
+// provisioning it if allowed, and brings its mapped roles in line with the
+// groups header. The caller must have checked the source first.
+func (s *Service) Authenticate(req Request) (*usermodel.User, error) {
+	username := strings.TrimSpace(req.Header.Get(s.cfg.UserHeader))
+	if username == "" {
+		return nil, ErrUsernameMissing
+	}
+
+	var user usermodel.User
+	err := usermodel.FirstWithActiveRoles(s.db.Where("username = ?", username), &user)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if !s.cfg.AutoProvision {
+			return nil, ErrAccountNotFound
+		}
+		provisioned, err := s.provision(username, req)
+		if err != nil {
+			return nil, err
+		}
+		user = *provisioned
+	case err != nil:
+		return nil, err
+	}
+
+	if s.cfg.GroupsHeader == "" {
+		return &user, nil
+	}
+
+	assigned, revoked := s.roles.Sync(&user, s.groups(req.Header))
+	for _, role := range assigned {
+		s.auditUser(req, &user, security.EventUserRoleAssigned, map[string]any{"role_name": role})
+	}
+	for _, role := range revoked {
+		s.auditUser(req, &user, security.EventUserRoleRevoked, map[string]any{"role_name": role})
+	}
+	if len(assigned) > 0 || len(revoked) > 0 {
+		var refreshed usermodel.User
+		if err := usermodel.FirstWithActiveRoles(s.db, &refreshed, user.ID); err != nil {
+			return nil, err
+		}
+		user = refreshed
+	}
+
+	return &user, nil
+}
+
+func (s *Service) provision(username string, req Request) (*usermodel.User, error) {
+	email := ""
+	if s.cfg.EmailHeader != "" {
+		email = strings.ToLower(strings.TrimSpace(req.Header.Get(s.cfg.EmailHeader)))
+	}
+	if email == "" {
+		return nil, ErrEmailMissing
+	}
+
+	hashed, err := federation.PlaceholderPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	user := usermodel.User{
+		Username:        username,
+		Email:           email,
+		Password:        hashed,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.db.Create(&user).Error; err != nil {
+		return nil, fmt.Errorf("provision user: %w", err)
+	}
+
+	s.logger.Info("provisioned user from proxy identity",
+		zap.Uint("user_id", user.ID),
+		zap.String("username", user.Username),
+	)
+	s.auditUser(req, &user, security.EventUserCreated, map[string]any{"username": user.Username})
+	return &user, nil
+}
+
+// auditUser records a change made to the account on the user's behalf. The
+// user is both actor and target, as for other external identity sources.
+func (s *Service) auditUser(req Request, user *usermodel.User, eventType string, metadata map[string]any) {
+	metadata["source"] = "proxy"
+	_ = s.audit.Log(security.LogEvent{
+		EventType:      eventType,
+		Success:        true,
+		ActorUserID:    &user.ID,
+		ActorUsername:  user.Username,
+		ActorIP:        req.IP,
+		ActorUserAgent: req.UserAgent,
+		TargetUserID:   &user.ID,
+		TargetType:     security.TargetTypeUser,
+		TargetID:       &user.ID,
+		TargetName:     user.Email,
+		Metadata:       metadata,
+	})
+}
+
+func (s *Service) groups(h http.Header) []string {
+	var groups []string
+	for _, value := range h.Values(s.cfg.GroupsHeader) {
+		for _, group := range strings.Split(value, s.cfg.GroupSeparator) {
+			if group = strings.TrimSpace(group); group != "" {
+				groups = append(groups, group)
+			}
+		}
+	}
+	return groups
+}
+
+func (s *Service) headerNames() []string {
+	names := []string{s.cfg.UserHeader}
+	for _, name := range []string{s.cfg.EmailHeader, s.cfg.GroupsHeader} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (s *Service) presentHeaders(h http.Header) []string {
+	var present []string
+	for _, name := range s.headerNames() {
+		if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+			present = append(present, http.CanonicalHeaderKey(name))
+		}
+	}
+	return present
+}
+
+func (s *Service) isTrusted(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies accepts the same CIDR or bare address entries as the
+// HTTP server's IP extractor; invalid entries are ignored there too.
+func parseTrustedProxies(entries []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			networks = append(networks, network)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			continue
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 32
+		}
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return networks
+}
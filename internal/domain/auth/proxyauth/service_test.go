@@ -0,0 +1,184 @@
+package proxyauth
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"berth/internal/domain/security"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+	"berth/internal/platform/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type dbRoleManager struct {
+	db *gorm.DB
+}
+
+func (r dbRoleManager) AssignRole(userID, roleID uint) error {
+	return r.db.Model(&usermodel.User{BaseModel: db.BaseModel{ID: userID}}).Association("Roles").Append(&usermodel.Role{BaseModel: db.BaseModel{ID: roleID}})
+}
+
+func (r dbRoleManager) RevokeRole(userID, roleID uint) error {
+	return r.db.Model(&usermodel.User{BaseModel: db.BaseModel{ID: userID}}).Association("Roles").Delete(&usermodel.Role{BaseModel: db.BaseModel{ID: roleID}})
+}
+
+type recordingAudit struct {
+	events []security.LogEvent
+}
+
+func (r *recordingAudit) Log(event security.LogEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordingAudit) types() []string {
+	types := make([]string, len(r.events))
+	for i, e := range r.events {
+		types[i] = e.EventType
+	}
+	return types
+}
+
+func newTestService(t *testing.T, modify func(*config.ProxyAuthConfig)) (*Service, *gorm.DB, *recordingAudit) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:proxyauth_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&usermodel.User{}, &usermodel.Role{}, &usermodel.UserRole{}))
+	require.NoError(t, database.Create(&usermodel.Role{Name: "admin", IsAdmin: true}).Error)
+	require.NoError(t, database.Create(&usermodel.Role{Name: "developer"}).Error)
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{TrustedProxies: []string{"10.0.0.0/8", "192.0.2.10"}},
+		ProxyAuth: config.ProxyAuthConfig{
+			Enabled:        true,
+			UserHeader:     "Remote-User",
+			EmailHeader:    "Remote-Email",
+			GroupsHeader:   "Remote-Groups",
+			GroupSeparator: ",",
+			RoleMapping:    "berth-admins=admin;devs=developer",
+			AutoProvision:  true,
+		},
+	}
+	if modify != nil {
+		modify(&cfg.ProxyAuth)
+	}
+
+	audit := &recordingAudit{}
+	svc, err := NewService(cfg, database, dbRoleManager{db: database}, audit, zap.NewNop())
+	require.NoError(t, err)
+	return svc, database, audit
+}
+
+func proxyReq(remoteAddr string, headers map[string]string) Request {
+	h := http.Header{}
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+	return Request{Header: h, RemoteAddr: remoteAddr, IP: "203.0.113.7", UserAgent: "test"}
+}
+
+func roleNames(user *usermodel.User) []string {
+	names := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+func TestNewService_RequiresTrustedProxy(t *testing.T) {
+	cfg := &config.Config{
+		Server:    config.ServerConfig{TrustedProxies: []string{"not-an-ip"}},
+		ProxyAuth: config.ProxyAuthConfig{Enabled: true, UserHeader: "Remote-User"},
+	}
+	_, err := NewService(cfg, nil, nil, &recordingAudit{}, zap.NewNop())
+	assert.ErrorIs(t, err, ErrNoTrustedProxies)
+}
+
+func TestCheckSource(t *testing.T) {
+	svc, _, audit := newTestService(t, nil)
+
+	assert.NoError(t, svc.CheckSource(proxyReq("10.1.2.3:5000", map[string]string{"Remote-User": "alice"})))
+	assert.NoError(t, svc.CheckSource(proxyReq("192.0.2.10:5000", map[string]string{"Remote-User": "alice"})))
+	assert.Empty(t, audit.events)
+
+	err := svc.CheckSource(proxyReq("198.51.100.1:5000", map[string]string{"Remote-User": "alice", "Remote-Groups": "berth-admins"}))
+	assert.ErrorIs(t, err, ErrUntrustedSource)
+	require.Len(t, audit.events, 1)
+	event := audit.events[0]
+	assert.Equal(t, security.EventAuthProxyHeaderRejected, event.EventType)
+	assert.False(t, event.Success)
+	assert.Equal(t, "alice", event.ActorUsername)
+	assert.Equal(t, []string{"Remote-User", "Remote-Groups"}, event.Metadata["headers"])
+}
+
+func TestPresent(t *testing.T) {
+	svc, _, _ := newTestService(t, nil)
+
+	assert.False(t, svc.Present(http.Header{"Authorization": {"Bearer x"}}))
+	assert.True(t, svc.Present(http.Header{"Remote-Groups": {""}}), "an empty header still counts as an attempt")
+}
+
+func TestAuthenticate_ProvisionsAndMapsGroups(t *testing.T) {
+	svc, _, audit := newTestService(t, nil)
+
+	user, err := svc.Authenticate(proxyReq("10.0.0.1:1", map[string]string{
+		"Remote-User":   "alice",
+		"Remote-Email":  "Alice@Example.com",
+		"Remote-Groups": "devs, other",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+	assert.Equal(t, "alice@example.com", user.Email)
+	assert.NotNil(t, user.EmailVerifiedAt)
+	assert.Equal(t, []string{"developer"}, roleNames(user))
+	assert.Equal(t, []string{security.EventUserCreated, security.EventUserRoleAssigned}, audit.types())
+
+	user, err = svc.Authenticate(proxyReq("10.0.0.1:1", map[string]string{
+		"Remote-User":   "alice",
+		"Remote-Groups": "berth-admins",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, roleNames(user))
+}
+
+func TestAuthenticate_ExistingAccountFollowsGroups(t *testing.T) {
+	svc, database, _ := newTestService(t, func(c *config.ProxyAuthConfig) { c.AutoProvision = false })
+
+	var developer usermodel.Role
+	require.NoError(t, database.Where("name = ?", "developer").First(&developer).Error)
+	bob := usermodel.User{Username: "bob", Email: "bob@example.com", Password: "x", Roles: []usermodel.Role{developer}}
+	require.NoError(t, database.Create(&bob).Error)
+
+	_, err := svc.Authenticate(proxyReq("10.0.0.1:1", map[string]string{"Remote-User": "carol", "Remote-Email": "carol@example.com"}))
+	assert.ErrorIs(t, err, ErrAccountNotFound)
+
+	user, err := svc.Authenticate(proxyReq("10.0.0.1:1", map[string]string{"Remote-User": "bob", "Remote-Groups": "devs"}))
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, user.ID)
+	assert.Equal(t, []string{"developer"}, roleNames(user))
+
+	user, err = svc.Authenticate(proxyReq("10.0.0.1:1", map[string]string{"Remote-User": "bob"}))
+	require.NoError(t, err)
+	assert.Empty(t, roleNames(user), "mapped roles follow the groups header")
+}
+
+func TestAuthenticate_RequiresUsernameAndEmail(t *testing.T) {
+	svc, _, _ := newTestService(t, nil)
+
+	_, err := svc.Authenticate(proxyReq("10.0.0.1:1", map[string]string{"Remote-Groups": "devs"}))
+	assert.ErrorIs(t, err, ErrUsernameMissing)
+
+	_, err = svc.Authenticate(proxyReq("10.0.0.1:1", map[string]string{"Remote-User": "dave"}))
+	assert.ErrorIs(t, err, ErrEmailMissing)
+}
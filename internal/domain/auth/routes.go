@@ -11,17 +11,37 @@ func (h *APIHandler) RegisterPublicAPIRoutes(reg *authz.Registrar) {
 	reg.POST("/password-reset/confirm", h.ConfirmPasswordResetAPI, pub)
 	reg.POST("/verify-email", h.VerifyEmailAPI, pub)
 	reg.POST("/resend-verification", h.ResendVerificationAPI, pub)
+	reg.POST("/email-change/confirm", h.ConfirmEmailChangeAPI, pub)
+	reg.GET("/methods", h.LoginMethods, pub)
+	reg.GET("/oidc/login", h.OIDCLogin, pub)
+	reg.GET("/oidc/callback", h.OIDCCallback, pub)
+	reg.POST("/passkey/login/begin", h.BeginPasskeyLogin, pub)
+	reg.POST("/passkey/login/finish", h.FinishPasskeyLogin, pub)
+	reg.POST("/passkey/verify/begin", h.BeginPasskeyVerification, pub)
+	reg.POST("/passkey/verify/finish", h.FinishPasskeyVerification, pub)
 }
 
 func (h *APIHandler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
 	denied := authz.APIKeyDenied()
-	reg.GET("/profile", h.Profile, authz.Authenticated())
-	reg.POST("/auth/logout", h.Logout, denied)
+	// enroll is reachable with the restricted token issued when a role
+	// requires a second factor the user has not set up yet.
+	enroll := authz.APIKeyDenied().AllowEnrollment()
+	reg.GET("/profile", h.Profile, authz.Authenticated().AllowEnrollment())
+	reg.POST("/auth/logout", h.Logout, enroll)
+	reg.POST("/profile/password", h.ChangePassword, denied)
+	reg.POST("/profile/email", h.ChangeEmail, denied)
 
-	reg.GET("/totp/setup", h.GetTOTPSetup, denied)
-	reg.POST("/totp/enable", h.EnableTOTP, denied)
+	reg.GET("/totp/setup", h.GetTOTPSetup, enroll)
+	reg.POST("/totp/enable", h.EnableTOTP, enroll)
 	reg.POST("/totp/disable", h.DisableTOTP, denied)
-	reg.GET("/totp/status", h.GetTOTPStatus, denied)
+	reg.GET("/totp/status", h.GetTOTPStatus, enroll)
+	reg.POST("/totp/recovery-codes", h.RegenerateRecoveryCodes, denied)
+
+	reg.GET("/passkeys", h.ListPasskeys, enroll)
+	reg.POST("/passkeys/register/begin", h.BeginPasskeyRegistration, enroll)
+	reg.POST("/passkeys/register/finish", h.FinishPasskeyRegistration, enroll)
+	reg.PUT("/passkeys/:id", h.RenamePasskey, denied)
+	reg.DELETE("/passkeys/:id", h.DeletePasskey, denied)
 
 	reg.GET("/sessions", h.GetSessions, denied)
 	reg.POST("/sessions/revoke", h.RevokeSession, denied)
@@ -27,6 +27,11 @@ var (
 	ErrEmailVerificationTokenExpired = errors.New("email verification token has expired")
 	ErrEmailVerificationTokenUsed    = errors.New("email verification token has already been used")
 
+	ErrEmailChangeTokenInvalid = errors.New("invalid or expired email change token")
+	ErrEmailUnchanged          = errors.New("new email matches the current email")
+	ErrEmailInUse              = errors.New("email address is already in use")
+	ErrPasswordUnchanged       = errors.New("new password must differ from the current password")
+
 	ErrWeakPassword           = errors.New("password does not meet requirements")
 	ErrMailServiceUnavailable = errors.New("mail service is not configured")
 )
@@ -67,3 +72,9 @@ func generateHexToken(length int) (string, error) {
 	}
 	return hex.EncodeToString(b), nil
 }
+
+// IsLocalLoginEnabled reports whether users may sign in with a Berth
+// username and password.
+func (s *Service) IsLocalLoginEnabled() bool {
+	return !s.config.Auth.LocalLoginDisabled
+}
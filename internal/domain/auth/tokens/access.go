@@ -10,24 +10,43 @@ import (
 	"go.uber.org/zap"
 )
 
-const totpPendingTTL = 10 * time.Minute
+const (
+	totpPendingTTL = 10 * time.Minute
+	enrollmentTTL  = 15 * time.Minute
+
+	tokenTypeEnrollment = "2fa_enrollment"
+)
 
 func (s *Service) IssueAccessToken(userID uint) (string, error) {
-	return s.signToken(userID, "", s.cfg.JWT.AccessExpiry)
+	return s.signToken(userID, "", "", s.cfg.JWT.AccessExpiry)
 }
 
-func (s *Service) IssueTOTPPendingToken(userID uint) (string, error) {
-	return s.signToken(userID, "totp_pending", totpPendingTTL)
+// IssueTOTPPendingToken issues the short-lived token exchanged at
+// /auth/totp/verify. authMethod names the backend that checked the password.
+func (s *Service) IssueTOTPPendingToken(userID uint, authMethod string) (string, error) {
+	return s.signToken(userID, "totp_pending", authMethod, totpPendingTTL)
 }
 
-func (s *Service) signToken(userID uint, tokenType string, ttl time.Duration) (string, error) {
+// IssueEnrollmentToken issues a restricted access token for a user whose role
+// requires a second factor they have not enrolled yet. It is only accepted on
+// routes that allow enrollment.
+func (s *Service) IssueEnrollmentToken(userID uint) (string, error) {
+	return s.signToken(userID, tokenTypeEnrollment, "", enrollmentTTL)
+}
+
+func (s *Service) GetEnrollmentExpirySeconds() int {
+	return int(enrollmentTTL.Seconds())
+}
+
+func (s *Service) signToken(userID uint, tokenType, authMethod string, ttl time.Duration) (string, error) {
 	now := time.Now()
 	jti := uuid.New().String()
 
 	claims := Claims{
-		UserID:    userID,
-		TokenType: tokenType,
-		JTI:       jti,
+		UserID:     userID,
+		TokenType:  tokenType,
+		AuthMethod: authMethod,
+		JTI:        jti,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        jti,
 			Issuer:    s.cfg.JWT.Issuer,
@@ -39,8 +58,16 @@ func (s *Service) signToken(userID uint, tokenType string, ttl time.Duration) (s
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString([]byte(s.cfg.JWT.SecretKey))
+	key, kid, err := s.signingKey()
+	if err != nil {
+		s.logger.Error("sign JWT failed", zap.Error(err), zap.Uint("user_id", userID))
+		return "", fmt.Errorf("sign JWT: %w", err)
+	}
+	token := jwt.NewWithClaims(s.signingMethod(), claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
 	if err != nil {
 		s.logger.Error("sign JWT failed", zap.Error(err), zap.Uint("user_id", userID))
 		return "", fmt.Errorf("sign JWT: %w", err)
@@ -59,6 +86,22 @@ func (s *Service) ValidateAccess(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// ValidateAccessOrEnrollment accepts full access tokens and enrollment tokens,
+// reporting whether the token was an enrollment token.
+func (s *Service) ValidateAccessOrEnrollment(tokenString string) (*Claims, bool, error) {
+	claims, err := s.parseToken(tokenString)
+	if err != nil {
+		return nil, false, err
+	}
+	switch claims.TokenType {
+	case "":
+		return claims, false, nil
+	case tokenTypeEnrollment:
+		return claims, true, nil
+	}
+	return nil, false, ErrInvalidTokenType
+}
+
 func (s *Service) ValidateTOTPPending(tokenString string) (*Claims, error) {
 	claims, err := s.parseToken(tokenString)
 	if err != nil {
@@ -71,12 +114,8 @@ func (s *Service) ValidateTOTPPending(tokenString string) (*Claims, error) {
 }
 
 func (s *Service) parseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok || t.Method.Alg() != "HS256" {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return []byte(s.cfg.JWT.SecretKey), nil
-	},
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.verificationKey,
+		jwt.WithValidMethods([]string{s.signingMethod().Alg()}),
 		jwt.WithIssuer(s.cfg.JWT.Issuer),
 		jwt.WithAudience(s.cfg.JWT.Issuer),
 	)
@@ -0,0 +1,428 @@
+package tokens
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	rsaKeyBits = 2048
+
+	// keyCheckInterval is how often the key set is reloaded from the
+	// database and the signing key rotated when due.
+	keyCheckInterval = time.Minute
+	// keyReloadCooldown limits reloads triggered by tokens with an unknown
+	// kid, which another instance may have just started signing with.
+	keyReloadCooldown = 10 * time.Second
+)
+
+var ErrUnknownKey = errors.New("JWT signed with an unknown key")
+
+// SigningKey is a key pair used to sign access tokens when JWT_ALGORITHM is
+// asymmetric. The newest unretired key signs; retired keys keep verifying
+// until every token they signed has expired. PrivateKey is encrypted.
+type SigningKey struct {
+	ID         uint       `gorm:"primaryKey"`
+	KID        string     `gorm:"column:kid;uniqueIndex;size:64;not null"`
+	Algorithm  string     `gorm:"size:16;not null"`
+	PrivateKey string     `gorm:"type:text;not null"`
+	PublicKey  string     `gorm:"type:text;not null"`
+	CreatedAt  time.Time  `gorm:"not null"`
+	RetiredAt  *time.Time `gorm:"index"`
+}
+
+func (SigningKey) TableName() string {
+	return "jwt_signing_keys"
+}
+
+type keyEncrypter interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+type loadedKey struct {
+	kid       string
+	private   crypto.Signer
+	public    crypto.PublicKey
+	createdAt time.Time
+	retiredAt *time.Time
+}
+
+// JWK is a public key in RFC 7517 form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (s *Service) asymmetric() bool {
+	return s.cfg.JWT.Algorithm == AlgorithmRS256 || s.cfg.JWT.Algorithm == AlgorithmEdDSA
+}
+
+// maxTokenTTL is the longest lifetime of any token this service signs, and so
+// how long a retired key must keep verifying.
+func (s *Service) maxTokenTTL() time.Duration {
+	return max(s.cfg.JWT.AccessExpiry, totpPendingTTL, enrollmentTTL)
+}
+
+func (s *Service) signingMethod() jwt.SigningMethod {
+	switch s.cfg.JWT.Algorithm {
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+// signingKey returns the key new tokens are signed with and its kid, which is
+// empty for HS256.
+func (s *Service) signingKey() (any, string, error) {
+	if !s.asymmetric() {
+		return []byte(s.cfg.JWT.SecretKey), "", nil
+	}
+
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+	if s.signer == nil {
+		return nil, "", errors.New("no JWT signing key available")
+	}
+	return s.signer.private, s.signer.kid, nil
+}
+
+func (s *Service) verificationKey(t *jwt.Token) (any, error) {
+	if !s.asymmetric() {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok || t.Method.Alg() != AlgorithmHS256 {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return []byte(s.cfg.JWT.SecretKey), nil
+	}
+
+	if t.Method.Alg() != s.cfg.JWT.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownKey
+	}
+
+	if key := s.lookupKey(kid); key != nil {
+		return key.public, nil
+	}
+	if s.reloadKeysForUnknownKID() {
+		if key := s.lookupKey(kid); key != nil {
+			return key.public, nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+func (s *Service) lookupKey(kid string) *loadedKey {
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+	key := s.verifiers[kid]
+	if key == nil || !s.verifiable(key, time.Now()) {
+		return nil
+	}
+	return key
+}
+
+func (s *Service) verifiable(key *loadedKey, now time.Time) bool {
+	return key.retiredAt == nil || now.Before(key.retiredAt.Add(s.maxTokenTTL()))
+}
+
+// JWKS returns the public keys that currently verify tokens. It is empty for
+// HS256, whose secret cannot be published.
+func (s *Service) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	if !s.asymmetric() {
+		return set
+	}
+
+	now := time.Now()
+	s.keyMu.RLock()
+	keys := make([]*loadedKey, 0, len(s.verifiers))
+	for _, key := range s.verifiers {
+		if s.verifiable(key, now) {
+			keys = append(keys, key)
+		}
+	}
+	s.keyMu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].createdAt.After(keys[j].createdAt) })
+	for _, key := range keys {
+		if jwk, ok := s.publicJWK(key); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+func (s *Service) publicJWK(key *loadedKey) (JWK, bool) {
+	jwk := JWK{Use: "sig", Alg: s.cfg.JWT.Algorithm, Kid: key.kid}
+	switch pub := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	default:
+		return JWK{}, false
+	}
+	return jwk, true
+}
+
+// initKeys loads the stored keys and creates a signing key if there is none
+// or the current one is due for rotation.
+func (s *Service) initKeys() error {
+	if s.db != nil {
+		if err := s.db.AutoMigrate(&SigningKey{}); err != nil {
+			return fmt.Errorf("migrate signing keys: %w", err)
+		}
+	}
+	return s.checkKeys()
+}
+
+// checkKeys reloads the key set, rotates the signing key when it is older
+// than JWT_KEY_ROTATION_INTERVAL and drops keys that no longer verify
+// anything.
+func (s *Service) checkKeys() error {
+	if err := s.reloadKeys(); err != nil {
+		return err
+	}
+
+	// Any instance creates the first key, but only the leader rotates so
+	// that replicas do not each replace the key on the same tick.
+	s.keyMu.RLock()
+	due := s.signer == nil || (s.leads() && time.Since(s.signer.createdAt) >= s.cfg.JWT.KeyRotationInterval)
+	s.keyMu.RUnlock()
+	if due {
+		if err := s.rotateKey(); err != nil {
+			return err
+		}
+	}
+
+	s.pruneKeys()
+	return nil
+}
+
+// RotateSigningKey retires the current signing key and starts signing with a
+// new one. Tokens signed with the retired key remain valid until they expire.
+func (s *Service) RotateSigningKey() error {
+	if !s.asymmetric() {
+		return errors.New("key rotation requires an asymmetric JWT algorithm")
+	}
+	return s.rotateKey()
+}
+
+func (s *Service) rotateKey() error {
+	private, err := s.generateKey()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	key := &loadedKey{
+		kid:       uuid.New().String(),
+		private:   private,
+		public:    private.Public(),
+		createdAt: now,
+	}
+
+	if s.db != nil {
+		row, err := s.encodeKey(key)
+		if err != nil {
+			return err
+		}
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&SigningKey{}).Where("retired_at IS NULL").Update("retired_at", now).Error; err != nil {
+				return err
+			}
+			return tx.Create(row).Error
+		})
+		if err != nil {
+			return fmt.Errorf("store signing key: %w", err)
+		}
+	}
+
+	s.keyMu.Lock()
+	for _, existing := range s.verifiers {
+		if existing.retiredAt == nil {
+			existing.retiredAt = &now
+		}
+	}
+	s.verifiers[key.kid] = key
+	s.signer = key
+	s.keyMu.Unlock()
+
+	s.logger.Info("rotated JWT signing key",
+		zap.String("kid", key.kid),
+		zap.String("algorithm", s.cfg.JWT.Algorithm))
+	return nil
+}
+
+func (s *Service) generateKey() (crypto.Signer, error) {
+	switch s.cfg.JWT.Algorithm {
+	case AlgorithmRS256:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("generate RSA key: %w", err)
+		}
+		return key, nil
+	case AlgorithmEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("generate Ed25519 key: %w", err)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported JWT algorithm %q", s.cfg.JWT.Algorithm)
+}
+
+func (s *Service) reloadKeys() error {
+	if s.db == nil {
+		return nil
+	}
+
+	var rows []SigningKey
+	if err := s.db.Where("algorithm = ?", s.cfg.JWT.Algorithm).Order("created_at ASC").Find(&rows).Error; err != nil {
+		return fmt.Errorf("load signing keys: %w", err)
+	}
+
+	verifiers := make(map[string]*loadedKey, len(rows))
+	var signer *loadedKey
+	for i := range rows {
+		key, err := s.decodeKey(&rows[i])
+		if err != nil {
+			s.logger.Error("skipping unreadable JWT signing key", zap.String("kid", rows[i].KID), zap.Error(err))
+			continue
+		}
+		verifiers[key.kid] = key
+		if key.retiredAt == nil {
+			signer = key
+		}
+	}
+
+	s.keyMu.Lock()
+	s.verifiers = verifiers
+	s.signer = signer
+	s.lastKeyReload = time.Now()
+	s.keyMu.Unlock()
+	return nil
+}
+
+// reloadKeysForUnknownKID reloads the key set unless that was done very
+// recently, so a flood of forged kids cannot hammer the database.
+func (s *Service) reloadKeysForUnknownKID() bool {
+	if s.db == nil {
+		return false
+	}
+	s.keyMu.RLock()
+	recent := time.Since(s.lastKeyReload) < keyReloadCooldown
+	s.keyMu.RUnlock()
+	if recent {
+		return false
+	}
+	if err := s.reloadKeys(); err != nil {
+		s.logger.Error("reload JWT signing keys failed", zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (s *Service) pruneKeys() {
+	cutoff := time.Now().Add(-s.maxTokenTTL())
+
+	s.keyMu.Lock()
+	for kid, key := range s.verifiers {
+		if key.retiredAt != nil && key.retiredAt.Before(cutoff) {
+			delete(s.verifiers, kid)
+		}
+	}
+	s.keyMu.Unlock()
+
+	if s.db != nil && s.leads() {
+		if err := s.db.Where("retired_at IS NOT NULL AND retired_at < ?", cutoff).Delete(&SigningKey{}).Error; err != nil {
+			s.logger.Error("prune JWT signing keys failed", zap.Error(err))
+		}
+	}
+}
+
+func (s *Service) encodeKey(key *loadedKey) (*SigningKey, error) {
+	privateDER, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return nil, fmt.Errorf("encode private key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(key.public)
+	if err != nil {
+		return nil, fmt.Errorf("encode public key: %w", err)
+	}
+	encrypted, err := s.enc.Encrypt(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})))
+	if err != nil {
+		return nil, fmt.Errorf("encrypt private key: %w", err)
+	}
+	return &SigningKey{
+		KID:        key.kid,
+		Algorithm:  s.cfg.JWT.Algorithm,
+		PrivateKey: encrypted,
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		CreatedAt:  key.createdAt,
+	}, nil
+}
+
+func (s *Service) decodeKey(row *SigningKey) (*loadedKey, error) {
+	decrypted, err := s.enc.Decrypt(row.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt private key: %w", err)
+	}
+	block, _ := pem.Decode([]byte(decrypted))
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key cannot sign")
+	}
+	return &loadedKey{
+		kid:       row.KID,
+		private:   signer,
+		public:    signer.Public(),
+		createdAt: row.CreatedAt,
+		retiredAt: row.RetiredAt,
+	}, nil
+}
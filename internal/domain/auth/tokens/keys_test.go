@@ -0,0 +1,171 @@
+package tokens
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/pkg/config"
+	"berth/internal/pkg/crypto"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:tokens_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := database.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return database
+}
+
+func newKeyedService(t *testing.T, db *gorm.DB, algorithm string) *Service {
+	t.Helper()
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			SecretKey:           "test-secret",
+			AccessExpiry:        15 * time.Minute,
+			Issuer:              "test-issuer",
+			Algorithm:           algorithm,
+			KeyRotationInterval: 24 * time.Hour,
+		},
+	}
+	svc, err := NewService(cfg, db, crypto.NewCrypto("test-encryption"), zap.NewNop())
+	require.NoError(t, err)
+	return svc
+}
+
+func tokenKID(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestAsymmetricSigning(t *testing.T) {
+	for _, tc := range []struct {
+		algorithm string
+		kty       string
+	}{
+		{AlgorithmRS256, "RSA"},
+		{AlgorithmEdDSA, "OKP"},
+	} {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			svc := newKeyedService(t, newTestDB(t), tc.algorithm)
+
+			token, err := svc.IssueAccessToken(7)
+			require.NoError(t, err)
+			kid := tokenKID(t, token)
+			require.NotEmpty(t, kid)
+
+			claims, err := svc.ValidateAccess(token)
+			require.NoError(t, err)
+			assert.Equal(t, uint(7), claims.UserID)
+
+			set := svc.JWKS()
+			require.Len(t, set.Keys, 1)
+			assert.Equal(t, kid, set.Keys[0].Kid)
+			assert.Equal(t, tc.kty, set.Keys[0].Kty)
+			assert.Equal(t, tc.algorithm, set.Keys[0].Alg)
+		})
+	}
+}
+
+func TestRotatedKeyStillVerifies(t *testing.T) {
+	svc := newKeyedService(t, newTestDB(t), AlgorithmEdDSA)
+
+	before, err := svc.IssueAccessToken(1)
+	require.NoError(t, err)
+	require.NoError(t, svc.RotateSigningKey())
+	after, err := svc.IssueAccessToken(1)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, tokenKID(t, before), tokenKID(t, after))
+	_, err = svc.ValidateAccess(before)
+	assert.NoError(t, err)
+	_, err = svc.ValidateAccess(after)
+	assert.NoError(t, err)
+	assert.Len(t, svc.JWKS().Keys, 2)
+}
+
+func TestRetiredKeyPrunedAfterTokensExpire(t *testing.T) {
+	db := newTestDB(t)
+	svc := newKeyedService(t, db, AlgorithmEdDSA)
+
+	old, err := svc.IssueAccessToken(1)
+	require.NoError(t, err)
+	oldKID := tokenKID(t, old)
+	require.NoError(t, svc.RotateSigningKey())
+
+	longAgo := time.Now().Add(-time.Hour)
+	require.NoError(t, db.Model(&SigningKey{}).Where("kid = ?", oldKID).Update("retired_at", longAgo).Error)
+	require.NoError(t, svc.checkKeys())
+
+	_, err = svc.ValidateAccess(old)
+	assert.Error(t, err)
+	var count int64
+	require.NoError(t, db.Model(&SigningKey{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestKeysSharedBetweenInstances(t *testing.T) {
+	db := newTestDB(t)
+	first := newKeyedService(t, db, AlgorithmRS256)
+	second := newKeyedService(t, db, AlgorithmRS256)
+
+	token, err := first.IssueAccessToken(3)
+	require.NoError(t, err)
+	_, err = second.ValidateAccess(token)
+	require.NoError(t, err)
+
+	require.NoError(t, first.RotateSigningKey())
+	second.lastKeyReload = time.Time{}
+	rotated, err := first.IssueAccessToken(3)
+	require.NoError(t, err)
+	_, err = second.ValidateAccess(rotated)
+	assert.NoError(t, err, "unknown kid should trigger a reload")
+}
+
+func TestUnknownKIDRejected(t *testing.T) {
+	svc := newKeyedService(t, newTestDB(t), AlgorithmEdDSA)
+	token, err := svc.IssueAccessToken(1)
+	require.NoError(t, err)
+
+	_, rest, _ := strings.Cut(token, ".")
+	forged := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{})
+	forged.Header["kid"] = "does-not-exist"
+	forgedHeader, _, _ := strings.Cut(mustSigningString(t, forged), ".")
+
+	_, err = svc.ValidateAccess(forgedHeader + "." + rest)
+	assert.Error(t, err)
+}
+
+func TestHS256PublishesNoKeys(t *testing.T) {
+	svc := newKeyedService(t, nil, AlgorithmHS256)
+	token, err := svc.IssueAccessToken(1)
+	require.NoError(t, err)
+	assert.Empty(t, tokenKID(t, token))
+	_, err = svc.ValidateAccess(token)
+	require.NoError(t, err)
+	assert.Empty(t, svc.JWKS().Keys)
+}
+
+func mustSigningString(t *testing.T, token *jwt.Token) string {
+	t.Helper()
+	s, err := token.SigningString()
+	require.NoError(t, err)
+	return s
+}
@@ -24,7 +24,10 @@ var (
 type Claims struct {
 	UserID    uint   `json:"user_id"`
 	TokenType string `json:"token_type,omitempty"`
-	JTI       string `json:"jti"`
+	// AuthMethod records which backend checked the password on TOTP
+	// pending tokens, so the completed login can be attributed to it.
+	AuthMethod string `json:"auth_method,omitempty"`
+	JTI        string `json:"jti"`
 	jwt.RegisteredClaims
 }
 
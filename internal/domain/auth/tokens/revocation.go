@@ -1,91 +1,171 @@
 package tokens
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+const (
+	RevocationStoreMemory   = "memory"
+	RevocationStoreDatabase = "database"
+)
+
+// revocationStore records revoked access token JTIs until the tokens expire.
+type revocationStore interface {
+	Start() error
+	Stop() error
+	Revoke(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+	Cleanup()
+}
+
+func newRevocationStore(kind string, db *gorm.DB, logger *zap.Logger) (revocationStore, error) {
+	switch kind {
+	case RevocationStoreDatabase:
+		if db == nil {
+			return nil, errors.New("database revocation store requires a database")
+		}
+		if err := db.AutoMigrate(&RevokedToken{}); err != nil {
+			return nil, fmt.Errorf("migrate revoked tokens: %w", err)
+		}
+		return &sqlRevocationStore{db: db, logger: logger, revoked: make(map[string]time.Time)}, nil
+	case RevocationStoreMemory, "":
+		store := &memoryRevocationStore{db: db, logger: logger, revoked: make(map[string]time.Time), persist: db != nil}
+		if store.persist {
+			if err := db.AutoMigrate(&RevokedToken{}); err != nil {
+				logger.Warn("revoked_tokens automigrate failed; falling back to memory-only revocation", zap.Error(err))
+				store.persist = false
+			}
+		}
+		return store, nil
+	}
+	return nil, fmt.Errorf("unknown revocation store %q", kind)
+}
+
 func (s *Service) RevokeToken(jti string, expiresAt time.Time) error {
 	if !s.revokeEnabled {
 		return nil
 	}
+	if err := s.revocations.Revoke(jti, expiresAt); err != nil {
+		s.logger.Error("persist revoked JTI failed", zap.String("jti", jti), zap.Error(err))
+		return err
+	}
+	return nil
+}
 
-	s.mu.Lock()
-	s.revokedJTIs[jti] = expiresAt
-	s.mu.Unlock()
+func (s *Service) isJTIRevoked(jti string) (bool, error) {
+	return s.revocations.IsRevoked(jti)
+}
 
-	if s.revokePersist {
-		if err := s.db.Create(&RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error; err != nil {
-			s.logger.Error("persist revoked JTI failed", zap.String("jti", jti), zap.Error(err))
-			return err
-		}
+func (s *Service) cleanupExpiredJTIs() {
+	s.revocations.Cleanup()
+}
+
+// memoryRevocationStore checks revocations against an in-process map. When a
+// database is available the map is loaded on start and saved on shutdown so
+// revocations survive a restart, but other instances never see them.
+type memoryRevocationStore struct {
+	db      *gorm.DB
+	logger  *zap.Logger
+	persist bool
+
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+func (m *memoryRevocationStore) Start() error {
+	if !m.persist {
+		return nil
+	}
+	return m.loadFromDB()
+}
+
+func (m *memoryRevocationStore) Stop() error {
+	if !m.persist {
+		return nil
+	}
+	return m.saveToDB()
+}
+
+func (m *memoryRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	m.revoked[jti] = expiresAt
+	m.mu.Unlock()
+
+	if m.persist {
+		return m.db.Create(&RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
 	}
 	return nil
 }
 
-func (s *Service) isJTIRevoked(jti string) (bool, error) {
-	s.mu.RLock()
-	expiresAt, exists := s.revokedJTIs[jti]
-	s.mu.RUnlock()
+func (m *memoryRevocationStore) IsRevoked(jti string) (bool, error) {
+	m.mu.RLock()
+	expiresAt, exists := m.revoked[jti]
+	m.mu.RUnlock()
 
 	if !exists {
 		return false, nil
 	}
 	if time.Now().After(expiresAt) {
-		s.mu.Lock()
-		delete(s.revokedJTIs, jti)
-		s.mu.Unlock()
+		m.mu.Lock()
+		delete(m.revoked, jti)
+		m.mu.Unlock()
 		return false, nil
 	}
 	return true, nil
 }
 
-func (s *Service) cleanupExpiredJTIs() {
+func (m *memoryRevocationStore) Cleanup() {
 	now := time.Now()
 
-	s.mu.Lock()
-	for jti, expiresAt := range s.revokedJTIs {
+	m.mu.Lock()
+	for jti, expiresAt := range m.revoked {
 		if now.After(expiresAt) {
-			delete(s.revokedJTIs, jti)
+			delete(m.revoked, jti)
 		}
 	}
-	s.mu.Unlock()
+	m.mu.Unlock()
 }
 
-func (s *Service) loadRevokedFromDB() error {
+func (m *memoryRevocationStore) loadFromDB() error {
 	now := time.Now()
 
 	var rows []RevokedToken
-	if err := s.db.Where("expires_at > ?", now).Find(&rows).Error; err != nil {
+	if err := m.db.Where("expires_at > ?", now).Find(&rows).Error; err != nil {
 		return err
 	}
 
-	s.mu.Lock()
+	m.mu.Lock()
 	for _, r := range rows {
-		s.revokedJTIs[r.JTI] = r.ExpiresAt
+		m.revoked[r.JTI] = r.ExpiresAt
 	}
-	s.mu.Unlock()
+	m.mu.Unlock()
 
-	if err := s.db.Unscoped().Where("expires_at <= ?", now).Delete(&RevokedToken{}).Error; err != nil {
-		s.logger.Warn("clean expired revoked rows on load failed", zap.Error(err))
+	if err := m.db.Unscoped().Where("expires_at <= ?", now).Delete(&RevokedToken{}).Error; err != nil {
+		m.logger.Warn("clean expired revoked rows on load failed", zap.Error(err))
 	}
 	return nil
 }
 
-func (s *Service) saveRevokedToDB() error {
+func (m *memoryRevocationStore) saveToDB() error {
 	now := time.Now()
 
-	s.mu.RLock()
-	rows := make([]RevokedToken, 0, len(s.revokedJTIs))
-	for jti, expiresAt := range s.revokedJTIs {
+	m.mu.RLock()
+	rows := make([]RevokedToken, 0, len(m.revoked))
+	for jti, expiresAt := range m.revoked {
 		if now.Before(expiresAt) {
 			rows = append(rows, RevokedToken{JTI: jti, ExpiresAt: expiresAt})
 		}
 	}
-	s.mu.RUnlock()
+	m.mu.RUnlock()
 
-	tx := s.db.Begin()
+	tx := m.db.Begin()
 	if tx.Error != nil {
 		return tx.Error
 	}
@@ -107,3 +187,72 @@ func (s *Service) saveRevokedToDB() error {
 	}
 	return tx.Commit().Error
 }
+
+// sqlRevocationStore checks revocations against the revoked_tokens table so
+// a logout on one instance is honoured by all of them. Revocations are
+// permanent until expiry, so hits are cached locally; misses always query.
+type sqlRevocationStore struct {
+	db     *gorm.DB
+	logger *zap.Logger
+
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+func (q *sqlRevocationStore) Start() error { return nil }
+
+func (q *sqlRevocationStore) Stop() error { return nil }
+
+func (q *sqlRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	err := q.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+	if err != nil {
+		return err
+	}
+	q.remember(jti, expiresAt)
+	return nil
+}
+
+func (q *sqlRevocationStore) IsRevoked(jti string) (bool, error) {
+	now := time.Now()
+
+	q.mu.RLock()
+	expiresAt, cached := q.revoked[jti]
+	q.mu.RUnlock()
+	if cached && now.Before(expiresAt) {
+		return true, nil
+	}
+
+	var row RevokedToken
+	err := q.db.Where("jti = ? AND expires_at > ?", jti, now).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	q.remember(jti, row.ExpiresAt)
+	return true, nil
+}
+
+func (q *sqlRevocationStore) Cleanup() {
+	now := time.Now()
+
+	q.mu.Lock()
+	for jti, expiresAt := range q.revoked {
+		if now.After(expiresAt) {
+			delete(q.revoked, jti)
+		}
+	}
+	q.mu.Unlock()
+
+	if err := q.db.Unscoped().Where("expires_at <= ?", now).Delete(&RevokedToken{}).Error; err != nil {
+		q.logger.Error("clean expired revoked tokens failed", zap.Error(err))
+	}
+}
+
+func (q *sqlRevocationStore) remember(jti string, expiresAt time.Time) {
+	q.mu.Lock()
+	q.revoked[jti] = expiresAt
+	q.mu.Unlock()
+}
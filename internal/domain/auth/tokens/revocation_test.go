@@ -0,0 +1,85 @@
+package tokens
+
+import (
+	"testing"
+	"time"
+
+	"berth/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newRevocationService(t *testing.T, db *gorm.DB, store string) *Service {
+	t.Helper()
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			SecretKey:    "test-secret",
+			AccessExpiry: 15 * time.Minute,
+			Issuer:       "test-issuer",
+			Algorithm:    AlgorithmHS256,
+		},
+		Revocation: config.RevocationConfig{Enabled: true, Store: store},
+	}
+	svc, err := NewService(cfg, db, nil, zap.NewNop())
+	require.NoError(t, err)
+	return svc
+}
+
+func revokeIssued(t *testing.T, svc *Service) string {
+	t.Helper()
+	token, err := svc.IssueAccessToken(1)
+	require.NoError(t, err)
+	jti, err := svc.ExtractJTI(token)
+	require.NoError(t, err)
+	require.NoError(t, svc.RevokeToken(jti, time.Now().Add(time.Hour)))
+	return token
+}
+
+func TestDatabaseRevocationSharedBetweenInstances(t *testing.T) {
+	db := newTestDB(t)
+	first := newRevocationService(t, db, RevocationStoreDatabase)
+	second := newRevocationService(t, db, RevocationStoreDatabase)
+
+	token := revokeIssued(t, first)
+
+	_, err := first.ValidateAccess(token)
+	assert.ErrorIs(t, err, ErrRevoked)
+	_, err = second.ValidateAccess(token)
+	assert.ErrorIs(t, err, ErrRevoked)
+}
+
+func TestDatabaseRevocationCleanupRemovesExpired(t *testing.T) {
+	db := newTestDB(t)
+	svc := newRevocationService(t, db, RevocationStoreDatabase)
+
+	require.NoError(t, svc.RevokeToken("expired", time.Now().Add(-time.Minute)))
+	require.NoError(t, svc.RevokeToken("live", time.Now().Add(time.Hour)))
+	require.NoError(t, svc.RevokeToken("live", time.Now().Add(time.Hour)), "revoking twice is not an error")
+	svc.cleanupExpiredJTIs()
+
+	var jtis []string
+	require.NoError(t, db.Unscoped().Model(&RevokedToken{}).Pluck("jti", &jtis).Error)
+	assert.Equal(t, []string{"live"}, jtis)
+}
+
+func TestMemoryRevocationIsPerInstance(t *testing.T) {
+	db := newTestDB(t)
+	first := newRevocationService(t, db, RevocationStoreMemory)
+	second := newRevocationService(t, db, RevocationStoreMemory)
+
+	token := revokeIssued(t, first)
+
+	_, err := first.ValidateAccess(token)
+	assert.ErrorIs(t, err, ErrRevoked)
+	_, err = second.ValidateAccess(token)
+	assert.NoError(t, err)
+}
+
+func TestUnknownRevocationStore(t *testing.T) {
+	cfg := &config.Config{Revocation: config.RevocationConfig{Enabled: true, Store: "redis"}}
+	_, err := NewService(cfg, nil, nil, zap.NewNop())
+	assert.Error(t, err)
+}
@@ -15,41 +15,67 @@ import (
 type Service struct {
 	cfg    *config.Config
 	db     *gorm.DB
+	enc    keyEncrypter
 	logger *zap.Logger
 
-	mu             sync.RWMutex
-	revokedJTIs    map[string]time.Time
-	revokePersist  bool
+	keyMu         sync.RWMutex
+	signer        *loadedKey
+	verifiers     map[string]*loadedKey
+	lastKeyReload time.Time
+
+	leader         leaderGate
+	revocations    revocationStore
 	revokeEnabled  bool
 	cleanupStop    chan struct{}
 	cleanupStopped chan struct{}
 }
 
-func NewService(cfg *config.Config, db *gorm.DB, logger *zap.Logger) (*Service, error) {
+func NewService(cfg *config.Config, db *gorm.DB, enc keyEncrypter, logger *zap.Logger) (*Service, error) {
 	s := &Service{
 		cfg:           cfg,
 		db:            db,
+		enc:           enc,
 		logger:        logger,
-		revokedJTIs:   make(map[string]time.Time),
+		verifiers:     make(map[string]*loadedKey),
 		revokeEnabled: cfg.Revocation.Enabled,
-		revokePersist: cfg.Revocation.Enabled && db != nil,
 	}
 
-	if s.revokePersist {
-		if err := db.AutoMigrate(&RevokedToken{}); err != nil {
-			logger.Warn("revoked_tokens automigrate failed; falling back to memory-only revocation", zap.Error(err))
-			s.revokePersist = false
+	storeKind, revocationDB := cfg.Revocation.Store, db
+	if !s.revokeEnabled {
+		storeKind, revocationDB = RevocationStoreMemory, nil
+	}
+	revocations, err := newRevocationStore(storeKind, revocationDB, logger)
+	if err != nil {
+		return nil, fmt.Errorf("revocation store: %w", err)
+	}
+	s.revocations = revocations
+
+	if s.asymmetric() {
+		if err := s.initKeys(); err != nil {
+			return nil, fmt.Errorf("initialise JWT signing keys: %w", err)
 		}
 	}
 
 	return s, nil
 }
 
+type leaderGate interface {
+	IsLeader() bool
+}
+
+// SetLeaderGate limits database maintenance, such as deleting expired refresh
+// tokens and rotating signing keys, to the instance holding the lease.
+func (s *Service) SetLeaderGate(g leaderGate) {
+	s.leader = g
+}
+
+func (s *Service) leads() bool {
+	return s.leader == nil || s.leader.IsLeader()
+}
+
 func (s *Service) Start(context.Context) error {
-	if s.revokePersist {
-		if err := s.loadRevokedFromDB(); err != nil {
-			return fmt.Errorf("load revoked tokens: %w", err)
-		}
+	if err := s.revocations.Start(); err != nil {
+		return fmt.Errorf("load revoked tokens: %w", err)
 	}
 	s.startCleanupWorker()
 	return nil
@@ -57,10 +83,8 @@ func (s *Service) Start(context.Context) error {
 
 func (s *Service) Stop(context.Context) error {
 	s.stopCleanupWorker()
-	if s.revokePersist {
-		if err := s.saveRevokedToDB(); err != nil {
-			s.logger.Error("failed to persist revoked tokens on shutdown", zap.Error(err))
-		}
+	if err := s.revocations.Stop(); err != nil {
+		s.logger.Error("failed to persist revoked tokens on shutdown", zap.Error(err))
 	}
 	return nil
 }
@@ -81,14 +105,28 @@ func (s *Service) startCleanupWorker() {
 		defer revokeTicker.Stop()
 		defer refreshTicker.Stop()
 
+		var keyTicks <-chan time.Time
+		if s.asymmetric() {
+			keyTicker := time.NewTicker(keyCheckInterval)
+			defer keyTicker.Stop()
+			keyTicks = keyTicker.C
+		}
+
 		for {
 			select {
 			case <-revokeTicker.C:
 				s.cleanupExpiredJTIs()
 			case <-refreshTicker.C:
+				if !s.leads() {
+					continue
+				}
 				if err := s.cleanupExpiredRefreshTokens(); err != nil {
 					s.logger.Error("refresh token cleanup failed", zap.Error(err))
 				}
+			case <-keyTicks:
+				if err := s.checkKeys(); err != nil {
+					s.logger.Error("JWT signing key check failed", zap.Error(err))
+				}
 			case <-s.cleanupStop:
 				return
 			}
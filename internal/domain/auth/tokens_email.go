@@ -6,9 +6,13 @@ import (
 	"gorm.io/gorm"
 )
 
+// EmailVerificationToken verifies ownership of Email. UserID is set when the
+// token confirms a signed-in user's request to change to Email rather than a
+// new account's address.
 type EmailVerificationToken struct {
 	gorm.Model
 	Email     string     `json:"email" gorm:"index;not null"`
+	UserID    *uint      `json:"user_id,omitempty" gorm:"index"`
 	Token     string     `json:"-" gorm:"uniqueIndex;not null"`
 	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
 	Used      bool       `json:"used" gorm:"default:false"`
@@ -1,6 +1,8 @@
 package totp
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -17,3 +19,12 @@ type UsedCode struct {
 	Code   string `gorm:"index:idx_user_code,priority:2;not null"`
 	UsedAt int64  `gorm:"index:idx_used_at;not null"`
 }
+
+// RecoveryCode is a single-use code that stands in for a TOTP code when the
+// authenticator is unavailable. Only the SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `gorm:"index;not null"`
+	CodeHash string     `gorm:"size:64;index;not null"`
+	UsedAt   *time.Time `gorm:"index"`
+}
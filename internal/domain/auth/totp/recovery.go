@@ -0,0 +1,149 @@
+package totp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidRecoveryCode = errors.New("invalid recovery code")
+
+const (
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 12
+	recoveryCodeGroup  = 4
+	// Lowercase letters and digits without the easily confused 0, 1, i, l and o.
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// IsRecoveryCode reports whether code has the shape of a recovery code rather
+// than a TOTP code, so both can be accepted in the same field.
+func IsRecoveryCode(code string) bool {
+	return len(normalizeRecoveryCode(code)) == recoveryCodeLength
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes with a new set
+// and returns the plaintext codes. They cannot be retrieved again.
+func (s *Service) RegenerateRecoveryCodes(userID uint) ([]string, error) {
+	if !s.enabled() {
+		return nil, ErrTOTPDisabled
+	}
+	secret, err := s.GetSecret(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !secret.Enabled {
+		return nil, ErrSecretNotFound
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var replaceErr error
+		codes, replaceErr = s.replaceRecoveryCodes(tx, userID)
+		return replaceErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("TOTP recovery codes regenerated", zap.Uint("user_id", userID))
+	return codes, nil
+}
+
+// ConsumeRecoveryCode marks a matching unused recovery code as used and
+// returns how many unused codes remain.
+func (s *Service) ConsumeRecoveryCode(userID uint, code string) (int, error) {
+	if !s.enabled() {
+		return 0, ErrTOTPDisabled
+	}
+	secret, err := s.GetSecret(userID)
+	if err != nil {
+		return 0, err
+	}
+	if !secret.Enabled {
+		return 0, ErrSecretNotFound
+	}
+
+	now := time.Now()
+	result := s.db.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", now)
+	if result.Error != nil {
+		return 0, fmt.Errorf("consume recovery code: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrInvalidRecoveryCode
+	}
+
+	remaining := s.RecoveryCodesRemaining(userID)
+	s.logger.Info("TOTP recovery code used", zap.Uint("user_id", userID), zap.Int("remaining", remaining))
+	return remaining, nil
+}
+
+func (s *Service) RecoveryCodesRemaining(userID uint) int {
+	var count int64
+	if err := s.db.Model(&RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error; err != nil {
+		s.logger.Warn("failed to count recovery codes", zap.Uint("user_id", userID), zap.Error(err))
+		return 0
+	}
+	return int(count)
+}
+
+func (s *Service) replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("delete recovery codes: %w", err)
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	rows := make([]RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		rows[i] = RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, fmt.Errorf("store recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+func generateRecoveryCode() (string, error) {
+	alphabetSize := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	var b strings.Builder
+	for i := 0; i < recoveryCodeLength; i++ {
+		if i > 0 && i%recoveryCodeGroup == 0 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", fmt.Errorf("generate recovery code: %w", err)
+		}
+		b.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,149 @@
+package totp
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/pkg/config"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+func newTestService(t *testing.T) (*Service, *gorm.DB) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:totp_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&TOTPSecret{}, &UsedCode{}, &RecoveryCode{}))
+
+	cfg := &config.Config{}
+	cfg.TOTP.Enabled = true
+	cfg.TOTP.Issuer = "Berth"
+	return NewService(cfg, database, zap.NewNop()), database
+}
+
+func enableTOTP(t *testing.T, svc *Service, userID uint) []string {
+	t.Helper()
+	secret, err := svc.GenerateSecret(userID, "alice@example.com")
+	require.NoError(t, err)
+	code, err := totp.GenerateCode(secret.Secret, time.Now())
+	require.NoError(t, err)
+	codes, err := svc.EnableTOTP(userID, code)
+	require.NoError(t, err)
+	return codes
+}
+
+func TestEnableTOTP_GeneratesRecoveryCodes(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	codes := enableTOTP(t, svc, 1)
+	require.Len(t, codes, recoveryCodeCount)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		assert.Regexp(t, `^[a-z2-9]{4}-[a-z2-9]{4}-[a-z2-9]{4}$`, code)
+		assert.True(t, IsRecoveryCode(code))
+		assert.False(t, seen[code], "codes must be unique")
+		seen[code] = true
+	}
+	assert.Equal(t, recoveryCodeCount, svc.RecoveryCodesRemaining(1))
+}
+
+func TestRecoveryCodes_StoredHashed(t *testing.T) {
+	svc, database := newTestService(t)
+	codes := enableTOTP(t, svc, 1)
+
+	var rows []RecoveryCode
+	require.NoError(t, database.Find(&rows).Error)
+	require.Len(t, rows, recoveryCodeCount)
+	hashes := map[string]bool{}
+	for _, row := range rows {
+		assert.Len(t, row.CodeHash, 64)
+		hashes[row.CodeHash] = true
+	}
+	for _, code := range codes {
+		assert.True(t, hashes[hashRecoveryCode(code)])
+		assert.False(t, hashes[code])
+	}
+}
+
+func TestConsumeRecoveryCode_SingleUse(t *testing.T) {
+	svc, _ := newTestService(t)
+	codes := enableTOTP(t, svc, 1)
+
+	remaining, err := svc.ConsumeRecoveryCode(1, strings.ToUpper(codes[0]))
+	require.NoError(t, err, "codes are case-insensitive")
+	assert.Equal(t, recoveryCodeCount-1, remaining)
+
+	_, err = svc.ConsumeRecoveryCode(1, codes[0])
+	assert.ErrorIs(t, err, ErrInvalidRecoveryCode)
+
+	remaining, err = svc.ConsumeRecoveryCode(1, strings.ReplaceAll(codes[1], "-", " "))
+	require.NoError(t, err, "separators are ignored")
+	assert.Equal(t, recoveryCodeCount-2, remaining)
+}
+
+func TestConsumeRecoveryCode_ScopedToUser(t *testing.T) {
+	svc, _ := newTestService(t)
+	aliceCodes := enableTOTP(t, svc, 1)
+	enableTOTP(t, svc, 2)
+
+	_, err := svc.ConsumeRecoveryCode(2, aliceCodes[0])
+	assert.ErrorIs(t, err, ErrInvalidRecoveryCode)
+	assert.Equal(t, recoveryCodeCount, svc.RecoveryCodesRemaining(1))
+}
+
+func TestRegenerateRecoveryCodes_InvalidatesOldSet(t *testing.T) {
+	svc, _ := newTestService(t)
+	oldCodes := enableTOTP(t, svc, 1)
+	_, err := svc.ConsumeRecoveryCode(1, oldCodes[0])
+	require.NoError(t, err)
+
+	newCodes, err := svc.RegenerateRecoveryCodes(1)
+	require.NoError(t, err)
+	require.Len(t, newCodes, recoveryCodeCount)
+	assert.Equal(t, recoveryCodeCount, svc.RecoveryCodesRemaining(1))
+
+	_, err = svc.ConsumeRecoveryCode(1, oldCodes[1])
+	assert.ErrorIs(t, err, ErrInvalidRecoveryCode)
+	_, err = svc.ConsumeRecoveryCode(1, newCodes[0])
+	assert.NoError(t, err)
+}
+
+func TestRecoveryCodes_RequireEnabledTOTP(t *testing.T) {
+	svc, _ := newTestService(t)
+	_, err := svc.GenerateSecret(1, "alice@example.com")
+	require.NoError(t, err)
+
+	_, err = svc.RegenerateRecoveryCodes(1)
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+	_, err = svc.ConsumeRecoveryCode(1, "abcd-efgh-jkmn")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+}
+
+func TestDisableTOTP_RemovesRecoveryCodes(t *testing.T) {
+	svc, database := newTestService(t)
+	enableTOTP(t, svc, 1)
+
+	require.NoError(t, svc.DisableTOTP(1))
+
+	var count int64
+	require.NoError(t, database.Unscoped().Model(&RecoveryCode{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestIsRecoveryCode(t *testing.T) {
+	assert.False(t, IsRecoveryCode("123456"))
+	assert.True(t, IsRecoveryCode("abcd-efgh-jkmn"))
+	assert.True(t, IsRecoveryCode("ABCDEFGHJKMN"))
+	assert.False(t, IsRecoveryCode(""))
+}
@@ -97,23 +97,35 @@ func (s *Service) GetSecret(userID uint) (*TOTPSecret, error) {
 	return &row, nil
 }
 
-func (s *Service) EnableTOTP(userID uint, code string) error {
+// EnableTOTP turns on TOTP after checking a code from the authenticator and
+// returns a fresh set of recovery codes.
+func (s *Service) EnableTOTP(userID uint, code string) ([]string, error) {
 	if !s.enabled() {
-		return ErrTOTPDisabled
+		return nil, ErrTOTPDisabled
 	}
 	secret, err := s.GetSecret(userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if !totp.Validate(code, secret.Secret) {
-		return ErrInvalidCode
+		return nil, ErrInvalidCode
 	}
-	secret.Enabled = true
-	if err := s.db.Save(secret).Error; err != nil {
-		return fmt.Errorf("enable TOTP: %w", err)
+
+	var recoveryCodes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		secret.Enabled = true
+		if err := tx.Save(secret).Error; err != nil {
+			return fmt.Errorf("enable TOTP: %w", err)
+		}
+		var replaceErr error
+		recoveryCodes, replaceErr = s.replaceRecoveryCodes(tx, userID)
+		return replaceErr
+	})
+	if err != nil {
+		return nil, err
 	}
 	s.logger.Info("TOTP enabled", zap.Uint("user_id", userID))
-	return nil
+	return recoveryCodes, nil
 }
 
 func (s *Service) DisableTOTP(userID uint) error {
@@ -131,6 +143,9 @@ func (s *Service) DisableTOTP(userID uint) error {
 		if err := tx.Where("user_id = ?", userID).Delete(&UsedCode{}).Error; err != nil {
 			return fmt.Errorf("cleanup used codes: %w", err)
 		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("cleanup recovery codes: %w", err)
+		}
 		s.logger.Info("TOTP disabled", zap.Uint("user_id", userID))
 		return nil
 	})
@@ -3,8 +3,9 @@ package auth
 import "errors"
 
 var (
-	ErrTOTPEnableCodeRequired         = errors.New("TOTP code is required")
-	ErrTOTPDisableCredentialsRequired = errors.New("TOTP code and password are required to disable 2FA")
+	ErrTOTPEnableCodeRequired          = errors.New("TOTP code is required")
+	ErrTOTPDisableCredentialsRequired  = errors.New("TOTP code and password are required to disable 2FA")
+	ErrTOTPRecoveryCredentialsRequired = errors.New("TOTP code and password are required to regenerate recovery codes")
 )
 
 type TOTPEnableRequest struct {
@@ -30,8 +31,34 @@ func (r *TOTPDisableRequest) Validate() error {
 	return nil
 }
 
+// TOTPRecoveryCodesRequest regenerates recovery codes. Code may be a TOTP
+// code or an unused recovery code.
+type TOTPRecoveryCodesRequest struct {
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
+
+func (r *TOTPRecoveryCodesRequest) Validate() error {
+	if r.Code == "" || r.Password == "" {
+		return ErrTOTPRecoveryCredentialsRequired
+	}
+	return nil
+}
+
 type TOTPStatusData struct {
-	Enabled bool `json:"enabled"`
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TOTPEnableData returns the recovery codes generated when TOTP is enabled.
+// They are shown only once.
+type TOTPEnableData struct {
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TOTPRecoveryCodesData struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 type TOTPSetupData struct {
@@ -46,3 +46,25 @@ func TestTOTPDisableRequest_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestTOTPRecoveryCodesRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     TOTPRecoveryCodesRequest
+		wantErr error
+	}{
+		{"empty both", TOTPRecoveryCodesRequest{}, ErrTOTPRecoveryCredentialsRequired},
+		{"empty code", TOTPRecoveryCodesRequest{Password: "pw"}, ErrTOTPRecoveryCredentialsRequired},
+		{"empty password", TOTPRecoveryCodesRequest{Code: "123456"}, ErrTOTPRecoveryCredentialsRequired},
+		{"both present", TOTPRecoveryCodesRequest{Code: "123456", Password: "pw"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+
+	"berth/internal/domain/auth/passkey"
+	"berth/internal/domain/auth/totp"
+	usermodel "berth/internal/domain/user"
+)
+
+// RequiresTwoFactor reports whether any of the user's roles requires a second
+// factor.
+func (s *Service) RequiresTwoFactor(userID uint) (bool, error) {
+	var count int64
+	err := s.db.Model(&usermodel.Role{}).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ? AND roles.require_two_factor = ?", userID, true).
+		Scopes(usermodel.ActiveRoleAssignments).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("check two-factor requirement: %w", err)
+	}
+	return count > 0, nil
+}
+
+// NeedsTwoFactorEnrollment reports whether one of the user's roles requires a
+// second factor and the user has neither TOTP enabled nor a usable passkey.
+func (s *Service) NeedsTwoFactorEnrollment(userID uint) (bool, error) {
+	required, err := s.RequiresTwoFactor(userID)
+	if err != nil || !required {
+		return false, err
+	}
+
+	var count int64
+	if err := s.db.Model(&totp.TOTPSecret{}).Where("user_id = ? AND enabled = ?", userID, true).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("check TOTP enrollment: %w", err)
+	}
+	if count == 0 && s.config.WebAuthn.Enabled {
+		if err := s.db.Model(&passkey.Credential{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return false, fmt.Errorf("check passkey enrollment: %w", err)
+		}
+	}
+	return count == 0, nil
+}
+
+// TwoFactorNonCompliantUsers lists users holding a role that requires a second
+// factor who have neither TOTP enabled nor a usable passkey. Roles is
+// populated with only the roles that impose the requirement.
+func (s *Service) TwoFactorNonCompliantUsers() ([]usermodel.User, error) {
+	required := s.db.Table("user_roles").
+		Select("user_roles.user_id").
+		Joins("JOIN roles ON roles.id = user_roles.role_id AND roles.deleted_at IS NULL").
+		Where("roles.require_two_factor = ?", true).
+		Scopes(usermodel.ActiveRoleAssignments)
+
+	query := s.db.Where("id IN (?)", required).
+		Where("id NOT IN (?)", s.db.Model(&totp.TOTPSecret{}).Select("user_id").Where("enabled = ?", true))
+	if s.config.WebAuthn.Enabled {
+		query = query.Where("id NOT IN (?)", s.db.Model(&passkey.Credential{}).Select("user_id"))
+	}
+
+	var users []usermodel.User
+	if err := query.Order("username").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("list two-factor non-compliant users: %w", err)
+	}
+
+	ptrs := make([]*usermodel.User, len(users))
+	for i := range users {
+		ptrs[i] = &users[i]
+	}
+	if err := usermodel.LoadActiveRoles(s.db, ptrs...); err != nil {
+		return nil, fmt.Errorf("list two-factor non-compliant users: %w", err)
+	}
+	for i := range users {
+		users[i].Roles = slices.DeleteFunc(users[i].Roles, func(r usermodel.Role) bool { return !r.RequireTwoFactor })
+	}
+	return users, nil
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"berth/internal/domain/auth/passkey"
+	"berth/internal/domain/auth/totp"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var twoFactorDBCounter atomic.Int64
+
+func newTwoFactorService(t *testing.T, webauthn bool) (*Service, *gorm.DB) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:twofactor_test_%d?mode=memory&cache=shared", twoFactorDBCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&usermodel.User{}, &usermodel.Role{}, &usermodel.UserRole{}, &totp.TOTPSecret{}, &passkey.Credential{}))
+
+	cfg := &config.Config{}
+	cfg.WebAuthn.Enabled = webauthn
+	return NewService(cfg, db, nil, nil, zap.NewNop()), db
+}
+
+func createTwoFactorUser(t *testing.T, db *gorm.DB, name string, roles ...usermodel.Role) usermodel.User {
+	t.Helper()
+	u := usermodel.User{Username: name, Email: name + "@example.com", Password: "x", Roles: roles}
+	require.NoError(t, db.Create(&u).Error)
+	return u
+}
+
+func TestRequiresTwoFactor_FollowsRoleFlag(t *testing.T) {
+	svc, db := newTwoFactorService(t, false)
+	strict := usermodel.Role{Name: "operators", RequireTwoFactor: true}
+	relaxed := usermodel.Role{Name: "viewers"}
+	require.NoError(t, db.Create(&strict).Error)
+	require.NoError(t, db.Create(&relaxed).Error)
+
+	op := createTwoFactorUser(t, db, "op", strict, relaxed)
+	viewer := createTwoFactorUser(t, db, "viewer", relaxed)
+
+	required, err := svc.RequiresTwoFactor(op.ID)
+	require.NoError(t, err)
+	assert.True(t, required)
+
+	required, err = svc.RequiresTwoFactor(viewer.ID)
+	require.NoError(t, err)
+	assert.False(t, required)
+
+	require.NoError(t, db.Delete(&strict).Error)
+	required, err = svc.RequiresTwoFactor(op.ID)
+	require.NoError(t, err)
+	assert.False(t, required, "a deleted role must no longer impose the requirement")
+}
+
+func TestTwoFactorNonCompliantUsers(t *testing.T) {
+	svc, db := newTwoFactorService(t, true)
+	strict := usermodel.Role{Name: "operators", RequireTwoFactor: true}
+	relaxed := usermodel.Role{Name: "viewers"}
+	require.NoError(t, db.Create(&strict).Error)
+	require.NoError(t, db.Create(&relaxed).Error)
+
+	missing := createTwoFactorUser(t, db, "missing", strict, relaxed)
+	withTOTP := createTwoFactorUser(t, db, "with-totp", strict)
+	withPasskey := createTwoFactorUser(t, db, "with-passkey", strict)
+	pending := createTwoFactorUser(t, db, "pending-totp", strict)
+	createTwoFactorUser(t, db, "unaffected", relaxed)
+
+	require.NoError(t, db.Create(&totp.TOTPSecret{UserID: withTOTP.ID, Secret: "s", Enabled: true}).Error)
+	require.NoError(t, db.Create(&totp.TOTPSecret{UserID: pending.ID, Secret: "s", Enabled: false}).Error)
+	require.NoError(t, db.Create(&passkey.Credential{UserID: withPasskey.ID, CredentialID: "cred-1", PublicKey: []byte{1}, Name: "key"}).Error)
+
+	users, err := svc.TwoFactorNonCompliantUsers()
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, missing.ID, users[0].ID)
+	assert.Equal(t, pending.ID, users[1].ID)
+	require.Len(t, users[0].Roles, 1, "only the roles imposing the requirement are reported")
+	assert.Equal(t, "operators", users[0].Roles[0].Name)
+
+	svc.config.WebAuthn.Enabled = false
+	users, err = svc.TwoFactorNonCompliantUsers()
+	require.NoError(t, err)
+	assert.Len(t, users, 3, "passkeys do not count while WebAuthn is disabled")
+}
+
+func TestNeedsTwoFactorEnrollment(t *testing.T) {
+	svc, db := newTwoFactorService(t, true)
+	strict := usermodel.Role{Name: "operators", RequireTwoFactor: true}
+	relaxed := usermodel.Role{Name: "viewers"}
+	require.NoError(t, db.Create(&strict).Error)
+	require.NoError(t, db.Create(&relaxed).Error)
+
+	missing := createTwoFactorUser(t, db, "missing", strict)
+	withTOTP := createTwoFactorUser(t, db, "with-totp", strict)
+	withPasskey := createTwoFactorUser(t, db, "with-passkey", strict)
+	unaffected := createTwoFactorUser(t, db, "unaffected", relaxed)
+
+	require.NoError(t, db.Create(&totp.TOTPSecret{UserID: withTOTP.ID, Secret: "s", Enabled: true}).Error)
+	require.NoError(t, db.Create(&passkey.Credential{UserID: withPasskey.ID, CredentialID: "cred-1", PublicKey: []byte{1}, Name: "key"}).Error)
+
+	for _, tc := range []struct {
+		user usermodel.User
+		want bool
+	}{
+		{missing, true},
+		{withTOTP, false},
+		{withPasskey, false},
+		{unaffected, false},
+	} {
+		needs, err := svc.NeedsTwoFactorEnrollment(tc.user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, needs, tc.user.Username)
+	}
+
+	svc.config.WebAuthn.Enabled = false
+	needs, err := svc.NeedsTwoFactorEnrollment(withPasskey.ID)
+	require.NoError(t, err)
+	assert.True(t, needs, "passkeys do not count while WebAuthn is disabled")
+}
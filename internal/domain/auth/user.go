@@ -25,7 +25,7 @@ func NewUserProvider(db *gorm.DB) UserProvider {
 
 func (p *gormUserProvider) GetUser(userID uint) (any, error) {
 	var user usermodel.User
-	if err := p.db.Preload("Roles").First(&user, userID).Error; err != nil {
+	if err := usermodel.FirstWithActiveRoles(p.db, &user, userID); err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -3,6 +3,7 @@ package engine
 import (
 	"fmt"
 
+	"berth/internal/domain/accessrequests"
 	"berth/internal/domain/authz"
 	"berth/internal/domain/rbac/permnames"
 	usermodel "berth/internal/domain/user"
@@ -143,11 +144,35 @@ func (e *Engine) checkUserAnyStackPermission(p authz.Principal, serverID uint, p
 		Joins("JOIN user_roles ON user_roles.role_id = server_role_stack_permissions.role_id").
 		Joins("JOIN permissions ON permissions.id = server_role_stack_permissions.permission_id").
 		Where("user_roles.user_id = ? AND server_role_stack_permissions.server_id = ? AND permissions.name = ?", userID, serverID, permName).
+		Scopes(usermodel.ActiveRoleAssignments).
 		Count(&count).Error
 	if err != nil {
 		return false, err
 	}
-	return count > 0, nil
+	if count > 0 {
+		return true, nil
+	}
+
+	grants, err := e.accessGrants(userID, serverID)
+	if err != nil {
+		return false, err
+	}
+	for _, grant := range grants {
+		if grant.Permission == permName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// accessGrants returns the user's approved, unexpired access requests on
+// the server. They add to the user's role permissions.
+func (e *Engine) accessGrants(userID, serverID uint) ([]accessrequests.AccessRequest, error) {
+	var grants []accessrequests.AccessRequest
+	err := e.db.Where("user_id = ? AND server_id = ?", userID, serverID).
+		Scopes(accessrequests.ActiveGrants).
+		Find(&grants).Error
+	return grants, err
 }
 
 func checkAPIKeyServerPermission(key *authz.KeyDescriptor, serverID uint, permName string) bool {
@@ -201,6 +226,7 @@ func (e *Engine) checkUserStackPermission(p authz.Principal, serverID uint, stac
 	err := e.db.Preload("Permission").
 		Joins("JOIN user_roles ON user_roles.role_id = server_role_stack_permissions.role_id").
 		Where("user_roles.user_id = ? AND server_role_stack_permissions.server_id = ?", userID, serverID).
+		Scopes(usermodel.ActiveRoleAssignments).
 		Find(&srsps).Error
 	if err != nil {
 		return false, err
@@ -211,6 +237,16 @@ func (e *Engine) checkUserStackPermission(p authz.Principal, serverID uint, stac
 			return true, nil
 		}
 	}
+
+	grants, err := e.accessGrants(userID, serverID)
+	if err != nil {
+		return false, err
+	}
+	for _, grant := range grants {
+		if grant.Permission == permName && patterns.Matches(stack, grant.StackPattern) {
+			return true, nil
+		}
+	}
 	return false, nil
 }
 
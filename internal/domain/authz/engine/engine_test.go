@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"sync/atomic"
 	"testing"
+	"time"
 
+	"berth/internal/domain/accessrequests"
 	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
 	usermodel "berth/internal/domain/user"
 	"berth/seeds"
 
@@ -284,6 +287,30 @@ func TestAuthorize_KindServerAccess(t *testing.T) {
 		require.NoError(t, err)
 		assert.True(t, ok)
 	})
+
+	t.Run("expired role assignment denies before it is pruned", func(t *testing.T) {
+		u := usermodel.User{Username: "expired", Email: "expired@example.com", Password: "x"}
+		require.NoError(t, f.db.Create(&u).Error)
+		past := time.Now().Add(-time.Minute)
+		require.NoError(t, f.db.Create(&usermodel.UserRole{UserID: u.ID, RoleID: f.roleID, ExpiresAt: &past}).Error)
+
+		p := principalFor(t, f, u.ID)
+		ok, err := e.Authorize(p, req)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("unexpired role assignment grants", func(t *testing.T) {
+		u := usermodel.User{Username: "temporary", Email: "temporary@example.com", Password: "x"}
+		require.NoError(t, f.db.Create(&u).Error)
+		future := time.Now().Add(time.Hour)
+		require.NoError(t, f.db.Create(&usermodel.UserRole{UserID: u.ID, RoleID: f.roleID, ExpiresAt: &future}).Error)
+
+		p := principalFor(t, f, u.ID)
+		ok, err := e.Authorize(p, req)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
 }
 
 func TestAuthorize_KindServer(t *testing.T) {
@@ -754,3 +781,78 @@ func TestAuthorize_KindServerAccess_RequiresStacksRead(t *testing.T) {
 		assert.True(t, ok)
 	})
 }
+
+func TestAuthorize_AccessRequestGrant(t *testing.T) {
+	f := seedFixture(t)
+	e := New(f.db, zap.NewNop())
+
+	grant := func(pattern string, status string, expiresAt time.Time) {
+		require.NoError(t, f.db.Create(&accessrequests.AccessRequest{
+			UserID:          f.userID,
+			Username:        "eng1",
+			ServerID:        f.serverID,
+			StackPattern:    pattern,
+			Permission:      permnames.StacksManage,
+			DurationMinutes: 60,
+			Reason:          "test",
+			Status:          status,
+			ExpiresAt:       &expiresAt,
+		}).Error)
+	}
+	manage := func(stack string) authz.Requirement {
+		return authz.Requirement{Kind: authz.KindStack, ServerID: f.serverID, Stack: stack, Permission: permnames.StacksManage}
+	}
+
+	p := principalFor(t, f, f.userID)
+
+	ok, err := e.Authorize(p, manage("web-app"))
+	require.NoError(t, err)
+	require.False(t, ok, "no grant yet")
+
+	grant("web-*", accessrequests.StatusApproved, time.Now().Add(time.Hour))
+	grant("db-*", accessrequests.StatusApproved, time.Now().Add(-time.Minute))
+	grant("cache-*", accessrequests.StatusPending, time.Now().Add(time.Hour))
+
+	t.Run("active grant adds the permission on matching stacks", func(t *testing.T) {
+		ok, err := e.Authorize(p, manage("web-app"))
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		perms, err := e.StackPermissions(p, f.serverID, "web-app")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{testPermName, permnames.StacksManage}, perms)
+
+		ok, err = e.HasServerPermission(p, f.serverID, permnames.StacksManage)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("grant does not reach other stacks", func(t *testing.T) {
+		ok, err := e.Authorize(p, manage("api"))
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("lapsed grant denies before the worker expires it", func(t *testing.T) {
+		ok, err := e.Authorize(p, manage("db-main"))
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("pending request grants nothing", func(t *testing.T) {
+		ok, err := e.Authorize(p, manage("cache-redis"))
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("grant does not bypass the stacks.read prerequisite", func(t *testing.T) {
+		require.NoError(t, f.db.Create(&accessrequests.AccessRequest{
+			UserID: f.noRoleUserID, Username: "norole", ServerID: f.serverID, StackPattern: "*",
+			Permission: permnames.StacksManage, DurationMinutes: 60, Reason: "test",
+			Status: accessrequests.StatusApproved, ExpiresAt: ptr(time.Now().Add(time.Hour)),
+		}).Error)
+		ok, err := e.Authorize(principalFor(t, f, f.noRoleUserID), manage("web-app"))
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
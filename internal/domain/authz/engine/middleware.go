@@ -25,6 +25,10 @@ func (e *Engine) Middleware(rule authz.Rule) echo.MiddlewareFunc {
 			if !ok {
 				return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
 			}
+			if p.EnrollmentOnly() && !rule.AllowsEnrollment() {
+				e.auditDenied(c, p, "second factor enrollment required")
+				return echo.NewHTTPError(http.StatusForbidden, "Two-factor enrollment required")
+			}
 			if rule.DeniesAPIKey() && p.Key() != nil {
 				e.auditDenied(c, p, "api key forbidden on this endpoint")
 				return echo.NewHTTPError(http.StatusForbidden, "API keys cannot access this endpoint")
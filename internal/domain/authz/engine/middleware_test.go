@@ -180,6 +180,26 @@ func TestMiddleware_PermissionDenial_IsAudited(t *testing.T) {
 	assert.Contains(t, call.permission, testPermName, "the denied permission must be recorded")
 }
 
+func TestMiddleware_EnrollmentOnly_DeniedUnlessRuleAllows(t *testing.T) {
+	f := seedFixture(t)
+	engine := New(f.db, zap.NewNop())
+
+	var u usermodel.User
+	require.NoError(t, f.db.Preload("Roles").First(&u, f.userID).Error)
+
+	c := newMiddlewareCtx(t, nil)
+	authz.SetPrincipal(c, principalForRoles(u.ID, u.Roles).WithEnrollmentOnly())
+	ran, err := runMiddleware(t, engine, authz.Authenticated(), c)
+	assert.False(t, ran)
+	assert.Equal(t, http.StatusForbidden, httpStatus(err))
+
+	c = newMiddlewareCtx(t, nil)
+	authz.SetPrincipal(c, principalForRoles(u.ID, u.Roles).WithEnrollmentOnly())
+	ran, err = runMiddleware(t, engine, authz.APIKeyDenied().AllowEnrollment(), c)
+	require.NoError(t, err)
+	assert.True(t, ran)
+}
+
 func TestMiddleware_APIKeyForbiddenDenial_IsAudited(t *testing.T) {
 	f := seedFixture(t)
 	engine := New(f.db, zap.NewNop())
@@ -66,6 +66,7 @@ func (e *Engine) computeRoleScope(p authz.Principal) ([]uint, map[uint][]string,
 	err := e.db.Preload("Permission").
 		Joins("JOIN user_roles ON user_roles.role_id = server_role_stack_permissions.role_id").
 		Where("user_roles.user_id = ?", userID).
+		Scopes(usermodel.ActiveRoleAssignments).
 		Find(&srsps).Error
 	if err != nil {
 		return nil, nil, err
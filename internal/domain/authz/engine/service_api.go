@@ -14,7 +14,7 @@ import (
 
 func (e *Engine) PrincipalForUser(userID uint) (authz.Principal, error) {
 	var user usermodel.User
-	if err := e.db.Preload("Roles").First(&user, userID).Error; err != nil {
+	if err := usermodel.FirstWithActiveRoles(e.db, &user, userID); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return authz.Principal{}, errors.New("user not found")
 		}
@@ -98,6 +98,7 @@ func (e *Engine) checkUserAnyServerGrant(p authz.Principal, serverID uint) (bool
 	err := e.db.Model(&usermodel.ServerRoleStackPermission{}).
 		Joins("JOIN user_roles ON user_roles.role_id = server_role_stack_permissions.role_id").
 		Where("user_roles.user_id = ? AND server_role_stack_permissions.server_id = ?", p.UserID(), serverID).
+		Scopes(usermodel.ActiveRoleAssignments).
 		Count(&count).Error
 	if err != nil {
 		return false, err
@@ -118,17 +119,28 @@ func (e *Engine) StackPermissions(p authz.Principal, serverID uint, stackname st
 		err := e.db.Preload("Permission").
 			Joins("JOIN user_roles ON user_roles.role_id = server_role_stack_permissions.role_id").
 			Where("user_roles.user_id = ? AND server_role_stack_permissions.server_id = ?", p.UserID(), serverID).
+			Scopes(usermodel.ActiveRoleAssignments).
 			Find(&srsps).Error
 		if err != nil {
 			return nil, err
 		}
 
+		grants, err := e.accessGrants(p.UserID(), serverID)
+		if err != nil {
+			return nil, err
+		}
+
 		permissionSet := make(map[string]bool)
 		for _, srsp := range srsps {
 			if patterns.Matches(stackname, srsp.StackPattern) {
 				permissionSet[srsp.Permission.Name] = true
 			}
 		}
+		for _, grant := range grants {
+			if patterns.Matches(stackname, grant.StackPattern) {
+				permissionSet[grant.Permission] = true
+			}
+		}
 		rolePermissions = make([]string, 0, len(permissionSet))
 		for permission := range permissionSet {
 			rolePermissions = append(rolePermissions, permission)
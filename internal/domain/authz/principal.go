@@ -14,16 +14,20 @@ type KeyScope struct {
 	Permission   string
 }
 
+// KeyDescriptor describes the API key a principal authenticated with.
+// RateLimit is the key's own request limit, or zero for the configured one.
 type KeyDescriptor struct {
-	ID     uint
-	Scopes []KeyScope
+	ID        uint
+	Scopes    []KeyScope
+	RateLimit int
 }
 
 type Principal struct {
-	userID  uint
-	isAdmin bool
-	key     *KeyDescriptor
-	system  bool
+	userID         uint
+	isAdmin        bool
+	key            *KeyDescriptor
+	system         bool
+	enrollmentOnly bool
 }
 
 func NewPrincipal(userID uint, isAdmin bool, key *KeyDescriptor) Principal {
@@ -37,6 +41,15 @@ func (p Principal) IsAdmin() bool       { return p.isAdmin }
 func (p Principal) Key() *KeyDescriptor { return p.key }
 func (p Principal) IsSystem() bool      { return p.system }
 
+// EnrollmentOnly reports whether the principal authenticated with a restricted
+// token that may only reach routes allowing second-factor enrollment.
+func (p Principal) EnrollmentOnly() bool { return p.enrollmentOnly }
+
+func (p Principal) WithEnrollmentOnly() Principal {
+	p.enrollmentOnly = true
+	return p
+}
+
 func (p Principal) IsAuthenticated() bool { return p.system || p.userID != 0 }
 
 func SetPrincipal(c echo.Context, p Principal) {
@@ -0,0 +1,37 @@
+package authz
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RateLimitKeyByUser keys a rate limit on the authenticated user. Requests
+// made with an API key return an empty key and are left to
+// RateLimitKeyByAPIKey.
+func RateLimitKeyByUser(c echo.Context) string {
+	p, ok := PrincipalFromEcho(c)
+	if !ok || p.IsSystem() || p.Key() != nil {
+		return ""
+	}
+	return "user:" + strconv.FormatUint(uint64(p.UserID()), 10)
+}
+
+// RateLimitKeyByAPIKey keys a rate limit on the API key used for the request,
+// so that every key has its own budget regardless of owner or address.
+func RateLimitKeyByAPIKey(c echo.Context) string {
+	p, ok := PrincipalFromEcho(c)
+	if !ok || p.Key() == nil {
+		return ""
+	}
+	return "key:" + strconv.FormatUint(uint64(p.Key().ID), 10)
+}
+
+// APIKeyRateLimit returns the request's API key override, or zero.
+func APIKeyRateLimit(c echo.Context) int {
+	p, ok := PrincipalFromEcho(c)
+	if !ok || p.Key() == nil {
+		return 0
+	}
+	return p.Key().RateLimit
+}
@@ -0,0 +1,47 @@
+package authz
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRateLimitKeys(t *testing.T) {
+	e := echo.New()
+	newCtx := func(p *Principal) echo.Context {
+		c := e.NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+		if p != nil {
+			SetPrincipal(c, *p)
+		}
+		return c
+	}
+
+	user := NewPrincipal(7, false, nil)
+	keyed := NewPrincipal(7, false, &KeyDescriptor{ID: 9, RateLimit: 50})
+
+	cases := []struct {
+		name     string
+		p        *Principal
+		byUser   string
+		byAPIKey string
+		rate     int
+	}{
+		{"anonymous", nil, "", "", 0},
+		{"system", &SystemPrincipal, "", "", 0},
+		{"user", &user, "user:7", "", 0},
+		{"api key", &keyed, "", "key:9", 50},
+	}
+	for _, tc := range cases {
+		c := newCtx(tc.p)
+		if got := RateLimitKeyByUser(c); got != tc.byUser {
+			t.Errorf("%s: RateLimitKeyByUser = %q, want %q", tc.name, got, tc.byUser)
+		}
+		if got := RateLimitKeyByAPIKey(c); got != tc.byAPIKey {
+			t.Errorf("%s: RateLimitKeyByAPIKey = %q, want %q", tc.name, got, tc.byAPIKey)
+		}
+		if got := APIKeyRateLimit(c); got != tc.rate {
+			t.Errorf("%s: APIKeyRateLimit = %d, want %d", tc.name, got, tc.rate)
+		}
+	}
+}
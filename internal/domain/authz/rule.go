@@ -32,6 +32,7 @@ type Rule struct {
 	public      bool
 	denyAPIKey  bool
 	listScope   bool
+	enrollment  bool
 	customFn    func(echo.Context) ([]Requirement, error)
 }
 
@@ -50,9 +51,10 @@ func (r Rule) Resolve(c echo.Context) ([]Requirement, error) {
 	return reqs, nil
 }
 
-func (r Rule) IsPublic() bool       { return r.public }
-func (r Rule) DeniesAPIKey() bool   { return r.denyAPIKey }
-func (r Rule) WantsListScope() bool { return r.listScope }
+func (r Rule) IsPublic() bool         { return r.public }
+func (r Rule) DeniesAPIKey() bool     { return r.denyAPIKey }
+func (r Rule) WantsListScope() bool   { return r.listScope }
+func (r Rule) AllowsEnrollment() bool { return r.enrollment }
 
 func (r Rule) resolveBase(c echo.Context) ([]Requirement, error) {
 	switch r.kind {
@@ -122,6 +124,13 @@ func (r Rule) WithListScope() Rule {
 	return r
 }
 
+// AllowEnrollment lets principals holding only a second-factor enrollment
+// token through, for the endpoints needed to finish enrolling.
+func (r Rule) AllowEnrollment() Rule {
+	r.enrollment = true
+	return r
+}
+
 func Stack(perm string) Rule {
 	r := newRule(ruleStack)
 	r.perm = perm
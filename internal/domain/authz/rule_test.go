@@ -79,6 +79,16 @@ func TestWithListScope_setsFlag(t *testing.T) {
 	}
 }
 
+func TestAllowEnrollment_setsFlag(t *testing.T) {
+	if APIKeyDenied().AllowsEnrollment() {
+		t.Fatal("enrollment unexpectedly allowed by default")
+	}
+	r := APIKeyDenied().AllowEnrollment()
+	if !r.AllowsEnrollment() || !r.DeniesAPIKey() {
+		t.Fatal("AllowEnrollment must set its flag and keep the others")
+	}
+}
+
 func TestWithListScope_composesWithServer(t *testing.T) {
 	c := newParamCtx(t, []string{"serverid"}, []string{"5"})
 	r := Server("stacks.read").WithListScope()
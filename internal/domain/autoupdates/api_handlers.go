@@ -0,0 +1,155 @@
+package autoupdates
+
+import (
+	"errors"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/security"
+	"berth/internal/domain/session"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type policyAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+type APIHandler struct {
+	service      *Service
+	auditService policyAuditLogger
+}
+
+func NewAPIHandler(service *Service, auditService policyAuditLogger) *APIHandler {
+	return &APIHandler{
+		service:      service,
+		auditService: auditService,
+	}
+}
+
+func (h *APIHandler) ListPolicies(c echo.Context) error {
+	serverID, stackName, err := echoparams.GetServerIDAndStackName(c)
+	if err != nil {
+		return err
+	}
+
+	policies, err := h.service.ListPolicies(serverID, stackName)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch image update policies")
+	}
+
+	return response.OK(c, ListPoliciesData{
+		Policies: policies,
+	})
+}
+
+func (h *APIHandler) SetPolicy(c echo.Context) error {
+	serverID, stackName, err := echoparams.GetServerIDAndStackName(c)
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req SetPolicyRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	policy, err := h.service.SetPolicy(serverID, stackName, req, p.UserID())
+	if err != nil {
+		return response.Internal(c, "Failed to save image update policy")
+	}
+
+	h.audit(c, p, security.EventStackUpdatePolicyUpdated, policy)
+
+	return response.OK(c, GetPolicyData{
+		Policy: *policy,
+	})
+}
+
+func (h *APIHandler) DeletePolicy(c echo.Context) error {
+	serverID, stackName, err := echoparams.GetServerIDAndStackName(c)
+	if err != nil {
+		return err
+	}
+
+	policyID, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	policy, err := h.service.GetPolicy(serverID, stackName, policyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Image update policy not found")
+		}
+		return response.Internal(c, "Failed to fetch image update policy")
+	}
+
+	if err := h.service.DeletePolicy(serverID, stackName, policy.ID); err != nil {
+		return response.Internal(c, "Failed to delete image update policy")
+	}
+
+	h.audit(c, p, security.EventStackUpdatePolicyDeleted, policy)
+
+	return response.OK(c, DeletePolicyMessageData{
+		Message: "Image update policy deleted successfully",
+	})
+}
+
+func (h *APIHandler) ListHistory(c echo.Context) error {
+	serverID, stackName, err := echoparams.GetServerIDAndStackName(c)
+	if err != nil {
+		return err
+	}
+
+	history, err := h.service.ListHistory(serverID, stackName)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch image update history")
+	}
+
+	return response.OK(c, ListHistoryData{
+		History: history,
+	})
+}
+
+func (h *APIHandler) audit(c echo.Context, p authz.Principal, eventType string, policy *UpdatePolicy) {
+	actorID := p.UserID()
+	policyID := policy.ID
+	serverID := policy.ServerID
+	targetName := policy.StackName
+	if policy.ServiceName != "" {
+		targetName += "/" + policy.ServiceName
+	}
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      eventType,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetType:     security.TargetTypeUpdatePolicy,
+		TargetID:       &policyID,
+		TargetName:     targetName,
+		Success:        true,
+		Metadata: map[string]any{
+			"service_name":            policy.ServiceName,
+			"mode":                    policy.Mode,
+			"window_cron":             policy.WindowCron,
+			"window_duration_minutes": policy.WindowDurationMinutes,
+		},
+		ServerID:  &serverID,
+		StackName: policy.StackName,
+	})
+}
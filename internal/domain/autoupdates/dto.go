@@ -0,0 +1,68 @@
+package autoupdates
+
+import (
+	"errors"
+	"strings"
+
+	"berth/internal/pkg/cronspec"
+)
+
+// maxWindowMinutes caps a maintenance window at one week, the longest gap
+// between firings of a weekly cron expression.
+const maxWindowMinutes = 7 * 24 * 60
+
+var (
+	ErrModeInvalid         = errors.New("mode must be one of notify, auto or window")
+	ErrServiceNameInvalid  = errors.New("service_name must not contain whitespace")
+	ErrWindowRequired      = errors.New("window mode requires window_cron and window_duration_minutes")
+	ErrWindowNotAllowed    = errors.New("window_cron and window_duration_minutes are only valid in window mode")
+	ErrWindowDurationRange = errors.New("window_duration_minutes must be between 1 and 10080")
+)
+
+type SetPolicyRequest struct {
+	ServiceName           string `json:"service_name,omitempty"`
+	Mode                  string `json:"mode"`
+	WindowCron            string `json:"window_cron,omitempty"`
+	WindowDurationMinutes int    `json:"window_duration_minutes,omitempty"`
+}
+
+func (r *SetPolicyRequest) Validate() error {
+	if strings.ContainsAny(r.ServiceName, " \t\r\n") {
+		return ErrServiceNameInvalid
+	}
+	switch r.Mode {
+	case ModeNotify, ModeAuto:
+		if r.WindowCron != "" || r.WindowDurationMinutes != 0 {
+			return ErrWindowNotAllowed
+		}
+	case ModeWindow:
+		if r.WindowCron == "" || r.WindowDurationMinutes == 0 {
+			return ErrWindowRequired
+		}
+		if r.WindowDurationMinutes < 1 || r.WindowDurationMinutes > maxWindowMinutes {
+			return ErrWindowDurationRange
+		}
+		if err := cronspec.Validate(r.WindowCron); err != nil {
+			return err
+		}
+	default:
+		return ErrModeInvalid
+	}
+	return nil
+}
+
+type ListPoliciesData struct {
+	Policies []UpdatePolicy `json:"policies"`
+}
+
+type GetPolicyData struct {
+	Policy UpdatePolicy `json:"policy"`
+}
+
+type ListHistoryData struct {
+	History []UpdateHistory `json:"history"`
+}
+
+type DeletePolicyMessageData struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,43 @@
+package autoupdates
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetPolicyRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     SetPolicyRequest
+		wantErr error
+		anyErr  bool
+	}{
+		{"empty", SetPolicyRequest{}, ErrModeInvalid, false},
+		{"unknown mode", SetPolicyRequest{Mode: "always"}, ErrModeInvalid, false},
+		{"notify", SetPolicyRequest{Mode: ModeNotify}, nil, false},
+		{"auto for service", SetPolicyRequest{Mode: ModeAuto, ServiceName: "web"}, nil, false},
+		{"service with space", SetPolicyRequest{Mode: ModeAuto, ServiceName: "web app"}, ErrServiceNameInvalid, false},
+		{"auto with window", SetPolicyRequest{Mode: ModeAuto, WindowCron: "0 2 * * *"}, ErrWindowNotAllowed, false},
+		{"window", SetPolicyRequest{Mode: ModeWindow, WindowCron: "0 2 * * 0", WindowDurationMinutes: 120}, nil, false},
+		{"window without cron", SetPolicyRequest{Mode: ModeWindow, WindowDurationMinutes: 60}, ErrWindowRequired, false},
+		{"window without duration", SetPolicyRequest{Mode: ModeWindow, WindowCron: "0 2 * * *"}, ErrWindowRequired, false},
+		{"window too long", SetPolicyRequest{Mode: ModeWindow, WindowCron: "0 2 * * *", WindowDurationMinutes: 20000}, ErrWindowDurationRange, false},
+		{"window negative", SetPolicyRequest{Mode: ModeWindow, WindowCron: "0 2 * * *", WindowDurationMinutes: -5}, ErrWindowDurationRange, false},
+		{"window invalid cron", SetPolicyRequest{Mode: ModeWindow, WindowCron: "nightly", WindowDurationMinutes: 60}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if tt.anyErr {
+				if got == nil {
+					t.Errorf("Validate() = nil, want error")
+				}
+				return
+			}
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
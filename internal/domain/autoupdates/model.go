@@ -0,0 +1,68 @@
+package autoupdates
+
+import (
+	"time"
+
+	"berth/internal/platform/db"
+)
+
+const (
+	ModeNotify = "notify"
+	ModeAuto   = "auto"
+	ModeWindow = "window"
+)
+
+const (
+	StatusPulling   = "pulling"
+	StatusDeploying = "deploying"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// UpdatePolicy decides what happens when a newer image is found for a stack.
+// A policy with an empty ServiceName is the stack default; a policy naming a
+// service overrides it for that service. Stacks without a policy are
+// notify-only.
+type UpdatePolicy struct {
+	db.BaseModel
+	ServerID              uint   `json:"server_id" gorm:"not null;index:idx_update_policy_target"`
+	StackName             string `json:"stack_name" gorm:"not null;index:idx_update_policy_target"`
+	ServiceName           string `json:"service_name" gorm:"not null;default:'';index:idx_update_policy_target"`
+	Mode                  string `json:"mode" gorm:"not null"`
+	WindowCron            string `json:"window_cron,omitempty"`
+	WindowDurationMinutes int    `json:"window_duration_minutes,omitempty" gorm:"not null;default:0"`
+	CreatedByUserID       *uint  `json:"created_by_user_id,omitempty"`
+}
+
+func (UpdatePolicy) TableName() string {
+	return "image_update_policies"
+}
+
+// UpdateHistory records one container moving from OldDigest to NewDigest.
+// Containers updated together share the pull and up operations, which are
+// tracked by operation ID; OperationLogID links the entry to the output of
+// the up that applied it.
+type UpdateHistory struct {
+	ID                 uint       `json:"id" gorm:"primarykey"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	PolicyID           uint       `json:"policy_id" gorm:"not null;index"`
+	ServerID           uint       `json:"server_id" gorm:"not null;index:idx_update_history_target"`
+	StackName          string     `json:"stack_name" gorm:"not null;index:idx_update_history_target"`
+	ServiceName        string     `json:"service_name" gorm:"not null"`
+	ContainerName      string     `json:"container_name" gorm:"not null"`
+	ImageName          string     `json:"image_name" gorm:"not null"`
+	OldDigest          string     `json:"old_digest" gorm:"type:text"`
+	NewDigest          string     `json:"new_digest" gorm:"type:text"`
+	Status             string     `json:"status" gorm:"not null;index"`
+	Message            string     `json:"message,omitempty" gorm:"type:text"`
+	PullOperationID    string     `json:"pull_operation_id,omitempty" gorm:"index"`
+	PullOperationLogID *uint      `json:"pull_operation_log_id,omitempty" gorm:"index"`
+	OperationID        string     `json:"operation_id,omitempty" gorm:"index"`
+	OperationLogID     *uint      `json:"operation_log_id,omitempty" gorm:"index"`
+	FinishedAt         *time.Time `json:"finished_at,omitempty"`
+}
+
+func (UpdateHistory) TableName() string {
+	return "image_update_history"
+}
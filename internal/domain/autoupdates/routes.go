@@ -0,0 +1,13 @@
+package autoupdates
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
+
+func (h *APIHandler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/servers/:serverid/stacks/:stackname/update-policies", h.ListPolicies, authz.Stack(permnames.StacksRead))
+	reg.PUT("/servers/:serverid/stacks/:stackname/update-policies", h.SetPolicy, authz.Stack(permnames.StacksManage))
+	reg.DELETE("/servers/:serverid/stacks/:stackname/update-policies/:id", h.DeletePolicy, authz.Stack(permnames.StacksManage))
+	reg.GET("/servers/:serverid/stacks/:stackname/update-history", h.ListHistory, authz.Stack(permnames.StacksRead))
+}
@@ -0,0 +1,527 @@
+package autoupdates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/imageupdates"
+	"berth/internal/domain/maintwindows"
+	"berth/internal/domain/operationlogs"
+	"berth/internal/domain/operations"
+	"berth/internal/domain/stack"
+	"berth/internal/pkg/cronspec"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	historyLimit = 100
+
+	// staleAfter bounds how long an update may sit in pulling or deploying
+	// without its operation reporting back before the stack is unblocked.
+	staleAfter = 2 * time.Hour
+)
+
+type availableUpdateSource interface {
+	GetAvailableUpdates() ([]imageupdates.ContainerImageUpdate, error)
+}
+
+type stackDetailsProvider interface {
+	GetStackDetails(ctx context.Context, p authz.Principal, serverID uint, stackname string) (*stack.StackDetails, error)
+}
+
+type updateOperationRunner interface {
+	StartOperation(ctx context.Context, p authz.Principal, serverID uint, stackname string, req operations.OperationRequest) (*operations.OperationStartData, error)
+	RecordStartAndPersist(p authz.Principal, source operationlogs.TriggerSource, serverID uint, stackname string, operationID string, req operations.OperationRequest, startTime time.Time)
+}
+
+type updateOperationLogFinder interface {
+	FindOperationLogByOperationID(operationID string) (*operationlogs.OperationLog, error)
+}
+
+type updateWindowChecker interface {
+	StatusAt(serverID uint, stackName string, at time.Time) (*maintwindows.Status, error)
+}
+
+type Service struct {
+	db        *gorm.DB
+	updateSrc availableUpdateSource
+	stackSvc  stackDetailsProvider
+	opsSvc    updateOperationRunner
+	logFinder updateOperationLogFinder
+	windowSvc updateWindowChecker
+	logger    *zap.Logger
+	now       func() time.Time
+}
+
+func NewService(db *gorm.DB, updateSrc availableUpdateSource, stackSvc stackDetailsProvider, opsSvc updateOperationRunner, logFinder updateOperationLogFinder, windowSvc updateWindowChecker, logger *zap.Logger) *Service {
+	return &Service{
+		db:        db,
+		updateSrc: updateSrc,
+		stackSvc:  stackSvc,
+		opsSvc:    opsSvc,
+		logFinder: logFinder,
+		windowSvc: windowSvc,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+func (s *Service) Logger() *zap.Logger {
+	return s.logger
+}
+
+func (s *Service) ListPolicies(serverID uint, stackName string) ([]UpdatePolicy, error) {
+	var policies []UpdatePolicy
+	if err := s.db.Where("server_id = ? AND stack_name = ?", serverID, stackName).
+		Order("service_name").
+		Find(&policies).Error; err != nil {
+		s.logger.Error("failed to list image update policies",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_name", stackName),
+		)
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (s *Service) GetPolicy(serverID uint, stackName string, policyID uint) (*UpdatePolicy, error) {
+	var policy UpdatePolicy
+	if err := s.db.Where("server_id = ? AND stack_name = ?", serverID, stackName).First(&policy, policyID).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SetPolicy creates or replaces the policy for the stack, or for one of its
+// services when req.ServiceName is set.
+func (s *Service) SetPolicy(serverID uint, stackName string, req SetPolicyRequest, userID uint) (*UpdatePolicy, error) {
+	var policy UpdatePolicy
+	err := s.db.Where("server_id = ? AND stack_name = ? AND service_name = ?", serverID, stackName, req.ServiceName).
+		First(&policy).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	policy.ServerID = serverID
+	policy.StackName = stackName
+	policy.ServiceName = req.ServiceName
+	policy.Mode = req.Mode
+	policy.WindowCron = strings.TrimSpace(req.WindowCron)
+	policy.WindowDurationMinutes = req.WindowDurationMinutes
+	if userID != 0 {
+		policy.CreatedByUserID = &userID
+	}
+
+	if err := s.db.Save(&policy).Error; err != nil {
+		s.logger.Error("failed to save image update policy",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_name", stackName),
+			zap.String("service_name", req.ServiceName),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("image update policy saved",
+		zap.Uint("policy_id", policy.ID),
+		zap.Uint("server_id", serverID),
+		zap.String("stack_name", stackName),
+		zap.String("service_name", policy.ServiceName),
+		zap.String("mode", policy.Mode),
+	)
+
+	return &policy, nil
+}
+
+func (s *Service) DeletePolicy(serverID uint, stackName string, policyID uint) error {
+	policy, err := s.GetPolicy(serverID, stackName, policyID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(policy).Error; err != nil {
+		s.logger.Error("failed to delete image update policy",
+			zap.Error(err),
+			zap.Uint("policy_id", policyID),
+		)
+		return err
+	}
+
+	s.logger.Info("image update policy deleted",
+		zap.Uint("policy_id", policyID),
+		zap.Uint("server_id", serverID),
+		zap.String("stack_name", stackName),
+	)
+
+	return nil
+}
+
+func (s *Service) ListHistory(serverID uint, stackName string) ([]UpdateHistory, error) {
+	var history []UpdateHistory
+	if err := s.db.Where("server_id = ? AND stack_name = ?", serverID, stackName).
+		Order("id DESC").
+		Limit(historyLimit).
+		Find(&history).Error; err != nil {
+		s.logger.Error("failed to list image update history",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_name", stackName),
+		)
+		return nil, err
+	}
+	return history, nil
+}
+
+type stackKey struct {
+	serverID  uint
+	stackName string
+}
+
+// RunPendingUpdates applies available image updates to every stack whose
+// policy allows it right now. Each container is attempted at most once per
+// new digest; failures are recorded in the history rather than retried.
+func (s *Service) RunPendingUpdates(ctx context.Context) error {
+	now := s.now()
+
+	if err := s.expireStaleUpdates(now); err != nil {
+		return err
+	}
+
+	updates, err := s.updateSrc.GetAvailableUpdates()
+	if err != nil {
+		return fmt.Errorf("failed to load available image updates: %w", err)
+	}
+
+	var order []stackKey
+	byStack := make(map[stackKey][]imageupdates.ContainerImageUpdate)
+	for _, update := range updates {
+		if update.CheckError != "" || update.LatestRepoDigest == "" {
+			continue
+		}
+		key := stackKey{serverID: update.ServerID, stackName: update.StackName}
+		if _, ok := byStack[key]; !ok {
+			order = append(order, key)
+		}
+		byStack[key] = append(byStack[key], update)
+	}
+
+	for _, key := range order {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.updateStack(ctx, key.serverID, key.stackName, byStack[key], now)
+	}
+
+	return nil
+}
+
+func (s *Service) updateStack(ctx context.Context, serverID uint, stackName string, pending []imageupdates.ContainerImageUpdate, now time.Time) {
+	policies, err := s.ListPolicies(serverID, stackName)
+	if err != nil || !anyPolicyActive(policies, now) {
+		return
+	}
+
+	pending, err = s.withoutAttempted(serverID, stackName, pending)
+	if err != nil || len(pending) == 0 {
+		return
+	}
+
+	var inFlight int64
+	if err := s.db.Model(&UpdateHistory{}).
+		Where("server_id = ? AND stack_name = ? AND status IN ?", serverID, stackName, []string{StatusPulling, StatusDeploying}).
+		Count(&inFlight).Error; err != nil || inFlight > 0 {
+		return
+	}
+
+	// Updates wait for the stack's maintenance window rather than being
+	// refused by the operation gate and recorded as failures.
+	window, err := s.windowSvc.StatusAt(serverID, stackName, now)
+	if err != nil {
+		s.logger.Error("failed to check maintenance window for image update",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_name", stackName),
+		)
+		return
+	}
+	if !window.Open {
+		return
+	}
+
+	details, err := s.stackSvc.GetStackDetails(ctx, authz.SystemPrincipal, serverID, stackName)
+	if err != nil {
+		s.logger.Warn("failed to resolve stack services for image update",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_name", stackName),
+		)
+		return
+	}
+	serviceOf := make(map[string]string)
+	for _, svc := range details.Services {
+		for _, ctr := range svc.Containers {
+			serviceOf[ctr.Name] = svc.Name
+		}
+	}
+
+	var entries []UpdateHistory
+	var services []string
+	for _, update := range pending {
+		service, ok := serviceOf[update.ContainerName]
+		if !ok {
+			continue
+		}
+		policy := resolvePolicy(policies, service)
+		if policy == nil || !policyAllows(policy, now) {
+			continue
+		}
+		entries = append(entries, UpdateHistory{
+			PolicyID:      policy.ID,
+			ServerID:      serverID,
+			StackName:     stackName,
+			ServiceName:   service,
+			ContainerName: update.ContainerName,
+			ImageName:     update.CurrentImageName,
+			OldDigest:     update.CurrentRepoDigest,
+			NewDigest:     update.LatestRepoDigest,
+			Status:        StatusPulling,
+		})
+		if !slices.Contains(services, service) {
+			services = append(services, service)
+		}
+	}
+	if len(entries) == 0 {
+		return
+	}
+	slices.Sort(services)
+
+	if err := s.db.Create(&entries).Error; err != nil {
+		s.logger.Error("failed to record image update history",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_name", stackName),
+		)
+		return
+	}
+
+	opID, err := s.startOperation(ctx, entries, "pull", services, now, operationLink{
+		idColumn:    "pull_operation_id",
+		logIDColumn: "pull_operation_log_id",
+	})
+	if err != nil {
+		s.finish(entries, StatusFailed, fmt.Sprintf("pull failed to start: %v", err))
+		return
+	}
+
+	s.logger.Info("automatic image update started",
+		zap.Uint("server_id", serverID),
+		zap.String("stack_name", stackName),
+		zap.Strings("services", services),
+		zap.String("operation_id", opID),
+	)
+}
+
+// OnOperationEnd advances automatic updates: a finished pull starts the up
+// for the same services, and a finished up completes the history entries.
+func (s *Service) OnOperationEnd(log *operationlogs.OperationLog) {
+	var entries []UpdateHistory
+	if err := s.db.Where("status IN ? AND (pull_operation_id = ? OR operation_id = ?)",
+		[]string{StatusPulling, StatusDeploying}, log.OperationID, log.OperationID).
+		Find(&entries).Error; err != nil || len(entries) == 0 {
+		return
+	}
+
+	success := log.Success != nil && *log.Success
+	switch {
+	case entries[0].Status == StatusDeploying && success:
+		s.finish(entries, StatusSucceeded, "")
+	case entries[0].Status == StatusDeploying:
+		s.finish(entries, StatusFailed, "up failed; see the operation log")
+	case !success:
+		s.finish(entries, StatusFailed, "pull failed; see the operation log")
+	default:
+		go s.deploy(entries)
+	}
+}
+
+func (s *Service) deploy(entries []UpdateHistory) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	var services []string
+	for _, e := range entries {
+		if !slices.Contains(services, e.ServiceName) {
+			services = append(services, e.ServiceName)
+		}
+	}
+	slices.Sort(services)
+
+	_, err := s.startOperation(ctx, entries, "up", services, s.now(), operationLink{
+		idColumn:    "operation_id",
+		logIDColumn: "operation_log_id",
+		status:      StatusDeploying,
+	})
+	if err != nil {
+		s.finish(entries, StatusFailed, fmt.Sprintf("up failed to start: %v", err))
+	}
+}
+
+// operationLink names the history columns an operation is recorded in, and
+// the status the entries move to once it has started.
+type operationLink struct {
+	idColumn    string
+	logIDColumn string
+	status      string
+}
+
+// startOperation starts command for the entries' services and links them to
+// the operation before its output is persisted, so OnOperationEnd finds the
+// entries however quickly the operation finishes.
+func (s *Service) startOperation(ctx context.Context, entries []UpdateHistory, command string, services []string, now time.Time, link operationLink) (string, error) {
+	serverID, stackName := entries[0].ServerID, entries[0].StackName
+	req := operations.OperationRequest{
+		Command:  command,
+		Services: services,
+	}
+
+	resp, err := s.opsSvc.StartOperation(ctx, authz.SystemPrincipal, serverID, stackName, req)
+	if err != nil {
+		s.logger.Error("automatic image update operation failed to start",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_name", stackName),
+			zap.String("command", command),
+		)
+		return "", err
+	}
+
+	started := map[string]any{link.idColumn: resp.OperationID}
+	if link.status != "" {
+		started["status"] = link.status
+	}
+	s.link(entries, started)
+
+	s.opsSvc.RecordStartAndPersist(authz.SystemPrincipal, operationlogs.TriggerSourceScheduled, serverID, stackName, resp.OperationID, req, now)
+
+	if log, err := s.logFinder.FindOperationLogByOperationID(resp.OperationID); err == nil {
+		s.link(entries, map[string]any{link.logIDColumn: log.ID})
+	}
+	return resp.OperationID, nil
+}
+
+// withoutAttempted drops containers that already have a history entry for
+// their latest digest.
+func (s *Service) withoutAttempted(serverID uint, stackName string, pending []imageupdates.ContainerImageUpdate) ([]imageupdates.ContainerImageUpdate, error) {
+	var attempted []UpdateHistory
+	if err := s.db.Select("container_name", "new_digest").
+		Where("server_id = ? AND stack_name = ?", serverID, stackName).
+		Find(&attempted).Error; err != nil {
+		s.logger.Error("failed to load image update history",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_name", stackName),
+		)
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(attempted))
+	for _, h := range attempted {
+		seen[h.ContainerName+"|"+h.NewDigest] = true
+	}
+
+	var remaining []imageupdates.ContainerImageUpdate
+	for _, update := range pending {
+		if !seen[update.ContainerName+"|"+update.LatestRepoDigest] {
+			remaining = append(remaining, update)
+		}
+	}
+	return remaining, nil
+}
+
+func (s *Service) expireStaleUpdates(now time.Time) error {
+	err := s.db.Model(&UpdateHistory{}).
+		Where("status IN ? AND updated_at < ?", []string{StatusPulling, StatusDeploying}, now.Add(-staleAfter)).
+		Updates(map[string]any{
+			"status":      StatusFailed,
+			"message":     "no result was recorded for the update operation",
+			"finished_at": now,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to expire stale image updates: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) link(entries []UpdateHistory, updates map[string]any) {
+	if err := s.db.Model(&UpdateHistory{}).Where("id IN ?", historyIDs(entries)).Updates(updates).Error; err != nil {
+		s.logger.Error("failed to link image update operation",
+			zap.Error(err),
+			zap.Uint("server_id", entries[0].ServerID),
+			zap.String("stack_name", entries[0].StackName),
+		)
+	}
+}
+
+func (s *Service) finish(entries []UpdateHistory, status, message string) {
+	now := s.now()
+	s.link(entries, map[string]any{"status": status, "message": message, "finished_at": now})
+
+	s.logger.Info("automatic image update finished",
+		zap.Uint("server_id", entries[0].ServerID),
+		zap.String("stack_name", entries[0].StackName),
+		zap.String("status", status),
+		zap.Int("containers", len(entries)),
+	)
+}
+
+func historyIDs(entries []UpdateHistory) []uint {
+	ids := make([]uint, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+// resolvePolicy returns the service's own policy, falling back to the stack
+// default.
+func resolvePolicy(policies []UpdatePolicy, service string) *UpdatePolicy {
+	var fallback *UpdatePolicy
+	for i := range policies {
+		switch policies[i].ServiceName {
+		case service:
+			return &policies[i]
+		case "":
+			fallback = &policies[i]
+		}
+	}
+	return fallback
+}
+
+func anyPolicyActive(policies []UpdatePolicy, now time.Time) bool {
+	for i := range policies {
+		if policyAllows(&policies[i], now) {
+			return true
+		}
+	}
+	return false
+}
+
+func policyAllows(policy *UpdatePolicy, now time.Time) bool {
+	switch policy.Mode {
+	case ModeAuto:
+		return true
+	case ModeWindow:
+		open, err := cronspec.Within(policy.WindowCron, time.Duration(policy.WindowDurationMinutes)*time.Minute, now)
+		return err == nil && open
+	default:
+		return false
+	}
+}
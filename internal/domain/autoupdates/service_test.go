@@ -0,0 +1,363 @@
+package autoupdates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/imageupdates"
+	"berth/internal/domain/maintwindows"
+	"berth/internal/domain/operationlogs"
+	"berth/internal/domain/operations"
+	"berth/internal/domain/stack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type fakeUpdates struct {
+	updates []imageupdates.ContainerImageUpdate
+}
+
+func (f *fakeUpdates) GetAvailableUpdates() ([]imageupdates.ContainerImageUpdate, error) {
+	return f.updates, nil
+}
+
+type fakeStacks struct {
+	services map[string][]string
+}
+
+func (f *fakeStacks) GetStackDetails(_ context.Context, _ authz.Principal, _ uint, stackname string) (*stack.StackDetails, error) {
+	details := &stack.StackDetails{Name: stackname}
+	for service, containers := range f.services {
+		svc := stack.ComposeService{Name: service}
+		for _, name := range containers {
+			svc.Containers = append(svc.Containers, stack.Container{Name: name})
+		}
+		details.Services = append(details.Services, svc)
+	}
+	return details, nil
+}
+
+type startedOp struct {
+	principal authz.Principal
+	command   string
+	services  []string
+}
+
+type fakeOps struct {
+	mu      sync.Mutex
+	started []startedOp
+	logIDs  map[string]uint
+	noLogs  bool
+
+	// onPersist, when set, runs as output persistence starts, standing in
+	// for an operation that finishes before RecordStartAndPersist returns.
+	onPersist func(operationID string)
+}
+
+func (f *fakeOps) StartOperation(_ context.Context, p authz.Principal, _ uint, _ string, req operations.OperationRequest) (*operations.OperationStartData, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.started = append(f.started, startedOp{principal: p, command: req.Command, services: req.Services})
+	opID := fmt.Sprintf("op-%d", len(f.started))
+	f.logIDs[opID] = uint(len(f.started))
+	return &operations.OperationStartData{OperationID: opID}, nil
+}
+
+func (f *fakeOps) RecordStartAndPersist(_ authz.Principal, _ operationlogs.TriggerSource, _ uint, _ string, operationID string, _ operations.OperationRequest, _ time.Time) {
+	if f.onPersist != nil {
+		f.onPersist(operationID)
+	}
+}
+
+func (f *fakeOps) FindOperationLogByOperationID(operationID string) (*operationlogs.OperationLog, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id, ok := f.logIDs[operationID]
+	if !ok || f.noLogs {
+		return nil, gorm.ErrRecordNotFound
+	}
+	log := &operationlogs.OperationLog{OperationID: operationID}
+	log.ID = id
+	return log, nil
+}
+
+func (f *fakeOps) commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var cmds []string
+	for _, op := range f.started {
+		cmds = append(cmds, op.command)
+	}
+	return cmds
+}
+
+type fakeWindows struct {
+	closed bool
+	err    error
+}
+
+func (f *fakeWindows) StatusAt(uint, string, time.Time) (*maintwindows.Status, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &maintwindows.Status{Gated: f.closed, Open: !f.closed}, nil
+}
+
+func newTestService(t *testing.T, updates []imageupdates.ContainerImageUpdate, services map[string][]string) (*Service, *fakeOps) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:autoupdates_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&UpdatePolicy{}, &UpdateHistory{}))
+
+	ops := &fakeOps{logIDs: map[string]uint{}}
+	svc := NewService(db, &fakeUpdates{updates: updates}, &fakeStacks{services: services}, ops, ops, &fakeWindows{}, zap.NewNop())
+	return svc, ops
+}
+
+func pendingUpdate(container, oldDigest, newDigest string) imageupdates.ContainerImageUpdate {
+	return imageupdates.ContainerImageUpdate{
+		ServerID:          1,
+		StackName:         "app",
+		ContainerName:     container,
+		CurrentImageName:  "nginx:latest",
+		CurrentRepoDigest: oldDigest,
+		LatestRepoDigest:  newDigest,
+		UpdateAvailable:   true,
+	}
+}
+
+func endOperation(svc *Service, operationID string, success bool) {
+	svc.OnOperationEnd(&operationlogs.OperationLog{OperationID: operationID, Success: &success})
+}
+
+func TestRunPendingUpdates_PullsThenUpsAffectedServices(t *testing.T) {
+	svc, ops := newTestService(t,
+		[]imageupdates.ContainerImageUpdate{pendingUpdate("app-web-1", "sha256:old", "sha256:new")},
+		map[string][]string{"web": {"app-web-1"}, "db": {"app-db-1"}},
+	)
+	_, err := svc.SetPolicy(1, "app", SetPolicyRequest{Mode: ModeAuto}, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+
+	require.Len(t, ops.started, 1)
+	assert.Equal(t, "pull", ops.started[0].command)
+	assert.Equal(t, []string{"web"}, ops.started[0].services, "only services with an update are pulled")
+	assert.True(t, ops.started[0].principal.IsSystem())
+
+	history, err := svc.ListHistory(1, "app")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, StatusPulling, history[0].Status)
+	assert.Equal(t, "sha256:old", history[0].OldDigest)
+	assert.Equal(t, "sha256:new", history[0].NewDigest)
+	require.NotNil(t, history[0].PullOperationLogID)
+
+	endOperation(svc, history[0].PullOperationID, true)
+	require.Eventually(t, func() bool {
+		h, _ := svc.ListHistory(1, "app")
+		return h[0].Status == StatusDeploying
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"pull", "up"}, ops.commands())
+
+	history, err = svc.ListHistory(1, "app")
+	require.NoError(t, err)
+	require.NotNil(t, history[0].OperationLogID)
+
+	endOperation(svc, history[0].OperationID, true)
+	history, err = svc.ListHistory(1, "app")
+	require.NoError(t, err)
+	assert.Equal(t, StatusSucceeded, history[0].Status)
+	assert.NotNil(t, history[0].FinishedAt)
+}
+
+func TestRunPendingUpdates_AdvancesWithoutOperationLog(t *testing.T) {
+	svc, ops := newTestService(t,
+		[]imageupdates.ContainerImageUpdate{pendingUpdate("app-web-1", "sha256:old", "sha256:new")},
+		map[string][]string{"web": {"app-web-1"}},
+	)
+	ops.noLogs = true
+	_, err := svc.SetPolicy(1, "app", SetPolicyRequest{Mode: ModeAuto}, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+	history, err := svc.ListHistory(1, "app")
+	require.NoError(t, err)
+	assert.Nil(t, history[0].PullOperationLogID)
+
+	endOperation(svc, "op-1", true)
+	require.Eventually(t, func() bool {
+		h, _ := svc.ListHistory(1, "app")
+		return h[0].Status == StatusDeploying
+	}, time.Second, 10*time.Millisecond)
+
+	endOperation(svc, "op-2", true)
+	history, err = svc.ListHistory(1, "app")
+	require.NoError(t, err)
+	assert.Equal(t, StatusSucceeded, history[0].Status)
+}
+
+func TestRunPendingUpdates_AdvancesWhenOperationsEndImmediately(t *testing.T) {
+	svc, ops := newTestService(t,
+		[]imageupdates.ContainerImageUpdate{pendingUpdate("app-web-1", "sha256:old", "sha256:new")},
+		map[string][]string{"web": {"app-web-1"}},
+	)
+	ops.onPersist = func(operationID string) { endOperation(svc, operationID, true) }
+	_, err := svc.SetPolicy(1, "app", SetPolicyRequest{Mode: ModeAuto}, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+	require.Eventually(t, func() bool {
+		h, _ := svc.ListHistory(1, "app")
+		return h[0].Status == StatusSucceeded
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"pull", "up"}, ops.commands())
+
+	history, err := svc.ListHistory(1, "app")
+	require.NoError(t, err)
+	assert.Equal(t, "op-1", history[0].PullOperationID)
+	assert.Equal(t, "op-2", history[0].OperationID)
+}
+
+func TestRunPendingUpdates_FailedPullIsNotRetried(t *testing.T) {
+	svc, ops := newTestService(t,
+		[]imageupdates.ContainerImageUpdate{pendingUpdate("app-web-1", "sha256:old", "sha256:new")},
+		map[string][]string{"web": {"app-web-1"}},
+	)
+	_, err := svc.SetPolicy(1, "app", SetPolicyRequest{Mode: ModeAuto}, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+	history, err := svc.ListHistory(1, "app")
+	require.NoError(t, err)
+	endOperation(svc, history[0].PullOperationID, false)
+
+	history, err = svc.ListHistory(1, "app")
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, history[0].Status)
+
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+	assert.Equal(t, []string{"pull"}, ops.commands(), "the same digest is only attempted once")
+}
+
+func TestRunPendingUpdates_NotifyOnlyByDefault(t *testing.T) {
+	svc, ops := newTestService(t,
+		[]imageupdates.ContainerImageUpdate{pendingUpdate("app-web-1", "sha256:old", "sha256:new")},
+		map[string][]string{"web": {"app-web-1"}},
+	)
+
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+
+	_, err := svc.SetPolicy(1, "app", SetPolicyRequest{Mode: ModeNotify}, 1)
+	require.NoError(t, err)
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+
+	assert.Empty(t, ops.started)
+}
+
+func TestRunPendingUpdates_ServicePolicyOverridesStackDefault(t *testing.T) {
+	svc, ops := newTestService(t,
+		[]imageupdates.ContainerImageUpdate{
+			pendingUpdate("app-web-1", "sha256:a", "sha256:b"),
+			pendingUpdate("app-db-1", "sha256:c", "sha256:d"),
+		},
+		map[string][]string{"web": {"app-web-1"}, "db": {"app-db-1"}},
+	)
+	_, err := svc.SetPolicy(1, "app", SetPolicyRequest{Mode: ModeAuto}, 1)
+	require.NoError(t, err)
+	_, err = svc.SetPolicy(1, "app", SetPolicyRequest{Mode: ModeNotify, ServiceName: "db"}, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+
+	require.Len(t, ops.started, 1)
+	assert.Equal(t, []string{"web"}, ops.started[0].services)
+}
+
+func TestRunPendingUpdates_WaitsForWindow(t *testing.T) {
+	svc, ops := newTestService(t,
+		[]imageupdates.ContainerImageUpdate{pendingUpdate("app-web-1", "sha256:old", "sha256:new")},
+		map[string][]string{"web": {"app-web-1"}},
+	)
+	_, err := svc.SetPolicy(1, "app", SetPolicyRequest{Mode: ModeWindow, WindowCron: "0 2 * * *", WindowDurationMinutes: 60}, 1)
+	require.NoError(t, err)
+
+	svc.now = func() time.Time { return time.Date(2025, 1, 15, 12, 0, 0, 0, time.Local) }
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+	assert.Empty(t, ops.started, "outside the window nothing is updated")
+
+	svc.now = func() time.Time { return time.Date(2025, 1, 15, 2, 30, 0, 0, time.Local) }
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+	assert.Equal(t, []string{"pull"}, ops.commands())
+}
+
+func TestRunPendingUpdates_WaitsForMaintenanceWindow(t *testing.T) {
+	svc, ops := newTestService(t,
+		[]imageupdates.ContainerImageUpdate{pendingUpdate("app-web-1", "sha256:old", "sha256:new")},
+		map[string][]string{"web": {"app-web-1"}},
+	)
+	windows := &fakeWindows{closed: true}
+	svc.windowSvc = windows
+	_, err := svc.SetPolicy(1, "app", SetPolicyRequest{Mode: ModeAuto}, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+	assert.Empty(t, ops.started)
+	history, err := svc.ListHistory(1, "app")
+	require.NoError(t, err)
+	assert.Empty(t, history, "nothing is attempted while the window is closed")
+
+	windows.closed = false
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+	assert.Equal(t, []string{"pull"}, ops.commands())
+}
+
+func TestRunPendingUpdates_LogsWindowCheckFailure(t *testing.T) {
+	svc, ops := newTestService(t,
+		[]imageupdates.ContainerImageUpdate{pendingUpdate("app-web-1", "sha256:old", "sha256:new")},
+		map[string][]string{"web": {"app-web-1"}},
+	)
+	core, logs := observer.New(zap.ErrorLevel)
+	svc.logger = zap.New(core)
+	svc.windowSvc = &fakeWindows{err: errors.New("database is locked")}
+	_, err := svc.SetPolicy(1, "app", SetPolicyRequest{Mode: ModeAuto}, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RunPendingUpdates(context.Background()))
+	assert.Empty(t, ops.started)
+
+	entries := logs.FilterMessage("failed to check maintenance window for image update").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, uint64(1), fields["server_id"])
+	assert.Equal(t, "app", fields["stack_name"])
+}
+
+func TestSetPolicy_ReplacesExisting(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+
+	first, err := svc.SetPolicy(1, "app", SetPolicyRequest{Mode: ModeNotify}, 1)
+	require.NoError(t, err)
+	second, err := svc.SetPolicy(1, "app", SetPolicyRequest{Mode: ModeAuto}, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.ID, second.ID)
+	policies, err := svc.ListPolicies(1, "app")
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, ModeAuto, policies[0].Mode)
+}
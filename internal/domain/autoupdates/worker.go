@@ -0,0 +1,15 @@
+package autoupdates
+
+import (
+	"time"
+
+	"berth/internal/pkg/periodic"
+
+	"go.uber.org/zap"
+)
+
+// NewWorker returns a runner that applies pending automatic updates every
+// minute.
+func NewWorker(service *Service, logger *zap.Logger) *periodic.Runner {
+	return periodic.NewRunner("auto-update worker", time.Minute, service.RunPendingUpdates, logger)
+}
@@ -0,0 +1,267 @@
+package backupretention
+
+import (
+	"errors"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+	"berth/internal/domain/security"
+	"berth/internal/domain/session"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type policyAuthorizer interface {
+	HasStackPermission(p authz.Principal, serverID uint, stackname, permission string) (bool, error)
+}
+
+type APIHandler struct {
+	service      *Service
+	authzSvc     policyAuthorizer
+	auditService retentionAuditLogger
+}
+
+func NewAPIHandler(service *Service, authzSvc policyAuthorizer, auditService retentionAuditLogger) *APIHandler {
+	return &APIHandler{
+		service:      service,
+		authzSvc:     authzSvc,
+		auditService: auditService,
+	}
+}
+
+// canManagePattern reports whether the principal holds backups.manage for
+// every stack the pattern could match.
+func (h *APIHandler) canManagePattern(p authz.Principal, serverID uint, pattern string) (bool, error) {
+	return h.authzSvc.HasStackPermission(p, serverID, normalisePattern(pattern), permnames.BackupsManage)
+}
+
+func (h *APIHandler) ListPolicies(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	policies, err := h.service.ListPolicies(serverID)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch backup retention policies")
+	}
+
+	visible := make([]RetentionPolicy, 0, len(policies))
+	for _, policy := range policies {
+		allowed, err := h.canManagePattern(p, serverID, policy.StackPattern)
+		if err != nil {
+			return response.Internal(c, "Failed to check permissions")
+		}
+		if allowed {
+			visible = append(visible, policy)
+		}
+	}
+
+	return response.OK(c, ListPoliciesData{
+		Policies: ToResponseList(visible),
+	})
+}
+
+func (h *APIHandler) GetPolicy(c echo.Context) error {
+	_, _, policy, err := h.loadPolicy(c)
+	if err != nil || policy == nil {
+		return err
+	}
+
+	return response.OK(c, GetPolicyData{
+		Policy: ToResponse(policy),
+	})
+}
+
+func (h *APIHandler) DryRun(c echo.Context) error {
+	p, _, policy, err := h.loadPolicy(c)
+	if err != nil || policy == nil {
+		return err
+	}
+
+	plans, err := h.service.DryRun(c.Request().Context(), p, policy, c.QueryParam("stack"))
+	if err != nil {
+		return response.Internal(c, err.Error())
+	}
+
+	return response.OK(c, DryRunData{
+		Policy: ToResponse(policy),
+		Stacks: plans,
+	})
+}
+
+func (h *APIHandler) CreatePolicy(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req PolicyRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	allowed, err := h.canManagePattern(p, serverID, req.StackPattern)
+	if err != nil {
+		return response.Internal(c, "Failed to check permissions")
+	}
+	if !allowed {
+		return response.Forbidden(c, "Insufficient permissions to manage backups for this stack pattern")
+	}
+
+	policy, err := h.service.CreatePolicy(serverID, req)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	h.service.Logger().Info("backup retention policy created",
+		zap.Uint("user_id", p.UserID()),
+		zap.Uint("server_id", serverID),
+		zap.Uint("policy_id", policy.ID),
+	)
+
+	h.audit(c, p, security.EventBackupRetentionPolicyCreated, serverID, policy, nil)
+
+	return response.Created(c, GetPolicyData{
+		Policy: ToResponse(policy),
+	})
+}
+
+func (h *APIHandler) UpdatePolicy(c echo.Context) error {
+	p, serverID, existing, err := h.loadPolicy(c)
+	if err != nil || existing == nil {
+		return err
+	}
+	previous := *existing
+
+	var req PolicyRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	allowed, err := h.canManagePattern(p, serverID, req.StackPattern)
+	if err != nil {
+		return response.Internal(c, "Failed to check permissions")
+	}
+	if !allowed {
+		return response.Forbidden(c, "Insufficient permissions to manage backups for this stack pattern")
+	}
+
+	policy, err := h.service.UpdatePolicy(serverID, existing.ID, req)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	h.audit(c, p, security.EventBackupRetentionPolicyUpdated, serverID, policy, map[string]any{
+		"previous_stack_pattern": previous.StackPattern,
+		"previous_keep_last":     previous.KeepLast,
+		"previous_keep_daily":    previous.KeepDaily,
+		"previous_keep_weekly":   previous.KeepWeekly,
+		"previous_keep_monthly":  previous.KeepMonthly,
+		"previous_enabled":       previous.Enabled,
+	})
+
+	return response.OK(c, GetPolicyData{
+		Policy: ToResponse(policy),
+	})
+}
+
+func (h *APIHandler) DeletePolicy(c echo.Context) error {
+	p, serverID, existing, err := h.loadPolicy(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	if err := h.service.DeletePolicy(serverID, existing.ID); err != nil {
+		return response.Internal(c, "Failed to delete backup retention policy")
+	}
+
+	h.audit(c, p, security.EventBackupRetentionPolicyDeleted, serverID, existing, nil)
+
+	return response.OK(c, DeletePolicyMessageData{
+		Message: "Backup retention policy deleted successfully",
+	})
+}
+
+// loadPolicy resolves the policy named in the path and checks the caller may
+// manage its stack pattern. A nil policy with a nil error means a response
+// has already been written.
+func (h *APIHandler) loadPolicy(c echo.Context) (authz.Principal, uint, *RetentionPolicy, error) {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return authz.Principal{}, 0, nil, err
+	}
+
+	policyID, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return authz.Principal{}, 0, nil, err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return authz.Principal{}, 0, nil, err
+	}
+
+	policy, err := h.service.GetPolicy(serverID, policyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return p, serverID, nil, response.NotFound(c, "Backup retention policy not found")
+		}
+		return p, serverID, nil, response.Internal(c, "Failed to fetch backup retention policy")
+	}
+
+	allowed, err := h.canManagePattern(p, serverID, policy.StackPattern)
+	if err != nil {
+		return p, serverID, nil, response.Internal(c, "Failed to check permissions")
+	}
+	if !allowed {
+		return p, serverID, nil, response.NotFound(c, "Backup retention policy not found")
+	}
+
+	return p, serverID, policy, nil
+}
+
+func (h *APIHandler) audit(c echo.Context, p authz.Principal, eventType string, serverID uint, policy *RetentionPolicy, extra map[string]any) {
+	metadata := map[string]any{
+		"stack_pattern": policy.StackPattern,
+		"keep_last":     policy.KeepLast,
+		"keep_daily":    policy.KeepDaily,
+		"keep_weekly":   policy.KeepWeekly,
+		"keep_monthly":  policy.KeepMonthly,
+		"enabled":       policy.Enabled,
+	}
+	for k, v := range extra {
+		metadata[k] = v
+	}
+
+	actorID := p.UserID()
+	policyID := policy.ID
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      eventType,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetType:     security.TargetTypeBackupRetentionPolicy,
+		TargetID:       &policyID,
+		TargetName:     policy.StackPattern,
+		Success:        true,
+		Metadata:       metadata,
+		ServerID:       &serverID,
+	})
+}
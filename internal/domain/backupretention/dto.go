@@ -0,0 +1,113 @@
+package backupretention
+
+import (
+	"errors"
+	"time"
+
+	"berth/internal/domain/backups"
+)
+
+var (
+	ErrNoKeepRules         = errors.New("at least one of keep_last, keep_daily, keep_weekly or keep_monthly must be greater than 0")
+	ErrNegativeKeepRule    = errors.New("keep values must not be negative")
+	ErrStackPatternInvalid = errors.New("stack_pattern must not contain '/' or whitespace")
+)
+
+type PolicyRequest struct {
+	StackPattern string `json:"stack_pattern,omitempty"`
+	KeepLast     int    `json:"keep_last"`
+	KeepDaily    int    `json:"keep_daily"`
+	KeepWeekly   int    `json:"keep_weekly"`
+	KeepMonthly  int    `json:"keep_monthly"`
+	Enabled      *bool  `json:"enabled,omitempty"`
+}
+
+func (r *PolicyRequest) Validate() error {
+	if r.KeepLast < 0 || r.KeepDaily < 0 || r.KeepWeekly < 0 || r.KeepMonthly < 0 {
+		return ErrNegativeKeepRule
+	}
+	if r.rules().IsEmpty() {
+		return ErrNoKeepRules
+	}
+	for _, c := range r.StackPattern {
+		if c == '/' || c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			return ErrStackPatternInvalid
+		}
+	}
+	return nil
+}
+
+func (r *PolicyRequest) rules() Rules {
+	return Rules{
+		KeepLast:    r.KeepLast,
+		KeepDaily:   r.KeepDaily,
+		KeepWeekly:  r.KeepWeekly,
+		KeepMonthly: r.KeepMonthly,
+	}
+}
+
+type RetentionPolicyInfo struct {
+	ID             uint       `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	ServerID       uint       `json:"server_id"`
+	StackPattern   string     `json:"stack_pattern"`
+	KeepLast       int        `json:"keep_last"`
+	KeepDaily      int        `json:"keep_daily"`
+	KeepWeekly     int        `json:"keep_weekly"`
+	KeepMonthly    int        `json:"keep_monthly"`
+	Enabled        bool       `json:"enabled"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastRunStatus  string     `json:"last_run_status,omitempty"`
+	LastRunMessage string     `json:"last_run_message,omitempty"`
+}
+
+type ListPoliciesData struct {
+	Policies []RetentionPolicyInfo `json:"policies"`
+}
+
+type GetPolicyData struct {
+	Policy RetentionPolicyInfo `json:"policy"`
+}
+
+type DeletePolicyMessageData struct {
+	Message string `json:"message"`
+}
+
+type StackPlan struct {
+	StackName string               `json:"stack_name"`
+	Keep      []backups.RunSummary `json:"keep"`
+	Remove    []backups.RunSummary `json:"remove"`
+	Error     string               `json:"error,omitempty"`
+}
+
+type DryRunData struct {
+	Policy RetentionPolicyInfo `json:"policy"`
+	Stacks []StackPlan         `json:"stacks"`
+}
+
+func ToResponse(p *RetentionPolicy) RetentionPolicyInfo {
+	return RetentionPolicyInfo{
+		ID:             p.ID,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+		ServerID:       p.ServerID,
+		StackPattern:   p.StackPattern,
+		KeepLast:       p.KeepLast,
+		KeepDaily:      p.KeepDaily,
+		KeepWeekly:     p.KeepWeekly,
+		KeepMonthly:    p.KeepMonthly,
+		Enabled:        p.Enabled,
+		LastRunAt:      p.LastRunAt,
+		LastRunStatus:  p.LastRunStatus,
+		LastRunMessage: p.LastRunMessage,
+	}
+}
+
+func ToResponseList(policies []RetentionPolicy) []RetentionPolicyInfo {
+	result := make([]RetentionPolicyInfo, len(policies))
+	for i := range policies {
+		result[i] = ToResponse(&policies[i])
+	}
+	return result
+}
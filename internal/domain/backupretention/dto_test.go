@@ -0,0 +1,30 @@
+package backupretention
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicyRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     PolicyRequest
+		wantErr error
+	}{
+		{"empty", PolicyRequest{}, ErrNoKeepRules},
+		{"keep last only", PolicyRequest{KeepLast: 3}, nil},
+		{"calendar rules", PolicyRequest{KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 6}, nil},
+		{"negative", PolicyRequest{KeepDaily: 7, KeepWeekly: -1}, ErrNegativeKeepRule},
+		{"stack pattern", PolicyRequest{StackPattern: "app-*", KeepLast: 1}, nil},
+		{"bad stack pattern", PolicyRequest{StackPattern: "app/*", KeepLast: 1}, ErrStackPatternInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package backupretention
+
+import (
+	"time"
+
+	"berth/internal/platform/db"
+)
+
+const (
+	RunStatusSuccess = "success"
+	RunStatusPartial = "partial"
+	RunStatusFailed  = "failed"
+)
+
+type RetentionPolicy struct {
+	db.BaseModel
+	ServerID       uint       `json:"server_id" gorm:"not null;index"`
+	StackPattern   string     `json:"stack_pattern" gorm:"not null"`
+	KeepLast       int        `json:"keep_last" gorm:"not null;default:0"`
+	KeepDaily      int        `json:"keep_daily" gorm:"not null;default:0"`
+	KeepWeekly     int        `json:"keep_weekly" gorm:"not null;default:0"`
+	KeepMonthly    int        `json:"keep_monthly" gorm:"not null;default:0"`
+	Enabled        bool       `json:"enabled" gorm:"not null"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+	LastRunStatus  string     `json:"last_run_status"`
+	LastRunMessage string     `json:"last_run_message" gorm:"type:text"`
+}
+
+func (RetentionPolicy) TableName() string {
+	return "backup_retention_policies"
+}
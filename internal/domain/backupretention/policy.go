@@ -0,0 +1,110 @@
+package backupretention
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"berth/internal/domain/backups"
+)
+
+const (
+	backupStatusCompleted = "completed"
+	backupStatusRunning   = "running"
+)
+
+type Rules struct {
+	KeepLast    int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+}
+
+func (p *RetentionPolicy) Rules() Rules {
+	return Rules{
+		KeepLast:    p.KeepLast,
+		KeepDaily:   p.KeepDaily,
+		KeepWeekly:  p.KeepWeekly,
+		KeepMonthly: p.KeepMonthly,
+	}
+}
+
+func (r Rules) IsEmpty() bool {
+	return r.KeepLast <= 0 && r.KeepDaily <= 0 && r.KeepWeekly <= 0 && r.KeepMonthly <= 0
+}
+
+// Evaluate splits runs into those the rules keep and those they remove.
+// KeepLast counts every finished run; the daily, weekly and monthly buckets
+// only count completed runs, keeping the newest run in each bucket. Runs
+// still in progress are always kept, as is everything when the rules are
+// empty.
+func Evaluate(runs []backups.RunSummary, rules Rules, loc *time.Location) (keep, remove []backups.RunSummary) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	sorted := make([]backups.RunSummary, len(runs))
+	copy(sorted, runs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartedAt.After(sorted[j].StartedAt)
+	})
+
+	if rules.IsEmpty() {
+		return sorted, nil
+	}
+
+	kept := make(map[int]bool, len(sorted))
+
+	last := 0
+	for i, run := range sorted {
+		if run.Status == backupStatusRunning {
+			kept[i] = true
+			continue
+		}
+		if last < rules.KeepLast {
+			kept[i] = true
+			last++
+		}
+	}
+
+	keepBuckets(sorted, kept, rules.KeepDaily, func(t time.Time) string {
+		return t.In(loc).Format("2006-01-02")
+	})
+	keepBuckets(sorted, kept, rules.KeepWeekly, func(t time.Time) string {
+		year, week := t.In(loc).ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	keepBuckets(sorted, kept, rules.KeepMonthly, func(t time.Time) string {
+		return t.In(loc).Format("2006-01")
+	})
+
+	for i, run := range sorted {
+		if kept[i] {
+			keep = append(keep, run)
+		} else {
+			remove = append(remove, run)
+		}
+	}
+	return keep, remove
+}
+
+func keepBuckets(sorted []backups.RunSummary, kept map[int]bool, limit int, bucket func(time.Time) string) {
+	if limit <= 0 {
+		return
+	}
+	seen := make(map[string]bool, limit)
+	for i, run := range sorted {
+		if len(seen) >= limit {
+			return
+		}
+		if run.Status != backupStatusCompleted {
+			continue
+		}
+		key := bucket(run.StartedAt)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept[i] = true
+	}
+}
@@ -0,0 +1,121 @@
+package backupretention
+
+import (
+	"testing"
+	"time"
+
+	"berth/internal/domain/backups"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dailyRuns(start time.Time, days int, status string) []backups.RunSummary {
+	runs := make([]backups.RunSummary, days)
+	for i := range days {
+		started := start.AddDate(0, 0, -i)
+		finished := started.Add(time.Minute)
+		runs[i] = backups.RunSummary{
+			ID:         started.Format("2006-01-02"),
+			StartedAt:  started,
+			FinishedAt: &finished,
+			Status:     status,
+		}
+	}
+	return runs
+}
+
+func ids(runs []backups.RunSummary) []string {
+	out := make([]string, len(runs))
+	for i, r := range runs {
+		out[i] = r.ID
+	}
+	return out
+}
+
+func TestEvaluate_EmptyRulesKeepEverything(t *testing.T) {
+	runs := dailyRuns(time.Date(2025, 3, 31, 3, 0, 0, 0, time.UTC), 5, backupStatusCompleted)
+
+	keep, remove := Evaluate(runs, Rules{}, time.UTC)
+
+	assert.Len(t, keep, 5)
+	assert.Empty(t, remove)
+}
+
+func TestEvaluate_KeepLast(t *testing.T) {
+	runs := dailyRuns(time.Date(2025, 3, 31, 3, 0, 0, 0, time.UTC), 5, backupStatusCompleted)
+
+	keep, remove := Evaluate(runs, Rules{KeepLast: 2}, time.UTC)
+
+	assert.Equal(t, []string{"2025-03-31", "2025-03-30"}, ids(keep))
+	assert.Equal(t, []string{"2025-03-29", "2025-03-28", "2025-03-27"}, ids(remove))
+}
+
+func TestEvaluate_KeepDailyUsesNewestRunPerDay(t *testing.T) {
+	day := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	done := func(t time.Time) *time.Time { f := t.Add(time.Minute); return &f }
+	runs := []backups.RunSummary{
+		{ID: "morning", StartedAt: day.Add(6 * time.Hour), FinishedAt: done(day.Add(6 * time.Hour)), Status: backupStatusCompleted},
+		{ID: "evening", StartedAt: day.Add(18 * time.Hour), FinishedAt: done(day.Add(18 * time.Hour)), Status: backupStatusCompleted},
+		{ID: "yesterday", StartedAt: day.Add(-6 * time.Hour), FinishedAt: done(day.Add(-6 * time.Hour)), Status: backupStatusCompleted},
+	}
+
+	keep, remove := Evaluate(runs, Rules{KeepDaily: 2}, time.UTC)
+
+	assert.ElementsMatch(t, []string{"evening", "yesterday"}, ids(keep))
+	assert.Equal(t, []string{"morning"}, ids(remove))
+}
+
+func TestEvaluate_CombinedRules(t *testing.T) {
+	// 60 consecutive daily runs ending on a Monday.
+	runs := dailyRuns(time.Date(2025, 3, 31, 3, 0, 0, 0, time.UTC), 60, backupStatusCompleted)
+
+	keep, remove := Evaluate(runs, Rules{KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 3}, time.UTC)
+
+	kept := ids(keep)
+	for i := range 7 {
+		assert.Contains(t, kept, runs[i].ID, "the last seven days are kept")
+	}
+	assert.Contains(t, kept, "2025-03-30", "Sunday closes the previous ISO week")
+	assert.Contains(t, kept, "2025-02-28", "last run of February is kept for monthly")
+	assert.Contains(t, kept, "2025-01-31", "last run of January is kept for monthly")
+	assert.Equal(t, len(runs), len(keep)+len(remove))
+	assert.Less(t, len(keep), 15)
+}
+
+func TestEvaluate_FailedRunsOnlyCountForKeepLast(t *testing.T) {
+	start := time.Date(2025, 3, 31, 3, 0, 0, 0, time.UTC)
+	runs := append(dailyRuns(start, 1, "failed"), dailyRuns(start.AddDate(0, 0, -1), 2, backupStatusCompleted)...)
+
+	keep, remove := Evaluate(runs, Rules{KeepDaily: 1}, time.UTC)
+
+	assert.Equal(t, []string{"2025-03-30"}, ids(keep), "a failed run never fills a daily bucket")
+	assert.ElementsMatch(t, []string{"2025-03-31", "2025-03-29"}, ids(remove))
+}
+
+func TestEvaluate_RunningRunsAreNeverRemoved(t *testing.T) {
+	start := time.Date(2025, 3, 31, 3, 0, 0, 0, time.UTC)
+	runs := dailyRuns(start, 3, backupStatusCompleted)
+	runs = append(runs, backups.RunSummary{ID: "in-progress", StartedAt: start.AddDate(0, 0, -10), Status: backupStatusRunning})
+
+	keep, _ := Evaluate(runs, Rules{KeepLast: 1}, time.UTC)
+
+	assert.Contains(t, ids(keep), "in-progress")
+}
+
+func TestSelectPolicy_MostSpecificWins(t *testing.T) {
+	policies := []RetentionPolicy{
+		{StackPattern: "*", Enabled: true},
+		{StackPattern: "app-*", Enabled: true},
+		{StackPattern: "app-db", Enabled: true},
+		{StackPattern: "app-web*", Enabled: false},
+	}
+	for i := range policies {
+		policies[i].ID = uint(i + 1)
+	}
+
+	assert.Equal(t, uint(3), selectPolicy(policies, "app-db", 0).ID)
+	assert.Equal(t, uint(2), selectPolicy(policies, "app-web", 0).ID, "disabled policies are ignored")
+	assert.Equal(t, uint(4), selectPolicy(policies, "app-web", 4).ID, "a previewed disabled policy takes part")
+	assert.Equal(t, uint(1), selectPolicy(policies, "monitoring", 0).ID)
+	assert.Nil(t, selectPolicy(policies[1:3], "monitoring", 0))
+}
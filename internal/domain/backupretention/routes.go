@@ -0,0 +1,15 @@
+package backupretention
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
+
+func (h *APIHandler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/servers/:serverid/backup-retention-policies", h.ListPolicies, authz.Server(permnames.BackupsManage))
+	reg.GET("/servers/:serverid/backup-retention-policies/:id", h.GetPolicy, authz.Server(permnames.BackupsManage))
+	reg.GET("/servers/:serverid/backup-retention-policies/:id/dry-run", h.DryRun, authz.Server(permnames.BackupsManage))
+	reg.POST("/servers/:serverid/backup-retention-policies", h.CreatePolicy, authz.Server(permnames.BackupsManage))
+	reg.PUT("/servers/:serverid/backup-retention-policies/:id", h.UpdatePolicy, authz.Server(permnames.BackupsManage))
+	reg.DELETE("/servers/:serverid/backup-retention-policies/:id", h.DeletePolicy, authz.Server(permnames.BackupsManage))
+}
@@ -0,0 +1,463 @@
+package backupretention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/backups"
+	"berth/internal/domain/security"
+	"berth/internal/domain/stack"
+	"berth/internal/pkg/patterns"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	retentionActor = "retention"
+	listPageSize   = 100
+)
+
+type retentionBackupStore interface {
+	ListBackups(ctx context.Context, p authz.Principal, serverID uint, stackname string, limit, offset int) (*backups.ListResponse, error)
+	DeleteBackup(ctx context.Context, p authz.Principal, serverID uint, stackname, backupID string) (*backups.Run, error)
+}
+
+type retentionStackLister interface {
+	ListStacksForServer(ctx context.Context, serverID uint, scope authz.ScopeSet) ([]stack.Stack, error)
+}
+
+type retentionScopeProvider interface {
+	AuthorizedScope(p authz.Principal) (authz.ScopeSet, error)
+}
+
+type retentionAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+type Service struct {
+	db         *gorm.DB
+	backupsSvc retentionBackupStore
+	stackSvc   retentionStackLister
+	scopeSvc   retentionScopeProvider
+	auditSvc   retentionAuditLogger
+	logger     *zap.Logger
+	location   *time.Location
+}
+
+func NewService(db *gorm.DB, backupsSvc retentionBackupStore, stackSvc retentionStackLister, scopeSvc retentionScopeProvider, auditSvc retentionAuditLogger, logger *zap.Logger) *Service {
+	return &Service{
+		db:         db,
+		backupsSvc: backupsSvc,
+		stackSvc:   stackSvc,
+		scopeSvc:   scopeSvc,
+		auditSvc:   auditSvc,
+		logger:     logger,
+		location:   time.Local,
+	}
+}
+
+func (s *Service) Logger() *zap.Logger {
+	return s.logger
+}
+
+func (s *Service) ListPolicies(serverID uint) ([]RetentionPolicy, error) {
+	var policies []RetentionPolicy
+	if err := s.db.Where("server_id = ?", serverID).Order("id").Find(&policies).Error; err != nil {
+		s.logger.Error("failed to list backup retention policies",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+		)
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (s *Service) GetPolicy(serverID, policyID uint) (*RetentionPolicy, error) {
+	var policy RetentionPolicy
+	if err := s.db.Where("server_id = ?", serverID).First(&policy, policyID).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (s *Service) CreatePolicy(serverID uint, req PolicyRequest) (*RetentionPolicy, error) {
+	policy := RetentionPolicy{
+		ServerID: serverID,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+	}
+	applyRequest(&policy, req)
+
+	if err := s.ensureUniquePattern(serverID, policy.StackPattern, 0); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(&policy).Error; err != nil {
+		s.logger.Error("failed to create backup retention policy",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_pattern", policy.StackPattern),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("backup retention policy created",
+		zap.Uint("policy_id", policy.ID),
+		zap.Uint("server_id", serverID),
+		zap.String("stack_pattern", policy.StackPattern),
+	)
+
+	return &policy, nil
+}
+
+func (s *Service) UpdatePolicy(serverID, policyID uint, req PolicyRequest) (*RetentionPolicy, error) {
+	policy, err := s.GetPolicy(serverID, policyID)
+	if err != nil {
+		return nil, err
+	}
+
+	applyRequest(policy, req)
+	if req.Enabled != nil {
+		policy.Enabled = *req.Enabled
+	}
+
+	if err := s.ensureUniquePattern(serverID, policy.StackPattern, policy.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(policy).Error; err != nil {
+		s.logger.Error("failed to update backup retention policy",
+			zap.Error(err),
+			zap.Uint("policy_id", policyID),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("backup retention policy updated",
+		zap.Uint("policy_id", policyID),
+		zap.Uint("server_id", serverID),
+		zap.String("stack_pattern", policy.StackPattern),
+		zap.Bool("enabled", policy.Enabled),
+	)
+
+	return policy, nil
+}
+
+func (s *Service) DeletePolicy(serverID, policyID uint) error {
+	policy, err := s.GetPolicy(serverID, policyID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(policy).Error; err != nil {
+		s.logger.Error("failed to delete backup retention policy",
+			zap.Error(err),
+			zap.Uint("policy_id", policyID),
+		)
+		return err
+	}
+
+	s.logger.Info("backup retention policy deleted",
+		zap.Uint("policy_id", policyID),
+		zap.Uint("server_id", serverID),
+	)
+
+	return nil
+}
+
+func (s *Service) ensureUniquePattern(serverID uint, pattern string, exceptID uint) error {
+	var count int64
+	q := s.db.Model(&RetentionPolicy{}).Where("server_id = ? AND stack_pattern = ?", serverID, pattern)
+	if exceptID != 0 {
+		q = q.Where("id <> ?", exceptID)
+	}
+	if err := q.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("a retention policy for stack pattern %q already exists on this server", pattern)
+	}
+	return nil
+}
+
+// DryRun evaluates the policy against the stacks it currently governs using
+// the caller's own permissions. Nothing is deleted.
+func (s *Service) DryRun(ctx context.Context, p authz.Principal, policy *RetentionPolicy, stackFilter string) ([]StackPlan, error) {
+	policies, err := s.ListPolicies(policy.ServerID)
+	if err != nil {
+		return nil, err
+	}
+
+	stacks, err := s.governedStacks(ctx, p, policy.ServerID)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := []StackPlan{}
+	for _, st := range stacks {
+		if stackFilter != "" && st.Name != stackFilter {
+			continue
+		}
+		effective := selectPolicy(policies, st.Name, policy.ID)
+		if effective == nil || effective.ID != policy.ID {
+			continue
+		}
+
+		plan := StackPlan{StackName: st.Name, Keep: []backups.RunSummary{}, Remove: []backups.RunSummary{}}
+		runs, _, err := s.listAllRuns(ctx, p, policy.ServerID, st.Name)
+		if err != nil {
+			plan.Error = err.Error()
+			plans = append(plans, plan)
+			continue
+		}
+
+		keep, remove := Evaluate(runs, policy.Rules(), s.location)
+		if keep != nil {
+			plan.Keep = keep
+		}
+		if remove != nil {
+			plan.Remove = remove
+		}
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+// ApplyPolicies prunes backups on every server with an enabled policy. Each
+// stack is governed by the most specific matching policy.
+func (s *Service) ApplyPolicies(ctx context.Context) error {
+	var policies []RetentionPolicy
+	if err := s.db.Where("enabled = ?", true).Order("server_id, id").Find(&policies).Error; err != nil {
+		return fmt.Errorf("failed to query backup retention policies: %w", err)
+	}
+
+	byServer := make(map[uint][]RetentionPolicy)
+	var serverIDs []uint
+	for _, policy := range policies {
+		if _, ok := byServer[policy.ServerID]; !ok {
+			serverIDs = append(serverIDs, policy.ServerID)
+		}
+		byServer[policy.ServerID] = append(byServer[policy.ServerID], policy)
+	}
+
+	var errs []error
+	for _, serverID := range serverIDs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.applyServerPolicies(ctx, serverID, byServer[serverID]); err != nil {
+			errs = append(errs, fmt.Errorf("server %d: %w", serverID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+type policyOutcome struct {
+	deleted int
+	failed  []string
+}
+
+func (s *Service) applyServerPolicies(ctx context.Context, serverID uint, policies []RetentionPolicy) error {
+	outcomes := make(map[uint]*policyOutcome, len(policies))
+	for _, policy := range policies {
+		outcomes[policy.ID] = &policyOutcome{}
+	}
+
+	stacks, err := s.governedStacks(ctx, authz.SystemPrincipal, serverID)
+	if err != nil {
+		for _, policy := range policies {
+			s.recordRun(policy.ID, RunStatusFailed, fmt.Sprintf("failed to list stacks: %v", err))
+		}
+		return err
+	}
+
+	for _, st := range stacks {
+		policy := selectPolicy(policies, st.Name, 0)
+		if policy == nil {
+			continue
+		}
+		outcome := outcomes[policy.ID]
+
+		runs, enabled, err := s.listAllRuns(ctx, authz.SystemPrincipal, serverID, st.Name)
+		if err != nil {
+			outcome.failed = append(outcome.failed, fmt.Sprintf("%s: %v", st.Name, err))
+			continue
+		}
+		if !enabled {
+			continue
+		}
+
+		_, remove := Evaluate(runs, policy.Rules(), s.location)
+		for _, run := range remove {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := s.deleteRun(ctx, policy, st.Name, run); err != nil {
+				outcome.failed = append(outcome.failed, fmt.Sprintf("%s/%s: %v", st.Name, run.ID, err))
+				if errors.Is(err, backups.ErrRepositoryBusy) {
+					break
+				}
+				continue
+			}
+			outcome.deleted++
+		}
+	}
+
+	for _, policy := range policies {
+		outcome := outcomes[policy.ID]
+		switch {
+		case len(outcome.failed) == 0:
+			s.recordRun(policy.ID, RunStatusSuccess, fmt.Sprintf("deleted %d backup(s)", outcome.deleted))
+		case outcome.deleted > 0:
+			s.recordRun(policy.ID, RunStatusPartial, fmt.Sprintf("deleted %d backup(s); %s", outcome.deleted, strings.Join(outcome.failed, "; ")))
+		default:
+			s.recordRun(policy.ID, RunStatusFailed, strings.Join(outcome.failed, "; "))
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) deleteRun(ctx context.Context, policy *RetentionPolicy, stackName string, run backups.RunSummary) error {
+	if _, err := s.backupsSvc.DeleteBackup(ctx, authz.SystemPrincipal, policy.ServerID, stackName, run.ID); err != nil {
+		if errors.Is(err, backups.ErrBackupNotFound) {
+			return nil
+		}
+		s.logger.Warn("retention failed to delete backup",
+			zap.Error(err),
+			zap.Uint("policy_id", policy.ID),
+			zap.Uint("server_id", policy.ServerID),
+			zap.String("stack_name", stackName),
+			zap.String("backup_id", run.ID),
+		)
+		return err
+	}
+
+	s.logger.Info("retention deleted backup",
+		zap.Uint("policy_id", policy.ID),
+		zap.Uint("server_id", policy.ServerID),
+		zap.String("stack_name", stackName),
+		zap.String("backup_id", run.ID),
+		zap.Time("backup_taken_at", run.StartedAt),
+	)
+
+	serverID := policy.ServerID
+	_ = s.auditSvc.Log(security.LogEvent{
+		EventType:     security.EventBackupDeleted,
+		Success:       true,
+		ActorUsername: retentionActor,
+		TargetType:    security.TargetTypeBackup,
+		TargetName:    run.ID,
+		ServerID:      &serverID,
+		StackName:     stackName,
+		Metadata: map[string]any{
+			"actor":             retentionActor,
+			"policy_id":         policy.ID,
+			"stack":             stackName,
+			"server_id":         serverID,
+			"backup_taken_at":   run.StartedAt,
+			"backup_status":     run.Status,
+			"component_count":   run.ComponentCount,
+			"data_size_bytes":   run.SizeBytes,
+			"repo_growth_bytes": run.AddedBytes,
+		},
+	})
+
+	return nil
+}
+
+func (s *Service) governedStacks(ctx context.Context, p authz.Principal, serverID uint) ([]stack.Stack, error) {
+	scope, err := s.scopeSvc.AuthorizedScope(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve scope: %w", err)
+	}
+	return s.stackSvc.ListStacksForServer(ctx, serverID, scope)
+}
+
+func (s *Service) listAllRuns(ctx context.Context, p authz.Principal, serverID uint, stackName string) ([]backups.RunSummary, bool, error) {
+	var runs []backups.RunSummary
+	enabled := false
+	for offset := 0; ; offset += listPageSize {
+		page, err := s.backupsSvc.ListBackups(ctx, p, serverID, stackName, listPageSize, offset)
+		if err != nil {
+			return nil, false, err
+		}
+		enabled = page.Enabled
+		runs = append(runs, page.Runs...)
+		if len(page.Runs) < listPageSize || len(runs) >= page.Total {
+			break
+		}
+	}
+	return runs, enabled, nil
+}
+
+func (s *Service) recordRun(policyID uint, status, message string) {
+	now := time.Now()
+	if err := s.db.Model(&RetentionPolicy{}).Where("id = ?", policyID).Updates(map[string]any{
+		"last_run_at":      now,
+		"last_run_status":  status,
+		"last_run_message": message,
+	}).Error; err != nil {
+		s.logger.Error("failed to record backup retention run",
+			zap.Error(err),
+			zap.Uint("policy_id", policyID),
+		)
+	}
+}
+
+// selectPolicy picks the most specific policy matching the stack: an exact
+// name beats a wildcard pattern, a longer pattern beats a shorter one, and
+// "*" is the server-wide fallback. includeID lets a disabled policy take part
+// when previewing it.
+func selectPolicy(policies []RetentionPolicy, stackName string, includeID uint) *RetentionPolicy {
+	var best *RetentionPolicy
+	bestRank := -1
+	for i := range policies {
+		policy := &policies[i]
+		if !policy.Enabled && policy.ID != includeID {
+			continue
+		}
+		if !patterns.Matches(stackName, policy.StackPattern) {
+			continue
+		}
+		rank := patternSpecificity(policy.StackPattern)
+		if rank > bestRank {
+			best = policy
+			bestRank = rank
+		}
+	}
+	return best
+}
+
+func patternSpecificity(pattern string) int {
+	switch {
+	case pattern == "*":
+		return 0
+	case !strings.Contains(pattern, "*"):
+		return 1_000_000
+	default:
+		return len(strings.ReplaceAll(pattern, "*", ""))
+	}
+}
+
+func applyRequest(policy *RetentionPolicy, req PolicyRequest) {
+	policy.StackPattern = normalisePattern(req.StackPattern)
+	policy.KeepLast = req.KeepLast
+	policy.KeepDaily = req.KeepDaily
+	policy.KeepWeekly = req.KeepWeekly
+	policy.KeepMonthly = req.KeepMonthly
+}
+
+func normalisePattern(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return "*"
+	}
+	return pattern
+}
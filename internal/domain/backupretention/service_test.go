@@ -0,0 +1,188 @@
+package backupretention
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/backups"
+	"berth/internal/domain/security"
+	"berth/internal/domain/stack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type fakeBackups struct {
+	runs    map[string][]backups.RunSummary
+	enabled bool
+	deleted []string
+}
+
+func (f *fakeBackups) ListBackups(_ context.Context, _ authz.Principal, _ uint, stackname string, limit, offset int) (*backups.ListResponse, error) {
+	all := f.runs[stackname]
+	end := min(offset+limit, len(all))
+	page := []backups.RunSummary{}
+	if offset < len(all) {
+		page = all[offset:end]
+	}
+	return &backups.ListResponse{Enabled: f.enabled, Configured: true, Total: len(all), Runs: page}, nil
+}
+
+func (f *fakeBackups) DeleteBackup(_ context.Context, p authz.Principal, _ uint, stackname, backupID string) (*backups.Run, error) {
+	if !p.IsSystem() {
+		return nil, fmt.Errorf("retention must delete as the system principal")
+	}
+	f.deleted = append(f.deleted, stackname+"/"+backupID)
+	return &backups.Run{ID: backupID}, nil
+}
+
+type fakeStacks struct{ names []string }
+
+func (f *fakeStacks) ListStacksForServer(_ context.Context, _ uint, _ authz.ScopeSet) ([]stack.Stack, error) {
+	out := make([]stack.Stack, len(f.names))
+	for i, n := range f.names {
+		out[i] = stack.Stack{Name: n}
+	}
+	return out, nil
+}
+
+type fakeScope struct{}
+
+func (fakeScope) AuthorizedScope(authz.Principal) (authz.ScopeSet, error) {
+	return authz.NewScopeSet(nil, nil, nil, false, true), nil
+}
+
+type captureAudit struct{ events []security.LogEvent }
+
+func (c *captureAudit) Log(e security.LogEvent) error {
+	c.events = append(c.events, e)
+	return nil
+}
+
+func newTestService(t *testing.T, store *fakeBackups, stacks ...string) (*Service, *captureAudit) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:backupretention_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&RetentionPolicy{}))
+
+	audit := &captureAudit{}
+	svc := NewService(db, store, &fakeStacks{names: stacks}, fakeScope{}, audit, zap.NewNop())
+	svc.location = time.UTC
+	return svc, audit
+}
+
+func TestApplyPolicies_DeletesRunsOutsidePolicyAndAuditsAsRetention(t *testing.T) {
+	start := time.Date(2025, 3, 31, 3, 0, 0, 0, time.UTC)
+	store := &fakeBackups{enabled: true, runs: map[string][]backups.RunSummary{
+		"app": dailyRuns(start, 4, backupStatusCompleted),
+	}}
+	svc, audit := newTestService(t, store, "app")
+
+	policy, err := svc.CreatePolicy(1, PolicyRequest{KeepLast: 2})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.ApplyPolicies(context.Background()))
+
+	assert.ElementsMatch(t, []string{"app/2025-03-29", "app/2025-03-28"}, store.deleted)
+	require.Len(t, audit.events, 2)
+	for _, e := range audit.events {
+		assert.Equal(t, security.EventBackupDeleted, e.EventType)
+		assert.Equal(t, retentionActor, e.ActorUsername)
+		assert.Nil(t, e.ActorUserID)
+		assert.Equal(t, retentionActor, e.Metadata["actor"])
+		assert.Equal(t, policy.ID, e.Metadata["policy_id"])
+	}
+
+	reloaded, err := svc.GetPolicy(1, policy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusSuccess, reloaded.LastRunStatus)
+	assert.NotNil(t, reloaded.LastRunAt)
+}
+
+func TestApplyPolicies_SkipsServersWithBackupsDisabled(t *testing.T) {
+	start := time.Date(2025, 3, 31, 3, 0, 0, 0, time.UTC)
+	store := &fakeBackups{enabled: false, runs: map[string][]backups.RunSummary{
+		"app": dailyRuns(start, 4, backupStatusCompleted),
+	}}
+	svc, _ := newTestService(t, store, "app")
+
+	_, err := svc.CreatePolicy(1, PolicyRequest{KeepLast: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.ApplyPolicies(context.Background()))
+	assert.Empty(t, store.deleted)
+}
+
+func TestApplyPolicies_StackPolicyOverridesServerPolicy(t *testing.T) {
+	start := time.Date(2025, 3, 31, 3, 0, 0, 0, time.UTC)
+	store := &fakeBackups{enabled: true, runs: map[string][]backups.RunSummary{
+		"app": dailyRuns(start, 3, backupStatusCompleted),
+		"db":  dailyRuns(start, 3, backupStatusCompleted),
+	}}
+	svc, _ := newTestService(t, store, "app", "db")
+
+	_, err := svc.CreatePolicy(1, PolicyRequest{KeepLast: 1})
+	require.NoError(t, err)
+	_, err = svc.CreatePolicy(1, PolicyRequest{StackPattern: "db", KeepLast: 3})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.ApplyPolicies(context.Background()))
+	assert.ElementsMatch(t, []string{"app/2025-03-30", "app/2025-03-29"}, store.deleted)
+}
+
+func TestApplyPolicies_PagesThroughAllRuns(t *testing.T) {
+	start := time.Date(2025, 3, 31, 3, 0, 0, 0, time.UTC)
+	store := &fakeBackups{enabled: true, runs: map[string][]backups.RunSummary{
+		"app": dailyRuns(start, listPageSize+20, backupStatusCompleted),
+	}}
+	svc, _ := newTestService(t, store, "app")
+
+	_, err := svc.CreatePolicy(1, PolicyRequest{KeepLast: 10})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.ApplyPolicies(context.Background()))
+	assert.Len(t, store.deleted, listPageSize+10)
+}
+
+func TestDryRun_DoesNotDelete(t *testing.T) {
+	start := time.Date(2025, 3, 31, 3, 0, 0, 0, time.UTC)
+	store := &fakeBackups{enabled: true, runs: map[string][]backups.RunSummary{
+		"app": dailyRuns(start, 4, backupStatusCompleted),
+	}}
+	svc, audit := newTestService(t, store, "app")
+
+	disabled := false
+	policy, err := svc.CreatePolicy(1, PolicyRequest{KeepLast: 1, Enabled: &disabled})
+	require.NoError(t, err)
+
+	plans, err := svc.DryRun(context.Background(), authz.NewPrincipal(1, true, nil), policy, "")
+	require.NoError(t, err)
+
+	require.Len(t, plans, 1)
+	assert.Equal(t, "app", plans[0].StackName)
+	assert.Len(t, plans[0].Keep, 1)
+	assert.Len(t, plans[0].Remove, 3)
+	assert.Empty(t, store.deleted)
+	assert.Empty(t, audit.events)
+}
+
+func TestCreatePolicy_RejectsDuplicatePattern(t *testing.T) {
+	svc, _ := newTestService(t, &fakeBackups{})
+
+	_, err := svc.CreatePolicy(1, PolicyRequest{StackPattern: "app-*", KeepLast: 1})
+	require.NoError(t, err)
+	_, err = svc.CreatePolicy(1, PolicyRequest{StackPattern: "app-*", KeepDaily: 1})
+	assert.Error(t, err)
+	_, err = svc.CreatePolicy(2, PolicyRequest{StackPattern: "app-*", KeepDaily: 1})
+	assert.NoError(t, err, "patterns are unique per server")
+}
@@ -0,0 +1,248 @@
+package backupschedules
+
+import (
+	"errors"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+	"berth/internal/domain/security"
+	"berth/internal/domain/session"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type scheduleAuthorizer interface {
+	HasStackPermission(p authz.Principal, serverID uint, stackname, permission string) (bool, error)
+}
+
+type scheduleAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+type APIHandler struct {
+	service      *Service
+	authzSvc     scheduleAuthorizer
+	auditService scheduleAuditLogger
+}
+
+func NewAPIHandler(service *Service, authzSvc scheduleAuthorizer, auditService scheduleAuditLogger) *APIHandler {
+	return &APIHandler{
+		service:      service,
+		authzSvc:     authzSvc,
+		auditService: auditService,
+	}
+}
+
+// canManagePattern reports whether the principal holds backups.manage for
+// every stack the pattern could match, so a schedule cannot be used to back
+// up stacks outside the caller's grants.
+func (h *APIHandler) canManagePattern(p authz.Principal, serverID uint, pattern string) (bool, error) {
+	return h.authzSvc.HasStackPermission(p, serverID, normalisePattern(pattern), permnames.BackupsManage)
+}
+
+func (h *APIHandler) ListSchedules(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	schedules, err := h.service.ListSchedules(serverID)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch backup schedules")
+	}
+
+	visible := make([]BackupSchedule, 0, len(schedules))
+	for _, schedule := range schedules {
+		allowed, err := h.canManagePattern(p, serverID, schedule.StackPattern)
+		if err != nil {
+			return response.Internal(c, "Failed to check permissions")
+		}
+		if allowed {
+			visible = append(visible, schedule)
+		}
+	}
+
+	return response.OK(c, ListSchedulesData{
+		Schedules: ToResponseList(visible),
+	})
+}
+
+func (h *APIHandler) GetSchedule(c echo.Context) error {
+	_, _, schedule, err := h.loadSchedule(c)
+	if err != nil || schedule == nil {
+		return err
+	}
+
+	return response.OK(c, GetScheduleData{
+		Schedule: ToResponse(schedule),
+	})
+}
+
+func (h *APIHandler) CreateSchedule(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req CreateScheduleRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	allowed, err := h.canManagePattern(p, serverID, req.StackPattern)
+	if err != nil {
+		return response.Internal(c, "Failed to check permissions")
+	}
+	if !allowed {
+		return response.Forbidden(c, "Insufficient permissions to manage backups for this stack pattern")
+	}
+
+	schedule, err := h.service.CreateSchedule(serverID, req, p.UserID())
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	h.service.Logger().Info("backup schedule created",
+		zap.Uint("user_id", p.UserID()),
+		zap.Uint("server_id", serverID),
+		zap.Uint("schedule_id", schedule.ID),
+	)
+
+	h.audit(c, p, security.EventBackupScheduleCreated, serverID, schedule, nil)
+
+	return response.Created(c, GetScheduleData{
+		Schedule: ToResponse(schedule),
+	})
+}
+
+func (h *APIHandler) UpdateSchedule(c echo.Context) error {
+	p, serverID, existing, err := h.loadSchedule(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	var req UpdateScheduleRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	allowed, err := h.canManagePattern(p, serverID, req.StackPattern)
+	if err != nil {
+		return response.Internal(c, "Failed to check permissions")
+	}
+	if !allowed {
+		return response.Forbidden(c, "Insufficient permissions to manage backups for this stack pattern")
+	}
+
+	schedule, err := h.service.UpdateSchedule(serverID, existing.ID, req)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	h.audit(c, p, security.EventBackupScheduleUpdated, serverID, schedule, map[string]any{
+		"previous_stack_pattern":   existing.StackPattern,
+		"previous_cron_expression": existing.CronExpression,
+		"previous_enabled":         existing.Enabled,
+	})
+
+	return response.OK(c, GetScheduleData{
+		Schedule: ToResponse(schedule),
+	})
+}
+
+func (h *APIHandler) DeleteSchedule(c echo.Context) error {
+	p, serverID, existing, err := h.loadSchedule(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	if err := h.service.DeleteSchedule(serverID, existing.ID); err != nil {
+		return response.Internal(c, "Failed to delete backup schedule")
+	}
+
+	h.audit(c, p, security.EventBackupScheduleDeleted, serverID, existing, nil)
+
+	return response.OK(c, DeleteScheduleMessageData{
+		Message: "Backup schedule deleted successfully",
+	})
+}
+
+// loadSchedule resolves the schedule named in the path and checks the caller
+// may manage its stack pattern. A nil schedule with a nil error means a
+// response has already been written.
+func (h *APIHandler) loadSchedule(c echo.Context) (authz.Principal, uint, *BackupSchedule, error) {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return authz.Principal{}, 0, nil, err
+	}
+
+	scheduleID, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return authz.Principal{}, 0, nil, err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return authz.Principal{}, 0, nil, err
+	}
+
+	schedule, err := h.service.GetSchedule(serverID, scheduleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return p, serverID, nil, response.NotFound(c, "Backup schedule not found")
+		}
+		return p, serverID, nil, response.Internal(c, "Failed to fetch backup schedule")
+	}
+
+	allowed, err := h.canManagePattern(p, serverID, schedule.StackPattern)
+	if err != nil {
+		return p, serverID, nil, response.Internal(c, "Failed to check permissions")
+	}
+	if !allowed {
+		return p, serverID, nil, response.NotFound(c, "Backup schedule not found")
+	}
+
+	return p, serverID, schedule, nil
+}
+
+func (h *APIHandler) audit(c echo.Context, p authz.Principal, eventType string, serverID uint, schedule *BackupSchedule, extra map[string]any) {
+	metadata := map[string]any{
+		"stack_pattern":   schedule.StackPattern,
+		"cron_expression": schedule.CronExpression,
+		"enabled":         schedule.Enabled,
+	}
+	for k, v := range extra {
+		metadata[k] = v
+	}
+
+	actorID := p.UserID()
+	scheduleID := schedule.ID
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      eventType,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetType:     security.TargetTypeBackupSchedule,
+		TargetID:       &scheduleID,
+		TargetName:     schedule.StackPattern,
+		Success:        true,
+		Metadata:       metadata,
+		ServerID:       &serverID,
+	})
+}
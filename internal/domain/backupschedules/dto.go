@@ -0,0 +1,106 @@
+package backupschedules
+
+import (
+	"errors"
+	"time"
+
+	"berth/internal/pkg/cronspec"
+)
+
+var (
+	ErrCronExpressionRequired = errors.New("cron_expression is required")
+	ErrStackPatternInvalid    = errors.New("stack_pattern must not contain '/' or whitespace")
+)
+
+type CreateScheduleRequest struct {
+	StackPattern   string `json:"stack_pattern,omitempty"`
+	CronExpression string `json:"cron_expression"`
+	Enabled        *bool  `json:"enabled,omitempty"`
+}
+
+func (r *CreateScheduleRequest) Validate() error {
+	if r.CronExpression == "" {
+		return ErrCronExpressionRequired
+	}
+	if err := cronspec.Validate(r.CronExpression); err != nil {
+		return err
+	}
+	return validateStackPattern(r.StackPattern)
+}
+
+type UpdateScheduleRequest struct {
+	StackPattern   string `json:"stack_pattern,omitempty"`
+	CronExpression string `json:"cron_expression"`
+	Enabled        *bool  `json:"enabled,omitempty"`
+}
+
+func (r *UpdateScheduleRequest) Validate() error {
+	if r.CronExpression == "" {
+		return ErrCronExpressionRequired
+	}
+	if err := cronspec.Validate(r.CronExpression); err != nil {
+		return err
+	}
+	return validateStackPattern(r.StackPattern)
+}
+
+func validateStackPattern(pattern string) error {
+	for _, r := range pattern {
+		if r == '/' || r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			return ErrStackPatternInvalid
+		}
+	}
+	return nil
+}
+
+type BackupScheduleInfo struct {
+	ID              uint       `json:"id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	ServerID        uint       `json:"server_id"`
+	StackPattern    string     `json:"stack_pattern"`
+	CronExpression  string     `json:"cron_expression"`
+	Enabled         bool       `json:"enabled"`
+	CreatedByUserID *uint      `json:"created_by_user_id,omitempty"`
+	NextRunAt       *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty"`
+	LastRunStatus   string     `json:"last_run_status,omitempty"`
+	LastRunMessage  string     `json:"last_run_message,omitempty"`
+}
+
+type ListSchedulesData struct {
+	Schedules []BackupScheduleInfo `json:"schedules"`
+}
+
+type GetScheduleData struct {
+	Schedule BackupScheduleInfo `json:"schedule"`
+}
+
+type DeleteScheduleMessageData struct {
+	Message string `json:"message"`
+}
+
+func ToResponse(s *BackupSchedule) BackupScheduleInfo {
+	return BackupScheduleInfo{
+		ID:              s.ID,
+		CreatedAt:       s.CreatedAt,
+		UpdatedAt:       s.UpdatedAt,
+		ServerID:        s.ServerID,
+		StackPattern:    s.StackPattern,
+		CronExpression:  s.CronExpression,
+		Enabled:         s.Enabled,
+		CreatedByUserID: s.CreatedByUserID,
+		NextRunAt:       s.NextRunAt,
+		LastRunAt:       s.LastRunAt,
+		LastRunStatus:   s.LastRunStatus,
+		LastRunMessage:  s.LastRunMessage,
+	}
+}
+
+func ToResponseList(schedules []BackupSchedule) []BackupScheduleInfo {
+	result := make([]BackupScheduleInfo, len(schedules))
+	for i := range schedules {
+		result[i] = ToResponse(&schedules[i])
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package backupschedules
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateScheduleRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CreateScheduleRequest
+		wantErr error
+		anyErr  bool
+	}{
+		{"empty", CreateScheduleRequest{}, ErrCronExpressionRequired, false},
+		{"valid daily", CreateScheduleRequest{CronExpression: "0 3 * * *"}, nil, false},
+		{"valid descriptor", CreateScheduleRequest{CronExpression: "@weekly", StackPattern: "app-*"}, nil, false},
+		{"invalid cron", CreateScheduleRequest{CronExpression: "every day"}, nil, true},
+		{"pattern with slash", CreateScheduleRequest{CronExpression: "0 3 * * *", StackPattern: "a/b"}, ErrStackPatternInvalid, false},
+		{"pattern with space", CreateScheduleRequest{CronExpression: "0 3 * * *", StackPattern: "a b"}, ErrStackPatternInvalid, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if tt.anyErr {
+				if got == nil {
+					t.Errorf("Validate() = nil, want error")
+				}
+				return
+			}
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateScheduleRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     UpdateScheduleRequest
+		wantErr error
+	}{
+		{"empty", UpdateScheduleRequest{}, ErrCronExpressionRequired},
+		{"valid", UpdateScheduleRequest{CronExpression: "*/30 * * * *"}, nil},
+		{"bad pattern", UpdateScheduleRequest{CronExpression: "@daily", StackPattern: "x\ty"}, ErrStackPatternInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
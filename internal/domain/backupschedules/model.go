@@ -0,0 +1,31 @@
+package backupschedules
+
+import (
+	"time"
+
+	"berth/internal/platform/db"
+)
+
+const (
+	RunStatusSuccess = "success"
+	RunStatusPartial = "partial"
+	RunStatusFailed  = "failed"
+	RunStatusSkipped = "skipped"
+)
+
+type BackupSchedule struct {
+	db.BaseModel
+	ServerID        uint       `json:"server_id" gorm:"not null;index"`
+	StackPattern    string     `json:"stack_pattern" gorm:"not null"`
+	CronExpression  string     `json:"cron_expression" gorm:"not null"`
+	Enabled         bool       `json:"enabled" gorm:"not null"`
+	CreatedByUserID *uint      `json:"created_by_user_id"`
+	NextRunAt       *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt       *time.Time `json:"last_run_at"`
+	LastRunStatus   string     `json:"last_run_status"`
+	LastRunMessage  string     `json:"last_run_message" gorm:"type:text"`
+}
+
+func (BackupSchedule) TableName() string {
+	return "backup_schedules"
+}
@@ -0,0 +1,14 @@
+package backupschedules
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
+
+func (h *APIHandler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/servers/:serverid/backup-schedules", h.ListSchedules, authz.Server(permnames.BackupsManage))
+	reg.GET("/servers/:serverid/backup-schedules/:id", h.GetSchedule, authz.Server(permnames.BackupsManage))
+	reg.POST("/servers/:serverid/backup-schedules", h.CreateSchedule, authz.Server(permnames.BackupsManage))
+	reg.PUT("/servers/:serverid/backup-schedules/:id", h.UpdateSchedule, authz.Server(permnames.BackupsManage))
+	reg.DELETE("/servers/:serverid/backup-schedules/:id", h.DeleteSchedule, authz.Server(permnames.BackupsManage))
+}
@@ -0,0 +1,14 @@
+package backupschedules
+
+import (
+	"time"
+
+	"berth/internal/pkg/periodic"
+
+	"go.uber.org/zap"
+)
+
+// NewScheduler returns a runner that runs due backup schedules every 30 seconds.
+func NewScheduler(service *Service, logger *zap.Logger) *periodic.Runner {
+	return periodic.NewRunner("backup scheduler", 30*time.Second, service.RunDueSchedules, logger)
+}
@@ -0,0 +1,336 @@
+package backupschedules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/operationlogs"
+	"berth/internal/domain/operations"
+	"berth/internal/domain/server"
+	"berth/internal/domain/stack"
+	"berth/internal/pkg/cronspec"
+	"berth/internal/pkg/patterns"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const createBackupCommand = "create-backup"
+
+type scheduleServerProvider interface {
+	GetServer(id uint) (*server.Server, error)
+}
+
+type scheduleStackLister interface {
+	ListStacksForServer(ctx context.Context, serverID uint, scope authz.ScopeSet) ([]stack.Stack, error)
+}
+
+type scheduleScopeProvider interface {
+	AuthorizedScope(p authz.Principal) (authz.ScopeSet, error)
+}
+
+type scheduleOperationRunner interface {
+	StartOperation(ctx context.Context, p authz.Principal, serverID uint, stackname string, req operations.OperationRequest) (*operations.OperationStartData, error)
+	RecordStartAndPersist(p authz.Principal, source operationlogs.TriggerSource, serverID uint, stackname string, operationID string, req operations.OperationRequest, startTime time.Time)
+}
+
+type Service struct {
+	db        *gorm.DB
+	serverSvc scheduleServerProvider
+	stackSvc  scheduleStackLister
+	scopeSvc  scheduleScopeProvider
+	opsSvc    scheduleOperationRunner
+	logger    *zap.Logger
+	now       func() time.Time
+}
+
+func NewService(db *gorm.DB, serverSvc scheduleServerProvider, stackSvc scheduleStackLister, scopeSvc scheduleScopeProvider, opsSvc scheduleOperationRunner, logger *zap.Logger) *Service {
+	return &Service{
+		db:        db,
+		serverSvc: serverSvc,
+		stackSvc:  stackSvc,
+		scopeSvc:  scopeSvc,
+		opsSvc:    opsSvc,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+func (s *Service) Logger() *zap.Logger {
+	return s.logger
+}
+
+func (s *Service) ListSchedules(serverID uint) ([]BackupSchedule, error) {
+	var schedules []BackupSchedule
+	if err := s.db.Where("server_id = ?", serverID).Order("id").Find(&schedules).Error; err != nil {
+		s.logger.Error("failed to list backup schedules",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+		)
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (s *Service) GetSchedule(serverID, scheduleID uint) (*BackupSchedule, error) {
+	var schedule BackupSchedule
+	if err := s.db.Where("server_id = ?", serverID).First(&schedule, scheduleID).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (s *Service) CreateSchedule(serverID uint, req CreateScheduleRequest, createdBy uint) (*BackupSchedule, error) {
+	next, err := cronspec.Next(req.CronExpression, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := BackupSchedule{
+		ServerID:       serverID,
+		StackPattern:   normalisePattern(req.StackPattern),
+		CronExpression: strings.TrimSpace(req.CronExpression),
+		Enabled:        req.Enabled == nil || *req.Enabled,
+		NextRunAt:      &next,
+	}
+	if createdBy != 0 {
+		schedule.CreatedByUserID = &createdBy
+	}
+
+	if err := s.db.Create(&schedule).Error; err != nil {
+		s.logger.Error("failed to create backup schedule",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_pattern", schedule.StackPattern),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("backup schedule created",
+		zap.Uint("schedule_id", schedule.ID),
+		zap.Uint("server_id", serverID),
+		zap.String("stack_pattern", schedule.StackPattern),
+		zap.String("cron_expression", schedule.CronExpression),
+	)
+
+	return &schedule, nil
+}
+
+func (s *Service) UpdateSchedule(serverID, scheduleID uint, req UpdateScheduleRequest) (*BackupSchedule, error) {
+	schedule, err := s.GetSchedule(serverID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := cronspec.Next(req.CronExpression, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.StackPattern = normalisePattern(req.StackPattern)
+	schedule.CronExpression = strings.TrimSpace(req.CronExpression)
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+	schedule.NextRunAt = &next
+
+	if err := s.db.Save(schedule).Error; err != nil {
+		s.logger.Error("failed to update backup schedule",
+			zap.Error(err),
+			zap.Uint("schedule_id", scheduleID),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("backup schedule updated",
+		zap.Uint("schedule_id", scheduleID),
+		zap.Uint("server_id", serverID),
+		zap.String("stack_pattern", schedule.StackPattern),
+		zap.String("cron_expression", schedule.CronExpression),
+		zap.Bool("enabled", schedule.Enabled),
+	)
+
+	return schedule, nil
+}
+
+func (s *Service) DeleteSchedule(serverID, scheduleID uint) error {
+	schedule, err := s.GetSchedule(serverID, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(schedule).Error; err != nil {
+		s.logger.Error("failed to delete backup schedule",
+			zap.Error(err),
+			zap.Uint("schedule_id", scheduleID),
+		)
+		return err
+	}
+
+	s.logger.Info("backup schedule deleted",
+		zap.Uint("schedule_id", scheduleID),
+		zap.Uint("server_id", serverID),
+	)
+
+	return nil
+}
+
+// RunDueSchedules starts a backup for every stack matched by an enabled
+// schedule whose next run time has passed.
+func (s *Service) RunDueSchedules(ctx context.Context) error {
+	now := s.now()
+
+	var due []BackupSchedule
+	if err := s.db.Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at").
+		Find(&due).Error; err != nil {
+		return fmt.Errorf("failed to query due backup schedules: %w", err)
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.runSchedule(ctx, &due[i], now)
+	}
+
+	return nil
+}
+
+func (s *Service) runSchedule(ctx context.Context, schedule *BackupSchedule, now time.Time) {
+	updates := map[string]any{"last_run_at": now}
+
+	next, err := cronspec.Next(schedule.CronExpression, now)
+	if err != nil {
+		s.logger.Error("backup schedule has an invalid cron expression; disabling",
+			zap.Error(err),
+			zap.Uint("schedule_id", schedule.ID),
+		)
+		updates["enabled"] = false
+		updates["next_run_at"] = nil
+		updates["last_run_status"] = RunStatusFailed
+		updates["last_run_message"] = err.Error()
+		s.saveRunResult(schedule.ID, updates)
+		return
+	}
+
+	// Claim the slot before running so a slow agent cannot cause the same
+	// schedule to fire twice.
+	claimed := s.db.Model(&BackupSchedule{}).
+		Where("id = ? AND next_run_at <= ?", schedule.ID, now).
+		Update("next_run_at", next)
+	if claimed.Error != nil {
+		s.logger.Error("failed to claim backup schedule",
+			zap.Error(claimed.Error),
+			zap.Uint("schedule_id", schedule.ID),
+		)
+		return
+	}
+	if claimed.RowsAffected == 0 {
+		return
+	}
+
+	status, message := s.executeSchedule(ctx, schedule, now)
+	updates["last_run_status"] = status
+	updates["last_run_message"] = message
+	s.saveRunResult(schedule.ID, updates)
+}
+
+func (s *Service) executeSchedule(ctx context.Context, schedule *BackupSchedule, now time.Time) (string, string) {
+	srv, err := s.serverSvc.GetServer(schedule.ServerID)
+	if err != nil {
+		s.logger.Error("failed to load server for backup schedule",
+			zap.Error(err),
+			zap.Uint("schedule_id", schedule.ID),
+			zap.Uint("server_id", schedule.ServerID),
+		)
+		return RunStatusFailed, fmt.Sprintf("failed to load server: %v", err)
+	}
+
+	if !srv.IsActive {
+		return RunStatusSkipped, "server is not active"
+	}
+	if !srv.BackupsEnabled {
+		s.logger.Info("skipping backup schedule: backups are not enabled for this server",
+			zap.Uint("schedule_id", schedule.ID),
+			zap.Uint("server_id", schedule.ServerID),
+		)
+		return RunStatusSkipped, "backups are not enabled for this server"
+	}
+
+	scope, err := s.scopeSvc.AuthorizedScope(authz.SystemPrincipal)
+	if err != nil {
+		return RunStatusFailed, fmt.Sprintf("failed to resolve scope: %v", err)
+	}
+
+	stacks, err := s.stackSvc.ListStacksForServer(ctx, schedule.ServerID, scope)
+	if err != nil {
+		s.logger.Error("failed to list stacks for backup schedule",
+			zap.Error(err),
+			zap.Uint("schedule_id", schedule.ID),
+			zap.Uint("server_id", schedule.ServerID),
+		)
+		return RunStatusFailed, fmt.Sprintf("failed to list stacks: %v", err)
+	}
+
+	var started, failed []string
+	for _, st := range stacks {
+		if !patterns.Matches(st.Name, schedule.StackPattern) {
+			continue
+		}
+
+		req := operations.OperationRequest{Command: createBackupCommand}
+		resp, err := s.opsSvc.StartOperation(ctx, authz.SystemPrincipal, schedule.ServerID, st.Name, req)
+		if err != nil {
+			s.logger.Error("scheduled backup failed to start",
+				zap.Error(err),
+				zap.Uint("schedule_id", schedule.ID),
+				zap.Uint("server_id", schedule.ServerID),
+				zap.String("stack_name", st.Name),
+			)
+			failed = append(failed, fmt.Sprintf("%s: %v", st.Name, err))
+			continue
+		}
+
+		s.opsSvc.RecordStartAndPersist(authz.SystemPrincipal, operationlogs.TriggerSourceScheduled, schedule.ServerID, st.Name, resp.OperationID, req, now)
+		started = append(started, st.Name)
+
+		s.logger.Info("scheduled backup started",
+			zap.Uint("schedule_id", schedule.ID),
+			zap.Uint("server_id", schedule.ServerID),
+			zap.String("stack_name", st.Name),
+			zap.String("operation_id", resp.OperationID),
+		)
+	}
+
+	switch {
+	case len(started) == 0 && len(failed) == 0:
+		return RunStatusSkipped, "no stacks matched the schedule pattern"
+	case len(failed) == 0:
+		return RunStatusSuccess, fmt.Sprintf("started backups for %d stack(s): %s", len(started), strings.Join(started, ", "))
+	case len(started) == 0:
+		return RunStatusFailed, strings.Join(failed, "; ")
+	default:
+		return RunStatusPartial, fmt.Sprintf("started %d, failed %d: %s", len(started), len(failed), strings.Join(failed, "; "))
+	}
+}
+
+func (s *Service) saveRunResult(scheduleID uint, updates map[string]any) {
+	if err := s.db.Model(&BackupSchedule{}).Where("id = ?", scheduleID).Updates(updates).Error; err != nil {
+		s.logger.Error("failed to record backup schedule run",
+			zap.Error(err),
+			zap.Uint("schedule_id", scheduleID),
+		)
+	}
+}
+
+func normalisePattern(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return "*"
+	}
+	return pattern
+}
@@ -0,0 +1,196 @@
+package backupschedules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/operationlogs"
+	"berth/internal/domain/operations"
+	"berth/internal/domain/server"
+	"berth/internal/domain/stack"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type fakeServers struct {
+	servers map[uint]*server.Server
+}
+
+func (f *fakeServers) GetServer(id uint) (*server.Server, error) {
+	srv, ok := f.servers[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return srv, nil
+}
+
+type fakeStacks struct {
+	stacks []stack.Stack
+}
+
+func (f *fakeStacks) ListStacksForServer(_ context.Context, _ uint, _ authz.ScopeSet) ([]stack.Stack, error) {
+	return f.stacks, nil
+}
+
+type fakeScope struct{}
+
+func (fakeScope) AuthorizedScope(authz.Principal) (authz.ScopeSet, error) {
+	return authz.NewScopeSet(nil, nil, nil, false, true), nil
+}
+
+type startedOp struct {
+	principal authz.Principal
+	stack     string
+	command   string
+}
+
+type fakeOps struct {
+	started  []startedOp
+	recorded []string
+	failFor  map[string]bool
+}
+
+func (f *fakeOps) StartOperation(_ context.Context, p authz.Principal, _ uint, stackname string, req operations.OperationRequest) (*operations.OperationStartData, error) {
+	if f.failFor[stackname] {
+		return nil, errors.New("agent unavailable")
+	}
+	f.started = append(f.started, startedOp{principal: p, stack: stackname, command: req.Command})
+	return &operations.OperationStartData{OperationID: "op-" + stackname}, nil
+}
+
+func (f *fakeOps) RecordStartAndPersist(_ authz.Principal, _ operationlogs.TriggerSource, _ uint, _ string, operationID string, _ operations.OperationRequest, _ time.Time) {
+	f.recorded = append(f.recorded, operationID)
+}
+
+func newTestService(t *testing.T, srv *server.Server, stacks []string) (*Service, *fakeOps) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:backupschedules_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&BackupSchedule{}))
+
+	list := make([]stack.Stack, len(stacks))
+	for i, name := range stacks {
+		list[i] = stack.Stack{Name: name}
+	}
+	ops := &fakeOps{failFor: map[string]bool{}}
+	svc := NewService(db, &fakeServers{servers: map[uint]*server.Server{srv.ID: srv}}, &fakeStacks{stacks: list}, fakeScope{}, ops, zap.NewNop())
+	return svc, ops
+}
+
+func backupServer(enabled bool) *server.Server {
+	srv := &server.Server{IsActive: true, BackupsEnabled: enabled, BackupPassword: "pw"}
+	srv.ID = 1
+	return srv
+}
+
+func makeDue(t *testing.T, svc *Service, id uint) {
+	t.Helper()
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, svc.db.Model(&BackupSchedule{}).Where("id = ?", id).Update("next_run_at", past).Error)
+}
+
+func TestCreateSchedule_DefaultsPatternAndComputesNextRun(t *testing.T) {
+	svc, _ := newTestService(t, backupServer(true), nil)
+
+	schedule, err := svc.CreateSchedule(1, CreateScheduleRequest{CronExpression: "0 3 * * *"}, 7)
+	require.NoError(t, err)
+
+	assert.Equal(t, "*", schedule.StackPattern)
+	assert.True(t, schedule.Enabled, "schedules are enabled unless explicitly disabled")
+	require.NotNil(t, schedule.NextRunAt)
+	assert.True(t, schedule.NextRunAt.After(time.Now()))
+	require.NotNil(t, schedule.CreatedByUserID)
+	assert.Equal(t, uint(7), *schedule.CreatedByUserID)
+}
+
+func TestRunDueSchedules_BacksUpMatchingStacksAsSystem(t *testing.T) {
+	svc, ops := newTestService(t, backupServer(true), []string{"app-web", "app-db", "monitoring"})
+
+	schedule, err := svc.CreateSchedule(1, CreateScheduleRequest{CronExpression: "@hourly", StackPattern: "app-*"}, 1)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	require.Len(t, ops.started, 2)
+	for _, op := range ops.started {
+		assert.True(t, op.principal.IsSystem(), "scheduled backups run as the system principal")
+		assert.Equal(t, createBackupCommand, op.command)
+	}
+	assert.ElementsMatch(t, []string{"op-app-web", "op-app-db"}, ops.recorded, "each started backup is recorded in operation logs")
+
+	reloaded, err := svc.GetSchedule(1, schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusSuccess, reloaded.LastRunStatus)
+	require.NotNil(t, reloaded.LastRunAt)
+	require.NotNil(t, reloaded.NextRunAt)
+	assert.True(t, reloaded.NextRunAt.After(time.Now()), "next run is advanced past now")
+}
+
+func TestRunDueSchedules_SkipsServersWithoutBackups(t *testing.T) {
+	svc, ops := newTestService(t, backupServer(false), []string{"app"})
+
+	schedule, err := svc.CreateSchedule(1, CreateScheduleRequest{CronExpression: "@hourly"}, 1)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	assert.Empty(t, ops.started)
+	reloaded, err := svc.GetSchedule(1, schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusSkipped, reloaded.LastRunStatus)
+}
+
+func TestRunDueSchedules_RecordsPartialFailure(t *testing.T) {
+	svc, ops := newTestService(t, backupServer(true), []string{"a", "b"})
+	ops.failFor["b"] = true
+
+	schedule, err := svc.CreateSchedule(1, CreateScheduleRequest{CronExpression: "@hourly"}, 1)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	reloaded, err := svc.GetSchedule(1, schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusPartial, reloaded.LastRunStatus)
+	assert.Contains(t, reloaded.LastRunMessage, "agent unavailable")
+}
+
+func TestRunDueSchedules_IgnoresDisabledAndNotYetDue(t *testing.T) {
+	svc, ops := newTestService(t, backupServer(true), []string{"app"})
+
+	disabled := false
+	off, err := svc.CreateSchedule(1, CreateScheduleRequest{CronExpression: "@hourly", Enabled: &disabled}, 1)
+	require.NoError(t, err)
+	makeDue(t, svc, off.ID)
+
+	_, err = svc.CreateSchedule(1, CreateScheduleRequest{CronExpression: "@hourly"}, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+	assert.Empty(t, ops.started)
+}
+
+func TestGetSchedule_ScopedToServer(t *testing.T) {
+	svc, _ := newTestService(t, backupServer(true), nil)
+
+	schedule, err := svc.CreateSchedule(1, CreateScheduleRequest{CronExpression: "@daily"}, 1)
+	require.NoError(t, err)
+
+	_, err = svc.GetSchedule(2, schedule.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
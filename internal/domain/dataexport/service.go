@@ -37,8 +37,10 @@ type ExportData struct {
 }
 
 type UserRoleMapping struct {
-	UserID uint `json:"user_id"`
-	RoleID uint `json:"role_id"`
+	UserID         uint       `json:"user_id"`
+	RoleID         uint       `json:"role_id"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	RevokeSessions bool       `json:"revoke_sessions,omitempty"`
 }
 
 type TOTPSecret struct {
@@ -161,17 +163,16 @@ func (s *Service) gatherExportData() (*ExportData, error) {
 		return nil, fmt.Errorf("failed to export server role stack permissions: %w", err)
 	}
 
-	var userRoles []struct {
-		UserID uint `gorm:"column:user_id"`
-		RoleID uint `gorm:"column:role_id"`
-	}
-	if err := s.db.Table("user_roles").Find(&userRoles).Error; err != nil {
+	var userRoles []user.UserRole
+	if err := s.db.Find(&userRoles).Error; err != nil {
 		return nil, fmt.Errorf("failed to export user roles: %w", err)
 	}
 	for _, ur := range userRoles {
 		data.UserRoles = append(data.UserRoles, UserRoleMapping{
-			UserID: ur.UserID,
-			RoleID: ur.RoleID,
+			UserID:         ur.UserID,
+			RoleID:         ur.RoleID,
+			ExpiresAt:      ur.ExpiresAt,
+			RevokeSessions: ur.RevokeSessions,
 		})
 	}
 
@@ -310,7 +311,7 @@ func (s *Service) importData(data *ExportData) (*ImportSummary, error) {
 	}
 
 	for _, ur := range data.UserRoles {
-		if err := tx.Exec("INSERT INTO user_roles (user_id, role_id) VALUES (?, ?)", ur.UserID, ur.RoleID).Error; err != nil {
+		if err := tx.Exec("INSERT INTO user_roles (user_id, role_id, expires_at, revoke_sessions) VALUES (?, ?, ?, ?)", ur.UserID, ur.RoleID, ur.ExpiresAt, ur.RevokeSessions).Error; err != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("failed to import user role mapping: %w", err)
 		}
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -24,6 +25,24 @@ type serverProvider interface {
 	GetServer(id uint) (*server.Server, error)
 }
 
+// DigestChangeListener is notified when a check finds a stack running a
+// different image digest than the previous check recorded, for example after
+// it was redeployed outside Berth. Listeners must not block.
+type DigestChangeListener interface {
+	OnImageDigestChanged(serverID uint, stackName string)
+}
+
+// UpdateAvailableListener is notified when a check first finds a newer image
+// for a container, or finds a newer image than the one previously reported.
+// Listeners must not block.
+type UpdateAvailableListener interface {
+	OnImageUpdateAvailable(update *ContainerImageUpdate)
+}
+
+type leaderGate interface {
+	IsLeader() bool
+}
+
 type Service struct {
 	db                 *gorm.DB
 	agentSvc           agentClient
@@ -33,6 +52,9 @@ type Service struct {
 	interval           time.Duration
 	enabled            bool
 	disabledRegistries map[string]bool
+	digestListeners    []DigestChangeListener
+	updateListeners    []UpdateAvailableListener
+	leader             leaderGate
 	ctx                context.Context
 	cancel             context.CancelFunc
 }
@@ -84,6 +106,24 @@ func NewService(db *gorm.DB, agentSvc agentClient, serverSvc serverProvider,
 	return svc
 }
 
+// AddDigestChangeListener registers l for digest change notifications. It must
+// be called during wiring, before Start.
+func (s *Service) AddDigestChangeListener(l DigestChangeListener) {
+	s.digestListeners = append(s.digestListeners, l)
+}
+
+// AddUpdateAvailableListener registers l for update availability
+// notifications. It must be called during wiring, before Start.
+func (s *Service) AddUpdateAvailableListener(l UpdateAvailableListener) {
+	s.updateListeners = append(s.updateListeners, l)
+}
+
+// SetLeaderGate limits the scheduled checks to the instance holding the
+// image update lease. Checks requested through the API always run.
+func (s *Service) SetLeaderGate(g leaderGate) {
+	s.leader = g
+}
+
 func (s *Service) Start() {
 	if !s.enabled {
 		s.logger.Info("image update check is disabled via configuration")
@@ -99,18 +139,25 @@ func (s *Service) checkLoop() {
 	ticker := time.NewTicker(s.interval)
 	defer ticker.Stop()
 
-	s.checkAllServers()
+	s.runScheduledCheck()
 
 	for {
 		select {
 		case <-ticker.C:
-			s.checkAllServers()
+			s.runScheduledCheck()
 		case <-s.ctx.Done():
 			return
 		}
 	}
 }
 
+func (s *Service) runScheduledCheck() {
+	if s.leader != nil && !s.leader.IsLeader() {
+		return
+	}
+	s.checkAllServers()
+}
+
 func (s *Service) checkAllServers() {
 	s.logger.Info("starting image update check for all servers")
 
@@ -234,6 +281,8 @@ func (s *Service) checkServer(serverID uint) {
 
 func (s *Service) processUpdateResults(serverID uint, results []ContainerImageCheckResult) {
 	now := time.Now()
+	var changedStacks []string
+	var newUpdates []ContainerImageUpdate
 
 	for _, result := range results {
 		updateAvailable := false
@@ -286,8 +335,14 @@ func (s *Service) processUpdateResults(serverID uint, results []ContainerImageCh
 					zap.String("container_name", result.ContainerName),
 					zap.Bool("update_available", updateAvailable),
 				)
+				if updateAvailable {
+					newUpdates = append(newUpdates, update)
+				}
 			}
 		} else {
+			if digestChanged(existing, result) && !slices.Contains(changedStacks, result.StackName) {
+				changedStacks = append(changedStacks, result.StackName)
+			}
 			if err := s.db.Model(&existing).
 				Select("CurrentImageName", "CurrentRepoDigest", "LatestRepoDigest", "UpdateAvailable", "LastCheckedAt", "CheckError").
 				Updates(update).Error; err != nil {
@@ -304,9 +359,36 @@ func (s *Service) processUpdateResults(serverID uint, results []ContainerImageCh
 					zap.String("container_name", result.ContainerName),
 					zap.Bool("update_available", updateAvailable),
 				)
+				if updateAvailable && (!existing.UpdateAvailable || existing.LatestRepoDigest != result.LatestRepoDigest) {
+					update.ID = existing.ID
+					newUpdates = append(newUpdates, update)
+				}
 			}
 		}
 	}
+
+	for _, stackName := range changedStacks {
+		s.logger.Info("running image digest changed",
+			zap.Uint("server_id", serverID),
+			zap.String("stack_name", stackName),
+		)
+		for _, l := range s.digestListeners {
+			l.OnImageDigestChanged(serverID, stackName)
+		}
+	}
+
+	for i := range newUpdates {
+		for _, l := range s.updateListeners {
+			l.OnImageUpdateAvailable(&newUpdates[i])
+		}
+	}
+}
+
+func digestChanged(existing ContainerImageUpdate, result ContainerImageCheckResult) bool {
+	if result.Error != "" || existing.CurrentRepoDigest == "" || result.CurrentRepoDigest == "" {
+		return false
+	}
+	return existing.CurrentRepoDigest != result.CurrentRepoDigest
 }
 
 func (s *Service) cleanupStaleRecords(serverID uint, currentResults []ContainerImageCheckResult) {
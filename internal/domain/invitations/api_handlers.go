@@ -0,0 +1,242 @@
+package invitations
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"berth/internal/domain/auth"
+	"berth/internal/domain/authz"
+	"berth/internal/domain/security"
+	"berth/internal/domain/session"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type invitationAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+type APIHandler struct {
+	service      *Service
+	auditService invitationAuditLogger
+	logger       *zap.Logger
+}
+
+func NewAPIHandler(service *Service, auditService invitationAuditLogger, logger *zap.Logger) *APIHandler {
+	return &APIHandler{
+		service:      service,
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+func (h *APIHandler) ListInvitations(c echo.Context) error {
+	invitations, err := h.service.List()
+	if err != nil {
+		return response.Internal(c, "Failed to fetch invitations")
+	}
+
+	return response.OK(c, ListInvitationsData{
+		Invitations: ToResponseList(invitations, time.Now()),
+	})
+}
+
+func (h *APIHandler) CreateInvitation(c echo.Context) error {
+	var req CreateInvitationRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	invitation, err := h.service.Create(req.Email, req.RoleIDs, Inviter{
+		ID:       p.UserID(),
+		Username: session.ResolveUsername(c),
+	})
+	if err != nil {
+		return h.adminError(c, err, "Failed to create invitation")
+	}
+
+	h.auditAdmin(c, security.EventUserInvitationCreated, invitation, map[string]any{
+		"roles": roleNames(invitation),
+	})
+
+	return response.Created(c, ToResponse(invitation, time.Now()))
+}
+
+func (h *APIHandler) ResendInvitation(c echo.Context) error {
+	id, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	invitation, err := h.service.Resend(id)
+	if err != nil {
+		return h.adminError(c, err, "Failed to resend invitation")
+	}
+
+	h.auditAdmin(c, security.EventUserInvitationResent, invitation, map[string]any{
+		"send_count": invitation.SendCount,
+	})
+
+	return response.OK(c, ToResponse(invitation, time.Now()))
+}
+
+func (h *APIHandler) RevokeInvitation(c echo.Context) error {
+	id, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	invitation, err := h.service.Revoke(id)
+	if err != nil {
+		return h.adminError(c, err, "Failed to revoke invitation")
+	}
+
+	h.auditAdmin(c, security.EventUserInvitationRevoked, invitation, nil)
+
+	return response.OK(c, RevokeInvitationData{
+		Message: "Invitation revoked successfully",
+	})
+}
+
+func (h *APIHandler) LookupInvitation(c echo.Context) error {
+	var req LookupInvitationRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	invitation, err := h.service.Lookup(req.Token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			return response.Err(c, http.StatusBadRequest, "invalid_token", "This invitation link is invalid or has expired")
+		}
+		return response.Internal(c, "Failed to look up invitation")
+	}
+
+	return response.OK(c, LookupInvitationData{
+		Email:     invitation.Email,
+		InvitedBy: invitation.InvitedBy,
+		Roles:     roleNames(invitation),
+		ExpiresAt: invitation.ExpiresAt,
+	})
+}
+
+func (h *APIHandler) AcceptInvitation(c echo.Context) error {
+	var req AcceptInvitationRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	user, invitation, err := h.service.Accept(req.Token, req.Username, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidToken):
+			return response.Err(c, http.StatusBadRequest, "invalid_token", "This invitation link is invalid or has expired")
+		case errors.Is(err, auth.ErrWeakPassword):
+			return response.Err(c, http.StatusBadRequest, "weak_password", err.Error())
+		case errors.Is(err, ErrUsernameTaken):
+			return response.Err(c, http.StatusConflict, "username_taken", "Username is already taken")
+		case errors.Is(err, ErrEmailInUse):
+			return response.Err(c, http.StatusConflict, "email_in_use", "An account with this email already exists")
+		default:
+			h.logger.Error("accept invitation failed", zap.Error(err))
+			return response.Internal(c, "Failed to accept invitation")
+		}
+	}
+
+	base := security.LogEvent{
+		ActorUserID:    &user.ID,
+		ActorUsername:  user.Username,
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetUserID:   &user.ID,
+		TargetType:     security.TargetTypeUser,
+		TargetID:       &user.ID,
+		TargetName:     user.Username,
+		Success:        true,
+	}
+
+	accepted := base
+	accepted.EventType = security.EventUserInvitationAccepted
+	accepted.Metadata = map[string]any{
+		"invitation_id": invitation.ID,
+		"email":         invitation.Email,
+		"invited_by":    invitation.InvitedBy,
+		"roles":         roleNames(invitation),
+	}
+	_ = h.auditService.Log(accepted)
+
+	created := base
+	created.EventType = security.EventUserCreated
+	created.Metadata = map[string]any{
+		"username":      user.Username,
+		"source":        "invitation",
+		"invitation_id": invitation.ID,
+	}
+	_ = h.auditService.Log(created)
+
+	return response.Created(c, AcceptInvitationData{
+		Message:  "Account created successfully. You can now log in.",
+		Username: user.Username,
+	})
+}
+
+func (h *APIHandler) adminError(c echo.Context, err error, fallback string) error {
+	switch {
+	case errors.Is(err, ErrInvitationNotFound):
+		return response.NotFound(c, "Invitation not found")
+	case errors.Is(err, ErrNotOpen):
+		return response.Err(c, http.StatusConflict, "invitation_closed", err.Error())
+	case errors.Is(err, ErrEmailInUse):
+		return response.Err(c, http.StatusConflict, "email_in_use", err.Error())
+	case errors.Is(err, ErrAlreadyInvited):
+		return response.Err(c, http.StatusConflict, "already_invited", err.Error())
+	case errors.Is(err, ErrRoleNotFound):
+		return response.BadRequest(c, err.Error())
+	default:
+		h.logger.Error(fallback, zap.Error(err))
+		return response.Internal(c, fallback)
+	}
+}
+
+func (h *APIHandler) auditAdmin(c echo.Context, eventType string, invitation *Invitation, metadata map[string]any) {
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return
+	}
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	metadata["email"] = invitation.Email
+
+	actorID := p.UserID()
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      eventType,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetType:     security.TargetTypeInvitation,
+		TargetID:       &invitation.ID,
+		TargetName:     invitation.Email,
+		Success:        true,
+		Metadata:       metadata,
+	})
+}
+
+func roleNames(invitation *Invitation) []string {
+	names := make([]string, len(invitation.Roles))
+	for i, role := range invitation.Roles {
+		names[i] = role.Name
+	}
+	return names
+}
@@ -0,0 +1,116 @@
+package invitations
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrCreateInvitationEmailRequired    = errors.New("Email is required")
+	ErrInvitationTokenRequired          = errors.New("Invitation token is required")
+	ErrAcceptInvitationFieldsRequired   = errors.New("Token, username and password are required")
+	ErrAcceptInvitationPasswordMismatch = errors.New("Passwords do not match")
+)
+
+type CreateInvitationRequest struct {
+	Email   string `json:"email"`
+	RoleIDs []uint `json:"role_ids"`
+}
+
+func (r *CreateInvitationRequest) Validate() error {
+	if r.Email == "" {
+		return ErrCreateInvitationEmailRequired
+	}
+	return nil
+}
+
+type LookupInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+func (r *LookupInvitationRequest) Validate() error {
+	if r.Token == "" {
+		return ErrInvitationTokenRequired
+	}
+	return nil
+}
+
+type AcceptInvitationRequest struct {
+	Token                string `json:"token"`
+	Username             string `json:"username"`
+	Password             string `json:"password"`
+	PasswordConfirmation string `json:"password_confirmation"`
+}
+
+func (r *AcceptInvitationRequest) Validate() error {
+	if r.Token == "" || r.Username == "" || r.Password == "" {
+		return ErrAcceptInvitationFieldsRequired
+	}
+	if r.Password != r.PasswordConfirmation {
+		return ErrAcceptInvitationPasswordMismatch
+	}
+	return nil
+}
+
+type InvitationRole struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+type InvitationInfo struct {
+	ID         uint             `json:"id"`
+	Email      string           `json:"email"`
+	Status     string           `json:"status"`
+	Roles      []InvitationRole `json:"roles"`
+	InvitedBy  string           `json:"invited_by"`
+	ExpiresAt  time.Time        `json:"expires_at"`
+	LastSentAt time.Time        `json:"last_sent_at"`
+	SendCount  int              `json:"send_count"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+type ListInvitationsData struct {
+	Invitations []InvitationInfo `json:"invitations"`
+}
+
+type RevokeInvitationData struct {
+	Message string `json:"message"`
+}
+
+type LookupInvitationData struct {
+	Email     string    `json:"email"`
+	InvitedBy string    `json:"invited_by"`
+	Roles     []string  `json:"roles"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AcceptInvitationData struct {
+	Message  string `json:"message"`
+	Username string `json:"username"`
+}
+
+func ToResponse(i *Invitation, now time.Time) InvitationInfo {
+	roles := make([]InvitationRole, len(i.Roles))
+	for idx, role := range i.Roles {
+		roles[idx] = InvitationRole{ID: role.ID, Name: role.Name}
+	}
+	return InvitationInfo{
+		ID:         i.ID,
+		Email:      i.Email,
+		Status:     i.Status(now),
+		Roles:      roles,
+		InvitedBy:  i.InvitedBy,
+		ExpiresAt:  i.ExpiresAt,
+		LastSentAt: i.LastSentAt,
+		SendCount:  i.SendCount,
+		CreatedAt:  i.CreatedAt,
+	}
+}
+
+func ToResponseList(invitations []Invitation, now time.Time) []InvitationInfo {
+	result := make([]InvitationInfo, len(invitations))
+	for idx := range invitations {
+		result[idx] = ToResponse(&invitations[idx], now)
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package invitations
+
+import (
+	"time"
+
+	usermodel "berth/internal/domain/user"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusExpired  = "expired"
+	StatusAccepted = "accepted"
+	StatusRevoked  = "revoked"
+)
+
+// Invitation lets the holder of Email create an account with the given roles.
+// The emailed token is an HMAC over the ID, Nonce and ExpiresAt, so nothing
+// secret is stored; resending rotates Nonce, invalidating earlier links.
+type Invitation struct {
+	ID             uint             `json:"id" gorm:"primaryKey"`
+	Email          string           `json:"email" gorm:"size:255;not null;index"`
+	Nonce          string           `json:"-" gorm:"size:64;not null"`
+	Roles          []usermodel.Role `json:"roles" gorm:"many2many:invitation_roles;"`
+	InvitedByID    uint             `json:"invited_by_id" gorm:"not null"`
+	InvitedBy      string           `json:"invited_by" gorm:"size:255"`
+	ExpiresAt      time.Time        `json:"expires_at" gorm:"not null"`
+	LastSentAt     time.Time        `json:"last_sent_at"`
+	SendCount      int              `json:"send_count" gorm:"not null;default:0"`
+	AcceptedAt     *time.Time       `json:"accepted_at,omitempty"`
+	AcceptedUserID *uint            `json:"accepted_user_id,omitempty"`
+	RevokedAt      *time.Time       `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// Status reports where the invitation is in its lifecycle at now.
+func (i *Invitation) Status(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return StatusAccepted
+	case i.RevokedAt != nil:
+		return StatusRevoked
+	case now.After(i.ExpiresAt):
+		return StatusExpired
+	default:
+		return StatusPending
+	}
+}
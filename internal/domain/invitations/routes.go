@@ -0,0 +1,18 @@
+package invitations
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
+
+func (h *APIHandler) RegisterAdminAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/invitations", h.ListInvitations, authz.Admin(permnames.AdminUsersRead))
+	reg.POST("/invitations", h.CreateInvitation, authz.Admin(permnames.AdminUsersWrite))
+	reg.POST("/invitations/:id/resend", h.ResendInvitation, authz.Admin(permnames.AdminUsersWrite))
+	reg.DELETE("/invitations/:id", h.RevokeInvitation, authz.Admin(permnames.AdminUsersWrite))
+}
+
+func (h *APIHandler) RegisterPublicAPIRoutes(reg *authz.Registrar) {
+	reg.POST("/invitations/lookup", h.LookupInvitation, authz.Public())
+	reg.POST("/invitations/accept", h.AcceptInvitation, authz.Public())
+}
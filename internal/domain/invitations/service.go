@@ -0,0 +1,362 @@
+package invitations
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const templateName = "user_invitation"
+
+var (
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrInvalidToken       = errors.New("invalid or expired invitation")
+	ErrNotOpen            = errors.New("invitation has already been accepted or revoked")
+	ErrEmailInUse         = errors.New("an account with this email already exists")
+	ErrAlreadyInvited     = errors.New("a pending invitation for this email already exists")
+	ErrRoleNotFound       = errors.New("one or more roles do not exist")
+	ErrUsernameTaken      = errors.New("username is already taken")
+)
+
+type invitationMailer interface {
+	SendTemplate(templateName string, to []string, subject string, data map[string]any) error
+}
+
+type passwordHasher interface {
+	HashPassword(password string) (string, error)
+}
+
+// Inviter identifies the admin sending an invitation.
+type Inviter struct {
+	ID       uint
+	Username string
+}
+
+type Service struct {
+	db      *gorm.DB
+	mailer  invitationMailer
+	hasher  passwordHasher
+	key     []byte
+	expiry  time.Duration
+	appName string
+	appURL  string
+	logger  *zap.Logger
+	now     func() time.Time
+}
+
+func NewService(cfg *config.Config, db *gorm.DB, mailer invitationMailer, hasher passwordHasher, logger *zap.Logger) *Service {
+	// Derive a key of our own so an invitation signature can never be
+	// mistaken for anything else signed with the JWT secret.
+	mac := hmac.New(sha256.New, []byte(cfg.JWT.SecretKey))
+	mac.Write([]byte("berth-user-invitation"))
+
+	return &Service{
+		db:      db,
+		mailer:  mailer,
+		hasher:  hasher,
+		key:     mac.Sum(nil),
+		expiry:  cfg.Auth.InvitationExpiry,
+		appName: cfg.App.Name,
+		appURL:  cfg.App.URL,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Create records an invitation for email and sends it. Nothing is stored if
+// the email cannot be sent.
+func (s *Service) Create(email string, roleIDs []uint, inviter Inviter) (*Invitation, error) {
+	email = strings.TrimSpace(email)
+
+	var invitation Invitation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.ensureInvitable(tx, email); err != nil {
+			return err
+		}
+
+		roles, err := loadRoles(tx, roleIDs)
+		if err != nil {
+			return err
+		}
+
+		nonce, err := newNonce()
+		if err != nil {
+			return err
+		}
+		now := s.now()
+		invitation = Invitation{
+			Email:       email,
+			Nonce:       nonce,
+			Roles:       roles,
+			InvitedByID: inviter.ID,
+			InvitedBy:   inviter.Username,
+			ExpiresAt:   now.Add(s.expiry),
+			LastSentAt:  now,
+			SendCount:   1,
+		}
+		if err := tx.Create(&invitation).Error; err != nil {
+			return fmt.Errorf("create invitation: %w", err)
+		}
+		return s.send(&invitation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// List returns invitations that have been neither accepted nor revoked,
+// newest first. Expired ones are included so they can be resent.
+func (s *Service) List() ([]Invitation, error) {
+	var invitations []Invitation
+	err := s.db.Preload("Roles").
+		Where("accepted_at IS NULL AND revoked_at IS NULL").
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+// Resend issues a fresh link with a new expiry. Links sent earlier stop
+// working.
+func (s *Service) Resend(id uint) (*Invitation, error) {
+
+	var invitation Invitation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := loadOpen(tx, id, &invitation); err != nil {
+			return err
+		}
+		nonce, err := newNonce()
+		if err != nil {
+			return err
+		}
+		now := s.now()
+		invitation.Nonce = nonce
+		invitation.ExpiresAt = now.Add(s.expiry)
+		invitation.LastSentAt = now
+		invitation.SendCount++
+		if err := tx.Model(&invitation).Select("Nonce", "ExpiresAt", "LastSentAt", "SendCount").Updates(&invitation).Error; err != nil {
+			return fmt.Errorf("update invitation: %w", err)
+		}
+		return s.send(&invitation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// Revoke cancels an open invitation so its link can no longer be used.
+func (s *Service) Revoke(id uint) (*Invitation, error) {
+	var invitation Invitation
+	if err := loadOpen(s.db, id, &invitation); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if err := s.db.Model(&invitation).Update("revoked_at", now).Error; err != nil {
+		return nil, fmt.Errorf("revoke invitation: %w", err)
+	}
+	invitation.RevokedAt = &now
+	return &invitation, nil
+}
+
+// Lookup returns the pending invitation a token was issued for.
+func (s *Service) Lookup(token string) (*Invitation, error) {
+	return s.verify(s.db, token)
+}
+
+// Accept creates the invited account with the chosen username and password.
+// The email address is treated as verified since the invitation reached it.
+func (s *Service) Accept(token, username, password string) (*usermodel.User, *Invitation, error) {
+	username = strings.TrimSpace(username)
+	hashedPassword, err := s.hasher.HashPassword(password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var user usermodel.User
+	var invitation *Invitation
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var verifyErr error
+		invitation, verifyErr = s.verify(tx, token)
+		if verifyErr != nil {
+			return verifyErr
+		}
+		if err := s.ensureNoAccount(tx, invitation.Email); err != nil {
+			return err
+		}
+
+		var taken int64
+		if err := tx.Model(&usermodel.User{}).Where("username = ?", username).Count(&taken).Error; err != nil {
+			return fmt.Errorf("check username: %w", err)
+		}
+		if taken > 0 {
+			return ErrUsernameTaken
+		}
+
+		now := s.now()
+		user = usermodel.User{
+			Username:        username,
+			Email:           invitation.Email,
+			Password:        hashedPassword,
+			EmailVerifiedAt: &now,
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("create user: %w", err)
+		}
+		if len(invitation.Roles) > 0 {
+			if err := tx.Model(&user).Association("Roles").Append(invitation.Roles); err != nil {
+				return fmt.Errorf("assign roles: %w", err)
+			}
+		}
+
+		res := tx.Model(&Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitation.ID).
+			Updates(map[string]any{"accepted_at": now, "accepted_user_id": user.ID})
+		if res.Error != nil {
+			return fmt.Errorf("mark invitation accepted: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidToken
+		}
+		invitation.AcceptedAt = &now
+		invitation.AcceptedUserID = &user.ID
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &user, invitation, nil
+}
+
+func (s *Service) verify(db *gorm.DB, token string) (*Invitation, error) {
+	idPart, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	id, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var invitation Invitation
+	if err := db.Preload("Roles").First(&invitation, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("load invitation: %w", err)
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.sign(&invitation))) {
+		return nil, ErrInvalidToken
+	}
+	if invitation.Status(s.now()) != StatusPending {
+		return nil, ErrInvalidToken
+	}
+	return &invitation, nil
+}
+
+func (s *Service) token(invitation *Invitation) string {
+	return fmt.Sprintf("%d.%s", invitation.ID, s.sign(invitation))
+}
+
+func (s *Service) sign(invitation *Invitation) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%d|%s|%s|%d", invitation.ID, strings.ToLower(invitation.Email), invitation.Nonce, invitation.ExpiresAt.Unix())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Service) send(invitation *Invitation) error {
+	acceptURL := fmt.Sprintf("%s/auth/accept-invitation?token=%s", s.appURL, s.token(invitation))
+	err := s.mailer.SendTemplate(templateName, []string{invitation.Email}, fmt.Sprintf("You have been invited to %s", s.appName), map[string]any{
+		"Email":          invitation.Email,
+		"InvitedBy":      invitation.InvitedBy,
+		"AcceptURL":      acceptURL,
+		"ExpiryDuration": s.expiry.String(),
+		"AppName":        s.appName,
+	})
+	if err != nil {
+		s.logger.Error("send invitation email failed",
+			zap.Uint("invitation_id", invitation.ID),
+			zap.String("email", invitation.Email),
+			zap.Error(err))
+		return fmt.Errorf("send invitation email: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) ensureInvitable(db *gorm.DB, email string) error {
+	if err := s.ensureNoAccount(db, email); err != nil {
+		return err
+	}
+	var pending int64
+	if err := db.Model(&Invitation{}).
+		Where("LOWER(email) = LOWER(?) AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", email, s.now()).
+		Count(&pending).Error; err != nil {
+		return fmt.Errorf("check pending invitations: %w", err)
+	}
+	if pending > 0 {
+		return ErrAlreadyInvited
+	}
+	return nil
+}
+
+func (s *Service) ensureNoAccount(db *gorm.DB, email string) error {
+	var existing int64
+	if err := db.Model(&usermodel.User{}).Where("LOWER(email) = LOWER(?)", email).Count(&existing).Error; err != nil {
+		return fmt.Errorf("check existing account: %w", err)
+	}
+	if existing > 0 {
+		return ErrEmailInUse
+	}
+	return nil
+}
+
+func loadOpen(db *gorm.DB, id uint, invitation *Invitation) error {
+	if err := db.Preload("Roles").First(invitation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvitationNotFound
+		}
+		return fmt.Errorf("load invitation: %w", err)
+	}
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil {
+		return ErrNotOpen
+	}
+	return nil
+}
+
+func loadRoles(db *gorm.DB, roleIDs []uint) ([]usermodel.Role, error) {
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+	var roles []usermodel.Role
+	if err := db.Where("id IN ?", roleIDs).Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("load roles: %w", err)
+	}
+	seen := make(map[uint]struct{}, len(roleIDs))
+	for _, id := range roleIDs {
+		seen[id] = struct{}{}
+	}
+	if len(roles) != len(seen) {
+		return nil, ErrRoleNotFound
+	}
+	return roles, nil
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate invitation nonce: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,217 @@
+package invitations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type sentMail struct {
+	template string
+	to       []string
+	data     map[string]any
+}
+
+type fakeMailer struct {
+	sent []sentMail
+	err  error
+}
+
+func (f *fakeMailer) SendTemplate(templateName string, to []string, _ string, data map[string]any) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, sentMail{template: templateName, to: to, data: data})
+	return nil
+}
+
+func (f *fakeMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	require.NotEmpty(t, f.sent)
+	url, _ := f.sent[len(f.sent)-1].data["AcceptURL"].(string)
+	_, token, ok := strings.Cut(url, "token=")
+	require.True(t, ok, "accept URL %q has no token", url)
+	return token
+}
+
+type fakeHasher struct{}
+
+func (fakeHasher) HashPassword(password string) (string, error) {
+	return "hashed:" + password, nil
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestService(t *testing.T) (*Service, *gorm.DB, *fakeMailer, *testClock) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:invitations_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&usermodel.User{}, &usermodel.Role{}, &usermodel.UserRole{}, &usermodel.Permission{}, &Invitation{}))
+
+	cfg := &config.Config{}
+	cfg.App.Name = "berth"
+	cfg.App.URL = "https://berth.example.com"
+	cfg.JWT.SecretKey = "test-secret"
+	cfg.Auth.InvitationExpiry = 72 * time.Hour
+
+	mailer := &fakeMailer{}
+	clock := &testClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	svc := NewService(cfg, database, mailer, fakeHasher{}, zap.NewNop())
+	svc.now = func() time.Time { return clock.now }
+	return svc, database, mailer, clock
+}
+
+var inviter = Inviter{ID: 1, Username: "admin"}
+
+func TestCreate_SendsSignedInvitation(t *testing.T) {
+	svc, database, mailer, _ := newTestService(t)
+	role := usermodel.Role{Name: "operators"}
+	require.NoError(t, database.Create(&role).Error)
+
+	invitation, err := svc.Create("new@example.com", []uint{role.ID}, inviter)
+	require.NoError(t, err)
+	require.Len(t, mailer.sent, 1)
+	assert.Equal(t, "user_invitation", mailer.sent[0].template)
+	assert.Equal(t, []string{"new@example.com"}, mailer.sent[0].to)
+	assert.Equal(t, "admin", mailer.sent[0].data["InvitedBy"])
+
+	found, err := svc.Lookup(mailer.lastToken(t))
+	require.NoError(t, err)
+	assert.Equal(t, invitation.ID, found.ID)
+	require.Len(t, found.Roles, 1)
+	assert.Equal(t, "operators", found.Roles[0].Name)
+}
+
+func TestCreate_RejectsExistingAccountsAndDuplicates(t *testing.T) {
+	svc, database, _, _ := newTestService(t)
+	require.NoError(t, database.Create(&usermodel.User{Username: "taken", Email: "taken@example.com", Password: "x"}).Error)
+
+	_, err := svc.Create("TAKEN@example.com", nil, inviter)
+	assert.ErrorIs(t, err, ErrEmailInUse)
+
+	_, err = svc.Create("new@example.com", nil, inviter)
+	require.NoError(t, err)
+	_, err = svc.Create("new@example.com", nil, inviter)
+	assert.ErrorIs(t, err, ErrAlreadyInvited)
+
+	_, err = svc.Create("other@example.com", []uint{999}, inviter)
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+}
+
+func TestCreate_MailFailureStoresNothing(t *testing.T) {
+	svc, database, mailer, _ := newTestService(t)
+	mailer.err = errors.New("smtp down")
+
+	_, err := svc.Create("new@example.com", nil, inviter)
+	require.Error(t, err)
+
+	var count int64
+	require.NoError(t, database.Model(&Invitation{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestLookup_RejectsTamperedAndExpiredTokens(t *testing.T) {
+	svc, _, mailer, clock := newTestService(t)
+	_, err := svc.Create("new@example.com", nil, inviter)
+	require.NoError(t, err)
+	token := mailer.lastToken(t)
+
+	for _, bad := range []string{"", "nope", "1.deadbeef", "2." + strings.SplitN(token, ".", 2)[1]} {
+		_, err := svc.Lookup(bad)
+		assert.ErrorIs(t, err, ErrInvalidToken, "token %q", bad)
+	}
+
+	clock.advance(73 * time.Hour)
+	_, err = svc.Lookup(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestResend_RotatesToken(t *testing.T) {
+	svc, _, mailer, clock := newTestService(t)
+	invitation, err := svc.Create("new@example.com", nil, inviter)
+	require.NoError(t, err)
+	oldToken := mailer.lastToken(t)
+
+	clock.advance(73 * time.Hour)
+	resent, err := svc.Resend(invitation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resent.SendCount)
+	assert.Equal(t, clock.now.Add(72*time.Hour), resent.ExpiresAt)
+
+	_, err = svc.Lookup(oldToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = svc.Lookup(mailer.lastToken(t))
+	assert.NoError(t, err)
+}
+
+func TestRevoke_InvalidatesToken(t *testing.T) {
+	svc, _, mailer, _ := newTestService(t)
+	invitation, err := svc.Create("new@example.com", nil, inviter)
+	require.NoError(t, err)
+
+	_, err = svc.Revoke(invitation.ID)
+	require.NoError(t, err)
+
+	_, err = svc.Lookup(mailer.lastToken(t))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = svc.Revoke(invitation.ID)
+	assert.ErrorIs(t, err, ErrNotOpen)
+	_, err = svc.Resend(invitation.ID)
+	assert.ErrorIs(t, err, ErrNotOpen)
+	_, err = svc.Revoke(999)
+	assert.ErrorIs(t, err, ErrInvitationNotFound)
+
+	list, err := svc.List()
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestAccept_CreatesVerifiedUserWithRoles(t *testing.T) {
+	svc, database, mailer, _ := newTestService(t)
+	role := usermodel.Role{Name: "operators"}
+	require.NoError(t, database.Create(&role).Error)
+	require.NoError(t, database.Create(&usermodel.User{Username: "existing", Email: "existing@example.com", Password: "x"}).Error)
+
+	_, err := svc.Create("new@example.com", []uint{role.ID}, inviter)
+	require.NoError(t, err)
+	token := mailer.lastToken(t)
+
+	_, _, err = svc.Accept(token, "existing", "Secret123")
+	assert.ErrorIs(t, err, ErrUsernameTaken)
+
+	user, invitation, err := svc.Accept(token, "newbie", "Secret123")
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", user.Email)
+	assert.Equal(t, "hashed:Secret123", user.Password)
+	assert.NotNil(t, user.EmailVerifiedAt)
+	assert.Equal(t, user.ID, *invitation.AcceptedUserID)
+
+	var stored usermodel.User
+	require.NoError(t, database.Preload("Roles").First(&stored, user.ID).Error)
+	require.Len(t, stored.Roles, 1)
+	assert.Equal(t, "operators", stored.Roles[0].Name)
+
+	_, _, err = svc.Accept(token, "again", "Secret123")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -0,0 +1,23 @@
+package leader
+
+import (
+	"berth/internal/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+type APIHandler struct {
+	service *Service
+}
+
+func NewAPIHandler(service *Service) *APIHandler {
+	return &APIHandler{service: service}
+}
+
+func (h *APIHandler) GetStatus(c echo.Context) error {
+	status, err := h.service.Status()
+	if err != nil {
+		return response.Internal(c, "Failed to fetch leader leases")
+	}
+	return response.OK(c, status)
+}
@@ -0,0 +1,19 @@
+package leader
+
+import "time"
+
+type LeaseInfo struct {
+	Name       string    `json:"name"`
+	Holder     string    `json:"holder"`
+	Active     bool      `json:"active"`
+	Self       bool      `json:"self"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	RenewedAt  time.Time `json:"renewed_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type StatusData struct {
+	Enabled    bool        `json:"enabled"`
+	InstanceID string      `json:"instance_id"`
+	Leases     []LeaseInfo `json:"leases"`
+}
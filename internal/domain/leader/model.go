@@ -0,0 +1,17 @@
+package leader
+
+import "time"
+
+// Lease records which instance runs a background worker. The holder renews
+// it while alive; once ExpiresAt passes any instance may take it over.
+type Lease struct {
+	Name       string    `gorm:"primaryKey;size:100"`
+	Holder     string    `gorm:"size:255;not null"`
+	AcquiredAt time.Time `gorm:"not null"`
+	RenewedAt  time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"index;not null"`
+}
+
+func (Lease) TableName() string {
+	return "leader_leases"
+}
@@ -0,0 +1,10 @@
+package leader
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
+
+func (h *APIHandler) RegisterAdminAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/leader/leases", h.GetStatus, authz.Admin(permnames.AdminSystemRead))
+}
@@ -0,0 +1,296 @@
+package leader
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"berth/internal/pkg/config"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Lease names, one per background worker that must only run on one
+// instance at a time.
+const (
+	LeaseImageUpdates        = "image-updates"
+	LeaseVulnscanPoller      = "vulnscan-poller"
+	LeaseScanSchedules       = "scan-schedules"
+	LeaseBackupSchedules     = "backup-schedules"
+	LeaseOperationSchedules  = "operation-schedules"
+	LeasePruneSchedules      = "prune-schedules"
+	LeaseUpdateDigests       = "update-digests"
+	LeaseAutoUpdates         = "auto-updates"
+	LeaseWebhooks            = "webhooks"
+	LeaseRetention           = "retention"
+	LeaseTokenMaintenance    = "token-maintenance"
+	LeaseRoleExpiry          = "role-expiry"
+	LeaseAccessRequestExpiry = "access-request-expiry"
+)
+
+// Service elects one instance per lease using rows in the application
+// database. With election disabled every gate reports leadership, which is
+// the single-instance behaviour.
+type Service struct {
+	db         *gorm.DB
+	logger     *zap.Logger
+	enabled    bool
+	instanceID string
+	ttl        time.Duration
+	renewEvery time.Duration
+
+	mu        sync.RWMutex
+	heldUntil map[string]time.Time
+	names     []string
+
+	stop    chan struct{}
+	stopped chan struct{}
+	now     func() time.Time
+}
+
+func NewService(db *gorm.DB, cfg config.LeaderConfig, logger *zap.Logger) *Service {
+	instanceID := cfg.InstanceID
+	if instanceID == "" {
+		instanceID = defaultInstanceID()
+	}
+	return &Service{
+		db:         db,
+		logger:     logger,
+		enabled:    cfg.Enabled,
+		instanceID: instanceID,
+		ttl:        cfg.LeaseTTL,
+		renewEvery: cfg.LeaseTTL / 3,
+		heldUntil:  make(map[string]time.Time),
+		now:        time.Now,
+	}
+}
+
+// defaultInstanceID combines the hostname with a random suffix so that two
+// processes on one host never share an identity.
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "berth"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+func (s *Service) InstanceID() string {
+	return s.instanceID
+}
+
+// Gate returns the leadership check for the named lease. Gates must be
+// requested before Start so the lease is contested from the first round.
+func (s *Service) Gate(name string) *Gate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !containsName(s.names, name) {
+		s.names = append(s.names, name)
+	}
+	return &Gate{service: s, name: name}
+}
+
+// Gate reports whether this instance currently holds one lease. Workers
+// check it on every tick and skip the tick when it reports false.
+type Gate struct {
+	service *Service
+	name    string
+}
+
+func (g *Gate) IsLeader() bool {
+	return g.service.isLeader(g.name)
+}
+
+func (s *Service) isLeader(name string) bool {
+	if !s.enabled {
+		return true
+	}
+	s.mu.RLock()
+	until, ok := s.heldUntil[name]
+	s.mu.RUnlock()
+	return ok && s.now().Before(until)
+}
+
+func (s *Service) Start(context.Context) error {
+	if !s.enabled {
+		s.logger.Info("leader election disabled; running all background workers")
+		return nil
+	}
+
+	s.logger.Info("starting leader election",
+		zap.String("instance_id", s.instanceID),
+		zap.Duration("lease_ttl", s.ttl),
+	)
+	s.renewAll()
+
+	s.stop = make(chan struct{})
+	s.stopped = make(chan struct{})
+	go s.loop()
+	return nil
+}
+
+// Stop releases every lease this instance holds so another instance can
+// take over without waiting for them to expire.
+func (s *Service) Stop(context.Context) error {
+	if s.stop == nil {
+		return nil
+	}
+	close(s.stop)
+	<-s.stopped
+
+	s.mu.Lock()
+	held := make([]string, 0, len(s.heldUntil))
+	for name := range s.heldUntil {
+		held = append(held, name)
+	}
+	s.heldUntil = make(map[string]time.Time)
+	s.mu.Unlock()
+
+	if len(held) == 0 {
+		return nil
+	}
+	err := s.db.Model(&Lease{}).
+		Where("name IN ? AND holder = ?", held, s.instanceID).
+		Update("expires_at", s.now().UTC()).Error
+	if err != nil {
+		s.logger.Error("failed to release leader leases", zap.Error(err))
+	}
+	return nil
+}
+
+func (s *Service) loop() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.renewEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.renewAll()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *Service) renewAll() {
+	s.mu.RLock()
+	names := append([]string(nil), s.names...)
+	s.mu.RUnlock()
+
+	for _, name := range names {
+		s.renew(name)
+	}
+}
+
+// renew acquires or extends one lease. Leadership is kept locally for one
+// renewal interval less than the lease lasts, so a holder that cannot reach
+// the database stops working before anyone else can take over.
+func (s *Service) renew(name string) {
+	started := s.now()
+	acquired, err := s.tryAcquire(name, started.UTC())
+	if err != nil {
+		s.logger.Error("leader lease renewal failed", zap.String("lease", name), zap.Error(err))
+		return
+	}
+
+	s.mu.Lock()
+	_, wasLeader := s.heldUntil[name]
+	if acquired {
+		s.heldUntil[name] = started.Add(s.ttl - s.renewEvery)
+	} else {
+		delete(s.heldUntil, name)
+	}
+	s.mu.Unlock()
+
+	switch {
+	case acquired && !wasLeader:
+		s.logger.Info("acquired leader lease", zap.String("lease", name), zap.String("instance_id", s.instanceID))
+	case !acquired && wasLeader:
+		s.logger.Warn("lost leader lease", zap.String("lease", name), zap.String("instance_id", s.instanceID))
+	}
+}
+
+func (s *Service) tryAcquire(name string, now time.Time) (bool, error) {
+	expires := now.Add(s.ttl)
+
+	// Columns are assigned in key order, so acquired_at is evaluated against
+	// the previous holder even on MySQL, which applies SET left to right.
+	res := s.db.Model(&Lease{}).
+		Where("name = ? AND (holder = ? OR expires_at <= ?)", name, s.instanceID, now).
+		Updates(map[string]any{
+			"acquired_at": gorm.Expr("CASE WHEN holder = ? THEN acquired_at ELSE ? END", s.instanceID, now),
+			"expires_at":  expires,
+			"holder":      s.instanceID,
+			"renewed_at":  now,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return true, nil
+	}
+
+	res = s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&Lease{
+		Name:       name,
+		Holder:     s.instanceID,
+		AcquiredAt: now,
+		RenewedAt:  now,
+		ExpiresAt:  expires,
+	})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// Status lists every lease with its holder, marking the ones this instance
+// holds.
+func (s *Service) Status() (*StatusData, error) {
+	data := &StatusData{
+		Enabled:    s.enabled,
+		InstanceID: s.instanceID,
+		Leases:     []LeaseInfo{},
+	}
+	if !s.enabled {
+		return data, nil
+	}
+
+	var leases []Lease
+	if err := s.db.Order("name ASC").Find(&leases).Error; err != nil {
+		return nil, fmt.Errorf("list leader leases: %w", err)
+	}
+
+	now := s.now()
+	for _, l := range leases {
+		active := now.Before(l.ExpiresAt)
+		data.Leases = append(data.Leases, LeaseInfo{
+			Name:       l.Name,
+			Holder:     l.Holder,
+			Active:     active,
+			Self:       active && l.Holder == s.instanceID,
+			AcquiredAt: l.AcquiredAt,
+			RenewedAt:  l.RenewedAt,
+			ExpiresAt:  l.ExpiresAt,
+		})
+	}
+	return data, nil
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:leader_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&Lease{}))
+	sqlDB, err := database.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return database
+}
+
+func newTestService(t *testing.T, db *gorm.DB, id string, clock *testClock) *Service {
+	t.Helper()
+	svc := NewService(db, config.LeaderConfig{Enabled: true, InstanceID: id, LeaseTTL: 30 * time.Second}, zap.NewNop())
+	svc.now = clock.Now
+	return svc
+}
+
+func TestSingleLeaderPerLease(t *testing.T) {
+	db := newTestDB(t)
+	clock := &testClock{now: time.Now()}
+	a := newTestService(t, db, "a", clock)
+	b := newTestService(t, db, "b", clock)
+	gateA, gateB := a.Gate(LeaseRetention), b.Gate(LeaseRetention)
+
+	a.renewAll()
+	b.renewAll()
+	assert.True(t, gateA.IsLeader())
+	assert.False(t, gateB.IsLeader())
+
+	clock.now = clock.now.Add(10 * time.Second)
+	a.renewAll()
+	b.renewAll()
+	assert.True(t, gateA.IsLeader(), "renewal keeps the lease")
+	assert.False(t, gateB.IsLeader())
+}
+
+func TestTakeoverAfterLeaderStopsRenewing(t *testing.T) {
+	db := newTestDB(t)
+	clock := &testClock{now: time.Now()}
+	a := newTestService(t, db, "a", clock)
+	b := newTestService(t, db, "b", clock)
+	gateA, gateB := a.Gate(LeaseWebhooks), b.Gate(LeaseWebhooks)
+
+	a.renewAll()
+	b.renewAll()
+	require.True(t, gateA.IsLeader())
+
+	clock.now = clock.now.Add(25 * time.Second)
+	assert.False(t, gateA.IsLeader(), "leadership lapses locally before the lease expires")
+	b.renewAll()
+	assert.False(t, gateB.IsLeader(), "lease has not expired yet")
+
+	clock.now = clock.now.Add(10 * time.Second)
+	b.renewAll()
+	assert.True(t, gateB.IsLeader())
+
+	a.renewAll()
+	assert.False(t, gateA.IsLeader())
+}
+
+func TestStopReleasesLeases(t *testing.T) {
+	db := newTestDB(t)
+	a := NewService(db, config.LeaderConfig{Enabled: true, InstanceID: "a", LeaseTTL: time.Minute}, zap.NewNop())
+	b := NewService(db, config.LeaderConfig{Enabled: true, InstanceID: "b", LeaseTTL: time.Minute}, zap.NewNop())
+	gateA, gateB := a.Gate(LeaseScanSchedules), b.Gate(LeaseScanSchedules)
+
+	require.NoError(t, a.Start(context.Background()))
+	require.True(t, gateA.IsLeader())
+	require.NoError(t, a.Stop(context.Background()))
+	assert.False(t, gateA.IsLeader())
+
+	b.renewAll()
+	assert.True(t, gateB.IsLeader(), "a released lease is taken over immediately")
+}
+
+func TestStatusListsLeases(t *testing.T) {
+	db := newTestDB(t)
+	clock := &testClock{now: time.Now()}
+	a := newTestService(t, db, "a", clock)
+	b := newTestService(t, db, "b", clock)
+	a.Gate(LeaseRetention)
+	b.Gate(LeaseRetention)
+	b.Gate(LeaseWebhooks)
+	a.renewAll()
+	b.renewAll()
+
+	status, err := a.Status()
+	require.NoError(t, err)
+	assert.Equal(t, "a", status.InstanceID)
+	require.Len(t, status.Leases, 2)
+	assert.Equal(t, LeaseRetention, status.Leases[0].Name)
+	assert.Equal(t, "a", status.Leases[0].Holder)
+	assert.True(t, status.Leases[0].Self)
+	assert.Equal(t, LeaseWebhooks, status.Leases[1].Name)
+	assert.Equal(t, "b", status.Leases[1].Holder)
+	assert.False(t, status.Leases[1].Self)
+	assert.True(t, status.Leases[1].Active)
+}
+
+func TestDisabledElectionAlwaysLeads(t *testing.T) {
+	svc := NewService(nil, config.LeaderConfig{}, zap.NewNop())
+	assert.True(t, svc.Gate(LeaseRetention).IsLeader())
+
+	status, err := svc.Status()
+	require.NoError(t, err)
+	assert.False(t, status.Enabled)
+	assert.Empty(t, status.Leases)
+}
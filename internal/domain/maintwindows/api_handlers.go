@@ -0,0 +1,187 @@
+package maintwindows
+
+import (
+	"errors"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/security"
+	"berth/internal/domain/session"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type windowAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+type APIHandler struct {
+	service      *Service
+	auditService windowAuditLogger
+}
+
+func NewAPIHandler(service *Service, auditService windowAuditLogger) *APIHandler {
+	return &APIHandler{
+		service:      service,
+		auditService: auditService,
+	}
+}
+
+func (h *APIHandler) GetStackStatus(c echo.Context) error {
+	serverID, stackName, err := echoparams.GetServerIDAndStackName(c)
+	if err != nil {
+		return err
+	}
+
+	status, err := h.service.Status(serverID, stackName)
+	if err != nil {
+		return response.Internal(c, "Failed to evaluate maintenance windows")
+	}
+
+	return response.OK(c, StatusData{
+		Status: *status,
+	})
+}
+
+func (h *APIHandler) ListWindows(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+
+	windows, err := h.service.ListWindows(serverID)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch maintenance windows")
+	}
+
+	return response.OK(c, ListWindowsData{
+		Windows: windows,
+	})
+}
+
+func (h *APIHandler) CreateWindow(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req WindowRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	window, err := h.service.CreateWindow(serverID, req, p.UserID())
+	if err != nil {
+		return response.Internal(c, "Failed to create maintenance window")
+	}
+
+	h.audit(c, p, security.EventServerMaintenanceWindowCreated, window)
+
+	return response.Created(c, GetWindowData{
+		Window: *window,
+	})
+}
+
+func (h *APIHandler) UpdateWindow(c echo.Context) error {
+	p, existing, err := h.loadWindow(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	var req WindowRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	window, err := h.service.UpdateWindow(existing.ServerID, existing.ID, req)
+	if err != nil {
+		return response.Internal(c, "Failed to update maintenance window")
+	}
+
+	h.audit(c, p, security.EventServerMaintenanceWindowUpdated, window)
+
+	return response.OK(c, GetWindowData{
+		Window: *window,
+	})
+}
+
+func (h *APIHandler) DeleteWindow(c echo.Context) error {
+	p, existing, err := h.loadWindow(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	if err := h.service.DeleteWindow(existing.ServerID, existing.ID); err != nil {
+		return response.Internal(c, "Failed to delete maintenance window")
+	}
+
+	h.audit(c, p, security.EventServerMaintenanceWindowDeleted, existing)
+
+	return response.OK(c, DeleteWindowMessageData{
+		Message: "Maintenance window deleted successfully",
+	})
+}
+
+// loadWindow resolves the window named in the path. A nil window with a nil
+// error means a response has already been written.
+func (h *APIHandler) loadWindow(c echo.Context) (authz.Principal, *MaintenanceWindow, error) {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	windowID, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	window, err := h.service.GetWindow(serverID, windowID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return p, nil, response.NotFound(c, "Maintenance window not found")
+		}
+		return p, nil, response.Internal(c, "Failed to fetch maintenance window")
+	}
+
+	return p, window, nil
+}
+
+func (h *APIHandler) audit(c echo.Context, p authz.Principal, eventType string, window *MaintenanceWindow) {
+	actorID := p.UserID()
+	windowID := window.ID
+	serverID := window.ServerID
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      eventType,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetType:     security.TargetTypeMaintenanceWindow,
+		TargetID:       &windowID,
+		TargetName:     window.Name,
+		Success:        true,
+		Metadata: map[string]any{
+			"kind":             window.Kind,
+			"stack_pattern":    window.StackPattern,
+			"cron_expression":  window.CronExpression,
+			"duration_minutes": window.DurationMinutes,
+			"starts_at":        window.StartsAt,
+			"ends_at":          window.EndsAt,
+			"enabled":          window.Enabled,
+		},
+		ServerID: &serverID,
+	})
+}
@@ -0,0 +1,95 @@
+package maintwindows
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"berth/internal/pkg/cronspec"
+)
+
+// maxDurationMinutes caps a recurring window at one week.
+const maxDurationMinutes = 7 * 24 * 60
+
+var (
+	ErrNameRequired        = errors.New("name is required")
+	ErrKindInvalid         = errors.New("kind must be recurring or freeze")
+	ErrStackPatternInvalid = errors.New("stack_pattern must not contain '/' or whitespace")
+	ErrRecurringFields     = errors.New("recurring windows require cron_expression and duration_minutes, and no starts_at or ends_at")
+	ErrDurationRange       = errors.New("duration_minutes must be between 1 and 10080")
+	ErrCronNeverOpens      = errors.New("cron_expression never fires")
+	ErrFreezeFields        = errors.New("freezes require starts_at and ends_at, and no cron_expression or duration_minutes")
+	ErrFreezeRange         = errors.New("ends_at must be after starts_at")
+)
+
+type WindowRequest struct {
+	Name            string     `json:"name"`
+	StackPattern    string     `json:"stack_pattern,omitempty"`
+	Kind            string     `json:"kind"`
+	CronExpression  string     `json:"cron_expression,omitempty"`
+	DurationMinutes int        `json:"duration_minutes,omitempty"`
+	StartsAt        *time.Time `json:"starts_at,omitempty"`
+	EndsAt          *time.Time `json:"ends_at,omitempty"`
+	Enabled         *bool      `json:"enabled,omitempty"`
+}
+
+func (r *WindowRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrNameRequired
+	}
+	if strings.ContainsAny(r.StackPattern, "/ \t\r\n") {
+		return ErrStackPatternInvalid
+	}
+	switch r.Kind {
+	case KindRecurring:
+		if r.CronExpression == "" || r.DurationMinutes == 0 || r.StartsAt != nil || r.EndsAt != nil {
+			return ErrRecurringFields
+		}
+		if r.DurationMinutes < 1 || r.DurationMinutes > maxDurationMinutes {
+			return ErrDurationRange
+		}
+		next, err := cronspec.Next(r.CronExpression, time.Now())
+		if err != nil {
+			return err
+		}
+		if next.IsZero() {
+			return ErrCronNeverOpens
+		}
+		return nil
+	case KindFreeze:
+		if r.StartsAt == nil || r.EndsAt == nil || r.CronExpression != "" || r.DurationMinutes != 0 {
+			return ErrFreezeFields
+		}
+		if !r.EndsAt.After(*r.StartsAt) {
+			return ErrFreezeRange
+		}
+		return nil
+	default:
+		return ErrKindInvalid
+	}
+}
+
+// Status describes whether gated commands may run on a stack right now.
+// Gated is false when no window or freeze applies to the stack at all.
+type Status struct {
+	Gated      bool       `json:"gated"`
+	Open       bool       `json:"open"`
+	Reason     string     `json:"reason,omitempty"`
+	NextOpenAt *time.Time `json:"next_open_at,omitempty"`
+}
+
+type ListWindowsData struct {
+	Windows []MaintenanceWindow `json:"windows"`
+}
+
+type GetWindowData struct {
+	Window MaintenanceWindow `json:"window"`
+}
+
+type StatusData struct {
+	Status Status `json:"status"`
+}
+
+type DeleteWindowMessageData struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,50 @@
+package maintwindows
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWindowRequest_Validate(t *testing.T) {
+	start := time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC)
+	end := start.Add(14 * 24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		req     WindowRequest
+		wantErr error
+		anyErr  bool
+	}{
+		{"empty", WindowRequest{}, ErrNameRequired, false},
+		{"unknown kind", WindowRequest{Name: "w", Kind: "weekly"}, ErrKindInvalid, false},
+		{"pattern with slash", WindowRequest{Name: "w", Kind: KindRecurring, StackPattern: "prod/*", CronExpression: "0 2 * * *", DurationMinutes: 60}, ErrStackPatternInvalid, false},
+		{"recurring", WindowRequest{Name: "nightly", Kind: KindRecurring, StackPattern: "prod-*", CronExpression: "0 2 * * *", DurationMinutes: 120}, nil, false},
+		{"recurring without cron", WindowRequest{Name: "w", Kind: KindRecurring, DurationMinutes: 60}, ErrRecurringFields, false},
+		{"recurring without duration", WindowRequest{Name: "w", Kind: KindRecurring, CronExpression: "0 2 * * *"}, ErrRecurringFields, false},
+		{"recurring with range", WindowRequest{Name: "w", Kind: KindRecurring, CronExpression: "0 2 * * *", DurationMinutes: 60, StartsAt: &start}, ErrRecurringFields, false},
+		{"recurring too long", WindowRequest{Name: "w", Kind: KindRecurring, CronExpression: "0 2 * * *", DurationMinutes: 20000}, ErrDurationRange, false},
+		{"recurring negative", WindowRequest{Name: "w", Kind: KindRecurring, CronExpression: "0 2 * * *", DurationMinutes: -5}, ErrDurationRange, false},
+		{"recurring invalid cron", WindowRequest{Name: "w", Kind: KindRecurring, CronExpression: "nightly", DurationMinutes: 60}, nil, true},
+		{"recurring never fires", WindowRequest{Name: "w", Kind: KindRecurring, CronExpression: "0 0 30 2 *", DurationMinutes: 60}, ErrCronNeverOpens, false},
+		{"freeze", WindowRequest{Name: "holidays", Kind: KindFreeze, StartsAt: &start, EndsAt: &end}, nil, false},
+		{"freeze without end", WindowRequest{Name: "w", Kind: KindFreeze, StartsAt: &start}, ErrFreezeFields, false},
+		{"freeze with cron", WindowRequest{Name: "w", Kind: KindFreeze, StartsAt: &start, EndsAt: &end, CronExpression: "0 2 * * *"}, ErrFreezeFields, false},
+		{"freeze reversed", WindowRequest{Name: "w", Kind: KindFreeze, StartsAt: &end, EndsAt: &start}, ErrFreezeRange, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if tt.anyErr {
+				if got == nil {
+					t.Errorf("Validate() = nil, want error")
+				}
+				return
+			}
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
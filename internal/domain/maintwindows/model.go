@@ -0,0 +1,33 @@
+package maintwindows
+
+import (
+	"time"
+
+	"berth/internal/platform/db"
+)
+
+const (
+	KindRecurring = "recurring"
+	KindFreeze    = "freeze"
+)
+
+// MaintenanceWindow either opens a recurring period in which gated commands
+// may run on matching stacks, or freezes them for a one-off period. Stacks
+// matched by no recurring window are not gated outside of freezes.
+type MaintenanceWindow struct {
+	db.BaseModel
+	ServerID        uint       `json:"server_id" gorm:"not null;index"`
+	Name            string     `json:"name" gorm:"not null"`
+	StackPattern    string     `json:"stack_pattern" gorm:"not null"`
+	Kind            string     `json:"kind" gorm:"not null"`
+	CronExpression  string     `json:"cron_expression,omitempty"`
+	DurationMinutes int        `json:"duration_minutes,omitempty" gorm:"not null;default:0"`
+	StartsAt        *time.Time `json:"starts_at,omitempty"`
+	EndsAt          *time.Time `json:"ends_at,omitempty"`
+	Enabled         bool       `json:"enabled" gorm:"not null"`
+	CreatedByUserID *uint      `json:"created_by_user_id,omitempty"`
+}
+
+func (MaintenanceWindow) TableName() string {
+	return "maintenance_windows"
+}
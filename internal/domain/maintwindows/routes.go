@@ -0,0 +1,17 @@
+package maintwindows
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
+
+func (h *APIHandler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/servers/:serverid/stacks/:stackname/maintenance-status", h.GetStackStatus, authz.Stack(permnames.StacksRead))
+}
+
+func (h *APIHandler) RegisterAdminAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/servers/:serverid/maintenance-windows", h.ListWindows, authz.Admin(permnames.AdminServersRead))
+	reg.POST("/servers/:serverid/maintenance-windows", h.CreateWindow, authz.Admin(permnames.AdminServersWrite))
+	reg.PUT("/servers/:serverid/maintenance-windows/:id", h.UpdateWindow, authz.Admin(permnames.AdminServersWrite))
+	reg.DELETE("/servers/:serverid/maintenance-windows/:id", h.DeleteWindow, authz.Admin(permnames.AdminServersWrite))
+}
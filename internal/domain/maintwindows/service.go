@@ -0,0 +1,297 @@
+package maintwindows
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+	"berth/internal/pkg/cronspec"
+	"berth/internal/pkg/patterns"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var ErrOutsideWindow = errors.New("outside maintenance window")
+
+// gatedCommands are the operations refused while a stack's maintenance
+// window is closed.
+var gatedCommands = map[string]bool{
+	"up":             true,
+	"down":           true,
+	"restart":        true,
+	"restore-backup": true,
+}
+
+func IsGatedCommand(command string) bool {
+	return gatedCommands[command]
+}
+
+type windowAuthorizer interface {
+	HasStackPermission(p authz.Principal, serverID uint, stackname, permission string) (bool, error)
+}
+
+type Service struct {
+	db       *gorm.DB
+	authzSvc windowAuthorizer
+	logger   *zap.Logger
+	now      func() time.Time
+}
+
+func NewService(db *gorm.DB, authzSvc windowAuthorizer, logger *zap.Logger) *Service {
+	return &Service{
+		db:       db,
+		authzSvc: authzSvc,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+func (s *Service) Logger() *zap.Logger {
+	return s.logger
+}
+
+func (s *Service) ListWindows(serverID uint) ([]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+	if err := s.db.Where("server_id = ?", serverID).Order("id").Find(&windows).Error; err != nil {
+		s.logger.Error("failed to list maintenance windows",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+		)
+		return nil, err
+	}
+	return windows, nil
+}
+
+func (s *Service) GetWindow(serverID, windowID uint) (*MaintenanceWindow, error) {
+	var window MaintenanceWindow
+	if err := s.db.Where("server_id = ?", serverID).First(&window, windowID).Error; err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+func (s *Service) CreateWindow(serverID uint, req WindowRequest, userID uint) (*MaintenanceWindow, error) {
+	window := MaintenanceWindow{
+		ServerID: serverID,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+	}
+	if userID != 0 {
+		window.CreatedByUserID = &userID
+	}
+	applyRequest(&window, req)
+
+	if err := s.db.Create(&window).Error; err != nil {
+		s.logger.Error("failed to create maintenance window",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("maintenance window created",
+		zap.Uint("window_id", window.ID),
+		zap.Uint("server_id", serverID),
+		zap.String("kind", window.Kind),
+		zap.String("stack_pattern", window.StackPattern),
+	)
+
+	return &window, nil
+}
+
+func (s *Service) UpdateWindow(serverID, windowID uint, req WindowRequest) (*MaintenanceWindow, error) {
+	window, err := s.GetWindow(serverID, windowID)
+	if err != nil {
+		return nil, err
+	}
+
+	applyRequest(window, req)
+	if req.Enabled != nil {
+		window.Enabled = *req.Enabled
+	}
+
+	if err := s.db.Select("Name", "StackPattern", "Kind", "CronExpression", "DurationMinutes", "StartsAt", "EndsAt", "Enabled").
+		Updates(window).Error; err != nil {
+		s.logger.Error("failed to update maintenance window",
+			zap.Error(err),
+			zap.Uint("window_id", windowID),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("maintenance window updated",
+		zap.Uint("window_id", windowID),
+		zap.Uint("server_id", serverID),
+	)
+
+	return window, nil
+}
+
+func (s *Service) DeleteWindow(serverID, windowID uint) error {
+	window, err := s.GetWindow(serverID, windowID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(window).Error; err != nil {
+		s.logger.Error("failed to delete maintenance window",
+			zap.Error(err),
+			zap.Uint("window_id", windowID),
+		)
+		return err
+	}
+
+	s.logger.Info("maintenance window deleted",
+		zap.Uint("window_id", windowID),
+		zap.Uint("server_id", serverID),
+	)
+
+	return nil
+}
+
+// StatusAt evaluates the server's windows for a stack at the given time.
+func (s *Service) StatusAt(serverID uint, stackName string, at time.Time) (*Status, error) {
+	var windows []MaintenanceWindow
+	if err := s.db.Where("server_id = ? AND enabled = ?", serverID, true).Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load maintenance windows: %w", err)
+	}
+	status := evaluate(windows, stackName, at)
+	return &status, nil
+}
+
+func (s *Service) Status(serverID uint, stackName string) (*Status, error) {
+	return s.StatusAt(serverID, stackName, s.now())
+}
+
+// CheckOperation refuses gated commands while the stack's window is closed,
+// unless the principal holds the maintenance override permission. The
+// system principal is never exempt: automated jobs wait for the window.
+func (s *Service) CheckOperation(p authz.Principal, serverID uint, stackName, command string) error {
+	if !IsGatedCommand(command) {
+		return nil
+	}
+
+	status, err := s.Status(serverID, stackName)
+	if err != nil {
+		return err
+	}
+	if status.Open {
+		return nil
+	}
+
+	if !p.IsSystem() {
+		override, err := s.authzSvc.HasStackPermission(p, serverID, stackName, permnames.StacksMaintenanceOverride)
+		if err != nil {
+			return fmt.Errorf("failed to check permissions: %w", err)
+		}
+		if override {
+			s.logger.Info("maintenance window overridden",
+				zap.Uint("user_id", p.UserID()),
+				zap.Uint("server_id", serverID),
+				zap.String("stack_name", stackName),
+				zap.String("command", command),
+			)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrOutsideWindow, status.Reason)
+}
+
+func applyRequest(window *MaintenanceWindow, req WindowRequest) {
+	window.Name = strings.TrimSpace(req.Name)
+	window.StackPattern = normalisePattern(req.StackPattern)
+	window.Kind = req.Kind
+	window.CronExpression = strings.TrimSpace(req.CronExpression)
+	window.DurationMinutes = req.DurationMinutes
+	window.StartsAt = req.StartsAt
+	window.EndsAt = req.EndsAt
+}
+
+func normalisePattern(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return "*"
+	}
+	return pattern
+}
+
+// evaluate applies every enabled window matching the stack. Freezes close the
+// stack outright; otherwise the stack is open if it has no recurring windows
+// or one of them is open.
+func evaluate(windows []MaintenanceWindow, stackName string, at time.Time) Status {
+	var recurring []MaintenanceWindow
+	var freeze *MaintenanceWindow
+	for i := range windows {
+		w := &windows[i]
+		if !patterns.Matches(stackName, w.StackPattern) {
+			continue
+		}
+		switch w.Kind {
+		case KindRecurring:
+			recurring = append(recurring, *w)
+		case KindFreeze:
+			if w.StartsAt != nil && w.EndsAt != nil && !at.Before(*w.StartsAt) && at.Before(*w.EndsAt) {
+				if freeze == nil || w.EndsAt.After(*freeze.EndsAt) {
+					freeze = w
+				}
+			}
+		}
+	}
+
+	if freeze != nil {
+		status := Status{
+			Gated:  true,
+			Reason: fmt.Sprintf("stack is frozen by %q until %s", freeze.Name, freeze.EndsAt.Format(time.RFC3339)),
+		}
+		if len(recurring) == 0 {
+			status.NextOpenAt = freeze.EndsAt
+		} else if next, ok := nextOpening(recurring, *freeze.EndsAt); ok {
+			status.NextOpenAt = &next
+		}
+		return status
+	}
+
+	if len(recurring) == 0 {
+		return Status{Open: true}
+	}
+
+	next, ok := nextOpening(recurring, at)
+	if !ok {
+		return Status{
+			Gated:  true,
+			Reason: "no maintenance window can open; check the windows' cron expressions",
+		}
+	}
+	if !next.After(at) {
+		return Status{Gated: true, Open: true}
+	}
+	return Status{
+		Gated:      true,
+		Reason:     fmt.Sprintf("no maintenance window is open; the next opens at %s", next.Format(time.RFC3339)),
+		NextOpenAt: &next,
+	}
+}
+
+// nextOpening returns from itself if a window is open then, or else the
+// earliest time one opens. Windows whose cron expression cannot be parsed
+// or never fires are ignored; ok is false when none can open.
+func nextOpening(recurring []MaintenanceWindow, from time.Time) (next time.Time, ok bool) {
+	for _, w := range recurring {
+		duration := time.Duration(w.DurationMinutes) * time.Minute
+		if open, err := cronspec.Within(w.CronExpression, duration, from); err == nil && open {
+			return from, true
+		}
+		start, err := cronspec.Next(w.CronExpression, from)
+		if err != nil || start.IsZero() {
+			continue
+		}
+		if !ok || start.Before(next) {
+			next, ok = start, true
+		}
+	}
+	return next, ok
+}
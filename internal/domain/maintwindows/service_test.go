@@ -0,0 +1,164 @@
+package maintwindows
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/domain/authz"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type fakeAuthorizer struct {
+	override map[uint]bool
+}
+
+func (f *fakeAuthorizer) HasStackPermission(p authz.Principal, _ uint, _, _ string) (bool, error) {
+	return f.override[p.UserID()], nil
+}
+
+// monday0300 falls inside a nightly 02:00 window of two hours.
+var monday0300 = time.Date(2026, 10, 19, 3, 0, 0, 0, time.Local)
+
+func newTestService(t *testing.T, at time.Time) (*Service, *fakeAuthorizer) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:maintwindows_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&MaintenanceWindow{}))
+
+	authorizer := &fakeAuthorizer{override: map[uint]bool{}}
+	svc := NewService(db, authorizer, zap.NewNop())
+	svc.now = func() time.Time { return at }
+	return svc, authorizer
+}
+
+func nightly(t *testing.T, svc *Service, pattern string) {
+	t.Helper()
+	_, err := svc.CreateWindow(1, WindowRequest{
+		Name:            "nightly",
+		Kind:            KindRecurring,
+		StackPattern:    pattern,
+		CronExpression:  "0 2 * * *",
+		DurationMinutes: 120,
+	}, 7)
+	require.NoError(t, err)
+}
+
+func TestStatus_UngatedWithoutWindows(t *testing.T) {
+	svc, _ := newTestService(t, monday0300)
+
+	status, err := svc.Status(1, "app")
+	require.NoError(t, err)
+	assert.Equal(t, Status{Open: true}, *status)
+}
+
+func TestStatus_RecurringWindow(t *testing.T) {
+	svc, _ := newTestService(t, monday0300)
+	nightly(t, svc, "prod-*")
+
+	status, err := svc.StatusAt(1, "prod-web", monday0300)
+	require.NoError(t, err)
+	assert.True(t, status.Gated)
+	assert.True(t, status.Open)
+
+	closedAt := monday0300.Add(2 * time.Hour)
+	status, err = svc.StatusAt(1, "prod-web", closedAt)
+	require.NoError(t, err)
+	assert.True(t, status.Gated)
+	assert.False(t, status.Open)
+	require.NotNil(t, status.NextOpenAt)
+	assert.True(t, status.NextOpenAt.Equal(time.Date(2026, 10, 20, 2, 0, 0, 0, time.Local)))
+
+	status, err = svc.StatusAt(1, "staging-web", closedAt)
+	require.NoError(t, err)
+	assert.False(t, status.Gated, "windows only gate stacks matching their pattern")
+	assert.True(t, status.Open)
+}
+
+func TestStatus_FreezeOverridesOpenWindow(t *testing.T) {
+	svc, _ := newTestService(t, monday0300)
+	nightly(t, svc, "*")
+	start := monday0300.Add(-time.Hour)
+	end := monday0300.Add(48 * time.Hour)
+	_, err := svc.CreateWindow(1, WindowRequest{Name: "release freeze", Kind: KindFreeze, StartsAt: &start, EndsAt: &end}, 7)
+	require.NoError(t, err)
+
+	status, err := svc.Status(1, "app")
+	require.NoError(t, err)
+	assert.False(t, status.Open)
+	assert.Contains(t, status.Reason, "release freeze")
+	require.NotNil(t, status.NextOpenAt)
+	assert.True(t, status.NextOpenAt.Equal(end), "the freeze ends inside the nightly window")
+}
+
+func TestStatus_IgnoresDisabledWindows(t *testing.T) {
+	svc, _ := newTestService(t, monday0300.Add(4*time.Hour))
+	disabled := false
+	_, err := svc.CreateWindow(1, WindowRequest{
+		Name:            "nightly",
+		Kind:            KindRecurring,
+		CronExpression:  "0 2 * * *",
+		DurationMinutes: 120,
+		Enabled:         &disabled,
+	}, 7)
+	require.NoError(t, err)
+
+	status, err := svc.Status(1, "app")
+	require.NoError(t, err)
+	assert.True(t, status.Open)
+}
+
+func TestStatus_InvalidCronFailsClosed(t *testing.T) {
+	for _, cron := range []string{"nightly", "0 0 30 2 *"} {
+		t.Run(cron, func(t *testing.T) {
+			svc, _ := newTestService(t, monday0300)
+			_, err := svc.CreateWindow(1, WindowRequest{
+				Name:            "broken",
+				Kind:            KindRecurring,
+				CronExpression:  cron,
+				DurationMinutes: 120,
+			}, 7)
+			require.NoError(t, err)
+
+			status, err := svc.Status(1, "app")
+			require.NoError(t, err)
+			assert.True(t, status.Gated)
+			assert.False(t, status.Open, "a window that cannot open keeps the stack closed")
+			assert.Nil(t, status.NextOpenAt)
+
+			err = svc.CheckOperation(authz.NewPrincipal(5, false, nil), 1, "app", "up")
+			assert.True(t, errors.Is(err, ErrOutsideWindow))
+		})
+	}
+}
+
+func TestCheckOperation(t *testing.T) {
+	svc, authorizer := newTestService(t, monday0300.Add(4*time.Hour))
+	nightly(t, svc, "*")
+	authorizer.override[9] = true
+
+	user := authz.NewPrincipal(5, false, nil)
+	overrider := authz.NewPrincipal(9, false, nil)
+
+	err := svc.CheckOperation(user, 1, "app", "up")
+	assert.True(t, errors.Is(err, ErrOutsideWindow))
+
+	assert.NoError(t, svc.CheckOperation(user, 1, "app", "pull"), "only gated commands are refused")
+	assert.NoError(t, svc.CheckOperation(overrider, 1, "app", "restart"))
+
+	err = svc.CheckOperation(authz.SystemPrincipal, 1, "app", "restart")
+	assert.True(t, errors.Is(err, ErrOutsideWindow), "automated jobs are not exempt")
+
+	svc.now = func() time.Time { return monday0300 }
+	assert.NoError(t, svc.CheckOperation(user, 1, "app", "down"))
+}
@@ -17,16 +17,29 @@ const (
 	OperationStatusCancelled OperationStatus = "cancelled"
 )
 
+// TriggerSource records what started an operation.
+type TriggerSource string
+
+const (
+	TriggerSourceManual    TriggerSource = "manual"
+	TriggerSourceScheduled TriggerSource = "scheduled"
+)
+
+// OperationLog records one stack operation. UserID is nil for operations
+// started by the system, such as scheduled backups and automatic updates;
+// scheduled operations run as their owner still carry the owner's UserID, so
+// TriggerSource records how the operation was started.
 type OperationLog struct {
 	db.BaseModel
-	UserID        uint            `json:"user_id" gorm:"not null;index"`
-	User          user.User       `json:"user" gorm:"foreignKey:UserID"`
+	UserID        *uint           `json:"user_id" gorm:"index"`
+	User          *user.User      `json:"user" gorm:"foreignKey:UserID"`
 	UserName      string          `json:"-" gorm:"size:255;index"`
 	ServerID      uint            `json:"server_id" gorm:"not null;index"`
 	Server        server.Server   `json:"server" gorm:"foreignKey:ServerID"`
 	StackName     string          `json:"stack_name" gorm:"not null;index"`
 	OperationID   string          `json:"operation_id" gorm:"not null;index"`
 	Command       string          `json:"command" gorm:"not null"`
+	TriggerSource TriggerSource   `json:"-" gorm:"size:32;not null;default:'manual'"`
 	Options       string          `json:"options,omitempty" gorm:"type:text"`
 	Services      string          `json:"services,omitempty" gorm:"type:text"`
 	Status        OperationStatus `json:"status,omitempty" gorm:"not null;default:'completed'"`
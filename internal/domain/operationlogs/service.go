@@ -151,15 +151,13 @@ func (s *Service) ListOperationLogs(params ListOperationLogsParams) ([]Operation
 			serverName = log.Server.Name
 		}
 
-		triggerSource := "manual"
-
 		partialDuration := s.calculatePartialDuration(log)
 
 		response = append(response, OperationLogInfo{
 			OperationLog:    log,
 			UserName:        userName,
 			ServerName:      serverName,
-			TriggerSource:   triggerSource,
+			TriggerSource:   string(log.TriggerSource),
 			IsIncomplete:    log.EndTime == nil,
 			FormattedDate:   log.CreatedAt.Format("2006-01-02 15:04:05"),
 			MessageCount:    messageCount,
@@ -204,8 +202,6 @@ func (s *Service) GetOperationLogDetails(logID uint, userID *uint) (*OperationLo
 		serverName = log.Server.Name
 	}
 
-	triggerSource := "manual"
-
 	partialDuration := s.calculatePartialDuration(log)
 
 	return &OperationLogDetailData{
@@ -213,7 +209,7 @@ func (s *Service) GetOperationLogDetails(logID uint, userID *uint) (*OperationLo
 			OperationLog:    log,
 			UserName:        userName,
 			ServerName:      serverName,
-			TriggerSource:   triggerSource,
+			TriggerSource:   string(log.TriggerSource),
 			IsIncomplete:    log.EndTime == nil,
 			FormattedDate:   log.CreatedAt.Format("2006-01-02 15:04:05"),
 			MessageCount:    int64(len(messages)),
@@ -257,8 +253,6 @@ func (s *Service) GetOperationLogDetailsByOperationID(operationID string, userID
 		serverName = log.Server.Name
 	}
 
-	triggerSource := "manual"
-
 	partialDuration := s.calculatePartialDuration(log)
 
 	return &OperationLogDetailData{
@@ -266,7 +260,7 @@ func (s *Service) GetOperationLogDetailsByOperationID(operationID string, userID
 			OperationLog:    log,
 			UserName:        userName,
 			ServerName:      serverName,
-			TriggerSource:   triggerSource,
+			TriggerSource:   string(log.TriggerSource),
 			IsIncomplete:    log.EndTime == nil,
 			FormattedDate:   log.CreatedAt.Format("2006-01-02 15:04:05"),
 			MessageCount:    int64(len(messages)),
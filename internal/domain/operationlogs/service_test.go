@@ -31,8 +31,9 @@ func TestDeleteOldOperationLogs_HardDeletesOldLogsWithMessagesAndKeepsRecent(t *
 	s := newTestService(t)
 	now := time.Now()
 
-	oldLog := OperationLog{UserID: 1, ServerID: 1, StackName: "s", OperationID: "old", Command: "up", StartTime: now.AddDate(0, 0, -40)}
-	recentLog := OperationLog{UserID: 1, ServerID: 1, StackName: "s", OperationID: "recent", Command: "up", StartTime: now.AddDate(0, 0, -5)}
+	ownerID := uint(1)
+	oldLog := OperationLog{UserID: &ownerID, ServerID: 1, StackName: "s", OperationID: "old", Command: "up", StartTime: now.AddDate(0, 0, -40)}
+	recentLog := OperationLog{UserID: &ownerID, ServerID: 1, StackName: "s", OperationID: "recent", Command: "up", StartTime: now.AddDate(0, 0, -5)}
 	require.NoError(t, s.db.Create(&oldLog).Error)
 	require.NoError(t, s.db.Create(&recentLog).Error)
 	require.NoError(t, s.db.Create(&OperationLogMessage{OperationLogID: oldLog.ID, MessageType: "stdout", Timestamp: now.AddDate(0, 0, -40), SequenceNumber: 1}).Error)
@@ -16,7 +16,7 @@ type AuditLogger struct {
 type AuditLogEntry struct {
 	Timestamp   string `json:"timestamp"`
 	LogID       uint   `json:"log_id"`
-	UserID      uint   `json:"user_id"`
+	UserID      *uint  `json:"user_id,omitempty"`
 	ServerID    uint   `json:"server_id"`
 	StackName   string `json:"stack_name"`
 	OperationID string `json:"operation_id"`
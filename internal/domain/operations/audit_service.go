@@ -10,10 +10,28 @@ import (
 	"gorm.io/gorm"
 )
 
+// OperationEndListener is notified once an operation's outcome has been
+// recorded. Listeners run on the persisting goroutine and must not block.
+type OperationEndListener interface {
+	OnOperationEnd(log *operationlogs.OperationLog)
+}
+
+// OperationStartListener is notified once an operation's start has been
+// recorded. Listeners run on the starting goroutine and must not block.
+type OperationStartListener interface {
+	OnOperationStart(log *operationlogs.OperationLog)
+}
+
+// systemUsername is recorded as the user of operations started by the
+// system.
+const systemUsername = "system"
+
 type AuditService struct {
-	db            *gorm.DB
-	logger        *zap.Logger
-	summaryParser *SummaryParser
+	db             *gorm.DB
+	logger         *zap.Logger
+	summaryParser  *SummaryParser
+	startListeners []OperationStartListener
+	endListeners   []OperationEndListener
 }
 
 func NewAuditService(db *gorm.DB, logger *zap.Logger, summaryParser *SummaryParser) *AuditService {
@@ -24,9 +42,24 @@ func NewAuditService(db *gorm.DB, logger *zap.Logger, summaryParser *SummaryPars
 	}
 }
 
-func (s *AuditService) LogOperationStart(userID uint, serverID uint, stackName string, operationID string, request OperationRequest, startTime time.Time) (*operationlogs.OperationLog, error) {
+// AddEndListener registers l to be called after each LogOperationEnd. It must
+// be called during wiring, before any operation is started.
+func (s *AuditService) AddEndListener(l OperationEndListener) {
+	s.endListeners = append(s.endListeners, l)
+}
+
+// AddStartListener registers l to be called after each LogOperationStart. It
+// must be called during wiring, before any operation is started.
+func (s *AuditService) AddStartListener(l OperationStartListener) {
+	s.startListeners = append(s.startListeners, l)
+}
+
+// LogOperationStart records the start of an operation. userID is nil for
+// operations started by the system, which are attributed to systemUsername.
+func (s *AuditService) LogOperationStart(userID *uint, source operationlogs.TriggerSource, serverID uint, stackName string, operationID string, request OperationRequest, startTime time.Time) (*operationlogs.OperationLog, error) {
 	s.logger.Debug("logging operation start",
-		zap.Uint("user_id", userID),
+		zap.Uintp("user_id", userID),
+		zap.String("trigger_source", string(source)),
 		zap.Uint("server_id", serverID),
 		zap.String("stack_name", stackName),
 		zap.String("operation_id", operationID),
@@ -50,31 +83,35 @@ func (s *AuditService) LogOperationStart(userID uint, serverID uint, stackName s
 		)
 	}
 
-	var username string
-	if err := s.db.Model(&user.User{}).Select("username").Where("id = ?", userID).Scan(&username).Error; err != nil {
-		s.logger.Warn("failed to resolve username for operation log",
-			zap.Error(err),
-			zap.Uint("user_id", userID),
-			zap.String("operation_id", operationID),
-		)
+	username := systemUsername
+	if userID != nil {
+		username = ""
+		if err := s.db.Model(&user.User{}).Select("username").Where("id = ?", *userID).Scan(&username).Error; err != nil {
+			s.logger.Warn("failed to resolve username for operation log",
+				zap.Error(err),
+				zap.Uint("user_id", *userID),
+				zap.String("operation_id", operationID),
+			)
+		}
 	}
 
 	log := &operationlogs.OperationLog{
-		UserID:      userID,
-		UserName:    username,
-		ServerID:    serverID,
-		StackName:   stackName,
-		OperationID: operationID,
-		Command:     request.Command,
-		Options:     string(options),
-		Services:    string(services),
-		StartTime:   startTime,
+		UserID:        userID,
+		UserName:      username,
+		ServerID:      serverID,
+		StackName:     stackName,
+		OperationID:   operationID,
+		Command:       request.Command,
+		TriggerSource: source,
+		Options:       string(options),
+		Services:      string(services),
+		StartTime:     startTime,
 	}
 
 	if err := s.db.Create(log).Error; err != nil {
 		s.logger.Error("failed to save operation start log",
 			zap.Error(err),
-			zap.Uint("user_id", userID),
+			zap.Uintp("user_id", userID),
 			zap.String("operation_id", operationID),
 			zap.String("command", request.Command),
 		)
@@ -82,7 +119,7 @@ func (s *AuditService) LogOperationStart(userID uint, serverID uint, stackName s
 	}
 
 	s.logger.Info("operation start logged successfully",
-		zap.Uint("user_id", userID),
+		zap.Uintp("user_id", userID),
 		zap.Uint("server_id", serverID),
 		zap.String("stack_name", stackName),
 		zap.String("operation_id", operationID),
@@ -90,6 +127,10 @@ func (s *AuditService) LogOperationStart(userID uint, serverID uint, stackName s
 		zap.Uint("log_id", log.ID),
 	)
 
+	for _, l := range s.startListeners {
+		l.OnOperationStart(log)
+	}
+
 	return log, nil
 }
 
@@ -259,5 +300,17 @@ func (s *AuditService) LogOperationEnd(operationLogID uint, endTime time.Time, s
 		zap.Int("duration_ms", duration),
 	)
 
+	log.Success = &success
+	log.ExitCode = &exitCode
+	if end, ok := updates["end_time"].(time.Time); ok {
+		log.EndTime = &end
+	}
+	if summary, ok := updates["summary"].(string); ok {
+		log.Summary = summary
+	}
+	for _, l := range s.endListeners {
+		l.OnOperationEnd(log)
+	}
+
 	return nil
 }
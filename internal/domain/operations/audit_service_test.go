@@ -0,0 +1,77 @@
+package operations
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/domain/operationlogs"
+	"berth/internal/domain/server"
+	"berth/internal/domain/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+// newForeignKeyDB enforces foreign keys, as Postgres and MySQL do.
+func newForeignKeyDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:ops_audit_test_%d?mode=memory&cache=shared&_foreign_keys=1", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&user.User{}, &server.Server{}, &operationlogs.OperationLog{}, &operationlogs.OperationLogMessage{}))
+	return db
+}
+
+func TestLogOperationStart_Owner(t *testing.T) {
+	db := newForeignKeyDB(t)
+	svc := NewAuditService(db, zap.NewNop(), NewSummaryParser(zap.NewNop()))
+
+	srv := server.Server{Name: "srv", Host: "localhost", Port: 1, AccessToken: "x"}
+	require.NoError(t, db.Create(&srv).Error)
+	usr := user.User{Username: "alice", Email: "alice@example.com", Password: "x"}
+	require.NoError(t, db.Create(&usr).Error)
+
+	t.Run("user operation", func(t *testing.T) {
+		log, err := svc.LogOperationStart(&usr.ID, operationlogs.TriggerSourceManual, srv.ID, "web", "op-user", OperationRequest{Command: "up"}, time.Now())
+		require.NoError(t, err)
+		require.NotNil(t, log.UserID)
+		assert.Equal(t, usr.ID, *log.UserID)
+		assert.Equal(t, "alice", log.UserName)
+		assert.Equal(t, operationlogs.TriggerSourceManual, log.TriggerSource)
+	})
+
+	t.Run("scheduled operation run as its owner", func(t *testing.T) {
+		_, err := svc.LogOperationStart(&usr.ID, operationlogs.TriggerSourceScheduled, srv.ID, "web", "op-owner-scheduled", OperationRequest{Command: "restart"}, time.Now())
+		require.NoError(t, err)
+
+		stored, err := svc.FindOperationLogByOperationID("op-owner-scheduled")
+		require.NoError(t, err)
+		require.NotNil(t, stored.UserID)
+		assert.Equal(t, operationlogs.TriggerSourceScheduled, stored.TriggerSource)
+	})
+
+	t.Run("system operation", func(t *testing.T) {
+		log, err := svc.LogOperationStart(nil, operationlogs.TriggerSourceScheduled, srv.ID, "web", "op-system", OperationRequest{Command: "create-backup"}, time.Now())
+		require.NoError(t, err)
+		assert.Nil(t, log.UserID)
+		assert.Equal(t, systemUsername, log.UserName)
+
+		stored, err := svc.FindOperationLogByOperationID("op-system")
+		require.NoError(t, err)
+		assert.Nil(t, stored.UserID)
+		assert.Equal(t, operationlogs.TriggerSourceScheduled, stored.TriggerSource)
+	})
+
+	t.Run("unknown user is rejected", func(t *testing.T) {
+		missing := uint(0)
+		_, err := svc.LogOperationStart(&missing, operationlogs.TriggerSourceManual, srv.ID, "web", "op-missing", OperationRequest{Command: "up"}, time.Now())
+		assert.Error(t, err, "foreign keys are enforced")
+	})
+}
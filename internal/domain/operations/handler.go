@@ -6,6 +6,8 @@ import (
 
 	"berth/internal/domain/authz"
 	"berth/internal/domain/backups"
+	"berth/internal/domain/maintwindows"
+	"berth/internal/domain/operationlogs"
 	"berth/internal/domain/security"
 	"berth/internal/domain/session"
 	"berth/internal/pkg/echoparams"
@@ -115,14 +117,14 @@ func (h *Handler) StartOperation(c echo.Context) error {
 
 	resp, err := h.service.StartOperation(c.Request().Context(), p, serverID, stackname, req)
 	if err != nil {
-		if errors.Is(err, backups.ErrBackupsNotEnabled) {
+		if errors.Is(err, backups.ErrBackupsNotEnabled) || errors.Is(err, maintwindows.ErrOutsideWindow) {
 			return response.Conflict(c, err.Error())
 		}
 		return response.Internal(c, err.Error())
 	}
 
 	startTime := time.Now()
-	h.service.RecordStartAndPersist(p, serverID, stackname, resp.OperationID, req, startTime)
+	h.service.RecordStartAndPersist(p, operationlogs.TriggerSourceManual, serverID, stackname, resp.OperationID, req, startTime)
 
 	if eventType, isBackup := backupSecurityEvent(req.Command); isBackup {
 		_ = h.securityLog.LogBackupEvent(
@@ -5,6 +5,7 @@ import (
 	"berth/internal/domain/backups"
 	"berth/internal/domain/compose"
 	"berth/internal/domain/files"
+	"berth/internal/domain/operationlogs"
 	"berth/internal/domain/registry"
 	"berth/internal/domain/server"
 	"bufio"
@@ -40,13 +41,20 @@ type opsFileReader interface {
 	ReadFile(ctx context.Context, p authz.Principal, serverID uint, stackname, path string) (*files.FileContent, error)
 }
 
+// opsMaintenanceGate decides whether a command may run on a stack given its
+// maintenance windows.
+type opsMaintenanceGate interface {
+	CheckOperation(p authz.Principal, serverID uint, stackname, command string) error
+}
+
 type Service struct {
-	serverSvc   opsServerProvider
-	authzSvc    opsAuthorizer
-	auditSvc    *AuditService
-	registrySvc opsRegistryProvider
-	filesSvc    opsFileReader
-	logger      *zap.Logger
+	serverSvc       opsServerProvider
+	authzSvc        opsAuthorizer
+	auditSvc        *AuditService
+	registrySvc     opsRegistryProvider
+	filesSvc        opsFileReader
+	maintenanceGate opsMaintenanceGate
+	logger          *zap.Logger
 }
 
 func NewService(serverSvc opsServerProvider, authzSvc opsAuthorizer, auditSvc *AuditService, registrySvc opsRegistryProvider, filesSvc opsFileReader, logger *zap.Logger) *Service {
@@ -60,6 +68,10 @@ func NewService(serverSvc opsServerProvider, authzSvc opsAuthorizer, auditSvc *A
 	}
 }
 
+func (s *Service) SetMaintenanceGate(g opsMaintenanceGate) {
+	s.maintenanceGate = g
+}
+
 func (s *Service) StartOperation(ctx context.Context, p authz.Principal, serverID uint, stackname string, req OperationRequest) (*OperationStartData, error) {
 	s.logger.Debug("starting Docker operation",
 		zap.Uint("user_id", p.UserID()),
@@ -103,6 +115,19 @@ func (s *Service) StartOperation(ctx context.Context, p authz.Principal, serverI
 		return nil, fmt.Errorf("insufficient permissions for operation '%s' on stack '%s' (requires %s)", req.Command, stackname, requiredPermission)
 	}
 
+	if s.maintenanceGate != nil {
+		if err := s.maintenanceGate.CheckOperation(p, serverID, stackname, req.Command); err != nil {
+			s.logger.Warn("operation refused by maintenance window",
+				zap.Error(err),
+				zap.Uint("user_id", p.UserID()),
+				zap.Uint("server_id", serverID),
+				zap.String("stack_name", stackname),
+				zap.String("operation_command", req.Command),
+			)
+			return nil, err
+		}
+	}
+
 	if req.Command == "up" || req.Command == "pull" {
 		credentials, err := s.fetchRegistryCredentials(ctx, p, serverID, stackname)
 		if err != nil {
@@ -202,8 +227,13 @@ func (s *Service) StartOperation(ctx context.Context, p authz.Principal, serverI
 	return &response, nil
 }
 
-func (s *Service) RecordStartAndPersist(p authz.Principal, serverID uint, stackname string, operationID string, req OperationRequest, startTime time.Time) {
-	operationLog, err := s.auditSvc.LogOperationStart(p.UserID(), serverID, stackname, operationID, req, startTime)
+func (s *Service) RecordStartAndPersist(p authz.Principal, source operationlogs.TriggerSource, serverID uint, stackname string, operationID string, req OperationRequest, startTime time.Time) {
+	var userID *uint
+	if !p.IsSystem() {
+		id := p.UserID()
+		userID = &id
+	}
+	operationLog, err := s.auditSvc.LogOperationStart(userID, source, serverID, stackname, operationID, req, startTime)
 	if err != nil || operationLog == nil {
 		s.logger.Error("failed to record operation start; output will not be persisted",
 			zap.Error(err),
@@ -0,0 +1,205 @@
+package operationschedules
+
+import (
+	"errors"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/security"
+	"berth/internal/domain/session"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type scheduleAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+type APIHandler struct {
+	service      *Service
+	auditService scheduleAuditLogger
+}
+
+func NewAPIHandler(service *Service, auditService scheduleAuditLogger) *APIHandler {
+	return &APIHandler{
+		service:      service,
+		auditService: auditService,
+	}
+}
+
+func (h *APIHandler) ListSchedules(c echo.Context) error {
+	serverID, stackName, err := echoparams.GetServerIDAndStackName(c)
+	if err != nil {
+		return err
+	}
+
+	schedules, err := h.service.ListSchedules(serverID, stackName)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch operation schedules")
+	}
+
+	return response.OK(c, ListSchedulesData{
+		Schedules: ToResponseList(schedules),
+	})
+}
+
+func (h *APIHandler) CreateSchedule(c echo.Context) error {
+	serverID, stackName, err := echoparams.GetServerIDAndStackName(c)
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+	if p.UserID() == 0 {
+		return response.BadRequest(c, "Operation schedules must be owned by a user")
+	}
+
+	var req CreateScheduleRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	schedule, err := h.service.CreateSchedule(serverID, stackName, req, p.UserID())
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	h.service.Logger().Info("operation schedule created",
+		zap.Uint("user_id", p.UserID()),
+		zap.Uint("server_id", serverID),
+		zap.String("stack_name", stackName),
+		zap.Uint("schedule_id", schedule.ID),
+	)
+
+	h.audit(c, p, security.EventStackScheduleCreated, schedule)
+
+	return response.Created(c, GetScheduleData{
+		Schedule: ToResponse(schedule),
+	})
+}
+
+func (h *APIHandler) ListRuns(c echo.Context) error {
+	_, existing, err := h.loadSchedule(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	runs, err := h.service.ListRuns(existing.ID)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch operation schedule runs")
+	}
+
+	return response.OK(c, ListRunsData{
+		Runs: runs,
+	})
+}
+
+func (h *APIHandler) PauseSchedule(c echo.Context) error {
+	return h.setEnabled(c, false)
+}
+
+func (h *APIHandler) ResumeSchedule(c echo.Context) error {
+	return h.setEnabled(c, true)
+}
+
+func (h *APIHandler) setEnabled(c echo.Context, enabled bool) error {
+	p, existing, err := h.loadSchedule(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	schedule, err := h.service.SetEnabled(existing.ServerID, existing.StackName, existing.ID, enabled)
+	if err != nil {
+		return response.Internal(c, "Failed to update operation schedule")
+	}
+
+	eventType := security.EventStackSchedulePaused
+	if enabled {
+		eventType = security.EventStackScheduleResumed
+	}
+	h.audit(c, p, eventType, schedule)
+
+	return response.OK(c, GetScheduleData{
+		Schedule: ToResponse(schedule),
+	})
+}
+
+func (h *APIHandler) DeleteSchedule(c echo.Context) error {
+	p, existing, err := h.loadSchedule(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	if err := h.service.DeleteSchedule(existing.ServerID, existing.StackName, existing.ID); err != nil {
+		return response.Internal(c, "Failed to delete operation schedule")
+	}
+
+	h.audit(c, p, security.EventStackScheduleDeleted, existing)
+
+	return response.OK(c, DeleteScheduleMessageData{
+		Message: "Operation schedule deleted successfully",
+	})
+}
+
+// loadSchedule resolves the schedule named in the path. A nil schedule with a
+// nil error means a response has already been written.
+func (h *APIHandler) loadSchedule(c echo.Context) (authz.Principal, *OperationSchedule, error) {
+	serverID, stackName, err := echoparams.GetServerIDAndStackName(c)
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	scheduleID, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	schedule, err := h.service.GetSchedule(serverID, stackName, scheduleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return p, nil, response.NotFound(c, "Operation schedule not found")
+		}
+		return p, nil, response.Internal(c, "Failed to fetch operation schedule")
+	}
+
+	return p, schedule, nil
+}
+
+func (h *APIHandler) audit(c echo.Context, p authz.Principal, eventType string, schedule *OperationSchedule) {
+	actorID := p.UserID()
+	scheduleID := schedule.ID
+	serverID := schedule.ServerID
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      eventType,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetType:     security.TargetTypeScheduledOperation,
+		TargetID:       &scheduleID,
+		TargetName:     schedule.Command,
+		Success:        true,
+		Metadata: map[string]any{
+			"command":         schedule.Command,
+			"options":         decodeList(schedule.Options),
+			"services":        decodeList(schedule.Services),
+			"cron_expression": schedule.CronExpression,
+			"owner_user_id":   schedule.OwnerUserID,
+			"enabled":         schedule.Enabled,
+		},
+		ServerID:  &serverID,
+		StackName: schedule.StackName,
+	})
+}
@@ -0,0 +1,147 @@
+package operationschedules
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"berth/internal/pkg/cronspec"
+)
+
+var (
+	ErrCommandRequired        = errors.New("command is required")
+	ErrCommandNotSchedulable  = errors.New("command must be one of pull, up, down, restart, start or stop")
+	ErrCronExpressionRequired = errors.New("cron_expression is required")
+	ErrOptionInvalid          = errors.New("options must be command-line flags starting with '-'")
+	ErrServiceNameInvalid     = errors.New("services must be non-empty names without whitespace")
+)
+
+// schedulableCommands are the compose commands a schedule may run. Backup and
+// archive commands have their own flows and are deliberately excluded.
+var schedulableCommands = map[string]bool{
+	"pull":    true,
+	"up":      true,
+	"down":    true,
+	"restart": true,
+	"start":   true,
+	"stop":    true,
+}
+
+type CreateScheduleRequest struct {
+	Command        string   `json:"command"`
+	Options        []string `json:"options,omitempty"`
+	Services       []string `json:"services,omitempty"`
+	CronExpression string   `json:"cron_expression"`
+	Enabled        *bool    `json:"enabled,omitempty"`
+}
+
+func (r *CreateScheduleRequest) Validate() error {
+	if r.Command == "" {
+		return ErrCommandRequired
+	}
+	if !schedulableCommands[r.Command] {
+		return ErrCommandNotSchedulable
+	}
+	if r.CronExpression == "" {
+		return ErrCronExpressionRequired
+	}
+	if err := cronspec.Validate(r.CronExpression); err != nil {
+		return err
+	}
+	for _, opt := range r.Options {
+		if !strings.HasPrefix(opt, "-") {
+			return ErrOptionInvalid
+		}
+	}
+	for _, svc := range r.Services {
+		if svc == "" || strings.ContainsAny(svc, " \t\r\n") {
+			return ErrServiceNameInvalid
+		}
+	}
+	return nil
+}
+
+type OperationScheduleInfo struct {
+	ID             uint       `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	ServerID       uint       `json:"server_id"`
+	StackName      string     `json:"stack_name"`
+	Command        string     `json:"command"`
+	Options        []string   `json:"options"`
+	Services       []string   `json:"services"`
+	CronExpression string     `json:"cron_expression"`
+	Enabled        bool       `json:"enabled"`
+	OwnerUserID    uint       `json:"owner_user_id"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastRunStatus  string     `json:"last_run_status,omitempty"`
+	LastRunMessage string     `json:"last_run_message,omitempty"`
+}
+
+type ListSchedulesData struct {
+	Schedules []OperationScheduleInfo `json:"schedules"`
+}
+
+type GetScheduleData struct {
+	Schedule OperationScheduleInfo `json:"schedule"`
+}
+
+type ListRunsData struct {
+	Runs []OperationScheduleRun `json:"runs"`
+}
+
+type DeleteScheduleMessageData struct {
+	Message string `json:"message"`
+}
+
+func ToResponse(s *OperationSchedule) OperationScheduleInfo {
+	return OperationScheduleInfo{
+		ID:             s.ID,
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
+		ServerID:       s.ServerID,
+		StackName:      s.StackName,
+		Command:        s.Command,
+		Options:        decodeList(s.Options),
+		Services:       decodeList(s.Services),
+		CronExpression: s.CronExpression,
+		Enabled:        s.Enabled,
+		OwnerUserID:    s.OwnerUserID,
+		NextRunAt:      s.NextRunAt,
+		LastRunAt:      s.LastRunAt,
+		LastRunStatus:  s.LastRunStatus,
+		LastRunMessage: s.LastRunMessage,
+	}
+}
+
+func ToResponseList(schedules []OperationSchedule) []OperationScheduleInfo {
+	result := make([]OperationScheduleInfo, len(schedules))
+	for i := range schedules {
+		result[i] = ToResponse(&schedules[i])
+	}
+	return result
+}
+
+func encodeList(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+func decodeList(raw string) []string {
+	values := []string{}
+	if raw == "" {
+		return values
+	}
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return []string{}
+	}
+	return values
+}
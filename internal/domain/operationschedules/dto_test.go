@@ -0,0 +1,41 @@
+package operationschedules
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateScheduleRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CreateScheduleRequest
+		wantErr error
+		anyErr  bool
+	}{
+		{"empty", CreateScheduleRequest{}, ErrCommandRequired, false},
+		{"backup command", CreateScheduleRequest{Command: "create-backup", CronExpression: "@daily"}, ErrCommandNotSchedulable, false},
+		{"missing cron", CreateScheduleRequest{Command: "pull"}, ErrCronExpressionRequired, false},
+		{"invalid cron", CreateScheduleRequest{Command: "pull", CronExpression: "weekly"}, nil, true},
+		{"valid pull", CreateScheduleRequest{Command: "pull", CronExpression: "0 4 * * 1"}, nil, false},
+		{"valid restart with service", CreateScheduleRequest{Command: "restart", CronExpression: "@daily", Services: []string{"worker"}}, nil, false},
+		{"valid up with option", CreateScheduleRequest{Command: "up", CronExpression: "@daily", Options: []string{"--remove-orphans"}}, nil, false},
+		{"option without dash", CreateScheduleRequest{Command: "up", CronExpression: "@daily", Options: []string{"remove-orphans"}}, ErrOptionInvalid, false},
+		{"empty service", CreateScheduleRequest{Command: "restart", CronExpression: "@daily", Services: []string{""}}, ErrServiceNameInvalid, false},
+		{"service with space", CreateScheduleRequest{Command: "restart", CronExpression: "@daily", Services: []string{"a b"}}, ErrServiceNameInvalid, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if tt.anyErr {
+				if got == nil {
+					t.Errorf("Validate() = nil, want error")
+				}
+				return
+			}
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
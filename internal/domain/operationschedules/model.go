@@ -0,0 +1,50 @@
+package operationschedules
+
+import (
+	"time"
+
+	"berth/internal/platform/db"
+)
+
+const (
+	RunStatusStarted  = "started"
+	RunStatusFailed   = "failed"
+	RunStatusDeferred = "deferred"
+)
+
+type OperationSchedule struct {
+	db.BaseModel
+	ServerID       uint       `json:"server_id" gorm:"not null;index"`
+	StackName      string     `json:"stack_name" gorm:"not null;index"`
+	Command        string     `json:"command" gorm:"not null"`
+	Options        string     `json:"options,omitempty" gorm:"type:text"`
+	Services       string     `json:"services,omitempty" gorm:"type:text"`
+	CronExpression string     `json:"cron_expression" gorm:"not null"`
+	Enabled        bool       `json:"enabled" gorm:"not null"`
+	OwnerUserID    uint       `json:"owner_user_id" gorm:"not null;index"`
+	NextRunAt      *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+	LastRunStatus  string     `json:"last_run_status"`
+	LastRunMessage string     `json:"last_run_message" gorm:"type:text"`
+}
+
+func (OperationSchedule) TableName() string {
+	return "operation_schedules"
+}
+
+// OperationScheduleRun records one firing of a schedule. OperationLogID links
+// the run to the operation log holding its output when the operation started.
+type OperationScheduleRun struct {
+	ID             uint      `json:"id" gorm:"primarykey"`
+	CreatedAt      time.Time `json:"created_at"`
+	ScheduleID     uint      `json:"schedule_id" gorm:"not null;index"`
+	OperationID    string    `json:"operation_id,omitempty"`
+	OperationLogID *uint     `json:"operation_log_id,omitempty"`
+	Status         string    `json:"status" gorm:"not null"`
+	Message        string    `json:"message,omitempty" gorm:"type:text"`
+	StartedAt      time.Time `json:"started_at"`
+}
+
+func (OperationScheduleRun) TableName() string {
+	return "operation_schedule_runs"
+}
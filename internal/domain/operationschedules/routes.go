@@ -0,0 +1,15 @@
+package operationschedules
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
+
+func (h *APIHandler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/servers/:serverid/stacks/:stackname/operation-schedules", h.ListSchedules, authz.Stack(permnames.StacksRead))
+	reg.POST("/servers/:serverid/stacks/:stackname/operation-schedules", h.CreateSchedule, authz.Stack(permnames.StacksManage))
+	reg.GET("/servers/:serverid/stacks/:stackname/operation-schedules/:id/runs", h.ListRuns, authz.Stack(permnames.StacksRead))
+	reg.POST("/servers/:serverid/stacks/:stackname/operation-schedules/:id/pause", h.PauseSchedule, authz.Stack(permnames.StacksManage))
+	reg.POST("/servers/:serverid/stacks/:stackname/operation-schedules/:id/resume", h.ResumeSchedule, authz.Stack(permnames.StacksManage))
+	reg.DELETE("/servers/:serverid/stacks/:stackname/operation-schedules/:id", h.DeleteSchedule, authz.Stack(permnames.StacksManage))
+}
@@ -0,0 +1,376 @@
+package operationschedules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/maintwindows"
+	"berth/internal/domain/operationlogs"
+	"berth/internal/domain/operations"
+	"berth/internal/pkg/cronspec"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const runHistoryLimit = 50
+
+type schedulePrincipalResolver interface {
+	PrincipalForUser(userID uint) (authz.Principal, error)
+}
+
+type scheduleOperationRunner interface {
+	StartOperation(ctx context.Context, p authz.Principal, serverID uint, stackname string, req operations.OperationRequest) (*operations.OperationStartData, error)
+	RecordStartAndPersist(p authz.Principal, source operationlogs.TriggerSource, serverID uint, stackname string, operationID string, req operations.OperationRequest, startTime time.Time)
+}
+
+type scheduleOperationLogFinder interface {
+	FindOperationLogByOperationID(operationID string) (*operationlogs.OperationLog, error)
+}
+
+type scheduleWindowChecker interface {
+	StatusAt(serverID uint, stackName string, at time.Time) (*maintwindows.Status, error)
+}
+
+type Service struct {
+	db           *gorm.DB
+	principalSvc schedulePrincipalResolver
+	opsSvc       scheduleOperationRunner
+	logFinder    scheduleOperationLogFinder
+	windowSvc    scheduleWindowChecker
+	logger       *zap.Logger
+	now          func() time.Time
+}
+
+func NewService(db *gorm.DB, principalSvc schedulePrincipalResolver, opsSvc scheduleOperationRunner, logFinder scheduleOperationLogFinder, windowSvc scheduleWindowChecker, logger *zap.Logger) *Service {
+	return &Service{
+		db:           db,
+		principalSvc: principalSvc,
+		opsSvc:       opsSvc,
+		logFinder:    logFinder,
+		windowSvc:    windowSvc,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+func (s *Service) Logger() *zap.Logger {
+	return s.logger
+}
+
+func (s *Service) ListSchedules(serverID uint, stackName string) ([]OperationSchedule, error) {
+	var schedules []OperationSchedule
+	if err := s.db.Where("server_id = ? AND stack_name = ?", serverID, stackName).Order("id").Find(&schedules).Error; err != nil {
+		s.logger.Error("failed to list operation schedules",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_name", stackName),
+		)
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (s *Service) GetSchedule(serverID uint, stackName string, scheduleID uint) (*OperationSchedule, error) {
+	var schedule OperationSchedule
+	if err := s.db.Where("server_id = ? AND stack_name = ?", serverID, stackName).First(&schedule, scheduleID).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (s *Service) CreateSchedule(serverID uint, stackName string, req CreateScheduleRequest, ownerID uint) (*OperationSchedule, error) {
+	next, err := cronspec.Next(req.CronExpression, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := OperationSchedule{
+		ServerID:       serverID,
+		StackName:      stackName,
+		Command:        req.Command,
+		Options:        encodeList(req.Options),
+		Services:       encodeList(req.Services),
+		CronExpression: strings.TrimSpace(req.CronExpression),
+		Enabled:        req.Enabled == nil || *req.Enabled,
+		OwnerUserID:    ownerID,
+	}
+	if schedule.Enabled {
+		schedule.NextRunAt = &next
+	}
+
+	if err := s.db.Create(&schedule).Error; err != nil {
+		s.logger.Error("failed to create operation schedule",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_name", stackName),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("operation schedule created",
+		zap.Uint("schedule_id", schedule.ID),
+		zap.Uint("server_id", serverID),
+		zap.String("stack_name", stackName),
+		zap.String("command", schedule.Command),
+		zap.String("cron_expression", schedule.CronExpression),
+	)
+
+	return &schedule, nil
+}
+
+// SetEnabled pauses or resumes a schedule. Resuming computes the next run
+// from now, so slots missed while paused are not replayed.
+func (s *Service) SetEnabled(serverID uint, stackName string, scheduleID uint, enabled bool) (*OperationSchedule, error) {
+	schedule, err := s.GetSchedule(serverID, stackName, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{"enabled": enabled}
+	if enabled {
+		next, err := cronspec.Next(schedule.CronExpression, s.now())
+		if err != nil {
+			return nil, err
+		}
+		updates["next_run_at"] = next
+	} else {
+		updates["next_run_at"] = nil
+	}
+
+	if err := s.db.Model(schedule).Updates(updates).Error; err != nil {
+		s.logger.Error("failed to update operation schedule state",
+			zap.Error(err),
+			zap.Uint("schedule_id", scheduleID),
+			zap.Bool("enabled", enabled),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("operation schedule state changed",
+		zap.Uint("schedule_id", scheduleID),
+		zap.Bool("enabled", enabled),
+	)
+
+	return s.GetSchedule(serverID, stackName, scheduleID)
+}
+
+func (s *Service) DeleteSchedule(serverID uint, stackName string, scheduleID uint) error {
+	schedule, err := s.GetSchedule(serverID, stackName, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("schedule_id = ?", schedule.ID).Delete(&OperationScheduleRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(schedule).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to delete operation schedule",
+			zap.Error(err),
+			zap.Uint("schedule_id", scheduleID),
+		)
+		return err
+	}
+
+	s.logger.Info("operation schedule deleted",
+		zap.Uint("schedule_id", scheduleID),
+		zap.Uint("server_id", serverID),
+		zap.String("stack_name", stackName),
+	)
+
+	return nil
+}
+
+func (s *Service) ListRuns(scheduleID uint) ([]OperationScheduleRun, error) {
+	var runs []OperationScheduleRun
+	if err := s.db.Where("schedule_id = ?", scheduleID).
+		Order("started_at DESC, id DESC").
+		Limit(runHistoryLimit).
+		Find(&runs).Error; err != nil {
+		s.logger.Error("failed to list operation schedule runs",
+			zap.Error(err),
+			zap.Uint("schedule_id", scheduleID),
+		)
+		return nil, err
+	}
+	return runs, nil
+}
+
+// RunDueSchedules starts the operation of every enabled schedule whose next
+// run time has passed, acting as the schedule's owner.
+func (s *Service) RunDueSchedules(ctx context.Context) error {
+	now := s.now()
+
+	var due []OperationSchedule
+	if err := s.db.Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at").
+		Find(&due).Error; err != nil {
+		return fmt.Errorf("failed to query due operation schedules: %w", err)
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.runSchedule(ctx, &due[i], now)
+	}
+
+	return nil
+}
+
+func (s *Service) runSchedule(ctx context.Context, schedule *OperationSchedule, now time.Time) {
+	updates := map[string]any{"last_run_at": now}
+
+	next, err := cronspec.Next(schedule.CronExpression, now)
+	if err != nil {
+		s.logger.Error("operation schedule has an invalid cron expression; disabling",
+			zap.Error(err),
+			zap.Uint("schedule_id", schedule.ID),
+		)
+		updates["enabled"] = false
+		updates["next_run_at"] = nil
+		updates["last_run_status"] = RunStatusFailed
+		updates["last_run_message"] = err.Error()
+		s.saveRunResult(schedule.ID, updates)
+		return
+	}
+
+	deferral := s.windowDeferral(schedule, now)
+	if deferral != nil && deferral.NextOpenAt != nil {
+		next = *deferral.NextOpenAt
+	}
+
+	claimed := s.db.Model(&OperationSchedule{}).
+		Where("id = ? AND enabled = ? AND next_run_at <= ?", schedule.ID, true, now).
+		Update("next_run_at", next)
+	if claimed.Error != nil {
+		s.logger.Error("failed to claim operation schedule",
+			zap.Error(claimed.Error),
+			zap.Uint("schedule_id", schedule.ID),
+		)
+		return
+	}
+	if claimed.RowsAffected == 0 {
+		return
+	}
+
+	var run *OperationScheduleRun
+	if deferral != nil {
+		run = &OperationScheduleRun{
+			ScheduleID: schedule.ID,
+			Status:     RunStatusDeferred,
+			Message:    deferral.Reason,
+			StartedAt:  now,
+		}
+		s.logger.Info("scheduled operation deferred to maintenance window",
+			zap.Uint("schedule_id", schedule.ID),
+			zap.String("reason", deferral.Reason),
+		)
+	} else {
+		run = s.executeSchedule(ctx, schedule, now)
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		s.logger.Error("failed to record operation schedule run",
+			zap.Error(err),
+			zap.Uint("schedule_id", schedule.ID),
+		)
+	}
+
+	updates["last_run_status"] = run.Status
+	updates["last_run_message"] = run.Message
+	s.saveRunResult(schedule.ID, updates)
+}
+
+// windowDeferral returns the maintenance status when the schedule's command
+// is gated and the stack's window is closed, so the run waits for the window
+// rather than failing.
+func (s *Service) windowDeferral(schedule *OperationSchedule, now time.Time) *maintwindows.Status {
+	if !maintwindows.IsGatedCommand(schedule.Command) {
+		return nil
+	}
+	status, err := s.windowSvc.StatusAt(schedule.ServerID, schedule.StackName, now)
+	if err != nil {
+		s.logger.Warn("failed to evaluate maintenance windows for scheduled operation",
+			zap.Error(err),
+			zap.Uint("schedule_id", schedule.ID),
+		)
+		return nil
+	}
+	if status.Open {
+		return nil
+	}
+	return status
+}
+
+func (s *Service) executeSchedule(ctx context.Context, schedule *OperationSchedule, now time.Time) *OperationScheduleRun {
+	run := &OperationScheduleRun{
+		ScheduleID: schedule.ID,
+		StartedAt:  now,
+	}
+
+	p, err := s.principalSvc.PrincipalForUser(schedule.OwnerUserID)
+	if err != nil {
+		s.logger.Warn("failed to resolve operation schedule owner",
+			zap.Error(err),
+			zap.Uint("schedule_id", schedule.ID),
+			zap.Uint("owner_user_id", schedule.OwnerUserID),
+		)
+		run.Status = RunStatusFailed
+		run.Message = fmt.Sprintf("failed to resolve schedule owner: %v", err)
+		return run
+	}
+
+	req := operations.OperationRequest{
+		Command:  schedule.Command,
+		Options:  decodeList(schedule.Options),
+		Services: decodeList(schedule.Services),
+	}
+
+	resp, err := s.opsSvc.StartOperation(ctx, p, schedule.ServerID, schedule.StackName, req)
+	if err != nil {
+		s.logger.Error("scheduled operation failed to start",
+			zap.Error(err),
+			zap.Uint("schedule_id", schedule.ID),
+			zap.Uint("server_id", schedule.ServerID),
+			zap.String("stack_name", schedule.StackName),
+			zap.String("command", schedule.Command),
+		)
+		run.Status = RunStatusFailed
+		run.Message = err.Error()
+		return run
+	}
+
+	s.opsSvc.RecordStartAndPersist(p, operationlogs.TriggerSourceScheduled, schedule.ServerID, schedule.StackName, resp.OperationID, req, now)
+
+	run.Status = RunStatusStarted
+	run.OperationID = resp.OperationID
+	run.Message = fmt.Sprintf("started %s", schedule.Command)
+	if log, err := s.logFinder.FindOperationLogByOperationID(resp.OperationID); err == nil {
+		run.OperationLogID = &log.ID
+	}
+
+	s.logger.Info("scheduled operation started",
+		zap.Uint("schedule_id", schedule.ID),
+		zap.Uint("server_id", schedule.ServerID),
+		zap.String("stack_name", schedule.StackName),
+		zap.String("command", schedule.Command),
+		zap.String("operation_id", resp.OperationID),
+	)
+
+	return run
+}
+
+func (s *Service) saveRunResult(scheduleID uint, updates map[string]any) {
+	if err := s.db.Model(&OperationSchedule{}).Where("id = ?", scheduleID).Updates(updates).Error; err != nil {
+		s.logger.Error("failed to record operation schedule run",
+			zap.Error(err),
+			zap.Uint("schedule_id", scheduleID),
+		)
+	}
+}
@@ -0,0 +1,236 @@
+package operationschedules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/maintwindows"
+	"berth/internal/domain/operationlogs"
+	"berth/internal/domain/operations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type fakePrincipals struct {
+	missing map[uint]bool
+}
+
+func (f *fakePrincipals) PrincipalForUser(userID uint) (authz.Principal, error) {
+	if f.missing[userID] {
+		return authz.Principal{}, errors.New("user not found")
+	}
+	return authz.NewPrincipal(userID, false, nil), nil
+}
+
+type fakeOps struct {
+	started []operations.OperationRequest
+	callers []authz.Principal
+	fail    error
+}
+
+func (f *fakeOps) StartOperation(_ context.Context, p authz.Principal, _ uint, stackname string, req operations.OperationRequest) (*operations.OperationStartData, error) {
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	f.started = append(f.started, req)
+	f.callers = append(f.callers, p)
+	return &operations.OperationStartData{OperationID: fmt.Sprintf("op-%s-%d", stackname, len(f.started))}, nil
+}
+
+func (f *fakeOps) RecordStartAndPersist(authz.Principal, operationlogs.TriggerSource, uint, string, string, operations.OperationRequest, time.Time) {
+}
+
+type fakeLogs struct{}
+
+func (fakeLogs) FindOperationLogByOperationID(operationID string) (*operationlogs.OperationLog, error) {
+	log := &operationlogs.OperationLog{OperationID: operationID}
+	log.ID = 42
+	return log, nil
+}
+
+type fakeWindows struct {
+	status maintwindows.Status
+}
+
+func (f *fakeWindows) StatusAt(uint, string, time.Time) (*maintwindows.Status, error) {
+	status := f.status
+	return &status, nil
+}
+
+func newTestService(t *testing.T) (*Service, *fakeOps, *fakePrincipals) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:operationschedules_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&OperationSchedule{}, &OperationScheduleRun{}))
+
+	ops := &fakeOps{}
+	principals := &fakePrincipals{missing: map[uint]bool{}}
+	return NewService(db, principals, ops, fakeLogs{}, &fakeWindows{status: maintwindows.Status{Open: true}}, zap.NewNop()), ops, principals
+}
+
+func makeDue(t *testing.T, svc *Service, id uint) {
+	t.Helper()
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, svc.db.Model(&OperationSchedule{}).Where("id = ?", id).Update("next_run_at", past).Error)
+}
+
+func TestRunDueSchedules_StartsOperationAsOwner(t *testing.T) {
+	svc, ops, _ := newTestService(t)
+
+	schedule, err := svc.CreateSchedule(1, "app", CreateScheduleRequest{
+		Command:        "restart",
+		Services:       []string{"worker"},
+		Options:        []string{"--timeout=5"},
+		CronExpression: "@daily",
+	}, 7)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	require.Len(t, ops.started, 1)
+	assert.Equal(t, "restart", ops.started[0].Command)
+	assert.Equal(t, []string{"worker"}, ops.started[0].Services)
+	assert.Equal(t, []string{"--timeout=5"}, ops.started[0].Options)
+	assert.Equal(t, uint(7), ops.callers[0].UserID(), "operations run with the owner's principal")
+	assert.False(t, ops.callers[0].IsSystem())
+
+	runs, err := svc.ListRuns(schedule.ID)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, RunStatusStarted, runs[0].Status)
+	require.NotNil(t, runs[0].OperationLogID)
+	assert.Equal(t, uint(42), *runs[0].OperationLogID)
+
+	reloaded, err := svc.GetSchedule(1, "app", schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusStarted, reloaded.LastRunStatus)
+	require.NotNil(t, reloaded.NextRunAt)
+	assert.True(t, reloaded.NextRunAt.After(time.Now()))
+}
+
+func TestRunDueSchedules_RecordsPermissionFailure(t *testing.T) {
+	svc, ops, _ := newTestService(t)
+	ops.fail = errors.New("insufficient permissions for operation 'pull' on stack 'app' (requires stacks.manage)")
+
+	schedule, err := svc.CreateSchedule(1, "app", CreateScheduleRequest{Command: "pull", CronExpression: "@weekly"}, 7)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	runs, err := svc.ListRuns(schedule.ID)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, RunStatusFailed, runs[0].Status)
+	assert.Nil(t, runs[0].OperationLogID)
+	assert.Contains(t, runs[0].Message, "insufficient permissions")
+}
+
+func TestRunDueSchedules_FailsWhenOwnerIsGone(t *testing.T) {
+	svc, ops, principals := newTestService(t)
+	principals.missing[7] = true
+
+	schedule, err := svc.CreateSchedule(1, "app", CreateScheduleRequest{Command: "pull", CronExpression: "@weekly"}, 7)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	assert.Empty(t, ops.started)
+	reloaded, err := svc.GetSchedule(1, "app", schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusFailed, reloaded.LastRunStatus)
+}
+
+func TestRunDueSchedules_DefersGatedCommandUntilWindowOpens(t *testing.T) {
+	svc, ops, _ := newTestService(t)
+	opensAt := time.Now().Add(3 * time.Hour).Truncate(time.Second)
+	svc.windowSvc = &fakeWindows{status: maintwindows.Status{
+		Gated:      true,
+		Reason:     "no maintenance window is open",
+		NextOpenAt: &opensAt,
+	}}
+
+	schedule, err := svc.CreateSchedule(1, "app", CreateScheduleRequest{Command: "restart", CronExpression: "@daily"}, 7)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	assert.Empty(t, ops.started)
+	runs, err := svc.ListRuns(schedule.ID)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, RunStatusDeferred, runs[0].Status)
+	assert.Equal(t, "no maintenance window is open", runs[0].Message)
+
+	reloaded, err := svc.GetSchedule(1, "app", schedule.ID)
+	require.NoError(t, err)
+	require.NotNil(t, reloaded.NextRunAt)
+	assert.True(t, reloaded.NextRunAt.Equal(opensAt), "the next run waits for the window to open")
+}
+
+func TestSetEnabled_PauseStopsRunsAndResumeSkipsMissedSlots(t *testing.T) {
+	svc, ops, _ := newTestService(t)
+
+	schedule, err := svc.CreateSchedule(1, "app", CreateScheduleRequest{Command: "up", CronExpression: "@hourly"}, 7)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+
+	paused, err := svc.SetEnabled(1, "app", schedule.ID, false)
+	require.NoError(t, err)
+	assert.False(t, paused.Enabled)
+	assert.Nil(t, paused.NextRunAt)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+	assert.Empty(t, ops.started)
+
+	resumed, err := svc.SetEnabled(1, "app", schedule.ID, true)
+	require.NoError(t, err)
+	assert.True(t, resumed.Enabled)
+	require.NotNil(t, resumed.NextRunAt)
+	assert.True(t, resumed.NextRunAt.After(time.Now()), "resuming does not replay missed runs")
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+	assert.Empty(t, ops.started)
+}
+
+func TestGetSchedule_ScopedToStack(t *testing.T) {
+	svc, _, _ := newTestService(t)
+
+	schedule, err := svc.CreateSchedule(1, "app", CreateScheduleRequest{Command: "pull", CronExpression: "@daily"}, 7)
+	require.NoError(t, err)
+
+	_, err = svc.GetSchedule(1, "other", schedule.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = svc.GetSchedule(2, "app", schedule.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestDeleteSchedule_RemovesRunHistory(t *testing.T) {
+	svc, _, _ := newTestService(t)
+
+	schedule, err := svc.CreateSchedule(1, "app", CreateScheduleRequest{Command: "pull", CronExpression: "@daily"}, 7)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	require.NoError(t, svc.DeleteSchedule(1, "app", schedule.ID))
+
+	runs, err := svc.ListRuns(schedule.ID)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
@@ -0,0 +1,219 @@
+package prunepolicies
+
+import (
+	"errors"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/security"
+	"berth/internal/domain/session"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type APIHandler struct {
+	service      *Service
+	auditService policyAuditLogger
+}
+
+func NewAPIHandler(service *Service, auditService policyAuditLogger) *APIHandler {
+	return &APIHandler{
+		service:      service,
+		auditService: auditService,
+	}
+}
+
+func (h *APIHandler) ListPolicies(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+
+	policies, err := h.service.ListPolicies(serverID)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch prune policies")
+	}
+
+	return response.OK(c, ListPoliciesData{
+		Policies: ToResponseList(policies),
+	})
+}
+
+func (h *APIHandler) GetPolicy(c echo.Context) error {
+	_, policy, err := h.loadPolicy(c)
+	if err != nil || policy == nil {
+		return err
+	}
+
+	return response.OK(c, GetPolicyData{
+		Policy: ToResponse(policy),
+	})
+}
+
+func (h *APIHandler) CreatePolicy(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req PolicyRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	policy, err := h.service.CreatePolicy(serverID, req, p.UserID())
+	if err != nil {
+		return response.Internal(c, "Failed to create prune policy")
+	}
+
+	h.audit(c, p, security.EventDockerPrunePolicyCreated, policy)
+
+	return response.Created(c, GetPolicyData{
+		Policy: ToResponse(policy),
+	})
+}
+
+func (h *APIHandler) UpdatePolicy(c echo.Context) error {
+	p, existing, err := h.loadPolicy(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	var req PolicyRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	policy, err := h.service.UpdatePolicy(existing.ServerID, existing.ID, req)
+	if err != nil {
+		return response.Internal(c, "Failed to update prune policy")
+	}
+
+	h.audit(c, p, security.EventDockerPrunePolicyUpdated, policy)
+
+	return response.OK(c, GetPolicyData{
+		Policy: ToResponse(policy),
+	})
+}
+
+func (h *APIHandler) DeletePolicy(c echo.Context) error {
+	p, existing, err := h.loadPolicy(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	if err := h.service.DeletePolicy(existing.ServerID, existing.ID); err != nil {
+		return response.Internal(c, "Failed to delete prune policy")
+	}
+
+	h.audit(c, p, security.EventDockerPrunePolicyDeleted, existing)
+
+	return response.OK(c, DeletePolicyMessageData{
+		Message: "Prune policy deleted successfully",
+	})
+}
+
+func (h *APIHandler) PreviewPolicy(c echo.Context) error {
+	p, policy, err := h.loadPolicy(c)
+	if err != nil || policy == nil {
+		return err
+	}
+
+	preview, err := h.service.PreviewPolicy(c.Request().Context(), p, policy)
+	if err != nil {
+		return response.Internal(c, err.Error())
+	}
+
+	return response.OK(c, PreviewData{
+		Preview: *preview,
+	})
+}
+
+func (h *APIHandler) PreviewRequest(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req PreviewRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	preview, err := h.service.PreviewRequest(c.Request().Context(), p, serverID, req)
+	if err != nil {
+		return response.Internal(c, err.Error())
+	}
+
+	return response.OK(c, PreviewData{
+		Preview: *preview,
+	})
+}
+
+// loadPolicy resolves the policy named in the path. A nil policy with a nil
+// error means a response has already been written.
+func (h *APIHandler) loadPolicy(c echo.Context) (authz.Principal, *PrunePolicy, error) {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	policyID, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	policy, err := h.service.GetPolicy(serverID, policyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return p, nil, response.NotFound(c, "Prune policy not found")
+		}
+		return p, nil, response.Internal(c, "Failed to fetch prune policy")
+	}
+
+	return p, policy, nil
+}
+
+func (h *APIHandler) audit(c echo.Context, p authz.Principal, eventType string, policy *PrunePolicy) {
+	actorID := p.UserID()
+	policyID := policy.ID
+	serverID := policy.ServerID
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      eventType,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetType:     security.TargetTypePrunePolicy,
+		TargetID:       &policyID,
+		TargetName:     policy.Name,
+		Success:        true,
+		Metadata: map[string]any{
+			"resource_types":  decodeList(policy.ResourceTypes),
+			"min_age_hours":   policy.MinAgeHours,
+			"labels":          decodeList(policy.Labels),
+			"all_images":      policy.AllImages,
+			"cron_expression": policy.CronExpression,
+			"enabled":         policy.Enabled,
+		},
+		ServerID: &serverID,
+	})
+}
@@ -0,0 +1,198 @@
+package prunepolicies
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"berth/internal/pkg/cronspec"
+)
+
+const (
+	TypeImages     = "images"
+	TypeContainers = "containers"
+	TypeVolumes    = "volumes"
+	TypeNetworks   = "networks"
+	TypeBuildCache = "build-cache"
+)
+
+// maxMinAgeHours caps the minimum age at one year.
+const maxMinAgeHours = 365 * 24
+
+var validResourceTypes = map[string]struct{}{
+	TypeImages:     {},
+	TypeContainers: {},
+	TypeVolumes:    {},
+	TypeNetworks:   {},
+	TypeBuildCache: {},
+}
+
+var (
+	ErrNameRequired         = errors.New("name is required")
+	ErrResourceTypeRequired = errors.New("at least one resource type is required")
+	ErrResourceTypeInvalid  = errors.New("resource types must be images, containers, volumes, networks or build-cache")
+	ErrResourceTypeRepeated = errors.New("resource types must not repeat")
+	ErrMinAgeRange          = errors.New("min_age_hours must be between 0 and 8760")
+	ErrVolumesMinAge        = errors.New("volumes cannot be pruned with min_age_hours because Docker cannot filter volumes by age")
+	ErrLabelInvalid         = errors.New("labels must be 'key' or 'key=value' without whitespace")
+	ErrCronRequired         = errors.New("cron_expression is required")
+)
+
+type PolicyRequest struct {
+	Name           string   `json:"name"`
+	ResourceTypes  []string `json:"resource_types"`
+	MinAgeHours    int      `json:"min_age_hours"`
+	Labels         []string `json:"labels,omitempty"`
+	AllImages      bool     `json:"all_images"`
+	CronExpression string   `json:"cron_expression"`
+	Enabled        *bool    `json:"enabled,omitempty"`
+}
+
+func (r *PolicyRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrNameRequired
+	}
+	if err := r.validateFilters(); err != nil {
+		return err
+	}
+	cron := strings.TrimSpace(r.CronExpression)
+	if cron == "" {
+		return ErrCronRequired
+	}
+	return cronspec.Validate(cron)
+}
+
+// validateFilters checks the fields shared with preview requests, which have
+// no name or schedule.
+func (r *PolicyRequest) validateFilters() error {
+	if len(r.ResourceTypes) == 0 {
+		return ErrResourceTypeRequired
+	}
+	seen := make(map[string]bool, len(r.ResourceTypes))
+	for _, t := range r.ResourceTypes {
+		if _, ok := validResourceTypes[t]; !ok {
+			return ErrResourceTypeInvalid
+		}
+		if seen[t] {
+			return ErrResourceTypeRepeated
+		}
+		seen[t] = true
+	}
+	if r.MinAgeHours < 0 || r.MinAgeHours > maxMinAgeHours {
+		return ErrMinAgeRange
+	}
+	if r.MinAgeHours > 0 && seen[TypeVolumes] {
+		return ErrVolumesMinAge
+	}
+	for _, label := range r.Labels {
+		if label == "" || strings.HasPrefix(label, "=") || strings.ContainsAny(label, " \t\r\n") {
+			return ErrLabelInvalid
+		}
+	}
+	return nil
+}
+
+type PreviewRequest struct {
+	ResourceTypes []string `json:"resource_types"`
+	MinAgeHours   int      `json:"min_age_hours"`
+	Labels        []string `json:"labels,omitempty"`
+	AllImages     bool     `json:"all_images"`
+}
+
+func (r *PreviewRequest) Validate() error {
+	req := PolicyRequest{
+		ResourceTypes: r.ResourceTypes,
+		MinAgeHours:   r.MinAgeHours,
+		Labels:        r.Labels,
+	}
+	return req.validateFilters()
+}
+
+type PrunePolicyInfo struct {
+	ID                 uint       `json:"id"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	ServerID           uint       `json:"server_id"`
+	Name               string     `json:"name"`
+	ResourceTypes      []string   `json:"resource_types"`
+	MinAgeHours        int        `json:"min_age_hours"`
+	Labels             []string   `json:"labels"`
+	AllImages          bool       `json:"all_images"`
+	CronExpression     string     `json:"cron_expression"`
+	Enabled            bool       `json:"enabled"`
+	CreatedByUserID    *uint      `json:"created_by_user_id,omitempty"`
+	NextRunAt          *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt          *time.Time `json:"last_run_at,omitempty"`
+	LastRunStatus      string     `json:"last_run_status,omitempty"`
+	LastRunMessage     string     `json:"last_run_message,omitempty"`
+	LastSpaceReclaimed int64      `json:"last_space_reclaimed"`
+}
+
+type ListPoliciesData struct {
+	Policies []PrunePolicyInfo `json:"policies"`
+}
+
+type GetPolicyData struct {
+	Policy PrunePolicyInfo `json:"policy"`
+}
+
+type PreviewData struct {
+	Preview Preview `json:"preview"`
+}
+
+type DeletePolicyMessageData struct {
+	Message string `json:"message"`
+}
+
+func ToResponse(p *PrunePolicy) PrunePolicyInfo {
+	return PrunePolicyInfo{
+		ID:                 p.ID,
+		CreatedAt:          p.CreatedAt,
+		UpdatedAt:          p.UpdatedAt,
+		ServerID:           p.ServerID,
+		Name:               p.Name,
+		ResourceTypes:      decodeList(p.ResourceTypes),
+		MinAgeHours:        p.MinAgeHours,
+		Labels:             decodeList(p.Labels),
+		AllImages:          p.AllImages,
+		CronExpression:     p.CronExpression,
+		Enabled:            p.Enabled,
+		CreatedByUserID:    p.CreatedByUserID,
+		NextRunAt:          p.NextRunAt,
+		LastRunAt:          p.LastRunAt,
+		LastRunStatus:      p.LastRunStatus,
+		LastRunMessage:     p.LastRunMessage,
+		LastSpaceReclaimed: p.LastSpaceReclaimed,
+	}
+}
+
+func ToResponseList(policies []PrunePolicy) []PrunePolicyInfo {
+	result := make([]PrunePolicyInfo, len(policies))
+	for i := range policies {
+		result[i] = ToResponse(&policies[i])
+	}
+	return result
+}
+
+func encodeList(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+func decodeList(raw string) []string {
+	values := []string{}
+	if raw == "" {
+		return values
+	}
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return []string{}
+	}
+	return values
+}
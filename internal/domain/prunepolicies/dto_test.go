@@ -0,0 +1,80 @@
+package prunepolicies
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicyRequest_Validate(t *testing.T) {
+	valid := func(mod func(*PolicyRequest)) PolicyRequest {
+		req := PolicyRequest{
+			Name:           "nightly",
+			ResourceTypes:  []string{TypeImages, TypeContainers},
+			MinAgeHours:    72,
+			Labels:         []string{"env=dev", "ephemeral"},
+			CronExpression: "0 3 * * *",
+		}
+		if mod != nil {
+			mod(&req)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		req     PolicyRequest
+		wantErr error
+		anyErr  bool
+	}{
+		{"valid", valid(nil), nil, false},
+		{"missing name", valid(func(r *PolicyRequest) { r.Name = " " }), ErrNameRequired, false},
+		{"no types", valid(func(r *PolicyRequest) { r.ResourceTypes = nil }), ErrResourceTypeRequired, false},
+		{"system type", valid(func(r *PolicyRequest) { r.ResourceTypes = []string{"system"} }), ErrResourceTypeInvalid, false},
+		{"repeated type", valid(func(r *PolicyRequest) { r.ResourceTypes = []string{TypeVolumes, TypeVolumes} }), ErrResourceTypeRepeated, false},
+		{"negative age", valid(func(r *PolicyRequest) { r.MinAgeHours = -1 }), ErrMinAgeRange, false},
+		{"age too large", valid(func(r *PolicyRequest) { r.MinAgeHours = 9000 }), ErrMinAgeRange, false},
+		{"volumes with min age", valid(func(r *PolicyRequest) { r.ResourceTypes = []string{TypeImages, TypeVolumes} }), ErrVolumesMinAge, false},
+		{"volumes without min age", valid(func(r *PolicyRequest) { r.ResourceTypes = []string{TypeVolumes}; r.MinAgeHours = 0 }), nil, false},
+		{"empty label", valid(func(r *PolicyRequest) { r.Labels = []string{""} }), ErrLabelInvalid, false},
+		{"label without key", valid(func(r *PolicyRequest) { r.Labels = []string{"=dev"} }), ErrLabelInvalid, false},
+		{"label with space", valid(func(r *PolicyRequest) { r.Labels = []string{"env=my dev"} }), ErrLabelInvalid, false},
+		{"missing cron", valid(func(r *PolicyRequest) { r.CronExpression = "" }), ErrCronRequired, false},
+		{"invalid cron", valid(func(r *PolicyRequest) { r.CronExpression = "nightly" }), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if tt.anyErr {
+				if got == nil {
+					t.Errorf("Validate() = nil, want error")
+				}
+				return
+			}
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPreviewRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     PreviewRequest
+		wantErr error
+	}{
+		{"valid without schedule", PreviewRequest{ResourceTypes: []string{TypeBuildCache}}, nil},
+		{"no types", PreviewRequest{}, ErrResourceTypeRequired},
+		{"bad label", PreviewRequest{ResourceTypes: []string{TypeImages}, Labels: []string{"a b"}}, ErrLabelInvalid},
+		{"volumes with min age", PreviewRequest{ResourceTypes: []string{TypeVolumes}, MinAgeHours: 24}, ErrVolumesMinAge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.req.Validate(); !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
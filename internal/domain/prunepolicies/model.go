@@ -0,0 +1,39 @@
+package prunepolicies
+
+import (
+	"time"
+
+	"berth/internal/platform/db"
+)
+
+const (
+	RunStatusSuccess = "success"
+	RunStatusPartial = "partial"
+	RunStatusFailed  = "failed"
+	RunStatusSkipped = "skipped"
+)
+
+// PrunePolicy prunes the listed Docker resource types on a server on a cron
+// schedule. MinAgeHours and Labels narrow what is removed; both are passed to
+// the agent as Docker prune filters.
+type PrunePolicy struct {
+	db.BaseModel
+	ServerID           uint       `json:"server_id" gorm:"not null;index"`
+	Name               string     `json:"name" gorm:"not null"`
+	ResourceTypes      string     `json:"resource_types" gorm:"type:text;not null"`
+	MinAgeHours        int        `json:"min_age_hours" gorm:"not null;default:0"`
+	Labels             string     `json:"labels,omitempty" gorm:"type:text"`
+	AllImages          bool       `json:"all_images" gorm:"not null"`
+	CronExpression     string     `json:"cron_expression" gorm:"not null"`
+	Enabled            bool       `json:"enabled" gorm:"not null"`
+	CreatedByUserID    *uint      `json:"created_by_user_id"`
+	NextRunAt          *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt          *time.Time `json:"last_run_at"`
+	LastRunStatus      string     `json:"last_run_status"`
+	LastRunMessage     string     `json:"last_run_message" gorm:"type:text"`
+	LastSpaceReclaimed int64      `json:"last_space_reclaimed" gorm:"not null;default:0"`
+}
+
+func (PrunePolicy) TableName() string {
+	return "docker_prune_policies"
+}
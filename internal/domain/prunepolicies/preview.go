@@ -0,0 +1,219 @@
+package prunepolicies
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"berth/internal/domain/maintenance"
+)
+
+// Preview estimates what a policy would remove, from the agent's system info.
+// Image sizes include layers shared with kept images, so ReclaimableSize is
+// an upper bound.
+type Preview struct {
+	GeneratedAt     time.Time      `json:"generated_at"`
+	Cutoff          *time.Time     `json:"cutoff,omitempty"`
+	Groups          []PreviewGroup `json:"groups"`
+	TotalCount      int            `json:"total_count"`
+	ReclaimableSize int64          `json:"reclaimable_size"`
+}
+
+type PreviewGroup struct {
+	Type  string        `json:"type"`
+	Count int           `json:"count"`
+	Size  int64         `json:"size"`
+	Items []PreviewItem `json:"items"`
+	Notes []string      `json:"notes,omitempty"`
+}
+
+type PreviewItem struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	Created time.Time `json:"created"`
+}
+
+// criteria are the prune settings shared by saved policies and previews.
+type criteria struct {
+	types       []string
+	minAgeHours int
+	labels      []string
+	allImages   bool
+}
+
+func policyCriteria(policy *PrunePolicy) criteria {
+	return criteria{
+		types:       decodeList(policy.ResourceTypes),
+		minAgeHours: policy.MinAgeHours,
+		labels:      decodeList(policy.Labels),
+		allImages:   policy.AllImages,
+	}
+}
+
+func requestCriteria(req PreviewRequest) criteria {
+	return criteria{
+		types:       req.ResourceTypes,
+		minAgeHours: req.MinAgeHours,
+		labels:      req.Labels,
+		allImages:   req.AllImages,
+	}
+}
+
+// supportsAge and supportsLabels mirror the filters Docker accepts for each
+// prune: volume prune has no until filter and builder prune has no label
+// filter.
+func supportsAge(resourceType string) bool {
+	return resourceType != TypeVolumes
+}
+
+func supportsLabels(resourceType string) bool {
+	return resourceType != TypeBuildCache
+}
+
+// pruneFilters encodes the criteria as Docker prune filters for one
+// resource type, or returns "" when none apply.
+func (c criteria) pruneFilters(resourceType string) string {
+	filters := map[string][]string{}
+	if c.minAgeHours > 0 && supportsAge(resourceType) {
+		filters["until"] = []string{fmt.Sprintf("%dh", c.minAgeHours)}
+	}
+	if len(c.labels) > 0 && supportsLabels(resourceType) {
+		filters["label"] = c.labels
+	}
+	if len(filters) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(filters)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func (c criteria) cutoff(now time.Time) *time.Time {
+	if c.minAgeHours == 0 {
+		return nil
+	}
+	cutoff := now.Add(-time.Duration(c.minAgeHours) * time.Hour)
+	return &cutoff
+}
+
+func buildPreview(info *maintenance.MaintenanceInfo, c criteria, now time.Time) Preview {
+	preview := Preview{
+		GeneratedAt: now,
+		Cutoff:      c.cutoff(now),
+		Groups:      []PreviewGroup{},
+	}
+
+	olderThanCutoff := func(resourceType string, t time.Time) bool {
+		return preview.Cutoff == nil || !supportsAge(resourceType) || t.Before(*preview.Cutoff)
+	}
+
+	for _, resourceType := range c.types {
+		group := PreviewGroup{Type: resourceType, Items: []PreviewItem{}}
+
+		switch resourceType {
+		case TypeImages:
+			if len(c.labels) > 0 {
+				group.Notes = append(group.Notes, "the agent does not report image labels, so label filters are applied when pruning but not in this preview")
+			}
+			for _, img := range info.ImageSummary.Images {
+				candidate := img.Dangling || (c.allImages && img.Unused)
+				if !candidate || !olderThanCutoff(resourceType, img.Created) {
+					continue
+				}
+				group.add(PreviewItem{ID: img.ID, Name: imageName(img), Size: img.Size, Created: img.Created})
+			}
+		case TypeContainers:
+			for _, ctr := range info.ContainerSummary.Containers {
+				if !isStopped(ctr.State) || !olderThanCutoff(resourceType, ctr.Created) || !matchesLabels(ctr.Labels, c.labels) {
+					continue
+				}
+				group.add(PreviewItem{ID: ctr.ID, Name: ctr.Name, Size: ctr.Size, Created: ctr.Created})
+			}
+		case TypeVolumes:
+			if c.minAgeHours > 0 {
+				group.Notes = append(group.Notes, ErrVolumesMinAge.Error())
+				break
+			}
+			for _, vol := range info.VolumeSummary.Volumes {
+				if !vol.Unused || !matchesLabels(vol.Labels, c.labels) {
+					continue
+				}
+				group.add(PreviewItem{ID: vol.Name, Name: vol.Name, Size: vol.Size, Created: vol.Created})
+			}
+		case TypeNetworks:
+			for _, nw := range info.NetworkSummary.Networks {
+				if !nw.Unused || isPredefinedNetwork(nw.Name) || !olderThanCutoff(resourceType, nw.Created) || !matchesLabels(nw.Labels, c.labels) {
+					continue
+				}
+				group.add(PreviewItem{ID: nw.ID, Name: nw.Name, Created: nw.Created})
+			}
+		case TypeBuildCache:
+			if len(c.labels) > 0 {
+				group.Notes = append(group.Notes, "Docker cannot filter build cache by label, so label filters do not apply to it")
+			}
+			for _, entry := range info.BuildCacheSummary.Cache {
+				lastUsed := entry.LastUsed
+				if lastUsed.IsZero() {
+					lastUsed = entry.Created
+				}
+				if entry.InUse || !olderThanCutoff(resourceType, lastUsed) {
+					continue
+				}
+				group.add(PreviewItem{ID: entry.ID, Name: entry.Description, Size: entry.Size, Created: entry.Created})
+			}
+		}
+
+		preview.TotalCount += group.Count
+		preview.ReclaimableSize += group.Size
+		preview.Groups = append(preview.Groups, group)
+	}
+
+	return preview
+}
+
+func (g *PreviewGroup) add(item PreviewItem) {
+	g.Items = append(g.Items, item)
+	g.Count++
+	g.Size += item.Size
+}
+
+func imageName(img maintenance.ImageInfo) string {
+	if img.Repository == "" || img.Repository == "<none>" {
+		return img.ID
+	}
+	return img.Repository + ":" + img.Tag
+}
+
+func isStopped(state string) bool {
+	switch state {
+	case "exited", "created", "dead":
+		return true
+	}
+	return false
+}
+
+// isPredefinedNetwork reports the networks Docker never prunes.
+func isPredefinedNetwork(name string) bool {
+	switch name {
+	case "bridge", "host", "none":
+		return true
+	}
+	return false
+}
+
+// matchesLabels applies Docker's label filter semantics: every filter must
+// match, either as a bare key that must be present or as key=value.
+func matchesLabels(labels map[string]string, filters []string) bool {
+	for _, filter := range filters {
+		key, value, hasValue := strings.Cut(filter, "=")
+		actual, ok := labels[key]
+		if !ok || (hasValue && actual != value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,16 @@
+package prunepolicies
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
+
+func (h *APIHandler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/servers/:serverid/prune-policies", h.ListPolicies, authz.Server(permnames.DockerMaintenanceRead))
+	reg.POST("/servers/:serverid/prune-policies", h.CreatePolicy, authz.Server(permnames.DockerMaintenanceWrite))
+	reg.POST("/servers/:serverid/prune-policies/preview", h.PreviewRequest, authz.Server(permnames.DockerMaintenanceRead))
+	reg.GET("/servers/:serverid/prune-policies/:id", h.GetPolicy, authz.Server(permnames.DockerMaintenanceRead))
+	reg.PUT("/servers/:serverid/prune-policies/:id", h.UpdatePolicy, authz.Server(permnames.DockerMaintenanceWrite))
+	reg.DELETE("/servers/:serverid/prune-policies/:id", h.DeletePolicy, authz.Server(permnames.DockerMaintenanceWrite))
+	reg.GET("/servers/:serverid/prune-policies/:id/preview", h.PreviewPolicy, authz.Server(permnames.DockerMaintenanceRead))
+}
@@ -0,0 +1,14 @@
+package prunepolicies
+
+import (
+	"time"
+
+	"berth/internal/pkg/periodic"
+
+	"go.uber.org/zap"
+)
+
+// NewScheduler returns a runner that runs due prune policies every 30 seconds.
+func NewScheduler(service *Service, logger *zap.Logger) *periodic.Runner {
+	return periodic.NewRunner("prune scheduler", 30*time.Second, service.RunDueSchedules, logger)
+}
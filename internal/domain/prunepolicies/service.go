@@ -0,0 +1,361 @@
+package prunepolicies
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/maintenance"
+	"berth/internal/domain/security"
+	"berth/internal/domain/server"
+	"berth/internal/pkg/cronspec"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// pruneActor is recorded as the actor of scheduled prunes.
+const pruneActor = "prune-policy"
+
+type policyServerProvider interface {
+	GetServer(id uint) (*server.Server, error)
+}
+
+type policyMaintenance interface {
+	GetSystemInfo(ctx context.Context, p authz.Principal, serverID uint) (*maintenance.MaintenanceInfo, error)
+	PruneDocker(ctx context.Context, p authz.Principal, serverID uint, request *maintenance.PruneRequest) (*maintenance.PruneResult, error)
+}
+
+type policyAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+type Service struct {
+	db        *gorm.DB
+	serverSvc policyServerProvider
+	maintSvc  policyMaintenance
+	auditSvc  policyAuditLogger
+	logger    *zap.Logger
+	now       func() time.Time
+}
+
+func NewService(db *gorm.DB, serverSvc policyServerProvider, maintSvc policyMaintenance, auditSvc policyAuditLogger, logger *zap.Logger) *Service {
+	return &Service{
+		db:        db,
+		serverSvc: serverSvc,
+		maintSvc:  maintSvc,
+		auditSvc:  auditSvc,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+func (s *Service) Logger() *zap.Logger {
+	return s.logger
+}
+
+func (s *Service) ListPolicies(serverID uint) ([]PrunePolicy, error) {
+	var policies []PrunePolicy
+	if err := s.db.Where("server_id = ?", serverID).Order("id").Find(&policies).Error; err != nil {
+		s.logger.Error("failed to list prune policies",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+		)
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (s *Service) GetPolicy(serverID, policyID uint) (*PrunePolicy, error) {
+	var policy PrunePolicy
+	if err := s.db.Where("server_id = ?", serverID).First(&policy, policyID).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (s *Service) CreatePolicy(serverID uint, req PolicyRequest, createdBy uint) (*PrunePolicy, error) {
+	policy := PrunePolicy{
+		ServerID: serverID,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+	}
+	if createdBy != 0 {
+		policy.CreatedByUserID = &createdBy
+	}
+	if err := s.applyRequest(&policy, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(&policy).Error; err != nil {
+		s.logger.Error("failed to create prune policy",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("prune policy created",
+		zap.Uint("policy_id", policy.ID),
+		zap.Uint("server_id", serverID),
+		zap.Strings("resource_types", req.ResourceTypes),
+		zap.String("cron_expression", policy.CronExpression),
+	)
+
+	return &policy, nil
+}
+
+func (s *Service) UpdatePolicy(serverID, policyID uint, req PolicyRequest) (*PrunePolicy, error) {
+	policy, err := s.GetPolicy(serverID, policyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		policy.Enabled = *req.Enabled
+	}
+	if err := s.applyRequest(policy, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(policy).Error; err != nil {
+		s.logger.Error("failed to update prune policy",
+			zap.Error(err),
+			zap.Uint("policy_id", policyID),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("prune policy updated",
+		zap.Uint("policy_id", policyID),
+		zap.Uint("server_id", serverID),
+		zap.Bool("enabled", policy.Enabled),
+	)
+
+	return policy, nil
+}
+
+func (s *Service) DeletePolicy(serverID, policyID uint) error {
+	policy, err := s.GetPolicy(serverID, policyID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(policy).Error; err != nil {
+		s.logger.Error("failed to delete prune policy",
+			zap.Error(err),
+			zap.Uint("policy_id", policyID),
+		)
+		return err
+	}
+
+	s.logger.Info("prune policy deleted",
+		zap.Uint("policy_id", policyID),
+		zap.Uint("server_id", serverID),
+	)
+	return nil
+}
+
+func (s *Service) applyRequest(policy *PrunePolicy, req PolicyRequest) error {
+	policy.Name = strings.TrimSpace(req.Name)
+	policy.ResourceTypes = encodeList(req.ResourceTypes)
+	policy.MinAgeHours = req.MinAgeHours
+	policy.Labels = encodeList(req.Labels)
+	policy.AllImages = req.AllImages
+	policy.CronExpression = strings.TrimSpace(req.CronExpression)
+
+	next, err := cronspec.Next(policy.CronExpression, s.now())
+	if err != nil {
+		return err
+	}
+	policy.NextRunAt = &next
+	return nil
+}
+
+// PreviewPolicy estimates what the policy would remove right now.
+func (s *Service) PreviewPolicy(ctx context.Context, p authz.Principal, policy *PrunePolicy) (*Preview, error) {
+	return s.preview(ctx, p, policy.ServerID, policyCriteria(policy))
+}
+
+// PreviewRequest estimates what unsaved policy settings would remove.
+func (s *Service) PreviewRequest(ctx context.Context, p authz.Principal, serverID uint, req PreviewRequest) (*Preview, error) {
+	return s.preview(ctx, p, serverID, requestCriteria(req))
+}
+
+func (s *Service) preview(ctx context.Context, p authz.Principal, serverID uint, c criteria) (*Preview, error) {
+	info, err := s.maintSvc.GetSystemInfo(ctx, p, serverID)
+	if err != nil {
+		return nil, err
+	}
+	preview := buildPreview(info, c, s.now())
+	return &preview, nil
+}
+
+// RunDueSchedules executes every enabled policy whose next run time has
+// passed.
+func (s *Service) RunDueSchedules(ctx context.Context) error {
+	now := s.now()
+
+	var due []PrunePolicy
+	if err := s.db.Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at").
+		Find(&due).Error; err != nil {
+		return fmt.Errorf("failed to query due prune policies: %w", err)
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.runPolicy(ctx, &due[i], now)
+	}
+
+	return nil
+}
+
+func (s *Service) runPolicy(ctx context.Context, policy *PrunePolicy, now time.Time) {
+	updates := map[string]any{"last_run_at": now}
+
+	next, err := cronspec.Next(policy.CronExpression, now)
+	if err != nil {
+		s.logger.Error("prune policy has an invalid cron expression; clearing it",
+			zap.Error(err),
+			zap.Uint("policy_id", policy.ID),
+		)
+		updates["next_run_at"] = nil
+		updates["last_run_status"] = RunStatusFailed
+		updates["last_run_message"] = err.Error()
+		s.saveRunResult(policy.ID, updates)
+		return
+	}
+
+	claimed := s.db.Model(&PrunePolicy{}).
+		Where("id = ? AND next_run_at <= ?", policy.ID, now).
+		Update("next_run_at", next)
+	if claimed.Error != nil {
+		s.logger.Error("failed to claim prune policy",
+			zap.Error(claimed.Error),
+			zap.Uint("policy_id", policy.ID),
+		)
+		return
+	}
+	if claimed.RowsAffected == 0 {
+		return
+	}
+
+	status, message, reclaimed := s.executePolicy(ctx, policy)
+	updates["last_run_status"] = status
+	updates["last_run_message"] = message
+	updates["last_space_reclaimed"] = reclaimed
+	s.saveRunResult(policy.ID, updates)
+}
+
+// executePolicy prunes each resource type as the system principal, auditing
+// every prune the agent performs.
+func (s *Service) executePolicy(ctx context.Context, policy *PrunePolicy) (string, string, int64) {
+	srv, err := s.serverSvc.GetServer(policy.ServerID)
+	if err != nil {
+		return RunStatusFailed, fmt.Sprintf("failed to load server: %v", err), 0
+	}
+	if !srv.IsActive {
+		return RunStatusSkipped, "server is not active", 0
+	}
+
+	c := policyCriteria(policy)
+	var reclaimed int64
+	var items int
+	var pruned, failed []string
+	for _, resourceType := range c.types {
+		// Policies saved before volumes and a minimum age were rejected
+		// together would otherwise remove volumes of every age.
+		if resourceType == TypeVolumes && c.minAgeHours > 0 {
+			failed = append(failed, fmt.Sprintf("%s: %v", resourceType, ErrVolumesMinAge))
+			continue
+		}
+
+		request := &maintenance.PruneRequest{
+			Type:    resourceType,
+			Force:   true,
+			All:     resourceType == TypeImages && c.allImages,
+			Filters: c.pruneFilters(resourceType),
+		}
+
+		result, err := s.maintSvc.PruneDocker(ctx, authz.SystemPrincipal, policy.ServerID, request)
+		s.auditPrune(policy, request, result, err)
+		if err != nil {
+			s.logger.Error("scheduled Docker prune failed",
+				zap.Error(err),
+				zap.Uint("policy_id", policy.ID),
+				zap.Uint("server_id", policy.ServerID),
+				zap.String("prune_type", resourceType),
+			)
+			failed = append(failed, fmt.Sprintf("%s: %v", resourceType, err))
+			continue
+		}
+
+		reclaimed += result.SpaceReclaimed
+		items += len(result.ItemsDeleted)
+		pruned = append(pruned, resourceType)
+	}
+
+	s.logger.Info("prune policy executed",
+		zap.Uint("policy_id", policy.ID),
+		zap.Uint("server_id", policy.ServerID),
+		zap.Strings("pruned", pruned),
+		zap.Int("failed", len(failed)),
+		zap.Int64("space_reclaimed", reclaimed),
+	)
+
+	summary := fmt.Sprintf("removed %d item(s), reclaiming %d bytes", items, reclaimed)
+	switch {
+	case len(failed) == 0:
+		return RunStatusSuccess, summary, reclaimed
+	case len(pruned) == 0:
+		return RunStatusFailed, strings.Join(failed, "; "), reclaimed
+	default:
+		return RunStatusPartial, fmt.Sprintf("%s; failed %s", summary, strings.Join(failed, "; ")), reclaimed
+	}
+}
+
+func (s *Service) auditPrune(policy *PrunePolicy, request *maintenance.PruneRequest, result *maintenance.PruneResult, pruneErr error) {
+	serverID := policy.ServerID
+	policyID := policy.ID
+	metadata := map[string]any{
+		"actor":       pruneActor,
+		"policy_id":   policy.ID,
+		"policy_name": policy.Name,
+		"prune_type":  request.Type,
+		"force":       request.Force,
+		"all":         request.All,
+		"filters":     request.Filters,
+	}
+	if result != nil {
+		metadata["items_deleted"] = len(result.ItemsDeleted)
+		metadata["space_reclaimed"] = result.SpaceReclaimed
+	}
+
+	event := security.LogEvent{
+		EventType:     security.EventDockerPruneExecuted,
+		Success:       pruneErr == nil,
+		ActorUsername: pruneActor,
+		TargetType:    security.TargetTypePrunePolicy,
+		TargetID:      &policyID,
+		TargetName:    policy.Name,
+		ServerID:      &serverID,
+		Metadata:      metadata,
+	}
+	if pruneErr != nil {
+		event.FailureReason = pruneErr.Error()
+	}
+	_ = s.auditSvc.Log(event)
+}
+
+func (s *Service) saveRunResult(policyID uint, updates map[string]any) {
+	if err := s.db.Model(&PrunePolicy{}).Where("id = ?", policyID).Updates(updates).Error; err != nil {
+		s.logger.Error("failed to record prune policy run",
+			zap.Error(err),
+			zap.Uint("policy_id", policyID),
+		)
+	}
+}
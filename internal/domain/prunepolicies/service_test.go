@@ -0,0 +1,305 @@
+package prunepolicies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/maintenance"
+	"berth/internal/domain/security"
+	"berth/internal/domain/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type fakeServers struct {
+	inactive bool
+}
+
+func (f *fakeServers) GetServer(id uint) (*server.Server, error) {
+	srv := &server.Server{IsActive: !f.inactive}
+	srv.ID = id
+	return srv, nil
+}
+
+type fakeMaintenance struct {
+	info     *maintenance.MaintenanceInfo
+	requests []maintenance.PruneRequest
+	callers  []authz.Principal
+	fail     map[string]error
+}
+
+func (f *fakeMaintenance) GetSystemInfo(context.Context, authz.Principal, uint) (*maintenance.MaintenanceInfo, error) {
+	return f.info, nil
+}
+
+func (f *fakeMaintenance) PruneDocker(_ context.Context, p authz.Principal, _ uint, request *maintenance.PruneRequest) (*maintenance.PruneResult, error) {
+	f.requests = append(f.requests, *request)
+	f.callers = append(f.callers, p)
+	if err := f.fail[request.Type]; err != nil {
+		return nil, err
+	}
+	return &maintenance.PruneResult{Type: request.Type, ItemsDeleted: []string{"a", "b"}, SpaceReclaimed: 1000}, nil
+}
+
+type fakeAudit struct {
+	events []security.LogEvent
+}
+
+func (f *fakeAudit) Log(event security.LogEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func newTestService(t *testing.T) (*Service, *fakeMaintenance, *fakeAudit, *fakeServers) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:prunepolicies_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&PrunePolicy{}))
+
+	maint := &fakeMaintenance{fail: map[string]error{}}
+	audit := &fakeAudit{}
+	servers := &fakeServers{}
+	return NewService(db, servers, maint, audit, zap.NewNop()), maint, audit, servers
+}
+
+func makeDue(t *testing.T, svc *Service, id uint) {
+	t.Helper()
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, svc.db.Model(&PrunePolicy{}).Where("id = ?", id).Update("next_run_at", past).Error)
+}
+
+func TestRunDueSchedules_PrunesEachTypeWithFilters(t *testing.T) {
+	svc, maint, audit, _ := newTestService(t)
+
+	policy, err := svc.CreatePolicy(1, PolicyRequest{
+		Name:           "nightly",
+		ResourceTypes:  []string{TypeImages, TypeContainers, TypeBuildCache},
+		MinAgeHours:    48,
+		Labels:         []string{"env=dev"},
+		AllImages:      true,
+		CronExpression: "0 3 * * *",
+	}, 7)
+	require.NoError(t, err)
+	makeDue(t, svc, policy.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	require.Len(t, maint.requests, 3)
+	assert.Equal(t, maintenance.PruneRequest{Type: TypeImages, Force: true, All: true, Filters: `{"label":["env=dev"],"until":["48h"]}`}, maint.requests[0])
+	assert.Equal(t, `{"label":["env=dev"],"until":["48h"]}`, maint.requests[1].Filters)
+	assert.False(t, maint.requests[1].All)
+	assert.Equal(t, `{"until":["48h"]}`, maint.requests[2].Filters, "builder prune has no label filter")
+	for _, p := range maint.callers {
+		assert.True(t, p.IsSystem())
+	}
+
+	require.Len(t, audit.events, 3)
+	for _, event := range audit.events {
+		assert.Equal(t, security.EventDockerPruneExecuted, event.EventType)
+		assert.True(t, event.Success)
+		assert.Equal(t, policy.ID, event.Metadata["policy_id"])
+	}
+
+	reloaded, err := svc.GetPolicy(1, policy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusSuccess, reloaded.LastRunStatus)
+	assert.Equal(t, int64(3000), reloaded.LastSpaceReclaimed)
+	require.NotNil(t, reloaded.NextRunAt)
+	assert.True(t, reloaded.NextRunAt.After(time.Now()))
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+	assert.Len(t, maint.requests, 3, "the policy does not run again until its next slot")
+}
+
+func TestRunDueSchedules_NeverPrunesVolumesWithMinAge(t *testing.T) {
+	svc, maint, _, _ := newTestService(t)
+
+	policy, err := svc.CreatePolicy(1, PolicyRequest{
+		Name:           "nightly",
+		ResourceTypes:  []string{TypeImages},
+		MinAgeHours:    48,
+		CronExpression: "0 3 * * *",
+	}, 7)
+	require.NoError(t, err)
+	require.NoError(t, svc.db.Model(&PrunePolicy{}).Where("id = ?", policy.ID).
+		Update("resource_types", encodeList([]string{TypeImages, TypeVolumes})).Error)
+	makeDue(t, svc, policy.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	require.Len(t, maint.requests, 1, "a saved policy combining volumes and an age keeps every volume")
+	assert.Equal(t, TypeImages, maint.requests[0].Type)
+
+	reloaded, err := svc.GetPolicy(1, policy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusPartial, reloaded.LastRunStatus)
+	assert.Contains(t, reloaded.LastRunMessage, ErrVolumesMinAge.Error())
+}
+
+func TestRunDueSchedules_RecordsPartialFailure(t *testing.T) {
+	svc, maint, audit, _ := newTestService(t)
+	maint.fail[TypeNetworks] = errors.New("agent returned status 500")
+
+	policy, err := svc.CreatePolicy(1, PolicyRequest{
+		Name:           "weekly",
+		ResourceTypes:  []string{TypeContainers, TypeNetworks},
+		CronExpression: "@weekly",
+	}, 7)
+	require.NoError(t, err)
+	makeDue(t, svc, policy.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	assert.Empty(t, maint.requests[0].Filters)
+	require.Len(t, audit.events, 2)
+	assert.False(t, audit.events[1].Success)
+	assert.Contains(t, audit.events[1].FailureReason, "status 500")
+
+	reloaded, err := svc.GetPolicy(1, policy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusPartial, reloaded.LastRunStatus)
+	assert.Contains(t, reloaded.LastRunMessage, "networks: agent returned status 500")
+}
+
+func TestRunDueSchedules_SkipsInactiveServer(t *testing.T) {
+	svc, maint, _, servers := newTestService(t)
+	servers.inactive = true
+
+	policy, err := svc.CreatePolicy(1, PolicyRequest{Name: "n", ResourceTypes: []string{TypeImages}, CronExpression: "@daily"}, 7)
+	require.NoError(t, err)
+	makeDue(t, svc, policy.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	assert.Empty(t, maint.requests)
+	reloaded, err := svc.GetPolicy(1, policy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusSkipped, reloaded.LastRunStatus)
+}
+
+func TestRunDueSchedules_IgnoresDisabledPolicies(t *testing.T) {
+	svc, maint, _, _ := newTestService(t)
+	disabled := false
+
+	policy, err := svc.CreatePolicy(1, PolicyRequest{Name: "n", ResourceTypes: []string{TypeImages}, CronExpression: "@daily", Enabled: &disabled}, 7)
+	require.NoError(t, err)
+	makeDue(t, svc, policy.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+	assert.Empty(t, maint.requests)
+}
+
+func TestPreviewRequest(t *testing.T) {
+	svc, maint, _, _ := newTestService(t)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	old := now.Add(-72 * time.Hour)
+	recent := now.Add(-time.Hour)
+
+	maint.info = &maintenance.MaintenanceInfo{
+		ImageSummary: maintenance.ImageSummary{Images: []maintenance.ImageInfo{
+			{ID: "sha256:dangling", Repository: "<none>", Size: 100, Created: old, Dangling: true, Unused: true},
+			{ID: "sha256:unused", Repository: "nginx", Tag: "1.25", Size: 200, Created: old, Unused: true},
+			{ID: "sha256:recent", Repository: "<none>", Size: 400, Created: recent, Dangling: true, Unused: true},
+			{ID: "sha256:used", Repository: "redis", Tag: "7", Size: 800, Created: old},
+		}},
+		ContainerSummary: maintenance.ContainerSummary{Containers: []maintenance.ContainerInfo{
+			{ID: "c1", Name: "old-job", State: "exited", Size: 10, Created: old, Labels: map[string]string{"env": "dev"}},
+			{ID: "c2", Name: "prod-job", State: "exited", Size: 20, Created: old, Labels: map[string]string{"env": "prod"}},
+			{ID: "c3", Name: "web", State: "running", Size: 40, Created: old, Labels: map[string]string{"env": "dev"}},
+		}},
+		VolumeSummary: maintenance.VolumeSummary{Volumes: []maintenance.VolumeInfo{
+			{Name: "scratch", Size: 1000, Created: recent, Unused: true, Labels: map[string]string{"env": "dev"}},
+			{Name: "data", Size: 2000, Created: old, Labels: map[string]string{"env": "dev"}},
+		}},
+		NetworkSummary: maintenance.NetworkSummary{Networks: []maintenance.NetworkInfo{
+			{ID: "n1", Name: "bridge", Created: old, Unused: true},
+			{ID: "n2", Name: "old_default", Created: old, Unused: true, Labels: map[string]string{"env": "dev"}},
+		}},
+	}
+
+	t.Run("dangling images older than the cutoff", func(t *testing.T) {
+		preview, err := svc.PreviewRequest(context.Background(), authz.SystemPrincipal, 1, PreviewRequest{
+			ResourceTypes: []string{TypeImages},
+			MinAgeHours:   24,
+		})
+		require.NoError(t, err)
+		require.Len(t, preview.Groups, 1)
+		require.Len(t, preview.Groups[0].Items, 1)
+		assert.Equal(t, "sha256:dangling", preview.Groups[0].Items[0].ID)
+		assert.Equal(t, int64(100), preview.ReclaimableSize)
+		require.NotNil(t, preview.Cutoff)
+		assert.True(t, preview.Cutoff.Equal(now.Add(-24*time.Hour)))
+	})
+
+	t.Run("all unused images", func(t *testing.T) {
+		preview, err := svc.PreviewRequest(context.Background(), authz.SystemPrincipal, 1, PreviewRequest{
+			ResourceTypes: []string{TypeImages},
+			AllImages:     true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, preview.TotalCount)
+		assert.Equal(t, int64(700), preview.ReclaimableSize)
+	})
+
+	t.Run("labels and age across types", func(t *testing.T) {
+		preview, err := svc.PreviewRequest(context.Background(), authz.SystemPrincipal, 1, PreviewRequest{
+			ResourceTypes: []string{TypeContainers, TypeNetworks},
+			MinAgeHours:   24,
+			Labels:        []string{"env=dev"},
+		})
+		require.NoError(t, err)
+		require.Len(t, preview.Groups, 2)
+
+		containers, networks := preview.Groups[0], preview.Groups[1]
+		require.Len(t, containers.Items, 1)
+		assert.Equal(t, "old-job", containers.Items[0].Name)
+		require.Len(t, networks.Items, 1, "predefined networks are never pruned")
+		assert.Equal(t, "old_default", networks.Items[0].Name)
+
+		assert.Equal(t, 2, preview.TotalCount)
+		assert.Equal(t, int64(10), preview.ReclaimableSize)
+	})
+
+	t.Run("unused volumes by label", func(t *testing.T) {
+		preview, err := svc.PreviewRequest(context.Background(), authz.SystemPrincipal, 1, PreviewRequest{
+			ResourceTypes: []string{TypeVolumes},
+			Labels:        []string{"env=dev"},
+		})
+		require.NoError(t, err)
+		require.Len(t, preview.Groups, 1)
+		require.Len(t, preview.Groups[0].Items, 1)
+		assert.Equal(t, "scratch", preview.Groups[0].Items[0].Name)
+		assert.Empty(t, preview.Groups[0].Notes)
+	})
+}
+
+func TestPreviewPolicy_UsesSavedSettings(t *testing.T) {
+	svc, maint, _, _ := newTestService(t)
+	maint.info = &maintenance.MaintenanceInfo{
+		ContainerSummary: maintenance.ContainerSummary{Containers: []maintenance.ContainerInfo{
+			{ID: "c1", Name: "job", State: "dead", Size: 5, Created: time.Now().Add(-time.Hour)},
+		}},
+	}
+
+	policy, err := svc.CreatePolicy(1, PolicyRequest{Name: "n", ResourceTypes: []string{TypeContainers}, CronExpression: "@daily"}, 7)
+	require.NoError(t, err)
+
+	preview, err := svc.PreviewPolicy(context.Background(), authz.SystemPrincipal, policy)
+	require.NoError(t, err)
+	assert.Nil(t, preview.Cutoff)
+	assert.Equal(t, 1, preview.TotalCount)
+	assert.Empty(t, maint.requests, "previews never prune")
+}
@@ -49,7 +49,14 @@ func NewAPIHandler(db *gorm.DB, rbacSvc *Service, totpSvc *totp.Service, authSvc
 
 func (h *APIHandler) ListUsers(c echo.Context) error {
 	var users []usermodel.User
-	if err := h.db.Preload("Roles").Find(&users).Error; err != nil {
+	if err := h.db.Find(&users).Error; err != nil {
+		return response.Internal(c, "Failed to fetch users")
+	}
+	ptrs := make([]*usermodel.User, len(users))
+	for i := range users {
+		ptrs[i] = &users[i]
+	}
+	if err := usermodel.LoadActiveRoles(h.db, ptrs...); err != nil {
 		return response.Internal(c, "Failed to fetch users")
 	}
 
@@ -97,7 +104,7 @@ func (h *APIHandler) CreateUser(c echo.Context) error {
 		}
 	}
 
-	if err := h.db.Preload("Roles").First(&user, user.ID).Error; err != nil {
+	if err := usermodel.FirstWithActiveRoles(h.db, &user, user.ID); err != nil {
 		return response.Internal(c, "failed to load created user")
 	}
 
@@ -181,7 +188,7 @@ func (h *APIHandler) GetUserRoles(c echo.Context) error {
 	}
 
 	var user usermodel.User
-	if err := h.db.Preload("Roles").First(&user, userID).Error; err != nil {
+	if err := usermodel.FirstWithActiveRoles(h.db, &user, userID); err != nil {
 		return response.NotFound(c, "User not found")
 	}
 
@@ -195,15 +202,31 @@ func (h *APIHandler) GetUserRoles(c echo.Context) error {
 	roleInfos := make([]usermodel.RoleInfo, len(allRoles))
 	for i, role := range allRoles {
 		roleInfos[i] = usermodel.RoleInfo{
-			ID:          role.ID,
-			Name:        role.Name,
-			Description: role.Description,
+			ID:               role.ID,
+			Name:             role.Name,
+			Description:      role.Description,
+			RequireTwoFactor: role.RequireTwoFactor,
+		}
+	}
+
+	assignments, err := h.rbacSvc.ListRoleAssignments(user.ID)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch role assignments")
+	}
+	assignmentInfos := make([]RoleAssignmentInfo, len(assignments))
+	for i, a := range assignments {
+		assignmentInfos[i] = RoleAssignmentInfo{
+			RoleID:         a.RoleID,
+			RoleName:       a.Role.Name,
+			ExpiresAt:      usermodel.FormatTimePtr(a.ExpiresAt),
+			RevokeSessions: a.RevokeSessions,
 		}
 	}
 
 	return response.OK(c, GetUserRolesData{
-		User:     userInfo,
-		AllRoles: roleInfos,
+		User:        userInfo,
+		AllRoles:    roleInfos,
+		Assignments: assignmentInfos,
 	})
 }
 
@@ -219,7 +242,10 @@ func (h *APIHandler) AssignRole(c echo.Context) error {
 	var role usermodel.Role
 	h.db.First(&role, req.RoleID)
 
-	if err := h.rbacSvc.AssignRole(req.UserID, req.RoleID); err != nil {
+	if err := h.rbacSvc.AssignRoleUntil(req.UserID, req.RoleID, req.ExpiresAt, req.RevokeSessions); err != nil {
+		if errors.Is(err, ErrRoleHeldPermanently) {
+			return response.Conflict(c, "Role already held permanently; revoke it first")
+		}
 		return response.Internal(c, "Failed to assign role")
 	}
 
@@ -238,8 +264,10 @@ func (h *APIHandler) AssignRole(c echo.Context) error {
 		targetUser.Email,
 		c.RealIP(),
 		map[string]any{
-			"role_id":   req.RoleID,
-			"role_name": role.Name,
+			"role_id":         req.RoleID,
+			"role_name":       role.Name,
+			"expires_at":      usermodel.FormatTimePtr(req.ExpiresAt),
+			"revoke_sessions": req.RevokeSessions,
 		},
 	)
 
@@ -308,7 +336,7 @@ func (h *APIHandler) CreateRole(c echo.Context) error {
 		return err
 	}
 
-	role, err := h.rbacSvc.CreateRole(req.Name, req.Description)
+	role, err := h.rbacSvc.CreateRole(req.Name, req.Description, req.RequireTwoFactor)
 	if err != nil {
 		return response.Internal(c, "Failed to create role")
 	}
@@ -329,7 +357,8 @@ func (h *APIHandler) CreateRole(c echo.Context) error {
 		role.Name,
 		c.RealIP(),
 		map[string]any{
-			"description": role.Description,
+			"description":        role.Description,
+			"require_two_factor": role.RequireTwoFactor,
 		},
 	)
 
@@ -375,6 +404,72 @@ func (h *APIHandler) UpdateRole(c echo.Context) error {
 	return response.OK(c, usermodel.ToRoleWithPermissions(*role))
 }
 
+func (h *APIHandler) SetRoleTwoFactor(c echo.Context) error {
+	roleID, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var req SetRoleTwoFactorRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	role, err := h.rbacSvc.SetRoleTwoFactor(roleID, req.Required)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Role not found")
+		}
+		return response.Internal(c, "Failed to update role")
+	}
+
+	actorUserID, _ := session.GetCurrentUserID(c)
+	actorUser, _ := session.LoadCurrentUser(c, h.db)
+	actorUsername := ""
+	if actorUser != nil {
+		actorUsername = actorUser.Username
+	}
+
+	h.auditService.LogRBACEvent(
+		security.EventRoleUpdated,
+		actorUserID,
+		actorUsername,
+		security.TargetTypeRole,
+		role.ID,
+		role.Name,
+		c.RealIP(),
+		map[string]any{
+			"require_two_factor": role.RequireTwoFactor,
+		},
+	)
+
+	return response.OK(c, usermodel.ToRoleWithPermissions(*role))
+}
+
+func (h *APIHandler) ListTwoFactorNonCompliantUsers(c echo.Context) error {
+	users, err := h.authSvc.TwoFactorNonCompliantUsers()
+	if err != nil {
+		return response.Internal(c, "Failed to fetch two-factor compliance")
+	}
+
+	result := make([]TwoFactorNonCompliantUser, len(users))
+	for i, u := range users {
+		roles := make([]string, len(u.Roles))
+		for j, role := range u.Roles {
+			roles[j] = role.Name
+		}
+		result[i] = TwoFactorNonCompliantUser{
+			ID:          u.ID,
+			Username:    u.Username,
+			Email:       u.Email,
+			LastLoginAt: usermodel.FormatTimePtr(u.LastLoginAt),
+			Roles:       roles,
+		}
+	}
+
+	return response.OK(c, ListTwoFactorNonCompliantData{Users: result})
+}
+
 func (h *APIHandler) DeleteRole(c echo.Context) error {
 	roleID, err := echoparams.ParseUintParam(c, "id")
 	if err != nil {
@@ -2,6 +2,7 @@ package rbac
 
 import (
 	"errors"
+	"time"
 
 	"berth/internal/domain/user"
 )
@@ -12,6 +13,8 @@ var (
 	ErrRoleNameRequired              = errors.New("name is required")
 	ErrStackPermissionFieldsRequired = errors.New("server_id and permission_id are required")
 	ErrRoleAssignmentFieldsRequired  = errors.New("user_id and role_id are required")
+	ErrRoleExpiryInPast              = errors.New("expires_at must be in the future")
+	ErrRevokeSessionsWithoutExpiry   = errors.New("revoke_sessions requires expires_at")
 )
 
 type CreateUserRequest struct {
@@ -31,15 +34,26 @@ func (r *CreateUserRequest) Validate() error {
 	return nil
 }
 
+// AssignRoleRequest assigns a role permanently, or until ExpiresAt for
+// temporary elevation. RevokeSessions signs the user out everywhere when a
+// temporary role expires.
 type AssignRoleRequest struct {
-	UserID uint `json:"user_id"`
-	RoleID uint `json:"role_id"`
+	UserID         uint       `json:"user_id"`
+	RoleID         uint       `json:"role_id"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	RevokeSessions bool       `json:"revoke_sessions,omitempty"`
 }
 
 func (r *AssignRoleRequest) Validate() error {
 	if r.UserID == 0 || r.RoleID == 0 {
 		return ErrRoleAssignmentFieldsRequired
 	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return ErrRoleExpiryInPast
+	}
+	if r.RevokeSessions && r.ExpiresAt == nil {
+		return ErrRevokeSessionsWithoutExpiry
+	}
 	return nil
 }
 
@@ -56,8 +70,9 @@ func (r *RevokeRoleRequest) Validate() error {
 }
 
 type CreateRoleRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	RequireTwoFactor bool   `json:"require_two_factor"`
 }
 
 func (r *CreateRoleRequest) Validate() error {
@@ -79,6 +94,14 @@ func (r *UpdateRoleRequest) Validate() error {
 	return nil
 }
 
+type SetRoleTwoFactorRequest struct {
+	Required bool `json:"required"`
+}
+
+func (r *SetRoleTwoFactorRequest) Validate() error {
+	return nil
+}
+
 type CreateStackPermissionRequest struct {
 	ServerID     uint   `json:"server_id"`
 	PermissionID uint   `json:"permission_id"`
@@ -117,9 +140,18 @@ type ListUsersData struct {
 	Users []user.UserInfo `json:"users"`
 }
 
+// RoleAssignmentInfo is one of a user's roles with its expiry, if any.
+type RoleAssignmentInfo struct {
+	RoleID         uint    `json:"role_id"`
+	RoleName       string  `json:"role_name"`
+	ExpiresAt      *string `json:"expires_at"`
+	RevokeSessions bool    `json:"revoke_sessions"`
+}
+
 type GetUserRolesData struct {
-	User     user.UserInfo   `json:"user"`
-	AllRoles []user.RoleInfo `json:"all_roles"`
+	User        user.UserInfo        `json:"user"`
+	AllRoles    []user.RoleInfo      `json:"all_roles"`
+	Assignments []RoleAssignmentInfo `json:"assignments"`
 }
 
 type ListRolesData struct {
@@ -133,6 +165,18 @@ type ListRoleStackPermissionsData struct {
 	PermissionRules []StackPermissionRule `json:"permissionRules"`
 }
 
+type TwoFactorNonCompliantUser struct {
+	ID          uint     `json:"id"`
+	Username    string   `json:"username"`
+	Email       string   `json:"email"`
+	LastLoginAt *string  `json:"last_login_at,omitempty"`
+	Roles       []string `json:"roles"`
+}
+
+type ListTwoFactorNonCompliantData struct {
+	Users []TwoFactorNonCompliantUser `json:"users"`
+}
+
 type ListPermissionsData struct {
 	Permissions []user.PermissionInfo `json:"permissions"`
 }
@@ -3,6 +3,7 @@ package rbac
 import (
 	"errors"
 	"testing"
+	"time"
 )
 
 func TestCreateUserRequest_Validate(t *testing.T) {
@@ -40,6 +41,8 @@ func TestCreateUserRequest_Validate(t *testing.T) {
 }
 
 func TestAssignRoleRequest_Validate(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Minute)
 	tests := []struct {
 		name    string
 		req     AssignRoleRequest
@@ -49,6 +52,10 @@ func TestAssignRoleRequest_Validate(t *testing.T) {
 		{"user zero", AssignRoleRequest{UserID: 0, RoleID: 2}, ErrRoleAssignmentFieldsRequired},
 		{"role zero", AssignRoleRequest{UserID: 1, RoleID: 0}, ErrRoleAssignmentFieldsRequired},
 		{"both present", AssignRoleRequest{UserID: 1, RoleID: 2}, nil},
+		{"future expiry", AssignRoleRequest{UserID: 1, RoleID: 2, ExpiresAt: &future}, nil},
+		{"future expiry revoking sessions", AssignRoleRequest{UserID: 1, RoleID: 2, ExpiresAt: &future, RevokeSessions: true}, nil},
+		{"past expiry", AssignRoleRequest{UserID: 1, RoleID: 2, ExpiresAt: &past}, ErrRoleExpiryInPast},
+		{"revoke sessions without expiry", AssignRoleRequest{UserID: 1, RoleID: 2, RevokeSessions: true}, ErrRevokeSessionsWithoutExpiry},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,91 @@
+package rbac
+
+import (
+	"context"
+	"time"
+
+	"berth/internal/domain/security"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/periodic"
+
+	"go.uber.org/zap"
+)
+
+// roleExpiryActor is recorded as the actor of automatic role revocations.
+const roleExpiryActor = "role-expiry"
+
+type roleExpiryAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+// roleExpiry removes temporary role assignments once they expire, records a
+// user.role.expired event for each and revokes the user's sessions when the
+// assignment asked for it.
+type roleExpiry struct {
+	svc      *Service
+	sessions UserSessionRevoker
+	audit    roleExpiryAuditLogger
+	logger   *zap.Logger
+}
+
+// NewExpiryWorker returns a runner that expires role assignments every
+// interval; an interval of zero disables it.
+func NewExpiryWorker(svc *Service, sessions UserSessionRevoker, audit roleExpiryAuditLogger, interval time.Duration, logger *zap.Logger) *periodic.Runner {
+	w := &roleExpiry{svc: svc, sessions: sessions, audit: audit, logger: logger}
+	return periodic.NewRunner("role expiry worker", interval, w.run, logger)
+}
+
+func (w *roleExpiry) run(context.Context) error {
+	expired, err := w.svc.ExpireRoleAssignments(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, ur := range expired {
+		w.expire(ur)
+	}
+	return nil
+}
+
+func (w *roleExpiry) expire(ur usermodel.UserRole) {
+	var target usermodel.User
+	_ = w.svc.db.First(&target, ur.UserID).Error
+
+	sessionsRevoked := false
+	if ur.RevokeSessions && w.sessions != nil {
+		if err := w.sessions.RevokeAllUserSessions(ur.UserID); err != nil {
+			w.logger.Error("failed to revoke sessions after role expiry",
+				zap.Error(err),
+				zap.Uint("user_id", ur.UserID),
+			)
+		} else {
+			sessionsRevoked = true
+		}
+	}
+
+	w.logger.Info("role assignment expired",
+		zap.Uint("user_id", ur.UserID),
+		zap.Uint("role_id", ur.RoleID),
+		zap.String("role_name", ur.Role.Name),
+		zap.Bool("sessions_revoked", sessionsRevoked),
+	)
+
+	if w.audit == nil {
+		return
+	}
+	userID := ur.UserID
+	_ = w.audit.Log(security.LogEvent{
+		EventType:     security.EventUserRoleExpired,
+		Success:       true,
+		ActorUsername: roleExpiryActor,
+		TargetUserID:  &userID,
+		TargetType:    security.TargetTypeUser,
+		TargetID:      &userID,
+		TargetName:    target.Email,
+		Metadata: map[string]any{
+			"role_id":          ur.RoleID,
+			"role_name":        ur.Role.Name,
+			"expires_at":       ur.ExpiresAt.Format(time.RFC3339),
+			"sessions_revoked": sessionsRevoked,
+		},
+	})
+}
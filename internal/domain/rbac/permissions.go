@@ -20,5 +20,7 @@ func AdminStackPermissions() []string {
 		permnames.BackupsRead,
 		permnames.BackupsManage,
 		permnames.BackupsRestore,
+		permnames.StacksMaintenanceOverride,
+		permnames.StacksAccessApprove,
 	}
 }
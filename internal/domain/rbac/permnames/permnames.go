@@ -13,6 +13,9 @@ const (
 	BackupsRead            = "backups.read"
 	BackupsManage          = "backups.manage"
 	BackupsRestore         = "backups.restore"
+
+	StacksMaintenanceOverride = "stacks.maintenance.override"
+	StacksAccessApprove       = "stacks.access.approve"
 )
 
 const (
@@ -25,8 +28,11 @@ const (
 	AdminServersWrite    = "admin.servers.write"
 	AdminLogsRead        = "admin.logs.read"
 	AdminAuditRead       = "admin.audit.read"
+	AdminSystemRead      = "admin.system.read"
 	AdminSystemExport    = "admin.system.export"
 	AdminSystemImport    = "admin.system.import"
+	AdminWebhooksRead    = "admin.webhooks.read"
+	AdminWebhooksWrite   = "admin.webhooks.write"
 )
 
 const (
@@ -8,6 +8,7 @@ import (
 func (h *APIHandler) RegisterAdminAPIRoutes(reg *authz.Registrar) {
 	reg.GET("/users", h.ListUsers, authz.Admin(permnames.AdminUsersRead))
 	reg.POST("/users", h.CreateUser, authz.Admin(permnames.AdminUsersWrite))
+	reg.GET("/users/two-factor/non-compliant", h.ListTwoFactorNonCompliantUsers, authz.Admin(permnames.AdminUsersRead))
 	reg.GET("/users/:id/roles", h.GetUserRoles, authz.Admin(permnames.AdminUsersRead))
 	reg.DELETE("/users/:id", h.DeleteUser, authz.Admin(permnames.AdminUsersWrite))
 	reg.POST("/users/assign-role", h.AssignRole, authz.Admin(permnames.AdminUsersWrite))
@@ -16,6 +17,7 @@ func (h *APIHandler) RegisterAdminAPIRoutes(reg *authz.Registrar) {
 	reg.GET("/roles", h.ListRoles, authz.Admin(permnames.AdminRolesRead))
 	reg.POST("/roles", h.CreateRole, authz.Admin(permnames.AdminRolesWrite))
 	reg.PUT("/roles/:id", h.UpdateRole, authz.Admin(permnames.AdminRolesWrite))
+	reg.PUT("/roles/:id/two-factor", h.SetRoleTwoFactor, authz.Admin(permnames.AdminRolesWrite))
 	reg.DELETE("/roles/:id", h.DeleteRole, authz.Admin(permnames.AdminRolesWrite))
 	reg.GET("/roles/:roleId/stack-permissions", h.ListRoleServerStackPermissions, authz.Admin(permnames.AdminRolesRead))
 	reg.POST("/roles/:roleId/stack-permissions", h.CreateRoleStackPermission, authz.Admin(permnames.AdminRolesWrite))
@@ -3,11 +3,17 @@ package rbac
 import (
 	"berth/internal/domain/apikey"
 	"berth/internal/domain/auth"
+	"berth/internal/domain/auth/ldap"
+	"berth/internal/domain/auth/lockout"
+	"berth/internal/domain/auth/oidc"
+	"berth/internal/domain/auth/passkey"
 	"berth/internal/domain/auth/tokens"
 	"berth/internal/domain/auth/totp"
 	"berth/internal/domain/rbac/permnames"
 	usermodel "berth/internal/domain/user"
 	"errors"
+	"slices"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -15,6 +21,8 @@ import (
 
 var ErrLastAdmin = errors.New("operation would leave the system without an administrator")
 
+var ErrRoleHeldPermanently = errors.New("role already held permanently; revoke it before assigning it temporarily")
+
 type Service struct {
 	db     *gorm.DB
 	logger *zap.Logger
@@ -34,7 +42,7 @@ func (s *Service) HasRole(userID uint, roleName string) (bool, error) {
 	)
 
 	var user usermodel.User
-	err := s.db.Preload("Roles", "name = ?", roleName).First(&user, userID).Error
+	err := usermodel.FirstWithActiveRoles(s.db, &user, userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			s.logger.Debug("user not found for role check",
@@ -51,7 +59,7 @@ func (s *Service) HasRole(userID uint, roleName string) (bool, error) {
 		return false, err
 	}
 
-	hasRole := len(user.Roles) > 0
+	hasRole := slices.ContainsFunc(user.Roles, func(r usermodel.Role) bool { return r.Name == roleName })
 	s.logger.Debug("user role check completed",
 		zap.Uint("user_id", userID),
 		zap.String("role_name", roleName),
@@ -62,13 +70,24 @@ func (s *Service) HasRole(userID uint, roleName string) (bool, error) {
 }
 
 func (s *Service) AssignRole(userID uint, roleID uint) error {
+	return s.AssignRoleUntil(userID, roleID, nil, false)
+}
+
+// AssignRoleUntil assigns a role that expires at expiresAt, or permanently
+// when expiresAt is nil. revokeSessions asks the role expiry worker to sign
+// the user out everywhere when the role expires. Assigning a role the user
+// already holds temporarily replaces its expiry. A permanent assignment is
+// never shortened: assigning it again with an expiry returns
+// ErrRoleHeldPermanently.
+func (s *Service) AssignRoleUntil(userID uint, roleID uint, expiresAt *time.Time, revokeSessions bool) error {
 	s.logger.Info("assigning role to user",
 		zap.Uint("user_id", userID),
 		zap.Uint("role_id", roleID),
+		zap.Timep("expires_at", expiresAt),
 	)
 
 	var user usermodel.User
-	if err := s.db.Preload("Roles").First(&user, userID).Error; err != nil {
+	if err := s.db.First(&user, userID).Error; err != nil {
 		s.logger.Error("failed to find user for role assignment",
 			zap.Error(err),
 			zap.Uint("user_id", userID),
@@ -77,16 +96,6 @@ func (s *Service) AssignRole(userID uint, roleID uint) error {
 		return err
 	}
 
-	for _, role := range user.Roles {
-		if role.ID == roleID {
-			s.logger.Debug("user already has role",
-				zap.Uint("user_id", userID),
-				zap.Uint("role_id", roleID),
-			)
-			return nil
-		}
-	}
-
 	var role usermodel.Role
 	if err := s.db.First(&role, roleID).Error; err != nil {
 		s.logger.Error("failed to find role for assignment",
@@ -96,7 +105,31 @@ func (s *Service) AssignRole(userID uint, roleID uint) error {
 		return err
 	}
 
-	if err := s.db.Model(&user).Association("Roles").Append(&role); err != nil {
+	var existing usermodel.UserRole
+	err := s.db.Where("user_id = ? AND role_id = ?", userID, roleID).First(&existing).Error
+	switch {
+	case err == nil && existing.ExpiresAt == nil:
+		s.logger.Debug("user already has role",
+			zap.Uint("user_id", userID),
+			zap.Uint("role_id", roleID),
+		)
+		if expiresAt != nil {
+			return ErrRoleHeldPermanently
+		}
+		return nil
+	case err == nil:
+		err = s.db.Model(&usermodel.UserRole{}).
+			Where("user_id = ? AND role_id = ?", userID, roleID).
+			Updates(map[string]any{"expires_at": expiresAt, "revoke_sessions": revokeSessions}).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		err = s.db.Create(&usermodel.UserRole{
+			UserID:         userID,
+			RoleID:         roleID,
+			ExpiresAt:      expiresAt,
+			RevokeSessions: revokeSessions,
+		}).Error
+	}
+	if err != nil {
 		s.logger.Error("failed to assign role to user",
 			zap.Error(err),
 			zap.Uint("user_id", userID),
@@ -115,12 +148,54 @@ func (s *Service) AssignRole(userID uint, roleID uint) error {
 	return nil
 }
 
+// ListRoleAssignments returns the user's unexpired role assignments.
+func (s *Service) ListRoleAssignments(userID uint) ([]usermodel.UserRole, error) {
+	var assignments []usermodel.UserRole
+	err := s.db.Preload("Role").
+		Where("user_roles.user_id = ?", userID).
+		Scopes(usermodel.ActiveRoleAssignments).
+		Order("role_id").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+// ExpireRoleAssignments removes every assignment that expired at or before
+// now and returns the ones it removed.
+func (s *Service) ExpireRoleAssignments(now time.Time) ([]usermodel.UserRole, error) {
+	var due []usermodel.UserRole
+	if err := s.db.Preload("Role").Where("expires_at <= ?", now).Find(&due).Error; err != nil {
+		return nil, err
+	}
+
+	expired := make([]usermodel.UserRole, 0, len(due))
+	for _, ur := range due {
+		result := s.db.Where("user_id = ? AND role_id = ? AND expires_at <= ?", ur.UserID, ur.RoleID, now).
+			Delete(&usermodel.UserRole{})
+		if result.Error != nil {
+			s.logger.Error("failed to remove expired role assignment",
+				zap.Error(result.Error),
+				zap.Uint("user_id", ur.UserID),
+				zap.Uint("role_id", ur.RoleID),
+			)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		expired = append(expired, ur)
+	}
+	return expired, nil
+}
+
+// countAdminUsersExcluding counts users holding an admin role permanently.
+// Temporary admin grants are left out because they lapse on their own.
 func countAdminUsersExcluding(db *gorm.DB, excludeUserID, excludeRoleID uint) (int64, error) {
 	var count int64
 	err := db.Model(&usermodel.User{}).
 		Joins("JOIN user_roles ON user_roles.user_id = users.id").
 		Joins("JOIN roles ON roles.id = user_roles.role_id").
 		Where("roles.is_admin = ? AND roles.deleted_at IS NULL AND users.deleted_at IS NULL", true).
+		Where("user_roles.expires_at IS NULL").
 		Where("NOT (user_roles.user_id = ? AND (? = 0 OR user_roles.role_id = ?))", excludeUserID, excludeRoleID, excludeRoleID).
 		Distinct("user_roles.user_id").
 		Count(&count).Error
@@ -134,7 +209,7 @@ func (s *Service) DeleteUser(userID uint) (*usermodel.User, error) {
 
 	err := s.db.Transaction(func(tx *gorm.DB) error {
 		var target usermodel.User
-		if err := tx.Preload("Roles").First(&target, userID).Error; err != nil {
+		if err := usermodel.FirstWithActiveRoles(tx, &target, userID); err != nil {
 			return err
 		}
 
@@ -158,9 +233,24 @@ func (s *Service) DeleteUser(userID uint) (*usermodel.User, error) {
 		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&totp.UsedCode{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&totp.RecoveryCode{}).Error; err != nil {
+			return err
+		}
 		if err := tx.Where("user_id = ?", userID).Delete(&tokens.RefreshToken{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&oidc.Identity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&ldap.Identity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&lockout.AccountLockout{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&passkey.Credential{}).Error; err != nil {
+			return err
+		}
 
 		var keys []apikey.APIKey
 		if err := tx.Where("user_id = ?", userID).Find(&keys).Error; err != nil {
@@ -261,7 +351,7 @@ func (s *Service) RevokeRole(userID uint, roleID uint) error {
 
 func (s *Service) GetUserRoles(userID uint) ([]usermodel.Role, error) {
 	var user usermodel.User
-	err := s.db.Preload("Roles").First(&user, userID).Error
+	err := usermodel.FirstWithActiveRoles(s.db, &user, userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return []usermodel.Role{}, nil
@@ -294,7 +384,7 @@ func (s *Service) GetAllRoles() ([]usermodel.Role, error) {
 	return roles, err
 }
 
-func (s *Service) CreateRole(name, description string) (*usermodel.Role, error) {
+func (s *Service) CreateRole(name, description string, requireTwoFactor bool) (*usermodel.Role, error) {
 	s.logger.Info("creating new role",
 		zap.String("name", name),
 		zap.String("description", description),
@@ -315,9 +405,10 @@ func (s *Service) CreateRole(name, description string) (*usermodel.Role, error)
 	}
 
 	role := usermodel.Role{
-		Name:        name,
-		Description: description,
-		IsAdmin:     false,
+		Name:             name,
+		Description:      description,
+		IsAdmin:          false,
+		RequireTwoFactor: requireTwoFactor,
 	}
 
 	if err := s.db.Create(&role).Error; err != nil {
@@ -367,6 +458,27 @@ func (s *Service) UpdateRole(roleID uint, name, description string) (*usermodel.
 	return &role, nil
 }
 
+// SetRoleTwoFactor toggles mandatory second-factor enrollment for a role.
+// Unlike UpdateRole it also applies to admin roles.
+func (s *Service) SetRoleTwoFactor(roleID uint, required bool) (*usermodel.Role, error) {
+	var role usermodel.Role
+	if err := s.db.First(&role, roleID).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(&role).Update("require_two_factor", required).Error; err != nil {
+		return nil, err
+	}
+	role.RequireTwoFactor = required
+
+	s.logger.Info("role two-factor requirement updated",
+		zap.Uint("role_id", role.ID),
+		zap.Bool("require_two_factor", required),
+	)
+
+	return &role, nil
+}
+
 func (s *Service) DeleteRole(roleID uint) error {
 	s.logger.Info("deleting role",
 		zap.Uint("role_id", roleID),
@@ -496,7 +608,7 @@ func (s *Service) DeleteRoleStackPermission(permissionID uint) error {
 
 func (s *Service) GetUserAccessibleStackPatterns(userID uint, serverID uint) ([]string, error) {
 	var user usermodel.User
-	if err := s.db.Preload("Roles").First(&user, userID).Error; err != nil {
+	if err := usermodel.FirstWithActiveRoles(s.db, &user, userID); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return []string{}, nil
 		}
@@ -513,6 +625,7 @@ func (s *Service) GetUserAccessibleStackPatterns(userID uint, serverID uint) ([]
 	err := s.db.Preload("Permission").
 		Joins("JOIN user_roles ON user_roles.role_id = server_role_stack_permissions.role_id").
 		Where("user_roles.user_id = ? AND server_role_stack_permissions.server_id = ?", userID, serverID).
+		Scopes(usermodel.ActiveRoleAssignments).
 		Find(&serverRoleStackPermissions).Error
 
 	if err != nil {
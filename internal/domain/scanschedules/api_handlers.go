@@ -0,0 +1,297 @@
+package scanschedules
+
+import (
+	"errors"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+	"berth/internal/domain/security"
+	"berth/internal/domain/session"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type scheduleAuthorizer interface {
+	HasStackPermission(p authz.Principal, serverID uint, stackname, permission string) (bool, error)
+}
+
+type scheduleAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+type APIHandler struct {
+	service      *Service
+	authzSvc     scheduleAuthorizer
+	auditService scheduleAuditLogger
+}
+
+func NewAPIHandler(service *Service, authzSvc scheduleAuthorizer, auditService scheduleAuditLogger) *APIHandler {
+	return &APIHandler{
+		service:      service,
+		authzSvc:     authzSvc,
+		auditService: auditService,
+	}
+}
+
+// canManagePattern reports whether the principal holds stacks.manage for
+// every stack the pattern could match. Fleet-wide schedules are guarded by
+// their admin routes instead.
+func (h *APIHandler) canManagePattern(p authz.Principal, serverID *uint, pattern string) (bool, error) {
+	if serverID == nil {
+		return true, nil
+	}
+	return h.authzSvc.HasStackPermission(p, *serverID, normalisePattern(pattern), permnames.StacksManage)
+}
+
+func (h *APIHandler) ListSchedules(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+	return h.list(c, &serverID)
+}
+
+func (h *APIHandler) ListFleetSchedules(c echo.Context) error {
+	return h.list(c, nil)
+}
+
+func (h *APIHandler) GetSchedule(c echo.Context) error {
+	return h.get(c, true)
+}
+
+func (h *APIHandler) GetFleetSchedule(c echo.Context) error {
+	return h.get(c, false)
+}
+
+func (h *APIHandler) CreateSchedule(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+	return h.create(c, &serverID)
+}
+
+func (h *APIHandler) CreateFleetSchedule(c echo.Context) error {
+	return h.create(c, nil)
+}
+
+func (h *APIHandler) UpdateSchedule(c echo.Context) error {
+	return h.update(c, true)
+}
+
+func (h *APIHandler) UpdateFleetSchedule(c echo.Context) error {
+	return h.update(c, false)
+}
+
+func (h *APIHandler) DeleteSchedule(c echo.Context) error {
+	return h.delete(c, true)
+}
+
+func (h *APIHandler) DeleteFleetSchedule(c echo.Context) error {
+	return h.delete(c, false)
+}
+
+func (h *APIHandler) list(c echo.Context, serverID *uint) error {
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	schedules, err := h.service.ListSchedules(serverID)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch scan schedules")
+	}
+
+	visible := make([]ScanSchedule, 0, len(schedules))
+	for _, schedule := range schedules {
+		allowed, err := h.canManagePattern(p, serverID, schedule.StackPattern)
+		if err != nil {
+			return response.Internal(c, "Failed to check permissions")
+		}
+		if allowed {
+			visible = append(visible, schedule)
+		}
+	}
+
+	return response.OK(c, ListSchedulesData{
+		Schedules: ToResponseList(visible),
+	})
+}
+
+func (h *APIHandler) get(c echo.Context, serverScoped bool) error {
+	_, _, schedule, err := h.loadSchedule(c, serverScoped)
+	if err != nil || schedule == nil {
+		return err
+	}
+
+	return response.OK(c, GetScheduleData{
+		Schedule: ToResponse(schedule),
+	})
+}
+
+func (h *APIHandler) create(c echo.Context, serverID *uint) error {
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req ScheduleRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	allowed, err := h.canManagePattern(p, serverID, req.StackPattern)
+	if err != nil {
+		return response.Internal(c, "Failed to check permissions")
+	}
+	if !allowed {
+		return response.Forbidden(c, "Insufficient permissions to manage stacks matching this pattern")
+	}
+
+	schedule, err := h.service.CreateSchedule(serverID, req, p.UserID())
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	h.service.Logger().Info("scan schedule created",
+		zap.Uint("user_id", p.UserID()),
+		zap.Uint("schedule_id", schedule.ID),
+	)
+
+	h.audit(c, p, security.EventVulnscanScheduleCreated, schedule, nil)
+
+	return response.Created(c, GetScheduleData{
+		Schedule: ToResponse(schedule),
+	})
+}
+
+func (h *APIHandler) update(c echo.Context, serverScoped bool) error {
+	p, serverID, existing, err := h.loadSchedule(c, serverScoped)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	var req ScheduleRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	allowed, err := h.canManagePattern(p, serverID, req.StackPattern)
+	if err != nil {
+		return response.Internal(c, "Failed to check permissions")
+	}
+	if !allowed {
+		return response.Forbidden(c, "Insufficient permissions to manage stacks matching this pattern")
+	}
+
+	schedule, err := h.service.UpdateSchedule(serverID, existing.ID, req)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	h.audit(c, p, security.EventVulnscanScheduleUpdated, schedule, map[string]any{
+		"previous_stack_pattern":   existing.StackPattern,
+		"previous_cron_expression": existing.CronExpression,
+		"previous_on_image_change": existing.OnImageChange,
+		"previous_after_deploy":    existing.AfterDeploy,
+		"previous_enabled":         existing.Enabled,
+	})
+
+	return response.OK(c, GetScheduleData{
+		Schedule: ToResponse(schedule),
+	})
+}
+
+func (h *APIHandler) delete(c echo.Context, serverScoped bool) error {
+	p, serverID, existing, err := h.loadSchedule(c, serverScoped)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	if err := h.service.DeleteSchedule(serverID, existing.ID); err != nil {
+		return response.Internal(c, "Failed to delete scan schedule")
+	}
+
+	h.audit(c, p, security.EventVulnscanScheduleDeleted, existing, nil)
+
+	return response.OK(c, DeleteScheduleMessageData{
+		Message: "Scan schedule deleted successfully",
+	})
+}
+
+// loadSchedule resolves the schedule named in the path and checks the caller
+// may manage its stack pattern. A nil schedule with a nil error means a
+// response has already been written.
+func (h *APIHandler) loadSchedule(c echo.Context, serverScoped bool) (authz.Principal, *uint, *ScanSchedule, error) {
+	var serverID *uint
+	if serverScoped {
+		id, err := echoparams.ParseUintParam(c, "serverid")
+		if err != nil {
+			return authz.Principal{}, nil, nil, err
+		}
+		serverID = &id
+	}
+
+	scheduleID, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return authz.Principal{}, nil, nil, err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return authz.Principal{}, nil, nil, err
+	}
+
+	schedule, err := h.service.GetSchedule(serverID, scheduleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return p, serverID, nil, response.NotFound(c, "Scan schedule not found")
+		}
+		return p, serverID, nil, response.Internal(c, "Failed to fetch scan schedule")
+	}
+
+	allowed, err := h.canManagePattern(p, serverID, schedule.StackPattern)
+	if err != nil {
+		return p, serverID, nil, response.Internal(c, "Failed to check permissions")
+	}
+	if !allowed {
+		return p, serverID, nil, response.NotFound(c, "Scan schedule not found")
+	}
+
+	return p, serverID, schedule, nil
+}
+
+func (h *APIHandler) audit(c echo.Context, p authz.Principal, eventType string, schedule *ScanSchedule, extra map[string]any) {
+	metadata := map[string]any{
+		"stack_pattern":   schedule.StackPattern,
+		"cron_expression": schedule.CronExpression,
+		"on_image_change": schedule.OnImageChange,
+		"after_deploy":    schedule.AfterDeploy,
+		"enabled":         schedule.Enabled,
+		"fleet_wide":      schedule.ServerID == nil,
+	}
+	for k, v := range extra {
+		metadata[k] = v
+	}
+
+	actorID := p.UserID()
+	scheduleID := schedule.ID
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      eventType,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetType:     security.TargetTypeScanSchedule,
+		TargetID:       &scheduleID,
+		TargetName:     schedule.StackPattern,
+		Success:        true,
+		Metadata:       metadata,
+		ServerID:       schedule.ServerID,
+	})
+}
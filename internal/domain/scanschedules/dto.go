@@ -0,0 +1,94 @@
+package scanschedules
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"berth/internal/pkg/cronspec"
+)
+
+var (
+	ErrNoTrigger           = errors.New("at least one of cron_expression, on_image_change or after_deploy is required")
+	ErrStackPatternInvalid = errors.New("stack_pattern must not contain '/' or whitespace")
+)
+
+type ScheduleRequest struct {
+	StackPattern   string `json:"stack_pattern,omitempty"`
+	CronExpression string `json:"cron_expression,omitempty"`
+	OnImageChange  bool   `json:"on_image_change"`
+	AfterDeploy    bool   `json:"after_deploy"`
+	Enabled        *bool  `json:"enabled,omitempty"`
+}
+
+func (r *ScheduleRequest) Validate() error {
+	cron := strings.TrimSpace(r.CronExpression)
+	if cron == "" && !r.OnImageChange && !r.AfterDeploy {
+		return ErrNoTrigger
+	}
+	if cron != "" {
+		if err := cronspec.Validate(cron); err != nil {
+			return err
+		}
+	}
+	if strings.ContainsAny(r.StackPattern, "/ \t\r\n") {
+		return ErrStackPatternInvalid
+	}
+	return nil
+}
+
+type ScanScheduleInfo struct {
+	ID              uint       `json:"id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	ServerID        *uint      `json:"server_id"`
+	StackPattern    string     `json:"stack_pattern"`
+	CronExpression  string     `json:"cron_expression,omitempty"`
+	OnImageChange   bool       `json:"on_image_change"`
+	AfterDeploy     bool       `json:"after_deploy"`
+	Enabled         bool       `json:"enabled"`
+	CreatedByUserID *uint      `json:"created_by_user_id,omitempty"`
+	NextRunAt       *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty"`
+	LastRunStatus   string     `json:"last_run_status,omitempty"`
+	LastRunMessage  string     `json:"last_run_message,omitempty"`
+}
+
+type ListSchedulesData struct {
+	Schedules []ScanScheduleInfo `json:"schedules"`
+}
+
+type GetScheduleData struct {
+	Schedule ScanScheduleInfo `json:"schedule"`
+}
+
+type DeleteScheduleMessageData struct {
+	Message string `json:"message"`
+}
+
+func ToResponse(s *ScanSchedule) ScanScheduleInfo {
+	return ScanScheduleInfo{
+		ID:              s.ID,
+		CreatedAt:       s.CreatedAt,
+		UpdatedAt:       s.UpdatedAt,
+		ServerID:        s.ServerID,
+		StackPattern:    s.StackPattern,
+		CronExpression:  s.CronExpression,
+		OnImageChange:   s.OnImageChange,
+		AfterDeploy:     s.AfterDeploy,
+		Enabled:         s.Enabled,
+		CreatedByUserID: s.CreatedByUserID,
+		NextRunAt:       s.NextRunAt,
+		LastRunAt:       s.LastRunAt,
+		LastRunStatus:   s.LastRunStatus,
+		LastRunMessage:  s.LastRunMessage,
+	}
+}
+
+func ToResponseList(schedules []ScanSchedule) []ScanScheduleInfo {
+	result := make([]ScanScheduleInfo, len(schedules))
+	for i := range schedules {
+		result[i] = ToResponse(&schedules[i])
+	}
+	return result
+}
@@ -0,0 +1,38 @@
+package scanschedules
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScheduleRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ScheduleRequest
+		wantErr error
+		anyErr  bool
+	}{
+		{"empty", ScheduleRequest{}, ErrNoTrigger, false},
+		{"blank cron only", ScheduleRequest{CronExpression: "  "}, ErrNoTrigger, false},
+		{"cron", ScheduleRequest{CronExpression: "0 2 * * *"}, nil, false},
+		{"image change only", ScheduleRequest{OnImageChange: true}, nil, false},
+		{"after deploy only", ScheduleRequest{AfterDeploy: true, StackPattern: "app-*"}, nil, false},
+		{"invalid cron", ScheduleRequest{CronExpression: "nightly", OnImageChange: true}, nil, true},
+		{"pattern with slash", ScheduleRequest{OnImageChange: true, StackPattern: "a/b"}, ErrStackPatternInvalid, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if tt.anyErr {
+				if got == nil {
+					t.Errorf("Validate() = nil, want error")
+				}
+				return
+			}
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package scanschedules
+
+import (
+	"time"
+
+	"berth/internal/platform/db"
+)
+
+const (
+	RunStatusSuccess = "success"
+	RunStatusPartial = "partial"
+	RunStatusFailed  = "failed"
+	RunStatusSkipped = "skipped"
+)
+
+// ScanSchedule starts vulnerability scans for the stacks matching
+// StackPattern, on a cron expression and/or when their images change. A nil
+// ServerID makes the schedule fleet-wide.
+type ScanSchedule struct {
+	db.BaseModel
+	ServerID        *uint      `json:"server_id" gorm:"index"`
+	StackPattern    string     `json:"stack_pattern" gorm:"not null"`
+	CronExpression  string     `json:"cron_expression"`
+	OnImageChange   bool       `json:"on_image_change" gorm:"not null"`
+	AfterDeploy     bool       `json:"after_deploy" gorm:"not null"`
+	Enabled         bool       `json:"enabled" gorm:"not null"`
+	CreatedByUserID *uint      `json:"created_by_user_id"`
+	NextRunAt       *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt       *time.Time `json:"last_run_at"`
+	LastRunStatus   string     `json:"last_run_status"`
+	LastRunMessage  string     `json:"last_run_message" gorm:"type:text"`
+}
+
+func (ScanSchedule) TableName() string {
+	return "scan_schedules"
+}
@@ -0,0 +1,22 @@
+package scanschedules
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
+
+func (h *APIHandler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/servers/:serverid/scan-schedules", h.ListSchedules, authz.Server(permnames.StacksManage))
+	reg.GET("/servers/:serverid/scan-schedules/:id", h.GetSchedule, authz.Server(permnames.StacksManage))
+	reg.POST("/servers/:serverid/scan-schedules", h.CreateSchedule, authz.Server(permnames.StacksManage))
+	reg.PUT("/servers/:serverid/scan-schedules/:id", h.UpdateSchedule, authz.Server(permnames.StacksManage))
+	reg.DELETE("/servers/:serverid/scan-schedules/:id", h.DeleteSchedule, authz.Server(permnames.StacksManage))
+}
+
+func (h *APIHandler) RegisterAdminAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/scan-schedules", h.ListFleetSchedules, authz.Admin(permnames.AdminServersRead))
+	reg.GET("/scan-schedules/:id", h.GetFleetSchedule, authz.Admin(permnames.AdminServersRead))
+	reg.POST("/scan-schedules", h.CreateFleetSchedule, authz.Admin(permnames.AdminServersWrite))
+	reg.PUT("/scan-schedules/:id", h.UpdateFleetSchedule, authz.Admin(permnames.AdminServersWrite))
+	reg.DELETE("/scan-schedules/:id", h.DeleteFleetSchedule, authz.Admin(permnames.AdminServersWrite))
+}
@@ -0,0 +1,434 @@
+package scanschedules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/operationlogs"
+	"berth/internal/domain/server"
+	"berth/internal/domain/stack"
+	"berth/internal/domain/vulnscan"
+	"berth/internal/pkg/cronspec"
+	"berth/internal/pkg/patterns"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const triggeredScanTimeout = 2 * time.Minute
+
+type scheduleServerProvider interface {
+	GetServer(id uint) (*server.Server, error)
+	ListServers() ([]server.ServerInfo, error)
+}
+
+type scheduleStackLister interface {
+	ListStacksForServer(ctx context.Context, serverID uint, scope authz.ScopeSet) ([]stack.Stack, error)
+}
+
+type scheduleScopeProvider interface {
+	AuthorizedScope(p authz.Principal) (authz.ScopeSet, error)
+}
+
+type scheduleScanStarter interface {
+	StartScan(ctx context.Context, p authz.Principal, serverID uint, stackName string, opts *vulnscan.StartScanOptions) (*vulnscan.ImageScan, error)
+}
+
+type Service struct {
+	db        *gorm.DB
+	serverSvc scheduleServerProvider
+	stackSvc  scheduleStackLister
+	scopeSvc  scheduleScopeProvider
+	scanSvc   scheduleScanStarter
+	logger    *zap.Logger
+	now       func() time.Time
+}
+
+func NewService(db *gorm.DB, serverSvc scheduleServerProvider, stackSvc scheduleStackLister, scopeSvc scheduleScopeProvider, scanSvc scheduleScanStarter, logger *zap.Logger) *Service {
+	return &Service{
+		db:        db,
+		serverSvc: serverSvc,
+		stackSvc:  stackSvc,
+		scopeSvc:  scopeSvc,
+		scanSvc:   scanSvc,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+func (s *Service) Logger() *zap.Logger {
+	return s.logger
+}
+
+// scoped restricts a query to one server's schedules, or to fleet-wide
+// schedules when serverID is nil.
+func (s *Service) scoped(serverID *uint) *gorm.DB {
+	if serverID == nil {
+		return s.db.Where("server_id IS NULL")
+	}
+	return s.db.Where("server_id = ?", *serverID)
+}
+
+func (s *Service) ListSchedules(serverID *uint) ([]ScanSchedule, error) {
+	var schedules []ScanSchedule
+	if err := s.scoped(serverID).Order("id").Find(&schedules).Error; err != nil {
+		s.logger.Error("failed to list scan schedules", zap.Error(err))
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (s *Service) GetSchedule(serverID *uint, scheduleID uint) (*ScanSchedule, error) {
+	var schedule ScanSchedule
+	if err := s.scoped(serverID).First(&schedule, scheduleID).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (s *Service) CreateSchedule(serverID *uint, req ScheduleRequest, createdBy uint) (*ScanSchedule, error) {
+	schedule := ScanSchedule{
+		ServerID: serverID,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+	}
+	if createdBy != 0 {
+		schedule.CreatedByUserID = &createdBy
+	}
+	if err := s.applyRequest(&schedule, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(&schedule).Error; err != nil {
+		s.logger.Error("failed to create scan schedule",
+			zap.Error(err),
+			zap.String("stack_pattern", schedule.StackPattern),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("scan schedule created",
+		zap.Uint("schedule_id", schedule.ID),
+		zap.String("stack_pattern", schedule.StackPattern),
+		zap.String("cron_expression", schedule.CronExpression),
+		zap.Bool("fleet_wide", serverID == nil),
+	)
+
+	return &schedule, nil
+}
+
+func (s *Service) UpdateSchedule(serverID *uint, scheduleID uint, req ScheduleRequest) (*ScanSchedule, error) {
+	schedule, err := s.GetSchedule(serverID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+	if err := s.applyRequest(schedule, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(schedule).Error; err != nil {
+		s.logger.Error("failed to update scan schedule",
+			zap.Error(err),
+			zap.Uint("schedule_id", scheduleID),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("scan schedule updated",
+		zap.Uint("schedule_id", scheduleID),
+		zap.String("stack_pattern", schedule.StackPattern),
+		zap.String("cron_expression", schedule.CronExpression),
+		zap.Bool("enabled", schedule.Enabled),
+	)
+
+	return schedule, nil
+}
+
+func (s *Service) DeleteSchedule(serverID *uint, scheduleID uint) error {
+	schedule, err := s.GetSchedule(serverID, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(schedule).Error; err != nil {
+		s.logger.Error("failed to delete scan schedule",
+			zap.Error(err),
+			zap.Uint("schedule_id", scheduleID),
+		)
+		return err
+	}
+
+	s.logger.Info("scan schedule deleted", zap.Uint("schedule_id", scheduleID))
+	return nil
+}
+
+func (s *Service) applyRequest(schedule *ScanSchedule, req ScheduleRequest) error {
+	schedule.StackPattern = normalisePattern(req.StackPattern)
+	schedule.CronExpression = strings.TrimSpace(req.CronExpression)
+	schedule.OnImageChange = req.OnImageChange
+	schedule.AfterDeploy = req.AfterDeploy
+	schedule.NextRunAt = nil
+
+	if schedule.CronExpression != "" {
+		next, err := cronspec.Next(schedule.CronExpression, s.now())
+		if err != nil {
+			return err
+		}
+		schedule.NextRunAt = &next
+	}
+	return nil
+}
+
+// RunDueSchedules scans every stack matched by an enabled schedule whose next
+// cron run time has passed.
+func (s *Service) RunDueSchedules(ctx context.Context) error {
+	now := s.now()
+
+	var due []ScanSchedule
+	if err := s.db.Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at").
+		Find(&due).Error; err != nil {
+		return fmt.Errorf("failed to query due scan schedules: %w", err)
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.runSchedule(ctx, &due[i], now)
+	}
+
+	return nil
+}
+
+func (s *Service) runSchedule(ctx context.Context, schedule *ScanSchedule, now time.Time) {
+	updates := map[string]any{"last_run_at": now}
+
+	next, err := cronspec.Next(schedule.CronExpression, now)
+	if err != nil {
+		s.logger.Error("scan schedule has an invalid cron expression; clearing it",
+			zap.Error(err),
+			zap.Uint("schedule_id", schedule.ID),
+		)
+		updates["next_run_at"] = nil
+		updates["last_run_status"] = RunStatusFailed
+		updates["last_run_message"] = err.Error()
+		s.saveRunResult(schedule.ID, updates)
+		return
+	}
+
+	claimed := s.db.Model(&ScanSchedule{}).
+		Where("id = ? AND next_run_at <= ?", schedule.ID, now).
+		Update("next_run_at", next)
+	if claimed.Error != nil {
+		s.logger.Error("failed to claim scan schedule",
+			zap.Error(claimed.Error),
+			zap.Uint("schedule_id", schedule.ID),
+		)
+		return
+	}
+	if claimed.RowsAffected == 0 {
+		return
+	}
+
+	status, message := s.executeSchedule(ctx, schedule)
+	updates["last_run_status"] = status
+	updates["last_run_message"] = message
+	s.saveRunResult(schedule.ID, updates)
+}
+
+func (s *Service) executeSchedule(ctx context.Context, schedule *ScanSchedule) (string, string) {
+	serverIDs, err := s.targetServers(schedule)
+	if err != nil {
+		return RunStatusFailed, err.Error()
+	}
+
+	scope, err := s.scopeSvc.AuthorizedScope(authz.SystemPrincipal)
+	if err != nil {
+		return RunStatusFailed, fmt.Sprintf("failed to resolve scope: %v", err)
+	}
+
+	var started, skipped, failed []string
+	for _, serverID := range serverIDs {
+		stacks, err := s.stackSvc.ListStacksForServer(ctx, serverID, scope)
+		if err != nil {
+			s.logger.Error("failed to list stacks for scan schedule",
+				zap.Error(err),
+				zap.Uint("schedule_id", schedule.ID),
+				zap.Uint("server_id", serverID),
+			)
+			failed = append(failed, fmt.Sprintf("server %d: %v", serverID, err))
+			continue
+		}
+
+		for _, st := range stacks {
+			if !patterns.Matches(st.Name, schedule.StackPattern) {
+				continue
+			}
+			label := st.Name
+			if schedule.ServerID == nil {
+				label = fmt.Sprintf("%d/%s", serverID, st.Name)
+			}
+			switch err := s.startScan(ctx, serverID, st.Name, vulnscan.ScanTriggerSchedule); {
+			case err == nil:
+				started = append(started, label)
+			case isScopeConflict(err):
+				skipped = append(skipped, label)
+			default:
+				failed = append(failed, fmt.Sprintf("%s: %v", label, err))
+			}
+		}
+	}
+
+	switch {
+	case len(started) == 0 && len(failed) == 0 && len(skipped) == 0:
+		return RunStatusSkipped, "no stacks matched the schedule pattern"
+	case len(started) == 0 && len(failed) == 0:
+		return RunStatusSkipped, fmt.Sprintf("a differently scoped scan was already running for: %s", strings.Join(skipped, ", "))
+	case len(failed) == 0:
+		msg := fmt.Sprintf("started scans for %d stack(s)", len(started))
+		if len(skipped) > 0 {
+			msg += fmt.Sprintf("; %d skipped because a scan was already running", len(skipped))
+		}
+		return RunStatusSuccess, msg
+	case len(started) == 0:
+		return RunStatusFailed, strings.Join(failed, "; ")
+	default:
+		return RunStatusPartial, fmt.Sprintf("started %d, failed %d: %s", len(started), len(failed), strings.Join(failed, "; "))
+	}
+}
+
+func (s *Service) targetServers(schedule *ScanSchedule) ([]uint, error) {
+	if schedule.ServerID != nil {
+		srv, err := s.serverSvc.GetServer(*schedule.ServerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load server: %w", err)
+		}
+		if !srv.IsActive {
+			return nil, nil
+		}
+		return []uint{srv.ID}, nil
+	}
+
+	servers, err := s.serverSvc.ListServers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list servers: %w", err)
+	}
+	ids := make([]uint, 0, len(servers))
+	for _, srv := range servers {
+		if srv.IsActive {
+			ids = append(ids, srv.ID)
+		}
+	}
+	return ids, nil
+}
+
+// startScan starts a full scan as the system principal. StartScan serialises
+// starts per stack and returns the running scan when one with the same scope
+// is already in progress, so repeated triggers do not pile up.
+func (s *Service) startScan(ctx context.Context, serverID uint, stackName, trigger string) error {
+	scan, err := s.scanSvc.StartScan(ctx, authz.SystemPrincipal, serverID, stackName, &vulnscan.StartScanOptions{Trigger: trigger})
+	if err != nil {
+		if isScopeConflict(err) {
+			s.logger.Info("skipping automatic scan: a differently scoped scan is running",
+				zap.Uint("server_id", serverID),
+				zap.String("stack_name", stackName),
+				zap.String("trigger", trigger),
+			)
+		} else {
+			s.logger.Error("automatic scan failed to start",
+				zap.Error(err),
+				zap.Uint("server_id", serverID),
+				zap.String("stack_name", stackName),
+				zap.String("trigger", trigger),
+			)
+		}
+		return err
+	}
+
+	s.logger.Info("automatic scan started",
+		zap.Uint("scan_id", scan.ID),
+		zap.Uint("server_id", serverID),
+		zap.String("stack_name", stackName),
+		zap.String("trigger", trigger),
+	)
+	return nil
+}
+
+func isScopeConflict(err error) bool {
+	var conflict *vulnscan.ScanScopeConflictError
+	return errors.As(err, &conflict)
+}
+
+// OnImageDigestChanged starts a scan when an enabled schedule covering the
+// stack asks for scans on image changes.
+func (s *Service) OnImageDigestChanged(serverID uint, stackName string) {
+	go s.triggerScan(serverID, stackName, "on_image_change", vulnscan.ScanTriggerImageChange)
+}
+
+// OnOperationEnd starts a scan after a successful up when an enabled schedule
+// covering the stack asks for scans after deploys.
+func (s *Service) OnOperationEnd(log *operationlogs.OperationLog) {
+	if log.Command != "up" || log.Success == nil || !*log.Success {
+		return
+	}
+	go s.triggerScan(log.ServerID, log.StackName, "after_deploy", vulnscan.ScanTriggerDeploy)
+}
+
+func (s *Service) triggerScan(serverID uint, stackName, flagColumn, trigger string) {
+	covered, err := s.hasEventSchedule(serverID, stackName, flagColumn)
+	if err != nil {
+		s.logger.Error("failed to look up scan schedules for trigger",
+			zap.Error(err),
+			zap.Uint("server_id", serverID),
+			zap.String("stack_name", stackName),
+			zap.String("trigger", trigger),
+		)
+		return
+	}
+	if !covered {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), triggeredScanTimeout)
+	defer cancel()
+	_ = s.startScan(ctx, serverID, stackName, trigger)
+}
+
+func (s *Service) hasEventSchedule(serverID uint, stackName, flagColumn string) (bool, error) {
+	var schedules []ScanSchedule
+	if err := s.db.Where("enabled = ? AND "+flagColumn+" = ? AND (server_id = ? OR server_id IS NULL)", true, true, serverID).
+		Find(&schedules).Error; err != nil {
+		return false, err
+	}
+	for _, schedule := range schedules {
+		if patterns.Matches(stackName, schedule.StackPattern) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Service) saveRunResult(scheduleID uint, updates map[string]any) {
+	if err := s.db.Model(&ScanSchedule{}).Where("id = ?", scheduleID).Updates(updates).Error; err != nil {
+		s.logger.Error("failed to record scan schedule run",
+			zap.Error(err),
+			zap.Uint("schedule_id", scheduleID),
+		)
+	}
+}
+
+func normalisePattern(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return "*"
+	}
+	return pattern
+}
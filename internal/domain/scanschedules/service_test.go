@@ -0,0 +1,246 @@
+package scanschedules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/server"
+	"berth/internal/domain/stack"
+	"berth/internal/domain/vulnscan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type fakeServers struct {
+	servers []server.Server
+}
+
+func (f *fakeServers) GetServer(id uint) (*server.Server, error) {
+	for i := range f.servers {
+		if f.servers[i].ID == id {
+			return &f.servers[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeServers) ListServers() ([]server.ServerInfo, error) {
+	infos := make([]server.ServerInfo, len(f.servers))
+	for i, srv := range f.servers {
+		infos[i] = server.ServerInfo{ID: srv.ID, IsActive: srv.IsActive}
+	}
+	return infos, nil
+}
+
+type fakeStacks struct {
+	byServer map[uint][]string
+}
+
+func (f *fakeStacks) ListStacksForServer(_ context.Context, serverID uint, _ authz.ScopeSet) ([]stack.Stack, error) {
+	names := f.byServer[serverID]
+	list := make([]stack.Stack, len(names))
+	for i, name := range names {
+		list[i] = stack.Stack{Name: name}
+	}
+	return list, nil
+}
+
+type fakeScope struct{}
+
+func (fakeScope) AuthorizedScope(authz.Principal) (authz.ScopeSet, error) {
+	return authz.NewScopeSet(nil, nil, nil, false, true), nil
+}
+
+type startedScan struct {
+	principal authz.Principal
+	serverID  uint
+	stack     string
+	trigger   string
+}
+
+type fakeScans struct {
+	started    []startedScan
+	conflictOn map[string]bool
+	failOn     map[string]bool
+}
+
+func (f *fakeScans) StartScan(_ context.Context, p authz.Principal, serverID uint, stackName string, opts *vulnscan.StartScanOptions) (*vulnscan.ImageScan, error) {
+	if f.conflictOn[stackName] {
+		return nil, &vulnscan.ScanScopeConflictError{Existing: &vulnscan.ImageScan{ServiceFilter: "web"}}
+	}
+	if f.failOn[stackName] {
+		return nil, errors.New("agent unavailable")
+	}
+	f.started = append(f.started, startedScan{principal: p, serverID: serverID, stack: stackName, trigger: opts.Trigger})
+	scan := &vulnscan.ImageScan{ServerID: serverID, StackName: stackName, Trigger: opts.Trigger}
+	scan.ID = uint(len(f.started))
+	return scan, nil
+}
+
+func activeServer(id uint) server.Server {
+	srv := server.Server{IsActive: true}
+	srv.ID = id
+	return srv
+}
+
+func newTestService(t *testing.T, servers []server.Server, stacks map[uint][]string) (*Service, *fakeScans) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:scanschedules_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&ScanSchedule{}))
+
+	scans := &fakeScans{conflictOn: map[string]bool{}, failOn: map[string]bool{}}
+	svc := NewService(db, &fakeServers{servers: servers}, &fakeStacks{byServer: stacks}, fakeScope{}, scans, zap.NewNop())
+	return svc, scans
+}
+
+func makeDue(t *testing.T, svc *Service, id uint) {
+	t.Helper()
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, svc.db.Model(&ScanSchedule{}).Where("id = ?", id).Update("next_run_at", past).Error)
+}
+
+func uintPtr(v uint) *uint { return &v }
+
+func TestRunDueSchedules_ScansMatchingStacksWithScheduleTrigger(t *testing.T) {
+	svc, scans := newTestService(t, []server.Server{activeServer(1)}, map[uint][]string{1: {"app-web", "app-db", "monitoring"}})
+
+	schedule, err := svc.CreateSchedule(uintPtr(1), ScheduleRequest{StackPattern: "app-*", CronExpression: "@daily"}, 3)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	require.Len(t, scans.started, 2)
+	for _, scan := range scans.started {
+		assert.True(t, scan.principal.IsSystem())
+		assert.Equal(t, vulnscan.ScanTriggerSchedule, scan.trigger)
+	}
+
+	reloaded, err := svc.GetSchedule(uintPtr(1), schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusSuccess, reloaded.LastRunStatus)
+	require.NotNil(t, reloaded.NextRunAt)
+	assert.True(t, reloaded.NextRunAt.After(time.Now()))
+}
+
+func TestRunDueSchedules_FleetWideCoversActiveServers(t *testing.T) {
+	inactive := server.Server{IsActive: false}
+	inactive.ID = 3
+	svc, scans := newTestService(t,
+		[]server.Server{activeServer(1), activeServer(2), inactive},
+		map[uint][]string{1: {"web"}, 2: {"web", "db"}, 3: {"web"}},
+	)
+
+	schedule, err := svc.CreateSchedule(nil, ScheduleRequest{CronExpression: "@weekly"}, 1)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	require.Len(t, scans.started, 3)
+	for _, scan := range scans.started {
+		assert.NotEqual(t, uint(3), scan.serverID, "inactive servers are skipped")
+	}
+}
+
+func TestRunDueSchedules_ScopeConflictIsSkippedNotFailed(t *testing.T) {
+	svc, scans := newTestService(t, []server.Server{activeServer(1)}, map[uint][]string{1: {"busy"}})
+	scans.conflictOn["busy"] = true
+
+	schedule, err := svc.CreateSchedule(uintPtr(1), ScheduleRequest{CronExpression: "@daily"}, 1)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	reloaded, err := svc.GetSchedule(uintPtr(1), schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusSkipped, reloaded.LastRunStatus)
+	assert.Contains(t, reloaded.LastRunMessage, "busy")
+}
+
+func TestRunDueSchedules_RecordsPartialFailure(t *testing.T) {
+	svc, scans := newTestService(t, []server.Server{activeServer(1)}, map[uint][]string{1: {"a", "b"}})
+	scans.failOn["b"] = true
+
+	schedule, err := svc.CreateSchedule(uintPtr(1), ScheduleRequest{CronExpression: "@daily"}, 1)
+	require.NoError(t, err)
+	makeDue(t, svc, schedule.ID)
+
+	require.NoError(t, svc.RunDueSchedules(context.Background()))
+
+	reloaded, err := svc.GetSchedule(uintPtr(1), schedule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RunStatusPartial, reloaded.LastRunStatus)
+	assert.Contains(t, reloaded.LastRunMessage, "agent unavailable")
+}
+
+func TestEventOnlySchedule_HasNoNextRun(t *testing.T) {
+	svc, _ := newTestService(t, []server.Server{activeServer(1)}, nil)
+
+	schedule, err := svc.CreateSchedule(uintPtr(1), ScheduleRequest{OnImageChange: true}, 1)
+	require.NoError(t, err)
+	assert.Nil(t, schedule.NextRunAt)
+}
+
+func TestTriggerScan_RequiresMatchingEventSchedule(t *testing.T) {
+	svc, scans := newTestService(t, []server.Server{activeServer(1), activeServer(2)}, nil)
+
+	_, err := svc.CreateSchedule(uintPtr(1), ScheduleRequest{StackPattern: "app-*", OnImageChange: true}, 1)
+	require.NoError(t, err)
+	_, err = svc.CreateSchedule(nil, ScheduleRequest{StackPattern: "edge", AfterDeploy: true}, 1)
+	require.NoError(t, err)
+
+	svc.triggerScan(1, "app-web", "on_image_change", vulnscan.ScanTriggerImageChange)
+	svc.triggerScan(1, "other", "on_image_change", vulnscan.ScanTriggerImageChange)
+	svc.triggerScan(2, "app-web", "on_image_change", vulnscan.ScanTriggerImageChange)
+	svc.triggerScan(1, "app-web", "after_deploy", vulnscan.ScanTriggerDeploy)
+	svc.triggerScan(2, "edge", "after_deploy", vulnscan.ScanTriggerDeploy)
+
+	require.Len(t, scans.started, 2)
+	assert.Equal(t, startedScan{principal: authz.SystemPrincipal, serverID: 1, stack: "app-web", trigger: vulnscan.ScanTriggerImageChange}, scans.started[0])
+	assert.Equal(t, startedScan{principal: authz.SystemPrincipal, serverID: 2, stack: "edge", trigger: vulnscan.ScanTriggerDeploy}, scans.started[1])
+}
+
+func TestTriggerScan_IgnoresDisabledSchedules(t *testing.T) {
+	svc, scans := newTestService(t, []server.Server{activeServer(1)}, nil)
+
+	disabled := false
+	_, err := svc.CreateSchedule(uintPtr(1), ScheduleRequest{OnImageChange: true, Enabled: &disabled}, 1)
+	require.NoError(t, err)
+
+	svc.triggerScan(1, "app", "on_image_change", vulnscan.ScanTriggerImageChange)
+	assert.Empty(t, scans.started)
+}
+
+func TestGetSchedule_FleetAndServerScopesAreSeparate(t *testing.T) {
+	svc, _ := newTestService(t, []server.Server{activeServer(1)}, nil)
+
+	fleet, err := svc.CreateSchedule(nil, ScheduleRequest{OnImageChange: true}, 1)
+	require.NoError(t, err)
+	perServer, err := svc.CreateSchedule(uintPtr(1), ScheduleRequest{OnImageChange: true}, 1)
+	require.NoError(t, err)
+
+	_, err = svc.GetSchedule(uintPtr(1), fleet.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = svc.GetSchedule(nil, perServer.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	list, err := svc.ListSchedules(nil)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, fleet.ID, list[0].ID)
+}
@@ -56,17 +56,29 @@ const (
 )
 
 const (
-	TargetTypeUser               = "user"
-	TargetTypeRole               = "role"
-	TargetTypePermission         = "permission"
-	TargetTypeServer             = "server"
-	TargetTypeFile               = "file"
-	TargetTypeBackup             = "backup"
-	TargetTypeSession            = "session"
-	TargetTypeStack              = "stack"
-	TargetTypeRegistryCredential = "registry_credential"
-	TargetTypeAPIKey             = "api_key"
-	TargetTypeAPIKeyScope        = "api_key_scope"
+	TargetTypeUser                  = "user"
+	TargetTypeRole                  = "role"
+	TargetTypePermission            = "permission"
+	TargetTypeServer                = "server"
+	TargetTypeFile                  = "file"
+	TargetTypeBackup                = "backup"
+	TargetTypeSession               = "session"
+	TargetTypeStack                 = "stack"
+	TargetTypeRegistryCredential    = "registry_credential"
+	TargetTypeAPIKey                = "api_key"
+	TargetTypeAPIKeyScope           = "api_key_scope"
+	TargetTypeBackupSchedule        = "backup_schedule"
+	TargetTypeBackupRetentionPolicy = "backup_retention_policy"
+	TargetTypeScheduledOperation    = "scheduled_operation"
+	TargetTypeScanSchedule          = "scan_schedule"
+	TargetTypeUpdatePolicy          = "update_policy"
+	TargetTypeMaintenanceWindow     = "maintenance_window"
+	TargetTypePrunePolicy           = "prune_policy"
+	TargetTypeWebhookEndpoint       = "webhook_endpoint"
+	TargetTypeVulnAlertRule         = "vulnerability_alert_rule"
+	TargetTypeVulnAlert             = "vulnerability_alert"
+	TargetTypeInvitation            = "invitation"
+	TargetTypeAccessRequest         = "access_request"
 )
 
 func (l *SecurityAuditLog) BeforeCreate(tx *gorm.DB) error {
@@ -8,9 +8,16 @@ import (
 	"gorm.io/gorm"
 )
 
+// AuditListener is notified after each audit log entry is stored. Listeners
+// run on the caller's goroutine and must not block.
+type AuditListener interface {
+	OnAuditLog(log *SecurityAuditLog)
+}
+
 type AuditService struct {
-	db     *gorm.DB
-	logger *zap.Logger
+	db        *gorm.DB
+	logger    *zap.Logger
+	listeners []AuditListener
 }
 
 func NewAuditService(db *gorm.DB, logger *zap.Logger) *AuditService {
@@ -20,6 +27,12 @@ func NewAuditService(db *gorm.DB, logger *zap.Logger) *AuditService {
 	}
 }
 
+// AddListener registers l for audit log notifications. It must be called
+// during wiring, before any request is served.
+func (s *AuditService) AddListener(l AuditListener) {
+	s.listeners = append(s.listeners, l)
+}
+
 type LogEvent struct {
 	EventType string
 	Success   bool
@@ -101,6 +114,10 @@ func (s *AuditService) Log(event LogEvent) error {
 		zap.Bool("success", event.Success),
 	)
 
+	for _, l := range s.listeners {
+		l.OnAuditLog(&auditLog)
+	}
+
 	return nil
 }
 
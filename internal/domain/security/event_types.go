@@ -1,22 +1,36 @@
 package security
 
 const (
-	EventAuthLoginSuccess           = "auth.login.success"
-	EventAuthLoginFailure           = "auth.login.failure"
-	EventAuthLogout                 = "auth.logout"
-	EventAuthPasswordResetRequested = "auth.password_reset.requested"
-	EventAuthPasswordResetCompleted = "auth.password_reset.completed"
-	EventAuthEmailVerified          = "auth.email.verified"
-	EventAuthSessionRevoked         = "auth.session.revoked"
-	EventAuthSessionsRevokedAll     = "auth.sessions.revoked_all"
+	EventAuthLoginSuccess                = "auth.login.success"
+	EventAuthLoginFailure                = "auth.login.failure"
+	EventAuthLogout                      = "auth.logout"
+	EventAuthPasswordResetRequested      = "auth.password_reset.requested"
+	EventAuthPasswordResetCompleted      = "auth.password_reset.completed"
+	EventAuthEmailVerified               = "auth.email.verified"
+	EventAuthSessionRevoked              = "auth.session.revoked"
+	EventAuthSessionsRevokedAll          = "auth.sessions.revoked_all"
+	EventAuthAccountLocked               = "auth.account.locked"
+	EventAuthAccountUnlocked             = "auth.account.unlocked"
+	EventAuthTwoFactorEnrollmentRequired = "auth.two_factor.enrollment_required"
+	EventAuthProxyHeaderRejected         = "auth.proxy.header_rejected"
 )
 
 const (
-	EventTOTPEnabled             = "totp.enabled"
-	EventTOTPDisabled            = "totp.disabled"
-	EventTOTPVerificationSuccess = "totp.verification.success"
-	EventTOTPVerificationFailure = "totp.verification.failure"
-	EventTOTPSetupInitiated      = "totp.setup.initiated"
+	EventTOTPEnabled                  = "totp.enabled"
+	EventTOTPDisabled                 = "totp.disabled"
+	EventTOTPVerificationSuccess      = "totp.verification.success"
+	EventTOTPVerificationFailure      = "totp.verification.failure"
+	EventTOTPSetupInitiated           = "totp.setup.initiated"
+	EventTOTPRecoveryCodeUsed         = "totp.recovery_code.used"
+	EventTOTPRecoveryCodesRegenerated = "totp.recovery_codes.regenerated"
+)
+
+const (
+	EventPasskeyRegistered          = "passkey.registered"
+	EventPasskeyRenamed             = "passkey.renamed"
+	EventPasskeyRemoved             = "passkey.removed"
+	EventPasskeyVerificationSuccess = "passkey.verification.success"
+	EventPasskeyVerificationFailure = "passkey.verification.failure"
 )
 
 const (
@@ -26,6 +40,12 @@ const (
 	EventUserEmailChanged    = "user.email.changed"
 	EventUserRoleAssigned    = "user.role.assigned"
 	EventUserRoleRevoked     = "user.role.revoked"
+	EventUserRoleExpired     = "user.role.expired"
+
+	EventUserInvitationCreated  = "user.invitation.created"
+	EventUserInvitationResent   = "user.invitation.resent"
+	EventUserInvitationRevoked  = "user.invitation.revoked"
+	EventUserInvitationAccepted = "user.invitation.accepted"
 )
 
 const (
@@ -34,6 +54,12 @@ const (
 	EventRoleDeleted       = "rbac.role.deleted"
 	EventPermissionAdded   = "rbac.permission.added"
 	EventPermissionRemoved = "rbac.permission.removed"
+
+	EventAccessRequestCreated   = "rbac.access_request.created"
+	EventAccessRequestApproved  = "rbac.access_request.approved"
+	EventAccessRequestDenied    = "rbac.access_request.denied"
+	EventAccessRequestCancelled = "rbac.access_request.cancelled"
+	EventAccessRequestExpired   = "rbac.access_request.expired"
 )
 
 const (
@@ -46,6 +72,12 @@ const (
 	EventServerConnectionTestFailure  = "server.connection.test_failure"
 )
 
+const (
+	EventServerMaintenanceWindowCreated = "server.maintenance_window.created"
+	EventServerMaintenanceWindowUpdated = "server.maintenance_window.updated"
+	EventServerMaintenanceWindowDeleted = "server.maintenance_window.deleted"
+)
+
 const (
 	EventAPITokenIssued    = "api.token.issued"
 	EventAPITokenRefreshed = "api.token.refreshed"
@@ -59,6 +91,7 @@ const (
 	EventAPIKeyScopeAdded       = "apikey.scope.added"
 	EventAPIKeyScopeRemoved     = "apikey.scope.removed"
 	EventAPIKeyValidationFailed = "apikey.validation.failed"
+	EventAPIKeyRateLimitUpdated = "apikey.rate_limit.updated"
 )
 
 const (
@@ -67,9 +100,25 @@ const (
 	EventStackSecretsViewed = "stack.secrets.viewed"
 )
 
+const (
+	EventStackScheduleCreated = "stack.schedule.created"
+	EventStackSchedulePaused  = "stack.schedule.paused"
+	EventStackScheduleResumed = "stack.schedule.resumed"
+	EventStackScheduleDeleted = "stack.schedule.deleted"
+)
+
+const (
+	EventStackUpdatePolicyUpdated = "stack.update_policy.updated"
+	EventStackUpdatePolicyDeleted = "stack.update_policy.deleted"
+)
+
 const (
 	EventDockerPruneExecuted   = "docker.prune.executed"
 	EventDockerResourceDeleted = "docker.resource.deleted"
+
+	EventDockerPrunePolicyCreated = "docker.prune_policy.created"
+	EventDockerPrunePolicyUpdated = "docker.prune_policy.updated"
+	EventDockerPrunePolicyDeleted = "docker.prune_policy.deleted"
 )
 
 const (
@@ -90,35 +139,79 @@ const (
 	EventBackupFileDownloaded = "backup.file_downloaded"
 )
 
+const (
+	EventBackupScheduleCreated = "backup.schedule.created"
+	EventBackupScheduleUpdated = "backup.schedule.updated"
+	EventBackupScheduleDeleted = "backup.schedule.deleted"
+)
+
+const (
+	EventBackupRetentionPolicyCreated = "backup.retention_policy.created"
+	EventBackupRetentionPolicyUpdated = "backup.retention_policy.updated"
+	EventBackupRetentionPolicyDeleted = "backup.retention_policy.deleted"
+)
+
+const (
+	EventVulnscanScheduleCreated = "vulnscan.schedule.created"
+	EventVulnscanScheduleUpdated = "vulnscan.schedule.updated"
+	EventVulnscanScheduleDeleted = "vulnscan.schedule.deleted"
+)
+
+const (
+	EventVulnscanAlertRuleCreated  = "vulnscan.alert_rule.created"
+	EventVulnscanAlertRuleUpdated  = "vulnscan.alert_rule.updated"
+	EventVulnscanAlertRuleDeleted  = "vulnscan.alert_rule.deleted"
+	EventVulnscanAlertAcknowledged = "vulnscan.alert.acknowledged"
+)
+
 const (
 	EventRegistryCredentialCreated = "registry_credential_created"
 	EventRegistryCredentialUpdated = "registry_credential_updated"
 	EventRegistryCredentialDeleted = "registry_credential_deleted"
 )
 
+const (
+	EventWebhookEndpointCreated = "webhook.endpoint.created"
+	EventWebhookEndpointUpdated = "webhook.endpoint.updated"
+	EventWebhookEndpointDeleted = "webhook.endpoint.deleted"
+	EventWebhookTestSent        = "webhook.test.sent"
+)
+
 func GetEventCategory(eventType string) string {
 	switch eventType {
 	case EventAuthLoginSuccess, EventAuthLoginFailure, EventAuthLogout,
 		EventAuthPasswordResetRequested, EventAuthPasswordResetCompleted,
 		EventAuthEmailVerified,
-		EventAuthSessionRevoked, EventAuthSessionsRevokedAll:
+		EventAuthSessionRevoked, EventAuthSessionsRevokedAll,
+		EventAuthAccountLocked, EventAuthAccountUnlocked, EventAuthTwoFactorEnrollmentRequired,
+		EventAuthProxyHeaderRejected:
 		return "auth"
 
 	case EventTOTPEnabled, EventTOTPDisabled, EventTOTPVerificationSuccess,
-		EventTOTPVerificationFailure, EventTOTPSetupInitiated:
+		EventTOTPVerificationFailure, EventTOTPSetupInitiated,
+		EventTOTPRecoveryCodeUsed, EventTOTPRecoveryCodesRegenerated:
+		return "auth"
+
+	case EventPasskeyRegistered, EventPasskeyRenamed, EventPasskeyRemoved,
+		EventPasskeyVerificationSuccess, EventPasskeyVerificationFailure:
 		return "auth"
 
 	case EventUserCreated, EventUserDeleted, EventUserPasswordChanged,
-		EventUserEmailChanged, EventUserRoleAssigned, EventUserRoleRevoked:
+		EventUserEmailChanged, EventUserRoleAssigned, EventUserRoleRevoked, EventUserRoleExpired,
+		EventUserInvitationCreated, EventUserInvitationResent, EventUserInvitationRevoked,
+		EventUserInvitationAccepted:
 		return "user_mgmt"
 
 	case EventRoleCreated, EventRoleUpdated, EventRoleDeleted,
-		EventPermissionAdded, EventPermissionRemoved:
+		EventPermissionAdded, EventPermissionRemoved,
+		EventAccessRequestCreated, EventAccessRequestApproved, EventAccessRequestDenied,
+		EventAccessRequestCancelled, EventAccessRequestExpired:
 		return "rbac"
 
 	case EventServerCreated, EventServerUpdated, EventServerDeleted,
 		EventServerAccessTokenRegenerated, EventServerBackupPasswordChanged,
-		EventServerConnectionTestSuccess, EventServerConnectionTestFailure:
+		EventServerConnectionTestSuccess, EventServerConnectionTestFailure,
+		EventServerMaintenanceWindowCreated, EventServerMaintenanceWindowUpdated, EventServerMaintenanceWindowDeleted:
 		return "server"
 
 	case EventAPITokenIssued, EventAPITokenRefreshed, EventAPITokenRevoked,
@@ -126,13 +219,16 @@ func GetEventCategory(eventType string) string {
 		return "api"
 
 	case EventAPIKeyCreated, EventAPIKeyRevoked, EventAPIKeyScopeAdded,
-		EventAPIKeyScopeRemoved, EventAPIKeyValidationFailed:
+		EventAPIKeyScopeRemoved, EventAPIKeyValidationFailed, EventAPIKeyRateLimitUpdated:
 		return "apikey"
 
-	case EventStackCreated, EventStackDeleted, EventStackSecretsViewed:
+	case EventStackCreated, EventStackDeleted, EventStackSecretsViewed,
+		EventStackScheduleCreated, EventStackSchedulePaused, EventStackScheduleResumed, EventStackScheduleDeleted,
+		EventStackUpdatePolicyUpdated, EventStackUpdatePolicyDeleted:
 		return "stack"
 
-	case EventDockerPruneExecuted, EventDockerResourceDeleted:
+	case EventDockerPruneExecuted, EventDockerResourceDeleted,
+		EventDockerPrunePolicyCreated, EventDockerPrunePolicyUpdated, EventDockerPrunePolicyDeleted:
 		return "docker"
 
 	case EventAuthorizationDenied:
@@ -141,12 +237,22 @@ func GetEventCategory(eventType string) string {
 	case EventFileUploaded, EventFileDownloaded, EventFileDeleted, EventFileRenamed:
 		return "file"
 
-	case EventBackupCreated, EventBackupRestored, EventBackupDeleted, EventBackupFileDownloaded:
+	case EventBackupCreated, EventBackupRestored, EventBackupDeleted, EventBackupFileDownloaded,
+		EventBackupScheduleCreated, EventBackupScheduleUpdated, EventBackupScheduleDeleted,
+		EventBackupRetentionPolicyCreated, EventBackupRetentionPolicyUpdated, EventBackupRetentionPolicyDeleted:
 		return "backup"
 
+	case EventVulnscanScheduleCreated, EventVulnscanScheduleUpdated, EventVulnscanScheduleDeleted,
+		EventVulnscanAlertRuleCreated, EventVulnscanAlertRuleUpdated, EventVulnscanAlertRuleDeleted,
+		EventVulnscanAlertAcknowledged:
+		return "vulnscan"
+
 	case EventRegistryCredentialCreated, EventRegistryCredentialUpdated, EventRegistryCredentialDeleted:
 		return CategoryRegistry
 
+	case EventWebhookEndpointCreated, EventWebhookEndpointUpdated, EventWebhookEndpointDeleted, EventWebhookTestSent:
+		return "webhook"
+
 	default:
 		return "unknown"
 	}
@@ -161,30 +267,47 @@ func GetEventSeverity(eventType string) string {
 		return "critical"
 
 	case EventAuthLoginFailure, EventTOTPVerificationFailure, EventAPIAuthFailed,
-		EventUserCreated, EventUserRoleAssigned, EventUserRoleRevoked,
+		EventAuthAccountLocked, EventPasskeyVerificationFailure, EventAuthProxyHeaderRejected,
+		EventUserCreated, EventUserRoleAssigned, EventUserRoleRevoked, EventUserRoleExpired,
+		EventUserInvitationCreated, EventUserInvitationAccepted,
 		EventRoleCreated, EventRoleUpdated, EventPermissionAdded, EventPermissionRemoved,
+		EventAccessRequestApproved,
 		EventServerCreated, EventServerUpdated, EventServerBackupPasswordChanged,
-		EventTOTPEnabled, EventTOTPDisabled,
+		EventServerMaintenanceWindowCreated, EventServerMaintenanceWindowUpdated, EventServerMaintenanceWindowDeleted,
+		EventTOTPEnabled, EventTOTPDisabled, EventTOTPRecoveryCodeUsed,
+		EventPasskeyRegistered, EventPasskeyRemoved,
 		EventAPIKeyCreated, EventAPIKeyScopeAdded, EventAPIKeyScopeRemoved,
 		EventStackCreated, EventStackSecretsViewed, EventDockerResourceDeleted,
+		EventDockerPrunePolicyCreated, EventDockerPrunePolicyUpdated, EventDockerPrunePolicyDeleted,
+		EventWebhookEndpointCreated, EventWebhookEndpointUpdated, EventWebhookEndpointDeleted,
 		EventAuthorizationDenied:
 		return "high"
 
-	case EventBackupRestored, EventBackupDeleted:
+	case EventBackupRestored, EventBackupDeleted,
+		EventBackupRetentionPolicyCreated, EventBackupRetentionPolicyUpdated, EventBackupRetentionPolicyDeleted:
 		return "high"
 
 	case EventAuthPasswordResetRequested, EventAuthPasswordResetCompleted,
+		EventAuthAccountUnlocked, EventTOTPRecoveryCodesRegenerated, EventAuthTwoFactorEnrollmentRequired,
 		EventUserPasswordChanged, EventUserEmailChanged,
+		EventAccessRequestCreated, EventAccessRequestDenied, EventAccessRequestCancelled, EventAccessRequestExpired,
 		EventServerConnectionTestFailure, EventFileDeleted, EventFileRenamed,
-		EventAPIKeyValidationFailed,
+		EventAPIKeyValidationFailed, EventAPIKeyRateLimitUpdated,
+		EventBackupScheduleCreated, EventBackupScheduleUpdated, EventBackupScheduleDeleted,
+		EventStackScheduleCreated, EventStackSchedulePaused, EventStackScheduleResumed, EventStackScheduleDeleted,
+		EventStackUpdatePolicyUpdated, EventStackUpdatePolicyDeleted,
+		EventVulnscanScheduleCreated, EventVulnscanScheduleUpdated, EventVulnscanScheduleDeleted,
+		EventVulnscanAlertRuleCreated, EventVulnscanAlertRuleUpdated, EventVulnscanAlertRuleDeleted,
+		EventVulnscanAlertAcknowledged,
 		EventRegistryCredentialCreated, EventRegistryCredentialUpdated, EventRegistryCredentialDeleted:
 		return "medium"
 
 	case EventAuthLoginSuccess, EventAuthLogout, EventAuthEmailVerified,
 		EventAuthSessionRevoked, EventAuthSessionsRevokedAll,
 		EventTOTPVerificationSuccess, EventTOTPSetupInitiated,
+		EventPasskeyRenamed, EventPasskeyVerificationSuccess,
 		EventAPITokenIssued, EventAPITokenRefreshed, EventAPITokenRevoked,
-		EventServerConnectionTestSuccess,
+		EventServerConnectionTestSuccess, EventWebhookTestSent,
 		EventFileUploaded, EventFileDownloaded, EventBackupCreated,
 		EventBackupFileDownloaded:
 		return "low"
@@ -0,0 +1,81 @@
+package updatedigests
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type APIHandler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewAPIHandler(service *Service, logger *zap.Logger) *APIHandler {
+	return &APIHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *APIHandler) GetSubscription(c echo.Context) error {
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := h.service.GetSubscription(p.UserID())
+	if err != nil {
+		return response.Internal(c, "Failed to fetch update digest settings")
+	}
+
+	return response.OK(c, GetSubscriptionData{
+		Subscription: ToResponse(subscription),
+	})
+}
+
+func (h *APIHandler) UpdateSubscription(c echo.Context) error {
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req SubscriptionRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	subscription, err := h.service.UpdateSubscription(p.UserID(), req)
+	if err != nil {
+		return response.Internal(c, "Failed to save update digest settings")
+	}
+
+	h.logger.Info("update digest settings changed",
+		zap.Uint("user_id", p.UserID()),
+		zap.Bool("enabled", subscription.Enabled),
+		zap.String("frequency", subscription.Frequency),
+	)
+
+	return response.OK(c, GetSubscriptionData{
+		Subscription: ToResponse(subscription),
+	})
+}
+
+func (h *APIHandler) PreviewDigest(c echo.Context) error {
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	digest, err := h.service.BuildDigest(p.UserID())
+	if err != nil {
+		return response.Internal(c, "Failed to build update digest")
+	}
+
+	return response.OK(c, PreviewDigestData{
+		Digest: *digest,
+	})
+}
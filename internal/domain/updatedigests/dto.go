@@ -0,0 +1,92 @@
+package updatedigests
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	defaultSendHour    = 8
+	defaultSendWeekday = int(time.Monday)
+)
+
+var (
+	ErrFrequencyInvalid   = errors.New("frequency must be daily or weekly")
+	ErrSendHourInvalid    = errors.New("send_hour must be between 0 and 23")
+	ErrSendWeekdayInvalid = errors.New("send_weekday must be between 0 (Sunday) and 6 (Saturday)")
+)
+
+// SubscriptionRequest replaces the caller's digest settings. Omitted hours
+// and weekdays fall back to 08:00 UTC on Mondays.
+type SubscriptionRequest struct {
+	Enabled     bool   `json:"enabled"`
+	Frequency   string `json:"frequency"`
+	SendHour    *int   `json:"send_hour,omitempty"`
+	SendWeekday *int   `json:"send_weekday,omitempty"`
+}
+
+func (r *SubscriptionRequest) Validate() error {
+	if r.Frequency != FrequencyDaily && r.Frequency != FrequencyWeekly {
+		return ErrFrequencyInvalid
+	}
+	if r.SendHour != nil && (*r.SendHour < 0 || *r.SendHour > 23) {
+		return ErrSendHourInvalid
+	}
+	if r.SendWeekday != nil && (*r.SendWeekday < 0 || *r.SendWeekday > 6) {
+		return ErrSendWeekdayInvalid
+	}
+	return nil
+}
+
+type SubscriptionInfo struct {
+	Enabled     bool       `json:"enabled"`
+	Frequency   string     `json:"frequency"`
+	SendHour    int        `json:"send_hour"`
+	SendWeekday int        `json:"send_weekday"`
+	NextSendAt  *time.Time `json:"next_send_at,omitempty"`
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty"`
+}
+
+type GetSubscriptionData struct {
+	Subscription SubscriptionInfo `json:"subscription"`
+}
+
+// Digest is the content of one digest email: the image updates visible to a
+// user, grouped by server and stack.
+type Digest struct {
+	Servers []DigestServer `json:"servers"`
+	Total   int            `json:"total"`
+}
+
+type DigestServer struct {
+	ServerID   uint          `json:"server_id"`
+	ServerName string        `json:"server_name"`
+	Stacks     []DigestStack `json:"stacks"`
+}
+
+type DigestStack struct {
+	StackName string         `json:"stack_name"`
+	URL       string         `json:"url"`
+	Updates   []DigestUpdate `json:"updates"`
+}
+
+type DigestUpdate struct {
+	ContainerName string     `json:"container_name"`
+	ImageName     string     `json:"image_name"`
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+}
+
+type PreviewDigestData struct {
+	Digest Digest `json:"digest"`
+}
+
+func ToResponse(s *Subscription) SubscriptionInfo {
+	return SubscriptionInfo{
+		Enabled:     s.Enabled,
+		Frequency:   s.Frequency,
+		SendHour:    s.SendHour,
+		SendWeekday: s.SendWeekday,
+		NextSendAt:  s.NextSendAt,
+		LastSentAt:  s.LastSentAt,
+	}
+}
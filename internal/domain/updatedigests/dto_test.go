@@ -0,0 +1,33 @@
+package updatedigests
+
+import (
+	"errors"
+	"testing"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestSubscriptionRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     SubscriptionRequest
+		wantErr error
+	}{
+		{"daily", SubscriptionRequest{Enabled: true, Frequency: FrequencyDaily}, nil},
+		{"weekly with schedule", SubscriptionRequest{Enabled: true, Frequency: FrequencyWeekly, SendHour: intPtr(0), SendWeekday: intPtr(6)}, nil},
+		{"disabled still needs frequency", SubscriptionRequest{}, ErrFrequencyInvalid},
+		{"unknown frequency", SubscriptionRequest{Frequency: "hourly"}, ErrFrequencyInvalid},
+		{"hour too high", SubscriptionRequest{Frequency: FrequencyDaily, SendHour: intPtr(24)}, ErrSendHourInvalid},
+		{"negative hour", SubscriptionRequest{Frequency: FrequencyDaily, SendHour: intPtr(-1)}, ErrSendHourInvalid},
+		{"weekday too high", SubscriptionRequest{Frequency: FrequencyWeekly, SendWeekday: intPtr(7)}, ErrSendWeekdayInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.req.Validate()
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package updatedigests
+
+import (
+	"time"
+
+	"berth/internal/platform/db"
+)
+
+const (
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+)
+
+// Subscription is a user's opt-in to a digest email listing the image updates
+// they can see. Digests go out at SendHour (UTC) each day, or each SendWeekday
+// for weekly subscriptions.
+type Subscription struct {
+	db.BaseModel
+	UserID      uint       `json:"user_id" gorm:"not null;uniqueIndex"`
+	Frequency   string     `json:"frequency" gorm:"not null"`
+	SendHour    int        `json:"send_hour" gorm:"not null"`
+	SendWeekday int        `json:"send_weekday" gorm:"not null"`
+	Enabled     bool       `json:"enabled" gorm:"not null"`
+	NextSendAt  *time.Time `json:"next_send_at" gorm:"index"`
+	LastSentAt  *time.Time `json:"last_sent_at"`
+}
+
+func (Subscription) TableName() string {
+	return "update_digest_subscriptions"
+}
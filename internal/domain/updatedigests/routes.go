@@ -0,0 +1,10 @@
+package updatedigests
+
+import "berth/internal/domain/authz"
+
+func (h *APIHandler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
+	denied := authz.APIKeyDenied()
+	reg.GET("/profile/update-digest", h.GetSubscription, denied)
+	reg.PUT("/profile/update-digest", h.UpdateSubscription, denied)
+	reg.GET("/profile/update-digest/preview", h.PreviewDigest, denied)
+}
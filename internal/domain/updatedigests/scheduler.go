@@ -0,0 +1,14 @@
+package updatedigests
+
+import (
+	"time"
+
+	"berth/internal/pkg/periodic"
+
+	"go.uber.org/zap"
+)
+
+// NewScheduler returns a runner that runs due update digests every minute.
+func NewScheduler(service *Service, logger *zap.Logger) *periodic.Runner {
+	return periodic.NewRunner("update digest scheduler", time.Minute, service.RunDueDigests, logger)
+}
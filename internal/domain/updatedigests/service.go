@@ -0,0 +1,294 @@
+package updatedigests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/imageupdates"
+	usermodel "berth/internal/domain/user"
+	"berth/internal/pkg/cronspec"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const templateName = "image_update_digest"
+
+type digestScopeProvider interface {
+	PrincipalForUser(userID uint) (authz.Principal, error)
+	AuthorizedScope(p authz.Principal) (authz.ScopeSet, error)
+}
+
+type digestUpdateSource interface {
+	GetAvailableUpdates() ([]imageupdates.ContainerImageUpdate, error)
+}
+
+type digestMailer interface {
+	SendTemplate(templateName string, to []string, subject string, data map[string]any) error
+}
+
+type Service struct {
+	db        *gorm.DB
+	scopeSvc  digestScopeProvider
+	updateSvc digestUpdateSource
+	mailer    digestMailer
+	appName   string
+	appURL    string
+	logger    *zap.Logger
+	now       func() time.Time
+}
+
+func NewService(db *gorm.DB, scopeSvc digestScopeProvider, updateSvc digestUpdateSource, mailer digestMailer, appName, appURL string, logger *zap.Logger) *Service {
+	return &Service{
+		db:        db,
+		scopeSvc:  scopeSvc,
+		updateSvc: updateSvc,
+		mailer:    mailer,
+		appName:   appName,
+		appURL:    strings.TrimRight(appURL, "/"),
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// GetSubscription returns the user's digest settings, or the disabled
+// defaults when they have never subscribed.
+func (s *Service) GetSubscription(userID uint) (*Subscription, error) {
+	var subscription Subscription
+	err := s.db.Where("user_id = ?", userID).First(&subscription).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &Subscription{
+			UserID:      userID,
+			Frequency:   FrequencyDaily,
+			SendHour:    defaultSendHour,
+			SendWeekday: defaultSendWeekday,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (s *Service) UpdateSubscription(userID uint, req SubscriptionRequest) (*Subscription, error) {
+	subscription, err := s.GetSubscription(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.Enabled = req.Enabled
+	subscription.Frequency = req.Frequency
+	subscription.SendHour = defaultSendHour
+	if req.SendHour != nil {
+		subscription.SendHour = *req.SendHour
+	}
+	subscription.SendWeekday = defaultSendWeekday
+	if req.SendWeekday != nil {
+		subscription.SendWeekday = *req.SendWeekday
+	}
+
+	subscription.NextSendAt = nil
+	if subscription.Enabled {
+		next, err := nextSendAt(subscription, s.now())
+		if err != nil {
+			return nil, err
+		}
+		subscription.NextSendAt = &next
+	}
+
+	if err := s.db.Save(subscription).Error; err != nil {
+		s.logger.Error("failed to save update digest subscription",
+			zap.Error(err),
+			zap.Uint("user_id", userID),
+		)
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// BuildDigest collects the available image updates on the stacks the user
+// can read.
+func (s *Service) BuildDigest(userID uint) (*Digest, error) {
+	p, err := s.scopeSvc.PrincipalForUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve user: %w", err)
+	}
+	scope, err := s.scopeSvc.AuthorizedScope(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve stack scope: %w", err)
+	}
+	updates, err := s.updateSvc.GetAvailableUpdates()
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]imageupdates.ContainerImageUpdate, 0, len(updates))
+	for _, update := range updates {
+		if scope.AllowsStack(update.ServerID, update.StackName) {
+			visible = append(visible, update)
+		}
+	}
+	return s.groupUpdates(visible), nil
+}
+
+func (s *Service) groupUpdates(updates []imageupdates.ContainerImageUpdate) *Digest {
+	sort.SliceStable(updates, func(i, j int) bool {
+		a, b := updates[i], updates[j]
+		if a.Server.Name != b.Server.Name {
+			return a.Server.Name < b.Server.Name
+		}
+		if a.ServerID != b.ServerID {
+			return a.ServerID < b.ServerID
+		}
+		if a.StackName != b.StackName {
+			return a.StackName < b.StackName
+		}
+		return a.ContainerName < b.ContainerName
+	})
+
+	digest := &Digest{Servers: []DigestServer{}, Total: len(updates)}
+	for _, update := range updates {
+		n := len(digest.Servers)
+		if n == 0 || digest.Servers[n-1].ServerID != update.ServerID {
+			digest.Servers = append(digest.Servers, DigestServer{
+				ServerID:   update.ServerID,
+				ServerName: update.Server.Name,
+			})
+			n++
+		}
+		server := &digest.Servers[n-1]
+
+		m := len(server.Stacks)
+		if m == 0 || server.Stacks[m-1].StackName != update.StackName {
+			server.Stacks = append(server.Stacks, DigestStack{
+				StackName: update.StackName,
+				URL:       fmt.Sprintf("%s/servers/%d/stacks/%s", s.appURL, update.ServerID, url.PathEscape(update.StackName)),
+			})
+			m++
+		}
+		stack := &server.Stacks[m-1]
+
+		stack.Updates = append(stack.Updates, DigestUpdate{
+			ContainerName: update.ContainerName,
+			ImageName:     update.CurrentImageName,
+			LastCheckedAt: update.LastCheckedAt,
+		})
+	}
+	return digest
+}
+
+func (s *Service) RunDueDigests(ctx context.Context) error {
+	now := s.now()
+
+	var due []Subscription
+	if err := s.db.Where("enabled = ? AND next_send_at IS NOT NULL AND next_send_at <= ?", true, now).
+		Order("next_send_at").
+		Find(&due).Error; err != nil {
+		return fmt.Errorf("failed to query due update digests: %w", err)
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.runSubscription(&due[i], now)
+	}
+	return nil
+}
+
+func (s *Service) runSubscription(subscription *Subscription, now time.Time) {
+	next, err := nextSendAt(subscription, now)
+	if err != nil {
+		s.logger.Error("update digest subscription has invalid settings",
+			zap.Error(err),
+			zap.Uint("user_id", subscription.UserID),
+		)
+		return
+	}
+
+	claimed := s.db.Model(&Subscription{}).
+		Where("id = ? AND next_send_at <= ?", subscription.ID, now).
+		Update("next_send_at", next)
+	if claimed.Error != nil {
+		s.logger.Error("failed to claim update digest subscription",
+			zap.Error(claimed.Error),
+			zap.Uint("user_id", subscription.UserID),
+		)
+		return
+	}
+	if claimed.RowsAffected == 0 {
+		return
+	}
+
+	sent, err := s.sendDigest(subscription)
+	if err != nil {
+		s.logger.Error("failed to send update digest",
+			zap.Error(err),
+			zap.Uint("user_id", subscription.UserID),
+		)
+		return
+	}
+	if !sent {
+		return
+	}
+
+	if err := s.db.Model(&Subscription{}).Where("id = ?", subscription.ID).Update("last_sent_at", now).Error; err != nil {
+		s.logger.Error("failed to record update digest send",
+			zap.Error(err),
+			zap.Uint("user_id", subscription.UserID),
+		)
+	}
+}
+
+// sendDigest mails the user's digest. Nothing is sent when there are no
+// updates to report.
+func (s *Service) sendDigest(subscription *Subscription) (bool, error) {
+	var user usermodel.User
+	if err := s.db.First(&user, subscription.UserID).Error; err != nil {
+		return false, fmt.Errorf("failed to load user: %w", err)
+	}
+	if user.Email == "" {
+		return false, nil
+	}
+
+	digest, err := s.BuildDigest(user.ID)
+	if err != nil {
+		return false, err
+	}
+	if digest.Total == 0 {
+		return false, nil
+	}
+
+	subject := fmt.Sprintf("%d image update", digest.Total)
+	if digest.Total != 1 {
+		subject += "s"
+	}
+	subject += " available"
+
+	err = s.mailer.SendTemplate(templateName, []string{user.Email}, subject, map[string]any{
+		"Username":  user.Username,
+		"Frequency": subscription.Frequency,
+		"Total":     digest.Total,
+		"Servers":   digest.Servers,
+		"AppURL":    s.appURL,
+		"AppName":   s.appName,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func nextSendAt(subscription *Subscription, from time.Time) (time.Time, error) {
+	spec := fmt.Sprintf("0 %d * * *", subscription.SendHour)
+	if subscription.Frequency == FrequencyWeekly {
+		spec = fmt.Sprintf("0 %d * * %d", subscription.SendHour, subscription.SendWeekday)
+	}
+	return cronspec.Next(spec, from.UTC())
+}
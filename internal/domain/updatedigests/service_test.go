@@ -0,0 +1,199 @@
+package updatedigests
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/imageupdates"
+	"berth/internal/domain/server"
+	usermodel "berth/internal/domain/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+// fakeScope grants stack access per user from a fixed pattern map.
+type fakeScope struct {
+	patterns map[uint]map[uint][]string
+}
+
+func (f *fakeScope) PrincipalForUser(userID uint) (authz.Principal, error) {
+	return authz.NewPrincipal(userID, false, nil), nil
+}
+
+func (f *fakeScope) AuthorizedScope(p authz.Principal) (authz.ScopeSet, error) {
+	byServer := f.patterns[p.UserID()]
+	ids := make([]uint, 0, len(byServer))
+	for id := range byServer {
+		ids = append(ids, id)
+	}
+	return authz.NewScopeSet(ids, byServer, nil, false, false), nil
+}
+
+type fakeUpdates struct {
+	updates []imageupdates.ContainerImageUpdate
+}
+
+func (f *fakeUpdates) GetAvailableUpdates() ([]imageupdates.ContainerImageUpdate, error) {
+	return append([]imageupdates.ContainerImageUpdate(nil), f.updates...), nil
+}
+
+type sentMail struct {
+	template string
+	to       []string
+	subject  string
+	data     map[string]any
+}
+
+type fakeMailer struct {
+	sent []sentMail
+}
+
+func (f *fakeMailer) SendTemplate(templateName string, to []string, subject string, data map[string]any) error {
+	f.sent = append(f.sent, sentMail{template: templateName, to: to, subject: subject, data: data})
+	return nil
+}
+
+func update(serverID uint, serverName, stackName, container string) imageupdates.ContainerImageUpdate {
+	u := imageupdates.ContainerImageUpdate{
+		ServerID:         serverID,
+		StackName:        stackName,
+		ContainerName:    container,
+		CurrentImageName: container + ":latest",
+		UpdateAvailable:  true,
+		Server:           server.Server{Name: serverName},
+	}
+	u.Server.ID = serverID
+	return u
+}
+
+func newTestService(t *testing.T, scope *fakeScope, updates ...imageupdates.ContainerImageUpdate) (*Service, *fakeMailer) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:updatedigests_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&usermodel.User{}, &Subscription{}))
+
+	mailer := &fakeMailer{}
+	svc := NewService(db, scope, &fakeUpdates{updates: updates}, mailer, "berth", "https://berth.example.com/", zap.NewNop())
+	return svc, mailer
+}
+
+func createUser(t *testing.T, svc *Service, name string) uint {
+	t.Helper()
+	user := usermodel.User{Username: name, Email: name + "@example.com", Password: "x"}
+	require.NoError(t, svc.db.Create(&user).Error)
+	return user.ID
+}
+
+func TestGetSubscription_DefaultsToDisabled(t *testing.T) {
+	svc, _ := newTestService(t, &fakeScope{})
+
+	subscription, err := svc.GetSubscription(7)
+	require.NoError(t, err)
+
+	assert.False(t, subscription.Enabled)
+	assert.Equal(t, FrequencyDaily, subscription.Frequency)
+	assert.Equal(t, defaultSendHour, subscription.SendHour)
+	assert.Nil(t, subscription.NextSendAt)
+}
+
+func TestUpdateSubscription_SchedulesNextSend(t *testing.T) {
+	svc, _ := newTestService(t, &fakeScope{})
+	// A Wednesday.
+	svc.now = func() time.Time { return time.Date(2026, 5, 6, 12, 0, 0, 0, time.UTC) }
+
+	daily, err := svc.UpdateSubscription(1, SubscriptionRequest{Enabled: true, Frequency: FrequencyDaily, SendHour: intPtr(9)})
+	require.NoError(t, err)
+	require.NotNil(t, daily.NextSendAt)
+	assert.True(t, daily.NextSendAt.Equal(time.Date(2026, 5, 7, 9, 0, 0, 0, time.UTC)))
+
+	weekly, err := svc.UpdateSubscription(1, SubscriptionRequest{Enabled: true, Frequency: FrequencyWeekly})
+	require.NoError(t, err)
+	assert.Equal(t, daily.ID, weekly.ID, "settings are updated in place")
+	require.NotNil(t, weekly.NextSendAt)
+	assert.True(t, weekly.NextSendAt.Equal(time.Date(2026, 5, 11, 8, 0, 0, 0, time.UTC)), "weekly digests default to Monday 08:00")
+
+	disabled, err := svc.UpdateSubscription(1, SubscriptionRequest{Frequency: FrequencyWeekly})
+	require.NoError(t, err)
+	assert.Nil(t, disabled.NextSendAt)
+}
+
+func TestRunDueDigests_SendsUpdatesTheUserCanSeeGroupedByServerAndStack(t *testing.T) {
+	scope := &fakeScope{patterns: map[uint]map[uint][]string{}}
+	svc, mailer := newTestService(t, scope,
+		update(2, "staging", "web", "web-1"),
+		update(1, "prod", "web", "nginx"),
+		update(1, "prod", "db", "postgres"),
+		update(1, "prod", "monitoring", "grafana"),
+		update(3, "secret", "vault", "vault"),
+	)
+	userID := createUser(t, svc, "alice")
+	scope.patterns[userID] = map[uint][]string{1: {"web", "db"}, 2: {"*"}}
+
+	now := time.Date(2026, 5, 6, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now.Add(-90 * time.Minute) }
+	_, err := svc.UpdateSubscription(userID, SubscriptionRequest{Enabled: true, Frequency: FrequencyDaily, SendHour: intPtr(11)})
+	require.NoError(t, err)
+
+	svc.now = func() time.Time { return now }
+	require.NoError(t, svc.RunDueDigests(context.Background()))
+
+	require.Len(t, mailer.sent, 1)
+	mail := mailer.sent[0]
+	assert.Equal(t, templateName, mail.template)
+	assert.Equal(t, []string{"alice@example.com"}, mail.to)
+	assert.Equal(t, "3 image updates available", mail.subject)
+	assert.Equal(t, "https://berth.example.com", mail.data["AppURL"])
+
+	servers := mail.data["Servers"].([]DigestServer)
+	require.Len(t, servers, 2)
+	assert.Equal(t, "prod", servers[0].ServerName)
+	require.Len(t, servers[0].Stacks, 2)
+	assert.Equal(t, "db", servers[0].Stacks[0].StackName)
+	assert.Equal(t, "https://berth.example.com/servers/1/stacks/db", servers[0].Stacks[0].URL)
+	assert.Equal(t, "web", servers[0].Stacks[1].StackName)
+	assert.Equal(t, "nginx", servers[0].Stacks[1].Updates[0].ContainerName)
+	assert.Equal(t, "staging", servers[1].ServerName)
+
+	subscription, err := svc.GetSubscription(userID)
+	require.NoError(t, err)
+	require.NotNil(t, subscription.LastSentAt)
+	assert.True(t, subscription.LastSentAt.Equal(now))
+	require.NotNil(t, subscription.NextSendAt)
+	assert.True(t, subscription.NextSendAt.Equal(time.Date(2026, 5, 7, 11, 0, 0, 0, time.UTC)))
+
+	require.NoError(t, svc.RunDueDigests(context.Background()))
+	assert.Len(t, mailer.sent, 1, "a digest is only sent once per period")
+}
+
+func TestRunDueDigests_SkipsEmptyDigests(t *testing.T) {
+	scope := &fakeScope{patterns: map[uint]map[uint][]string{}}
+	svc, mailer := newTestService(t, scope, update(1, "prod", "web", "nginx"))
+	userID := createUser(t, svc, "bob")
+	scope.patterns[userID] = map[uint][]string{1: {"db"}}
+
+	now := time.Date(2026, 5, 6, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now.Add(-90 * time.Minute) }
+	_, err := svc.UpdateSubscription(userID, SubscriptionRequest{Enabled: true, Frequency: FrequencyDaily, SendHour: intPtr(11)})
+	require.NoError(t, err)
+
+	svc.now = func() time.Time { return now }
+	require.NoError(t, svc.RunDueDigests(context.Background()))
+
+	assert.Empty(t, mailer.sent)
+	subscription, err := svc.GetSubscription(userID)
+	require.NoError(t, err)
+	assert.Nil(t, subscription.LastSentAt)
+	require.NotNil(t, subscription.NextSendAt)
+	assert.True(t, subscription.NextSendAt.After(now), "the next send is still scheduled")
+}
@@ -3,10 +3,11 @@ package user
 import "time"
 
 type RoleInfo struct {
-	ID          uint   `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	IsAdmin     bool   `json:"is_admin"`
+	ID               uint   `json:"id"`
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	IsAdmin          bool   `json:"is_admin"`
+	RequireTwoFactor bool   `json:"require_two_factor"`
 }
 
 type PermissionInfo struct {
@@ -19,11 +20,12 @@ type PermissionInfo struct {
 }
 
 type RoleWithPermissions struct {
-	ID          uint             `json:"id"`
-	Name        string           `json:"name"`
-	Description string           `json:"description"`
-	IsAdmin     bool             `json:"is_admin"`
-	Permissions []PermissionInfo `json:"permissions"`
+	ID               uint             `json:"id"`
+	Name             string           `json:"name"`
+	Description      string           `json:"description"`
+	IsAdmin          bool             `json:"is_admin"`
+	RequireTwoFactor bool             `json:"require_two_factor"`
+	Permissions      []PermissionInfo `json:"permissions"`
 }
 
 type UserInfo struct {
@@ -78,10 +80,11 @@ func ToUserInfo(u User, totpEnabled bool) UserInfo {
 
 func ToRoleInfo(r Role) RoleInfo {
 	return RoleInfo{
-		ID:          r.ID,
-		Name:        r.Name,
-		Description: r.Description,
-		IsAdmin:     r.IsAdmin,
+		ID:               r.ID,
+		Name:             r.Name,
+		Description:      r.Description,
+		IsAdmin:          r.IsAdmin,
+		RequireTwoFactor: r.RequireTwoFactor,
 	}
 }
 
@@ -98,10 +101,11 @@ func ToPermissionInfo(p Permission) PermissionInfo {
 
 func ToRoleWithPermissions(r Role) RoleWithPermissions {
 	return RoleWithPermissions{
-		ID:          r.ID,
-		Name:        r.Name,
-		Description: r.Description,
-		IsAdmin:     r.IsAdmin,
-		Permissions: []PermissionInfo{},
+		ID:               r.ID,
+		Name:             r.Name,
+		Description:      r.Description,
+		IsAdmin:          r.IsAdmin,
+		RequireTwoFactor: r.RequireTwoFactor,
+		Permissions:      []PermissionInfo{},
 	}
 }
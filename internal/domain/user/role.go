@@ -14,6 +14,9 @@ type Role struct {
 	Name        string `json:"name" gorm:"uniqueIndex;not null"`
 	Description string `json:"description"`
 	IsAdmin     bool   `json:"is_admin" gorm:"default:false"`
+	// RequireTwoFactor makes holders of the role enroll TOTP or a passkey
+	// before password logins grant full access.
+	RequireTwoFactor bool `json:"require_two_factor" gorm:"not null;default:false"`
 }
 
 func (r *Role) BeforeDelete(tx *gorm.DB) error {
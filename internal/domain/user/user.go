@@ -30,6 +30,49 @@ func (u *User) BeforeDelete(tx *gorm.DB) error {
 	return nil
 }
 
+// LoadActiveRoles sets Roles on each user to the roles held through an
+// assignment that has not expired, in one query joined through user_roles.
+// Use it instead of Preload("Roles"): a many2many preload cannot filter on
+// the join table, so it would still return assignments that have expired but
+// that the role expiry worker has not removed yet.
+func LoadActiveRoles(db *gorm.DB, users ...*User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+
+	var assignments []UserRole
+	if err := db.Session(&gorm.Session{NewDB: true}).
+		InnerJoins("Role").
+		Where("user_roles.user_id IN ?", ids).
+		Scopes(ActiveRoleAssignments).
+		Order("user_roles.role_id").
+		Find(&assignments).Error; err != nil {
+		return err
+	}
+
+	byUser := make(map[uint][]Role, len(users))
+	for _, a := range assignments {
+		byUser[a.UserID] = append(byUser[a.UserID], a.Role)
+	}
+	for _, u := range users {
+		u.Roles = byUser[u.ID]
+	}
+	return nil
+}
+
+// FirstWithActiveRoles finds the first user matching conds and loads their
+// active roles.
+func FirstWithActiveRoles(db *gorm.DB, u *User, conds ...any) error {
+	if err := db.First(u, conds...).Error; err != nil {
+		return err
+	}
+	return LoadActiveRoles(db, u)
+}
+
 func (u *User) IsAdmin() bool {
 	for _, role := range u.Roles {
 		if role.IsAdmin {
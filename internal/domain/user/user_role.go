@@ -0,0 +1,34 @@
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserRole is a row of the user_roles join table behind User.Roles. A role
+// assigned with ExpiresAt is a temporary grant: it stops counting as soon as
+// ExpiresAt passes and the role expiry worker then removes the row, revoking
+// the user's sessions as well when RevokeSessions is set.
+type UserRole struct {
+	UserID         uint       `json:"user_id" gorm:"primaryKey"`
+	RoleID         uint       `json:"role_id" gorm:"primaryKey"`
+	ExpiresAt      *time.Time `json:"expires_at" gorm:"index"`
+	RevokeSessions bool       `json:"revoke_sessions" gorm:"not null;default:false"`
+	Role           Role       `json:"-" gorm:"foreignKey:RoleID"`
+}
+
+func (UserRole) TableName() string {
+	return "user_roles"
+}
+
+// IsExpired reports whether a temporary assignment has run out at now.
+func (ur *UserRole) IsExpired(now time.Time) bool {
+	return ur.ExpiresAt != nil && !ur.ExpiresAt.After(now)
+}
+
+// ActiveRoleAssignments limits a query joined to user_roles to assignments
+// that have not expired.
+func ActiveRoleAssignments(db *gorm.DB) *gorm.DB {
+	return db.Where("(user_roles.expires_at IS NULL OR user_roles.expires_at > ?)", time.Now())
+}
@@ -0,0 +1,308 @@
+package vulnalerts
+
+import (
+	"errors"
+	"strconv"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/security"
+	"berth/internal/domain/session"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type alertAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+type APIHandler struct {
+	service      *Service
+	auditService alertAuditLogger
+}
+
+func NewAPIHandler(service *Service, auditService alertAuditLogger) *APIHandler {
+	return &APIHandler{
+		service:      service,
+		auditService: auditService,
+	}
+}
+
+func (h *APIHandler) ListRules(c echo.Context) error {
+	rules, err := h.service.ListRules()
+	if err != nil {
+		return response.Internal(c, "Failed to fetch vulnerability alert rules")
+	}
+
+	return response.OK(c, ListRulesData{
+		Rules: ToRuleResponseList(rules),
+	})
+}
+
+func (h *APIHandler) GetRule(c echo.Context) error {
+	_, rule, err := h.loadRule(c)
+	if err != nil || rule == nil {
+		return err
+	}
+
+	return response.OK(c, GetRuleData{
+		Rule: ToRuleResponse(rule),
+	})
+}
+
+func (h *APIHandler) CreateRule(c echo.Context) error {
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req RuleRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	rule, err := h.service.CreateRule(req, p.UserID())
+	if err != nil {
+		return response.Internal(c, "Failed to create vulnerability alert rule")
+	}
+
+	h.auditRule(c, p, security.EventVulnscanAlertRuleCreated, rule)
+
+	return response.Created(c, GetRuleData{
+		Rule: ToRuleResponse(rule),
+	})
+}
+
+func (h *APIHandler) UpdateRule(c echo.Context) error {
+	p, existing, err := h.loadRule(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	var req RuleRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	rule, err := h.service.UpdateRule(existing.ID, req)
+	if err != nil {
+		return response.Internal(c, "Failed to update vulnerability alert rule")
+	}
+
+	h.auditRule(c, p, security.EventVulnscanAlertRuleUpdated, rule)
+
+	return response.OK(c, GetRuleData{
+		Rule: ToRuleResponse(rule),
+	})
+}
+
+func (h *APIHandler) DeleteRule(c echo.Context) error {
+	p, rule, err := h.loadRule(c)
+	if err != nil || rule == nil {
+		return err
+	}
+
+	if err := h.service.DeleteRule(rule.ID); err != nil {
+		return response.Internal(c, "Failed to delete vulnerability alert rule")
+	}
+
+	h.auditRule(c, p, security.EventVulnscanAlertRuleDeleted, rule)
+
+	return response.OK(c, DeleteRuleMessageData{
+		Message: "Vulnerability alert rule deleted successfully",
+	})
+}
+
+func (h *APIHandler) ListAlerts(c echo.Context) error {
+	filter := AlertFilter{}
+	if raw := c.QueryParam("server_id"); raw != "" {
+		serverID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return response.BadRequest(c, "Invalid server_id")
+		}
+		id := uint(serverID)
+		filter.ServerID = &id
+	}
+	filter.StackName = c.QueryParam("stack_name")
+	return h.listAlerts(c, filter)
+}
+
+func (h *APIHandler) ListStackAlerts(c echo.Context) error {
+	serverID, err := echoparams.ParseUintParam(c, "serverid")
+	if err != nil {
+		return err
+	}
+	return h.listAlerts(c, AlertFilter{
+		ServerID:  &serverID,
+		StackName: c.Param("stackname"),
+	})
+}
+
+func (h *APIHandler) AcknowledgeAlert(c echo.Context) error {
+	return h.acknowledge(c, false)
+}
+
+func (h *APIHandler) AcknowledgeStackAlert(c echo.Context) error {
+	return h.acknowledge(c, true)
+}
+
+func (h *APIHandler) listAlerts(c echo.Context, filter AlertFilter) error {
+	switch c.QueryParam("acknowledged") {
+	case "":
+	case "true":
+		acknowledged := true
+		filter.Acknowledged = &acknowledged
+	case "false":
+		acknowledged := false
+		filter.Acknowledged = &acknowledged
+	default:
+		return response.BadRequest(c, "acknowledged must be true or false")
+	}
+
+	page := intQueryParam(c, "page", 1)
+	pageSize := intQueryParam(c, "page_size", 25)
+
+	alerts, total, err := h.service.ListAlerts(filter, page, pageSize)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch vulnerability alerts")
+	}
+
+	return response.OK(c, ListAlertsData{
+		Alerts:   ToAlertResponseList(alerts),
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
+
+// acknowledge handles both acknowledgement routes. The stack route only
+// reaches alerts raised for the stack in its path, which its authz rule has
+// already checked.
+func (h *APIHandler) acknowledge(c echo.Context, stackScoped bool) error {
+	alertID, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req AcknowledgeRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	alert, err := h.service.GetAlert(alertID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response.NotFound(c, "Vulnerability alert not found")
+		}
+		return response.Internal(c, "Failed to fetch vulnerability alert")
+	}
+
+	if stackScoped {
+		serverID, err := echoparams.ParseUintParam(c, "serverid")
+		if err != nil {
+			return err
+		}
+		if alert.ServerID != serverID || alert.StackName != c.Param("stackname") {
+			return response.NotFound(c, "Vulnerability alert not found")
+		}
+	}
+
+	if err := h.service.Acknowledge(alert, p.UserID(), req.Note); err != nil {
+		return response.Internal(c, "Failed to acknowledge vulnerability alert")
+	}
+
+	actorID := p.UserID()
+	serverID := alert.ServerID
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      security.EventVulnscanAlertAcknowledged,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetType:     security.TargetTypeVulnAlert,
+		TargetID:       &alert.ID,
+		TargetName:     alert.RuleName,
+		ServerID:       &serverID,
+		StackName:      alert.StackName,
+		Success:        true,
+		Metadata: map[string]any{
+			"rule_id":          alert.RuleID,
+			"scan_id":          alert.ScanID,
+			"highest_severity": alert.HighestSeverity,
+			"note":             alert.AcknowledgementNote,
+		},
+	})
+
+	return response.OK(c, GetAlertData{
+		Alert: ToAlertResponse(alert),
+	})
+}
+
+// loadRule resolves the rule named in the path. A nil rule with a nil error
+// means a response has already been written.
+func (h *APIHandler) loadRule(c echo.Context) (authz.Principal, *AlertRule, error) {
+	ruleID, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	rule, err := h.service.GetRule(ruleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return p, nil, response.NotFound(c, "Vulnerability alert rule not found")
+		}
+		return p, nil, response.Internal(c, "Failed to fetch vulnerability alert rule")
+	}
+
+	return p, rule, nil
+}
+
+func (h *APIHandler) auditRule(c echo.Context, p authz.Principal, eventType string, rule *AlertRule) {
+	actorID := p.UserID()
+	ruleID := rule.ID
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      eventType,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetType:     security.TargetTypeVulnAlertRule,
+		TargetID:       &ruleID,
+		TargetName:     rule.Name,
+		ServerID:       rule.ServerID,
+		Success:        true,
+		Metadata: map[string]any{
+			"stack_pattern":    rule.StackPattern,
+			"condition":        rule.Condition,
+			"min_severity":     rule.MinSeverity,
+			"email_recipients": decodeList(rule.EmailRecipients),
+			"webhook":          rule.WebhookURL != "",
+			"enabled":          rule.Enabled,
+		},
+	})
+}
+
+func intQueryParam(c echo.Context, name string, fallback int) int {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return fallback
+	}
+	return value
+}
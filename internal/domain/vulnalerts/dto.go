@@ -0,0 +1,228 @@
+package vulnalerts
+
+import (
+	"encoding/json"
+	"errors"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"berth/internal/domain/vulnscan"
+)
+
+const maxNoteLength = 1000
+
+// Severities lists the severities a rule can use as its threshold.
+var Severities = []string{
+	vulnscan.VulnSeverityCritical,
+	vulnscan.VulnSeverityHigh,
+	vulnscan.VulnSeverityMedium,
+	vulnscan.VulnSeverityLow,
+}
+
+var (
+	ErrNameRequired        = errors.New("name is required")
+	ErrStackPatternInvalid = errors.New("stack_pattern must not contain '/' or whitespace")
+	ErrConditionInvalid    = errors.New("condition must be any or new")
+	ErrSeverityInvalid     = errors.New("min_severity must be Critical, High, Medium or Low")
+	ErrRecipientInvalid    = errors.New("email_recipients must be valid email addresses")
+	ErrWebhookURLInvalid   = errors.New("webhook_url must be an absolute http or https URL")
+	ErrNoteTooLong         = errors.New("note must be at most 1000 characters")
+)
+
+// RuleRequest creates or replaces a rule. A rule with no email recipients and
+// no webhook URL still records alerts.
+type RuleRequest struct {
+	Name            string   `json:"name"`
+	ServerID        *uint    `json:"server_id,omitempty"`
+	StackPattern    string   `json:"stack_pattern,omitempty"`
+	Condition       string   `json:"condition"`
+	MinSeverity     string   `json:"min_severity"`
+	EmailRecipients []string `json:"email_recipients,omitempty"`
+	WebhookURL      string   `json:"webhook_url,omitempty"`
+	Enabled         *bool    `json:"enabled,omitempty"`
+}
+
+func (r *RuleRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrNameRequired
+	}
+	if strings.ContainsAny(r.StackPattern, "/ \t\r\n") {
+		return ErrStackPatternInvalid
+	}
+	if r.Condition != ConditionAny && r.Condition != ConditionNew {
+		return ErrConditionInvalid
+	}
+	if !slices.Contains(Severities, r.MinSeverity) {
+		return ErrSeverityInvalid
+	}
+	for _, recipient := range r.EmailRecipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return ErrRecipientInvalid
+		}
+	}
+	if r.WebhookURL != "" {
+		u, err := url.Parse(strings.TrimSpace(r.WebhookURL))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrWebhookURLInvalid
+		}
+	}
+	return nil
+}
+
+type AcknowledgeRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+func (r *AcknowledgeRequest) Validate() error {
+	if len(r.Note) > maxNoteLength {
+		return ErrNoteTooLong
+	}
+	return nil
+}
+
+type AlertRuleInfo struct {
+	ID              uint      `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Name            string    `json:"name"`
+	ServerID        *uint     `json:"server_id"`
+	StackPattern    string    `json:"stack_pattern"`
+	Condition       string    `json:"condition"`
+	MinSeverity     string    `json:"min_severity"`
+	EmailRecipients []string  `json:"email_recipients"`
+	WebhookURL      string    `json:"webhook_url,omitempty"`
+	Enabled         bool      `json:"enabled"`
+	CreatedByUserID *uint     `json:"created_by_user_id,omitempty"`
+}
+
+type AlertInfo struct {
+	ID                   uint       `json:"id"`
+	CreatedAt            time.Time  `json:"created_at"`
+	RuleID               uint       `json:"rule_id"`
+	RuleName             string     `json:"rule_name"`
+	ServerID             uint       `json:"server_id"`
+	StackName            string     `json:"stack_name"`
+	ScanID               uint       `json:"scan_id"`
+	BaseScanID           *uint      `json:"base_scan_id,omitempty"`
+	Condition            string     `json:"condition"`
+	MinSeverity          string     `json:"min_severity"`
+	HighestSeverity      string     `json:"highest_severity"`
+	VulnerabilityIDs     []string   `json:"vulnerability_ids"`
+	NewVulnerabilityIDs  []string   `json:"new_vulnerability_ids"`
+	NotifiedAt           *time.Time `json:"notified_at,omitempty"`
+	NotificationError    string     `json:"notification_error,omitempty"`
+	AcknowledgedAt       *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedByUserID *uint      `json:"acknowledged_by_user_id,omitempty"`
+	AcknowledgementNote  string     `json:"acknowledgement_note,omitempty"`
+}
+
+type ListRulesData struct {
+	Rules []AlertRuleInfo `json:"rules"`
+}
+
+type GetRuleData struct {
+	Rule AlertRuleInfo `json:"rule"`
+}
+
+type DeleteRuleMessageData struct {
+	Message string `json:"message"`
+}
+
+type ListAlertsData struct {
+	Alerts   []AlertInfo `json:"alerts"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+}
+
+type GetAlertData struct {
+	Alert AlertInfo `json:"alert"`
+}
+
+// AlertPayload is the JSON body POSTed to a rule's webhook URL.
+type AlertPayload struct {
+	Event string    `json:"event"`
+	Alert AlertInfo `json:"alert"`
+	URL   string    `json:"url"`
+}
+
+func ToRuleResponse(r *AlertRule) AlertRuleInfo {
+	return AlertRuleInfo{
+		ID:              r.ID,
+		CreatedAt:       r.CreatedAt,
+		UpdatedAt:       r.UpdatedAt,
+		Name:            r.Name,
+		ServerID:        r.ServerID,
+		StackPattern:    r.StackPattern,
+		Condition:       r.Condition,
+		MinSeverity:     r.MinSeverity,
+		EmailRecipients: decodeList(r.EmailRecipients),
+		WebhookURL:      r.WebhookURL,
+		Enabled:         r.Enabled,
+		CreatedByUserID: r.CreatedByUserID,
+	}
+}
+
+func ToRuleResponseList(rules []AlertRule) []AlertRuleInfo {
+	result := make([]AlertRuleInfo, len(rules))
+	for i := range rules {
+		result[i] = ToRuleResponse(&rules[i])
+	}
+	return result
+}
+
+func ToAlertResponse(a *Alert) AlertInfo {
+	return AlertInfo{
+		ID:                   a.ID,
+		CreatedAt:            a.CreatedAt,
+		RuleID:               a.RuleID,
+		RuleName:             a.RuleName,
+		ServerID:             a.ServerID,
+		StackName:            a.StackName,
+		ScanID:               a.ScanID,
+		BaseScanID:           a.BaseScanID,
+		Condition:            a.Condition,
+		MinSeverity:          a.MinSeverity,
+		HighestSeverity:      a.HighestSeverity,
+		VulnerabilityIDs:     decodeList(a.VulnerabilityIDs),
+		NewVulnerabilityIDs:  decodeList(a.NewVulnerabilityIDs),
+		NotifiedAt:           a.NotifiedAt,
+		NotificationError:    a.NotificationError,
+		AcknowledgedAt:       a.AcknowledgedAt,
+		AcknowledgedByUserID: a.AcknowledgedByUserID,
+		AcknowledgementNote:  a.AcknowledgementNote,
+	}
+}
+
+func ToAlertResponseList(alerts []Alert) []AlertInfo {
+	result := make([]AlertInfo, len(alerts))
+	for i := range alerts {
+		result[i] = ToAlertResponse(&alerts[i])
+	}
+	return result
+}
+
+func encodeList(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+func decodeList(raw string) []string {
+	values := []string{}
+	if raw == "" {
+		return values
+	}
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return []string{}
+	}
+	return values
+}
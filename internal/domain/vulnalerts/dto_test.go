@@ -0,0 +1,43 @@
+package vulnalerts
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRuleRequest_Validate(t *testing.T) {
+	valid := func() RuleRequest {
+		return RuleRequest{Name: "criticals", Condition: ConditionAny, MinSeverity: "Critical"}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*RuleRequest)
+		wantErr error
+	}{
+		{"valid", func(*RuleRequest) {}, nil},
+		{"with channels", func(r *RuleRequest) {
+			r.EmailRecipients = []string{"sec@example.com"}
+			r.WebhookURL = "https://hooks.example.com/alerts"
+		}, nil},
+		{"blank name", func(r *RuleRequest) { r.Name = " " }, ErrNameRequired},
+		{"pattern with slash", func(r *RuleRequest) { r.StackPattern = "a/b" }, ErrStackPatternInvalid},
+		{"unknown condition", func(r *RuleRequest) { r.Condition = "worse" }, ErrConditionInvalid},
+		{"negligible threshold", func(r *RuleRequest) { r.MinSeverity = "Negligible" }, ErrSeverityInvalid},
+		{"lower-case severity", func(r *RuleRequest) { r.MinSeverity = "high" }, ErrSeverityInvalid},
+		{"bad recipient", func(r *RuleRequest) { r.EmailRecipients = []string{"not-an-address"} }, ErrRecipientInvalid},
+		{"relative webhook", func(r *RuleRequest) { r.WebhookURL = "/alerts" }, ErrWebhookURLInvalid},
+		{"non-http webhook", func(r *RuleRequest) { r.WebhookURL = "ftp://example.com" }, ErrWebhookURLInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.mutate(&req)
+			got := req.Validate()
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package vulnalerts
+
+import (
+	"time"
+
+	"berth/internal/platform/db"
+)
+
+const (
+	// ConditionAny fires when the scan has any vulnerability at or above the
+	// rule's severity.
+	ConditionAny = "any"
+	// ConditionNew fires only for vulnerabilities at or above the rule's
+	// severity that the previous comparable scan did not have.
+	ConditionNew = "new"
+)
+
+// AlertRule raises alerts for completed scans of the stacks matching
+// StackPattern. A nil ServerID applies the rule to every server.
+type AlertRule struct {
+	db.BaseModel
+	Name            string `json:"name" gorm:"not null"`
+	ServerID        *uint  `json:"server_id" gorm:"index"`
+	StackPattern    string `json:"stack_pattern" gorm:"not null"`
+	Condition       string `json:"condition" gorm:"not null"`
+	MinSeverity     string `json:"min_severity" gorm:"not null"`
+	EmailRecipients string `json:"-" gorm:"type:text"`
+	WebhookURL      string `json:"webhook_url" gorm:"type:text"`
+	Enabled         bool   `json:"enabled" gorm:"not null"`
+	CreatedByUserID *uint  `json:"created_by_user_id"`
+}
+
+func (AlertRule) TableName() string {
+	return "vulnerability_alert_rules"
+}
+
+// Alert records a rule firing for a scan. VulnerabilityIDs lists every
+// matching vulnerability; NewVulnerabilityIDs the subset introduced since
+// BaseScanID, or all of them when there was no earlier scan to compare with.
+type Alert struct {
+	db.BaseModel
+	RuleID               uint       `json:"rule_id" gorm:"not null;index"`
+	RuleName             string     `json:"rule_name" gorm:"not null"`
+	ServerID             uint       `json:"server_id" gorm:"not null;index:idx_vuln_alert_server_stack"`
+	StackName            string     `json:"stack_name" gorm:"not null;index:idx_vuln_alert_server_stack"`
+	ScanID               uint       `json:"scan_id" gorm:"not null;index"`
+	BaseScanID           *uint      `json:"base_scan_id"`
+	Condition            string     `json:"condition" gorm:"not null"`
+	MinSeverity          string     `json:"min_severity" gorm:"not null"`
+	HighestSeverity      string     `json:"highest_severity" gorm:"not null"`
+	VulnerabilityIDs     string     `json:"-" gorm:"type:text"`
+	NewVulnerabilityIDs  string     `json:"-" gorm:"type:text"`
+	NotifiedAt           *time.Time `json:"notified_at"`
+	NotificationError    string     `json:"notification_error" gorm:"type:text"`
+	AcknowledgedAt       *time.Time `json:"acknowledged_at" gorm:"index"`
+	AcknowledgedByUserID *uint      `json:"acknowledged_by_user_id"`
+	AcknowledgementNote  string     `json:"acknowledgement_note" gorm:"type:text"`
+}
+
+func (Alert) TableName() string {
+	return "vulnerability_alerts"
+}
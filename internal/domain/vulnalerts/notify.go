@@ -0,0 +1,140 @@
+package vulnalerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PayloadEvent identifies alert payloads POSTed to rule webhook URLs.
+	PayloadEvent = "vulnerability.alert"
+
+	templateName = "vulnerability_alert"
+
+	// maxEmailedIDs keeps alert emails readable; the full list is in the API.
+	maxEmailedIDs = 25
+)
+
+// notify sends the alert to the rule's channels and records the outcome on
+// the alert. A failing channel does not stop the others.
+func (s *Service) notify(ctx context.Context, rule *AlertRule, alert *Alert) {
+	recipients := decodeList(rule.EmailRecipients)
+	if len(recipients) == 0 && rule.WebhookURL == "" {
+		return
+	}
+
+	stackURL := fmt.Sprintf("%s/servers/%d/stacks/%s", s.appURL, alert.ServerID, url.PathEscape(alert.StackName))
+
+	var errs []error
+	if len(recipients) > 0 {
+		if err := s.sendEmail(recipients, alert, stackURL); err != nil {
+			errs = append(errs, fmt.Errorf("email: %w", err))
+		}
+	}
+	if rule.WebhookURL != "" {
+		if err := s.post(ctx, rule.WebhookURL, alert, stackURL); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+
+	now := s.now()
+	alert.NotifiedAt = &now
+	if err := errors.Join(errs...); err != nil {
+		alert.NotificationError = err.Error()
+		s.logger.Warn("vulnerability alert notification failed",
+			zap.Error(err),
+			zap.Uint("alert_id", alert.ID),
+		)
+	}
+
+	if err := s.db.Model(alert).Updates(map[string]any{
+		"notified_at":        alert.NotifiedAt,
+		"notification_error": alert.NotificationError,
+	}).Error; err != nil {
+		s.logger.Error("failed to record vulnerability alert notification",
+			zap.Error(err),
+			zap.Uint("alert_id", alert.ID),
+		)
+	}
+}
+
+func (s *Service) sendEmail(recipients []string, alert *Alert, stackURL string) error {
+	serverName := fmt.Sprintf("server %d", alert.ServerID)
+	if srv, err := s.serverSvc.GetServer(alert.ServerID); err == nil {
+		serverName = srv.Name
+	}
+
+	ids, moreIDs := truncateIDs(decodeList(alert.VulnerabilityIDs))
+	newIDs, moreNewIDs := truncateIDs(decodeList(alert.NewVulnerabilityIDs))
+
+	subject := fmt.Sprintf("[%s] Vulnerability alert: %s on %s", alert.HighestSeverity, alert.StackName, serverName)
+	return s.mailer.SendTemplate(templateName, recipients, subject, map[string]any{
+		"RuleName":            alert.RuleName,
+		"ServerName":          serverName,
+		"StackName":           alert.StackName,
+		"Condition":           describeCondition(alert.Condition, alert.MinSeverity),
+		"HighestSeverity":     alert.HighestSeverity,
+		"VulnerabilityIDs":    ids,
+		"MoreCount":           moreIDs,
+		"NewVulnerabilityIDs": newIDs,
+		"MoreNewCount":        moreNewIDs,
+		"StackURL":            stackURL,
+		"AppName":             s.appName,
+	})
+}
+
+func (s *Service) post(ctx context.Context, target string, alert *Alert, stackURL string) error {
+	body, err := json.Marshal(AlertPayload{
+		Event: PayloadEvent,
+		Alert: ToAlertResponse(alert),
+		URL:   stackURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Berth-Vulnerability-Alerts")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("endpoint responded with status " + strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
+
+// truncateIDs caps the IDs listed in an email, returning how many were left
+// out.
+func truncateIDs(ids []string) ([]string, int) {
+	if len(ids) <= maxEmailedIDs {
+		return ids, 0
+	}
+	return ids[:maxEmailedIDs], len(ids) - maxEmailedIDs
+}
+
+func describeCondition(condition, severity string) string {
+	if condition == ConditionNew {
+		return fmt.Sprintf("new %s or higher vulnerabilities since the previous scan", strings.ToLower(severity))
+	}
+	return fmt.Sprintf("any %s or higher vulnerability", strings.ToLower(severity))
+}
@@ -0,0 +1,21 @@
+package vulnalerts
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
+
+func (h *APIHandler) RegisterProtectedAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/servers/:serverid/stacks/:stackname/vulnerability-alerts", h.ListStackAlerts, authz.Stack(permnames.StacksRead))
+	reg.POST("/servers/:serverid/stacks/:stackname/vulnerability-alerts/:id/acknowledge", h.AcknowledgeStackAlert, authz.Stack(permnames.StacksManage))
+}
+
+func (h *APIHandler) RegisterAdminAPIRoutes(reg *authz.Registrar) {
+	reg.GET("/vulnerability-alert-rules", h.ListRules, authz.Admin(permnames.AdminServersRead))
+	reg.GET("/vulnerability-alert-rules/:id", h.GetRule, authz.Admin(permnames.AdminServersRead))
+	reg.POST("/vulnerability-alert-rules", h.CreateRule, authz.Admin(permnames.AdminServersWrite))
+	reg.PUT("/vulnerability-alert-rules/:id", h.UpdateRule, authz.Admin(permnames.AdminServersWrite))
+	reg.DELETE("/vulnerability-alert-rules/:id", h.DeleteRule, authz.Admin(permnames.AdminServersWrite))
+	reg.GET("/vulnerability-alerts", h.ListAlerts, authz.Admin(permnames.AdminServersRead))
+	reg.POST("/vulnerability-alerts/:id/acknowledge", h.AcknowledgeAlert, authz.Admin(permnames.AdminServersWrite))
+}
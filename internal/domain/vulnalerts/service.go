@@ -0,0 +1,419 @@
+package vulnalerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/server"
+	"berth/internal/domain/vulnscan"
+	"berth/internal/pkg/patterns"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	evaluationTimeout   = 2 * time.Minute
+	notificationTimeout = 10 * time.Second
+)
+
+type alertScanSource interface {
+	GetScan(ctx context.Context, p authz.Principal, scanID uint) (*vulnscan.ImageScan, error)
+	PreviousComparableScan(scan *vulnscan.ImageScan) (*vulnscan.ImageScan, error)
+	CompareScans(ctx context.Context, p authz.Principal, baseScanID, compareScanID uint) (*vulnscan.ScanComparison, error)
+}
+
+type alertServerProvider interface {
+	GetServer(id uint) (*server.Server, error)
+}
+
+type alertMailer interface {
+	SendTemplate(templateName string, to []string, subject string, data map[string]any) error
+}
+
+// AlertFilter narrows ListAlerts. Zero values match everything.
+type AlertFilter struct {
+	ServerID     *uint
+	StackName    string
+	Acknowledged *bool
+}
+
+type Service struct {
+	db        *gorm.DB
+	scanSvc   alertScanSource
+	serverSvc alertServerProvider
+	mailer    alertMailer
+	client    *http.Client
+	appName   string
+	appURL    string
+	logger    *zap.Logger
+	now       func() time.Time
+}
+
+func NewService(db *gorm.DB, scanSvc alertScanSource, serverSvc alertServerProvider, mailer alertMailer, appName, appURL string, logger *zap.Logger) *Service {
+	return &Service{
+		db:        db,
+		scanSvc:   scanSvc,
+		serverSvc: serverSvc,
+		mailer:    mailer,
+		client:    &http.Client{Timeout: notificationTimeout},
+		appName:   appName,
+		appURL:    strings.TrimRight(appURL, "/"),
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+func (s *Service) Logger() *zap.Logger {
+	return s.logger
+}
+
+func (s *Service) ListRules() ([]AlertRule, error) {
+	var rules []AlertRule
+	if err := s.db.Order("id").Find(&rules).Error; err != nil {
+		s.logger.Error("failed to list vulnerability alert rules", zap.Error(err))
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (s *Service) GetRule(id uint) (*AlertRule, error) {
+	var rule AlertRule
+	if err := s.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *Service) CreateRule(req RuleRequest, createdBy uint) (*AlertRule, error) {
+	rule := AlertRule{
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	if createdBy != 0 {
+		rule.CreatedByUserID = &createdBy
+	}
+	applyRequest(&rule, req)
+
+	if err := s.db.Create(&rule).Error; err != nil {
+		s.logger.Error("failed to create vulnerability alert rule",
+			zap.Error(err),
+			zap.String("stack_pattern", rule.StackPattern),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("vulnerability alert rule created",
+		zap.Uint("rule_id", rule.ID),
+		zap.String("stack_pattern", rule.StackPattern),
+		zap.String("condition", rule.Condition),
+		zap.String("min_severity", rule.MinSeverity),
+	)
+
+	return &rule, nil
+}
+
+func (s *Service) UpdateRule(id uint, req RuleRequest) (*AlertRule, error) {
+	rule, err := s.GetRule(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	applyRequest(rule, req)
+
+	if err := s.db.Save(rule).Error; err != nil {
+		s.logger.Error("failed to update vulnerability alert rule",
+			zap.Error(err),
+			zap.Uint("rule_id", id),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("vulnerability alert rule updated",
+		zap.Uint("rule_id", id),
+		zap.Bool("enabled", rule.Enabled),
+	)
+
+	return rule, nil
+}
+
+// DeleteRule removes a rule. Alerts it raised are kept.
+func (s *Service) DeleteRule(id uint) error {
+	rule, err := s.GetRule(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(rule).Error; err != nil {
+		s.logger.Error("failed to delete vulnerability alert rule",
+			zap.Error(err),
+			zap.Uint("rule_id", id),
+		)
+		return err
+	}
+
+	s.logger.Info("vulnerability alert rule deleted", zap.Uint("rule_id", id))
+	return nil
+}
+
+func applyRequest(rule *AlertRule, req RuleRequest) {
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.ServerID = req.ServerID
+	rule.StackPattern = normalisePattern(req.StackPattern)
+	rule.Condition = req.Condition
+	rule.MinSeverity = req.MinSeverity
+	rule.EmailRecipients = encodeList(req.EmailRecipients)
+	rule.WebhookURL = strings.TrimSpace(req.WebhookURL)
+}
+
+func (s *Service) ListAlerts(filter AlertFilter, page, pageSize int) ([]Alert, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 25
+	}
+
+	query := s.db.Model(&Alert{})
+	if filter.ServerID != nil {
+		query = query.Where("server_id = ?", *filter.ServerID)
+	}
+	if filter.StackName != "" {
+		query = query.Where("stack_name = ?", filter.StackName)
+	}
+	if filter.Acknowledged != nil {
+		if *filter.Acknowledged {
+			query = query.Where("acknowledged_at IS NOT NULL")
+		} else {
+			query = query.Where("acknowledged_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var alerts []Alert
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&alerts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return alerts, total, nil
+}
+
+func (s *Service) GetAlert(id uint) (*Alert, error) {
+	var alert Alert
+	if err := s.db.First(&alert, id).Error; err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// Acknowledge marks the alert as handled. Acknowledging an alert again
+// replaces the note and acknowledging user.
+func (s *Service) Acknowledge(alert *Alert, userID uint, note string) error {
+	now := s.now()
+	alert.AcknowledgedAt = &now
+	alert.AcknowledgedByUserID = &userID
+	alert.AcknowledgementNote = strings.TrimSpace(note)
+
+	if err := s.db.Model(alert).Updates(map[string]any{
+		"acknowledged_at":         alert.AcknowledgedAt,
+		"acknowledged_by_user_id": alert.AcknowledgedByUserID,
+		"acknowledgement_note":    alert.AcknowledgementNote,
+	}).Error; err != nil {
+		s.logger.Error("failed to acknowledge vulnerability alert",
+			zap.Error(err),
+			zap.Uint("alert_id", alert.ID),
+		)
+		return err
+	}
+	return nil
+}
+
+// OnScanComplete evaluates the alert rules for a completed scan in the
+// background, since rules may need a scan comparison and notifications.
+func (s *Service) OnScanComplete(scan *vulnscan.ImageScan, _ *vulnscan.VulnerabilitySummary) {
+	completed := *scan
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), evaluationTimeout)
+		defer cancel()
+		if _, err := s.EvaluateScan(ctx, &completed); err != nil {
+			s.logger.Error("failed to evaluate vulnerability alert rules",
+				zap.Error(err),
+				zap.Uint("scan_id", completed.ID),
+			)
+		}
+	}()
+}
+
+// EvaluateScan records and sends an alert for every enabled rule the
+// completed scan trips.
+func (s *Service) EvaluateScan(ctx context.Context, scan *vulnscan.ImageScan) ([]Alert, error) {
+	rules, err := s.matchingRules(scan.ServerID, scan.StackName)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	full, err := s.scanSvc.GetScan(ctx, authz.SystemPrincipal, scan.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scan: %w", err)
+	}
+
+	baseScanID, newVulns, comparable := s.newVulnerabilities(ctx, full)
+
+	var raised []Alert
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Condition == ConditionNew && !comparable {
+			continue
+		}
+
+		candidates := full.Vulnerabilities
+		if rule.Condition == ConditionNew {
+			candidates = newVulns
+		}
+		matching := atOrAbove(candidates, rule.MinSeverity)
+		if len(matching) == 0 {
+			continue
+		}
+		introduced := matching
+		if rule.Condition == ConditionAny && comparable {
+			introduced = atOrAbove(newVulns, rule.MinSeverity)
+		}
+
+		alert := Alert{
+			RuleID:              rule.ID,
+			RuleName:            rule.Name,
+			ServerID:            full.ServerID,
+			StackName:           full.StackName,
+			ScanID:              full.ID,
+			BaseScanID:          baseScanID,
+			Condition:           rule.Condition,
+			MinSeverity:         rule.MinSeverity,
+			HighestSeverity:     matching[0].Severity,
+			VulnerabilityIDs:    encodeList(vulnerabilityIDs(matching)),
+			NewVulnerabilityIDs: encodeList(vulnerabilityIDs(introduced)),
+		}
+		if err := s.db.Create(&alert).Error; err != nil {
+			s.logger.Error("failed to record vulnerability alert",
+				zap.Error(err),
+				zap.Uint("rule_id", rule.ID),
+				zap.Uint("scan_id", full.ID),
+			)
+			continue
+		}
+
+		s.logger.Info("vulnerability alert raised",
+			zap.Uint("alert_id", alert.ID),
+			zap.Uint("rule_id", rule.ID),
+			zap.Uint("scan_id", full.ID),
+			zap.String("highest_severity", alert.HighestSeverity),
+		)
+
+		s.notify(ctx, rule, &alert)
+		raised = append(raised, alert)
+	}
+
+	return raised, nil
+}
+
+func (s *Service) matchingRules(serverID uint, stackName string) ([]AlertRule, error) {
+	var rules []AlertRule
+	if err := s.db.Where("enabled = ? AND (server_id IS NULL OR server_id = ?)", true, serverID).
+		Order("id").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to query vulnerability alert rules: %w", err)
+	}
+
+	matched := rules[:0]
+	for _, rule := range rules {
+		if patterns.Matches(stackName, rule.StackPattern) {
+			matched = append(matched, rule)
+		}
+	}
+	return matched, nil
+}
+
+// newVulnerabilities diffs the scan against the previous comparable scan
+// with CompareScans. A stack's first scan has no baseline, so everything in
+// it is new. comparable is false when an earlier scan exists but cannot be
+// compared, in which case "new" rules are skipped.
+func (s *Service) newVulnerabilities(ctx context.Context, scan *vulnscan.ImageScan) (*uint, []vulnscan.ImageVulnerability, bool) {
+	previous, err := s.scanSvc.PreviousComparableScan(scan)
+	if err != nil {
+		s.logger.Warn("failed to find previous scan for vulnerability alerts",
+			zap.Error(err),
+			zap.Uint("scan_id", scan.ID),
+		)
+		return nil, nil, false
+	}
+	if previous == nil {
+		return nil, scan.Vulnerabilities, true
+	}
+
+	comparison, err := s.scanSvc.CompareScans(ctx, authz.SystemPrincipal, previous.ID, scan.ID)
+	if err != nil {
+		var notComparable *vulnscan.ScansNotComparableError
+		if !errors.As(err, &notComparable) {
+			s.logger.Warn("failed to compare scans for vulnerability alerts",
+				zap.Error(err),
+				zap.Uint("scan_id", scan.ID),
+				zap.Uint("base_scan_id", previous.ID),
+			)
+		}
+		return nil, nil, false
+	}
+
+	baseScanID := previous.ID
+	return &baseScanID, comparison.NewVulns, true
+}
+
+// atOrAbove returns the vulnerabilities at or above threshold, most severe
+// first.
+func atOrAbove(vulns []vulnscan.ImageVulnerability, threshold string) []vulnscan.ImageVulnerability {
+	var matched []vulnscan.ImageVulnerability
+	for _, v := range vulns {
+		if vulnscan.SeverityAtLeast(v.Severity, threshold) {
+			matched = append(matched, v)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return !vulnscan.SeverityAtLeast(matched[j].Severity, matched[i].Severity)
+	})
+	return matched
+}
+
+// vulnerabilityIDs returns the distinct IDs in vulns, keeping their order.
+func vulnerabilityIDs(vulns []vulnscan.ImageVulnerability) []string {
+	seen := make(map[string]bool, len(vulns))
+	ids := make([]string, 0, len(vulns))
+	for _, v := range vulns {
+		if seen[v.VulnerabilityID] {
+			continue
+		}
+		seen[v.VulnerabilityID] = true
+		ids = append(ids, v.VulnerabilityID)
+	}
+	return ids
+}
+
+func normalisePattern(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return "*"
+	}
+	return pattern
+}
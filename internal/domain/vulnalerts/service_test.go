@@ -0,0 +1,259 @@
+package vulnalerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/server"
+	"berth/internal/domain/vulnscan"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type fakeScans struct {
+	scans      map[uint]*vulnscan.ImageScan
+	previous   *vulnscan.ImageScan
+	comparison *vulnscan.ScanComparison
+	compareErr error
+	compared   [][2]uint
+}
+
+func (f *fakeScans) GetScan(_ context.Context, _ authz.Principal, scanID uint) (*vulnscan.ImageScan, error) {
+	scan, ok := f.scans[scanID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return scan, nil
+}
+
+func (f *fakeScans) PreviousComparableScan(*vulnscan.ImageScan) (*vulnscan.ImageScan, error) {
+	return f.previous, nil
+}
+
+func (f *fakeScans) CompareScans(_ context.Context, _ authz.Principal, baseScanID, compareScanID uint) (*vulnscan.ScanComparison, error) {
+	f.compared = append(f.compared, [2]uint{baseScanID, compareScanID})
+	if f.compareErr != nil {
+		return nil, f.compareErr
+	}
+	return f.comparison, nil
+}
+
+type fakeServers struct{}
+
+func (fakeServers) GetServer(id uint) (*server.Server, error) {
+	srv := &server.Server{Name: "prod"}
+	srv.ID = id
+	return srv, nil
+}
+
+type sentMail struct {
+	template string
+	to       []string
+	subject  string
+	data     map[string]any
+}
+
+type fakeMailer struct {
+	sent []sentMail
+}
+
+func (f *fakeMailer) SendTemplate(templateName string, to []string, subject string, data map[string]any) error {
+	f.sent = append(f.sent, sentMail{template: templateName, to: to, subject: subject, data: data})
+	return nil
+}
+
+func vuln(id, severity string) vulnscan.ImageVulnerability {
+	return vulnscan.ImageVulnerability{VulnerabilityID: id, Severity: severity, ImageName: "nginx:latest", Package: "openssl"}
+}
+
+func completedScan(id uint, stackName string, vulns ...vulnscan.ImageVulnerability) *vulnscan.ImageScan {
+	scan := &vulnscan.ImageScan{ServerID: 1, StackName: stackName, Status: vulnscan.ScanStatusCompleted, Vulnerabilities: vulns}
+	scan.ID = id
+	return scan
+}
+
+func newTestService(t *testing.T, scans *fakeScans) (*Service, *fakeMailer) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:vulnalerts_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&AlertRule{}, &Alert{}))
+
+	mailer := &fakeMailer{}
+	svc := NewService(db, scans, fakeServers{}, mailer, "berth", "https://berth.example.com", zap.NewNop())
+	return svc, mailer
+}
+
+func createRule(t *testing.T, svc *Service, req RuleRequest) *AlertRule {
+	t.Helper()
+	require.NoError(t, req.Validate())
+	rule, err := svc.CreateRule(req, 1)
+	require.NoError(t, err)
+	return rule
+}
+
+func TestEvaluateScan_AnyRuleRecordsMatchingVulnerabilitiesAndEmails(t *testing.T) {
+	scan := completedScan(5, "web", vuln("CVE-1", "High"), vuln("CVE-2", "Critical"), vuln("CVE-3", "Low"), vuln("CVE-2", "Critical"))
+	scans := &fakeScans{scans: map[uint]*vulnscan.ImageScan{5: scan}}
+	svc, mailer := newTestService(t, scans)
+	createRule(t, svc, RuleRequest{
+		Name:            "high and above",
+		StackPattern:    "web",
+		Condition:       ConditionAny,
+		MinSeverity:     vulnscan.VulnSeverityHigh,
+		EmailRecipients: []string{"sec@example.com"},
+	})
+	createRule(t, svc, RuleRequest{Name: "other stacks", StackPattern: "db", Condition: ConditionAny, MinSeverity: vulnscan.VulnSeverityLow})
+
+	alerts, err := svc.EvaluateScan(context.Background(), scan)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+
+	alert := ToAlertResponse(&alerts[0])
+	assert.Equal(t, "high and above", alert.RuleName)
+	assert.Equal(t, vulnscan.VulnSeverityCritical, alert.HighestSeverity)
+	assert.Equal(t, []string{"CVE-2", "CVE-1"}, alert.VulnerabilityIDs, "IDs are distinct and most severe first")
+	assert.Equal(t, alert.VulnerabilityIDs, alert.NewVulnerabilityIDs, "a first scan has no baseline so everything is new")
+	assert.Nil(t, alert.BaseScanID)
+	assert.NotNil(t, alert.NotifiedAt)
+	assert.Empty(t, alert.NotificationError)
+
+	require.Len(t, mailer.sent, 1)
+	assert.Equal(t, templateName, mailer.sent[0].template)
+	assert.Equal(t, []string{"sec@example.com"}, mailer.sent[0].to)
+	assert.Equal(t, "[Critical] Vulnerability alert: web on prod", mailer.sent[0].subject)
+	assert.Equal(t, "https://berth.example.com/servers/1/stacks/web", mailer.sent[0].data["StackURL"])
+}
+
+func TestEvaluateScan_NewRuleUsesScanComparison(t *testing.T) {
+	scan := completedScan(9, "web", vuln("CVE-OLD", "High"), vuln("CVE-NEW", "High"), vuln("CVE-MED", "Medium"))
+	previous := completedScan(4, "web", vuln("CVE-OLD", "High"))
+	scans := &fakeScans{
+		scans:    map[uint]*vulnscan.ImageScan{9: scan},
+		previous: previous,
+		comparison: &vulnscan.ScanComparison{
+			NewVulns: []vulnscan.ImageVulnerability{vuln("CVE-NEW", "High"), vuln("CVE-MED", "Medium")},
+		},
+	}
+	svc, _ := newTestService(t, scans)
+	createRule(t, svc, RuleRequest{Name: "new highs", Condition: ConditionNew, MinSeverity: vulnscan.VulnSeverityHigh})
+	createRule(t, svc, RuleRequest{Name: "any high", Condition: ConditionAny, MinSeverity: vulnscan.VulnSeverityHigh})
+
+	alerts, err := svc.EvaluateScan(context.Background(), scan)
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, [][2]uint{{4, 9}}, scans.compared, "the comparison runs once against the previous scan")
+
+	newRule := ToAlertResponse(&alerts[0])
+	assert.Equal(t, []string{"CVE-NEW"}, newRule.VulnerabilityIDs)
+	assert.Equal(t, []string{"CVE-NEW"}, newRule.NewVulnerabilityIDs)
+	require.NotNil(t, newRule.BaseScanID)
+	assert.Equal(t, uint(4), *newRule.BaseScanID)
+
+	anyRule := ToAlertResponse(&alerts[1])
+	assert.Equal(t, []string{"CVE-OLD", "CVE-NEW"}, anyRule.VulnerabilityIDs, "equal severities keep scan order")
+	assert.Equal(t, []string{"CVE-NEW"}, anyRule.NewVulnerabilityIDs)
+}
+
+func TestEvaluateScan_NewRuleSkippedWhenNothingNewOrNotComparable(t *testing.T) {
+	scan := completedScan(9, "web", vuln("CVE-OLD", "Critical"))
+	scans := &fakeScans{
+		scans:      map[uint]*vulnscan.ImageScan{9: scan},
+		previous:   completedScan(4, "web", vuln("CVE-OLD", "Critical")),
+		comparison: &vulnscan.ScanComparison{},
+	}
+	svc, _ := newTestService(t, scans)
+	createRule(t, svc, RuleRequest{Name: "new criticals", Condition: ConditionNew, MinSeverity: vulnscan.VulnSeverityCritical})
+
+	alerts, err := svc.EvaluateScan(context.Background(), scan)
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+
+	scans.compareErr = &vulnscan.ScansNotComparableError{Reason: "scans cover different services"}
+	alerts, err = svc.EvaluateScan(context.Background(), scan)
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+}
+
+func TestEvaluateScan_PostsAlertToWebhookURL(t *testing.T) {
+	var received AlertPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	scan := completedScan(3, "web", vuln("CVE-1", "Critical"))
+	svc, _ := newTestService(t, &fakeScans{scans: map[uint]*vulnscan.ImageScan{3: scan}})
+	createRule(t, svc, RuleRequest{Name: "criticals", Condition: ConditionAny, MinSeverity: vulnscan.VulnSeverityCritical, WebhookURL: srv.URL})
+
+	alerts, err := svc.EvaluateScan(context.Background(), scan)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+
+	assert.Equal(t, PayloadEvent, received.Event)
+	assert.Equal(t, []string{"CVE-1"}, received.Alert.VulnerabilityIDs)
+
+	stored, err := svc.GetAlert(alerts[0].ID)
+	require.NoError(t, err)
+	assert.NotNil(t, stored.NotifiedAt)
+	assert.Contains(t, stored.NotificationError, "503")
+}
+
+func TestEvaluateScan_IgnoresDisabledRules(t *testing.T) {
+	scan := completedScan(3, "web", vuln("CVE-1", "Critical"))
+	svc, _ := newTestService(t, &fakeScans{scans: map[uint]*vulnscan.ImageScan{3: scan}})
+	disabled := false
+	createRule(t, svc, RuleRequest{Name: "off", Condition: ConditionAny, MinSeverity: vulnscan.VulnSeverityLow, Enabled: &disabled})
+
+	alerts, err := svc.EvaluateScan(context.Background(), scan)
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+}
+
+func TestAcknowledge_FiltersAlertLists(t *testing.T) {
+	scan := completedScan(3, "web", vuln("CVE-1", "Critical"))
+	svc, _ := newTestService(t, &fakeScans{scans: map[uint]*vulnscan.ImageScan{3: scan}})
+	createRule(t, svc, RuleRequest{Name: "criticals", Condition: ConditionAny, MinSeverity: vulnscan.VulnSeverityCritical})
+	createRule(t, svc, RuleRequest{Name: "highs", Condition: ConditionAny, MinSeverity: vulnscan.VulnSeverityHigh})
+
+	alerts, err := svc.EvaluateScan(context.Background(), scan)
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+
+	require.NoError(t, svc.Acknowledge(&alerts[0], 7, " patched upstream "))
+
+	stored, err := svc.GetAlert(alerts[0].ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.AcknowledgedAt)
+	require.NotNil(t, stored.AcknowledgedByUserID)
+	assert.Equal(t, uint(7), *stored.AcknowledgedByUserID)
+	assert.Equal(t, "patched upstream", stored.AcknowledgementNote)
+
+	open := false
+	pending, total, err := svc.ListAlerts(AlertFilter{Acknowledged: &open}, 1, 25)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, pending, 1)
+	assert.Equal(t, alerts[1].ID, pending[0].ID)
+
+	serverID := uint(1)
+	all, total, err := svc.ListAlerts(AlertFilter{ServerID: &serverID, StackName: "web"}, 1, 25)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, all, 2)
+}
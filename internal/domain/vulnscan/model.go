@@ -15,6 +15,13 @@ const (
 	ScanStatusTimeout   = "timeout"
 )
 
+const (
+	ScanTriggerManual      = "manual"
+	ScanTriggerSchedule    = "schedule"
+	ScanTriggerImageChange = "image-change"
+	ScanTriggerDeploy      = "deploy"
+)
+
 type ImageScan struct {
 	db.BaseModel
 	ServerID      uint       `json:"server_id" gorm:"not null;index:idx_server_stack"`
@@ -32,6 +39,7 @@ type ImageScan struct {
 	LastPollError string     `json:"last_poll_error,omitempty" gorm:"type:text"`
 
 	ServiceFilter string `json:"service_filter,omitempty" gorm:"type:text"`
+	Trigger       string `json:"trigger" gorm:"not null;default:'manual'"`
 
 	ScannerVersion string     `json:"scanner_version,omitempty"`
 	ScannerDBBuilt *time.Time `json:"scanner_db_built,omitempty"`
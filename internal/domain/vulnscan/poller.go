@@ -8,11 +8,16 @@ import (
 	"gorm.io/gorm"
 )
 
+type leaderGate interface {
+	IsLeader() bool
+}
+
 type Poller struct {
 	db       *gorm.DB
 	service  *Service
 	logger   *zap.Logger
 	interval time.Duration
+	leader   leaderGate
 	ctx      context.Context
 	cancel   context.CancelFunc
 }
@@ -30,6 +35,11 @@ func NewPoller(db *gorm.DB, service *Service, logger *zap.Logger) *Poller {
 	}
 }
 
+// SetLeaderGate makes the poller idle while another instance holds the lease.
+func (p *Poller) SetLeaderGate(g leaderGate) {
+	p.leader = g
+}
+
 func (p *Poller) Start() {
 	p.logger.Info("starting vulnerability scan poller",
 		zap.Duration("interval", p.interval),
@@ -50,6 +60,9 @@ func (p *Poller) pollLoop() {
 	for {
 		select {
 		case <-ticker.C:
+			if p.leader != nil && !p.leader.IsLeader() {
+				continue
+			}
 			p.pollActiveScans()
 		case <-p.ctx.Done():
 			p.logger.Info("vulnerability scan poller stopped")
@@ -63,13 +63,21 @@ func (e *ScanScopeConflictError) Error() string {
 	return fmt.Sprintf("a scan covering %s is already running; requested scope %s conflicts", existingScope, requestedScope)
 }
 
+// ScanCompleteListener is notified after a scan's results have been stored
+// and the scan saved as completed. Listeners run on the poller goroutine and
+// must not block.
+type ScanCompleteListener interface {
+	OnScanComplete(scan *ImageScan, summary *VulnerabilitySummary)
+}
+
 type Service struct {
-	db         *gorm.DB
-	serverSvc  vulnscanServerProvider
-	agentSvc   vulnscanAgentClient
-	authzSvc   vulnscanAuthorizer
-	logger     *zap.Logger
-	startLocks sync.Map
+	db                  *gorm.DB
+	serverSvc           vulnscanServerProvider
+	agentSvc            vulnscanAgentClient
+	authzSvc            vulnscanAuthorizer
+	logger              *zap.Logger
+	startLocks          sync.Map
+	completionListeners []ScanCompleteListener
 }
 
 func (s *Service) lockScanStart(serverID uint, stackName string) func() {
@@ -105,6 +113,12 @@ func NewService(db *gorm.DB, serverSvc vulnscanServerProvider, agentSvc vulnscan
 	}
 }
 
+// AddCompletionListener registers l for scan completion notifications. It must
+// be called during wiring, before the poller starts.
+func (s *Service) AddCompletionListener(l ScanCompleteListener) {
+	s.completionListeners = append(s.completionListeners, l)
+}
+
 type AgentScanResponse struct {
 	ID             string              `json:"id"`
 	StackName      string              `json:"stack_name"`
@@ -151,6 +165,8 @@ type AgentVulnerability struct {
 
 type StartScanOptions struct {
 	Services []string
+	// Trigger records why the scan was started; empty means ScanTriggerManual.
+	Trigger string
 }
 
 func (s *Service) StartScan(ctx context.Context, p authz.Principal, serverID uint, stackName string, opts *StartScanOptions) (*ImageScan, error) {
@@ -184,8 +200,12 @@ func (s *Service) StartScan(ctx context.Context, p authz.Principal, serverID uin
 	}
 
 	var serviceFilter string
+	trigger := ScanTriggerManual
 	if opts != nil {
 		serviceFilter = normalizeServiceFilter(opts.Services)
+		if opts.Trigger != "" {
+			trigger = opts.Trigger
+		}
 	}
 
 	unlock := s.lockScanStart(serverID, stackName)
@@ -222,6 +242,7 @@ func (s *Service) StartScan(ctx context.Context, p authz.Principal, serverID uin
 		Status:        ScanStatusPending,
 		StartedAt:     time.Now(),
 		ServiceFilter: serviceFilter,
+		Trigger:       trigger,
 	}
 
 	if err := s.db.Create(scan).Error; err != nil {
@@ -274,6 +295,7 @@ func (s *Service) StartScan(ctx context.Context, p authz.Principal, serverID uin
 	s.logger.Info("vulnerability scan started successfully",
 		zap.Uint("scan_id", scan.ID),
 		zap.String("agent_scan_id", agentResp.ID),
+		zap.String("trigger", trigger),
 		zap.Int("total_images", agentResp.TotalImages),
 	)
 
@@ -510,9 +532,32 @@ func (s *Service) PollScan(ctx context.Context, scan *ImageScan) error {
 		return err
 	}
 
+	if scan.Status == ScanStatusCompleted {
+		s.notifyScanComplete(scan)
+	}
+
 	return nil
 }
 
+func (s *Service) notifyScanComplete(scan *ImageScan) {
+	if len(s.completionListeners) == 0 {
+		return
+	}
+
+	summaries, err := s.vulnerabilitySummariesByScanID([]uint{scan.ID})
+	if err != nil {
+		s.logger.Error("failed to summarise completed scan",
+			zap.Error(err),
+			zap.Uint("scan_id", scan.ID),
+		)
+		return
+	}
+
+	for _, l := range s.completionListeners {
+		l.OnScanComplete(scan, summaries[scan.ID])
+	}
+}
+
 func (s *Service) recordPollFailure(scan *ImageScan, errMsg string) {
 	now := time.Now()
 	scan.LastPolledAt = &now
@@ -780,6 +825,23 @@ func scannerDBDiffers(base, compare *ImageScan) bool {
 	return false
 }
 
+// PreviousComparableScan returns the most recent completed scan of the same
+// stack and services that started before scan, or nil when there is none.
+func (s *Service) PreviousComparableScan(scan *ImageScan) (*ImageScan, error) {
+	var previous []ImageScan
+	if err := s.db.Where("server_id = ? AND stack_name = ? AND service_filter = ? AND status = ? AND id <> ? AND started_at <= ?",
+		scan.ServerID, scan.StackName, scan.ServiceFilter, ScanStatusCompleted, scan.ID, scan.StartedAt).
+		Order("started_at DESC").
+		Limit(1).
+		Find(&previous).Error; err != nil {
+		return nil, err
+	}
+	if len(previous) == 0 {
+		return nil, nil
+	}
+	return &previous[0], nil
+}
+
 func (s *Service) CompareScans(ctx context.Context, p authz.Principal, baseScanID, compareScanID uint) (*ScanComparison, error) {
 	baseScan, err := s.GetScan(ctx, p, baseScanID)
 	if err != nil {
@@ -927,6 +989,12 @@ func severityOrder(severity string) int {
 	}
 }
 
+// SeverityAtLeast reports whether severity is as severe as threshold or
+// more so.
+func SeverityAtLeast(severity, threshold string) bool {
+	return severityOrder(severity) <= severityOrder(threshold)
+}
+
 func sortVulnerabilities(vulns []ImageVulnerability) {
 	sort.SliceStable(vulns, func(i, j int) bool {
 		oi, oj := severityOrder(vulns[i].Severity), severityOrder(vulns[j].Severity)
@@ -0,0 +1,231 @@
+package webhooks
+
+import (
+	"errors"
+	"strconv"
+
+	"berth/internal/domain/authz"
+	"berth/internal/domain/security"
+	"berth/internal/domain/session"
+	"berth/internal/pkg/echoparams"
+	"berth/internal/pkg/response"
+	"berth/internal/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type endpointAuditLogger interface {
+	Log(event security.LogEvent) error
+}
+
+type APIHandler struct {
+	service      *Service
+	auditService endpointAuditLogger
+}
+
+func NewAPIHandler(service *Service, auditService endpointAuditLogger) *APIHandler {
+	return &APIHandler{
+		service:      service,
+		auditService: auditService,
+	}
+}
+
+func (h *APIHandler) ListEventTypes(c echo.Context) error {
+	return response.OK(c, ListEventTypesData{
+		EventTypes: EventTypes,
+	})
+}
+
+func (h *APIHandler) ListEndpoints(c echo.Context) error {
+	endpoints, err := h.service.ListEndpoints()
+	if err != nil {
+		return response.Internal(c, "Failed to fetch webhook endpoints")
+	}
+
+	return response.OK(c, ListEndpointsData{
+		Endpoints: ToResponseList(endpoints),
+	})
+}
+
+func (h *APIHandler) GetEndpoint(c echo.Context) error {
+	_, endpoint, err := h.loadEndpoint(c)
+	if err != nil || endpoint == nil {
+		return err
+	}
+
+	return response.OK(c, GetEndpointData{
+		Endpoint: ToResponse(endpoint),
+	})
+}
+
+func (h *APIHandler) CreateEndpoint(c echo.Context) error {
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	var req EndpointRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	endpoint, secret, err := h.service.CreateEndpoint(req, p.UserID())
+	if err != nil {
+		return response.Internal(c, "Failed to create webhook endpoint")
+	}
+
+	h.audit(c, p, security.EventWebhookEndpointCreated, endpoint, nil)
+
+	return response.Created(c, CreateEndpointData{
+		Endpoint: ToResponse(endpoint),
+		Secret:   secret,
+	})
+}
+
+func (h *APIHandler) UpdateEndpoint(c echo.Context) error {
+	p, existing, err := h.loadEndpoint(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	var req EndpointRequest
+	if err := validation.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	endpoint, err := h.service.UpdateEndpoint(existing.ID, req)
+	if err != nil {
+		return response.Internal(c, "Failed to update webhook endpoint")
+	}
+
+	h.audit(c, p, security.EventWebhookEndpointUpdated, endpoint, map[string]any{
+		"secret_rotated": req.Secret != "",
+	})
+
+	return response.OK(c, GetEndpointData{
+		Endpoint: ToResponse(endpoint),
+	})
+}
+
+func (h *APIHandler) DeleteEndpoint(c echo.Context) error {
+	p, existing, err := h.loadEndpoint(c)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	if err := h.service.DeleteEndpoint(existing.ID); err != nil {
+		return response.Internal(c, "Failed to delete webhook endpoint")
+	}
+
+	h.audit(c, p, security.EventWebhookEndpointDeleted, existing, nil)
+
+	return response.OK(c, DeleteEndpointMessageData{
+		Message: "Webhook endpoint deleted successfully",
+	})
+}
+
+func (h *APIHandler) ListDeliveries(c echo.Context) error {
+	_, endpoint, err := h.loadEndpoint(c)
+	if err != nil || endpoint == nil {
+		return err
+	}
+
+	page := intQueryParam(c, "page", 1)
+	pageSize := intQueryParam(c, "page_size", 25)
+
+	deliveries, total, err := h.service.ListDeliveries(endpoint.ID, page, pageSize)
+	if err != nil {
+		return response.Internal(c, "Failed to fetch webhook deliveries")
+	}
+
+	return response.OK(c, ListDeliveriesData{
+		Deliveries: ToDeliveryResponseList(deliveries),
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+	})
+}
+
+func (h *APIHandler) SendTest(c echo.Context) error {
+	p, endpoint, err := h.loadEndpoint(c)
+	if err != nil || endpoint == nil {
+		return err
+	}
+
+	delivery, err := h.service.SendTest(c.Request().Context(), endpoint)
+	if err != nil {
+		return response.Internal(c, "Failed to send test event")
+	}
+
+	h.audit(c, p, security.EventWebhookTestSent, endpoint, map[string]any{
+		"delivery_id":      delivery.ID,
+		"delivery_status":  delivery.Status,
+		"last_status_code": delivery.LastStatusCode,
+	})
+
+	return response.OK(c, TestDeliveryData{
+		Delivery: ToDeliveryResponse(delivery),
+	})
+}
+
+// loadEndpoint resolves the endpoint named in the path. A nil endpoint with a
+// nil error means a response has already been written.
+func (h *APIHandler) loadEndpoint(c echo.Context) (authz.Principal, *Endpoint, error) {
+	endpointID, err := echoparams.ParseUintParam(c, "id")
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	p, err := authz.RequirePrincipal(c)
+	if err != nil {
+		return authz.Principal{}, nil, err
+	}
+
+	endpoint, err := h.service.GetEndpoint(endpointID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return p, nil, response.NotFound(c, "Webhook endpoint not found")
+		}
+		return p, nil, response.Internal(c, "Failed to fetch webhook endpoint")
+	}
+
+	return p, endpoint, nil
+}
+
+func (h *APIHandler) audit(c echo.Context, p authz.Principal, eventType string, endpoint *Endpoint, extra map[string]any) {
+	actorID := p.UserID()
+	endpointID := endpoint.ID
+	metadata := map[string]any{
+		"url":         endpoint.URL,
+		"event_types": decodeList(endpoint.EventTypes),
+		"enabled":     endpoint.Enabled,
+	}
+	for k, v := range extra {
+		metadata[k] = v
+	}
+	_ = h.auditService.Log(security.LogEvent{
+		EventType:      eventType,
+		ActorUserID:    &actorID,
+		ActorUsername:  session.ResolveUsername(c),
+		ActorIP:        c.RealIP(),
+		ActorUserAgent: c.Request().UserAgent(),
+		TargetType:     security.TargetTypeWebhookEndpoint,
+		TargetID:       &endpointID,
+		TargetName:     endpoint.Name,
+		Success:        true,
+		Metadata:       metadata,
+	})
+}
+
+func intQueryParam(c echo.Context, name string, fallback int) int {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return fallback
+	}
+	return value
+}
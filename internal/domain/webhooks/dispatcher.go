@@ -0,0 +1,76 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type leaderGate interface {
+	IsLeader() bool
+}
+
+// Dispatcher delivers queued webhooks. It wakes when events are published and
+// otherwise polls for retries that have come due.
+type Dispatcher struct {
+	service  *Service
+	logger   *zap.Logger
+	interval time.Duration
+	leader   leaderGate
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+func NewDispatcher(service *Service, logger *zap.Logger) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Dispatcher{
+		service:  service,
+		logger:   logger,
+		interval: 15 * time.Second,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// SetLeaderGate leaves delivery to the instance holding the webhook lease,
+// so each delivery is attempted once however many instances are running.
+func (d *Dispatcher) SetLeaderGate(g leaderGate) {
+	d.leader = g
+}
+
+func (d *Dispatcher) Start() {
+	d.logger.Info("starting webhook dispatcher",
+		zap.Duration("interval", d.interval),
+	)
+
+	go d.loop()
+}
+
+func (d *Dispatcher) Stop() {
+	d.logger.Info("stopping webhook dispatcher")
+	d.cancel()
+}
+
+func (d *Dispatcher) loop() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-d.service.wake:
+		case <-d.ctx.Done():
+			d.logger.Info("webhook dispatcher stopped")
+			return
+		}
+
+		if d.leader != nil && !d.leader.IsLeader() {
+			continue
+		}
+		if err := d.service.DeliverDue(d.ctx); err != nil && d.ctx.Err() == nil {
+			d.logger.Error("webhook dispatch failed", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// minSecretLength keeps admin-chosen signing secrets out of brute-force range.
+const minSecretLength = 16
+
+var (
+	ErrNameRequired      = errors.New("name is required")
+	ErrURLInvalid        = errors.New("url must be an absolute http or https URL")
+	ErrEventTypeRequired = errors.New("at least one event type is required")
+	ErrEventTypeInvalid  = errors.New("event types must be chosen from the supported event types")
+	ErrEventTypeRepeated = errors.New("event types must not repeat")
+	ErrSecretTooShort    = errors.New("secret must be at least 16 characters")
+)
+
+// EndpointRequest creates or replaces an endpoint. Secret is optional: on
+// create an empty secret is generated, and on update it keeps the current one.
+type EndpointRequest struct {
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types"`
+	Enabled    *bool    `json:"enabled,omitempty"`
+}
+
+func (r *EndpointRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrNameRequired
+	}
+	u, err := url.Parse(strings.TrimSpace(r.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrURLInvalid
+	}
+	if len(r.EventTypes) == 0 {
+		return ErrEventTypeRequired
+	}
+	seen := make(map[string]bool, len(r.EventTypes))
+	for _, t := range r.EventTypes {
+		if !slices.Contains(EventTypes, t) {
+			return ErrEventTypeInvalid
+		}
+		if seen[t] {
+			return ErrEventTypeRepeated
+		}
+		seen[t] = true
+	}
+	if r.Secret != "" && len(r.Secret) < minSecretLength {
+		return ErrSecretTooShort
+	}
+	return nil
+}
+
+type EndpointInfo struct {
+	ID              uint      `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Name            string    `json:"name"`
+	URL             string    `json:"url"`
+	EventTypes      []string  `json:"event_types"`
+	Enabled         bool      `json:"enabled"`
+	CreatedByUserID *uint     `json:"created_by_user_id,omitempty"`
+}
+
+type DeliveryInfo struct {
+	ID             uint       `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	EndpointID     uint       `json:"endpoint_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+type ListEndpointsData struct {
+	Endpoints []EndpointInfo `json:"endpoints"`
+}
+
+type GetEndpointData struct {
+	Endpoint EndpointInfo `json:"endpoint"`
+}
+
+// CreateEndpointData returns the signing secret. It is the only response
+// that does, so callers must store it.
+type CreateEndpointData struct {
+	Endpoint EndpointInfo `json:"endpoint"`
+	Secret   string       `json:"secret"`
+}
+
+type ListEventTypesData struct {
+	EventTypes []string `json:"event_types"`
+}
+
+type ListDeliveriesData struct {
+	Deliveries []DeliveryInfo `json:"deliveries"`
+	Total      int64          `json:"total"`
+	Page       int            `json:"page"`
+	PageSize   int            `json:"page_size"`
+}
+
+type TestDeliveryData struct {
+	Delivery DeliveryInfo `json:"delivery"`
+}
+
+type DeleteEndpointMessageData struct {
+	Message string `json:"message"`
+}
+
+func ToResponse(e *Endpoint) EndpointInfo {
+	return EndpointInfo{
+		ID:              e.ID,
+		CreatedAt:       e.CreatedAt,
+		UpdatedAt:       e.UpdatedAt,
+		Name:            e.Name,
+		URL:             e.URL,
+		EventTypes:      decodeList(e.EventTypes),
+		Enabled:         e.Enabled,
+		CreatedByUserID: e.CreatedByUserID,
+	}
+}
+
+func ToResponseList(endpoints []Endpoint) []EndpointInfo {
+	result := make([]EndpointInfo, len(endpoints))
+	for i := range endpoints {
+		result[i] = ToResponse(&endpoints[i])
+	}
+	return result
+}
+
+func ToDeliveryResponse(d *Delivery) DeliveryInfo {
+	return DeliveryInfo{
+		ID:             d.ID,
+		CreatedAt:      d.CreatedAt,
+		EndpointID:     d.EndpointID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastAttemptAt:  d.LastAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+	}
+}
+
+func ToDeliveryResponseList(deliveries []Delivery) []DeliveryInfo {
+	result := make([]DeliveryInfo, len(deliveries))
+	for i := range deliveries {
+		result[i] = ToDeliveryResponse(&deliveries[i])
+	}
+	return result
+}
+
+func encodeList(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+func decodeList(raw string) []string {
+	values := []string{}
+	if raw == "" {
+		return values
+	}
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return []string{}
+	}
+	return values
+}
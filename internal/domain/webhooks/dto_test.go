@@ -0,0 +1,49 @@
+package webhooks
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEndpointRequest_Validate(t *testing.T) {
+	valid := func(mod func(*EndpointRequest)) EndpointRequest {
+		req := EndpointRequest{
+			Name:       "ops",
+			URL:        "https://hooks.example.com/berth",
+			EventTypes: []string{EventOperationFailed, EventAgentDisconnected},
+		}
+		if mod != nil {
+			mod(&req)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		req     EndpointRequest
+		wantErr error
+	}{
+		{"valid", valid(nil), nil},
+		{"valid with secret", valid(func(r *EndpointRequest) { r.Secret = "0123456789abcdef" }), nil},
+		{"http allowed", valid(func(r *EndpointRequest) { r.URL = "http://10.0.0.5:9000/hook" }), nil},
+		{"missing name", valid(func(r *EndpointRequest) { r.Name = " " }), ErrNameRequired},
+		{"missing url", valid(func(r *EndpointRequest) { r.URL = "" }), ErrURLInvalid},
+		{"relative url", valid(func(r *EndpointRequest) { r.URL = "/hook" }), ErrURLInvalid},
+		{"unsupported scheme", valid(func(r *EndpointRequest) { r.URL = "ftp://example.com/hook" }), ErrURLInvalid},
+		{"no event types", valid(func(r *EndpointRequest) { r.EventTypes = nil }), ErrEventTypeRequired},
+		{"unknown event type", valid(func(r *EndpointRequest) { r.EventTypes = []string{"stack.exploded"} }), ErrEventTypeInvalid},
+		{"test event not subscribable", valid(func(r *EndpointRequest) { r.EventTypes = []string{EventTest} }), ErrEventTypeInvalid},
+		{"repeated event type", valid(func(r *EndpointRequest) {
+			r.EventTypes = []string{EventSecurityAudit, EventSecurityAudit}
+		}), ErrEventTypeRepeated},
+		{"short secret", valid(func(r *EndpointRequest) { r.Secret = "short" }), ErrSecretTooShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.req.Validate(); !errors.Is(got, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
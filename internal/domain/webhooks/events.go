@@ -0,0 +1,133 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"berth/internal/domain/vulnscan"
+)
+
+const (
+	EventOperationStarted     = "operation.started"
+	EventOperationCompleted   = "operation.completed"
+	EventOperationFailed      = "operation.failed"
+	EventVulnscanCompleted    = "vulnscan.completed"
+	EventImageUpdateAvailable = "image_update.available"
+	EventBackupCompleted      = "backup.completed"
+	EventBackupFailed         = "backup.failed"
+	EventAgentDisconnected    = "agent.disconnected"
+	EventSecurityAudit        = "security.audit"
+
+	EventAccessRequestCreated   = "access_request.created"
+	EventAccessRequestApproved  = "access_request.approved"
+	EventAccessRequestDenied    = "access_request.denied"
+	EventAccessRequestCancelled = "access_request.cancelled"
+	EventAccessRequestExpired   = "access_request.expired"
+
+	// EventTest is only sent by the test endpoint and cannot be subscribed to.
+	EventTest = "webhook.test"
+)
+
+// EventTypes lists every event type an endpoint can subscribe to.
+var EventTypes = []string{
+	EventOperationStarted,
+	EventOperationCompleted,
+	EventOperationFailed,
+	EventVulnscanCompleted,
+	EventImageUpdateAvailable,
+	EventBackupCompleted,
+	EventBackupFailed,
+	EventAgentDisconnected,
+	EventSecurityAudit,
+	EventAccessRequestCreated,
+	EventAccessRequestApproved,
+	EventAccessRequestDenied,
+	EventAccessRequestCancelled,
+	EventAccessRequestExpired,
+}
+
+// Event is the JSON body POSTed to endpoints.
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+type OperationEventData struct {
+	OperationID string     `json:"operation_id"`
+	ServerID    uint       `json:"server_id"`
+	StackName   string     `json:"stack_name"`
+	Command     string     `json:"command"`
+	UserID      uint       `json:"user_id,omitempty"`
+	Username    string     `json:"username,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	EndedAt     *time.Time `json:"ended_at,omitempty"`
+	Success     *bool      `json:"success,omitempty"`
+	ExitCode    *int       `json:"exit_code,omitempty"`
+	Summary     string     `json:"summary,omitempty"`
+}
+
+type ScanEventData struct {
+	ScanID       uint                           `json:"scan_id"`
+	ServerID     uint                           `json:"server_id"`
+	StackName    string                         `json:"stack_name"`
+	Trigger      string                         `json:"trigger"`
+	CompletedAt  *time.Time                     `json:"completed_at,omitempty"`
+	FullCoverage bool                           `json:"full_coverage"`
+	Summary      *vulnscan.VulnerabilitySummary `json:"summary"`
+}
+
+type ImageUpdateEventData struct {
+	ServerID          uint       `json:"server_id"`
+	StackName         string     `json:"stack_name"`
+	ContainerName     string     `json:"container_name"`
+	ImageName         string     `json:"image_name"`
+	CurrentRepoDigest string     `json:"current_repo_digest"`
+	LatestRepoDigest  string     `json:"latest_repo_digest"`
+	CheckedAt         *time.Time `json:"checked_at,omitempty"`
+}
+
+type AgentEventData struct {
+	ServerID   uint   `json:"server_id"`
+	ServerName string `json:"server_name"`
+	Reason     string `json:"reason"`
+}
+
+type AuditEventData struct {
+	AuditLogID    uint            `json:"audit_log_id"`
+	EventType     string          `json:"event_type"`
+	Category      string          `json:"category"`
+	Severity      string          `json:"severity"`
+	Success       bool            `json:"success"`
+	ActorUserID   *uint           `json:"actor_user_id,omitempty"`
+	ActorUsername string          `json:"actor_username,omitempty"`
+	ActorIP       string          `json:"actor_ip,omitempty"`
+	TargetType    string          `json:"target_type,omitempty"`
+	TargetID      *uint           `json:"target_id,omitempty"`
+	TargetName    string          `json:"target_name,omitempty"`
+	ServerID      *uint           `json:"server_id,omitempty"`
+	StackName     string          `json:"stack_name,omitempty"`
+	FailureReason string          `json:"failure_reason,omitempty"`
+	Metadata      json.RawMessage `json:"metadata,omitempty"`
+}
+
+type AccessRequestEventData struct {
+	RequestID         uint       `json:"request_id"`
+	UserID            uint       `json:"user_id"`
+	Username          string     `json:"username"`
+	ServerID          uint       `json:"server_id"`
+	StackPattern      string     `json:"stack_pattern"`
+	Permission        string     `json:"permission"`
+	DurationMinutes   int        `json:"duration_minutes"`
+	Reason            string     `json:"reason"`
+	Status            string     `json:"status"`
+	DecidedByUsername string     `json:"decided_by_username,omitempty"`
+	DecisionNote      string     `json:"decision_note,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+}
+
+type TestEventData struct {
+	EndpointID uint   `json:"endpoint_id"`
+	Message    string `json:"message"`
+}
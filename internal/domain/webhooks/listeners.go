@@ -0,0 +1,143 @@
+package webhooks
+
+import (
+	"encoding/json"
+
+	"berth/internal/domain/accessrequests"
+	"berth/internal/domain/imageupdates"
+	"berth/internal/domain/operationlogs"
+	"berth/internal/domain/security"
+	"berth/internal/domain/vulnscan"
+)
+
+// backupCommand is the operation command that creates a backup. Its outcome
+// is also published as a backup event.
+const backupCommand = "create-backup"
+
+func (s *Service) OnOperationStart(log *operationlogs.OperationLog) {
+	s.Publish(EventOperationStarted, operationData(log))
+}
+
+func (s *Service) OnOperationEnd(log *operationlogs.OperationLog) {
+	succeeded := log.Success != nil && *log.Success
+	data := operationData(log)
+
+	if succeeded {
+		s.Publish(EventOperationCompleted, data)
+	} else {
+		s.Publish(EventOperationFailed, data)
+	}
+
+	if log.Command == backupCommand {
+		if succeeded {
+			s.Publish(EventBackupCompleted, data)
+		} else {
+			s.Publish(EventBackupFailed, data)
+		}
+	}
+}
+
+func (s *Service) OnScanComplete(scan *vulnscan.ImageScan, summary *vulnscan.VulnerabilitySummary) {
+	s.Publish(EventVulnscanCompleted, ScanEventData{
+		ScanID:       scan.ID,
+		ServerID:     scan.ServerID,
+		StackName:    scan.StackName,
+		Trigger:      scan.Trigger,
+		CompletedAt:  scan.CompletedAt,
+		FullCoverage: scan.FullCoverage,
+		Summary:      summary,
+	})
+}
+
+func (s *Service) OnImageUpdateAvailable(update *imageupdates.ContainerImageUpdate) {
+	s.Publish(EventImageUpdateAvailable, ImageUpdateEventData{
+		ServerID:          update.ServerID,
+		StackName:         update.StackName,
+		ContainerName:     update.ContainerName,
+		ImageName:         update.CurrentImageName,
+		CurrentRepoDigest: update.CurrentRepoDigest,
+		LatestRepoDigest:  update.LatestRepoDigest,
+		CheckedAt:         update.LastCheckedAt,
+	})
+}
+
+func (s *Service) OnAgentDisconnected(serverID uint, serverName string, reason string) {
+	s.Publish(EventAgentDisconnected, AgentEventData{
+		ServerID:   serverID,
+		ServerName: serverName,
+		Reason:     reason,
+	})
+}
+
+// accessRequestEvents maps a request's status to the event announcing it.
+var accessRequestEvents = map[string]string{
+	accessrequests.StatusPending:   EventAccessRequestCreated,
+	accessrequests.StatusApproved:  EventAccessRequestApproved,
+	accessrequests.StatusDenied:    EventAccessRequestDenied,
+	accessrequests.StatusCancelled: EventAccessRequestCancelled,
+	accessrequests.StatusExpired:   EventAccessRequestExpired,
+}
+
+func (s *Service) OnAccessRequestChanged(req *accessrequests.AccessRequest) {
+	eventType, ok := accessRequestEvents[req.Status]
+	if !ok {
+		return
+	}
+	s.Publish(eventType, AccessRequestEventData{
+		RequestID:         req.ID,
+		UserID:            req.UserID,
+		Username:          req.Username,
+		ServerID:          req.ServerID,
+		StackPattern:      req.StackPattern,
+		Permission:        req.Permission,
+		DurationMinutes:   req.DurationMinutes,
+		Reason:            req.Reason,
+		Status:            req.Status,
+		DecidedByUsername: req.DecidedByUsername,
+		DecisionNote:      req.DecisionNote,
+		ExpiresAt:         req.ExpiresAt,
+	})
+}
+
+func (s *Service) OnAuditLog(log *security.SecurityAuditLog) {
+	data := AuditEventData{
+		AuditLogID:    log.ID,
+		EventType:     log.EventType,
+		Category:      log.EventCategory,
+		Severity:      log.Severity,
+		Success:       log.Success,
+		ActorUserID:   log.ActorUserID,
+		ActorUsername: log.ActorUsername,
+		ActorIP:       log.ActorIP,
+		TargetType:    log.TargetType,
+		TargetID:      log.TargetID,
+		TargetName:    log.TargetName,
+		ServerID:      log.ServerID,
+		StackName:     log.StackName,
+		FailureReason: log.FailureReason,
+	}
+	if log.Metadata != "" && json.Valid([]byte(log.Metadata)) {
+		data.Metadata = json.RawMessage(log.Metadata)
+	}
+	s.Publish(EventSecurityAudit, data)
+}
+
+func operationData(log *operationlogs.OperationLog) OperationEventData {
+	var userID uint
+	if log.UserID != nil {
+		userID = *log.UserID
+	}
+	return OperationEventData{
+		OperationID: log.OperationID,
+		ServerID:    log.ServerID,
+		StackName:   log.StackName,
+		Command:     log.Command,
+		UserID:      userID,
+		Username:    log.UserName,
+		StartedAt:   log.StartTime,
+		EndedAt:     log.EndTime,
+		Success:     log.Success,
+		ExitCode:    log.ExitCode,
+		Summary:     log.Summary,
+	}
+}
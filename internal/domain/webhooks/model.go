@@ -0,0 +1,50 @@
+package webhooks
+
+import (
+	"time"
+
+	"berth/internal/platform/db"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Endpoint receives a signed POST for every event whose type it subscribes
+// to. Secret holds the encrypted HMAC signing secret.
+type Endpoint struct {
+	db.BaseModel
+	Name            string `json:"name" gorm:"not null"`
+	URL             string `json:"url" gorm:"type:text;not null"`
+	Secret          string `json:"-" gorm:"type:text;not null"`
+	EventTypes      string `json:"event_types" gorm:"type:text;not null"`
+	Enabled         bool   `json:"enabled" gorm:"not null"`
+	CreatedByUserID *uint  `json:"created_by_user_id"`
+}
+
+func (Endpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// Delivery is one event queued for one endpoint. Pending deliveries are
+// retried with exponential backoff until they succeed or run out of attempts.
+type Delivery struct {
+	db.BaseModel
+	EndpointID     uint       `json:"endpoint_id" gorm:"not null;index"`
+	EventID        string     `json:"event_id" gorm:"not null;index"`
+	EventType      string     `json:"event_type" gorm:"not null;index"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"`
+	Status         string     `json:"status" gorm:"not null;index"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"index"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error" gorm:"type:text"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,20 @@
+package webhooks
+
+import (
+	"berth/internal/domain/authz"
+	"berth/internal/domain/rbac/permnames"
+)
+
+func (h *APIHandler) RegisterAdminAPIRoutes(reg *authz.Registrar) {
+	read := authz.Admin(permnames.AdminWebhooksRead)
+	write := authz.Admin(permnames.AdminWebhooksWrite)
+
+	reg.GET("/webhooks/event-types", h.ListEventTypes, read)
+	reg.GET("/webhooks", h.ListEndpoints, read)
+	reg.POST("/webhooks", h.CreateEndpoint, write)
+	reg.GET("/webhooks/:id", h.GetEndpoint, read)
+	reg.PUT("/webhooks/:id", h.UpdateEndpoint, write)
+	reg.DELETE("/webhooks/:id", h.DeleteEndpoint, write)
+	reg.GET("/webhooks/:id/deliveries", h.ListDeliveries, read)
+	reg.POST("/webhooks/:id/test", h.SendTest, write)
+}
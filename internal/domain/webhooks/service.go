@@ -0,0 +1,496 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"berth/internal/pkg/crypto"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxDeliveryAttempts bounds retries; with the backoff below the last
+	// attempt is made roughly an hour after the first.
+	maxDeliveryAttempts = 8
+	initialBackoff      = 30 * time.Second
+	maxBackoff          = time.Hour
+
+	deliveryTimeout   = 10 * time.Second
+	deliveryBatchSize = 50
+	maxErrorLength    = 1024
+)
+
+type Service struct {
+	db     *gorm.DB
+	crypto *crypto.Crypto
+	client *http.Client
+	logger *zap.Logger
+	now    func() time.Time
+	wake   chan struct{}
+}
+
+func NewService(db *gorm.DB, crypto *crypto.Crypto, logger *zap.Logger) *Service {
+	return &Service{
+		db:     db,
+		crypto: crypto,
+		client: &http.Client{Timeout: deliveryTimeout},
+		logger: logger,
+		now:    time.Now,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+func (s *Service) Logger() *zap.Logger {
+	return s.logger
+}
+
+func (s *Service) ListEndpoints() ([]Endpoint, error) {
+	var endpoints []Endpoint
+	if err := s.db.Order("id").Find(&endpoints).Error; err != nil {
+		s.logger.Error("failed to list webhook endpoints", zap.Error(err))
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (s *Service) GetEndpoint(id uint) (*Endpoint, error) {
+	var endpoint Endpoint
+	if err := s.db.First(&endpoint, id).Error; err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// CreateEndpoint stores a new endpoint and returns it with its plaintext
+// signing secret, generating one when the request leaves it empty.
+func (s *Service) CreateEndpoint(req EndpointRequest, createdBy uint) (*Endpoint, string, error) {
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, "", err
+		}
+		secret = generated
+	}
+
+	endpoint := Endpoint{
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	if createdBy != 0 {
+		endpoint.CreatedByUserID = &createdBy
+	}
+	applyRequest(&endpoint, req)
+	if err := s.setSecret(&endpoint, secret); err != nil {
+		return nil, "", err
+	}
+
+	if err := s.db.Create(&endpoint).Error; err != nil {
+		s.logger.Error("failed to create webhook endpoint", zap.Error(err))
+		return nil, "", err
+	}
+
+	s.logger.Info("webhook endpoint created",
+		zap.Uint("endpoint_id", endpoint.ID),
+		zap.Strings("event_types", req.EventTypes),
+	)
+
+	return &endpoint, secret, nil
+}
+
+func (s *Service) UpdateEndpoint(id uint, req EndpointRequest) (*Endpoint, error) {
+	endpoint, err := s.GetEndpoint(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		endpoint.Enabled = *req.Enabled
+	}
+	applyRequest(endpoint, req)
+	if req.Secret != "" {
+		if err := s.setSecret(endpoint, req.Secret); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.db.Save(endpoint).Error; err != nil {
+		s.logger.Error("failed to update webhook endpoint",
+			zap.Error(err),
+			zap.Uint("endpoint_id", id),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("webhook endpoint updated",
+		zap.Uint("endpoint_id", id),
+		zap.Bool("enabled", endpoint.Enabled),
+		zap.Bool("secret_rotated", req.Secret != ""),
+	)
+
+	return endpoint, nil
+}
+
+// DeleteEndpoint removes the endpoint together with its delivery log.
+func (s *Service) DeleteEndpoint(id uint) error {
+	endpoint, err := s.GetEndpoint(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("endpoint_id = ?", id).Delete(&Delivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(endpoint).Error
+	})
+	if err != nil {
+		s.logger.Error("failed to delete webhook endpoint",
+			zap.Error(err),
+			zap.Uint("endpoint_id", id),
+		)
+		return err
+	}
+
+	s.logger.Info("webhook endpoint deleted", zap.Uint("endpoint_id", id))
+	return nil
+}
+
+// ListDeliveries returns an endpoint's delivery log, newest first.
+func (s *Service) ListDeliveries(endpointID uint, page, pageSize int) ([]Delivery, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 25
+	}
+
+	query := s.db.Model(&Delivery{}).Where("endpoint_id = ?", endpointID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []Delivery
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// SendTest queues a test event for the endpoint and attempts it straight
+// away. A failed test is retried like any other delivery.
+func (s *Service) SendTest(ctx context.Context, endpoint *Endpoint) (*Delivery, error) {
+	delivery, err := s.enqueue(endpoint.ID, s.newEvent(EventTest, TestEventData{
+		EndpointID: endpoint.ID,
+		Message:    "This is a test event from Berth.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.claim(delivery, s.now()) {
+		s.attempt(ctx, endpoint, delivery)
+	}
+	return delivery, nil
+}
+
+// Publish queues the event for every enabled endpoint subscribed to its
+// type. Delivery happens in the background.
+func (s *Service) Publish(eventType string, data any) {
+	var endpoints []Endpoint
+	if err := s.db.Where("enabled = ?", true).Find(&endpoints).Error; err != nil {
+		s.logger.Error("failed to load webhook endpoints",
+			zap.Error(err),
+			zap.String("event_type", eventType),
+		)
+		return
+	}
+
+	var event *Event
+	queued := 0
+	for i := range endpoints {
+		if !slices.Contains(decodeList(endpoints[i].EventTypes), eventType) {
+			continue
+		}
+		if event == nil {
+			event = s.newEvent(eventType, data)
+		}
+		if _, err := s.enqueue(endpoints[i].ID, event); err != nil {
+			continue
+		}
+		queued++
+	}
+
+	if queued > 0 {
+		s.signal()
+	}
+}
+
+// DeliverDue attempts every pending delivery whose next attempt is due.
+func (s *Service) DeliverDue(ctx context.Context) error {
+	now := s.now()
+
+	var due []Delivery
+	if err := s.db.Where("status = ? AND next_attempt_at <= ?", DeliveryStatusPending, now).
+		Order("next_attempt_at").
+		Limit(deliveryBatchSize).
+		Find(&due).Error; err != nil {
+		return fmt.Errorf("failed to query due webhook deliveries: %w", err)
+	}
+
+	endpoints := make(map[uint]*Endpoint)
+	for i := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		delivery := &due[i]
+		if !s.claim(delivery, now) {
+			continue
+		}
+
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			var err error
+			endpoint, err = s.GetEndpoint(delivery.EndpointID)
+			if err != nil {
+				s.finish(delivery, DeliveryStatusFailed, 0, "endpoint no longer exists")
+				continue
+			}
+			endpoints[delivery.EndpointID] = endpoint
+		}
+
+		s.attempt(ctx, endpoint, delivery)
+	}
+
+	return nil
+}
+
+// DeleteOldDeliveries hard-deletes finished deliveries older than the given
+// number of days.
+func (s *Service) DeleteOldDeliveries(days int) (int64, error) {
+	cutoff := s.now().AddDate(0, 0, -days)
+	result := s.db.Unscoped().
+		Where("status <> ? AND created_at < ?", DeliveryStatusPending, cutoff).
+		Delete(&Delivery{})
+	if result.Error != nil {
+		s.logger.Error("failed to delete old webhook deliveries", zap.Error(result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+func (s *Service) newEvent(eventType string, data any) *Event {
+	return &Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OccurredAt: s.now().UTC(),
+		Data:       data,
+	}
+}
+
+func (s *Service) enqueue(endpointID uint, event *Event) (*Delivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("failed to marshal webhook event",
+			zap.Error(err),
+			zap.String("event_type", event.Type),
+		)
+		return nil, err
+	}
+
+	now := s.now()
+	delivery := Delivery{
+		EndpointID:    endpointID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       string(payload),
+		Status:        DeliveryStatusPending,
+		NextAttemptAt: &now,
+	}
+	if err := s.db.Create(&delivery).Error; err != nil {
+		s.logger.Error("failed to queue webhook delivery",
+			zap.Error(err),
+			zap.Uint("endpoint_id", endpointID),
+			zap.String("event_type", event.Type),
+		)
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// claim pushes the delivery's next attempt past the request timeout so a
+// concurrent run does not send it twice.
+func (s *Service) claim(delivery *Delivery, now time.Time) bool {
+	lease := now.Add(2 * deliveryTimeout)
+	claimed := s.db.Model(&Delivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", delivery.ID, DeliveryStatusPending, now).
+		Update("next_attempt_at", lease)
+	if claimed.Error != nil {
+		s.logger.Error("failed to claim webhook delivery",
+			zap.Error(claimed.Error),
+			zap.Uint("delivery_id", delivery.ID),
+		)
+		return false
+	}
+	return claimed.RowsAffected > 0
+}
+
+// attempt sends the delivery once and records the outcome, scheduling a
+// retry when the endpoint did not answer with a 2xx status.
+func (s *Service) attempt(ctx context.Context, endpoint *Endpoint, delivery *Delivery) {
+	secret, err := s.crypto.Decrypt(endpoint.Secret)
+	if err != nil {
+		s.logger.Error("failed to decrypt webhook secret",
+			zap.Error(err),
+			zap.Uint("endpoint_id", endpoint.ID),
+		)
+		s.finish(delivery, DeliveryStatusFailed, 0, "failed to decrypt signing secret")
+		return
+	}
+
+	statusCode, err := s.send(ctx, endpoint.URL, secret, delivery)
+	delivery.Attempts++
+	if err == nil {
+		s.finish(delivery, DeliveryStatusSucceeded, statusCode, "")
+		return
+	}
+
+	s.logger.Warn("webhook delivery attempt failed",
+		zap.Error(err),
+		zap.Uint("delivery_id", delivery.ID),
+		zap.Uint("endpoint_id", endpoint.ID),
+		zap.Int("attempts", delivery.Attempts),
+	)
+
+	if delivery.Attempts >= maxDeliveryAttempts {
+		s.finish(delivery, DeliveryStatusFailed, statusCode, err.Error())
+		return
+	}
+
+	next := s.now().Add(backoff(delivery.Attempts))
+	delivery.NextAttemptAt = &next
+	s.finish(delivery, DeliveryStatusPending, statusCode, err.Error())
+}
+
+func (s *Service) send(ctx context.Context, url, secret string, delivery *Delivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Berth-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.EventID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (s *Service) finish(delivery *Delivery, status string, statusCode int, errMsg string) {
+	now := s.now()
+	if len(errMsg) > maxErrorLength {
+		errMsg = errMsg[:maxErrorLength]
+	}
+
+	delivery.Status = status
+	delivery.LastAttemptAt = &now
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = errMsg
+	if status != DeliveryStatusPending {
+		delivery.NextAttemptAt = nil
+	}
+	if status == DeliveryStatusSucceeded {
+		delivery.DeliveredAt = &now
+	}
+
+	if err := s.db.Model(&Delivery{}).Where("id = ?", delivery.ID).Updates(map[string]any{
+		"status":           delivery.Status,
+		"attempts":         delivery.Attempts,
+		"next_attempt_at":  delivery.NextAttemptAt,
+		"last_attempt_at":  delivery.LastAttemptAt,
+		"last_status_code": delivery.LastStatusCode,
+		"last_error":       delivery.LastError,
+		"delivered_at":     delivery.DeliveredAt,
+	}).Error; err != nil {
+		s.logger.Error("failed to record webhook delivery result",
+			zap.Error(err),
+			zap.Uint("delivery_id", delivery.ID),
+		)
+	}
+}
+
+func (s *Service) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) setSecret(endpoint *Endpoint, secret string) error {
+	encrypted, err := s.crypto.Encrypt(secret)
+	if err != nil {
+		s.logger.Error("failed to encrypt webhook secret", zap.Error(err))
+		return fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	endpoint.Secret = encrypted
+	return nil
+}
+
+func applyRequest(endpoint *Endpoint, req EndpointRequest) {
+	endpoint.Name = strings.TrimSpace(req.Name)
+	endpoint.URL = strings.TrimSpace(req.URL)
+	endpoint.EventTypes = encodeList(req.EventTypes)
+}
+
+// backoff returns the wait before the next attempt after the given number of
+// failed attempts: 30s, 1m, 2m and so on, capped at an hour.
+func backoff(attempts int) time.Duration {
+	wait := initialBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return wait
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,278 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"berth/internal/domain/accessrequests"
+	"berth/internal/domain/operationlogs"
+	"berth/internal/pkg/crypto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver is a webhook endpoint that records requests and answers with
+// status.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []receivedRequest
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+	status := r.status
+	r.mu.Unlock()
+	w.WriteHeader(status)
+}
+
+func (r *receiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	dsn := fmt.Sprintf("file:webhooks_test_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Endpoint{}, &Delivery{}))
+	return NewService(db, crypto.NewCrypto("webhooks-test-encryption-secret"), zap.NewNop())
+}
+
+func newReceiver(t *testing.T, status int) (*receiver, string) {
+	t.Helper()
+	r := &receiver{status: status}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return r, srv.URL
+}
+
+func createEndpoint(t *testing.T, svc *Service, url string, eventTypes ...string) (*Endpoint, string) {
+	t.Helper()
+	endpoint, secret, err := svc.CreateEndpoint(EndpointRequest{
+		Name:       "receiver",
+		URL:        url,
+		EventTypes: eventTypes,
+	}, 1)
+	require.NoError(t, err)
+	return endpoint, secret
+}
+
+func deliveriesFor(t *testing.T, svc *Service, endpointID uint) []Delivery {
+	t.Helper()
+	deliveries, _, err := svc.ListDeliveries(endpointID, 1, 100)
+	require.NoError(t, err)
+	return deliveries
+}
+
+func TestPublish_DeliversSignedEventToSubscribedEndpoints(t *testing.T) {
+	svc := newTestService(t)
+	recv, url := newReceiver(t, http.StatusNoContent)
+	other, otherURL := newReceiver(t, http.StatusOK)
+
+	endpoint, secret := createEndpoint(t, svc, url, EventAgentDisconnected)
+	createEndpoint(t, svc, otherURL, EventOperationStarted)
+	assert.Len(t, secret, 64, "an empty secret is generated")
+
+	svc.OnAgentDisconnected(4, "prod-1", "connection reset")
+	require.NoError(t, svc.DeliverDue(context.Background()))
+
+	requests := recv.received()
+	require.Len(t, requests, 1)
+	assert.Empty(t, other.received(), "endpoints only receive subscribed event types")
+
+	req := requests[0]
+	assert.Equal(t, EventAgentDisconnected, req.header.Get(HeaderEvent))
+	timestamp, err := strconv.ParseInt(req.header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.True(t, Verify(secret, timestamp, req.body, req.header.Get(HeaderSignature)))
+	assert.False(t, Verify("wrong-secret-value", timestamp, req.body, req.header.Get(HeaderSignature)))
+
+	var event struct {
+		ID   string         `json:"id"`
+		Type string         `json:"type"`
+		Data AgentEventData `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(req.body, &event))
+	assert.Equal(t, req.header.Get(HeaderDelivery), event.ID)
+	assert.Equal(t, EventAgentDisconnected, event.Type)
+	assert.Equal(t, AgentEventData{ServerID: 4, ServerName: "prod-1", Reason: "connection reset"}, event.Data)
+
+	deliveries := deliveriesFor(t, svc, endpoint.ID)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, DeliveryStatusSucceeded, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusNoContent, deliveries[0].LastStatusCode)
+	assert.NotNil(t, deliveries[0].DeliveredAt)
+	assert.Nil(t, deliveries[0].NextAttemptAt)
+}
+
+func TestPublish_SkipsDisabledEndpoints(t *testing.T) {
+	svc := newTestService(t)
+	recv, url := newReceiver(t, http.StatusOK)
+
+	endpoint, _ := createEndpoint(t, svc, url, EventSecurityAudit)
+	disabled := false
+	_, err := svc.UpdateEndpoint(endpoint.ID, EndpointRequest{
+		Name:       endpoint.Name,
+		URL:        endpoint.URL,
+		EventTypes: []string{EventSecurityAudit},
+		Enabled:    &disabled,
+	})
+	require.NoError(t, err)
+
+	svc.Publish(EventSecurityAudit, AuditEventData{EventType: "auth.login.failure"})
+	require.NoError(t, svc.DeliverDue(context.Background()))
+
+	assert.Empty(t, recv.received())
+	assert.Empty(t, deliveriesFor(t, svc, endpoint.ID))
+}
+
+func TestDeliverDue_RetriesWithBackoffUntilAttemptsRunOut(t *testing.T) {
+	svc := newTestService(t)
+	recv, url := newReceiver(t, http.StatusInternalServerError)
+	endpoint, _ := createEndpoint(t, svc, url, EventVulnscanCompleted)
+
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	svc.Publish(EventVulnscanCompleted, ScanEventData{ScanID: 9})
+	require.NoError(t, svc.DeliverDue(context.Background()))
+
+	delivery := deliveriesFor(t, svc, endpoint.ID)[0]
+	assert.Equal(t, DeliveryStatusPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, delivery.LastStatusCode)
+	assert.Contains(t, delivery.LastError, "500")
+	require.NotNil(t, delivery.NextAttemptAt)
+	assert.True(t, delivery.NextAttemptAt.Equal(now.Add(30*time.Second)))
+
+	require.NoError(t, svc.DeliverDue(context.Background()))
+	assert.Len(t, recv.received(), 1, "the retry is not attempted before its backoff has passed")
+
+	for i := 1; i < maxDeliveryAttempts; i++ {
+		now = now.Add(maxBackoff)
+		require.NoError(t, svc.DeliverDue(context.Background()))
+	}
+
+	delivery = deliveriesFor(t, svc, endpoint.ID)[0]
+	assert.Equal(t, DeliveryStatusFailed, delivery.Status)
+	assert.Equal(t, maxDeliveryAttempts, delivery.Attempts)
+	assert.Nil(t, delivery.NextAttemptAt)
+	assert.Len(t, recv.received(), maxDeliveryAttempts)
+
+	now = now.Add(maxBackoff)
+	require.NoError(t, svc.DeliverDue(context.Background()))
+	assert.Len(t, recv.received(), maxDeliveryAttempts, "failed deliveries are not retried")
+}
+
+func TestBackoff_DoublesUpToCap(t *testing.T) {
+	assert.Equal(t, 30*time.Second, backoff(1))
+	assert.Equal(t, time.Minute, backoff(2))
+	assert.Equal(t, 2*time.Minute, backoff(3))
+	assert.Equal(t, 32*time.Minute, backoff(7))
+	assert.Equal(t, time.Hour, backoff(8))
+	assert.Equal(t, time.Hour, backoff(20))
+}
+
+func TestSendTest_DeliversImmediatelyRegardlessOfSubscriptions(t *testing.T) {
+	svc := newTestService(t)
+	recv, url := newReceiver(t, http.StatusOK)
+	endpoint, _ := createEndpoint(t, svc, url, EventBackupFailed)
+
+	delivery, err := svc.SendTest(context.Background(), endpoint)
+	require.NoError(t, err)
+
+	assert.Equal(t, DeliveryStatusSucceeded, delivery.Status)
+	assert.Equal(t, EventTest, delivery.EventType)
+	requests := recv.received()
+	require.Len(t, requests, 1)
+	assert.Equal(t, EventTest, requests[0].header.Get(HeaderEvent))
+}
+
+func TestOnOperationEnd_PublishesBackupOutcome(t *testing.T) {
+	svc := newTestService(t)
+	_, url := newReceiver(t, http.StatusOK)
+	endpoint, _ := createEndpoint(t, svc, url, EventOperationFailed, EventBackupCompleted, EventBackupFailed)
+
+	failed := false
+	exitCode := 1
+	svc.OnOperationEnd(&operationlogs.OperationLog{
+		ServerID:    2,
+		StackName:   "db",
+		OperationID: "op-1",
+		Command:     "create-backup",
+		Success:     &failed,
+		ExitCode:    &exitCode,
+	})
+
+	var types []string
+	for _, d := range deliveriesFor(t, svc, endpoint.ID) {
+		types = append(types, d.EventType)
+	}
+	assert.ElementsMatch(t, []string{EventOperationFailed, EventBackupFailed}, types)
+}
+
+func TestOnAccessRequestChanged_PublishesByStatus(t *testing.T) {
+	svc := newTestService(t)
+	_, url := newReceiver(t, http.StatusOK)
+	endpoint, _ := createEndpoint(t, svc, url, EventAccessRequestCreated, EventAccessRequestApproved)
+
+	req := &accessrequests.AccessRequest{
+		UserID:       3,
+		ServerID:     2,
+		StackPattern: "web-*",
+		Permission:   "stacks.manage",
+		Status:       accessrequests.StatusPending,
+	}
+	svc.OnAccessRequestChanged(req)
+	req.Status = accessrequests.StatusApproved
+	svc.OnAccessRequestChanged(req)
+	req.Status = accessrequests.StatusExpired
+	svc.OnAccessRequestChanged(req)
+
+	var types []string
+	for _, d := range deliveriesFor(t, svc, endpoint.ID) {
+		types = append(types, d.EventType)
+	}
+	assert.ElementsMatch(t, []string{EventAccessRequestCreated, EventAccessRequestApproved}, types)
+}
+
+func TestDeleteEndpoint_RemovesDeliveryLog(t *testing.T) {
+	svc := newTestService(t)
+	_, url := newReceiver(t, http.StatusOK)
+	endpoint, _ := createEndpoint(t, svc, url, EventImageUpdateAvailable)
+
+	svc.Publish(EventImageUpdateAvailable, ImageUpdateEventData{StackName: "web"})
+	require.Len(t, deliveriesFor(t, svc, endpoint.ID), 1)
+
+	require.NoError(t, svc.DeleteEndpoint(endpoint.ID))
+
+	var count int64
+	require.NoError(t, svc.db.Unscoped().Model(&Delivery{}).Where("endpoint_id = ?", endpoint.ID).Count(&count).Error)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,33 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+const (
+	HeaderEvent     = "X-Berth-Event"
+	HeaderDelivery  = "X-Berth-Delivery"
+	HeaderTimestamp = "X-Berth-Timestamp"
+	HeaderSignature = "X-Berth-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// Sign returns the X-Berth-Signature value for a payload: the hex HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the endpoint secret. Including the
+// timestamp lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid Sign result for the payload.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
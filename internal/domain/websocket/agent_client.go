@@ -17,22 +17,31 @@ import (
 
 const agentReadLimit = 1 << 20
 
+// DisconnectListener is notified when an established agent connection drops
+// unexpectedly. Deliberate disconnects and shutdown are not reported.
+// Listeners run on the connection goroutine and must not block.
+type DisconnectListener interface {
+	OnAgentDisconnected(serverID uint, serverName string, reason string)
+}
+
 type AgentClient struct {
-	server    *server.Server
-	conn      *websocket.Conn
-	registry  *StackEventRegistry
-	reconnect chan bool
-	stop      chan bool
-	connected bool
-	mutex     sync.RWMutex
-	logger    *zap.Logger
+	server       *server.Server
+	conn         *websocket.Conn
+	registry     *StackEventRegistry
+	reconnect    chan bool
+	stop         chan bool
+	connected    bool
+	mutex        sync.RWMutex
+	logger       *zap.Logger
+	disconnected func(reason string)
 }
 
 type AgentManager struct {
-	clients  map[uint]*AgentClient
-	registry *StackEventRegistry
-	mutex    sync.RWMutex
-	logger   *zap.Logger
+	clients             map[uint]*AgentClient
+	registry            *StackEventRegistry
+	mutex               sync.RWMutex
+	logger              *zap.Logger
+	disconnectListeners []DisconnectListener
 }
 
 func NewAgentManager(registry *StackEventRegistry, logger *zap.Logger) *AgentManager {
@@ -43,6 +52,12 @@ func NewAgentManager(registry *StackEventRegistry, logger *zap.Logger) *AgentMan
 	}
 }
 
+// AddDisconnectListener registers l for unexpected disconnect notifications.
+// It must be called during wiring, before any agent is connected.
+func (am *AgentManager) AddDisconnectListener(l DisconnectListener) {
+	am.disconnectListeners = append(am.disconnectListeners, l)
+}
+
 func (am *AgentManager) ConnectToAgent(server *server.Server) error {
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
@@ -70,6 +85,11 @@ func (am *AgentManager) ConnectToAgent(server *server.Server) error {
 		connected: false,
 		logger:    am.logger,
 	}
+	client.disconnected = func(reason string) {
+		for _, l := range am.disconnectListeners {
+			l.OnAgentDisconnected(server.ID, server.Name, reason)
+		}
+	}
 
 	am.clients[server.ID] = client
 	go client.connect()
@@ -204,9 +224,13 @@ func (ac *AgentClient) attemptConnection() error {
 }
 
 func (ac *AgentClient) readPump(ctx context.Context) {
+	var readErr error
 	defer func() {
 		ac.closeConn(websocket.StatusInternalError, "read loop ended")
 		ac.setConnected(false)
+		if readErr != nil && ctx.Err() == nil && ac.disconnected != nil {
+			ac.disconnected(readErr.Error())
+		}
 		select {
 		case ac.reconnect <- true:
 		default:
@@ -234,6 +258,7 @@ func (ac *AgentClient) readPump(ctx context.Context) {
 					zap.String("server_name", ac.server.Name),
 				)
 			}
+			readErr = err
 			break
 		}
 
@@ -138,3 +138,56 @@ func TestAgentClientReportsConnectionStatus(t *testing.T) {
 
 	assert.False(t, mgr.GetConnectionStatus(99), "unknown server must report disconnected")
 }
+
+type recordingDisconnectListener struct {
+	serverIDs chan uint
+}
+
+func (l *recordingDisconnectListener) OnAgentDisconnected(serverID uint, serverName string, reason string) {
+	l.serverIDs <- serverID
+}
+
+func TestAgentClientNotifiesListenersWhenAgentDropsConnection(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{InsecureSkipVerify: true})
+		if err != nil {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+		conn.Close(websocket.StatusInternalError, "agent restarting")
+	}))
+	t.Cleanup(srv.Close)
+
+	listener := &recordingDisconnectListener{serverIDs: make(chan uint, 4)}
+	mgr := NewAgentManager(NewStackEventRegistry(zap.NewNop()), zap.NewNop())
+	mgr.AddDisconnectListener(listener)
+	require.NoError(t, mgr.ConnectToAgent(agentServerModel(t, srv, 5, "agent-token")))
+	defer mgr.DisconnectAgent(5)
+
+	select {
+	case id := <-listener.serverIDs:
+		assert.Equal(t, uint(5), id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a disconnect notification after the agent closed the connection")
+	}
+}
+
+func TestAgentClientDoesNotNotifyOnDeliberateDisconnect(t *testing.T) {
+	agent := fakeAgent(t, "agent-token", nil)
+
+	listener := &recordingDisconnectListener{serverIDs: make(chan uint, 4)}
+	mgr := NewAgentManager(NewStackEventRegistry(zap.NewNop()), zap.NewNop())
+	mgr.AddDisconnectListener(listener)
+	require.NoError(t, mgr.ConnectToAgent(agentServerModel(t, agent, 6, "agent-token")))
+
+	require.Eventually(t, func() bool {
+		return mgr.GetConnectionStatus(6)
+	}, 5*time.Second, 50*time.Millisecond)
+	mgr.DisconnectAgent(6)
+
+	select {
+	case id := <-listener.serverIDs:
+		t.Fatalf("unexpected disconnect notification for server %d", id)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"berth/internal/domain/auth"
+	"berth/internal/domain/operationlogs"
 	"berth/internal/domain/operations"
 	"berth/internal/domain/server"
 	"berth/internal/pkg/origin"
@@ -230,8 +231,10 @@ func (h *Handler) prepareTerminalMessage(ctx context.Context, userID int, server
 			Services: []string{},
 		}
 
+		uid := uint(userID)
 		log, err := h.auditService.LogOperationStart(
-			uint(userID),
+			&uid,
+			operationlogs.TriggerSourceManual,
 			uint(serverID),
 			urlStack,
 			operationID,
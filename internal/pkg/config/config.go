@@ -2,6 +2,7 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"strings"
 	"time"
@@ -20,7 +21,12 @@ type Config struct {
 	JWT          JWTConfig          `envPrefix:"JWT_"`
 	RefreshToken RefreshTokenConfig `envPrefix:"REFRESH_TOKEN_"`
 	TOTP         TOTPConfig         `envPrefix:"TOTP_"`
+	OIDC         OIDCConfig         `envPrefix:"OIDC_"`
+	LDAP         LDAPConfig         `envPrefix:"LDAP_"`
+	WebAuthn     WebAuthnConfig     `envPrefix:"WEBAUTHN_"`
+	ProxyAuth    ProxyAuthConfig    `envPrefix:"PROXY_AUTH_"`
 	RateLimit    RateLimitConfig    `envPrefix:"RATE_LIMIT_"`
+	Leader       LeaderConfig       `envPrefix:"LEADER_ELECTION_"`
 	Mail         MailConfig         `envPrefix:"MAIL_"`
 	Revocation   RevocationConfig   `envPrefix:"JWT_REVOCATION_"`
 	Retention    RetentionConfig    `envPrefix:"RETENTION_"`
@@ -28,9 +34,10 @@ type Config struct {
 }
 
 type RetentionConfig struct {
-	Interval         time.Duration `env:"INTERVAL" envDefault:"6h"`
-	AuditLogDays     int           `env:"AUDIT_LOG_DAYS" envDefault:"365"`
-	OperationLogDays int           `env:"OPERATION_LOG_DAYS" envDefault:"365"`
+	Interval            time.Duration `env:"INTERVAL" envDefault:"6h"`
+	AuditLogDays        int           `env:"AUDIT_LOG_DAYS" envDefault:"365"`
+	OperationLogDays    int           `env:"OPERATION_LOG_DAYS" envDefault:"365"`
+	WebhookDeliveryDays int           `env:"WEBHOOK_DELIVERY_DAYS" envDefault:"30"`
 }
 
 type AppConfig struct {
@@ -75,13 +82,36 @@ type AuthConfig struct {
 	EmailVerificationEnabled     bool          `env:"EMAIL_VERIFICATION_ENABLED" envDefault:"false"`
 	EmailVerificationTokenLength int           `env:"EMAIL_VERIFICATION_TOKEN_LENGTH" envDefault:"32"`
 	EmailVerificationExpiry      time.Duration `env:"EMAIL_VERIFICATION_EXPIRY" envDefault:"24h"`
+	InvitationExpiry             time.Duration `env:"INVITATION_EXPIRY" envDefault:"72h"`
+	LocalLoginDisabled           bool          `env:"LOCAL_LOGIN_DISABLED" envDefault:"false"`
+	RoleExpiryInterval           time.Duration `env:"ROLE_EXPIRY_INTERVAL" envDefault:"1m"`
+	AccessRequestMaxDuration     time.Duration `env:"ACCESS_REQUEST_MAX_DURATION" envDefault:"24h"`
+	AccessRequestExpiryInterval  time.Duration `env:"ACCESS_REQUEST_EXPIRY_INTERVAL" envDefault:"1m"`
+	Lockout                      LockoutConfig `envPrefix:"LOCKOUT_"`
 }
 
+// LockoutConfig controls per-account lockout after repeated failed logins.
+// Each lockout of the same account lasts twice as long as the previous one,
+// up to MaxDuration.
+type LockoutConfig struct {
+	Enabled     bool          `env:"ENABLED" envDefault:"true"`
+	Threshold   int           `env:"THRESHOLD" envDefault:"5"`
+	Duration    time.Duration `env:"DURATION" envDefault:"5m"`
+	MaxDuration time.Duration `env:"MAX_DURATION" envDefault:"24h"`
+	ResetAfter  time.Duration `env:"RESET_AFTER" envDefault:"24h"`
+	NotifyUser  bool          `env:"NOTIFY_USER" envDefault:"true"`
+}
+
+// JWTConfig configures access token signing. With HS256 tokens are signed
+// with SecretKey; with RS256 or EdDSA a generated key pair is used instead,
+// replaced every KeyRotationInterval and published at /.well-known/jwks.json.
+// SecretKey is required either way since other features derive keys from it.
 type JWTConfig struct {
-	SecretKey    string        `env:"SECRET_KEY"`
-	AccessExpiry time.Duration `env:"ACCESS_EXPIRY" envDefault:"15m"`
-	Issuer       string        `env:"ISSUER" envDefault:"berth"`
-	Algorithm    string        `env:"ALGORITHM" envDefault:"HS256"`
+	SecretKey           string        `env:"SECRET_KEY"`
+	AccessExpiry        time.Duration `env:"ACCESS_EXPIRY" envDefault:"15m"`
+	Issuer              string        `env:"ISSUER" envDefault:"berth"`
+	Algorithm           string        `env:"ALGORITHM" envDefault:"HS256"`
+	KeyRotationInterval time.Duration `env:"KEY_ROTATION_INTERVAL" envDefault:"720h"`
 }
 
 type RefreshTokenConfig struct {
@@ -95,8 +125,81 @@ type TOTPConfig struct {
 	Issuer  string `env:"ISSUER" envDefault:"berth"`
 }
 
+type OIDCConfig struct {
+	Enabled       bool     `env:"ENABLED" envDefault:"false"`
+	ProviderName  string   `env:"PROVIDER_NAME" envDefault:"SSO"`
+	IssuerURL     string   `env:"ISSUER_URL"`
+	ClientID      string   `env:"CLIENT_ID"`
+	ClientSecret  string   `env:"CLIENT_SECRET"`
+	RedirectURL   string   `env:"REDIRECT_URL"`
+	Scopes        []string `env:"SCOPES" envSeparator:"," envDefault:"openid,profile,email"`
+	UsernameClaim string   `env:"USERNAME_CLAIM" envDefault:"preferred_username"`
+	GroupsClaim   string   `env:"GROUPS_CLAIM" envDefault:"groups"`
+	RoleMapping   string   `env:"ROLE_MAPPING"`
+	AutoProvision bool     `env:"AUTO_PROVISION" envDefault:"true"`
+}
+
+type LDAPConfig struct {
+	Enabled            bool          `env:"ENABLED" envDefault:"false"`
+	URL                string        `env:"URL"`
+	StartTLS           bool          `env:"START_TLS" envDefault:"false"`
+	InsecureSkipVerify bool          `env:"INSECURE_SKIP_VERIFY" envDefault:"false"`
+	BindDN             string        `env:"BIND_DN"`
+	BindPassword       string        `env:"BIND_PASSWORD"`
+	UserBaseDN         string        `env:"USER_BASE_DN"`
+	UserFilter         string        `env:"USER_FILTER" envDefault:"(uid={username})"`
+	UsernameAttribute  string        `env:"USERNAME_ATTRIBUTE" envDefault:"uid"`
+	EmailAttribute     string        `env:"EMAIL_ATTRIBUTE" envDefault:"mail"`
+	GroupAttribute     string        `env:"GROUP_ATTRIBUTE" envDefault:"memberOf"`
+	GroupBaseDN        string        `env:"GROUP_BASE_DN"`
+	GroupFilter        string        `env:"GROUP_FILTER" envDefault:"(member={dn})"`
+	RoleMapping        string        `env:"ROLE_MAPPING"`
+	AutoProvision      bool          `env:"AUTO_PROVISION" envDefault:"true"`
+	Timeout            time.Duration `env:"TIMEOUT" envDefault:"10s"`
+}
+
+// WebAuthnConfig configures passkey and security key sign-in. RPID defaults
+// to the host of APP_URL and Origins to APP_URL itself.
+type WebAuthnConfig struct {
+	Enabled       bool          `env:"ENABLED" envDefault:"true"`
+	RPID          string        `env:"RP_ID"`
+	RPDisplayName string        `env:"RP_DISPLAY_NAME"`
+	Origins       []string      `env:"ORIGINS" envSeparator:","`
+	Timeout       time.Duration `env:"TIMEOUT" envDefault:"5m"`
+}
+
+// ProxyAuthConfig authenticates API requests by identity headers set by an
+// authenticating reverse proxy. Headers are only honoured on connections from
+// SERVER_TRUSTED_PROXIES; GroupsHeader is split on GroupSeparator and mapped
+// to roles with RoleMapping.
+type ProxyAuthConfig struct {
+	Enabled        bool   `env:"ENABLED" envDefault:"false"`
+	UserHeader     string `env:"USER_HEADER" envDefault:"Remote-User"`
+	EmailHeader    string `env:"EMAIL_HEADER" envDefault:"Remote-Email"`
+	GroupsHeader   string `env:"GROUPS_HEADER" envDefault:"Remote-Groups"`
+	GroupSeparator string `env:"GROUP_SEPARATOR" envDefault:","`
+	RoleMapping    string `env:"ROLE_MAPPING"`
+	AutoProvision  bool   `env:"AUTO_PROVISION" envDefault:"false"`
+}
+
+// RateLimitConfig controls request rate limiting. Store is "memory" to keep
+// counters per instance or "database" to share them between instances.
+//
+// Auth limits the unauthenticated /auth routes per IP and API limits failed
+// requests to the protected API per IP. Successful authenticated requests
+// are counted per user, or per API key when one is used; a key's own
+// RateLimit replaces APIKeyRate.
 type RateLimitConfig struct {
-	Enabled bool `env:"ENABLED" envDefault:"true"`
+	Enabled      bool          `env:"ENABLED" envDefault:"true"`
+	Store        string        `env:"STORE" envDefault:"memory"`
+	AuthRate     int           `env:"AUTH_RATE" envDefault:"25"`
+	AuthPeriod   time.Duration `env:"AUTH_PERIOD" envDefault:"1m"`
+	APIRate      int           `env:"API_RATE" envDefault:"1000"`
+	APIPeriod    time.Duration `env:"API_PERIOD" envDefault:"10m"`
+	UserRate     int           `env:"USER_RATE" envDefault:"1000"`
+	UserPeriod   time.Duration `env:"USER_PERIOD" envDefault:"10m"`
+	APIKeyRate   int           `env:"API_KEY_RATE" envDefault:"1000"`
+	APIKeyPeriod time.Duration `env:"API_KEY_PERIOD" envDefault:"10m"`
 }
 
 type MailConfig struct {
@@ -110,6 +213,18 @@ type MailConfig struct {
 	TemplatesDir string `env:"TEMPLATES_DIR" envDefault:"templates/mail"`
 }
 
+// LeaderConfig controls which instance runs the background workers
+// when several share one database. InstanceID defaults to the hostname plus
+// a random suffix.
+type LeaderConfig struct {
+	Enabled    bool          `env:"ENABLED" envDefault:"true"`
+	InstanceID string        `env:"INSTANCE_ID"`
+	LeaseTTL   time.Duration `env:"LEASE_TTL" envDefault:"30s"`
+}
+
+// RevocationConfig controls access token revocation. Store is "memory" to
+// check revocations per instance or "database" to share them between
+// instances.
 type RevocationConfig struct {
 	Enabled       bool          `env:"ENABLED" envDefault:"true"`
 	Store         string        `env:"STORE" envDefault:"memory"`
@@ -147,8 +262,55 @@ func LoadConfig(cfg any) error {
 		if err := validateRefreshTokenConfig(&config.RefreshToken); err != nil {
 			return err
 		}
+		if err := validateLoginMethods(config); err != nil {
+			return err
+		}
+		if err := validateLockoutConfig(&config.Auth.Lockout); err != nil {
+			return err
+		}
+		if err := validateMultiInstanceConfig(config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateMultiInstanceConfig(config *Config) error {
+	if config.Revocation.Store != "memory" && config.Revocation.Store != "database" {
+		return errors.New("JWT_REVOCATION_STORE must be memory or database")
 	}
+	if config.RateLimit.Store != "memory" && config.RateLimit.Store != "database" {
+		return errors.New("RATE_LIMIT_STORE must be memory or database")
+	}
+	if err := validateRateLimitConfig(&config.RateLimit); err != nil {
+		return err
+	}
+	if config.Leader.Enabled && config.Leader.LeaseTTL < 3*time.Second {
+		return errors.New("LEADER_ELECTION_LEASE_TTL must be at least 3s")
+	}
+	return nil
+}
 
+func validateRateLimitConfig(rl *RateLimitConfig) error {
+	if !rl.Enabled {
+		return nil
+	}
+	policies := []struct {
+		name   string
+		rate   int
+		period time.Duration
+	}{
+		{"AUTH", rl.AuthRate, rl.AuthPeriod},
+		{"API", rl.APIRate, rl.APIPeriod},
+		{"USER", rl.UserRate, rl.UserPeriod},
+		{"API_KEY", rl.APIKeyRate, rl.APIKeyPeriod},
+	}
+	for _, p := range policies {
+		if p.rate < 1 || p.period <= 0 {
+			return fmt.Errorf("RATE_LIMIT_%s_RATE and RATE_LIMIT_%s_PERIOD must be positive", p.name, p.name)
+		}
+	}
 	return nil
 }
 
@@ -165,6 +327,16 @@ func validateJWTConfig(jwt *JWTConfig) error {
 		}
 	}
 
+	switch jwt.Algorithm {
+	case "HS256":
+	case "RS256", "EdDSA":
+		if jwt.KeyRotationInterval <= jwt.AccessExpiry {
+			return errors.New("JWT_KEY_ROTATION_INTERVAL must be longer than JWT_ACCESS_EXPIRY")
+		}
+	default:
+		return errors.New("JWT_ALGORITHM must be HS256, RS256 or EdDSA")
+	}
+
 	return nil
 }
 
@@ -179,3 +351,46 @@ func validateRefreshTokenConfig(rt *RefreshTokenConfig) error {
 
 	return nil
 }
+
+func validateLockoutConfig(lc *LockoutConfig) error {
+	if !lc.Enabled {
+		return nil
+	}
+	if lc.Threshold < 1 {
+		return errors.New("AUTH_LOCKOUT_THRESHOLD must be at least 1")
+	}
+	if lc.Duration <= 0 || lc.MaxDuration < lc.Duration {
+		return errors.New("AUTH_LOCKOUT_DURATION must be positive and not exceed AUTH_LOCKOUT_MAX_DURATION")
+	}
+	return nil
+}
+
+func validateLoginMethods(cfg *Config) error {
+	if cfg.OIDC.Enabled && (cfg.OIDC.IssuerURL == "" || cfg.OIDC.ClientID == "") {
+		return errors.New("OIDC_ISSUER_URL and OIDC_CLIENT_ID are required when OIDC is enabled")
+	}
+
+	if cfg.LDAP.Enabled {
+		if cfg.LDAP.URL == "" || cfg.LDAP.UserBaseDN == "" {
+			return errors.New("LDAP_URL and LDAP_USER_BASE_DN are required when LDAP is enabled")
+		}
+		if !strings.Contains(cfg.LDAP.UserFilter, "{username}") {
+			return errors.New("LDAP_USER_FILTER must contain the {username} placeholder")
+		}
+	}
+
+	if cfg.ProxyAuth.Enabled {
+		if len(cfg.Server.TrustedProxies) == 0 {
+			return errors.New("SERVER_TRUSTED_PROXIES is required when proxy authentication is enabled")
+		}
+		if cfg.ProxyAuth.UserHeader == "" {
+			return errors.New("PROXY_AUTH_USER_HEADER is required when proxy authentication is enabled")
+		}
+	}
+
+	if cfg.Auth.LocalLoginDisabled && !cfg.OIDC.Enabled && !cfg.LDAP.Enabled && !cfg.ProxyAuth.Enabled {
+		return errors.New("local login cannot be disabled without another login method enabled")
+	}
+
+	return nil
+}
@@ -27,7 +27,6 @@ func enforceTOTPSettings() {
 }
 
 func enforceJWTSettings() {
-	os.Setenv("JWT_ALGORITHM", "HS256")
 	os.Setenv("JWT_ACCESS_EXPIRY", "15m")
 }
 
@@ -38,5 +37,4 @@ func enforceRefreshTokenSettings() {
 
 func enforceJWTRevocationSettings() {
 	os.Setenv("JWT_REVOCATION_ENABLED", "true")
-	os.Setenv("JWT_REVOCATION_STORE", "memory")
 }
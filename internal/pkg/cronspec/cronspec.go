@@ -0,0 +1,49 @@
+package cronspec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var ErrEmptySpec = errors.New("cron expression is required")
+
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+func Parse(spec string) (cron.Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, ErrEmptySpec
+	}
+	schedule, err := parser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	return schedule, nil
+}
+
+func Validate(spec string) error {
+	_, err := Parse(spec)
+	return err
+}
+
+func Next(spec string, from time.Time) (time.Time, error) {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(from), nil
+}
+
+// Within reports whether at falls inside a window of length d that opens at
+// each firing of spec. A spec that never fires is never open.
+func Within(spec string, d time.Duration, at time.Time) (bool, error) {
+	start, err := Next(spec, at.Add(-d))
+	if err != nil {
+		return false, err
+	}
+	return !start.IsZero() && !start.After(at), nil
+}
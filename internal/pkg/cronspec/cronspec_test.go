@@ -0,0 +1,82 @@
+package cronspec
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{"0 3 * * *", false},
+		{"*/15 * * * *", false},
+		{"0 2 * * 0", false},
+		{"@daily", false},
+		{"@every 1h", false},
+		{"", true},
+		{"   ", true},
+		{"0 3 * *", true},
+		{"0 0 3 * * *", true},
+		{"not a cron", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			err := Validate(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate_EmptyReturnsSentinel(t *testing.T) {
+	assert.True(t, errors.Is(Validate(""), ErrEmptySpec))
+}
+
+func TestNext(t *testing.T) {
+	from := time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC)
+
+	next, err := Next("0 3 * * *", from)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC), next)
+
+	next, err = Next("*/15 * * * *", from)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 1, 10, 45, 0, 0, time.UTC), next)
+}
+
+func TestWithin(t *testing.T) {
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"at opening", time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC), true},
+		{"inside", time.Date(2025, 1, 1, 2, 59, 0, 0, time.UTC), true},
+		{"at closing", time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC), false},
+		{"before opening", time.Date(2025, 1, 1, 1, 59, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Within("0 2 * * *", time.Hour, tt.at)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWithin_NeverFires(t *testing.T) {
+	got, err := Within("0 0 30 2 *", 24*time.Hour, time.Date(2025, 2, 28, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.False(t, got)
+}
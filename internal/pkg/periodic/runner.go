@@ -0,0 +1,86 @@
+package periodic
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LeaderGate reports whether this instance holds the lease for a job.
+type LeaderGate interface {
+	IsLeader() bool
+}
+
+// Runner calls a job on a fixed interval until it is stopped. The background
+// schedulers and workers are each a Runner around one service method.
+type Runner struct {
+	name     string
+	run      func(context.Context) error
+	logger   *zap.Logger
+	interval time.Duration
+	leader   LeaderGate
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewRunner returns a stopped runner for run. name labels its log messages,
+// e.g. "backup scheduler".
+func NewRunner(name string, interval time.Duration, run func(context.Context) error, logger *zap.Logger) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Runner{
+		name:     name,
+		run:      run,
+		logger:   logger,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// SetLeaderGate makes the runner skip its runs while another instance holds
+// the lease.
+func (r *Runner) SetLeaderGate(g LeaderGate) {
+	r.leader = g
+}
+
+// Start runs the job every interval in the background. A runner with no
+// interval is disabled and Start only logs that.
+func (r *Runner) Start() {
+	if r.interval <= 0 {
+		r.logger.Info(r.name + " disabled")
+		return
+	}
+
+	r.logger.Info("starting "+r.name,
+		zap.Duration("interval", r.interval),
+	)
+
+	go r.loop()
+}
+
+func (r *Runner) Stop() {
+	r.logger.Info("stopping " + r.name)
+	r.cancel()
+}
+
+func (r *Runner) loop() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if r.leader != nil && !r.leader.IsLeader() {
+				continue
+			}
+			if err := r.run(r.ctx); err != nil && r.ctx.Err() == nil {
+				r.logger.Error(r.name+" run failed", zap.Error(err))
+			}
+		case <-r.ctx.Done():
+			r.logger.Info(r.name + " stopped")
+			return
+		}
+	}
+}
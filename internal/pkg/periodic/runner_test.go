@@ -0,0 +1,73 @@
+package periodic
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakeGate struct {
+	leader atomic.Bool
+}
+
+func (g *fakeGate) IsLeader() bool {
+	return g.leader.Load()
+}
+
+func TestRunner_RunsOnlyWhileLeader(t *testing.T) {
+	var runs atomic.Int32
+	r := NewRunner("test scheduler", 5*time.Millisecond, func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}, zap.NewNop())
+	gate := &fakeGate{}
+	r.SetLeaderGate(gate)
+
+	r.Start()
+	defer r.Stop()
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Zero(t, runs.Load(), "followers must not run the job")
+
+	gate.leader.Store(true)
+	assert.Eventually(t, func() bool { return runs.Load() > 0 }, time.Second, 5*time.Millisecond)
+}
+
+func TestRunner_StopCancelsRunContext(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	var once atomic.Bool
+	r := NewRunner("test scheduler", 5*time.Millisecond, func(ctx context.Context) error {
+		if once.CompareAndSwap(false, true) {
+			close(started)
+			<-ctx.Done()
+			close(cancelled)
+		}
+		return ctx.Err()
+	}, zap.NewNop())
+
+	r.Start()
+	<-started
+	r.Stop()
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("run context was not cancelled by Stop")
+	}
+}
+
+func TestRunner_ZeroIntervalDisables(t *testing.T) {
+	r := NewRunner("test worker", 0, func(context.Context) error {
+		t.Error("a disabled runner must not run the job")
+		return nil
+	}, zap.NewNop())
+
+	r.Start()
+	time.Sleep(10 * time.Millisecond)
+	r.Stop()
+}
@@ -16,28 +16,44 @@ const (
 	CountNon2xx CountingMode = "non_2xx"
 )
 
+// Config describes one rate limit. A KeyFunc that returns an empty key lets
+// the request through uncounted, so limiters keyed on something only some
+// requests have can be stacked. RateFunc, when set, may return a rate for
+// the request that replaces Rate; zero or less keeps Rate.
 type Config struct {
-	Store     *Store
+	Store     Store
 	Name      string
 	Rate      int
 	Period    time.Duration
 	CountMode CountingMode
 	KeyFunc   func(echo.Context) string
+	RateFunc  func(echo.Context) int
 }
 
 func New(cfg Config) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			key := "rate_limit:" + cfg.Name + ":" + cfg.KeyFunc(c)
+			bucket := cfg.KeyFunc(c)
+			if bucket == "" {
+				return next(c)
+			}
+			rate := cfg.Rate
+			if cfg.RateFunc != nil {
+				if r := cfg.RateFunc(c); r > 0 {
+					rate = r
+				}
+			}
+
+			key := "rate_limit:" + cfg.Name + ":" + bucket
 			freshReset := time.Now().Add(cfg.Period)
 
 			if cfg.CountMode == CountAll {
-				allowed, count, reset := cfg.Store.Allow(key, cfg.Rate, freshReset)
+				allowed, count, reset := cfg.Store.Allow(key, rate, freshReset)
 				if !allowed {
-					writeHeaders(c, cfg.Rate, 0, reset)
+					writeHeaders(c, rate, 0, reset)
 					return echo.NewHTTPError(http.StatusTooManyRequests, "Too Many Requests")
 				}
-				writeHeaders(c, cfg.Rate, cfg.Rate-count, reset)
+				writeHeaders(c, rate, rate-count, reset)
 				return next(c)
 			}
 
@@ -47,11 +63,11 @@ func New(cfg Config) echo.MiddlewareFunc {
 				resetTime = existingReset
 			}
 
-			if count >= cfg.Rate {
-				writeHeaders(c, cfg.Rate, 0, resetTime)
+			if count >= rate {
+				writeHeaders(c, rate, 0, resetTime)
 				return echo.NewHTTPError(http.StatusTooManyRequests, "Too Many Requests")
 			}
-			writeHeaders(c, cfg.Rate, cfg.Rate-(count+1), resetTime)
+			writeHeaders(c, rate, rate-(count+1), resetTime)
 
 			err := next(c)
 
@@ -81,7 +97,17 @@ func KeyByIP(c echo.Context) string {
 	return ip
 }
 
-type Store struct {
+// Store holds the request counters for every rate limit. MemoryStore keeps
+// them in process; SQLStore shares them between instances through the
+// database.
+type Store interface {
+	Get(key string) (count int, resetTime time.Time, exists bool)
+	Allow(key string, rate int, resetTime time.Time) (allowed bool, count int, reset time.Time)
+	Increment(key string, resetTime time.Time) int
+	Stop()
+}
+
+type MemoryStore struct {
 	mu       sync.Mutex
 	data     map[string]*entry
 	stop     chan struct{}
@@ -93,17 +119,17 @@ type entry struct {
 	resetTime time.Time
 }
 
-func NewStore() *Store {
-	s := &Store{data: make(map[string]*entry), stop: make(chan struct{})}
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{data: make(map[string]*entry), stop: make(chan struct{})}
 	go s.cleanupLoop()
 	return s
 }
 
-func (s *Store) Stop() {
+func (s *MemoryStore) Stop() {
 	s.stopOnce.Do(func() { close(s.stop) })
 }
 
-func (s *Store) Get(key string) (count int, resetTime time.Time, exists bool) {
+func (s *MemoryStore) Get(key string) (count int, resetTime time.Time, exists bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if e, ok := s.data[key]; ok && time.Now().Before(e.resetTime) {
@@ -112,7 +138,7 @@ func (s *Store) Get(key string) (count int, resetTime time.Time, exists bool) {
 	return 0, time.Time{}, false
 }
 
-func (s *Store) Allow(key string, rate int, resetTime time.Time) (allowed bool, count int, reset time.Time) {
+func (s *MemoryStore) Allow(key string, rate int, resetTime time.Time) (allowed bool, count int, reset time.Time) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if e, ok := s.data[key]; ok && time.Now().Before(e.resetTime) {
@@ -126,7 +152,7 @@ func (s *Store) Allow(key string, rate int, resetTime time.Time) (allowed bool,
 	return true, 1, resetTime
 }
 
-func (s *Store) Increment(key string, resetTime time.Time) int {
+func (s *MemoryStore) Increment(key string, resetTime time.Time) int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if e, ok := s.data[key]; ok && time.Now().Before(e.resetTime) {
@@ -137,7 +163,7 @@ func (s *Store) Increment(key string, resetTime time.Time) int {
 	return 1
 }
 
-func (s *Store) cleanupLoop() {
+func (s *MemoryStore) cleanupLoop() {
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
 	for {
@@ -20,9 +20,9 @@ func newTestEcho() *echo.Echo {
 	return e
 }
 
-func newStore(t *testing.T) *Store {
+func newStore(t *testing.T) *MemoryStore {
 	t.Helper()
-	s := NewStore()
+	s := NewMemoryStore()
 	t.Cleanup(s.Stop)
 	return s
 }
@@ -204,6 +204,49 @@ func TestKeyByIPIgnoresUserAgent(t *testing.T) {
 		"rotating User-Agent must NOT grant an attacker an independent bucket")
 }
 
+func TestEmptyKeySkipsLimit(t *testing.T) {
+	e := newTestEcho()
+	e.GET("/k", func(c echo.Context) error { return c.String(http.StatusOK, "ok") },
+		New(Config{Store: newStore(t), Rate: 1, Period: time.Minute, CountMode: CountAll,
+			KeyFunc: func(echo.Context) string { return "" }}))
+
+	for _, rec := range sendN(t, e, "/k", 3) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("X-RateLimit-Limit"), "unkeyed requests are not counted")
+	}
+}
+
+func TestRateFuncOverridesRate(t *testing.T) {
+	e := newTestEcho()
+	e.GET("/r", func(c echo.Context) error { return c.String(http.StatusOK, "ok") },
+		New(Config{Store: newStore(t), Rate: 5, Period: time.Minute, CountMode: CountAll, KeyFunc: KeyByIP,
+			RateFunc: func(c echo.Context) int {
+				if c.Request().Header.Get("X-Override") != "" {
+					return 2
+				}
+				return 0
+			}}))
+
+	send := func(ip string, override bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/r", nil)
+		req.RemoteAddr = ip + ":1"
+		if override {
+			req.Header.Set("X-Override", "1")
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("4.4.4.4", true)
+	assert.Equal(t, "2", first.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, http.StatusOK, send("4.4.4.4", true).Code)
+	assert.Equal(t, http.StatusTooManyRequests, send("4.4.4.4", true).Code)
+
+	other := send("5.5.5.5", false)
+	assert.Equal(t, "5", other.Header().Get("X-RateLimit-Limit"), "a zero rate keeps the configured one")
+}
+
 func TestStoreGetExpiredReturnsZero(t *testing.T) {
 	s := newStore(t)
 	s.data["k"] = &entry{count: 7, resetTime: time.Now().Add(20 * time.Millisecond)}
@@ -0,0 +1,164 @@
+package ratelimit
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sqlWriteAttempts bounds retries when another instance creates or resets a
+// counter between our conditional update and insert.
+const sqlWriteAttempts = 3
+
+// Counter is a rate-limit window shared between instances by SQLStore.
+type Counter struct {
+	Bucket  string    `gorm:"primaryKey;size:255"`
+	Hits    int       `gorm:"not null"`
+	ResetAt time.Time `gorm:"index;not null"`
+}
+
+func (Counter) TableName() string {
+	return "rate_limit_counters"
+}
+
+// SQLStore keeps counters in the application database so every instance
+// enforces the same limits. Each change is a single conditional statement,
+// which keeps it atomic on SQLite, PostgreSQL and MySQL alike. If the
+// database is unavailable requests are allowed rather than rejected.
+type SQLStore struct {
+	db       *gorm.DB
+	logger   *zap.Logger
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewSQLStore(db *gorm.DB, logger *zap.Logger) (*SQLStore, error) {
+	if err := db.AutoMigrate(&Counter{}); err != nil {
+		return nil, err
+	}
+	s := &SQLStore{db: db, logger: logger, stop: make(chan struct{})}
+	go s.cleanupLoop()
+	return s, nil
+}
+
+func (s *SQLStore) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+func (s *SQLStore) Get(key string) (count int, resetTime time.Time, exists bool) {
+	var c Counter
+	err := s.db.Where("bucket = ? AND reset_at > ?", key, time.Now().UTC()).Take(&c).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Error("rate limit lookup failed", zap.String("bucket", key), zap.Error(err))
+		}
+		return 0, time.Time{}, false
+	}
+	return c.Hits, c.ResetAt, true
+}
+
+func (s *SQLStore) Allow(key string, rate int, resetTime time.Time) (allowed bool, count int, reset time.Time) {
+	for range sqlWriteAttempts {
+		now := time.Now().UTC()
+
+		res := s.db.Model(&Counter{}).
+			Where("bucket = ? AND reset_at > ? AND hits < ?", key, now, rate).
+			Update("hits", gorm.Expr("hits + 1"))
+		if res.Error != nil {
+			return s.failOpen(key, resetTime, res.Error)
+		}
+		if res.RowsAffected == 1 {
+			if count, reset, ok := s.Get(key); ok {
+				return true, count, reset
+			}
+			return true, rate, resetTime
+		}
+
+		if count, reset, ok := s.Get(key); ok && count >= rate {
+			return false, count, reset
+		}
+
+		started, err := s.startWindow(key, resetTime, now)
+		if err != nil {
+			return s.failOpen(key, resetTime, err)
+		}
+		if started {
+			return true, 1, resetTime
+		}
+	}
+	return s.failOpen(key, resetTime, errors.New("counter kept changing"))
+}
+
+func (s *SQLStore) Increment(key string, resetTime time.Time) int {
+	for range sqlWriteAttempts {
+		now := time.Now().UTC()
+
+		res := s.db.Model(&Counter{}).
+			Where("bucket = ? AND reset_at > ?", key, now).
+			Update("hits", gorm.Expr("hits + 1"))
+		if res.Error != nil {
+			s.logger.Error("rate limit increment failed", zap.String("bucket", key), zap.Error(res.Error))
+			return 0
+		}
+		if res.RowsAffected == 1 {
+			count, _, _ := s.Get(key)
+			return count
+		}
+
+		started, err := s.startWindow(key, resetTime, now)
+		if err != nil {
+			s.logger.Error("rate limit increment failed", zap.String("bucket", key), zap.Error(err))
+			return 0
+		}
+		if started {
+			return 1
+		}
+	}
+	return 0
+}
+
+// startWindow opens a new window with one hit, either by resetting an
+// expired counter or by creating it. It reports false if another instance
+// got there first, in which case the caller retries against that window.
+func (s *SQLStore) startWindow(key string, resetTime, now time.Time) (bool, error) {
+	res := s.db.Model(&Counter{}).
+		Where("bucket = ? AND reset_at <= ?", key, now).
+		Updates(map[string]any{"hits": 1, "reset_at": resetTime.UTC()})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return true, nil
+	}
+
+	res = s.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Counter{Bucket: key, Hits: 1, ResetAt: resetTime.UTC()})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (s *SQLStore) failOpen(key string, resetTime time.Time, err error) (bool, int, time.Time) {
+	s.logger.Error("rate limit check failed; allowing request", zap.String("bucket", key), zap.Error(err))
+	return true, 0, resetTime
+}
+
+func (s *SQLStore) cleanupLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.db.Where("reset_at <= ?", time.Now().UTC()).Delete(&Counter{}).Error; err != nil {
+				s.logger.Error("rate limit cleanup failed", zap.Error(err))
+			}
+		}
+	}
+}
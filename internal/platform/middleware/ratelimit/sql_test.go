@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var dbCounter atomic.Int64
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:ratelimit_%d?mode=memory&cache=shared", dbCounter.Add(1))
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := database.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return database
+}
+
+func newSQLStore(t *testing.T, db *gorm.DB) *SQLStore {
+	t.Helper()
+	s, err := NewSQLStore(db, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func TestSQLStoreAllowBlocksAtLimit(t *testing.T) {
+	s := newSQLStore(t, newTestDB(t))
+	reset := time.Now().Add(time.Minute)
+
+	for i := 1; i <= 3; i++ {
+		allowed, count, _ := s.Allow("k", 3, reset)
+		require.True(t, allowed)
+		assert.Equal(t, i, count)
+	}
+	allowed, count, got := s.Allow("k", 3, time.Now().Add(time.Hour))
+	assert.False(t, allowed)
+	assert.Equal(t, 3, count)
+	assert.WithinDuration(t, reset, got, time.Second, "a blocked request reports the existing window")
+}
+
+func TestSQLStoreWindowResets(t *testing.T) {
+	db := newTestDB(t)
+	s := newSQLStore(t, db)
+
+	allowed, _, _ := s.Allow("k", 1, time.Now().Add(time.Minute))
+	require.True(t, allowed)
+	allowed, _, _ = s.Allow("k", 1, time.Now().Add(time.Minute))
+	require.False(t, allowed)
+
+	require.NoError(t, db.Model(&Counter{}).Where("bucket = ?", "k").Update("reset_at", time.Now().UTC().Add(-time.Second)).Error)
+	allowed, count, _ := s.Allow("k", 1, time.Now().Add(time.Minute))
+	assert.True(t, allowed)
+	assert.Equal(t, 1, count)
+}
+
+func TestSQLStoreIncrementAndGet(t *testing.T) {
+	s := newSQLStore(t, newTestDB(t))
+
+	_, _, exists := s.Get("k")
+	assert.False(t, exists)
+
+	reset := time.Now().Add(time.Minute)
+	assert.Equal(t, 1, s.Increment("k", reset))
+	assert.Equal(t, 2, s.Increment("k", reset))
+
+	count, got, exists := s.Get("k")
+	require.True(t, exists)
+	assert.Equal(t, 2, count)
+	assert.WithinDuration(t, reset, got, time.Second)
+}
+
+func TestSQLStoreSharedBetweenInstances(t *testing.T) {
+	db := newTestDB(t)
+	first := New(Config{Store: newSQLStore(t, db), Name: "shared", Rate: 2, Period: time.Minute, CountMode: CountAll, KeyFunc: KeyByIP})
+	second := New(Config{Store: newSQLStore(t, db), Name: "shared", Rate: 2, Period: time.Minute, CountMode: CountAll, KeyFunc: KeyByIP})
+
+	handler := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+	e1, e2 := newTestEcho(), newTestEcho()
+	e1.GET("/x", handler, first)
+	e2.GET("/x", handler, second)
+
+	send := func(e *echo.Echo) int {
+		req := httptest.NewRequest(http.MethodGet, "/x", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusOK, send(e1))
+	assert.Equal(t, http.StatusOK, send(e2))
+	assert.Equal(t, http.StatusTooManyRequests, send(e1))
+	assert.Equal(t, http.StatusTooManyRequests, send(e2))
+}
+
+func TestSQLStoreCountNon2xx(t *testing.T) {
+	e := newTestEcho()
+	e.GET("/fail", func(c echo.Context) error { return c.String(http.StatusUnauthorized, "bad") },
+		New(Config{Store: newSQLStore(t, newTestDB(t)), Rate: 2, Period: time.Minute, CountMode: CountNon2xx, KeyFunc: KeyByIP}))
+
+	recs := sendN(t, e, "/fail", 3)
+	assert.Equal(t, http.StatusUnauthorized, recs[0].Code)
+	assert.Equal(t, http.StatusUnauthorized, recs[1].Code)
+	assert.Equal(t, http.StatusTooManyRequests, recs[2].Code)
+}
@@ -12,10 +12,15 @@ type Task struct {
 	Run  func() error
 }
 
+type leaderGate interface {
+	IsLeader() bool
+}
+
 type Worker struct {
 	interval time.Duration
 	tasks    []Task
 	logger   *zap.Logger
+	leader   leaderGate
 
 	stop    chan struct{}
 	stopped chan struct{}
@@ -25,6 +30,11 @@ func NewWorker(interval time.Duration, logger *zap.Logger, tasks ...Task) *Worke
 	return &Worker{interval: interval, tasks: tasks, logger: logger}
 }
 
+// SetLeaderGate restricts retention runs to the instance holding the lease.
+func (w *Worker) SetLeaderGate(g leaderGate) {
+	w.leader = g
+}
+
 func (w *Worker) Start(context.Context) error {
 	if w.interval <= 0 || len(w.tasks) == 0 {
 		w.logger.Info("retention worker disabled",
@@ -50,6 +60,9 @@ func (w *Worker) loop() {
 	for {
 		select {
 		case <-ticker.C:
+			if w.leader != nil && !w.leader.IsLeader() {
+				continue
+			}
 			w.runAll()
 		case <-w.stop:
 			return
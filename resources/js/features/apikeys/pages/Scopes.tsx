@@ -32,6 +32,7 @@ import {
   PERM_BACKUPS_READ,
   PERM_BACKUPS_MANAGE,
   PERM_BACKUPS_RESTORE,
+  PERM_STACKS_MAINTENANCE_OVERRIDE,
   PERM_DOCKER_MAINTENANCE_READ,
   PERM_DOCKER_MAINTENANCE_WRITE,
   PERM_ADMIN_SERVERS_READ,
@@ -45,6 +46,8 @@ import {
   PERM_ADMIN_AUDIT_READ,
   PERM_ADMIN_SYSTEM_EXPORT,
   PERM_ADMIN_SYSTEM_IMPORT,
+  PERM_ADMIN_WEBHOOKS_READ,
+  PERM_ADMIN_WEBHOOKS_WRITE,
 } from '../../../shared/constants/permissions';
 
 interface NewScopeForm {
@@ -82,6 +85,10 @@ export const PERMISSIONS = [
     value: PERM_BACKUPS_RESTORE,
     label: 'Restore stack backups (overwrites current stack data)',
   },
+  {
+    value: PERM_STACKS_MAINTENANCE_OVERRIDE,
+    label: 'Run up, down, restart and restore-backup outside maintenance windows',
+  },
   {
     value: PERM_DOCKER_MAINTENANCE_READ,
     label: 'View Docker usage statistics and system information (server-wide)',
@@ -102,6 +109,8 @@ export const PERMISSIONS = [
   { value: PERM_ADMIN_AUDIT_READ, label: 'View security audit logs (admin)' },
   { value: PERM_ADMIN_SYSTEM_EXPORT, label: 'Export system configuration (admin)' },
   { value: PERM_ADMIN_SYSTEM_IMPORT, label: 'Import system configuration (admin)' },
+  { value: PERM_ADMIN_WEBHOOKS_READ, label: 'View webhooks and deliveries (admin)' },
+  { value: PERM_ADMIN_WEBHOOKS_WRITE, label: 'Manage and test webhooks (admin)' },
 ];
 
 export default function APIKeyScopesPage() {
@@ -10,6 +10,8 @@ export const PERM_REGISTRIES_MANAGE = 'registries.manage';
 export const PERM_BACKUPS_READ = 'backups.read';
 export const PERM_BACKUPS_MANAGE = 'backups.manage';
 export const PERM_BACKUPS_RESTORE = 'backups.restore';
+export const PERM_STACKS_MAINTENANCE_OVERRIDE = 'stacks.maintenance.override';
+export const PERM_STACKS_ACCESS_APPROVE = 'stacks.access.approve';
 
 export const PERM_ADMIN_USERS_READ = 'admin.users.read';
 export const PERM_ADMIN_USERS_WRITE = 'admin.users.write';
@@ -22,6 +24,8 @@ export const PERM_ADMIN_LOGS_READ = 'admin.logs.read';
 export const PERM_ADMIN_AUDIT_READ = 'admin.audit.read';
 export const PERM_ADMIN_SYSTEM_EXPORT = 'admin.system.export';
 export const PERM_ADMIN_SYSTEM_IMPORT = 'admin.system.import';
+export const PERM_ADMIN_WEBHOOKS_READ = 'admin.webhooks.read';
+export const PERM_ADMIN_WEBHOOKS_WRITE = 'admin.webhooks.write';
 
 export const PERM_SERVERS_READ = 'servers.read';
 export const PERM_LOGS_OPERATIONS_READ = 'logs.operations.read';
@@ -3,25 +3,40 @@ package routes
 import (
 	"berth/internal/domain/operationlogs"
 	"berth/internal/domain/operations"
+	"berth/internal/domain/operationschedules"
 	"net/http"
 
+	"berth/internal/domain/accessrequests"
 	"berth/internal/domain/apikey"
 	"berth/internal/domain/auth"
+	"berth/internal/domain/auth/lockout"
+	"berth/internal/domain/auth/tokens"
+	"berth/internal/domain/autoupdates"
+	"berth/internal/domain/backupretention"
 	"berth/internal/domain/backups"
+	"berth/internal/domain/backupschedules"
 	"berth/internal/domain/dataexport"
 	"berth/internal/domain/files"
 	"berth/internal/domain/imageupdates"
+	"berth/internal/domain/invitations"
+	"berth/internal/domain/leader"
 	"berth/internal/domain/logs"
 	"berth/internal/domain/maintenance"
+	"berth/internal/domain/maintwindows"
+	"berth/internal/domain/prunepolicies"
 	"berth/internal/domain/rbac"
 	"berth/internal/domain/registry"
+	"berth/internal/domain/scanschedules"
 	"berth/internal/domain/security"
 	"berth/internal/domain/server"
 	"berth/internal/domain/session"
 	"berth/internal/domain/stack"
+	"berth/internal/domain/updatedigests"
 	"berth/internal/domain/user"
 	"berth/internal/domain/version"
+	"berth/internal/domain/vulnalerts"
 	"berth/internal/domain/vulnscan"
+	"berth/internal/domain/webhooks"
 	"berth/internal/domain/websocket"
 	"berth/internal/pkg/config"
 	"berth/internal/pkg/response"
@@ -49,15 +64,43 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 	apiDoc.Document("POST", "/api/v1/auth/login").
 		Tags("auth").
 		Summary("Login with username and password").
-		Description("Authenticates a user with username and password. The 200 response is one of two shapes: AuthLoginData (full access and refresh tokens, plus user info) when login completes immediately, or AuthTOTPRequiredData (totp_required=true with a temporary token) when TOTP is enabled and the caller must complete /auth/totp/verify next. Clients should branch on the totp_required field. On full success the response also sets a `berth_refresh` cookie (HttpOnly, Secure, SameSite=Strict, Path=/api/v1/auth) carrying the refresh token for browser clients; mobile/CLI clients can keep using the body-returned `refresh_token`.").
+		Description("Authenticates a user with username and password. The 200 response is one of two shapes: AuthLoginData (full access and refresh tokens, plus user info) when login completes immediately, or AuthTOTPRequiredData (totp_required=true with a temporary token) when a second factor is enrolled and the caller must complete /auth/totp/verify or /auth/passkey/verify next. A third shape, AuthTwoFactorEnrollmentData (enrollment_required=true), is returned when one of the user's roles requires two-factor authentication and none is enrolled: its short-lived enrollment token is only accepted by the profile, logout, TOTP setup and passkey registration endpoints, and the user signs in again once enrolled. Clients should branch on the totp_required and enrollment_required fields; second_factor_methods lists which of `totp` and `passkey` the account can use. Repeated failed password or TOTP attempts lock the account for a period that doubles with each repeat lockout. When LDAP is enabled the credentials are checked against the local account first and then the directory; directory users are provisioned or linked on first login and their email and mapped roles are synced. On full success the response also sets a `berth_refresh` cookie (HttpOnly, Secure, SameSite=Strict, Path=/api/v1/auth) carrying the refresh token for browser clients; mobile/CLI clients can keep using the body-returned `refresh_token`.").
 		Body(auth.AuthLoginRequest{}, "Login credentials").
-		ResponseOneOf(http.StatusOK, "Login outcome — full tokens or a TOTP challenge",
+		ResponseOneOf(http.StatusOK, "Login outcome — full tokens, a TOTP challenge or a two-factor enrollment requirement",
 			response.Response[auth.AuthLoginData]{},
 			response.Response[auth.AuthTOTPRequiredData]{},
+			response.Response[auth.AuthTwoFactorEnrollmentData]{},
 		).
 		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request format").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Invalid credentials").
-		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Email not verified").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Email not verified, or password login disabled").
+		Response(http.StatusLocked, response.ErrorResponseBody{}, "Account temporarily locked after too many failed attempts; see the Retry-After header").
+		Response(http.StatusServiceUnavailable, response.ErrorResponseBody{}, "LDAP directory unavailable").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/auth/methods").
+		Tags("auth").
+		Summary("List available login methods").
+		Description("Reports whether username/password login is enabled for local accounts and for LDAP directory accounts, whether passkey sign-in is available and, when OpenID Connect single sign-on is configured, the provider's display name and the URL that starts the SSO flow. Login pages use this to decide which options to show.").
+		Response(http.StatusOK, response.Response[auth.AuthMethodsData]{}, "Available login methods").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/auth/oidc/login").
+		Tags("auth").
+		Summary("Start OpenID Connect single sign-on").
+		Description("Redirects the browser to the configured identity provider using the authorization code flow with PKCE. A short-lived `berth_oidc_state` cookie (HttpOnly, Secure, SameSite=Lax, Path=/api/v1/auth/oidc) binds the flow to this browser.").
+		Response(http.StatusFound, nil, "Redirect to the identity provider").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Single sign-on is not configured").
+		Response(http.StatusBadGateway, response.ErrorResponseBody{}, "Identity provider could not be reached").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/auth/oidc/callback").
+		Tags("auth").
+		Summary("Complete OpenID Connect single sign-on").
+		Description("Redirect target registered with the identity provider. Validates the state against the `berth_oidc_state` cookie, exchanges the code, verifies the ID token and signs the user in (linking or provisioning the account and syncing mapped roles). On success it sets the `berth_refresh` cookie and redirects to `/`; on failure it redirects to `/auth/login?sso_error=<code>`.").
+		QueryParam("code", "Authorization code issued by the identity provider").
+		QueryParam("state", "State value from the authorization request").
+		Response(http.StatusFound, nil, "Redirect to the application or back to the login page").
 		Build()
 
 	apiDoc.Document("POST", "/api/v1/auth/refresh").
@@ -74,11 +117,61 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 	apiDoc.Document("POST", "/api/v1/auth/totp/verify").
 		Tags("auth").
 		Summary("Verify TOTP code to complete login").
-		Description("Completes the login flow when TOTP is enabled. Requires the temporary token from /auth/login and a valid TOTP code from the authenticator app. On success the response also sets a `berth_refresh` cookie (HttpOnly, Secure, SameSite=Strict, Path=/api/v1/auth) carrying the refresh token for browser clients.").
+		Description("Completes the login flow when TOTP is enabled. Requires the temporary token from /auth/login and a valid TOTP code from the authenticator app, or one of the user's unused recovery codes (xxxx-xxxx-xxxx) in the same field. A recovery code is consumed on use. On success the response also sets a `berth_refresh` cookie (HttpOnly, Secure, SameSite=Strict, Path=/api/v1/auth) carrying the refresh token for browser clients.").
 		Body(auth.AuthTOTPVerifyRequest{}, "TOTP verification code").
 		Response(http.StatusOK, response.Response[auth.AuthLoginData]{}, "TOTP verified - returns access and refresh tokens").
 		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request format").
-		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Invalid or expired token, or invalid TOTP code").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Invalid or expired token, invalid TOTP code, or invalid or used recovery code").
+		Response(http.StatusLocked, response.ErrorResponseBody{}, "Account temporarily locked after too many failed attempts; see the Retry-After header").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Token generation failed").
+		Security("bearerAuth").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/auth/passkey/login/begin").
+		Tags("auth").
+		Summary("Start passwordless passkey login").
+		Description("Returns WebAuthn assertion options for a discoverable-credential (usernameless) login, to be passed to navigator.credentials.get(). The session_id must be sent back with the authenticator response; it is single use and expires after WEBAUTHN_TIMEOUT. Passkey sign-in is controlled by WEBAUTHN_ENABLED and stays available when AUTH_LOCAL_LOGIN_DISABLED is set.").
+		Response(http.StatusOK, response.Response[auth.PasskeyAssertionOptionsData]{}, "Assertion options and ceremony session ID").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Passkeys are not enabled").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/auth/passkey/login/finish").
+		Tags("auth").
+		Summary("Complete passwordless passkey login").
+		Description("Verifies the authenticator response (which must include user verification) and signs in the account that owns the passkey. No further second factor is required. On success the response also sets the `berth_refresh` cookie (HttpOnly, Secure, SameSite=Strict, Path=/api/v1/auth).").
+		Body(auth.PasskeyAssertionRequest{}, "Ceremony session ID and authenticator response").
+		Response(http.StatusOK, response.Response[auth.AuthLoginData]{}, "Passkey verified - returns access and refresh tokens").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request, or the challenge is invalid or expired").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Passkey could not be verified").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Email not verified").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Passkeys are not enabled").
+		Response(http.StatusLocked, response.ErrorResponseBody{}, "Account temporarily locked after too many failed attempts; see the Retry-After header").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Token generation failed").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/auth/passkey/verify/begin").
+		Tags("auth").
+		Summary("Start passkey second-factor verification").
+		Description("Returns WebAuthn assertion options restricted to the user's registered passkeys. Requires the temporary token from /auth/login as a Bearer token.").
+		Response(http.StatusOK, response.Response[auth.PasskeyAssertionOptionsData]{}, "Assertion options and ceremony session ID").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "No passkeys are registered for this account").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Invalid or expired token").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Passkeys are not enabled").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/auth/passkey/verify/finish").
+		Tags("auth").
+		Summary("Complete login with a passkey second factor").
+		Description("Completes the login flow as an alternative to /auth/totp/verify. Requires the temporary token from /auth/login as a Bearer token and the authenticator response. On success the response also sets the `berth_refresh` cookie (HttpOnly, Secure, SameSite=Strict, Path=/api/v1/auth).").
+		Body(auth.PasskeyAssertionRequest{}, "Ceremony session ID and authenticator response").
+		Response(http.StatusOK, response.Response[auth.AuthLoginData]{}, "Passkey verified - returns access and refresh tokens").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request, or the challenge is invalid or expired").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Invalid or expired token, or passkey could not be verified").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Passkeys are not enabled").
+		Response(http.StatusLocked, response.ErrorResponseBody{}, "Account temporarily locked after too many failed attempts; see the Retry-After header").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Token generation failed").
 		Security("bearerAuth").
 		Build()
@@ -138,6 +231,45 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Failed to send verification email").
 		Build()
 
+	apiDoc.Document("POST", "/api/v1/auth/email-change/confirm").
+		Tags("auth").
+		Summary("Confirm an email change").
+		Description("Completes a change requested with /profile/email using the token from the link sent to the new address. The new address is marked verified and the previous address is notified. The token is single-use.").
+		Body(auth.ConfirmEmailChangeRequest{}, "Email change token").
+		Response(http.StatusOK, response.Response[auth.AuthMessageData]{}, "Email changed successfully").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request, or invalid/expired/used token").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Email address is already in use").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Email change failed").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/auth/invitations/lookup").
+		Tags("auth").
+		Summary("Look up an invitation").
+		Description("Returns the email address, inviter and roles for an invitation token so the accept page can show what the invitee is signing up for.").
+		Body(invitations.LookupInvitationRequest{}, "Invitation token").
+		Response(http.StatusOK, response.Response[invitations.LookupInvitationData]{}, "Invitation details").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request, or invalid/expired/revoked invitation").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/auth/invitations/accept").
+		Tags("auth").
+		Summary("Accept an invitation").
+		Description("Creates an account for the invited email address with the chosen username and password and assigns the invited roles. The email address is marked verified. The invitation can only be accepted once.").
+		Body(invitations.AcceptInvitationRequest{}, "Invitation token and account details").
+		Response(http.StatusCreated, response.Response[invitations.AcceptInvitationData]{}, "Account created").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request, weak password, or invalid/expired/revoked invitation").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Username or email address is already in use").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Build()
+
+	apiDoc.Document("GET", "/.well-known/jwks.json").
+		Tags("auth").
+		Summary("Access token verification keys").
+		Description("Returns the public keys that currently verify access tokens as a bare RFC 7517 key set, not wrapped in the usual envelope. Keys are matched by the token's `kid` header. The set is empty when JWT_ALGORITHM is HS256.").
+		Response(http.StatusOK, tokens.JWKSet{}, "JSON Web Key Set").
+		Build()
+
 	apiDoc.Document("GET", "/api/v1/sessions").
 		Tags("sessions").
 		Summary("List user sessions").
@@ -413,6 +545,7 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request body").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Outside the stack's maintenance window").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
@@ -660,6 +793,104 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/prune-policies").
+		Tags("prune-policies").
+		Summary("List prune policies").
+		Description("Returns the server's scheduled Docker prune policies. Requires docker.maintenance.read permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[prunepolicies.ListPoliciesData]{}, "List of prune policies").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/prune-policies").
+		Tags("prune-policies").
+		Summary("Create prune policy").
+		Description("Creates a policy that prunes the listed resource types on a cron schedule. min_age_hours and labels are passed to Docker as until and label prune filters. Requires docker.maintenance.write permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		Body(prunepolicies.PolicyRequest{}, "Policy details").
+		Response(http.StatusCreated, response.Response[prunepolicies.GetPolicyData]{}, "Prune policy created").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/prune-policies/preview").
+		Tags("prune-policies").
+		Summary("Preview prune settings").
+		Description("Estimates what unsaved prune settings would remove and how much space they would reclaim, from the agent's Docker system information. Nothing is removed. Requires docker.maintenance.read permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		Body(prunepolicies.PreviewRequest{}, "Prune settings to preview").
+		Response(http.StatusOK, response.Response[prunepolicies.PreviewData]{}, "Prune preview").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/prune-policies/{id}").
+		Tags("prune-policies").
+		Summary("Get prune policy").
+		Description("Returns a single prune policy, including the result of its last run. Requires docker.maintenance.read permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("id", "Policy ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[prunepolicies.GetPolicyData]{}, "Prune policy").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Prune policy not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("PUT", "/api/v1/servers/{serverid}/prune-policies/{id}").
+		Tags("prune-policies").
+		Summary("Update prune policy").
+		Description("Replaces a prune policy's settings and recomputes its next run. Requires docker.maintenance.write permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("id", "Policy ID").TypeInt().Required().
+		Body(prunepolicies.PolicyRequest{}, "Policy details").
+		Response(http.StatusOK, response.Response[prunepolicies.GetPolicyData]{}, "Prune policy updated").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Prune policy not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("DELETE", "/api/v1/servers/{serverid}/prune-policies/{id}").
+		Tags("prune-policies").
+		Summary("Delete prune policy").
+		Description("Deletes a prune policy. Requires docker.maintenance.write permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("id", "Policy ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[prunepolicies.DeletePolicyMessageData]{}, "Prune policy deleted successfully").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Prune policy not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/prune-policies/{id}/preview").
+		Tags("prune-policies").
+		Summary("Preview prune policy").
+		Description("Estimates what the policy would remove if it ran now and how much space it would reclaim. Nothing is removed. Requires docker.maintenance.read permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("id", "Policy ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[prunepolicies.PreviewData]{}, "Prune preview").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Prune policy not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
 	apiDoc.Document("DELETE", "/api/v1/servers/{serverid}/maintenance/resource").
 		Tags("maintenance").
 		Summary("Delete Docker resource").
@@ -762,44 +993,39 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/files").
-		Tags("files").
-		Summary("List directory contents").
-		Description("Returns the contents of a directory within a stack's file system").
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/backup-schedules").
+		Tags("backup-schedules").
+		Summary("List backup schedules").
+		Description("Returns the server's cron backup schedules whose stack pattern the caller can manage. Requires backups.manage permission.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
-		PathParam("stackname", "Stack name").Required().
-		QueryParam("filePath", "Directory path to list").Optional().
-		Response(http.StatusOK, response.Response[files.DirectoryListing]{}, "Directory listing").
-		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusOK, response.Response[backupschedules.ListSchedulesData]{}, "List of backup schedules").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/files/read").
-		Tags("files").
-		Summary("Read file contents").
-		Description("Returns the contents of a file within a stack's file system").
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/backup-schedules/{id}").
+		Tags("backup-schedules").
+		Summary("Get backup schedule").
+		Description("Returns a backup schedule with its next run time and the outcome of its last run. Requires backups.manage permission.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
-		PathParam("stackname", "Stack name").Required().
-		QueryParam("filePath", "File path to read").Required().
-		Response(http.StatusOK, response.Response[files.FileContent]{}, "File contents").
-		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Path parameter is required").
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[backupschedules.GetScheduleData]{}, "Backup schedule details").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/write").
-		Tags("files").
-		Summary("Write file contents").
-		Description("Writes content to a file within a stack's file system").
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/backup-schedules").
+		Tags("backup-schedules").
+		Summary("Create backup schedule").
+		Description("Creates a cron schedule that backs up every stack matching the pattern (default `*`). Requires backups.manage on every stack the pattern can match. Servers without backups enabled are skipped at run time.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
-		PathParam("stackname", "Stack name").Required().
-		Body(files.WriteFileRequest{}, "File write request").
-		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "File written successfully").
+		Body(backupschedules.CreateScheduleRequest{}, "Schedule details").
+		Response(http.StatusCreated, response.Response[backupschedules.GetScheduleData]{}, "Created backup schedule").
 		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
@@ -807,92 +1033,84 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/upload").
-		Tags("files").
-		Summary("Upload a file").
-		Description("Uploads a file to a stack's file system using multipart form data").
+	apiDoc.Document("PUT", "/api/v1/servers/{serverid}/backup-schedules/{id}").
+		Tags("backup-schedules").
+		Summary("Update backup schedule").
+		Description("Replaces a backup schedule's pattern and cron expression, optionally toggling it, and recomputes the next run. Requires backups.manage permission.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
-		PathParam("stackname", "Stack name").Required().
-		BodyMultipart("File upload with optional destination path").
-		FileField("file", true).
-		Field("filePath", false).
-		Done().
-		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "File uploaded successfully").
-		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "File is required").
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Body(backupschedules.UpdateScheduleRequest{}, "Updated schedule details").
+		Response(http.StatusOK, response.Response[backupschedules.GetScheduleData]{}, "Updated backup schedule").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/mkdir").
-		Tags("files").
-		Summary("Create directory").
-		Description("Creates a new directory within a stack's file system").
+	apiDoc.Document("DELETE", "/api/v1/servers/{serverid}/backup-schedules/{id}").
+		Tags("backup-schedules").
+		Summary("Delete backup schedule").
+		Description("Deletes a backup schedule. Existing backups are not affected. Requires backups.manage permission.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
-		PathParam("stackname", "Stack name").Required().
-		Body(files.CreateDirectoryRequest{}, "Directory creation request").
-		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "Directory created successfully").
-		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[backupschedules.DeleteScheduleMessageData]{}, "Schedule deleted successfully").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("DELETE", "/api/v1/servers/{serverid}/stacks/{stackname}/files/delete").
-		Tags("files").
-		Summary("Delete file or directory").
-		Description("Deletes a file or directory from a stack's file system").
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/backup-retention-policies").
+		Tags("backup-retention").
+		Summary("List backup retention policies").
+		Description("Returns the server's backup retention policies whose stack pattern the caller can manage. Requires backups.manage permission.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
-		PathParam("stackname", "Stack name").Required().
-		Body(files.DeleteRequest{}, "Delete request").
-		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "File or directory deleted successfully").
-		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusOK, response.Response[backupretention.ListPoliciesData]{}, "List of retention policies").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/rename").
-		Tags("files").
-		Summary("Rename file or directory").
-		Description("Renames or moves a file or directory within a stack's file system").
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/backup-retention-policies/{id}").
+		Tags("backup-retention").
+		Summary("Get backup retention policy").
+		Description("Returns a retention policy and the outcome of its last run. Requires backups.manage permission.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
-		PathParam("stackname", "Stack name").Required().
-		Body(files.RenameRequest{}, "Rename request").
-		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "File or directory renamed successfully").
-		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		PathParam("id", "Policy ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[backupretention.GetPolicyData]{}, "Retention policy details").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Policy not found").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/copy").
-		Tags("files").
-		Summary("Copy file or directory").
-		Description("Copies a file or directory within a stack's file system").
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/backup-retention-policies/{id}/dry-run").
+		Tags("backup-retention").
+		Summary("Preview backup retention policy").
+		Description("Evaluates the policy against the backups of every stack it governs and returns which runs would be kept and removed. Nothing is deleted. Stacks governed by a more specific policy are omitted.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
-		PathParam("stackname", "Stack name").Required().
-		Body(files.CopyRequest{}, "Copy request").
-		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "File or directory copied successfully").
-		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		PathParam("id", "Policy ID").TypeInt().Required().
+		QueryParam("stack", "Limit the preview to one stack").Optional().
+		Response(http.StatusOK, response.Response[backupretention.DryRunData]{}, "Retention preview").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Policy not found").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/chmod").
-		Tags("files").
-		Summary("Change file permissions").
-		Description("Changes the permissions (mode) of a file or directory").
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/backup-retention-policies").
+		Tags("backup-retention").
+		Summary("Create backup retention policy").
+		Description("Creates a keep-last / daily / weekly / monthly retention policy for stacks matching the pattern (default `*`, the server-wide policy). Requires backups.manage on every stack the pattern can match.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
-		PathParam("stackname", "Stack name").Required().
-		Body(files.ChmodRequest{}, "Chmod request").
-		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "Permissions changed successfully").
+		Body(backupretention.PolicyRequest{}, "Policy details").
+		Response(http.StatusCreated, response.Response[backupretention.GetPolicyData]{}, "Created retention policy").
 		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
@@ -900,123 +1118,714 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/chown").
-		Tags("files").
-		Summary("Change file ownership").
-		Description("Changes the owner and/or group of a file or directory").
+	apiDoc.Document("PUT", "/api/v1/servers/{serverid}/backup-retention-policies/{id}").
+		Tags("backup-retention").
+		Summary("Update backup retention policy").
+		Description("Replaces a retention policy's pattern and keep rules, optionally toggling it. Requires backups.manage permission.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
-		PathParam("stackname", "Stack name").Required().
-		Body(files.ChownRequest{}, "Chown request").
-		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "Ownership changed successfully").
+		PathParam("id", "Policy ID").TypeInt().Required().
+		Body(backupretention.PolicyRequest{}, "Updated policy details").
+		Response(http.StatusOK, response.Response[backupretention.GetPolicyData]{}, "Updated retention policy").
 		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Policy not found").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/files/download").
-		Tags("files").
-		Summary("Download a file").
-		Description("Downloads a file from a stack's file system").
+	apiDoc.Document("DELETE", "/api/v1/servers/{serverid}/backup-retention-policies/{id}").
+		Tags("backup-retention").
+		Summary("Delete backup retention policy").
+		Description("Deletes a retention policy. Backups are no longer pruned for the stacks it governed unless another policy matches. Requires backups.manage permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("id", "Policy ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[backupretention.DeletePolicyMessageData]{}, "Policy deleted successfully").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Policy not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/operation-schedules").
+		Tags("operation-schedules").
+		Summary("List operation schedules").
+		Description("Returns the cron-scheduled compose operations configured for a stack. Requires stacks.read permission.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
 		PathParam("stackname", "Stack name").Required().
-		QueryParam("filePath", "File path to download").Required().
-		QueryParam("filename", "Optional filename for the downloaded file").Optional().
-		ResponseBinary(http.StatusOK, "application/octet-stream", "File content").
-		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Path parameter is required").
+		Response(http.StatusOK, response.Response[operationschedules.ListSchedulesData]{}, "List of operation schedules").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/files/stats").
-		Tags("files").
-		Summary("Get directory statistics").
-		Description("Returns statistics about a directory including most common owner, group, and mode").
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/operation-schedules").
+		Tags("operation-schedules").
+		Summary("Create operation schedule").
+		Description("Schedules a pull, up, down, restart, start or stop operation on a cron expression. The caller becomes the schedule's owner and each run is authorised as the owner. Requires stacks.manage permission.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
 		PathParam("stackname", "Stack name").Required().
-		QueryParam("filePath", "Directory path").Optional().
-		Response(http.StatusOK, response.Response[files.DirectoryStats]{}, "Directory statistics").
+		Body(operationschedules.CreateScheduleRequest{}, "Schedule details").
+		Response(http.StatusCreated, response.Response[operationschedules.GetScheduleData]{}, "Created operation schedule").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/can-create").
-		Tags("stacks").
-		Summary("Check if user can create stacks").
-		Description("Returns whether the authenticated user has permission to create stacks on the server").
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/operation-schedules/{id}/runs").
+		Tags("operation-schedules").
+		Summary("List operation schedule runs").
+		Description("Returns the most recent runs of a schedule, newest first. Started runs link to the operation log holding their output. Requires stacks.read permission.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
-		Response(http.StatusOK, response.Response[stack.CanCreateStackData]{}, "Can create response").
+		PathParam("stackname", "Stack name").Required().
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[operationschedules.ListRunsData]{}, "Schedule run history").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks").
-		Tags("stacks").
-		Summary("Create a new stack").
-		Description("Creates a new stack on the server").
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/operation-schedules/{id}/pause").
+		Tags("operation-schedules").
+		Summary("Pause operation schedule").
+		Description("Stops a schedule from running until it is resumed. Requires stacks.manage permission.").
 		PathParam("serverid", "Server ID").TypeInt().Required().
-		Body(stack.CreateStackRequest{}, "Stack creation request").
-		Response(http.StatusCreated, response.Response[stack.CreateStackData]{}, "Stack created successfully").
-		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		PathParam("stackname", "Stack name").Required().
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[operationschedules.GetScheduleData]{}, "Paused operation schedule").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
-		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Permission denied").
-		Response(http.StatusConflict, response.ErrorResponseBody{}, "Stack already exists").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	// Admin Operation Logs
-	apiDoc.Document("GET", "/api/v1/admin/operation-logs").
-		Tags("admin").
-		Summary("List all operation logs").
-		Description("Returns paginated list of all operation logs. Requires admin permissions.").
-		QueryParam("page", "Page number").TypeInt().Default(1).Min(1).
-		QueryParam("page_size", "Number of items per page").TypeInt().Default(20).Min(1).Max(100).
-		QueryParam("search", "Search term for stack name, command, or operation ID").Optional().
-		QueryParam("server_id", "Filter by server ID").Optional().
-		QueryParam("stack_name", "Filter by stack name (partial match)").Optional().
-		QueryParam("command", "Filter by command").Optional().
-		QueryParam("status", "Filter by status").Enum("complete", "incomplete", "failed", "success").Optional().
-		QueryParam("days_back", "Only return logs from the last N days").TypeInt().Min(1).Optional().
-		Response(http.StatusOK, response.Response[[]operationlogs.OperationLogInfo]{}, "Paginated list of operation logs").
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/operation-schedules/{id}/resume").
+		Tags("operation-schedules").
+		Summary("Resume operation schedule").
+		Description("Re-enables a paused schedule. The next run is computed from now; runs missed while paused are not replayed. Requires stacks.manage permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[operationschedules.GetScheduleData]{}, "Resumed operation schedule").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
-		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Admin access required").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("GET", "/api/v1/admin/operation-logs/stats").
-		Tags("admin").
-		Summary("Get operation logs statistics").
-		Description("Returns aggregated statistics for all operation logs. Requires admin permissions.").
-		Response(http.StatusOK, response.Response[operationlogs.OperationLogStatsData]{}, "Operation logs statistics").
+	apiDoc.Document("DELETE", "/api/v1/servers/{serverid}/stacks/{stackname}/operation-schedules/{id}").
+		Tags("operation-schedules").
+		Summary("Delete operation schedule").
+		Description("Deletes a schedule and its run history. Operation logs of past runs are kept. Requires stacks.manage permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[operationschedules.DeleteScheduleMessageData]{}, "Schedule deleted successfully").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
-		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Admin access required").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	apiDoc.Document("GET", "/api/v1/admin/operation-logs/{id}").
-		Tags("admin").
-		Summary("Get operation log details").
-		Description("Returns detailed information about a specific operation log including all messages. Requires admin permissions.").
-		PathParam("id", "Operation log ID").TypeInt().Required().
-		Response(http.StatusOK, response.Response[operationlogs.OperationLogDetailData]{}, "Operation log details with messages").
-		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid operation log ID").
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/scan-schedules").
+		Tags("scan-schedules").
+		Summary("List scan schedules").
+		Description("Returns the server's vulnerability scan schedules whose stack pattern the caller can manage. Requires stacks.manage permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[scanschedules.ListSchedulesData]{}, "List of scan schedules").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
-		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Admin access required").
-		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Operation log not found").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
-	// Admin Security Audit Logs
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/scan-schedules/{id}").
+		Tags("scan-schedules").
+		Summary("Get scan schedule").
+		Description("Returns a scan schedule and the outcome of its last cron run. Requires stacks.manage permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[scanschedules.GetScheduleData]{}, "Scan schedule details").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/scan-schedules").
+		Tags("scan-schedules").
+		Summary("Create scan schedule").
+		Description("Creates a schedule that scans stacks matching the pattern on a cron expression, when their running image digest changes, and/or after a successful up. Requires stacks.manage on every stack the pattern can match.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		Body(scanschedules.ScheduleRequest{}, "Schedule details").
+		Response(http.StatusCreated, response.Response[scanschedules.GetScheduleData]{}, "Created scan schedule").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("PUT", "/api/v1/servers/{serverid}/scan-schedules/{id}").
+		Tags("scan-schedules").
+		Summary("Update scan schedule").
+		Description("Replaces a scan schedule's pattern and triggers, optionally toggling it. Requires stacks.manage permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Body(scanschedules.ScheduleRequest{}, "Schedule details").
+		Response(http.StatusOK, response.Response[scanschedules.GetScheduleData]{}, "Updated scan schedule").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("DELETE", "/api/v1/servers/{serverid}/scan-schedules/{id}").
+		Tags("scan-schedules").
+		Summary("Delete scan schedule").
+		Description("Deletes a scan schedule. Existing scans are kept. Requires stacks.manage permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[scanschedules.DeleteScheduleMessageData]{}, "Schedule deleted successfully").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/scan-schedules").
+		Tags("scan-schedules").
+		Summary("List fleet-wide scan schedules").
+		Description("Returns scan schedules that apply to every server. Requires admin.servers.read permission.").
+		Response(http.StatusOK, response.Response[scanschedules.ListSchedulesData]{}, "List of fleet-wide scan schedules").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/scan-schedules/{id}").
+		Tags("scan-schedules").
+		Summary("Get fleet-wide scan schedule").
+		Description("Returns a fleet-wide scan schedule. Requires admin.servers.read permission.").
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[scanschedules.GetScheduleData]{}, "Scan schedule details").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/admin/scan-schedules").
+		Tags("scan-schedules").
+		Summary("Create fleet-wide scan schedule").
+		Description("Creates a scan schedule that applies to matching stacks on every active server. Requires admin.servers.write permission.").
+		Body(scanschedules.ScheduleRequest{}, "Schedule details").
+		Response(http.StatusCreated, response.Response[scanschedules.GetScheduleData]{}, "Created scan schedule").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("PUT", "/api/v1/admin/scan-schedules/{id}").
+		Tags("scan-schedules").
+		Summary("Update fleet-wide scan schedule").
+		Description("Replaces a fleet-wide scan schedule's pattern and triggers. Requires admin.servers.write permission.").
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Body(scanschedules.ScheduleRequest{}, "Schedule details").
+		Response(http.StatusOK, response.Response[scanschedules.GetScheduleData]{}, "Updated scan schedule").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("DELETE", "/api/v1/admin/scan-schedules/{id}").
+		Tags("scan-schedules").
+		Summary("Delete fleet-wide scan schedule").
+		Description("Deletes a fleet-wide scan schedule. Requires admin.servers.write permission.").
+		PathParam("id", "Schedule ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[scanschedules.DeleteScheduleMessageData]{}, "Schedule deleted successfully").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Schedule not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/vulnerability-alerts").
+		Tags("vulnerability-alerts").
+		Summary("List stack vulnerability alerts").
+		Description("Returns the alerts raised for the stack's scans, newest first. Requires stacks.read permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		QueryParam("acknowledged", "Filter by acknowledgement (true or false)").Optional().
+		QueryParam("page", "Page number (default 1)").TypeInt().Optional().
+		QueryParam("page_size", "Alerts per page (default 25, max 100)").TypeInt().Optional().
+		Response(http.StatusOK, response.Response[vulnalerts.ListAlertsData]{}, "List of vulnerability alerts").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/vulnerability-alerts/{id}/acknowledge").
+		Tags("vulnerability-alerts").
+		Summary("Acknowledge stack vulnerability alert").
+		Description("Marks an alert raised for the stack as acknowledged, with an optional note. Requires stacks.manage permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		PathParam("id", "Alert ID").TypeInt().Required().
+		Body(vulnalerts.AcknowledgeRequest{}, "Acknowledgement note").
+		Response(http.StatusOK, response.Response[vulnalerts.GetAlertData]{}, "Acknowledged alert").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Alert not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/vulnerability-alert-rules").
+		Tags("vulnerability-alerts").
+		Summary("List vulnerability alert rules").
+		Description("Returns the rules evaluated when a vulnerability scan completes. Requires admin.servers.read permission.").
+		Response(http.StatusOK, response.Response[vulnalerts.ListRulesData]{}, "List of alert rules").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/vulnerability-alert-rules/{id}").
+		Tags("vulnerability-alerts").
+		Summary("Get vulnerability alert rule").
+		Description("Returns a vulnerability alert rule. Requires admin.servers.read permission.").
+		PathParam("id", "Rule ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[vulnalerts.GetRuleData]{}, "Alert rule details").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Rule not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/admin/vulnerability-alert-rules").
+		Tags("vulnerability-alerts").
+		Summary("Create vulnerability alert rule").
+		Description("Creates a rule that raises an alert when a completed scan of a matching stack has any, or newly introduced, vulnerabilities at or above a severity. Alerts are emailed and/or POSTed to a webhook URL. Requires admin.servers.write permission.").
+		Body(vulnalerts.RuleRequest{}, "Rule details").
+		Response(http.StatusCreated, response.Response[vulnalerts.GetRuleData]{}, "Created alert rule").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("PUT", "/api/v1/admin/vulnerability-alert-rules/{id}").
+		Tags("vulnerability-alerts").
+		Summary("Update vulnerability alert rule").
+		Description("Replaces a vulnerability alert rule. Requires admin.servers.write permission.").
+		PathParam("id", "Rule ID").TypeInt().Required().
+		Body(vulnalerts.RuleRequest{}, "Rule details").
+		Response(http.StatusOK, response.Response[vulnalerts.GetRuleData]{}, "Updated alert rule").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Rule not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("DELETE", "/api/v1/admin/vulnerability-alert-rules/{id}").
+		Tags("vulnerability-alerts").
+		Summary("Delete vulnerability alert rule").
+		Description("Deletes a vulnerability alert rule. Alerts it already raised are kept. Requires admin.servers.write permission.").
+		PathParam("id", "Rule ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[vulnalerts.DeleteRuleMessageData]{}, "Rule deleted successfully").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Rule not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/vulnerability-alerts").
+		Tags("vulnerability-alerts").
+		Summary("List vulnerability alerts").
+		Description("Returns alerts raised across all servers, newest first. Requires admin.servers.read permission.").
+		QueryParam("server_id", "Filter by server ID").TypeInt().Optional().
+		QueryParam("stack_name", "Filter by stack name").Optional().
+		QueryParam("acknowledged", "Filter by acknowledgement (true or false)").Optional().
+		QueryParam("page", "Page number (default 1)").TypeInt().Optional().
+		QueryParam("page_size", "Alerts per page (default 25, max 100)").TypeInt().Optional().
+		Response(http.StatusOK, response.Response[vulnalerts.ListAlertsData]{}, "List of vulnerability alerts").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/admin/vulnerability-alerts/{id}/acknowledge").
+		Tags("vulnerability-alerts").
+		Summary("Acknowledge vulnerability alert").
+		Description("Marks an alert as acknowledged, with an optional note. Requires admin.servers.write permission.").
+		PathParam("id", "Alert ID").TypeInt().Required().
+		Body(vulnalerts.AcknowledgeRequest{}, "Acknowledgement note").
+		Response(http.StatusOK, response.Response[vulnalerts.GetAlertData]{}, "Acknowledged alert").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Alert not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/update-policies").
+		Tags("update-policies").
+		Summary("List image update policies").
+		Description("Returns the stack's image update policies. A policy without a service name is the stack default; stacks without a policy are notify-only. Requires stacks.read permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		Response(http.StatusOK, response.Response[autoupdates.ListPoliciesData]{}, "List of update policies").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("PUT", "/api/v1/servers/{serverid}/stacks/{stackname}/update-policies").
+		Tags("update-policies").
+		Summary("Set image update policy").
+		Description("Creates or replaces the policy for the stack, or for one service when service_name is set. Modes are notify, auto, and window (auto only while a cron-defined window is open). Requires stacks.manage permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		Body(autoupdates.SetPolicyRequest{}, "Policy details").
+		Response(http.StatusOK, response.Response[autoupdates.GetPolicyData]{}, "Saved update policy").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("DELETE", "/api/v1/servers/{serverid}/stacks/{stackname}/update-policies/{id}").
+		Tags("update-policies").
+		Summary("Delete image update policy").
+		Description("Deletes an image update policy. Requires stacks.manage permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		PathParam("id", "Policy ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[autoupdates.DeletePolicyMessageData]{}, "Policy deleted successfully").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Policy not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/update-history").
+		Tags("update-policies").
+		Summary("List automatic image update history").
+		Description("Returns the 100 most recent automatic updates for the stack, each linking the old digest, the new digest and the operation logs of the pull and up. Requires stacks.read permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		Response(http.StatusOK, response.Response[autoupdates.ListHistoryData]{}, "Update history").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/maintenance-status").
+		Tags("maintenance-windows").
+		Summary("Get stack maintenance status").
+		Description("Reports whether up, down, restart and restore-backup may run on the stack right now, and when its next maintenance window opens. Requires stacks.read permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		Response(http.StatusOK, response.Response[maintwindows.StatusData]{}, "Maintenance status").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/files").
+		Tags("files").
+		Summary("List directory contents").
+		Description("Returns the contents of a directory within a stack's file system").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		QueryParam("filePath", "Directory path to list").Optional().
+		Response(http.StatusOK, response.Response[files.DirectoryListing]{}, "Directory listing").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/files/read").
+		Tags("files").
+		Summary("Read file contents").
+		Description("Returns the contents of a file within a stack's file system").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		QueryParam("filePath", "File path to read").Required().
+		Response(http.StatusOK, response.Response[files.FileContent]{}, "File contents").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Path parameter is required").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/write").
+		Tags("files").
+		Summary("Write file contents").
+		Description("Writes content to a file within a stack's file system").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		Body(files.WriteFileRequest{}, "File write request").
+		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "File written successfully").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/upload").
+		Tags("files").
+		Summary("Upload a file").
+		Description("Uploads a file to a stack's file system using multipart form data").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		BodyMultipart("File upload with optional destination path").
+		FileField("file", true).
+		Field("filePath", false).
+		Done().
+		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "File uploaded successfully").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "File is required").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/mkdir").
+		Tags("files").
+		Summary("Create directory").
+		Description("Creates a new directory within a stack's file system").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		Body(files.CreateDirectoryRequest{}, "Directory creation request").
+		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "Directory created successfully").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("DELETE", "/api/v1/servers/{serverid}/stacks/{stackname}/files/delete").
+		Tags("files").
+		Summary("Delete file or directory").
+		Description("Deletes a file or directory from a stack's file system").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		Body(files.DeleteRequest{}, "Delete request").
+		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "File or directory deleted successfully").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/rename").
+		Tags("files").
+		Summary("Rename file or directory").
+		Description("Renames or moves a file or directory within a stack's file system").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		Body(files.RenameRequest{}, "Rename request").
+		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "File or directory renamed successfully").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/copy").
+		Tags("files").
+		Summary("Copy file or directory").
+		Description("Copies a file or directory within a stack's file system").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		Body(files.CopyRequest{}, "Copy request").
+		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "File or directory copied successfully").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/chmod").
+		Tags("files").
+		Summary("Change file permissions").
+		Description("Changes the permissions (mode) of a file or directory").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		Body(files.ChmodRequest{}, "Chmod request").
+		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "Permissions changed successfully").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks/{stackname}/files/chown").
+		Tags("files").
+		Summary("Change file ownership").
+		Description("Changes the owner and/or group of a file or directory").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		Body(files.ChownRequest{}, "Chown request").
+		Response(http.StatusOK, response.Response[files.FileMessageData]{}, "Ownership changed successfully").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/files/download").
+		Tags("files").
+		Summary("Download a file").
+		Description("Downloads a file from a stack's file system").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		QueryParam("filePath", "File path to download").Required().
+		QueryParam("filename", "Optional filename for the downloaded file").Optional().
+		ResponseBinary(http.StatusOK, "application/octet-stream", "File content").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Path parameter is required").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/{stackname}/files/stats").
+		Tags("files").
+		Summary("Get directory statistics").
+		Description("Returns statistics about a directory including most common owner, group, and mode").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("stackname", "Stack name").Required().
+		QueryParam("filePath", "Directory path").Optional().
+		Response(http.StatusOK, response.Response[files.DirectoryStats]{}, "Directory statistics").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/servers/{serverid}/stacks/can-create").
+		Tags("stacks").
+		Summary("Check if user can create stacks").
+		Description("Returns whether the authenticated user has permission to create stacks on the server").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[stack.CanCreateStackData]{}, "Can create response").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/stacks").
+		Tags("stacks").
+		Summary("Create a new stack").
+		Description("Creates a new stack on the server").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		Body(stack.CreateStackRequest{}, "Stack creation request").
+		Response(http.StatusCreated, response.Response[stack.CreateStackData]{}, "Stack created successfully").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Permission denied").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Stack already exists").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	// Admin Operation Logs
+	apiDoc.Document("GET", "/api/v1/admin/operation-logs").
+		Tags("admin").
+		Summary("List all operation logs").
+		Description("Returns paginated list of all operation logs. Requires admin permissions.").
+		QueryParam("page", "Page number").TypeInt().Default(1).Min(1).
+		QueryParam("page_size", "Number of items per page").TypeInt().Default(20).Min(1).Max(100).
+		QueryParam("search", "Search term for stack name, command, or operation ID").Optional().
+		QueryParam("server_id", "Filter by server ID").Optional().
+		QueryParam("stack_name", "Filter by stack name (partial match)").Optional().
+		QueryParam("command", "Filter by command").Optional().
+		QueryParam("status", "Filter by status").Enum("complete", "incomplete", "failed", "success").Optional().
+		QueryParam("days_back", "Only return logs from the last N days").TypeInt().Min(1).Optional().
+		Response(http.StatusOK, response.Response[[]operationlogs.OperationLogInfo]{}, "Paginated list of operation logs").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Admin access required").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/operation-logs/stats").
+		Tags("admin").
+		Summary("Get operation logs statistics").
+		Description("Returns aggregated statistics for all operation logs. Requires admin permissions.").
+		Response(http.StatusOK, response.Response[operationlogs.OperationLogStatsData]{}, "Operation logs statistics").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Admin access required").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/operation-logs/{id}").
+		Tags("admin").
+		Summary("Get operation log details").
+		Description("Returns detailed information about a specific operation log including all messages. Requires admin permissions.").
+		PathParam("id", "Operation log ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[operationlogs.OperationLogDetailData]{}, "Operation log details with messages").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid operation log ID").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Admin access required").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Operation log not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	// Admin Security Audit Logs
 	apiDoc.Document("GET", "/api/v1/admin/security-audit-logs").
 		Tags("admin").
 		Summary("List security audit logs").
@@ -1120,8 +1929,8 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 	apiDoc.Document("POST", "/api/v1/admin/users/assign-role").
 		Tags("admin").
 		Summary("Assign a role to a user").
-		Description("Assigns a role to a user. Requires admin permissions.").
-		Body(rbac.AssignRoleRequest{}, "User and role IDs").
+		Description("Assigns a role to a user, optionally until expires_at. Expired assignments are removed automatically and, with revoke_sessions, the user's sessions are revoked. Requires admin permissions.").
+		Body(rbac.AssignRoleRequest{}, "User and role IDs with optional expiry").
 		Response(http.StatusOK, response.Response[rbac.MessageData]{}, "Role assigned successfully").
 		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
@@ -1144,6 +1953,105 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
+	apiDoc.Document("GET", "/api/v1/admin/users/two-factor/non-compliant").
+		Tags("admin").
+		Summary("List users missing a required second factor").
+		Description("Lists users holding a role that requires two-factor authentication who have neither TOTP enabled nor a registered passkey (passkeys only count while WebAuthn is enabled). Each entry lists the roles imposing the requirement. Requires admin.users.read permission.").
+		Response(http.StatusOK, response.Response[rbac.ListTwoFactorNonCompliantData]{}, "Non-compliant users").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/leader/leases").
+		Tags("admin").
+		Summary("List background worker leases").
+		Description("Lists the database leases that decide which instance runs each background worker, with the holder and expiry of each. `self` marks leases held by the instance serving the request. Requires admin.system.read permission.").
+		Response(http.StatusOK, response.Response[leader.StatusData]{}, "Lease status").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/lockouts").
+		Tags("admin").
+		Summary("List account lockouts").
+		Description("Lists accounts that are locked after repeated failed password or TOTP attempts, followed by accounts with recent failed attempts. Requires admin.users.read permission.").
+		Response(http.StatusOK, response.Response[lockout.ListLockoutsData]{}, "Locked and failing accounts").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("DELETE", "/api/v1/admin/lockouts/{userid}").
+		Tags("admin").
+		Summary("Clear an account lockout").
+		Description("Unlocks the account and resets its failed attempt count and lockout backoff. Requires admin.users.write permission.").
+		PathParam("userid", "User ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[lockout.ClearLockoutData]{}, "Lockout cleared").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "User not found or not locked").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/invitations").
+		Tags("admin").
+		Summary("List invitations").
+		Description("Lists invitations that have been neither accepted nor revoked, newest first. Expired invitations are included so they can be resent. Requires admin.users.read permission.").
+		Response(http.StatusOK, response.Response[invitations.ListInvitationsData]{}, "Open invitations").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/admin/invitations").
+		Tags("admin").
+		Summary("Invite a user").
+		Description("Emails a signed, expiring link that lets the recipient create an account with the given roles. Fails if the address already has an account or an unexpired invitation. Requires admin.users.write permission.").
+		Body(invitations.CreateInvitationRequest{}, "Email address and roles to grant").
+		Response(http.StatusCreated, response.Response[invitations.InvitationInfo]{}, "Invitation sent").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request or unknown role").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Email address already has an account or a pending invitation").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/admin/invitations/{id}/resend").
+		Tags("admin").
+		Summary("Resend an invitation").
+		Description("Emails a new link with a fresh expiry. Links sent earlier stop working. Requires admin.users.write permission.").
+		PathParam("id", "Invitation ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[invitations.InvitationInfo]{}, "Invitation resent").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Invitation not found").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Invitation already accepted or revoked").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("DELETE", "/api/v1/admin/invitations/{id}").
+		Tags("admin").
+		Summary("Revoke an invitation").
+		Description("Cancels an open invitation so its link can no longer be used. Requires admin.users.write permission.").
+		PathParam("id", "Invitation ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[invitations.RevokeInvitationData]{}, "Invitation revoked").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Invitation not found").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Invitation already accepted or revoked").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
 	// Admin Role Management
 	apiDoc.Document("GET", "/api/v1/admin/roles").
 		Tags("admin").
@@ -1183,6 +2091,21 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
+	apiDoc.Document("PUT", "/api/v1/admin/roles/{id}/two-factor").
+		Tags("admin").
+		Summary("Set a role's two-factor requirement").
+		Description("Requires or stops requiring two-factor authentication for members of the role, including admin roles. Members without a second factor are asked to enroll at their next password login; existing sessions are unaffected. Requires admin.roles.write permission.").
+		PathParam("id", "Role ID").TypeInt().Required().
+		Body(rbac.SetRoleTwoFactorRequest{}, "Whether a second factor is required").
+		Response(http.StatusOK, response.Response[user.RoleWithPermissions]{}, "Role updated successfully").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Admin access required").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Role not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
 	apiDoc.Document("DELETE", "/api/v1/admin/roles/{id}").
 		Tags("admin").
 		Summary("Delete a role").
@@ -1330,6 +2253,166 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
+	apiDoc.Document("GET", "/api/v1/admin/servers/{serverid}/maintenance-windows").
+		Tags("maintenance-windows").
+		Summary("List maintenance windows").
+		Description("Returns the server's recurring maintenance windows and change freezes. Requires admin.servers.read permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[maintwindows.ListWindowsData]{}, "List of maintenance windows").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/admin/servers/{serverid}/maintenance-windows").
+		Tags("maintenance-windows").
+		Summary("Create maintenance window").
+		Description("Creates a recurring window (cron_expression and duration_minutes) or a freeze (starts_at and ends_at) for stacks matching stack_pattern. Matching stacks refuse up, down, restart and restore-backup outside their windows unless the user holds stacks.maintenance.override. Requires admin.servers.write permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		Body(maintwindows.WindowRequest{}, "Window details").
+		Response(http.StatusCreated, response.Response[maintwindows.GetWindowData]{}, "Maintenance window created").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("PUT", "/api/v1/admin/servers/{serverid}/maintenance-windows/{id}").
+		Tags("maintenance-windows").
+		Summary("Update maintenance window").
+		Description("Replaces a maintenance window's settings. Requires admin.servers.write permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("id", "Window ID").TypeInt().Required().
+		Body(maintwindows.WindowRequest{}, "Window details").
+		Response(http.StatusOK, response.Response[maintwindows.GetWindowData]{}, "Maintenance window updated").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Maintenance window not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("DELETE", "/api/v1/admin/servers/{serverid}/maintenance-windows/{id}").
+		Tags("maintenance-windows").
+		Summary("Delete maintenance window").
+		Description("Deletes a maintenance window. Requires admin.servers.write permission.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		PathParam("id", "Window ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[maintwindows.DeleteWindowMessageData]{}, "Maintenance window deleted").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Maintenance window not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	// Admin Webhooks
+	apiDoc.Document("GET", "/api/v1/admin/webhooks/event-types").
+		Tags("webhooks").
+		Summary("List webhook event types").
+		Description("Returns the event types a webhook endpoint can subscribe to. Requires admin.webhooks.read permission.").
+		Response(http.StatusOK, response.Response[webhooks.ListEventTypesData]{}, "Supported event types").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/webhooks").
+		Tags("webhooks").
+		Summary("List webhook endpoints").
+		Description("Returns all webhook endpoints. Signing secrets are never returned. Requires admin.webhooks.read permission.").
+		Response(http.StatusOK, response.Response[webhooks.ListEndpointsData]{}, "List of webhook endpoints").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/admin/webhooks").
+		Tags("webhooks").
+		Summary("Create webhook endpoint").
+		Description("Registers an endpoint for the chosen event types. If secret is omitted one is generated; the response is the only place the secret is returned. Requires admin.webhooks.write permission.").
+		Body(webhooks.EndpointRequest{}, "Endpoint details").
+		Response(http.StatusCreated, response.Response[webhooks.CreateEndpointData]{}, "Webhook endpoint created").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/webhooks/{id}").
+		Tags("webhooks").
+		Summary("Get webhook endpoint").
+		Description("Returns a webhook endpoint. Requires admin.webhooks.read permission.").
+		PathParam("id", "Endpoint ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[webhooks.GetEndpointData]{}, "Webhook endpoint").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Webhook endpoint not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("PUT", "/api/v1/admin/webhooks/{id}").
+		Tags("webhooks").
+		Summary("Update webhook endpoint").
+		Description("Replaces an endpoint's settings. A non-empty secret rotates the signing secret; an empty one keeps it. Requires admin.webhooks.write permission.").
+		PathParam("id", "Endpoint ID").TypeInt().Required().
+		Body(webhooks.EndpointRequest{}, "Endpoint details").
+		Response(http.StatusOK, response.Response[webhooks.GetEndpointData]{}, "Webhook endpoint updated").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Webhook endpoint not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("DELETE", "/api/v1/admin/webhooks/{id}").
+		Tags("webhooks").
+		Summary("Delete webhook endpoint").
+		Description("Deletes an endpoint and its delivery log. Requires admin.webhooks.write permission.").
+		PathParam("id", "Endpoint ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[webhooks.DeleteEndpointMessageData]{}, "Webhook endpoint deleted").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Webhook endpoint not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/admin/webhooks/{id}/deliveries").
+		Tags("webhooks").
+		Summary("List webhook deliveries").
+		Description("Returns the endpoint's delivery log, newest first, including attempts, the last response status and any pending retry. Requires admin.webhooks.read permission.").
+		PathParam("id", "Endpoint ID").TypeInt().Required().
+		QueryParam("page", "Page number").TypeInt().Default(1).Optional().
+		QueryParam("page_size", "Deliveries per page (max 100)").TypeInt().Default(25).Optional().
+		Response(http.StatusOK, response.Response[webhooks.ListDeliveriesData]{}, "Delivery log").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Webhook endpoint not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/admin/webhooks/{id}/test").
+		Tags("webhooks").
+		Summary("Send test event").
+		Description("Sends a webhook.test event to the endpoint immediately, whether or not it is enabled, and returns the resulting delivery. A failed test is retried like any other delivery. Requires admin.webhooks.write permission.").
+		PathParam("id", "Endpoint ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[webhooks.TestDeliveryData]{}, "Test delivery").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Webhook endpoint not found").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
 	// Admin Migration
 	apiDoc.Document("POST", "/api/v1/admin/migration/export").
 		Tags("admin").
@@ -1424,6 +2507,87 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
+	// Access Requests
+	apiDoc.Document("POST", "/api/v1/servers/{serverid}/access-requests").
+		Tags("access-requests").
+		Summary("Request temporary stack access").
+		Description("Asks for a stack permission on stacks matching stack_pattern for duration_minutes, which may not exceed AUTH_ACCESS_REQUEST_MAX_DURATION. The requester must already hold stacks.read on the stacks; stacks.read and stacks.access.approve cannot be requested.").
+		PathParam("serverid", "Server ID").TypeInt().Required().
+		Body(accessrequests.CreateRequest{}, "Request details").
+		Response(http.StatusCreated, response.Response[accessrequests.GetRequestData]{}, "Access request created").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Stacks not readable").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Permission already held or request already pending").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/access-requests").
+		Tags("access-requests").
+		Summary("List my access requests").
+		Description("Returns the authenticated user's access requests, newest first.").
+		Response(http.StatusOK, response.Response[accessrequests.ListRequestsData]{}, "List of access requests").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/access-requests/{id}/cancel").
+		Tags("access-requests").
+		Summary("Cancel access request").
+		Description("Withdraws one of the authenticated user's pending requests, or ends an approved grant early.").
+		PathParam("id", "Access request ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[accessrequests.GetRequestData]{}, "Access request cancelled").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Access request not found").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Request no longer pending or active").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/access-requests/pending").
+		Tags("access-requests").
+		Summary("List pending access requests").
+		Description("Returns the pending requests the authenticated user may decide, oldest first: those of other users for stacks on which the user holds stacks.access.approve.").
+		Response(http.StatusOK, response.Response[accessrequests.ListRequestsData]{}, "List of pending access requests").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/access-requests/{id}/approve").
+		Tags("access-requests").
+		Summary("Approve access request").
+		Description("Grants the requested permission for duration_minutes from now. Requires stacks.access.approve on the requested stacks; requesters cannot approve their own requests.").
+		PathParam("id", "Access request ID").TypeInt().Required().
+		Body(accessrequests.DecisionRequest{}, "Optional decision note").
+		Response(http.StatusOK, response.Response[accessrequests.GetRequestData]{}, "Access request approved").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Own request").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Access request not found").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Request no longer pending").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/access-requests/{id}/deny").
+		Tags("access-requests").
+		Summary("Deny access request").
+		Description("Denies a pending request. Requires stacks.access.approve on the requested stacks; requesters cannot deny their own requests.").
+		PathParam("id", "Access request ID").TypeInt().Required().
+		Body(accessrequests.DecisionRequest{}, "Optional decision note").
+		Response(http.StatusOK, response.Response[accessrequests.GetRequestData]{}, "Access request denied").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Own request").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Access request not found").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Request no longer pending").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
 	// API Keys
 	apiDoc.Document("GET", "/api/v1/api-keys").
 		Tags("api-keys").
@@ -1508,11 +2672,25 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Security("bearerAuth", "session").
 		Build()
 
+	apiDoc.Document("PUT", "/api/v1/admin/api-keys/{id}/rate-limit").
+		Tags("admin").
+		Summary("Set API key rate limit").
+		Description("Sets how many requests any user's API key may make per RATE_LIMIT_API_KEY_PERIOD, replacing RATE_LIMIT_API_KEY_RATE for that key. A null rate_limit restores the default. Requires admin.users.write permission.").
+		PathParam("id", "API key ID").TypeInt().Required().
+		Body(apikey.SetRateLimitRequest{}, "Rate limit").
+		Response(http.StatusOK, response.Response[apikey.APIKeyInfo]{}, "Rate limit updated").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusForbidden, response.ErrorResponseBody{}, "Insufficient permissions").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "API key not found").
+		Security("bearerAuth", "apiKey", "session").
+		Build()
+
 	// TOTP Management
 	apiDoc.Document("GET", "/api/v1/totp/status").
 		Tags("totp").
 		Summary("Get TOTP status").
-		Description("Returns whether two-factor authentication is enabled for the authenticated user.").
+		Description("Returns whether two-factor authentication is enabled for the authenticated user and how many unused recovery codes remain.").
 		Response(http.StatusOK, response.Response[auth.TOTPStatusData]{}, "TOTP status").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
@@ -1533,9 +2711,9 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 	apiDoc.Document("POST", "/api/v1/totp/enable").
 		Tags("totp").
 		Summary("Enable TOTP").
-		Description("Enables two-factor authentication after verifying the TOTP code from the authenticator app.").
+		Description("Enables two-factor authentication after verifying the TOTP code from the authenticator app. The response contains ten single-use recovery codes that can stand in for a TOTP code; they are shown only once.").
 		Body(auth.TOTPEnableRequest{}, "TOTP verification code").
-		Response(http.StatusOK, response.Response[auth.TOTPMessageData]{}, "TOTP enabled successfully").
+		Response(http.StatusOK, response.Response[auth.TOTPEnableData]{}, "TOTP enabled successfully, with recovery codes").
 		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid TOTP code").
 		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
 		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
@@ -1545,7 +2723,7 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 	apiDoc.Document("POST", "/api/v1/totp/disable").
 		Tags("totp").
 		Summary("Disable TOTP").
-		Description("Disables two-factor authentication. Requires both the current TOTP code and password for security.").
+		Description("Disables two-factor authentication. Requires the password and either the current TOTP code or an unused recovery code.").
 		Body(auth.TOTPDisableRequest{}, "TOTP code and password").
 		Response(http.StatusOK, response.Response[auth.TOTPMessageData]{}, "TOTP disabled successfully").
 		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
@@ -1554,6 +2732,82 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Security("bearerAuth", "session").
 		Build()
 
+	apiDoc.Document("POST", "/api/v1/totp/recovery-codes").
+		Tags("totp").
+		Summary("Regenerate TOTP recovery codes").
+		Description("Replaces the user's recovery codes with a new set of ten and invalidates the old ones. Requires the password and either the current TOTP code or an unused recovery code. The new codes are shown only once.").
+		Body(auth.TOTPRecoveryCodesRequest{}, "TOTP or recovery code and password").
+		Response(http.StatusOK, response.Response[auth.TOTPRecoveryCodesData]{}, "New recovery codes").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request, or TOTP is not enabled").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Invalid code or password").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
+	// Passkeys
+	apiDoc.Document("GET", "/api/v1/passkeys").
+		Tags("passkeys").
+		Summary("List passkeys").
+		Description("Lists the WebAuthn passkeys registered to the authenticated user.").
+		Response(http.StatusOK, response.Response[auth.PasskeyListData]{}, "Registered passkeys").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Passkeys are not enabled").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/passkeys/register/begin").
+		Tags("passkeys").
+		Summary("Start passkey registration").
+		Description("Returns WebAuthn creation options to be passed to navigator.credentials.create(). Passkeys already registered to the user are excluded. The session_id must be sent back with the authenticator response.").
+		Response(http.StatusOK, response.Response[auth.PasskeyRegistrationOptionsData]{}, "Creation options and ceremony session ID").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Passkeys are not enabled").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/passkeys/register/finish").
+		Tags("passkeys").
+		Summary("Complete passkey registration").
+		Description("Verifies the authenticator's attestation response and stores the passkey under the supplied name. Once registered, the passkey can be used as a second factor and for passwordless sign-in.").
+		Body(auth.PasskeyRegisterRequest{}, "Ceremony session ID, passkey name and authenticator response").
+		Response(http.StatusCreated, response.Response[auth.PasskeyData]{}, "Passkey registered").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request, invalid or expired challenge, or verification failed").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Passkeys are not enabled").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Passkey already registered").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
+	apiDoc.Document("PUT", "/api/v1/passkeys/{id}").
+		Tags("passkeys").
+		Summary("Rename a passkey").
+		Description("Changes the display name of one of the authenticated user's passkeys.").
+		PathParam("id", "Passkey ID").TypeInt().Required().
+		Body(auth.PasskeyRenameRequest{}, "New passkey name").
+		Response(http.StatusOK, response.Response[auth.PasskeyData]{}, "Passkey renamed").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Passkey not found, or passkeys are not enabled").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
+	apiDoc.Document("DELETE", "/api/v1/passkeys/{id}").
+		Tags("passkeys").
+		Summary("Remove a passkey").
+		Description("Revokes one of the authenticated user's passkeys. It can no longer be used to sign in.").
+		PathParam("id", "Passkey ID").TypeInt().Required().
+		Response(http.StatusOK, response.Response[auth.PasskeyMessageData]{}, "Passkey removed").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid passkey ID").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusNotFound, response.ErrorResponseBody{}, "Passkey not found, or passkeys are not enabled").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
 	// Profile
 	apiDoc.Document("GET", "/api/v1/profile").
 		Tags("profile").
@@ -1568,6 +2822,64 @@ func RegisterAPIDocs(apiDoc *apidocs.OpenAPI) {
 		Security("bearerAuth", "apiKey", "session").
 		Build()
 
+	apiDoc.Document("POST", "/api/v1/profile/password").
+		Tags("profile").
+		Summary("Change password").
+		Description("Changes the authenticated user's password after checking the current one. The new password must meet the password policy. All other sessions are signed out and the account address is notified by email.").
+		Body(auth.ProfileChangePasswordRequest{}, "Current and new password").
+		Response(http.StatusOK, response.Response[auth.AuthMessageData]{}, "Password changed").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request, wrong current password, unchanged or weak password").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
+	apiDoc.Document("POST", "/api/v1/profile/email").
+		Tags("profile").
+		Summary("Request an email change").
+		Description("Sends a verification link to the new address after checking the current password. The email is only changed once the link is confirmed with /auth/email-change/confirm, at which point the previous address is notified.").
+		Body(auth.ProfileChangeEmailRequest{}, "New email and current password").
+		Response(http.StatusOK, response.Response[auth.AuthMessageData]{}, "Verification link sent").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid request, wrong current password or unchanged email").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusConflict, response.ErrorResponseBody{}, "Email address is already in use").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Mail service unavailable or internal error").
+		Security("bearerAuth", "session").
+		Build()
+
+	// Image Update Digests
+	apiDoc.Document("GET", "/api/v1/profile/update-digest").
+		Tags("profile").
+		Summary("Get image update digest settings").
+		Description("Returns the authenticated user's image update digest email settings. Users who have never subscribed get the disabled defaults.").
+		Response(http.StatusOK, response.Response[updatedigests.GetSubscriptionData]{}, "Digest settings").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
+	apiDoc.Document("PUT", "/api/v1/profile/update-digest").
+		Tags("profile").
+		Summary("Update image update digest settings").
+		Description("Subscribes to, reschedules or turns off the daily or weekly email listing available image updates on the stacks the user can read. Send times are in UTC.").
+		Body(updatedigests.SubscriptionRequest{}, "Digest settings").
+		Response(http.StatusOK, response.Response[updatedigests.GetSubscriptionData]{}, "Digest settings saved").
+		Response(http.StatusBadRequest, response.ErrorResponseBody{}, "Invalid settings").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
+	apiDoc.Document("GET", "/api/v1/profile/update-digest/preview").
+		Tags("profile").
+		Summary("Preview image update digest").
+		Description("Returns the updates the next digest would list right now, grouped by server and stack.").
+		Response(http.StatusOK, response.Response[updatedigests.PreviewDigestData]{}, "Digest preview").
+		Response(http.StatusUnauthorized, response.ErrorResponseBody{}, "Not authenticated").
+		Response(http.StatusInternalServerError, response.ErrorResponseBody{}, "Internal server error").
+		Security("bearerAuth", "session").
+		Build()
+
 	// Sessions Management
 	apiDoc.Document("POST", "/api/v1/sessions/revoke").
 		Tags("sessions").
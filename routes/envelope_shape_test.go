@@ -12,6 +12,7 @@ import (
 var envelopeExempt = map[string]bool{
 	"GET /api/v1/servers/{serverid}/stacks/{stackname}/files/download": true,
 	"POST /api/v1/admin/migration/export":                              true,
+	"GET /.well-known/jwks.json":                                       true,
 }
 
 var legacyEnvelopeAllowlist = map[string]bool{}
@@ -1,6 +1,7 @@
 package seeds
 
 import (
+	"berth/internal/domain/accessrequests"
 	"berth/internal/domain/apikey"
 	"berth/internal/domain/rbac"
 	"berth/internal/domain/rbac/permnames"
@@ -12,9 +13,10 @@ import (
 
 func RBACModels() []any {
 	return []any{
-		&user.User{}, &user.Role{}, &user.Permission{}, &user.ServerRoleStackPermission{},
+		&user.User{}, &user.Role{}, &user.UserRole{}, &user.Permission{}, &user.ServerRoleStackPermission{},
 		&server.Server{},
 		&apikey.APIKey{}, &apikey.APIKeyScope{},
+		&accessrequests.AccessRequest{},
 		&SeedTracker{},
 	}
 }
@@ -34,6 +36,8 @@ func SeedRBACData(db *gorm.DB) error {
 		{Name: permnames.BackupsRead, Resource: "backups", Action: "read", Description: "View stack backups. Browsing and downloading backup contents (including volume data) requires this permission plus files.read", IsAPIKeyOnly: false},
 		{Name: permnames.BackupsManage, Resource: "backups", Action: "manage", Description: "Create and delete stack backups (reads all stack data including volumes)", IsAPIKeyOnly: false},
 		{Name: permnames.BackupsRestore, Resource: "backups", Action: "restore", Description: "Restore stack backups, overwriting current stack data", IsAPIKeyOnly: false},
+		{Name: permnames.StacksMaintenanceOverride, Resource: "stacks", Action: "maintenance.override", Description: "Run up, down, restart and restore-backup outside maintenance windows", IsAPIKeyOnly: false},
+		{Name: permnames.StacksAccessApprove, Resource: "stacks", Action: "access.approve", Description: "Approve or deny other users' temporary access requests for matching stacks", IsAPIKeyOnly: false},
 
 		// admin permissions for API key scope enforcement
 		{Name: permnames.AdminUsersRead, Resource: "admin.users", Action: "read", Description: "View users and their roles", IsAPIKeyOnly: true},
@@ -45,8 +49,11 @@ func SeedRBACData(db *gorm.DB) error {
 		{Name: permnames.AdminServersWrite, Resource: "admin.servers", Action: "write", Description: "Create/modify/delete servers", IsAPIKeyOnly: true},
 		{Name: permnames.AdminLogsRead, Resource: "admin.logs", Action: "read", Description: "View all operation logs", IsAPIKeyOnly: true},
 		{Name: permnames.AdminAuditRead, Resource: "admin.audit", Action: "read", Description: "View security audit logs", IsAPIKeyOnly: true},
+		{Name: permnames.AdminSystemRead, Resource: "admin.system", Action: "read", Description: "View instance and background worker status", IsAPIKeyOnly: true},
 		{Name: permnames.AdminSystemExport, Resource: "admin.system", Action: "export", Description: "Export system configuration", IsAPIKeyOnly: true},
 		{Name: permnames.AdminSystemImport, Resource: "admin.system", Action: "import", Description: "Import system configuration", IsAPIKeyOnly: true},
+		{Name: permnames.AdminWebhooksRead, Resource: "admin.webhooks", Action: "read", Description: "View webhook endpoints and deliveries", IsAPIKeyOnly: true},
+		{Name: permnames.AdminWebhooksWrite, Resource: "admin.webhooks", Action: "write", Description: "Create/modify/delete and test webhook endpoints", IsAPIKeyOnly: true},
 
 		// user-level permissions for API key scope enforcement
 		{Name: permnames.ServersRead, Resource: "servers", Action: "read", Description: "View accessible servers", IsAPIKeyOnly: true},